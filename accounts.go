@@ -30,6 +30,40 @@ import (
 	"unsafe"
 )
 
+// StateCacheConfig describes how much of the accounts tree is kept resident in
+// memory, and how much of it is prefetched from storage. Any field left at zero
+// falls back to its default.
+type StateCacheConfig struct {
+	Nodes         int   // Maximum number of tree nodes loaded from storage to keep in memory.
+	MemoryBudget  int64 // Maximum approximate bytes taken up by committed tree nodes in memory. Unbounded if zero.
+	PrefetchDepth int   // Levels of the subtree of every loaded node to prefetch. Disabled if negative.
+}
+
+func DefaultStateCacheConfig() StateCacheConfig {
+	return StateCacheConfig{
+		Nodes:         1 << 18,
+		PrefetchDepth: -1,
+	}
+}
+
+func (c StateCacheConfig) withDefaults() StateCacheConfig {
+	defaults := DefaultStateCacheConfig()
+
+	if c.Nodes <= 0 {
+		c.Nodes = defaults.Nodes
+	}
+
+	if c.PrefetchDepth == 0 {
+		c.PrefetchDepth = defaults.PrefetchDepth
+	}
+
+	return c
+}
+
+// accountsCacheCapacity is the maximum number of accounts whose balance and
+// stake are cached in between two commits.
+const accountsCacheCapacity = 4096
+
 type Accounts struct {
 	sync.RWMutex
 
@@ -37,10 +71,54 @@ type Accounts struct {
 	tree *avl.Tree
 
 	profile *avl.GCProfile
+
+	cache accountsCache
+
+	bloom     *accountBloom
+	bloomView uint64 // View ID of the tree as of when the bloom filter was last updated.
+}
+
+// accountsCache caches the balances and stakes of accounts as of the latest
+// commit. It is invalidated on every commit, with generation incremented such
+// that reads that started before a commit do not populate the cache.
+type accountsCache struct {
+	sync.Mutex
+
+	generation uint64
+	entries    map[AccountID]*cachedAccount
+}
+
+type cachedAccount struct {
+	balance, stake             uint64
+	hasBalance, hasStake       bool
+	loadedBalance, loadedStake bool
 }
 
 func NewAccounts(kv store.KV) *Accounts {
-	return &Accounts{kv: kv, tree: avl.New(kv)}
+	accounts := &Accounts{kv: kv, tree: avl.New(kv), cache: accountsCache{entries: make(map[AccountID]*cachedAccount)}}
+	accounts.rebuildBloom(0)
+
+	return accounts
+}
+
+// configure bounds the number of nodes of the accounts tree, and the memory they
+// take up, resident in memory across all snapshots of the tree, and has them be
+// prefetched from storage, as described by config.
+func (a *Accounts) configure(config StateCacheConfig) {
+	config = config.withDefaults()
+
+	a.Lock()
+	defer a.Unlock()
+
+	a.tree.WithNodeBudget(config.Nodes)
+
+	if config.MemoryBudget > 0 {
+		a.tree.WithMemoryBudget(config.MemoryBudget)
+	}
+
+	if config.PrefetchDepth > 0 {
+		a.tree.WithPrefetchDepth(config.PrefetchDepth)
+	}
 }
 
 // GC periodically garbage collects every 5 seconds. Only one
@@ -84,9 +162,185 @@ func (a *Accounts) Commit(new *avl.Tree) error {
 		return errors.Wrap(err, "accounts: failed to write")
 	}
 
+	a.updateBloom(new == nil)
+
+	a.cache.Lock()
+	a.cache.generation++
+	a.cache.entries = make(map[AccountID]*cachedAccount)
+	a.cache.Unlock()
+
 	profile := a.tree.GetGCProfile(0)
 	if profile != nil {
 		atomic.StorePointer((*unsafe.Pointer)(unsafe.Pointer(&a.profile)), unsafe.Pointer(profile))
 	}
 	return nil
 }
+
+// MayExist returns false should an account definitely not exist as of the
+// latest commit, without having to look it up in the accounts tree.
+func (a *Accounts) MayExist(id AccountID) bool {
+	a.RLock()
+	defer a.RUnlock()
+
+	return a.bloom.mayContain(id)
+}
+
+// ReadBalance returns the balance of an account as of the latest commit. Reads
+// are served from a cache which is invalidated on every commit.
+func (a *Accounts) ReadBalance(id AccountID) (uint64, bool) {
+	if !a.MayExist(id) {
+		return 0, false
+	}
+
+	if entry, ok := a.cached(id); ok && entry.loadedBalance {
+		return entry.balance, entry.hasBalance
+	}
+
+	snapshot, generation := a.snapshotAt()
+
+	balance, exists := ReadAccountBalance(snapshot, id)
+
+	a.store(generation, id, func(entry *cachedAccount) {
+		entry.balance, entry.hasBalance, entry.loadedBalance = balance, exists, true
+	})
+
+	return balance, exists
+}
+
+// ReadStake returns the stake of an account as of the latest commit. Reads are
+// served from a cache which is invalidated on every commit.
+func (a *Accounts) ReadStake(id AccountID) (uint64, bool) {
+	if !a.MayExist(id) {
+		return 0, false
+	}
+
+	if entry, ok := a.cached(id); ok && entry.loadedStake {
+		return entry.stake, entry.hasStake
+	}
+
+	snapshot, generation := a.snapshotAt()
+
+	stake, exists := ReadAccountStake(snapshot, id)
+
+	a.store(generation, id, func(entry *cachedAccount) {
+		entry.stake, entry.hasStake, entry.loadedStake = stake, exists, true
+	})
+
+	return stake, exists
+}
+
+func (a *Accounts) cached(id AccountID) (cachedAccount, bool) {
+	a.cache.Lock()
+	defer a.cache.Unlock()
+
+	entry, exists := a.cache.entries[id]
+	if !exists {
+		return cachedAccount{}, false
+	}
+
+	return *entry, true
+}
+
+// snapshotAt returns a snapshot of the latest committed state, alongside the
+// generation of the cache as of the snapshot.
+func (a *Accounts) snapshotAt() (*avl.Tree, uint64) {
+	a.RLock()
+	defer a.RUnlock()
+
+	a.cache.Lock()
+	generation := a.cache.generation
+	a.cache.Unlock()
+
+	return a.tree.Snapshot(), generation
+}
+
+func (a *Accounts) store(generation uint64, id AccountID, fn func(entry *cachedAccount)) {
+	a.cache.Lock()
+	defer a.cache.Unlock()
+
+	if a.cache.generation != generation {
+		return
+	}
+
+	entry, exists := a.cache.entries[id]
+
+	if !exists {
+		if len(a.cache.entries) >= accountsCacheCapacity {
+			return
+		}
+
+		entry = new(cachedAccount)
+		a.cache.entries[id] = entry
+	}
+
+	fn(entry)
+}
+
+// updateBloom adds the accounts modified since the bloom filter was last
+// updated to it. The bloom filter is instead rebuilt from scratch should the
+// accounts tree have been modified in place, should the modifications not be
+// distinguishable by view, or should the bloom filter have grown too full.
+func (a *Accounts) updateBloom(inPlace bool) {
+	view := a.tree.ViewID()
+
+	if inPlace || view <= a.bloomView {
+		a.rebuildBloom(a.bloom.count)
+		return
+	}
+
+	full := false
+
+	a.tree.IterateLeafDiff(a.bloomView, func(key, value []byte) bool {
+		if id, ok := accountIDFromKey(key); ok && !a.bloom.add(id) {
+			full = true
+			return false
+		}
+
+		return true
+	})
+
+	if full {
+		a.rebuildBloom(2 * a.bloom.capacity)
+		return
+	}
+
+	a.bloomView = view
+}
+
+// rebuildBloom builds a bloom filter over every account in the accounts tree,
+// sized for at least capacity accounts.
+func (a *Accounts) rebuildBloom(capacity int) {
+	var ids []AccountID
+
+	a.tree.IteratePrefix(keyAccounts[:], func(key, value []byte) {
+		if id, ok := accountIDFromKey(key); ok {
+			ids = append(ids, id)
+		}
+	})
+
+	if capacity < 2*len(ids) {
+		capacity = 2 * len(ids)
+	}
+
+	a.bloom = newAccountBloom(capacity)
+
+	for _, id := range ids {
+		a.bloom.add(id)
+	}
+
+	a.bloomView = a.tree.ViewID()
+}
+
+// accountIDFromKey returns the ID of the account a key in the accounts tree
+// is stored under.
+func accountIDFromKey(key []byte) (AccountID, bool) {
+	var id AccountID
+
+	if len(key) != len(keyAccounts)+1+SizeAccountID || key[0] != keyAccounts[0] {
+		return id, false
+	}
+
+	copy(id[:], key[len(keyAccounts)+1:])
+
+	return id, true
+}
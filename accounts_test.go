@@ -49,3 +49,132 @@ func TestSmartContract(t *testing.T) {
 
 	assert.NoError(t, quick.Check(fn, nil))
 }
+
+func TestAccountsReadCache(t *testing.T) {
+	accounts := NewAccounts(store.NewInmem())
+
+	var id AccountID
+	id[0] = 1
+
+	balance, exists := accounts.ReadBalance(id)
+	assert.False(t, exists)
+	assert.Zero(t, balance)
+
+	// Writes are not visible until they are committed.
+
+	tree := accounts.Snapshot()
+	WriteAccountBalance(tree, id, 100)
+	WriteAccountStake(tree, id, 50)
+
+	_, exists = accounts.ReadBalance(id)
+	assert.False(t, exists)
+
+	// Committing invalidates the cache.
+
+	assert.NoError(t, accounts.Commit(tree))
+
+	balance, exists = accounts.ReadBalance(id)
+	assert.True(t, exists)
+	assert.EqualValues(t, 100, balance)
+
+	stake, exists := accounts.ReadStake(id)
+	assert.True(t, exists)
+	assert.EqualValues(t, 50, stake)
+
+	tree = accounts.Snapshot()
+	WriteAccountBalance(tree, id, 200)
+	assert.NoError(t, accounts.Commit(tree))
+
+	balance, _ = accounts.ReadBalance(id)
+	assert.EqualValues(t, 200, balance)
+
+	stake, _ = accounts.ReadStake(id)
+	assert.EqualValues(t, 50, stake)
+}
+
+func TestAccountsReadCacheSkipsStaleReads(t *testing.T) {
+	accounts := NewAccounts(store.NewInmem())
+
+	var id AccountID
+	id[0] = 1
+
+	snapshot, generation := accounts.snapshotAt()
+
+	tree := accounts.Snapshot()
+	WriteAccountBalance(tree, id, 100)
+	assert.NoError(t, accounts.Commit(tree))
+
+	// A read that started before the commit must not populate the cache.
+
+	balance, exists := ReadAccountBalance(snapshot, id)
+	accounts.store(generation, id, func(entry *cachedAccount) {
+		entry.balance, entry.hasBalance, entry.loadedBalance = balance, exists, true
+	})
+
+	balance, exists = accounts.ReadBalance(id)
+	assert.True(t, exists)
+	assert.EqualValues(t, 100, balance)
+}
+
+func TestAccountsBloom(t *testing.T) {
+	accounts := NewAccounts(store.NewInmem())
+
+	var a, b AccountID
+	a[0], b[0] = 1, 2
+
+	assert.False(t, accounts.MayExist(a))
+	assert.False(t, accounts.MayExist(b))
+
+	// Accounts modified in a view are added to the bloom filter incrementally.
+
+	tree := accounts.Snapshot()
+	tree.SetViewID(1)
+	WriteAccountBalance(tree, a, 100)
+	assert.NoError(t, accounts.Commit(tree))
+
+	assert.True(t, accounts.MayExist(a))
+	assert.False(t, accounts.MayExist(b))
+
+	balance, exists := accounts.ReadBalance(a)
+	assert.True(t, exists)
+	assert.EqualValues(t, 100, balance)
+
+	tree = accounts.Snapshot()
+	tree.SetViewID(2)
+	WriteAccountStake(tree, b, 50)
+	assert.NoError(t, accounts.Commit(tree))
+
+	assert.True(t, accounts.MayExist(a))
+	assert.True(t, accounts.MayExist(b))
+
+	stake, exists := accounts.ReadStake(b)
+	assert.True(t, exists)
+	assert.EqualValues(t, 50, stake)
+
+	// The bloom filter is rebuilt should accounts be loaded from storage.
+
+	reloaded := &Accounts{kv: accounts.kv, tree: accounts.tree, cache: accountsCache{entries: make(map[AccountID]*cachedAccount)}}
+	reloaded.rebuildBloom(0)
+
+	assert.True(t, reloaded.MayExist(a))
+	assert.True(t, reloaded.MayExist(b))
+}
+
+func TestAccountBloomCapacity(t *testing.T) {
+	bloom := &accountBloom{bits: make([]uint64, 4), capacity: 2}
+
+	var id AccountID
+
+	for i := 1; i <= 2; i++ {
+		id[0], id[8] = byte(i), byte(i)
+		assert.True(t, bloom.add(id))
+		assert.True(t, bloom.mayContain(id))
+	}
+
+	// Adding an account that was already added does not count towards capacity.
+
+	assert.True(t, bloom.add(id))
+
+	id[0], id[8] = 3, 3
+	assert.False(t, bloom.add(id), "filter must ask to be rebuilt once it is full")
+}
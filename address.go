@@ -0,0 +1,72 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"encoding/hex"
+	"strings"
+
+	"github.com/perlin-network/wavelet/internal/bech32"
+	"github.com/perlin-network/wavelet/sys"
+	"github.com/pkg/errors"
+)
+
+// FormatAddress encodes an account ID as a checksummed bech32 address under the
+// human-readable part of the network, such that typos in addresses are caught
+// before funds are sent to them.
+func FormatAddress(id AccountID) string {
+	address, err := bech32.Encode(sys.AddressHRP, id[:])
+	if err != nil {
+		panic(err)
+	}
+
+	return address
+}
+
+// ParseAccountID parses an account ID presented either as hex, or as a bech32
+// address under the human-readable part of the network.
+func ParseAccountID(s string) (AccountID, error) {
+	var id AccountID
+
+	buf, err := hex.DecodeString(s)
+
+	if err != nil {
+		hrp, decoded, bech32Err := bech32.Decode(s)
+
+		switch {
+		case strings.HasPrefix(strings.ToLower(s), sys.AddressHRP+"1") && bech32Err != nil:
+			return id, errors.Wrap(bech32Err, "account address is invalid")
+		case bech32Err != nil:
+			return id, errors.Wrapf(err, "account ID must be presented as valid hex, or as a bech32 address prefixed with %q", sys.AddressHRP+"1")
+		case hrp != sys.AddressHRP:
+			return id, errors.Errorf("account address is meant for the network %q, but this is the network %q", hrp, sys.AddressHRP)
+		}
+
+		buf = decoded
+	}
+
+	if len(buf) != SizeAccountID {
+		return id, ErrInvalidAccountIDSize
+	}
+
+	copy(id[:], buf)
+
+	return id, nil
+}
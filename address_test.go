@@ -0,0 +1,55 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/perlin-network/wavelet/internal/bech32"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddress(t *testing.T) {
+	id := AccountID{1, 2, 3}
+
+	address := FormatAddress(id)
+	assert.Equal(t, "twav1qypqxqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqchqnk2", address)
+
+	parsed, err := ParseAccountID(address)
+	assert.NoError(t, err)
+	assert.Equal(t, id, parsed)
+
+	parsed, err = ParseAccountID(hex.EncodeToString(id[:]))
+	assert.NoError(t, err)
+	assert.Equal(t, id, parsed)
+
+	_, err = ParseAccountID(address[:len(address)-1] + "q")
+	assert.Error(t, err, "addresses with typos must be rejected")
+
+	other, err := bech32.Encode("wav", id[:])
+	assert.NoError(t, err)
+
+	_, err = ParseAccountID(other)
+	assert.Error(t, err, "addresses of other networks must be rejected")
+
+	_, err = ParseAccountID(hex.EncodeToString(id[:31]))
+	assert.Equal(t, ErrInvalidAccountIDSize, err)
+}
@@ -0,0 +1,336 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/perlin-network/wavelet/log"
+	"github.com/rs/zerolog"
+	"github.com/valyala/fastjson"
+)
+
+const (
+	AlertRoundStalled = "round_stalled"
+	AlertLowPeers     = "low_peers"
+	AlertSyncFailing  = "sync_failing"
+	AlertFork         = "fork"
+	AlertClockSkew    = "clock_skew"
+
+	AlertConsensusStalled = "consensus_stalled"
+)
+
+// AlertConfig describes the liveness rules an Alerter checks against the
+// ledger. Any rule whose threshold is left at zero is disabled.
+type AlertConfig struct {
+	// Fire if no consensus round has been finalized for this long.
+	RoundTimeout time.Duration
+
+	// Fire if we are connected to fewer than this many peers.
+	MinPeers int
+
+	// Fire if syncing to the latest round failed this many times in a row.
+	MaxSyncFailures int
+
+	// Fire if the median clock of our peers is ahead or behind ours by at least this much.
+	MaxClockSkew time.Duration
+
+	// URL which alerts are POST'ed to as JSON. Optional.
+	Webhook string
+}
+
+// Alert is a single liveness violation, or the resolution of one.
+type Alert struct {
+	Rule     string
+	Resolved bool
+
+	Value     int64
+	Threshold int64
+
+	Time time.Time
+}
+
+// Alerter checks a set of liveness rules against the ledger, and fires alerts
+// as log events, metrics and optionally webhook calls whenever a rule starts
+// or stops being violated.
+type Alerter struct {
+	sync.Mutex
+
+	config  AlertConfig
+	metrics *Metrics
+	client  *http.Client
+	logs    *log.Scope
+
+	lastRound    time.Time
+	syncFailures int
+
+	firing map[string]struct{}
+}
+
+func NewAlerter(config AlertConfig, metrics *Metrics) *Alerter {
+	return &Alerter{
+		config:  config,
+		metrics: metrics,
+		client:  &http.Client{Timeout: 5 * time.Second},
+		logs:    log.NewScope(""),
+
+		lastRound: time.Now(),
+
+		firing: make(map[string]struct{}),
+	}
+}
+
+// Enabled returns true if at least one rule is being checked.
+func (a *Alerter) Enabled() bool {
+	return a.config.RoundTimeout > 0 || a.config.MinPeers > 0 || a.config.MaxSyncFailures > 0 || a.config.MaxClockSkew > 0
+}
+
+// Run periodically checks all time and peer-based rules until ctx is cancelled.
+// numPeers is called on every check to retrieve our current number of peers.
+func (a *Alerter) Run(ctx context.Context, numPeers func() int) {
+	if !a.Enabled() {
+		return
+	}
+
+	ticker := time.NewTicker(1 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			a.Check(now, numPeers())
+		}
+	}
+}
+
+// Check evaluates the round stall and peer count rules at the given time.
+func (a *Alerter) Check(now time.Time, peers int) {
+	a.Lock()
+	defer a.Unlock()
+
+	if a.config.RoundTimeout > 0 {
+		elapsed := now.Sub(a.lastRound)
+
+		a.update(AlertRoundStalled, elapsed >= a.config.RoundTimeout, int64(elapsed.Seconds()), int64(a.config.RoundTimeout.Seconds()), now)
+	}
+
+	if a.config.MinPeers > 0 {
+		a.update(AlertLowPeers, peers < a.config.MinPeers, int64(peers), int64(a.config.MinPeers), now)
+	}
+}
+
+// RoundFinalized marks that the ledger has just advanced to a new round.
+func (a *Alerter) RoundFinalized() {
+	a.Lock()
+	defer a.Unlock()
+
+	a.lastRound = time.Now()
+
+	if a.config.RoundTimeout > 0 {
+		a.update(AlertRoundStalled, false, 0, int64(a.config.RoundTimeout.Seconds()), a.lastRound)
+	}
+}
+
+// SyncFailed marks that an attempt to sync to the latest round has failed.
+func (a *Alerter) SyncFailed() {
+	a.Lock()
+	defer a.Unlock()
+
+	a.syncFailures++
+
+	if a.config.MaxSyncFailures > 0 {
+		a.update(AlertSyncFailing, a.syncFailures >= a.config.MaxSyncFailures, int64(a.syncFailures), int64(a.config.MaxSyncFailures), time.Now())
+	}
+}
+
+// SyncSucceeded marks that the ledger has successfully synced to the latest round.
+func (a *Alerter) SyncSucceeded() {
+	a.Lock()
+	defer a.Unlock()
+
+	a.syncFailures = 0
+
+	if a.config.MaxSyncFailures > 0 {
+		a.update(AlertSyncFailing, false, 0, int64(a.config.MaxSyncFailures), time.Now())
+	}
+}
+
+// ClockSkewed marks how far ahead the median clock of our peers is of ours.
+func (a *Alerter) ClockSkewed(skew time.Duration) {
+	a.Lock()
+	defer a.Unlock()
+
+	if a.config.MaxClockSkew <= 0 {
+		return
+	}
+
+	if skew < 0 {
+		skew = -skew
+	}
+
+	a.update(AlertClockSkew, skew >= a.config.MaxClockSkew, int64(skew/time.Millisecond), int64(a.config.MaxClockSkew/time.Millisecond), time.Now())
+}
+
+// Forked marks that two different rounds have been finalized under the same view
+// ID. Unlike all other rules, this rule is always checked, and only resolves once
+// an operator resumes the ledger.
+func (a *Alerter) Forked(viewID uint64) {
+	a.Lock()
+	defer a.Unlock()
+
+	a.update(AlertFork, true, int64(viewID), 0, time.Now())
+}
+
+// ConsensusStalled marks whether the ledger has neither finalized a round nor
+// noticed it is out of sync for elapsed, past the timeout of its watchdog.
+// Unlike most rules, this rule is checked whenever the watchdog is enabled.
+func (a *Alerter) ConsensusStalled(stalled bool, elapsed, timeout time.Duration) {
+	a.Lock()
+	defer a.Unlock()
+
+	a.update(AlertConsensusStalled, stalled, int64(elapsed.Seconds()), int64(timeout.Seconds()), time.Now())
+}
+
+// ForkResolved marks that an operator has resumed the ledger after a fork.
+func (a *Alerter) ForkResolved() {
+	a.Lock()
+	defer a.Unlock()
+
+	a.update(AlertFork, false, 0, 0, time.Now())
+}
+
+// Firing returns the names of all rules that are currently being violated.
+func (a *Alerter) Firing() []string {
+	a.Lock()
+	defer a.Unlock()
+
+	rules := make([]string, 0, len(a.firing))
+
+	for rule := range a.firing {
+		rules = append(rules, rule)
+	}
+
+	return rules
+}
+
+// update only fires an alert whenever a rule transitions between being
+// violated and being resolved, so that operators are not flooded.
+func (a *Alerter) update(rule string, violated bool, value, threshold int64, now time.Time) {
+	_, firing := a.firing[rule]
+
+	if violated == firing {
+		return
+	}
+
+	if violated {
+		a.firing[rule] = struct{}{}
+	} else {
+		delete(a.firing, rule)
+	}
+
+	a.fire(Alert{Rule: rule, Resolved: !violated, Value: value, Threshold: threshold, Time: now})
+}
+
+func (a *Alerter) fire(alert Alert) {
+	var logger zerolog.Logger
+
+	switch alert.Rule {
+	case AlertRoundStalled, AlertFork, AlertConsensusStalled:
+		logger = a.logs.Consensus("alert")
+	case AlertLowPeers, AlertClockSkew:
+		logger = a.logs.Network("alert")
+	case AlertSyncFailing:
+		logger = a.logs.Sync("alert")
+	}
+
+	event := logger.Warn()
+	msg := "Liveness rule has been violated."
+
+	if alert.Resolved {
+		event = logger.Info()
+		msg = "Liveness rule is no longer being violated."
+	}
+
+	if alert.Rule == AlertFork {
+		if alert.Resolved {
+			msg = "Ledger has been resumed after a fork."
+		} else {
+			event = logger.Error()
+			msg = "Safety rule has been violated; ledger has been halted."
+		}
+	}
+
+	if alert.Rule == AlertConsensusStalled {
+		if alert.Resolved {
+			msg = "Consensus has recovered from stalling."
+		} else {
+			event = logger.Error()
+			msg = "Consensus has stalled; attempting to recover."
+		}
+	}
+
+	event.
+		Str("rule", alert.Rule).
+		Bool("resolved", alert.Resolved).
+		Int64("value", alert.Value).
+		Int64("threshold", alert.Threshold).
+		Msg(msg)
+
+	if a.metrics != nil && !alert.Resolved {
+		a.metrics.alerts.Mark(1)
+	}
+
+	if len(a.config.Webhook) > 0 {
+		go a.post(alert)
+	}
+}
+
+func (a *Alerter) post(alert Alert) {
+	var arena fastjson.Arena
+
+	o := arena.NewObject()
+	o.Set("rule", arena.NewString(alert.Rule))
+
+	if alert.Resolved {
+		o.Set("resolved", arena.NewTrue())
+	} else {
+		o.Set("resolved", arena.NewFalse())
+	}
+
+	o.Set("value", arena.NewNumberString(strconv.FormatInt(alert.Value, 10)))
+	o.Set("threshold", arena.NewNumberString(strconv.FormatInt(alert.Threshold, 10)))
+	o.Set("time", arena.NewString(alert.Time.Format(time.RFC3339)))
+
+	res, err := a.client.Post(a.config.Webhook, "application/json", bytes.NewReader(o.MarshalTo(nil)))
+	if err != nil {
+		logger := a.logs.Node()
+		logger.Warn().Err(err).Str("webhook", a.config.Webhook).Msg("Failed to deliver alert to webhook.")
+		return
+	}
+
+	_ = res.Body.Close()
+}
@@ -0,0 +1,107 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fastjson"
+)
+
+func TestAlerterRoundStalled(t *testing.T) {
+	t.Parallel()
+
+	alerts := NewAlerter(AlertConfig{RoundTimeout: 10 * time.Second}, nil)
+
+	now := time.Now()
+
+	alerts.Check(now, 0)
+	assert.Empty(t, alerts.Firing())
+
+	alerts.Check(now.Add(11*time.Second), 0)
+	assert.Equal(t, []string{AlertRoundStalled}, alerts.Firing())
+
+	alerts.RoundFinalized()
+	assert.Empty(t, alerts.Firing())
+}
+
+func TestAlerterLowPeers(t *testing.T) {
+	t.Parallel()
+
+	alerts := NewAlerter(AlertConfig{MinPeers: 3}, nil)
+
+	alerts.Check(time.Now(), 2)
+	assert.Equal(t, []string{AlertLowPeers}, alerts.Firing())
+
+	alerts.Check(time.Now(), 3)
+	assert.Empty(t, alerts.Firing())
+}
+
+func TestAlerterClockSkew(t *testing.T) {
+	t.Parallel()
+
+	alerts := NewAlerter(AlertConfig{MaxClockSkew: 5 * time.Second}, nil)
+
+	alerts.ClockSkewed(-6 * time.Second)
+	assert.Equal(t, []string{AlertClockSkew}, alerts.Firing())
+
+	alerts.ClockSkewed(1 * time.Second)
+	assert.Empty(t, alerts.Firing())
+}
+
+func TestAlerterSyncFailuresWebhook(t *testing.T) {
+	t.Parallel()
+
+	received := make(chan []byte, 2)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		received <- body
+	}))
+	defer server.Close()
+
+	alerts := NewAlerter(AlertConfig{MaxSyncFailures: 2, Webhook: server.URL}, nil)
+
+	alerts.SyncFailed()
+	assert.Empty(t, alerts.Firing())
+
+	alerts.SyncFailed()
+	assert.Equal(t, []string{AlertSyncFailing}, alerts.Firing())
+
+	select {
+	case body := <-received:
+		v, err := fastjson.ParseBytes(body)
+		assert.NoError(t, err)
+
+		assert.Equal(t, AlertSyncFailing, string(v.GetStringBytes("rule")))
+		assert.False(t, v.GetBool("resolved"))
+		assert.Equal(t, 2, v.GetInt("value"))
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook never received alert")
+	}
+
+	alerts.SyncSucceeded()
+	assert.Empty(t, alerts.Firing())
+}
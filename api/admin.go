@@ -0,0 +1,201 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package api
+
+import (
+	"crypto/subtle"
+	"encoding/hex"
+
+	"github.com/perlin-network/wavelet"
+	"github.com/pkg/errors"
+	"github.com/valyala/fasthttp"
+	"github.com/valyala/fastjson"
+)
+
+// WithAdminToken enables the admin endpoints of the gateway, which allow for the
+// peers of the node to be managed at runtime. Requests to admin endpoints must
+// carry token as a bearer token in their Authorization header.
+func WithAdminToken(token string) GatewayOption {
+	return func(g *Gateway) {
+		g.adminToken = token
+	}
+}
+
+func (g *Gateway) adminScope(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+	expected := []byte("Bearer " + g.adminToken)
+
+	return fasthttp.RequestHandler(func(ctx *fasthttp.RequestCtx) {
+		if subtle.ConstantTimeCompare(ctx.Request.Header.Peek("Authorization"), expected) != 1 {
+			g.renderError(ctx, ErrUnauthorized(errors.New("a valid admin token must be provided as a bearer token")))
+			return
+		}
+
+		next(ctx)
+	})
+}
+
+type peerAddressRequest struct {
+	address string
+}
+
+func (s *peerAddressRequest) bind(parser *fastjson.Parser, body []byte) error {
+	v, err := parser.ParseBytes(body)
+	if err != nil {
+		return errors.Wrap(err, "invalid json")
+	}
+
+	address := string(v.GetStringBytes("address"))
+	if len(address) == 0 {
+		return errors.New("missing address")
+	}
+
+	s.address = address
+
+	return nil
+}
+
+type peerPublicKeyRequest struct {
+	publicKey wavelet.AccountID
+}
+
+func (s *peerPublicKeyRequest) bind(parser *fastjson.Parser, body []byte) error {
+	v, err := parser.ParseBytes(body)
+	if err != nil {
+		return errors.Wrap(err, "invalid json")
+	}
+
+	buf, err := hex.DecodeString(string(v.GetStringBytes("public_key")))
+	if err != nil {
+		return errors.Wrap(err, "public key provided is not hex-formatted")
+	}
+
+	if len(buf) != wavelet.SizeAccountID {
+		return errors.Errorf("public key must be size %d", wavelet.SizeAccountID)
+	}
+
+	copy(s.publicKey[:], buf)
+
+	return nil
+}
+
+type adminResponse struct {
+	message string
+}
+
+func (s *adminResponse) marshalJSON(arena *fastjson.Arena) ([]byte, error) {
+	o := arena.NewObject()
+
+	o.Set("message", arena.NewString(s.message))
+
+	return o.MarshalTo(nil), nil
+}
+
+type bannedPeerList []wavelet.AccountID
+
+func (s bannedPeerList) marshalJSON(arena *fastjson.Arena) ([]byte, error) {
+	list := arena.NewArray()
+
+	for i, id := range s {
+		list.SetArrayItem(i, arena.NewString(hex.EncodeToString(id[:])))
+	}
+
+	return list.MarshalTo(nil), nil
+}
+
+func (g *Gateway) connectPeer(ctx *fasthttp.RequestCtx) {
+	req := new(peerAddressRequest)
+
+	parser := g.parserPool.Get()
+	err := req.bind(parser, ctx.PostBody())
+	g.parserPool.Put(parser)
+
+	if err != nil {
+		g.renderError(ctx, ErrBadRequest(err))
+		return
+	}
+
+	if err := g.ledger.ConnectPeer(req.address); err != nil {
+		g.renderError(ctx, ErrBadRequest(err))
+		return
+	}
+
+	g.render(ctx, &adminResponse{message: "Connected to peer at " + req.address + "."})
+}
+
+func (g *Gateway) disconnectPeer(ctx *fasthttp.RequestCtx) {
+	req := new(peerAddressRequest)
+
+	parser := g.parserPool.Get()
+	err := req.bind(parser, ctx.PostBody())
+	g.parserPool.Put(parser)
+
+	if err != nil {
+		g.renderError(ctx, ErrBadRequest(err))
+		return
+	}
+
+	if err := g.ledger.DisconnectPeer(req.address); err != nil {
+		g.renderError(ctx, ErrBadRequest(err))
+		return
+	}
+
+	g.render(ctx, &adminResponse{message: "Disconnected from peer at " + req.address + "."})
+}
+
+func (g *Gateway) banPeer(ctx *fasthttp.RequestCtx) {
+	req := new(peerPublicKeyRequest)
+
+	parser := g.parserPool.Get()
+	err := req.bind(parser, ctx.PostBody())
+	g.parserPool.Put(parser)
+
+	if err != nil {
+		g.renderError(ctx, ErrBadRequest(err))
+		return
+	}
+
+	g.ledger.BanPeer(req.publicKey)
+
+	g.render(ctx, &adminResponse{message: "Banned peer " + hex.EncodeToString(req.publicKey[:]) + "."})
+}
+
+func (g *Gateway) unbanPeer(ctx *fasthttp.RequestCtx) {
+	req := new(peerPublicKeyRequest)
+
+	parser := g.parserPool.Get()
+	err := req.bind(parser, ctx.PostBody())
+	g.parserPool.Put(parser)
+
+	if err != nil {
+		g.renderError(ctx, ErrBadRequest(err))
+		return
+	}
+
+	if !g.ledger.UnbanPeer(req.publicKey) {
+		g.renderError(ctx, ErrNotFound(errors.Errorf("peer %x is not banned", req.publicKey)))
+		return
+	}
+
+	g.render(ctx, &adminResponse{message: "Unbanned peer " + hex.EncodeToString(req.publicKey[:]) + "."})
+}
+
+func (g *Gateway) listBannedPeers(ctx *fasthttp.RequestCtx) {
+	g.render(ctx, bannedPeerList(g.ledger.Peers().Banned()))
+}
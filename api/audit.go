@@ -0,0 +1,139 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package api
+
+import (
+	"encoding/hex"
+	"strconv"
+	"time"
+
+	"github.com/perlin-network/wavelet"
+	"github.com/pkg/errors"
+	"github.com/valyala/fasthttp"
+	"github.com/valyala/fastjson"
+)
+
+// maxAuditLimit is the most audit entries listed per request.
+const maxAuditLimit = 1000
+
+// audited records every request served by next into the audit log of the ledger
+// as having taken action, alongside who made the request and what it resulted in.
+func (g *Gateway) audited(action string) middleware {
+	return func(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+		return func(ctx *fasthttp.RequestCtx) {
+			next(ctx)
+
+			if g.ledger == nil {
+				return
+			}
+
+			entry := wavelet.AuditEntry{
+				Action:    action,
+				Actor:     ctx.RemoteIP().String(),
+				RequestID: requestID(ctx),
+				Status:    uint16(ctx.Response.StatusCode()),
+				Details:   string(ctx.PostBody()),
+			}
+
+			if _, err := g.ledger.AuditLog().Append(entry); err != nil {
+				logger := requestLogger(ctx, g.logs().Node())
+				logger.Error().Err(err).Str("action", action).Msg("Failed to record admin action into the audit log.")
+			}
+		}
+	}
+}
+
+type auditEntryList struct {
+	length  uint64
+	entries []wavelet.AuditEntry
+}
+
+func (s *auditEntryList) marshalJSON(arena *fastjson.Arena) ([]byte, error) {
+	o := arena.NewObject()
+
+	o.Set("length", arena.NewNumberString(strconv.FormatUint(s.length, 10)))
+
+	list := arena.NewArray()
+
+	for i, entry := range s.entries {
+		v := arena.NewObject()
+
+		v.Set("index", arena.NewNumberString(strconv.FormatUint(entry.Index, 10)))
+		v.Set("time", arena.NewString(entry.Time.UTC().Format(time.RFC3339Nano)))
+		v.Set("action", arena.NewString(entry.Action))
+		v.Set("actor", arena.NewString(entry.Actor))
+		v.Set("request_id", arena.NewString(entry.RequestID))
+		v.Set("status", arena.NewNumberInt(int(entry.Status)))
+		v.Set("details", arena.NewString(entry.Details))
+		v.Set("prev_hash", arena.NewString(hex.EncodeToString(entry.Prev[:])))
+		v.Set("hash", arena.NewString(hex.EncodeToString(entry.Hash[:])))
+
+		list.SetArrayItem(i, v)
+	}
+
+	o.Set("entries", list)
+
+	return o.MarshalTo(nil), nil
+}
+
+// listAuditEntries lists entries of the audit log from the oldest to the most
+// recent, failing should any entry listed have been tampered with.
+func (g *Gateway) listAuditEntries(ctx *fasthttp.RequestCtx) {
+	var (
+		offset uint64
+		limit  uint64 = maxAuditLimit
+		err    error
+	)
+
+	queryArgs := ctx.QueryArgs()
+
+	if raw := string(queryArgs.Peek("offset")); len(raw) > 0 {
+		offset, err = strconv.ParseUint(raw, 10, 64)
+
+		if err != nil {
+			g.renderError(ctx, ErrBadRequest(errors.Wrap(err, "could not parse offset")))
+			return
+		}
+	}
+
+	if raw := string(queryArgs.Peek("limit")); len(raw) > 0 {
+		limit, err = strconv.ParseUint(raw, 10, 64)
+
+		if err != nil {
+			g.renderError(ctx, ErrBadRequest(errors.Wrap(err, "could not parse limit")))
+			return
+		}
+
+		if limit > maxAuditLimit {
+			g.renderError(ctx, ErrBadRequest(errors.Errorf("limit must not be larger than %d", maxAuditLimit)))
+			return
+		}
+	}
+
+	audit := g.ledger.AuditLog()
+
+	entries, err := audit.Entries(offset, limit)
+	if err != nil {
+		g.renderError(ctx, ErrInternal(err))
+		return
+	}
+
+	g.render(ctx, &auditEntryList{length: audit.Len(), entries: entries})
+}
@@ -0,0 +1,141 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package api
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+
+	"github.com/valyala/fasthttp"
+)
+
+// minCompressSize is the smallest response body worth compressing. Smaller
+// bodies are likely to end up larger than they were once compressed.
+const minCompressSize = 1024
+
+// WithCompressionLevel sets the level at which responses are compressed with
+// gzip or deflate, should the client accept either. Levels range from
+// fasthttp.CompressBestSpeed up to fasthttp.CompressBestCompression, and
+// fasthttp.CompressNoCompression disables compression altogether.
+func WithCompressionLevel(level int) GatewayOption {
+	return func(g *Gateway) {
+		g.compressionLevel = level
+	}
+}
+
+// compress compresses the body of responses using whichever of gzip or deflate
+// the client prefers according to its Accept-Encoding header. Streamed bodies,
+// such as exports, are left as is, as compressing them would have them read
+// into memory in full.
+func compress(level int) middleware {
+	return func(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+		return func(ctx *fasthttp.RequestCtx) {
+			next(ctx)
+
+			if ctx.Hijacked() || ctx.Response.StatusCode() == fasthttp.StatusSwitchingProtocols {
+				return
+			}
+
+			ctx.Response.Header.Add("Vary", "Accept-Encoding")
+
+			if len(ctx.Response.Header.Peek("Content-Encoding")) > 0 || ctx.Response.IsBodyStream() {
+				return
+			}
+
+			body := ctx.Response.Body()
+
+			if len(body) < minCompressSize || !compressible(ctx.Response.Header.ContentType()) {
+				return
+			}
+
+			switch negotiateEncoding(string(ctx.Request.Header.Peek("Accept-Encoding"))) {
+			case "gzip":
+				ctx.Response.SetBody(fasthttp.AppendGzipBytesLevel(nil, body, level))
+				ctx.Response.Header.Set("Content-Encoding", "gzip")
+			case "deflate":
+				ctx.Response.SetBody(fasthttp.AppendDeflateBytesLevel(nil, body, level))
+				ctx.Response.Header.Set("Content-Encoding", "deflate")
+			}
+		}
+	}
+}
+
+// compressible returns true if responses of the given content type, such as
+// JSON, CSV exports, or contract code, are worth compressing.
+func compressible(contentType []byte) bool {
+	for _, prefix := range []string{"application/json", "application/wasm", "text/"} {
+		if bytes.HasPrefix(contentType, []byte(prefix)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// negotiateEncoding picks whichever of gzip or deflate is given the highest
+// quality value in an Accept-Encoding header, preferring gzip on ties. An empty
+// string is returned should the client accept neither.
+func negotiateEncoding(header string) string {
+	qualities := map[string]float64{"gzip": 0, "deflate": 0}
+
+	var wildcard float64
+	var specified = map[string]bool{}
+
+	for _, part := range strings.Split(header, ",") {
+		params := strings.Split(part, ";")
+
+		coding := strings.ToLower(strings.TrimSpace(params[0]))
+		quality := 1.0
+
+		for _, param := range params[1:] {
+			param = strings.TrimSpace(param)
+
+			if strings.HasPrefix(param, "q=") {
+				if q, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					quality = q
+				}
+			}
+		}
+
+		switch coding {
+		case "*":
+			wildcard = quality
+		case "gzip", "deflate":
+			qualities[coding] = quality
+			specified[coding] = true
+		}
+	}
+
+	for coding := range qualities {
+		if !specified[coding] {
+			qualities[coding] = wildcard
+		}
+	}
+
+	switch {
+	case qualities["gzip"] > 0 && qualities["gzip"] >= qualities["deflate"]:
+		return "gzip"
+	case qualities["deflate"] > 0:
+		return "deflate"
+	}
+
+	return ""
+}
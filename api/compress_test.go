@@ -0,0 +1,122 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package api
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fasthttp"
+)
+
+func TestNegotiateEncoding(t *testing.T) {
+	tests := map[string]string{
+		"":                        "",
+		"identity":                "",
+		"gzip":                    "gzip",
+		"deflate":                 "deflate",
+		"deflate, gzip":           "gzip",
+		"gzip;q=0.5, deflate":     "deflate",
+		"gzip;q=0, deflate;q=0":   "",
+		"*":                       "gzip",
+		"*;q=0.1, deflate;q=0.5":  "deflate",
+		"GZIP;q=0.3, br":          "gzip",
+		"gzip;q=0, *":             "deflate",
+		"br, identity;q=1, *;q=0": "",
+	}
+
+	for header, expected := range tests {
+		assert.Equal(t, expected, negotiateEncoding(header), header)
+	}
+}
+
+func TestCompression(t *testing.T) {
+	body := `{"data":"` + strings.Repeat("a", 2*minCompressSize) + `"}`
+
+	handler := func(ctx *fasthttp.RequestCtx) {
+		ctx.SetContentType("application/json")
+
+		switch string(ctx.Path()) {
+		case "/small":
+			ctx.SetBodyString(`{}`)
+		case "/stream":
+			ctx.SetBodyStream(strings.NewReader(body), -1)
+		default:
+			ctx.SetBodyString(body)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		opts     []GatewayOption
+		path     string
+		encoding string
+		expected string
+	}{
+		{name: "gzip", path: "/", encoding: "gzip", expected: "gzip"},
+		{name: "deflate", path: "/", encoding: "deflate", expected: "deflate"},
+		{name: "identity", path: "/", encoding: "identity", expected: ""},
+		{name: "small", path: "/small", encoding: "gzip", expected: ""},
+		{name: "stream", path: "/stream", encoding: "gzip", expected: ""},
+		{name: "disabled", opts: []GatewayOption{WithCompressionLevel(fasthttp.CompressNoCompression)}, path: "/", encoding: "gzip", expected: ""},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			gateway := New(tc.opts...)
+
+			req, err := http.NewRequest("GET", "http://localhost"+tc.path, nil)
+			assert.NoError(t, err)
+
+			req.Header.Set("Accept-Encoding", tc.encoding)
+
+			res, err := serveHandler(gateway.applyMiddleware(handler, ""), req)
+			assert.NoError(t, err)
+
+			assert.Equal(t, tc.expected, res.Header.Get("Content-Encoding"))
+
+			var r io.Reader = res.Body
+
+			switch tc.expected {
+			case "gzip":
+				r, err = gzip.NewReader(res.Body)
+				assert.NoError(t, err)
+			case "deflate":
+				r, err = zlib.NewReader(res.Body)
+				assert.NoError(t, err)
+			}
+
+			decoded, err := ioutil.ReadAll(r)
+			assert.NoError(t, err)
+
+			if tc.path == "/small" {
+				assert.Equal(t, `{}`, string(decoded))
+			} else {
+				assert.Equal(t, body, string(decoded))
+			}
+		})
+	}
+}
@@ -0,0 +1,223 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package api
+
+import (
+	"encoding/hex"
+	"strconv"
+
+	"github.com/perlin-network/wavelet"
+	"github.com/perlin-network/wavelet/sys"
+	"github.com/pkg/errors"
+	"github.com/valyala/fasthttp"
+	"github.com/valyala/fastjson"
+)
+
+var _ marshalableJSON = (*contractTransactionResponse)(nil)
+
+type callContractRequest struct {
+	Func     string `json:"func"`
+	Params   string `json:"params"`
+	GasLimit uint64 `json:"gas_limit"`
+	Amount   uint64 `json:"amount"`
+
+	// Internal fields.
+	params []byte
+}
+
+func (s *callContractRequest) bind(parser *fastjson.Parser, body []byte) error {
+	v, err := parser.ParseBytes(body)
+	if err != nil {
+		return err
+	}
+
+	funcVal := v.Get("func")
+	if funcVal == nil {
+		return errors.New("missing func")
+	}
+	funcStr, err := funcVal.StringBytes()
+	if err != nil {
+		return errors.Wrap(err, "invalid func")
+	}
+
+	gasLimitVal := v.Get("gas_limit")
+	if gasLimitVal == nil {
+		return errors.New("missing gas_limit")
+	}
+	if s.GasLimit, err = gasLimitVal.Uint64(); err != nil {
+		return errors.Wrap(err, "invalid gas_limit")
+	}
+
+	// Parameters and the amount of PERLs to send alongside the call are
+	// optional.
+
+	if paramsVal := v.Get("params"); paramsVal != nil {
+		paramsStr, err := paramsVal.StringBytes()
+		if err != nil {
+			return errors.Wrap(err, "invalid params")
+		}
+
+		s.Params = string(paramsStr)
+	}
+
+	if amountVal := v.Get("amount"); amountVal != nil {
+		if s.Amount, err = amountVal.Uint64(); err != nil {
+			return errors.Wrap(err, "invalid amount")
+		}
+	}
+
+	s.Func = string(funcStr)
+
+	if len(s.Func) == 0 {
+		return errors.New("func must not be empty")
+	}
+
+	if s.GasLimit == 0 {
+		return errors.New("gas_limit must be greater than zero")
+	}
+
+	if s.params, err = hex.DecodeString(s.Params); err != nil {
+		return errors.Wrap(err, "params provided are not hex-formatted")
+	}
+
+	return nil
+}
+
+type contractTransactionResponse struct {
+	sendTransactionResponse
+
+	// Internal fields.
+	contractID wavelet.TransactionID
+}
+
+func (s *contractTransactionResponse) marshalJSON(arena *fastjson.Arena) ([]byte, error) {
+	o, err := s.getObject(arena)
+	if err != nil {
+		return nil, err
+	}
+
+	o.Set("contract_id", arena.NewString(hex.EncodeToString(s.contractID[:])))
+
+	return o.MarshalTo(nil), nil
+}
+
+// deployContract spawns the WASM code in the request body as a smart contract
+// paid for by the node, returning the ID of the contract. The gas limit and
+// the hex-encoded parameters to pass to the contract's init function are
+// provided as query arguments.
+func (g *Gateway) deployContract(ctx *fasthttp.RequestCtx) {
+	gasLimit, err := strconv.ParseUint(string(ctx.QueryArgs().Peek("gas_limit")), 10, 64)
+	if err != nil || gasLimit == 0 {
+		g.renderError(ctx, ErrBadRequest(errors.New("gas_limit must be a number greater than zero")))
+		return
+	}
+
+	params, err := hex.DecodeString(string(ctx.QueryArgs().Peek("params")))
+	if err != nil {
+		g.renderError(ctx, ErrBadRequest(errors.Wrap(err, "params provided are not hex-formatted")))
+		return
+	}
+
+	if len(ctx.PostBody()) == 0 {
+		g.renderError(ctx, ErrBadRequest(errors.New("contract code must be provided as the request body")))
+		return
+	}
+
+	payload := wavelet.Contract{GasLimit: gasLimit, Params: params, Code: ctx.PostBody()}
+
+	tx, e := g.submitOwnTransaction(ctx, sys.TagContract, payload.Marshal())
+	if e != nil {
+		g.renderError(ctx, e)
+		return
+	}
+
+	g.render(ctx, &contractTransactionResponse{sendTransactionResponse: sendTransactionResponse{ledger: g.ledger, tx: tx}, contractID: tx.ID})
+}
+
+// callContract invokes a function of a smart contract, paid for by the node.
+func (g *Gateway) callContract(ctx *fasthttp.RequestCtx) {
+	id, ok := ctx.UserValue("contract_id").(wavelet.TransactionID)
+	if !ok {
+		g.renderError(ctx, ErrBadRequest(errors.New("id must be a TransactionID")))
+		return
+	}
+
+	req := new(callContractRequest)
+
+	parser := g.parserPool.Get()
+	err := req.bind(parser, ctx.PostBody())
+	g.parserPool.Put(parser)
+
+	if err != nil {
+		g.renderError(ctx, ErrBadRequest(err))
+		return
+	}
+
+	if _, available := wavelet.ReadAccountContractCode(g.ledger.Snapshot(), id); !available {
+		g.renderError(ctx, ErrNotFound(errors.Errorf("could not find contract with ID %x", id)))
+		return
+	}
+
+	payload := wavelet.Transfer{
+		Recipient:  id,
+		Amount:     req.Amount,
+		GasLimit:   req.GasLimit,
+		FuncName:   []byte(req.Func),
+		FuncParams: req.params,
+	}
+
+	if _, err := wavelet.ParseTransferTransaction(payload.Marshal()); err != nil {
+		g.renderError(ctx, ErrBadRequest(err))
+		return
+	}
+
+	tx, e := g.submitOwnTransaction(ctx, sys.TagTransfer, payload.Marshal())
+	if e != nil {
+		g.renderError(ctx, e)
+		return
+	}
+
+	g.render(ctx, &contractTransactionResponse{sendTransactionResponse: sendTransactionResponse{ledger: g.ledger, tx: tx}, contractID: id})
+}
+
+// submitOwnTransaction creates a transaction which the signer of the node signs,
+// and adds it to the graph.
+func (g *Gateway) submitOwnTransaction(ctx *fasthttp.RequestCtx, tag sys.Tag, payload []byte) (*wavelet.Transaction, *errResponse) {
+	if !g.ledger.Mode().Participates() {
+		return nil, ErrBadRequest(wavelet.ErrReadOnly).withCode(CodeReadOnly).withDetail("mode", string(g.ledger.Mode()))
+	}
+
+	tx, err := wavelet.SignTransaction(g.signer, g.ledger.NextNonce(g.signer.PublicKey()), tag, payload)
+	if err != nil {
+		return nil, ErrInternal(err)
+	}
+
+	if tx, err = wavelet.AttachSignerToTransaction(g.signer, tx, g.ledger.Graph().FindEligibleParents()...); err != nil {
+		return nil, ErrInternal(err)
+	}
+
+	g.ledger.TraceTransaction(tx.ID, requestID(ctx))
+
+	if err := g.ledger.AddTransaction(tx); err != nil && errors.Cause(err) != wavelet.ErrMissingParents {
+		return nil, addTransactionError(err)
+	}
+
+	return &tx, nil
+}
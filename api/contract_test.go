@@ -0,0 +1,125 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package api
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/perlin-network/noise/skademlia"
+	"github.com/perlin-network/wavelet"
+	"github.com/perlin-network/wavelet/sys"
+	"github.com/perlin-network/wavelet/wallet"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContractDeployAndCall(t *testing.T) {
+	gateway := New(WithAdminToken("secret"))
+	gateway.setup()
+
+	keys, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	gateway.signer = wallet.Key(keys.PrivateKey())
+	gateway.ledger = createLedger(t)
+
+	post := func(path, token, body string) (*http.Response, map[string]interface{}) {
+		request := httptest.NewRequest("POST", "http://localhost"+path, bytes.NewBufferString(body))
+
+		if len(token) > 0 {
+			request.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		w, err := serve(gateway.router, request)
+		assert.NoError(t, err)
+
+		response, err := ioutil.ReadAll(w.Body)
+		assert.NoError(t, err)
+
+		var res map[string]interface{}
+		assert.NoError(t, json.Unmarshal(response, &res))
+
+		return w, res
+	}
+
+	w, _ := post("/contract?gas_limit=100", "", "code")
+	assert.Equal(t, http.StatusUnauthorized, w.StatusCode)
+
+	w, _ = post("/contract", "secret", "code")
+	assert.Equal(t, http.StatusBadRequest, w.StatusCode, "gas limit must be provided")
+
+	w, _ = post("/contract?gas_limit=100", "secret", "")
+	assert.Equal(t, http.StatusBadRequest, w.StatusCode, "code must be provided")
+
+	w, res := post("/contract?gas_limit=100&params=0102", "secret", "code")
+	if assert.Equal(t, http.StatusOK, w.StatusCode) {
+		assert.Equal(t, res["tx_id"], res["contract_id"])
+
+		buf, err := hex.DecodeString(res["contract_id"].(string))
+		assert.NoError(t, err)
+
+		var id wavelet.TransactionID
+		copy(id[:], buf)
+
+		tx := gateway.ledger.Graph().FindTransaction(id)
+		if assert.NotNil(t, tx) {
+			assert.Equal(t, sys.TagContract, tx.Tag)
+			assert.Equal(t, wavelet.AccountID(keys.PublicKey()), tx.Creator)
+
+			contract, err := wavelet.ParseContractTransaction(tx.Payload)
+			assert.NoError(t, err)
+			assert.Equal(t, wavelet.Contract{GasLimit: 100, Params: []byte{1, 2}, Code: []byte("code")}, contract)
+		}
+	}
+
+	id := hex.EncodeToString(bytes.Repeat([]byte{1}, wavelet.SizeTransactionID))
+
+	w, _ = post("/contract/"+id+"/call", "secret", `{"func":"hello"}`)
+	assert.Equal(t, http.StatusBadRequest, w.StatusCode, "gas limit must be provided")
+
+	w, _ = post("/contract/"+id+"/call", "secret", `{"func":"hello","gas_limit":100,"params":"zz"}`)
+	assert.Equal(t, http.StatusBadRequest, w.StatusCode, "params must be hex")
+
+	w, _ = post("/contract/"+id+"/call", "secret", `{"func":"hello","gas_limit":100}`)
+	assert.Equal(t, http.StatusNotFound, w.StatusCode, "contract does not exist")
+}
+
+func TestTransferMarshal(t *testing.T) {
+	var recipient wavelet.AccountID
+	recipient[0] = 1
+
+	transfers := []wavelet.Transfer{
+		{Recipient: recipient, Amount: 10},
+		{Recipient: recipient, Amount: 10, GasLimit: 100},
+		{Recipient: recipient, GasLimit: 100, FuncName: []byte("hello")},
+		{Recipient: recipient, GasLimit: 100, FuncName: []byte("hello"), FuncParams: []byte{1, 2, 3}},
+	}
+
+	for _, transfer := range transfers {
+		parsed, err := wavelet.ParseTransferTransaction(transfer.Marshal())
+		assert.NoError(t, err)
+		assert.Equal(t, transfer, parsed)
+	}
+}
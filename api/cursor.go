@@ -0,0 +1,124 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package api
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"math"
+
+	"github.com/perlin-network/wavelet"
+	"github.com/pkg/errors"
+)
+
+const sizeTxCursor = 8 + 8 + wavelet.SizeTransactionID
+
+// txCursor marks the position of a transaction within the listing of all
+// transactions, ordered by the round they belong to, their depth, and their ID.
+// Rounds partition the graph into disjoint ranges of depth, so cursors compare
+// the same way regardless of whether or not their round is taken into account.
+//
+// Transactions that have yet to be finalized belong to the round after the
+// latest finalized round.
+type txCursor struct {
+	round uint64
+	depth uint64
+	id    wavelet.TransactionID
+}
+
+// String encodes the cursor into an opaque, URL-safe string.
+func (c txCursor) String() string {
+	var buf [sizeTxCursor]byte
+
+	binary.BigEndian.PutUint64(buf[0:8], c.round)
+	binary.BigEndian.PutUint64(buf[8:16], c.depth)
+	copy(buf[16:], c.id[:])
+
+	return base64.RawURLEncoding.EncodeToString(buf[:])
+}
+
+func parseTxCursor(raw string) (txCursor, error) {
+	var c txCursor
+
+	buf, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return c, errors.Wrap(err, "cursor is malformed")
+	}
+
+	if len(buf) != sizeTxCursor {
+		return c, errors.Errorf("cursor must be %d bytes long, but got %d bytes", sizeTxCursor, len(buf))
+	}
+
+	c.round = binary.BigEndian.Uint64(buf[0:8])
+	c.depth = binary.BigEndian.Uint64(buf[8:16])
+	copy(c.id[:], buf[16:])
+
+	return c, nil
+}
+
+// pageTransactions lists up to limit transactions of ledger which come after the
+// position marked by the cursor raw, alongside a cursor to the next page. The
+// cursor to the next page is empty should there be no transactions left to list.
+// An empty cursor starts listing from the deepest transaction in the graph.
+func pageTransactions(ledger *wavelet.Ledger, raw string, limit uint64, sender, creator wavelet.AccountID) ([]*wavelet.Transaction, string, error) {
+	rounds := ledger.Rounds()
+	latest, oldest := rounds.Latest(), rounds.Oldest()
+
+	after := txCursor{round: latest.Index + 1, depth: math.MaxUint64}
+
+	if len(raw) > 0 {
+		var err error
+
+		if after, err = parseTxCursor(raw); err != nil {
+			return nil, "", err
+		}
+
+		if after.round > latest.Index+1 {
+			return nil, "", errors.Errorf("cursor refers to round %d, which is ahead of the latest round %d", after.round, latest.Index)
+		}
+
+		if after.round < oldest.Index {
+			return nil, "", errors.Errorf("cursor refers to round %d, which has since been pruned; the oldest round is %d", after.round, oldest.Index)
+		}
+	}
+
+	// Fetch one transaction more than requested to tell whether or not there is a next page.
+
+	txs := ledger.Graph().ListTransactionsAfter(after.depth, after.id, limit+1, sender, creator)
+
+	if uint64(len(txs)) <= limit {
+		return txs, "", nil
+	}
+
+	txs = txs[:limit]
+
+	last := txs[len(txs)-1]
+	next := txCursor{round: latest.Index + 1, depth: last.Depth, id: last.ID}
+
+	if last.Depth <= latest.End.Depth {
+		if round, err := rounds.GetByDepth(last.Depth); err == nil {
+			next.round = round.Index
+		} else {
+			next.round = oldest.Index
+		}
+	}
+
+	return txs, next.String(), nil
+}
@@ -0,0 +1,140 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package api
+
+import (
+	"encoding/base64"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/perlin-network/noise/skademlia"
+	"github.com/perlin-network/wavelet"
+	"github.com/perlin-network/wavelet/sys"
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fastjson"
+)
+
+func TestTxCursor(t *testing.T) {
+	expected := txCursor{round: 42, depth: 1337}
+	_, err := rand.Read(expected.id[:])
+	assert.NoError(t, err)
+
+	cursor, err := parseTxCursor(expected.String())
+	assert.NoError(t, err)
+	assert.Equal(t, expected, cursor)
+
+	_, err = parseTxCursor("not a cursor!")
+	assert.Error(t, err)
+
+	_, err = parseTxCursor(base64.RawURLEncoding.EncodeToString([]byte("short")))
+	assert.Error(t, err)
+}
+
+func TestListTransactionsByCursor(t *testing.T) {
+	gateway := New()
+	gateway.setup()
+
+	gateway.ledger = createLedger(t)
+
+	keys, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	graph := gateway.ledger.Graph()
+
+	addTransactions := func(n int) {
+		for i := 0; i < n; i++ {
+			var payload [50]byte
+
+			_, err := rand.Read(payload[:])
+			assert.NoError(t, err)
+
+			tx := wavelet.AttachSenderToTransaction(keys, wavelet.NewTransaction(keys, sys.TagTransfer, payload[:]), graph.FindEligibleParents()...)
+			assert.NoError(t, graph.AddTransaction(tx))
+		}
+	}
+
+	addTransactions(10)
+
+	expected := graph.Len()
+
+	list := func(query string) (*fastjson.Value, int) {
+		request := httptest.NewRequest("GET", "http://localhost/tx?"+query, nil)
+
+		w, err := serve(gateway.router, request)
+		assert.NoError(t, err)
+
+		var parser fastjson.Parser
+
+		buf, err := ioutil.ReadAll(w.Body)
+		assert.NoError(t, err)
+
+		v, err := parser.ParseBytes(buf)
+		assert.NoError(t, err)
+
+		return v, w.StatusCode
+	}
+
+	seen := make(map[string]struct{})
+	cursor := ""
+
+	for pages := 0; ; pages++ {
+		v, code := list("limit=3&cursor=" + cursor)
+		assert.Equal(t, http.StatusOK, code)
+
+		for _, tx := range v.GetArray("transactions") {
+			id := string(tx.GetStringBytes("id"))
+
+			_, exists := seen[id]
+			assert.False(t, exists, "transaction %s was listed twice", id)
+
+			seen[id] = struct{}{}
+		}
+
+		// New transactions arriving in between pages must not shift the pages.
+		addTransactions(2)
+
+		if v.Get("next_cursor").Type() == fastjson.TypeNull {
+			assert.True(t, pages < expected)
+			break
+		}
+
+		cursor = string(v.GetStringBytes("next_cursor"))
+	}
+
+	assert.Len(t, seen, expected)
+
+	_, code := list("cursor=invalid!")
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	_, code = list("offset=1&cursor=")
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	_, code = list("cursor=" + txCursor{round: gateway.ledger.Rounds().Latest().Index + 2}.String())
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	v, code := list("limit=" + strconv.Itoa(graph.Len()) + "&cursor=")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Len(t, v.GetArray("transactions"), graph.Len())
+	assert.Equal(t, fastjson.TypeNull, v.Get("next_cursor").Type())
+}
@@ -0,0 +1,116 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package api
+
+import (
+	"github.com/perlin-network/wavelet/debounce"
+	"github.com/perlin-network/wavelet/log"
+	"sort"
+	"time"
+)
+
+// SinkDebouncer configures how the events of a websocket sink are debounced
+// before being delivered to its clients.
+type SinkDebouncer struct {
+	// Type is either debounce.TypeLimiter, which delivers events in batches,
+	// or debounce.TypeDeduper, which groups events by Keys and only delivers
+	// the latest event of every group.
+	Type debounce.Type
+
+	// Period is how long events are debounced for. Zero denotes the default
+	// period of the debouncer.
+	Period time.Duration
+
+	// MaxBatchBytes caps the total size of a batch of events should Type be
+	// debounce.TypeLimiter. Zero denotes the default cap of the debouncer.
+	MaxBatchBytes int
+
+	// Keys are the fields events are grouped by should Type be
+	// debounce.TypeDeduper. Should no keys be specified, events are grouped
+	// by the fields the sink may be filtered by, alongside their event type.
+	Keys []string
+
+	// PriorityEvents are the types of events which bypass debouncing, such
+	// that they reach clients immediately. Should it be nil, rounds being
+	// finalized, alerts such as rounds stalling, forks being detected, and
+	// conflicting transactions being observed bypass debouncing.
+	PriorityEvents []string
+}
+
+var defaultPriorityEvents = []string{"round_end", "alert", "fork", "conflict"}
+
+func (c SinkDebouncer) priorityEvents() map[string]struct{} {
+	events := c.PriorityEvents
+
+	if events == nil {
+		events = defaultPriorityEvents
+	}
+
+	set := make(map[string]struct{}, len(events))
+
+	for _, event := range events {
+		set[event] = struct{}{}
+	}
+
+	return set
+}
+
+// WithSinkDebouncer overrides how the events of the websocket sink named sink,
+// such as "tx" or "accounts", are debounced.
+func WithSinkDebouncer(sink string, cfg SinkDebouncer) GatewayOption {
+	return func(g *Gateway) {
+		if g.debouncers == nil {
+			g.debouncers = make(map[string]SinkDebouncer)
+		}
+
+		g.debouncers[sink] = cfg
+	}
+}
+
+func (c SinkDebouncer) factory(filters map[string]string) *debounce.Factory {
+	var opts []debounce.ConfigOption
+
+	if c.Period > 0 {
+		opts = append(opts, debounce.WithPeriod(c.Period))
+	}
+
+	switch c.Type {
+	case debounce.TypeLimiter:
+		if c.MaxBatchBytes > 0 {
+			opts = append(opts, debounce.WithBufferLimit(c.MaxBatchBytes))
+		}
+	case debounce.TypeDeduper:
+		keys := c.Keys
+
+		if len(keys) == 0 {
+			for _, key := range filters {
+				keys = append(keys, key)
+			}
+
+			sort.Strings(keys)
+
+			keys = append(keys, log.KeyEvent)
+		}
+
+		opts = append(opts, debounce.WithKeys(keys...))
+	}
+
+	return debounce.NewFactory(c.Type, opts...)
+}
@@ -0,0 +1,102 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package api
+
+import (
+	"bytes"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+
+	"github.com/valyala/fasthttp"
+)
+
+// roundETag returns an entity tag identifying the ledger state as of the latest
+// finalized round, keyed on its index and merkle root. The tag is weak, as the
+// bodies it describes may be compressed, and as volatile fields such as the peers
+// listed under /ledger may drift within a round.
+func (g *Gateway) roundETag() []byte {
+	round := g.ledger.Rounds().Latest()
+
+	tag := make([]byte, 0, 4+20+2*len(round.Merkle))
+
+	tag = append(tag, `W/"`...)
+	tag = strconv.AppendUint(tag, round.Index, 10)
+	tag = append(tag, '-')
+	tag = append(tag, hex.EncodeToString(round.Merkle[:])...)
+	tag = append(tag, '"')
+
+	return tag
+}
+
+// notModified attaches an ETag derived from the latest finalized round to the
+// response. Should the ETag match the If-None-Match header of the request, a 304
+// is written and true is returned, in which case the handler should not render
+// anything further.
+//
+// The ETag is computed before the handler reads any state, such that a round
+// being finalized mid-request may only ever cause a stale tag to be attached to
+// a fresher body, and never the other way around.
+func (g *Gateway) notModified(ctx *fasthttp.RequestCtx) bool {
+	if g.ledger == nil {
+		return false
+	}
+
+	tag := g.roundETag()
+
+	ctx.Response.Header.SetBytesV("ETag", tag)
+
+	if !matchETag(ctx.Request.Header.Peek("If-None-Match"), tag) {
+		return false
+	}
+
+	ctx.Response.SetStatusCode(http.StatusNotModified)
+	ctx.Response.ResetBody()
+
+	return true
+}
+
+// matchETag reports whether tag is listed in the value of an If-None-Match
+// header, using the weak comparison function described in RFC 7232.
+func matchETag(header []byte, tag []byte) bool {
+	tag = bytes.TrimPrefix(tag, []byte("W/"))
+
+	for len(header) > 0 {
+		var candidate []byte
+
+		if i := bytes.IndexByte(header, ','); i >= 0 {
+			candidate, header = header[:i], header[i+1:]
+		} else {
+			candidate, header = header, nil
+		}
+
+		candidate = bytes.TrimSpace(candidate)
+
+		if bytes.Equal(candidate, []byte("*")) {
+			return true
+		}
+
+		if bytes.Equal(bytes.TrimPrefix(candidate, []byte("W/")), tag) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,96 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package api
+
+import (
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/perlin-network/noise/skademlia"
+	"github.com/perlin-network/wavelet/wallet"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchETag(t *testing.T) {
+	tag := []byte(`W/"1-abcd"`)
+
+	tests := map[string]bool{
+		``:                           false,
+		`*`:                          true,
+		`"1-abcd"`:                   true,
+		`W/"1-abcd"`:                 true,
+		`"0-abcd"`:                   false,
+		`"0-abcd", W/"1-abcd"`:       true,
+		`"0-abcd" ,  "1-abcd"  `:     true,
+		`"0-abcd", "2-abcd", "1-ab"`: false,
+	}
+
+	for header, expected := range tests {
+		assert.Equal(t, expected, matchETag([]byte(header), tag), header)
+	}
+}
+
+func TestConditionalRequests(t *testing.T) {
+	gateway := New()
+	gateway.setup()
+
+	gateway.ledger = createLedger(t)
+
+	keys, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+	gateway.signer = wallet.Key(keys.PrivateKey())
+
+	round := gateway.ledger.Rounds().Latest()
+	expected := `W/"` + strconv.FormatUint(round.Index, 10) + "-" + hex.EncodeToString(round.Merkle[:]) + `"`
+
+	publicKey := keys.PublicKey()
+
+	for _, path := range []string{"/accounts/" + hex.EncodeToString(publicKey[:]), "/validators"} {
+		request := httptest.NewRequest("GET", "http://localhost"+path, nil)
+
+		w, err := serve(gateway.router, request)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, w.StatusCode, path)
+		assert.Equal(t, expected, w.Header.Get("ETag"), path)
+
+		request = httptest.NewRequest("GET", "http://localhost"+path, nil)
+		request.Header.Set("If-None-Match", w.Header.Get("ETag"))
+
+		w, err = serve(gateway.router, request)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusNotModified, w.StatusCode, path)
+		assert.Equal(t, expected, w.Header.Get("ETag"), path)
+
+		body, err := ioutil.ReadAll(w.Body)
+		assert.NoError(t, err)
+		assert.Empty(t, body, path)
+
+		request = httptest.NewRequest("GET", "http://localhost"+path, nil)
+		request.Header.Set("If-None-Match", `W/"1-`+hex.EncodeToString(round.Merkle[:])+`"`)
+
+		w, err = serve(gateway.router, request)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, w.StatusCode, path)
+	}
+}
@@ -0,0 +1,660 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package api
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"strconv"
+
+	"github.com/perlin-network/wavelet"
+	"github.com/perlin-network/wavelet/avl"
+	"github.com/perlin-network/wavelet/internal/graphql"
+	"github.com/pkg/errors"
+	"github.com/valyala/fasthttp"
+	"github.com/valyala/fastjson"
+)
+
+const (
+	// maxGraphQLDepth limits how deeply selections may be nested in GraphQL queries.
+	maxGraphQLDepth = 8
+
+	// maxGraphQLLimit limits the number of items any list field in a GraphQL query
+	// may resolve to, as lists may be nested within one another.
+	maxGraphQLLimit = 100
+)
+
+// graphqlContext holds the state shared by all resolvers of a single GraphQL
+// query, such that every field is resolved against the same snapshot of the
+// ledger.
+type graphqlContext struct {
+	ledger   *wavelet.Ledger
+	snapshot *avl.Tree
+}
+
+// receipt describes the outcome of a transaction.
+type receipt struct {
+	status string
+	round  *wavelet.Round
+}
+
+var graphqlSchema = newGraphQLSchema()
+
+var scalarUint64 = &graphql.Scalar{
+	Name:        "Uint64",
+	Description: "An unsigned 64-bit integer, serialized as a JSON number.",
+	Serialize: func(arena *fastjson.Arena, v interface{}) (*fastjson.Value, error) {
+		switch v := v.(type) {
+		case uint64:
+			return arena.NewNumberString(strconv.FormatUint(v, 10)), nil
+		case uint32:
+			return arena.NewNumberString(strconv.FormatUint(uint64(v), 10)), nil
+		}
+
+		return nil, errors.Errorf("cannot serialize %T as a Uint64", v)
+	},
+	Parse: func(v interface{}) (interface{}, error) {
+		switch v := v.(type) {
+		case int64:
+			if v >= 0 {
+				return uint64(v), nil
+			}
+		case string:
+			return strconv.ParseUint(v, 10, 64)
+		}
+
+		return nil, errors.Errorf("expected a Uint64, but got %v", v)
+	},
+}
+
+func newGraphQLSchema() *graphql.Schema {
+	account := &graphql.Object{Name: "Account", Description: "An account, or a smart contract."}
+	tx := &graphql.Object{Name: "Transaction"}
+	txPage := &graphql.Object{Name: "TransactionPage", Description: "A page of transactions, alongside a cursor to the next page."}
+	rcpt := &graphql.Object{Name: "Receipt", Description: "The outcome of a transaction."}
+	round := &graphql.Object{Name: "Round", Description: "A finalized consensus round."}
+	contract := &graphql.Object{Name: "Contract", Description: "A smart contract."}
+
+	nonNull := func(t graphql.Type) graphql.Type {
+		return &graphql.NonNull{OfType: t}
+	}
+
+	hexString := func(f func(p graphql.ResolveParams) []byte) *graphql.Field {
+		return &graphql.Field{
+			Type: nonNull(graphql.String),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return hex.EncodeToString(f(p)), nil
+			},
+		}
+	}
+
+	uint64Field := func(f func(p graphql.ResolveParams) uint64) *graphql.Field {
+		return &graphql.Field{
+			Type: nonNull(scalarUint64),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return f(p), nil
+			},
+		}
+	}
+
+	snapshotOf := func(p graphql.ResolveParams) *avl.Tree {
+		return p.Context.(*graphqlContext).snapshot
+	}
+
+	pageArgs := graphql.Args{
+		"limit": {Type: graphql.Int, Default: 25, Description: "The maximum number of transactions to list, being at most 100."},
+		"after": {Type: graphql.String, Description: "The cursor to the page to list. Lists from the most recent transaction if omitted."},
+	}
+
+	account.Fields = graphql.Fields{
+		"id": hexString(func(p graphql.ResolveParams) []byte {
+			id := p.Source.(wavelet.AccountID)
+			return id[:]
+		}),
+		"balance": uint64Field(func(p graphql.ResolveParams) uint64 {
+			balance, _ := wavelet.ReadAccountBalance(snapshotOf(p), p.Source.(wavelet.AccountID))
+			return balance
+		}),
+		"stake": uint64Field(func(p graphql.ResolveParams) uint64 {
+			stake, _ := wavelet.ReadAccountStake(snapshotOf(p), p.Source.(wavelet.AccountID))
+			return stake
+		}),
+		"reward": uint64Field(func(p graphql.ResolveParams) uint64 {
+			reward, _ := wavelet.ReadAccountReward(snapshotOf(p), p.Source.(wavelet.AccountID))
+			return reward
+		}),
+		"nonce": uint64Field(func(p graphql.ResolveParams) uint64 {
+			nonce, _ := wavelet.ReadAccountNonce(snapshotOf(p), p.Source.(wavelet.AccountID))
+			return nonce
+		}),
+		"isContract": {
+			Type: nonNull(graphql.Boolean),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				_, isContract := wavelet.ReadAccountContractCode(snapshotOf(p), p.Source.(wavelet.AccountID))
+				return isContract, nil
+			},
+		},
+		"contract": {
+			Type:        contract,
+			Description: "The smart contract behind the account, should the account be one.",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				id := p.Source.(wavelet.AccountID)
+
+				if _, isContract := wavelet.ReadAccountContractCode(snapshotOf(p), id); !isContract {
+					return nil, nil
+				}
+
+				return id, nil
+			},
+		},
+		"transactions": {
+			Type:        nonNull(txPage),
+			Description: "Transactions which the account either sent or created, from the most recent.",
+			Args:        pageArgs,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				id := p.Source.(wavelet.AccountID)
+				return resolveTransactionPage(p, id, id)
+			},
+		},
+	}
+
+	tx.Fields = graphql.Fields{
+		"id": hexString(func(p graphql.ResolveParams) []byte {
+			return p.Source.(*wavelet.Transaction).ID[:]
+		}),
+		"sender": {
+			Type: nonNull(account),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(*wavelet.Transaction).Sender, nil
+			},
+		},
+		"creator": {
+			Type: nonNull(account),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(*wavelet.Transaction).Creator, nil
+			},
+		},
+		"nonce": uint64Field(func(p graphql.ResolveParams) uint64 {
+			return p.Source.(*wavelet.Transaction).Nonce
+		}),
+		"depth": uint64Field(func(p graphql.ResolveParams) uint64 {
+			return p.Source.(*wavelet.Transaction).Depth
+		}),
+		"tag": {
+			Type: nonNull(graphql.Int),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return int(p.Source.(*wavelet.Transaction).Tag), nil
+			},
+		},
+		"payload": {
+			Type:        nonNull(graphql.String),
+			Description: "The payload of the transaction, encoded in base64.",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return base64.StdEncoding.EncodeToString(p.Source.(*wavelet.Transaction).Payload), nil
+			},
+		},
+		"senderSignature": hexString(func(p graphql.ResolveParams) []byte {
+			return p.Source.(*wavelet.Transaction).SenderSignature[:]
+		}),
+		"creatorSignature": hexString(func(p graphql.ResolveParams) []byte {
+			return p.Source.(*wavelet.Transaction).CreatorSignature[:]
+		}),
+		"creatorScheme": {
+			Type:        nonNull(graphql.String),
+			Description: "The signature scheme the creator of the transaction signed under.",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(*wavelet.Transaction).CreatorScheme.String(), nil
+			},
+		},
+		"parentIds": {
+			Type: nonNull(&graphql.List{OfType: nonNull(graphql.String)}),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				ids := make([]interface{}, 0, len(p.Source.(*wavelet.Transaction).ParentIDs))
+
+				for _, id := range p.Source.(*wavelet.Transaction).ParentIDs {
+					ids = append(ids, hex.EncodeToString(id[:]))
+				}
+
+				return ids, nil
+			},
+		},
+		"parents": {
+			Type:        nonNull(&graphql.List{OfType: tx}),
+			Description: "The parents of the transaction, which are null should they have been pruned.",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				graph := p.Context.(*graphqlContext).ledger.Graph()
+				parents := make([]interface{}, 0, len(p.Source.(*wavelet.Transaction).ParentIDs))
+
+				for _, id := range p.Source.(*wavelet.Transaction).ParentIDs {
+					parents = append(parents, graph.FindTransaction(id))
+				}
+
+				return parents, nil
+			},
+		},
+		"status": {
+			Type:        nonNull(graphql.String),
+			Description: `Either "applied" or "received".`,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return resolveReceipt(p, p.Source.(*wavelet.Transaction)).status, nil
+			},
+		},
+		"receipt": {
+			Type: nonNull(rcpt),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return resolveReceipt(p, p.Source.(*wavelet.Transaction)), nil
+			},
+		},
+	}
+
+	txPage.Fields = graphql.Fields{
+		"transactions": {
+			Type: nonNull(&graphql.List{OfType: nonNull(tx)}),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(*graphqlPage).transactions, nil
+			},
+		},
+		"nextCursor": {
+			Type:        graphql.String,
+			Description: "The cursor to the next page, which is null should there be no transactions left to list.",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				if next := p.Source.(*graphqlPage).next; len(next) > 0 {
+					return next, nil
+				}
+
+				return nil, nil
+			},
+		},
+	}
+
+	rcpt.Fields = graphql.Fields{
+		"status": {
+			Type:        nonNull(graphql.String),
+			Description: `Either "applied" should the transaction have been finalized, or "received" otherwise.`,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(*receipt).status, nil
+			},
+		},
+		"round": {
+			Type:        round,
+			Description: "The round the transaction was finalized in, which is null should the transaction not have been finalized, or should the round have been pruned.",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(*receipt).round, nil
+			},
+		},
+	}
+
+	findTx := func(id func(r *wavelet.Round) wavelet.TransactionID) *graphql.Field {
+		return &graphql.Field{
+			Type: tx,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Context.(*graphqlContext).ledger.Graph().FindTransaction(id(p.Source.(*wavelet.Round))), nil
+			},
+		}
+	}
+
+	round.Fields = graphql.Fields{
+		"index": uint64Field(func(p graphql.ResolveParams) uint64 {
+			return p.Source.(*wavelet.Round).Index
+		}),
+		"id": hexString(func(p graphql.ResolveParams) []byte {
+			return p.Source.(*wavelet.Round).ID[:]
+		}),
+		"merkleRoot": hexString(func(p graphql.ResolveParams) []byte {
+			return p.Source.(*wavelet.Round).Merkle[:]
+		}),
+		"applied": uint64Field(func(p graphql.ResolveParams) uint64 {
+			return p.Source.(*wavelet.Round).Applied
+		}),
+		"depth": uint64Field(func(p graphql.ResolveParams) uint64 {
+			r := p.Source.(*wavelet.Round)
+			return r.End.Depth - r.Start.Depth
+		}),
+		"difficulty": {
+			Type: nonNull(graphql.Int),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return int(p.Context.(*graphqlContext).ledger.Difficulty(p.Source.(*wavelet.Round))), nil
+			},
+		},
+		"startId": hexString(func(p graphql.ResolveParams) []byte {
+			return p.Source.(*wavelet.Round).Start.ID[:]
+		}),
+		"endId": hexString(func(p graphql.ResolveParams) []byte {
+			return p.Source.(*wavelet.Round).End.ID[:]
+		}),
+		"start": findTx(func(r *wavelet.Round) wavelet.TransactionID {
+			return r.Start.ID
+		}),
+		"end": findTx(func(r *wavelet.Round) wavelet.TransactionID {
+			return r.End.ID
+		}),
+	}
+
+	contract.Fields = graphql.Fields{
+		"id": hexString(func(p graphql.ResolveParams) []byte {
+			id := p.Source.(wavelet.TransactionID)
+			return id[:]
+		}),
+		"code": {
+			Type:        nonNull(graphql.String),
+			Description: "The WebAssembly code of the contract, encoded in base64.",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				code, _ := wavelet.ReadAccountContractCode(snapshotOf(p), p.Source.(wavelet.TransactionID))
+				return base64.StdEncoding.EncodeToString(code), nil
+			},
+		},
+		"codeSize": {
+			Type: nonNull(graphql.Int),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				code, _ := wavelet.ReadAccountContractCode(snapshotOf(p), p.Source.(wavelet.TransactionID))
+				return len(code), nil
+			},
+		},
+		"numPages": uint64Field(func(p graphql.ResolveParams) uint64 {
+			numPages, _ := wavelet.ReadAccountContractNumPages(snapshotOf(p), p.Source.(wavelet.TransactionID))
+			return numPages
+		}),
+		"account": {
+			Type: nonNull(account),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(wavelet.TransactionID), nil
+			},
+		},
+		"transaction": {
+			Type:        tx,
+			Description: "The transaction which spawned the contract, which is null should it have been pruned.",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Context.(*graphqlContext).ledger.Graph().FindTransaction(p.Source.(wavelet.TransactionID)), nil
+			},
+		},
+	}
+
+	query := &graphql.Object{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"account": {
+				Type: nonNull(account),
+				Args: graphql.Args{"id": {Type: nonNull(graphql.String)}},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return decodeGraphQLAccountID("account", p.Args["id"])
+				},
+			},
+			"transaction": {
+				Type: tx,
+				Args: graphql.Args{"id": {Type: nonNull(graphql.String)}},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					var id wavelet.TransactionID
+
+					if err := decodeGraphQLID("transaction", p.Args["id"], id[:]); err != nil {
+						return nil, err
+					}
+
+					return p.Context.(*graphqlContext).ledger.Graph().FindTransaction(id), nil
+				},
+			},
+			"transactions": {
+				Type:        nonNull(txPage),
+				Description: "Transactions which were sent by sender, or created by creator, from the most recent. All transactions are listed if neither are specified.",
+				Args: graphql.Args{
+					"sender":  {Type: graphql.String},
+					"creator": {Type: graphql.String},
+					"limit":   pageArgs["limit"],
+					"after":   pageArgs["after"],
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					var sender, creator wavelet.AccountID
+
+					if raw, ok := p.Args["sender"]; ok && raw != nil {
+						id, err := decodeGraphQLAccountID("sender", raw)
+						if err != nil {
+							return nil, err
+						}
+
+						sender = id
+					}
+
+					if raw, ok := p.Args["creator"]; ok && raw != nil {
+						id, err := decodeGraphQLAccountID("creator", raw)
+						if err != nil {
+							return nil, err
+						}
+
+						creator = id
+					}
+
+					return resolveTransactionPage(p, sender, creator)
+				},
+			},
+			"round": {
+				Type:        round,
+				Description: "The round with the given index, or the latest round if no index is specified. Null should the round have been pruned, or have yet to be finalized.",
+				Args:        graphql.Args{"index": {Type: scalarUint64}},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					rounds := p.Context.(*graphqlContext).ledger.Rounds()
+
+					index, ok := p.Args["index"].(uint64)
+					if !ok {
+						return rounds.Latest(), nil
+					}
+
+					r, err := rounds.GetByIndex(index)
+					if err != nil {
+						return nil, nil
+					}
+
+					return r, nil
+				},
+			},
+			"rounds": {
+				Type:        nonNull(&graphql.List{OfType: nonNull(round)}),
+				Description: "All rounds which have yet to be pruned, from the latest.",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					rounds := p.Context.(*graphqlContext).ledger.Rounds()
+
+					latest, oldest := rounds.Latest().Index, rounds.Oldest().Index
+
+					var list []interface{}
+
+					for index := latest; index >= oldest && len(list) < maxGraphQLLimit; index-- {
+						if r, err := rounds.GetByIndex(index); err == nil {
+							list = append(list, r)
+						}
+
+						if index == 0 {
+							break
+						}
+					}
+
+					return list, nil
+				},
+			},
+			"contract": {
+				Type: contract,
+				Args: graphql.Args{"id": {Type: nonNull(graphql.String)}},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					var id wavelet.TransactionID
+
+					if err := decodeGraphQLID("contract", p.Args["id"], id[:]); err != nil {
+						return nil, err
+					}
+
+					if _, isContract := wavelet.ReadAccountContractCode(snapshotOf(p), id); !isContract {
+						return nil, nil
+					}
+
+					return id, nil
+				},
+			},
+		},
+	}
+
+	schema, err := graphql.NewSchema(query, maxGraphQLDepth)
+	if err != nil {
+		panic(err)
+	}
+
+	return schema
+}
+
+func decodeGraphQLID(kind string, raw interface{}, out []byte) error {
+	s, _ := raw.(string)
+
+	buf, err := hex.DecodeString(s)
+	if err != nil {
+		return errors.Wrapf(err, "%s ID must be presented as valid hex", kind)
+	}
+
+	if len(buf) != len(out) {
+		return errors.Errorf("%s ID must be %d bytes long", kind, len(out))
+	}
+
+	copy(out, buf)
+
+	return nil
+}
+
+// decodeGraphQLAccountID decodes an account ID presented either as hex, or as a
+// bech32 address.
+func decodeGraphQLAccountID(kind string, raw interface{}) (wavelet.AccountID, error) {
+	s, _ := raw.(string)
+
+	id, err := wavelet.ParseAccountID(s)
+	if err != nil {
+		return id, errors.Wrapf(err, "invalid %s ID", kind)
+	}
+
+	return id, nil
+}
+
+type graphqlPage struct {
+	transactions []interface{}
+	next         string
+}
+
+func resolveTransactionPage(p graphql.ResolveParams, sender, creator wavelet.AccountID) (interface{}, error) {
+	limit, _ := p.Args["limit"].(int)
+
+	if limit <= 0 || limit > maxGraphQLLimit {
+		return nil, errors.Errorf("limit must be between 1 and %d", maxGraphQLLimit)
+	}
+
+	after, _ := p.Args["after"].(string)
+
+	txs, next, err := pageTransactions(p.Context.(*graphqlContext).ledger, after, uint64(limit), sender, creator)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &graphqlPage{next: next, transactions: make([]interface{}, 0, len(txs))}
+
+	for _, tx := range txs {
+		page.transactions = append(page.transactions, tx)
+	}
+
+	return page, nil
+}
+
+func resolveReceipt(p graphql.ResolveParams, tx *wavelet.Transaction) *receipt {
+	ledger := p.Context.(*graphqlContext).ledger
+
+	if tx.Depth > ledger.Graph().RootDepth() {
+		return &receipt{status: "received"}
+	}
+
+	r := &receipt{status: "applied"}
+
+	if round, err := ledger.Rounds().GetByDepth(tx.Depth); err == nil {
+		r.round = round
+	}
+
+	return r
+}
+
+// graphqlResponse executes a GraphQL query against the ledger when marshaled.
+type graphqlResponse struct {
+	// Internal fields.
+	ledger *wavelet.Ledger
+	params graphql.Params
+}
+
+func (s *graphqlResponse) marshalJSON(arena *fastjson.Arena) ([]byte, error) {
+	if s.ledger == nil {
+		return nil, errors.New("insufficient parameters were provided")
+	}
+
+	s.params.Context = &graphqlContext{ledger: s.ledger, snapshot: s.ledger.Snapshot()}
+
+	return graphql.Do(arena, graphqlSchema, s.params).Value(arena).MarshalTo(nil), nil
+}
+
+// graphqlQuery executes a GraphQL query, specified either through the query
+// string of a GET request, or through the body of a POST request as either JSON
+// or as a raw query with the content type application/graphql.
+func (g *Gateway) graphqlQuery(ctx *fasthttp.RequestCtx) {
+	var params graphql.Params
+
+	switch {
+	case ctx.IsGet():
+		args := ctx.QueryArgs()
+
+		params.Query = string(args.Peek("query"))
+		params.OperationName = string(args.Peek("operationName"))
+
+		if raw := args.Peek("variables"); len(raw) > 0 {
+			v, err := fastjson.ParseBytes(raw)
+			if err != nil {
+				g.renderError(ctx, ErrBadRequest(errors.Wrap(err, "variables must be valid JSON")))
+				return
+			}
+
+			if params.Variables, err = graphql.Variables(v); err != nil {
+				g.renderError(ctx, ErrBadRequest(err))
+				return
+			}
+		}
+	case bytes.HasPrefix(ctx.Request.Header.ContentType(), []byte("application/graphql")):
+		params.Query = string(ctx.PostBody())
+	default:
+		parser := g.parserPool.Get()
+		defer g.parserPool.Put(parser)
+
+		v, err := parser.ParseBytes(ctx.PostBody())
+		if err != nil {
+			g.renderError(ctx, ErrBadRequest(errors.Wrap(err, "could not parse request body")))
+			return
+		}
+
+		params.Query = string(v.GetStringBytes("query"))
+		params.OperationName = string(v.GetStringBytes("operationName"))
+
+		if params.Variables, err = graphql.Variables(v.Get("variables")); err != nil {
+			g.renderError(ctx, ErrBadRequest(err))
+			return
+		}
+	}
+
+	if len(params.Query) == 0 {
+		g.renderError(ctx, ErrBadRequest(errors.New("a query must be specified")))
+		return
+	}
+
+	g.render(ctx, &graphqlResponse{ledger: g.ledger, params: params})
+}
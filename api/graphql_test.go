@@ -0,0 +1,121 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package api
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/perlin-network/noise/skademlia"
+	"github.com/perlin-network/wavelet"
+	"github.com/perlin-network/wavelet/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGraphQL(t *testing.T) {
+	gateway := New()
+	gateway.setup()
+
+	keys, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	genesis := `{
+		"400056ee68a7cc2695222df05ea76875bc27ec6e61e8e62317c336157019c405": {"balance": 100, "stake": 300, "reward": 5},
+		"696937c2c8df35dba0169de72990b80761e51dd9e2411fa1fce147f68ade830a": {"balance": 42}
+	}`
+
+	gateway.ledger = wavelet.NewLedger(store.NewInmem(), skademlia.NewClient(":0", keys), &genesis)
+
+	round := gateway.ledger.Rounds().Latest()
+	rootID := hex.EncodeToString(round.End.ID[:])
+	merkleRoot := hex.EncodeToString(round.Merkle[:])
+
+	query := `query Explorer($id: String!) {
+		account(id: $id) { id balance stake reward isContract contract { id } }
+		round { index merkleRoot end { ...Tx } }
+		transactions(limit: 1) { transactions { ...Tx } nextCursor }
+	}
+
+	fragment Tx on Transaction { id receipt { status round { index } } }`
+
+	expected := `{"data":{` +
+		`"account":{"id":"400056ee68a7cc2695222df05ea76875bc27ec6e61e8e62317c336157019c405","balance":100,"stake":300,"reward":5,"isContract":false,"contract":null},` +
+		`"round":{"index":0,"merkleRoot":"` + merkleRoot + `","end":{"id":"` + rootID + `","receipt":{"status":"applied","round":{"index":0}}}},` +
+		`"transactions":{"transactions":[{"id":"` + rootID + `","receipt":{"status":"applied","round":{"index":0}}}],"nextCursor":null}` +
+		`}}`
+
+	variables := `{"id":"400056ee68a7cc2695222df05ea76875bc27ec6e61e8e62317c336157019c405"}`
+
+	body, err := json.Marshal(query)
+	assert.NoError(t, err)
+
+	requests := map[string]*http.Request{
+		"GET": httptest.NewRequest("GET", "http://localhost/graphql?"+url.Values{
+			"query":     {query},
+			"variables": {variables},
+		}.Encode(), nil),
+		"POST": httptest.NewRequest("POST", "http://localhost/graphql", strings.NewReader(
+			`{"query":`+string(body)+`,"variables":`+variables+`}`,
+		)),
+	}
+
+	for name, request := range requests {
+		t.Run(name, func(t *testing.T) {
+			w, err := serve(gateway.router, request)
+			assert.NoError(t, err)
+			assert.Equal(t, http.StatusOK, w.StatusCode)
+
+			response, err := ioutil.ReadAll(w.Body)
+			assert.NoError(t, err)
+
+			assert.Equal(t, expected, string(bytes.TrimSpace(response)))
+		})
+	}
+
+	// Errors are reported alongside partial data.
+
+	request := httptest.NewRequest("POST", "http://localhost/graphql", strings.NewReader(`{ transaction(id: "zz") { id } round { index } }`))
+	request.Header.Set("Content-Type", "application/graphql")
+
+	w, err := serve(gateway.router, request)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, w.StatusCode)
+
+	response, err := ioutil.ReadAll(w.Body)
+	assert.NoError(t, err)
+
+	assert.Equal(t,
+		`{"errors":[{"message":"transaction ID must be presented as valid hex: encoding/hex: invalid byte: U+007A 'z'","locations":[{"line":1,"column":3}],"path":["transaction"]}],"data":{"transaction":null,"round":{"index":0}}}`,
+		string(bytes.TrimSpace(response)),
+	)
+
+	// Requests without a query are rejected.
+
+	w, err = serve(gateway.router, httptest.NewRequest("GET", "http://localhost/graphql", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, w.StatusCode)
+}
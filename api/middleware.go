@@ -21,8 +21,9 @@ package api
 
 import (
 	"fmt"
+	"github.com/pkg/errors"
 	"github.com/valyala/fasthttp"
-	"net/http"
+	"github.com/valyala/fastjson"
 	"os"
 	"runtime/debug"
 	"time"
@@ -42,10 +43,11 @@ func recoverer(next fasthttp.RequestHandler) fasthttp.RequestHandler {
 	fn := func(ctx *fasthttp.RequestCtx) {
 		defer func() {
 			if rvr := recover(); rvr != nil {
-				_, _ = fmt.Fprintf(os.Stderr, "Panic: %+v\n", rvr)
+				_, _ = fmt.Fprintf(os.Stderr, "Panic serving request %q: %+v\n", requestID(ctx), rvr)
 				debug.PrintStack()
 
-				ctx.Error(http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				ctx.Response.Reset()
+				writeError(ctx, new(fastjson.Arena), ErrInternal(errors.New("the request could not be served due to an internal error")))
 			}
 		}()
 
@@ -55,8 +57,10 @@ func recoverer(next fasthttp.RequestHandler) fasthttp.RequestHandler {
 	return fasthttp.RequestHandler(fn)
 }
 
-func timeout(timeout time.Duration, msg string) func(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+func timeout(timeout time.Duration, e *errResponse) func(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+	msg, _ := e.marshalJSON(new(fastjson.Arena))
+
 	return func(next fasthttp.RequestHandler) fasthttp.RequestHandler {
-		return fasthttp.TimeoutHandler(next, timeout, msg)
+		return fasthttp.TimeoutHandler(next, timeout, string(msg))
 	}
 }
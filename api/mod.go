@@ -20,10 +20,10 @@
 package api
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/hex"
-	"fmt"
 
 	"github.com/buaazp/fasthttprouter"
 	"github.com/perlin-network/noise/skademlia"
@@ -31,7 +31,9 @@ import (
 	"github.com/perlin-network/wavelet/debounce"
 	"github.com/perlin-network/wavelet/log"
 	"github.com/perlin-network/wavelet/sys"
+	"github.com/perlin-network/wavelet/wallet"
 	"github.com/pkg/errors"
+	"github.com/rcrowley/go-metrics"
 	"github.com/valyala/fasthttp"
 	"github.com/valyala/fasthttp/pprofhandler"
 	"github.com/valyala/fastjson"
@@ -43,17 +45,32 @@ import (
 	"time"
 )
 
+// MaxBatchSize is the maximum number of transactions which may be sent
+// through a single request to /tx/batch.
+const MaxBatchSize = 256
+
 type Gateway struct {
 	client *skademlia.Client
 	ledger *wavelet.Ledger
 
 	network *skademlia.Protocol
-	keys    *skademlia.Keypair
+	signer  wallet.Signer
 
 	router        *fasthttprouter.Router
 	server        *fasthttp.Server
 	sinks         map[string]*sink
 	enableTimeout bool
+	signResponses bool
+	prefix        string
+	adminToken    string
+	snapshotToken string
+	debouncers    map[string]SinkDebouncer
+
+	wsAuth    *wsAuth
+	wsLimiter *wsLimiter
+	wsMetrics metrics.Registry
+
+	compressionLevel int
 
 	rateLimiter *rateLimiter
 
@@ -61,41 +78,56 @@ type Gateway struct {
 	arenaPool  *fastjson.ArenaPool
 }
 
-func New() *Gateway {
-	return &Gateway{
+func New(opts ...GatewayOption) *Gateway {
+	g := &Gateway{
 		sinks:       make(map[string]*sink),
+		wsMetrics:   metrics.NewRegistry(),
 		parserPool:  new(fastjson.ParserPool),
 		arenaPool:   new(fastjson.ArenaPool),
 		rateLimiter: newRateLimiter(1000),
+
+		compressionLevel: fasthttp.CompressDefaultCompression,
 	}
+
+	for _, opt := range opts {
+		opt(g)
+	}
+
+	return g
 }
 
 func (g *Gateway) setup() {
 	// Setup websocket logging sinks.
 	sinkNetwork := g.registerWebsocketSink("ws://network/", nil)
 	sinkConsensus := g.registerWebsocketSink("ws://consensus/", nil)
+	sinkRounds := g.registerWebsocketSink("ws://rounds/", nil)
 	sinkStake := g.registerWebsocketSink("ws://stake/?id=account_id", nil)
 	sinkAccounts := g.registerWebsocketSink("ws://accounts/?id=account_id",
-		debounce.NewFactory(debounce.TypeDeduper,
-			debounce.WithPeriod(500*time.Millisecond),
-			debounce.WithKeys("account_id", "event"),
-		),
+		&SinkDebouncer{
+			Type:   debounce.TypeDeduper,
+			Period: 500 * time.Millisecond,
+			Keys:   []string{"account_id", "event"},
+		},
 	)
 	sinkContracts := g.registerWebsocketSink("ws://contract/?id=contract_id",
-		debounce.NewFactory(debounce.TypeDeduper,
-			debounce.WithPeriod(500*time.Millisecond),
-			debounce.WithKeys("contract_id"),
-		),
+		&SinkDebouncer{
+			Type:   debounce.TypeDeduper,
+			Period: 500 * time.Millisecond,
+			Keys:   []string{"contract_id"},
+		},
 	)
 	sinkTransactions := g.registerWebsocketSink("ws://tx/?id=tx_id&sender=sender_id&creator=creator_id&tag=tag",
-		debounce.NewFactory(debounce.TypeLimiter,
-			debounce.WithPeriod(2200*time.Millisecond),
-			debounce.WithBufferLimit(1638400),
-		),
+		&SinkDebouncer{
+			Type:          debounce.TypeLimiter,
+			Period:        2200 * time.Millisecond,
+			MaxBatchBytes: 1638400,
+		},
 	)
+	sinkContractEvents := g.registerWebsocketSink("ws://contract_events/?contract=contract_id&tx=tx_id&topic=topic", nil)
 	sinkMetrics := g.registerWebsocketSink("ws://metrics/", nil)
+	sinkQueues := g.registerWebsocketSink("ws://queues/", nil)
 
-	log.SetWriter(log.LoggerWebsocket, g)
+	log.SetWriter(log.LoggerWebsocket+g.prefix, g)
 
 	// Setup HTTP router.
 
@@ -107,32 +139,106 @@ func (g *Gateway) setup() {
 	r.NotFound = g.notFound()
 
 	// Websocket endpoints.
-	r.GET("/poll/network", g.applyMiddleware(g.poll(sinkNetwork), "/poll/network"))
-	r.GET("/poll/consensus", g.applyMiddleware(g.poll(sinkConsensus), "/poll/consensus"))
-	r.GET("/poll/stake", g.applyMiddleware(g.poll(sinkStake), "/poll/stake"))
-	r.GET("/poll/accounts", g.applyMiddleware(g.poll(sinkAccounts), "/poll/accounts"))
-	r.GET("/poll/contract", g.applyMiddleware(g.poll(sinkContracts), "/poll/contract"))
-	r.GET("/poll/tx", g.applyMiddleware(g.poll(sinkTransactions), "/poll/tx"))
-	r.GET("/poll/metrics", g.applyMiddleware(g.poll(sinkMetrics), "/poll/metrics"))
+	r.GET(g.prefix+"/poll/network", g.applyMiddleware(g.poll(sinkNetwork), "/poll/network"))
+	r.GET(g.prefix+"/poll/consensus", g.applyMiddleware(g.poll(sinkConsensus), "/poll/consensus"))
+	r.GET(g.prefix+"/poll/rounds", g.applyMiddleware(g.poll(sinkRounds), "/poll/rounds"))
+	r.GET(g.prefix+"/poll/stake", g.applyMiddleware(g.poll(sinkStake), "/poll/stake"))
+	r.GET(g.prefix+"/poll/accounts", g.applyMiddleware(g.poll(sinkAccounts), "/poll/accounts"))
+	r.GET(g.prefix+"/poll/contract", g.applyMiddleware(g.poll(sinkContracts), "/poll/contract"))
+	r.GET(g.prefix+"/poll/tx", g.applyMiddleware(g.poll(sinkTransactions), "/poll/tx"))
+	r.GET(g.prefix+"/poll/contract-events", g.applyMiddleware(g.poll(sinkContractEvents), "/poll/contract-events"))
+	r.GET(g.prefix+"/poll/metrics", g.applyMiddleware(g.poll(sinkMetrics), "/poll/metrics"))
+	r.GET(g.prefix+"/poll/queues", g.applyMiddleware(g.poll(sinkQueues), "/poll/queues"))
 
 	// Debug endpoint.
-	r.GET("/debug/*p", g.applyMiddleware(pprofhandler.PprofHandler, "/debug/*p"))
+	r.GET(g.prefix+"/debug/*p", g.applyMiddleware(pprofhandler.PprofHandler, "/debug/*p"))
 
 	// Ledger endpoint.
-	r.GET("/ledger", g.applyMiddleware(g.ledgerStatus, "/ledger"))
+	r.GET(g.prefix+"/ledger", g.applyMiddleware(g.ledgerStatus, "/ledger"))
+
+	// Network endpoint.
+	r.GET(g.prefix+"/network", g.applyMiddleware(g.networkStatus, "/network"))
+
+	// Consensus endpoint.
+	r.GET(g.prefix+"/consensus", g.applyMiddleware(g.consensusStatus, "/consensus"))
+
+	// Mempool endpoint.
+	r.GET(g.prefix+"/mempool", g.applyMiddleware(g.getMempool, "/mempool"))
 
 	// Account endpoints.
-	r.GET("/accounts/:id", g.applyMiddleware(g.getAccount, ""))
+	r.GET(g.prefix+"/accounts/:id/history", g.applyMiddleware(g.getAccountHistory, ""))
+	r.GET(g.prefix+"/accounts/:id/recovery", g.applyMiddleware(g.getAccountRecovery, ""))
+	r.GET(g.prefix+"/accounts/:id/transactions", g.applyMiddleware(g.listAccountTransactions, "/accounts/transactions"))
+	r.GET(g.prefix+"/accounts/:id", g.applyMiddleware(g.getAccount, ""))
+
+	// Governance endpoints.
+	r.GET(g.prefix+"/proposals/:id", g.applyMiddleware(g.getProposal, ""))
+
+	// Export endpoints.
+	r.GET(g.prefix+"/export/accounts", g.applyMiddleware(g.exportAccounts, "/export/accounts"))
+	r.GET(g.prefix+"/export/graph", g.applyMiddleware(g.exportGraph, "/export/graph"))
+
+	// Validator endpoints.
+	r.GET(g.prefix+"/validators", g.applyMiddleware(g.listValidators, "/validators"))
 
 	// Contract endpoints.
-	r.GET("/contract/:id/page/:index", g.applyMiddleware(g.getContractPages, "/contract/:id/page/:index", g.contractScope))
-	r.GET("/contract/:id/page", g.applyMiddleware(g.getContractPages, "/contract/:id/page", g.contractScope))
-	r.GET("/contract/:id", g.applyMiddleware(g.getContractCode, "/contract/:id", g.contractScope))
+	r.GET(g.prefix+"/contract/:id/page/:index", g.applyMiddleware(g.getContractPages, "/contract/:id/page/:index", g.contractScope))
+	r.GET(g.prefix+"/contract/:id/page", g.applyMiddleware(g.getContractPages, "/contract/:id/page", g.contractScope))
+	r.GET(g.prefix+"/contract/:id/code", g.applyMiddleware(g.getContractCode, "/contract/:id/code", g.contractScope))
+	r.GET(g.prefix+"/contract/:id", g.applyMiddleware(g.getContractCode, "/contract/:id", g.contractScope))
 
 	// Transaction endpoints.
-	r.POST("/tx/send", g.applyMiddleware(g.sendTransaction, ""))
-	r.GET("/tx/:id", g.applyMiddleware(g.getTransaction, ""))
-	r.GET("/tx", g.applyMiddleware(g.listTransactions, "/tx"))
+	r.POST(g.prefix+"/tx/send", g.applyMiddleware(g.sendTransaction, ""))
+	r.POST(g.prefix+"/tx/batch", g.applyMiddleware(g.sendBatch, ""))
+	r.GET(g.prefix+"/tx/:id/raw", g.applyMiddleware(g.getRawTransaction, ""))
+	r.GET(g.prefix+"/tx/:id/status", g.applyMiddleware(g.getTransactionStatus, ""))
+	r.GET(g.prefix+"/tx/:id/logs", g.applyMiddleware(g.getTransactionLogs, ""))
+	r.GET(g.prefix+"/tx/:id", g.applyMiddleware(g.getTransaction, ""))
+	r.GET(g.prefix+"/tx", g.applyMiddleware(g.listTransactions, "/tx"))
+
+	// Conflict endpoints.
+	r.GET(g.prefix+"/conflicts", g.applyMiddleware(g.listConflicts, "/conflicts"))
+
+	// Fork endpoints.
+	r.GET(g.prefix+"/forks", g.applyMiddleware(g.listForks, "/forks"))
+
+	// Round endpoints.
+	r.GET(g.prefix+"/rounds/:index/certificate", g.applyMiddleware(g.getRoundCertificate, "/rounds/:index/certificate"))
+
+	// GraphQL endpoint.
+	r.GET(g.prefix+"/graphql", g.applyMiddleware(g.graphqlQuery, "/graphql"))
+	r.POST(g.prefix+"/graphql", g.applyMiddleware(g.graphqlQuery, "/graphql"))
+
+	// JSON-RPC 2.0 endpoint.
+	r.POST(g.prefix+"/rpc", g.applyMiddleware(g.rpc, "/rpc"))
+
+	// State snapshot endpoint, which is only served should a snapshot token be
+	// configured.
+	if len(g.snapshotToken) > 0 {
+		r.GET(g.prefix+"/snapshot", g.applyMiddleware(g.getStateSnapshot, "", g.snapshotScope))
+		r.HEAD(g.prefix+"/snapshot", g.applyMiddleware(g.getStateSnapshot, "", g.snapshotScope))
+	}
+
+	// Admin endpoints, which are only served should an admin token be configured.
+	// Every authorized request made to an admin endpoint is recorded into the
+	// audit log of the ledger.
+	if len(g.adminToken) > 0 {
+		r.POST(g.prefix+"/admin/peers/connect", g.applyMiddleware(g.connectPeer, "", g.adminScope, g.audited("peers.connect")))
+		r.POST(g.prefix+"/admin/peers/disconnect", g.applyMiddleware(g.disconnectPeer, "", g.adminScope, g.audited("peers.disconnect")))
+		r.POST(g.prefix+"/admin/peers/ban", g.applyMiddleware(g.banPeer, "", g.adminScope, g.audited("peers.ban")))
+		r.POST(g.prefix+"/admin/peers/unban", g.applyMiddleware(g.unbanPeer, "", g.adminScope, g.audited("peers.unban")))
+		r.GET(g.prefix+"/admin/peers/banned", g.applyMiddleware(g.listBannedPeers, "", g.adminScope, g.audited("peers.list_banned")))
+		r.GET(g.prefix+"/admin/audit", g.applyMiddleware(g.listAuditEntries, "", g.adminScope, g.audited("audit.list")))
+		r.POST(g.prefix+"/admin/ws/token", g.applyMiddleware(g.issueWebsocketToken, "", g.adminScope, g.audited("ws.token")))
+
+		// Deploying and calling smart contracts is paid for by the node, and
+		// is thus restricted to administrators. Contracts are deployed through
+		// POST /contract, as the router does not allow for a static segment
+		// such as /contract/deploy to sit alongside /contract/:id/call.
+
+		r.POST(g.prefix+"/contract", g.applyMiddleware(g.deployContract, "", g.adminScope, g.audited("contract.deploy")))
+		r.POST(g.prefix+"/contract/:id/call", g.applyMiddleware(g.callContract, "", g.adminScope, g.contractScope, g.audited("contract.call")))
+	}
 
 	g.router = r
 }
@@ -140,25 +246,22 @@ func (g *Gateway) setup() {
 // Apply base middleware to the handler and along with middleware passed.
 // If rateLimiterKey is not empty, enable rate limit.
 func (g *Gateway) applyMiddleware(f fasthttp.RequestHandler, rateLimiterKey string, m ...middleware) fasthttp.RequestHandler {
-	var list []middleware
+	list := []middleware{tagRequestID, recoverer}
 
-	if len(rateLimiterKey) == 0 {
-		list = []middleware{
-			recoverer,
-			cors(),
-		}
-	} else {
-		// Base middleware with rate limiter middleware.
-		// Rate limiter middleware should be after recoverer and before anything else
-		list = []middleware{
-			recoverer,
-			g.rateLimiter.limit(rateLimiterKey),
-			cors(),
-		}
+	// Compress responses only once they have been signed and fully written.
+	if g.compressionLevel != fasthttp.CompressNoCompression {
+		list = append(list, compress(g.compressionLevel))
+	}
+
+	// Rate limiter middleware should be after recoverer and compression, and before anything else.
+	if len(rateLimiterKey) > 0 {
+		list = append(list, g.rateLimiter.limit(rateLimiterKey))
 	}
 
+	list = append(list, cors())
+
 	if g.enableTimeout {
-		list = append(list, timeout(60*time.Second, "Request timeout!"))
+		list = append(list, timeout(60*time.Second, ErrTimeout(errors.New("request timeout"))))
 	}
 
 	if len(m) > 0 {
@@ -170,19 +273,40 @@ func (g *Gateway) applyMiddleware(f fasthttp.RequestHandler, rateLimiterKey stri
 	return chain(f, list)
 }
 
-func (g *Gateway) StartHTTP(port int, c *skademlia.Client, l *wavelet.Ledger, k *skademlia.Keypair) {
-	stop := g.rateLimiter.cleanup(10 * time.Minute)
-	defer stop()
-
+// Bind sets up the gateway to serve the HTTP API of ledger l, without starting
+// an HTTP server. The transactions the gateway creates and relays, and its
+// responses, are signed by s. Bound gateways may then be served together via
+// StartMultiHTTP.
+func (g *Gateway) Bind(c *skademlia.Client, l *wavelet.Ledger, s wallet.Signer) {
 	g.client = c
 	g.ledger = l
 
-	g.keys = k
+	g.signer = s
 
 	g.enableTimeout = false
 	g.setup()
+}
+
+// logs returns the scope of the ledger the gateway serves, such that logs of the
+// gateway are tagged with the name of the ledger.
+func (g *Gateway) logs() *log.Scope {
+	if g.ledger == nil {
+		return log.Root()
+	}
 
-	logger := log.Node()
+	return g.ledger.Logs()
+}
+
+func (g *Gateway) StartHTTP(port int, c *skademlia.Client, l *wavelet.Ledger, s wallet.Signer) {
+	stop := g.rateLimiter.cleanup(10 * time.Minute)
+	defer stop()
+
+	g.Bind(c, l, s)
+
+	stopReporting := g.reportSinkMetrics(1 * time.Second)
+	defer stopReporting()
+
+	logger := g.logs().Node()
 	logger.Info().Int("port", port).Msg("Started HTTP API server.")
 
 	g.server = &fasthttp.Server{
@@ -194,7 +318,13 @@ func (g *Gateway) StartHTTP(port int, c *skademlia.Client, l *wavelet.Ledger, k
 	}
 }
 
+// Shutdown flushes the events pending to be delivered to websocket clients,
+// disconnects them, and shuts down the HTTP server of the gateway.
 func (g *Gateway) Shutdown() {
+	for _, sink := range g.sinks {
+		sink.close()
+	}
+
 	if g.server == nil {
 		return
 	}
@@ -202,79 +332,193 @@ func (g *Gateway) Shutdown() {
 }
 
 func (g *Gateway) sendTransaction(ctx *fasthttp.RequestCtx) {
+	res, e := g.submitTransaction(ctx, ctx.PostBody())
+	if e != nil {
+		g.renderError(ctx, e)
+		return
+	}
+
+	g.render(ctx, res)
+}
+
+// submitTransaction adds the transaction described by body, in the format
+// accepted by /tx/send, to the graph.
+func (g *Gateway) submitTransaction(ctx *fasthttp.RequestCtx, body []byte) (*sendTransactionResponse, *errResponse) {
 	req := new(sendTransactionRequest)
 
+	if g.ledger != nil && !g.ledger.Mode().Participates() {
+		return nil, ErrBadRequest(wavelet.ErrReadOnly).withCode(CodeReadOnly).withDetail("mode", string(g.ledger.Mode()))
+	}
+
 	if g.ledger != nil && g.ledger.TakeSendQuota() == false {
-		g.renderError(ctx, ErrInternal(errors.New("rate limit")))
-		return
+		return nil, ErrUnavailable(errors.New("the node is accepting no more transactions for now")).withCode(CodeRateLimited)
 	}
 
 	parser := g.parserPool.Get()
-	err := req.bind(parser, ctx.PostBody())
+	err := req.bind(parser, body)
 	g.parserPool.Put(parser)
 
 	if err != nil {
-		g.renderError(ctx, ErrBadRequest(err))
-		return
+		return nil, ErrBadRequest(err)
 	}
 
-	tx := wavelet.AttachSenderToTransaction(
-		g.keys,
-		wavelet.Transaction{Tag: sys.Tag(req.Tag), Payload: req.payload, Creator: req.creator, CreatorSignature: req.signature},
+	tx, err := wavelet.AttachSignerToTransaction(
+		g.signer,
+		wavelet.Transaction{Nonce: req.Nonce, Tag: sys.Tag(req.Tag), Payload: req.payload, Creator: req.creator, CreatorSignature: req.signature, CreatorScheme: req.scheme},
 		g.ledger.Graph().FindEligibleParents()...,
 	)
+	if err != nil {
+		return nil, ErrInternal(err)
+	}
+
+	g.ledger.TraceTransaction(tx.ID, requestID(ctx))
 
 	err = g.ledger.AddTransaction(tx)
 
 	if err != nil && errors.Cause(err) != wavelet.ErrMissingParents {
-		g.renderError(ctx, ErrInternal(errors.Wrap(err, "error adding your transaction to graph")))
+		return nil, addTransactionError(err)
+	}
+
+	return &sendTransactionResponse{ledger: g.ledger, tx: &tx}, nil
+}
+
+// sendBatch adds every transaction in a batch to the graph, reporting whether
+// or not each one was accepted. A transaction being rejected does not prevent
+// the others from being accepted.
+func (g *Gateway) sendBatch(ctx *fasthttp.RequestCtx) {
+	req := new(sendBatchRequest)
+
+	if g.ledger != nil && !g.ledger.Mode().Participates() {
+		g.renderError(ctx, ErrBadRequest(wavelet.ErrReadOnly).withCode(CodeReadOnly).withDetail("mode", string(g.ledger.Mode())))
+		return
+	}
+
+	parser := g.parserPool.Get()
+	err := req.bind(parser, ctx.PostBody())
+	g.parserPool.Put(parser)
+
+	if err != nil {
+		g.renderError(ctx, ErrBadRequest(err))
 		return
 	}
 
-	g.render(ctx, &sendTransactionResponse{ledger: g.ledger, tx: &tx})
+	results := make([]sendBatchResult, len(req.txs))
+
+	for i := range req.txs {
+		if req.errs[i] != nil {
+			results[i].err = ErrBadRequest(req.errs[i])
+			continue
+		}
+
+		if !g.ledger.TakeSendQuota() {
+			results[i].err = ErrUnavailable(errors.New("the node is accepting no more transactions for now")).withCode(CodeRateLimited)
+			continue
+		}
+
+		tx, err := wavelet.AttachSignerToTransaction(
+			g.signer,
+			wavelet.Transaction{Nonce: req.txs[i].Nonce, Tag: sys.Tag(req.txs[i].Tag), Payload: req.txs[i].payload, Creator: req.txs[i].creator, CreatorSignature: req.txs[i].signature, CreatorScheme: req.txs[i].scheme},
+			g.ledger.Graph().FindEligibleParents()...,
+		)
+		if err != nil {
+			results[i].err = ErrInternal(err)
+			continue
+		}
+
+		g.ledger.TraceTransaction(tx.ID, requestID(ctx))
+
+		if err := g.ledger.AddTransaction(tx); err != nil && errors.Cause(err) != wavelet.ErrMissingParents {
+			results[i].err = addTransactionError(err)
+			continue
+		}
+
+		results[i].tx = &tx
+	}
+
+	g.render(ctx, &sendBatchResponse{ledger: g.ledger, results: results})
+}
+
+// addTransactionError describes why a transaction could not be added to the graph.
+func addTransactionError(err error) *errResponse {
+	if errors.Cause(err) == wavelet.ErrQueueFull {
+		return ErrTooManyRequests(errors.Wrap(err, "your transaction could not be broadcasted")).withCode(CodeQueueFull)
+	}
+
+	if errors.Cause(err) == wavelet.ErrGraphFull {
+		return ErrTooManyRequests(errors.Wrap(err, "your transaction could not be added to the graph")).withCode(CodeGraphFull)
+	}
+
+	return ErrInternal(errors.Wrap(err, "error adding your transaction to graph"))
 }
 
 func (g *Gateway) ledgerStatus(ctx *fasthttp.RequestCtx) {
-	g.render(ctx, &ledgerStatusResponse{client: g.client, ledger: g.ledger, publicKey: g.keys.PublicKey()})
+	if g.notModified(ctx) {
+		return
+	}
+
+	g.render(ctx, &ledgerStatusResponse{client: g.client, ledger: g.ledger, publicKey: g.signer.PublicKey()})
+}
+
+func (g *Gateway) networkStatus(ctx *fasthttp.RequestCtx) {
+	g.render(ctx, &networkStatusResponse{client: g.client, ledger: g.ledger})
+}
+
+func (g *Gateway) consensusStatus(ctx *fasthttp.RequestCtx) {
+	g.render(ctx, &consensusResponse{ledger: g.ledger})
+}
+
+func (g *Gateway) getMempool(ctx *fasthttp.RequestCtx) {
+	g.render(ctx, &mempoolResponse{snapshot: g.ledger.BroadcastQueue().Snapshot()})
 }
 
 func (g *Gateway) listTransactions(ctx *fasthttp.RequestCtx) {
 	var sender wavelet.AccountID
 	var creator wavelet.AccountID
-	var offset, limit uint64
-	var err error
 
 	queryArgs := ctx.QueryArgs()
 	if raw := string(queryArgs.Peek("sender")); len(raw) > 0 {
-		slice, err := hex.DecodeString(raw)
+		id, err := wavelet.ParseAccountID(raw)
 		if err != nil {
-			g.renderError(ctx, ErrBadRequest(errors.Wrap(err, "sender ID must be presented as valid hex")))
-			return
-		}
-
-		if len(slice) != wavelet.SizeAccountID {
-			g.renderError(ctx, ErrBadRequest(errors.Errorf("sender ID must be %d bytes long", wavelet.SizeAccountID)))
+			g.renderError(ctx, ErrBadRequest(errors.Wrap(err, "invalid sender ID")))
 			return
 		}
 
-		copy(sender[:], slice)
+		sender = id
 	}
 
 	if raw := string(queryArgs.Peek("creator")); len(raw) > 0 {
-		slice, err := hex.DecodeString(raw)
+		id, err := wavelet.ParseAccountID(raw)
 		if err != nil {
-			g.renderError(ctx, ErrBadRequest(errors.Wrap(err, "creator ID must be presented as valid hex")))
+			g.renderError(ctx, ErrBadRequest(errors.Wrap(err, "invalid creator ID")))
 			return
 		}
 
-		if len(slice) != wavelet.SizeAccountID {
-			g.renderError(ctx, ErrBadRequest(errors.Errorf("creator ID must be %d bytes long", wavelet.SizeAccountID)))
-			return
-		}
+		creator = id
+	}
 
-		copy(creator[:], slice)
+	g.renderTransactions(ctx, sender, creator)
+}
+
+// listAccountTransactions lists the transactions sent or created by the account
+// specified by the "id" route parameter, paginated the same way as /tx.
+func (g *Gateway) listAccountTransactions(ctx *fasthttp.RequestCtx) {
+	id, ok := g.parseAccountID(ctx)
+	if !ok {
+		return
 	}
 
+	g.renderTransactions(ctx, id, id)
+}
+
+// renderTransactions renders a page of the transactions sent by sender or created
+// by creator, paginated either by the "offset" and "limit" query parameters or by
+// the "cursor" and "limit" query parameters.
+func (g *Gateway) renderTransactions(ctx *fasthttp.RequestCtx, sender, creator wavelet.AccountID) {
+	var offset, limit uint64
+	var err error
+
+	queryArgs := ctx.QueryArgs()
+
 	if raw := string(queryArgs.Peek("offset")); len(raw) > 0 {
 		offset, err = strconv.ParseUint(raw, 10, 64)
 
@@ -297,6 +541,16 @@ func (g *Gateway) listTransactions(ctx *fasthttp.RequestCtx) {
 		limit = maxPaginationLimit
 	}
 
+	if queryArgs.Has("cursor") {
+		if queryArgs.Has("offset") {
+			g.renderError(ctx, ErrBadRequest(errors.New("offset may not be specified alongside a cursor")))
+			return
+		}
+
+		g.listTransactionsAfter(ctx, string(queryArgs.Peek("cursor")), limit, sender, creator)
+		return
+	}
+
 	rootDepth := g.ledger.Graph().RootDepth()
 
 	var transactions transactionList
@@ -314,40 +568,124 @@ func (g *Gateway) listTransactions(ctx *fasthttp.RequestCtx) {
 	g.render(ctx, transactions)
 }
 
-func (g *Gateway) getTransaction(ctx *fasthttp.RequestCtx) {
-	param, ok := ctx.UserValue("id").(string)
+// listTransactionsAfter renders a page of at most limit transactions which come
+// after the position marked by the cursor raw, alongside a cursor to the next page.
+// An empty cursor starts listing from the deepest transaction in the graph.
+func (g *Gateway) listTransactionsAfter(ctx *fasthttp.RequestCtx, raw string, limit uint64, sender, creator wavelet.AccountID) {
+	if limit == 0 {
+		limit = maxPaginationLimit
+	}
+
+	txs, next, err := pageTransactions(g.ledger, raw, limit, sender, creator)
+	if err != nil {
+		g.renderError(ctx, ErrBadRequest(err))
+		return
+	}
+
+	page := &transactionPage{nextCursor: next}
+
+	rootDepth := g.ledger.Graph().RootDepth()
+
+	for _, tx := range txs {
+		status := "received"
+
+		if tx.Depth <= rootDepth {
+			status = "applied"
+		}
+
+		page.transactions = append(page.transactions, &transaction{tx: tx, status: status})
+	}
+
+	g.render(ctx, page)
+}
+
+func (g *Gateway) listConflicts(ctx *fasthttp.RequestCtx) {
+	var creator wavelet.AccountID
+
+	if raw := string(ctx.QueryArgs().Peek("creator")); len(raw) > 0 {
+		id, err := wavelet.ParseAccountID(raw)
+		if err != nil {
+			g.renderError(ctx, ErrBadRequest(errors.Wrap(err, "invalid creator ID")))
+			return
+		}
+
+		creator = id
+	}
+
+	g.render(ctx, conflictList(g.ledger.Graph().Conflicts(creator)))
+}
+
+func (g *Gateway) listForks(ctx *fasthttp.RequestCtx) {
+	forks, err := g.ledger.Forks()
+	if err != nil {
+		g.renderError(ctx, ErrInternal(err))
+		return
+	}
+
+	g.render(ctx, forkList(forks))
+}
+
+func (g *Gateway) getRoundCertificate(ctx *fasthttp.RequestCtx) {
+	param, ok := ctx.UserValue("index").(string)
 	if !ok {
-		g.renderError(ctx, ErrBadRequest(errors.New("id must be a string")))
+		g.renderError(ctx, ErrBadRequest(errors.New("index must be a string")))
 		return
 	}
 
-	slice, err := hex.DecodeString(param)
+	index, err := strconv.ParseUint(param, 10, 64)
 	if err != nil {
-		g.renderError(ctx, ErrBadRequest(errors.Wrap(err, "transaction ID must be presented as valid hex")))
+		g.renderError(ctx, ErrBadRequest(errors.Wrap(err, "could not parse round index")))
 		return
 	}
 
-	if len(slice) != wavelet.SizeTransactionID {
-		g.renderError(ctx, ErrBadRequest(errors.Errorf("transaction ID must be %d bytes long", wavelet.SizeTransactionID)))
+	cert, err := g.ledger.Certificate(index)
+	if err != nil {
+		g.renderError(ctx, ErrNotFound(err))
 		return
 	}
 
-	var id wavelet.TransactionID
-	copy(id[:], slice)
+	g.render(ctx, &certificate{cert: cert})
+}
 
-	tx := g.ledger.Graph().FindTransaction(id)
+func (g *Gateway) getTransaction(ctx *fasthttp.RequestCtx) {
+	tx := g.findTransaction(ctx)
+	if tx == nil {
+		return
+	}
+
+	g.render(ctx, g.newTransaction(tx))
+}
+
+// newTransaction renders tx, alongside whether it has been applied.
+func (g *Gateway) newTransaction(tx *wavelet.Transaction) *transaction {
+	res := &transaction{tx: tx}
+
+	if tx.Depth <= g.ledger.Graph().RootDepth() {
+		res.status = "applied"
+	} else {
+		res.status = "received"
+	}
+
+	return res
+}
 
+func (g *Gateway) getRawTransaction(ctx *fasthttp.RequestCtx) {
+	tx := g.findTransaction(ctx)
 	if tx == nil {
-		g.renderError(ctx, ErrNotFound(errors.Errorf("could not find transaction with ID %x", id)))
 		return
 	}
 
 	rootDepth := g.ledger.Graph().RootDepth()
 
-	res := &transaction{tx: tx}
+	res := &rawTransaction{transaction: transaction{tx: tx}}
 
 	if tx.Depth <= rootDepth {
 		res.status = "applied"
+
+		if round, err := g.ledger.Rounds().GetByDepth(tx.Depth); err == nil {
+			res.round = round
+			res.difficulty = g.ledger.Difficulty(round)
+		}
 	} else {
 		res.status = "received"
 	}
@@ -355,7 +693,213 @@ func (g *Gateway) getTransaction(ctx *fasthttp.RequestCtx) {
 	g.render(ctx, res)
 }
 
+func (g *Gateway) getTransactionStatus(ctx *fasthttp.RequestCtx) {
+	id, ok := g.parseTransactionID(ctx)
+	if !ok {
+		return
+	}
+
+	g.render(ctx, &transactionStatus{id: id, state: g.ledger.TransactionStatus(id)})
+}
+
+// getTransactionLogs renders the events emitted by smart contracts while
+// applying a finalized transaction.
+func (g *Gateway) getTransactionLogs(ctx *fasthttp.RequestCtx) {
+	id, ok := g.parseTransactionID(ctx)
+	if !ok {
+		return
+	}
+
+	events, err := g.ledger.ContractEvents(id)
+	if err != nil {
+		g.renderError(ctx, ErrInternal(errors.Wrap(err, "failed to read contract events")))
+		return
+	}
+
+	g.render(ctx, contractEventList(events))
+}
+
+// parseTransactionID parses the transaction ID specified by the "id" route
+// parameter, rendering an error and returning false should it be invalid.
+func (g *Gateway) parseTransactionID(ctx *fasthttp.RequestCtx) (wavelet.TransactionID, bool) {
+	var id wavelet.TransactionID
+
+	param, ok := ctx.UserValue("id").(string)
+	if !ok {
+		g.renderError(ctx, ErrBadRequest(errors.New("id must be a string")))
+		return id, false
+	}
+
+	slice, err := hex.DecodeString(param)
+	if err != nil {
+		g.renderError(ctx, ErrBadRequest(errors.Wrap(err, "transaction ID must be presented as valid hex")))
+		return id, false
+	}
+
+	if len(slice) != wavelet.SizeTransactionID {
+		g.renderError(ctx, ErrBadRequest(errors.Errorf("transaction ID must be %d bytes long", wavelet.SizeTransactionID)))
+		return id, false
+	}
+
+	copy(id[:], slice)
+
+	return id, true
+}
+
+// findTransaction looks up the transaction specified by the "id" route parameter,
+// rendering an error and returning nil should it be invalid or not be found.
+func (g *Gateway) findTransaction(ctx *fasthttp.RequestCtx) *wavelet.Transaction {
+	id, ok := g.parseTransactionID(ctx)
+	if !ok {
+		return nil
+	}
+
+	tx, e := g.lookupTransaction(id)
+	if e != nil {
+		g.renderError(ctx, e)
+		return nil
+	}
+
+	return tx
+}
+
+// lookupTransaction looks up a transaction in the graph, or amongst the
+// transactions the graph has archived.
+func (g *Gateway) lookupTransaction(id wavelet.TransactionID) (*wavelet.Transaction, *errResponse) {
+	tx := g.ledger.Graph().FindTransaction(id)
+
+	if tx == nil {
+		tx = g.ledger.Graph().FindArchivedTransaction(id)
+	}
+
+	if tx == nil {
+		return nil, ErrNotFound(errors.Errorf("could not find transaction with ID %x", id))
+	}
+
+	return tx, nil
+}
+
 func (g *Gateway) getAccount(ctx *fasthttp.RequestCtx) {
+	id, ok := g.parseAccountID(ctx)
+	if !ok {
+		return
+	}
+
+	if g.notModified(ctx) {
+		return
+	}
+
+	if !ctx.QueryArgs().Has("round") {
+		g.render(ctx, &account{ledger: g.ledger, id: id})
+		return
+	}
+
+	round, err := ctx.QueryArgs().GetUint("round")
+	if err != nil {
+		g.renderError(ctx, ErrBadRequest(errors.Wrap(err, "could not parse round")))
+		return
+	}
+
+	if g.ledger.History() == nil {
+		g.renderError(ctx, ErrBadRequest(errors.New("querying accounts as of a past round is only supported by archival nodes")))
+		return
+	}
+
+	if latest := g.ledger.Rounds().Latest().Index; uint64(round) > latest {
+		g.renderError(ctx, ErrBadRequest(errors.Errorf("round %d has not yet been finalized; the latest round is %d", round, latest)))
+		return
+	}
+
+	state, exists := g.ledger.History().Lookup(id, uint64(round))
+	if !exists {
+		g.renderError(ctx, ErrNotFound(errors.Errorf("could not find account %x as of round %d", id, round)))
+		return
+	}
+
+	g.render(ctx, &account{ledger: g.ledger, id: id, state: &state})
+}
+
+func (g *Gateway) getAccountHistory(ctx *fasthttp.RequestCtx) {
+	id, ok := g.parseAccountID(ctx)
+	if !ok {
+		return
+	}
+
+	if g.notModified(ctx) {
+		return
+	}
+
+	var offset, limit uint64
+	var err error
+
+	queryArgs := ctx.QueryArgs()
+
+	if raw := string(queryArgs.Peek("offset")); len(raw) > 0 {
+		offset, err = strconv.ParseUint(raw, 10, 64)
+
+		if err != nil {
+			g.renderError(ctx, ErrBadRequest(errors.Wrap(err, "could not parse offset")))
+			return
+		}
+	}
+
+	if raw := string(queryArgs.Peek("limit")); len(raw) > 0 {
+		limit, err = strconv.ParseUint(raw, 10, 64)
+
+		if err != nil {
+			g.renderError(ctx, ErrBadRequest(errors.Wrap(err, "could not parse limit")))
+			return
+		}
+	}
+
+	if limit == 0 || limit > maxPaginationLimit {
+		limit = maxPaginationLimit
+	}
+
+	if g.ledger.History() == nil {
+		g.renderError(ctx, ErrBadRequest(errors.New("balance-change journals are only recorded by archival nodes")))
+		return
+	}
+
+	entries, err := g.ledger.History().Journal(id, offset, limit)
+	if err != nil {
+		g.renderError(ctx, ErrInternal(err))
+		return
+	}
+
+	g.render(ctx, &accountHistory{total: g.ledger.History().JournalLen(id), entries: entries})
+}
+
+func (g *Gateway) getAccountRecovery(ctx *fasthttp.RequestCtx) {
+	id, ok := g.parseAccountID(ctx)
+	if !ok {
+		return
+	}
+
+	if g.notModified(ctx) {
+		return
+	}
+
+	snapshot := g.ledger.Snapshot()
+
+	res := &accountRecovery{}
+
+	if guardians, exists := wavelet.ReadAccountGuardians(snapshot, id); exists {
+		res.guardians = &guardians
+	}
+
+	if pending, exists := wavelet.ReadAccountRecovery(snapshot, id); exists {
+		res.pending = &pending
+	}
+
+	if recoveredTo, exists := wavelet.ReadAccountRecoveredTo(snapshot, id); exists {
+		res.recoveredTo = &recoveredTo
+	}
+
+	g.render(ctx, res)
+}
+
+func (g *Gateway) getProposal(ctx *fasthttp.RequestCtx) {
 	param, ok := ctx.UserValue("id").(string)
 	if !ok {
 		g.renderError(ctx, ErrBadRequest(errors.New("id must be a string")))
@@ -364,19 +908,176 @@ func (g *Gateway) getAccount(ctx *fasthttp.RequestCtx) {
 
 	slice, err := hex.DecodeString(param)
 	if err != nil {
-		g.renderError(ctx, ErrBadRequest(errors.Wrap(err, "account ID must be presented as valid hex")))
+		g.renderError(ctx, ErrBadRequest(errors.Wrap(err, "proposal ID must be presented as valid hex")))
 		return
 	}
 
-	if len(slice) != wavelet.SizeAccountID {
-		g.renderError(ctx, ErrBadRequest(errors.Errorf("account ID must be %d bytes long", wavelet.SizeAccountID)))
+	if len(slice) != wavelet.SizeTransactionID {
+		g.renderError(ctx, ErrBadRequest(errors.Errorf("proposal ID must be %d bytes long", wavelet.SizeTransactionID)))
 		return
 	}
 
-	var id wavelet.AccountID
+	var id wavelet.TransactionID
 	copy(id[:], slice)
 
-	g.render(ctx, &account{ledger: g.ledger, id: id})
+	proposal, exists := wavelet.ReadProposal(g.ledger.Snapshot(), id)
+	if !exists {
+		g.renderError(ctx, ErrNotFound(errors.Errorf("could not find proposal with ID %x", id)))
+		return
+	}
+
+	g.render(ctx, &proposalResponse{id: id, proposal: proposal})
+}
+
+func (g *Gateway) exportAccounts(ctx *fasthttp.RequestCtx) {
+	queryArgs := ctx.QueryArgs()
+
+	format := wavelet.ExportCSV
+
+	if raw := string(queryArgs.Peek("format")); len(raw) > 0 {
+		var err error
+
+		if format, err = wavelet.ParseExportFormat(raw); err != nil {
+			g.renderError(ctx, ErrBadRequest(err))
+			return
+		}
+	}
+
+	var round *uint64
+
+	if queryArgs.Has("round") {
+		index, err := queryArgs.GetUint("round")
+		if err != nil {
+			g.renderError(ctx, ErrBadRequest(errors.Wrap(err, "could not parse round")))
+			return
+		}
+
+		if g.ledger.History() == nil {
+			g.renderError(ctx, ErrBadRequest(errors.New("exporting accounts as of a past round is only supported by archival nodes")))
+			return
+		}
+
+		if latest := g.ledger.Rounds().Latest().Index; uint64(index) > latest {
+			g.renderError(ctx, ErrBadRequest(errors.Errorf("round %d has not yet been finalized; the latest round is %d", index, latest)))
+			return
+		}
+
+		r := uint64(index)
+		round = &r
+	}
+
+	snapshot := g.ledger.Snapshot()
+	history := g.ledger.History()
+
+	switch format {
+	case wavelet.ExportCSV:
+		ctx.SetContentType("text/csv")
+	case wavelet.ExportJSON:
+		ctx.SetContentType("application/json")
+	}
+
+	logger := requestLogger(ctx, g.logs().Node())
+
+	ctx.SetBodyStreamWriter(func(w *bufio.Writer) {
+		if err := wavelet.ExportAccounts(w, format, snapshot, history, round); err != nil {
+			logger.Warn().Err(err).Msg("Failed to stream exported accounts.")
+		}
+	})
+}
+
+func (g *Gateway) exportGraph(ctx *fasthttp.RequestCtx) {
+	queryArgs := ctx.QueryArgs()
+
+	format := wavelet.GraphDOT
+
+	if raw := string(queryArgs.Peek("format")); len(raw) > 0 {
+		var err error
+
+		if format, err = wavelet.ParseGraphFormat(raw); err != nil {
+			g.renderError(ctx, ErrBadRequest(err))
+			return
+		}
+	}
+
+	var depths uint64
+
+	if queryArgs.Has("depths") {
+		n, err := queryArgs.GetUint("depths")
+		if err != nil {
+			g.renderError(ctx, ErrBadRequest(errors.Wrap(err, "could not parse depths")))
+			return
+		}
+
+		depths = uint64(n)
+	}
+
+	graph := g.ledger.Graph()
+	difficulty := g.ledger.Difficulty(g.ledger.Rounds().Latest())
+
+	switch format {
+	case wavelet.GraphDOT:
+		ctx.SetContentType("text/vnd.graphviz")
+	case wavelet.GraphJSON:
+		ctx.SetContentType("application/json")
+	}
+
+	logger := requestLogger(ctx, g.logs().Node())
+
+	ctx.SetBodyStreamWriter(func(w *bufio.Writer) {
+		if err := wavelet.ExportGraph(w, format, graph, depths, difficulty); err != nil {
+			logger.Warn().Err(err).Msg("Failed to stream exported graph.")
+		}
+	})
+}
+
+// parseAccountID parses the account ID specified by the "id" route parameter,
+// rendering an error and returning false should it be invalid.
+func (g *Gateway) parseAccountID(ctx *fasthttp.RequestCtx) (wavelet.AccountID, bool) {
+	var id wavelet.AccountID
+
+	param, ok := ctx.UserValue("id").(string)
+	if !ok {
+		g.renderError(ctx, ErrBadRequest(errors.New("id must be a string")))
+		return id, false
+	}
+
+	id, err := wavelet.ParseAccountID(param)
+	if err != nil {
+		g.renderError(ctx, ErrBadRequest(err))
+		return id, false
+	}
+
+	return id, true
+}
+
+func (g *Gateway) listValidators(ctx *fasthttp.RequestCtx) {
+	if g.notModified(ctx) {
+		return
+	}
+
+	snapshot := g.ledger.Snapshot()
+
+	var validators validatorList
+	var totalStake uint64
+
+	minimumStake := g.ledger.Param(sys.ParamMinimumStake)
+
+	wavelet.IterateAccountStakes(snapshot, func(id wavelet.AccountID, stake uint64) {
+		if stake == 0 {
+			return
+		}
+
+		reward, _ := wavelet.ReadAccountReward(snapshot, id)
+
+		validators = append(validators, &validator{id: id, stake: stake, reward: reward})
+		totalStake += wavelet.VotingStake(stake, minimumStake)
+	})
+
+	for _, v := range validators {
+		v.weight = float64(wavelet.VotingStake(v.stake, minimumStake)) / float64(totalStake)
+	}
+
+	g.render(ctx, validators)
 }
 
 func (g *Gateway) contractScope(next fasthttp.RequestHandler) fasthttp.RequestHandler {
@@ -481,8 +1182,7 @@ func (g *Gateway) notFound() func(ctx *fasthttp.RequestCtx) {
 	methods := []string{"GET", "POST", "PUT", "DELETE", "PATCH"}
 
 	notFoundHandler := func(ctx *fasthttp.RequestCtx) {
-		ctx.Error(fasthttp.StatusMessage(fasthttp.StatusNotFound),
-			fasthttp.StatusNotFound)
+		g.renderError(ctx, ErrNotFound(errors.Errorf("no route exists for %s %s", ctx.Method(), ctx.Path())))
 	}
 
 	// This cors is only for OPTIONS, so we can pass any handler since it will not be triggered.
@@ -512,15 +1212,49 @@ func (g *Gateway) notFound() func(ctx *fasthttp.RequestCtx) {
 	}
 }
 
+// poll upgrades requests into websocket connections which stream the events
+// of sink. Should websocket authentication be enabled, a token must either be
+// specified through the "token" query parameter, or sent as the first message
+// after upgrading. No events are delivered until the token is redeemed.
+//
+// Should websocket limits be configured, connections beyond the limits of the
+// IP they originate from are rejected before being upgraded.
 func (g *Gateway) poll(sink *sink) func(ctx *fasthttp.RequestCtx) {
 	return func(ctx *fasthttp.RequestCtx) {
-		if err := sink.serve(ctx); err != nil {
+		session := wsSession{auth: g.wsAuth}
+
+		if g.wsLimiter != nil {
+			release, e := g.wsLimiter.acquire(ctx.RemoteIP().String(), sink.name)
+			if e != nil {
+				g.renderError(ctx, e)
+				return
+			}
+
+			session.release = release
+		}
+
+		if token := ctx.QueryArgs().Peek("token"); g.wsAuth != nil && len(token) > 0 {
+			p, err := g.wsAuth.redeem(sink.name, string(token))
+			if err != nil {
+				session.close()
+				g.renderError(ctx, ErrUnauthorized(err))
+				return
+			}
+
+			session.permissions = &p
+		}
+
+		if err := sink.serve(ctx, session); err != nil {
+			session.close()
 			g.renderError(ctx, ErrBadRequest(errors.Wrap(err, "failed to init websocket session")))
 		}
 	}
 }
 
-func (g *Gateway) registerWebsocketSink(rawURL string, factory *debounce.Factory) *sink {
+// registerWebsocketSink registers a websocket sink whose events are debounced
+// by debouncer by default. Should the sink not be debounced by default, then
+// debouncer is nil.
+func (g *Gateway) registerWebsocketSink(rawURL string, debouncer *SinkDebouncer) *sink {
 	u, err := url.Parse(rawURL)
 	if err != nil {
 		panic(err)
@@ -535,15 +1269,23 @@ func (g *Gateway) registerWebsocketSink(rawURL string, factory *debounce.Factory
 	}
 
 	sink := &sink{
+		name:      u.Hostname(),
 		filters:   filters,
 		broadcast: make(chan broadcastItem),
 		join:      make(chan *client),
 		leave:     make(chan *client),
+		shutdown:  make(chan chan struct{}),
 		clients:   make(map[*client]struct{}),
+		metrics:   newSinkMetrics(g.wsMetrics, u.Hostname()),
+	}
+
+	if cfg, overridden := g.debouncers[sink.name]; overridden {
+		debouncer = &cfg
 	}
 
-	if factory != nil {
-		sink.debouncer = factory.Init(context.Background(), debounce.WithAction(sink.debounce))
+	if debouncer != nil {
+		sink.debouncer = debouncer.factory(filters).Init(context.Background(), debounce.WithAction(sink.debounce))
+		sink.priority = debouncer.priorityEvents()
 	}
 
 	go sink.run()
@@ -566,6 +1308,13 @@ func (g *Gateway) Write(buf []byte) (n int, err error) {
 		return n, errors.Errorf("all logs must have the field %q", log.KeyModule)
 	}
 
+	// Should several ledgers be hosted in the same process, only stream logs
+	// that were emitted by the ledger this gateway is serving.
+
+	if ledger := v.GetStringBytes(log.KeyLedger); ledger != nil && g.ledger != nil && string(ledger) != g.ledger.Name() {
+		return len(buf), nil
+	}
+
 	sink, exists := g.sinks[string(mod)]
 	if !exists {
 		return len(buf), nil
@@ -582,29 +1331,29 @@ func (g *Gateway) Write(buf []byte) (n int, err error) {
 func (g *Gateway) render(ctx *fasthttp.RequestCtx, m marshalableJSON) {
 	arena := g.arenaPool.Get()
 	b, err := m.marshalJSON(arena)
-	g.arenaPool.Put(arena)
 
 	if err != nil {
-		ctx.Error(fmt.Sprintf(`{ "error": "render error: %s" }`, err.Error()), http.StatusInternalServerError)
+		writeError(ctx, arena, ErrInternal(errors.Wrap(err, "render error")))
+		g.arenaPool.Put(arena)
 		return
 	}
 
+	g.arenaPool.Put(arena)
+
+	g.sign(ctx, b)
+
 	ctx.SetContentType("application/json")
 	ctx.Response.SetStatusCode(http.StatusOK)
 	ctx.Response.SetBody(b)
 }
 
 func (g *Gateway) renderError(ctx *fasthttp.RequestCtx, e *errResponse) {
-	arena := g.arenaPool.Get()
-	b, err := e.marshalJSON(arena)
-	g.arenaPool.Put(arena)
-
-	if err != nil {
-		ctx.Error(fmt.Sprintf(`{ "error": "render error: %s" |`, err.Error()), http.StatusInternalServerError)
-		return
+	if e.HTTPStatusCode >= http.StatusInternalServerError {
+		logger := requestLogger(ctx, g.logs().Node())
+		logger.Warn().Err(e.Err).Str("code", string(e.Code)).Msg("Failed to serve API request.")
 	}
 
-	ctx.SetContentType("application/json")
-	ctx.Response.SetStatusCode(e.HTTPStatusCode)
-	ctx.Response.SetBody(b)
+	arena := g.arenaPool.Get()
+	writeError(ctx, arena, e)
+	g.arenaPool.Put(arena)
 }
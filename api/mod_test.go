@@ -22,6 +22,7 @@ package api
 import (
 	"bufio"
 	"bytes"
+	"compress/gzip"
 	"crypto/rand"
 	"encoding/hex"
 	"encoding/json"
@@ -31,6 +32,7 @@ import (
 	"github.com/perlin-network/wavelet"
 	"github.com/perlin-network/wavelet/store"
 	"github.com/perlin-network/wavelet/sys"
+	"github.com/perlin-network/wavelet/wallet"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/valyala/fasthttp"
@@ -41,6 +43,7 @@ import (
 	"net/http/httptest"
 	"net/http/httputil"
 	"strconv"
+	"strings"
 	"testing"
 	"testing/quick"
 	"time"
@@ -83,8 +86,8 @@ func TestListTransaction(t *testing.T) {
 			url:      "/tx?sender=1",
 			wantCode: http.StatusBadRequest,
 			wantResponse: testErrResponse{
-				StatusText: "Bad request.",
-				ErrorText:  "sender ID must be presented as valid hex: encoding/hex: odd length hex string",
+				Code:    CodeBadRequest,
+				Message: `invalid sender ID: account ID must be presented as valid hex, or as a bech32 address prefixed with "twav1": encoding/hex: odd length hex string`,
 			},
 		},
 		{
@@ -92,8 +95,8 @@ func TestListTransaction(t *testing.T) {
 			url:      "/tx?sender=746c703579786279793638626e726a77666574656c6d34386d6739306b7166306565",
 			wantCode: http.StatusBadRequest,
 			wantResponse: testErrResponse{
-				StatusText: "Bad request.",
-				ErrorText:  "sender ID must be 32 bytes long",
+				Code:    CodeBadRequest,
+				Message: "invalid sender ID: account ID is of an invalid size",
 			},
 		},
 		{
@@ -101,8 +104,8 @@ func TestListTransaction(t *testing.T) {
 			url:      "/tx?creator=1",
 			wantCode: http.StatusBadRequest,
 			wantResponse: testErrResponse{
-				StatusText: "Bad request.",
-				ErrorText:  "creator ID must be presented as valid hex: encoding/hex: odd length hex string",
+				Code:    CodeBadRequest,
+				Message: `invalid creator ID: account ID must be presented as valid hex, or as a bech32 address prefixed with "twav1": encoding/hex: odd length hex string`,
 			},
 		},
 		{
@@ -110,8 +113,8 @@ func TestListTransaction(t *testing.T) {
 			url:      "/tx?creator=746c703579786279793638626e726a77666574656c6d34386d6739306b7166306565",
 			wantCode: http.StatusBadRequest,
 			wantResponse: testErrResponse{
-				StatusText: "Bad request.",
-				ErrorText:  "creator ID must be 32 bytes long",
+				Code:    CodeBadRequest,
+				Message: "invalid creator ID: account ID is of an invalid size",
 			},
 		},
 		{
@@ -119,8 +122,8 @@ func TestListTransaction(t *testing.T) {
 			url:      "/tx?creator=1",
 			wantCode: http.StatusBadRequest,
 			wantResponse: testErrResponse{
-				StatusText: "Bad request.",
-				ErrorText:  "creator ID must be presented as valid hex: encoding/hex: odd length hex string",
+				Code:    CodeBadRequest,
+				Message: `invalid creator ID: account ID must be presented as valid hex, or as a bech32 address prefixed with "twav1": encoding/hex: odd length hex string`,
 			},
 		},
 		{
@@ -167,6 +170,49 @@ func TestListTransaction(t *testing.T) {
 	}
 }
 
+func TestListAccountTransactions(t *testing.T) {
+	gateway := New()
+	gateway.setup()
+
+	gateway.ledger = createLedger(t)
+
+	keys, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	tx := wavelet.AttachSenderToTransaction(keys, wavelet.NewTransaction(keys, sys.TagNop, nil), gateway.ledger.Graph().FindEligibleParents()...)
+	assert.NoError(t, gateway.ledger.Graph().AddTransaction(tx))
+
+	list := func(url string) []map[string]interface{} {
+		w, err := serve(gateway.router, httptest.NewRequest("GET", "http://localhost"+url, nil))
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, w.StatusCode)
+
+		response, err := ioutil.ReadAll(w.Body)
+		assert.NoError(t, err)
+
+		var txs []map[string]interface{}
+		assert.NoError(t, json.Unmarshal(response, &txs))
+
+		return txs
+	}
+
+	txs := list("/accounts/" + hex.EncodeToString(tx.Creator[:]) + "/transactions")
+	if assert.Len(t, txs, 1) {
+		assert.Equal(t, hex.EncodeToString(tx.ID[:]), txs[0]["id"])
+	}
+
+	assert.Len(t, list("/accounts/"+hex.EncodeToString(tx.Creator[:])+"/transactions?offset=1"), 0)
+	assert.Len(t, list("/accounts/"+hex.EncodeToString(make([]byte, wavelet.SizeAccountID-1))+"01/transactions"), 0)
+
+	w, err := serve(gateway.router, httptest.NewRequest("GET", "http://localhost/accounts/1/transactions", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, w.StatusCode)
+
+	w, err = serve(gateway.router, httptest.NewRequest("GET", "http://localhost/accounts/"+hex.EncodeToString(tx.Creator[:])+"/transactions?cursor=&offset=1", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, w.StatusCode)
+}
+
 func TestGetTransaction(t *testing.T) {
 	gateway := New()
 	gateway.setup()
@@ -207,8 +253,8 @@ func TestGetTransaction(t *testing.T) {
 			id:       "1c331c1d",
 			wantCode: http.StatusBadRequest,
 			wantResponse: &testErrResponse{
-				StatusText: "Bad request.",
-				ErrorText:  fmt.Sprintf("transaction ID must be %d bytes long", wavelet.SizeTransactionID),
+				Code:    CodeBadRequest,
+				Message: fmt.Sprintf("transaction ID must be %d bytes long", wavelet.SizeTransactionID),
 			},
 		},
 		{
@@ -242,6 +288,94 @@ func TestGetTransaction(t *testing.T) {
 	}
 }
 
+func TestGetTransactionStatus(t *testing.T) {
+	gateway := New()
+	gateway.setup()
+
+	gateway.ledger = createLedger(t)
+
+	keys, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	tx := wavelet.AttachSenderToTransaction(keys, wavelet.NewTransaction(keys, sys.TagNop, nil), gateway.ledger.Graph().FindEligibleParents()...)
+	assert.NoError(t, gateway.ledger.Graph().AddTransaction(tx))
+
+	status := func(id string) map[string]interface{} {
+		w, err := serve(gateway.router, httptest.NewRequest("GET", "http://localhost/tx/"+id+"/status", nil))
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, w.StatusCode)
+
+		response, err := ioutil.ReadAll(w.Body)
+		assert.NoError(t, err)
+
+		var res map[string]interface{}
+		assert.NoError(t, json.Unmarshal(response, &res))
+
+		return res
+	}
+
+	res := status(hex.EncodeToString(tx.ID[:]))
+	assert.Equal(t, hex.EncodeToString(tx.ID[:]), res["id"])
+	assert.Equal(t, "in_graph", res["status"])
+	assert.NotContains(t, res, "round")
+
+	res = status(hex.EncodeToString(make([]byte, wavelet.SizeTransactionID)))
+	assert.Equal(t, "unknown", res["status"])
+
+	w, err := serve(gateway.router, httptest.NewRequest("GET", "http://localhost/tx/1/status", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, w.StatusCode)
+}
+
+func TestGetTransactionLogs(t *testing.T) {
+	gateway := New()
+	gateway.setup()
+
+	gateway.ledger = createLedger(t)
+
+	w, err := serve(gateway.router, httptest.NewRequest("GET", "http://localhost/tx/"+hex.EncodeToString(make([]byte, wavelet.SizeTransactionID))+"/logs", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, w.StatusCode)
+
+	response, err := ioutil.ReadAll(w.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, "[]", string(response))
+
+	w, err = serve(gateway.router, httptest.NewRequest("GET", "http://localhost/tx/1/logs", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, w.StatusCode)
+}
+
+func TestGetRawTransaction(t *testing.T) {
+	gateway := New()
+	gateway.setup()
+
+	gateway.ledger = createLedger(t)
+
+	round := gateway.ledger.Rounds().Latest()
+
+	request, err := http.NewRequest("GET", "http://localhost/tx/"+hex.EncodeToString(round.End.ID[:])+"/raw", nil)
+	assert.NoError(t, err)
+
+	w, err := serve(gateway.router, request)
+	assert.NoError(t, err)
+	assert.NotNil(t, w)
+
+	response, err := ioutil.ReadAll(w.Body)
+	assert.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, w.StatusCode, "status code")
+
+	v, err := fastjson.ParseBytes(response)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "applied", string(v.GetStringBytes("status")))
+	assert.Equal(t, hex.EncodeToString(round.End.Marshal()), string(v.GetStringBytes("raw")))
+	assert.Equal(t, hex.EncodeToString(round.End.Seed[:]), string(v.GetStringBytes("seed")))
+	assert.Equal(t, hex.EncodeToString(round.ID[:]), string(v.GetStringBytes("round", "id")))
+	assert.Equal(t, hex.EncodeToString(round.Merkle[:]), string(v.GetStringBytes("round", "merkle_root")))
+}
+
 func TestSendTransaction(t *testing.T) {
 	gateway := New()
 	gateway.setup()
@@ -276,6 +410,51 @@ func TestSendTransaction(t *testing.T) {
 	}
 }
 
+func TestSendBatch(t *testing.T) {
+	gateway := New()
+	gateway.setup()
+
+	tooMany := `{"transactions": [` + strings.Repeat(`{},`, MaxBatchSize) + `{}]}`
+
+	tests := []struct {
+		name     string
+		body     string
+		wantCode int
+		wantBody string
+	}{
+		{name: "invalid json", body: `{`, wantCode: http.StatusBadRequest},
+		{name: "missing transactions", body: `{}`, wantCode: http.StatusBadRequest},
+		{name: "not an array", body: `{"transactions": 1}`, wantCode: http.StatusBadRequest},
+		{name: "empty", body: `{"transactions": []}`, wantCode: http.StatusBadRequest},
+		{name: "too many", body: tooMany, wantCode: http.StatusBadRequest},
+		{
+			name:     "malformed items are rejected",
+			body:     `{"transactions": [1, {"sender": "zz"}]}`,
+			wantCode: http.StatusOK,
+			wantBody: `{"accepted":0,"rejected":2,"results":[{"accepted":false,"error":{"code":"bad_request","message":"transaction is not an object","retryable":false}},{"accepted":false,"error":{"code":"bad_request","message":"missing payload","retryable":false}}]}`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			request := httptest.NewRequest("POST", "http://localhost/tx/batch", strings.NewReader(tc.body))
+
+			w, err := serve(gateway.router, request)
+			assert.NoError(t, err)
+			assert.NotNil(t, w)
+
+			body, err := ioutil.ReadAll(w.Body)
+			assert.Nil(t, err)
+
+			assert.Equal(t, tc.wantCode, w.StatusCode, "status code")
+
+			if len(tc.wantBody) > 0 {
+				assert.Equal(t, tc.wantBody, string(body))
+			}
+		})
+	}
+}
+
 func TestSendTransactionRandom(t *testing.T) {
 	gateway := New()
 	gateway.setup()
@@ -320,6 +499,15 @@ func TestPostPayloadRandom(t *testing.T) {
 		{
 			url: "/tx/send",
 		},
+		{
+			url: "/tx/batch",
+		},
+		{
+			url: "/graphql",
+		},
+		{
+			url: "/rpc",
+		},
 	}
 
 	for _, tc := range tests {
@@ -376,6 +564,14 @@ func TestGetAccount(t *testing.T) {
 	var id wavelet.AccountID
 	copy(id[:], idBytes)
 
+	// Mistype the last character of the address of the account.
+	typo := wavelet.FormatAddress(id)
+	if strings.HasSuffix(typo, "q") {
+		typo = typo[:len(typo)-1] + "p"
+	} else {
+		typo = typo[:len(typo)-1] + "q"
+	}
+
 	tests := []struct {
 		name         string
 		url          string
@@ -403,6 +599,17 @@ func TestGetAccount(t *testing.T) {
 			wantCode:     http.StatusOK,
 			wantResponse: &account{ledger: gateway.ledger, id: id},
 		},
+		{
+			name:         "valid address",
+			url:          "/accounts/" + wavelet.FormatAddress(id),
+			wantCode:     http.StatusOK,
+			wantResponse: &account{ledger: gateway.ledger, id: id},
+		},
+		{
+			name:     "address with typo",
+			url:      "/accounts/" + typo,
+			wantCode: http.StatusBadRequest,
+		},
 	}
 
 	for _, tc := range tests {
@@ -469,8 +676,8 @@ func TestGetContractCode(t *testing.T) {
 			url:      "/contract/" + "3132333435363738393031323334353637383930313233343536373839303132",
 			wantCode: http.StatusNotFound,
 			wantError: testErrResponse{
-				StatusText: "Bad request.",
-				ErrorText:  fmt.Sprintf("could not find contract with ID %s", "3132333435363738393031323334353637383930313233343536373839303132"),
+				Code:    CodeNotFound,
+				Message: fmt.Sprintf("could not find contract with ID %s", "3132333435363738393031323334353637383930313233343536373839303132"),
 			},
 		},
 	}
@@ -517,8 +724,8 @@ func TestGetContractPages(t *testing.T) {
 			url:      "/contract/" + id + "/page/-1",
 			wantCode: http.StatusBadRequest,
 			wantError: testErrResponse{
-				StatusText: "Bad request.",
-				ErrorText:  "could not parse page index",
+				Code:    CodeBadRequest,
+				Message: "could not parse page index",
 			},
 		},
 		{
@@ -526,8 +733,8 @@ func TestGetContractPages(t *testing.T) {
 			url:      "/contract/3132333435363738393031323334353637383930313233343536373839303132/page/1",
 			wantCode: http.StatusNotFound,
 			wantError: testErrResponse{
-				StatusText: "Bad request.",
-				ErrorText:  fmt.Sprintf("could not find any pages for contract with ID %s", "3132333435363738393031323334353637383930313233343536373839303132"),
+				Code:    CodeNotFound,
+				Message: fmt.Sprintf("could not find any pages for contract with ID %s", "3132333435363738393031323334353637383930313233343536373839303132"),
 			},
 		},
 	}
@@ -562,7 +769,7 @@ func TestGetLedger(t *testing.T) {
 
 	keys, err := skademlia.NewKeys(1, 1)
 	assert.NoError(t, err)
-	gateway.keys = keys
+	gateway.signer = wallet.Key(keys.PrivateKey())
 
 	listener, err := net.Listen("tcp", ":0")
 	assert.NoError(t, err)
@@ -593,14 +800,420 @@ func TestGetLedger(t *testing.T) {
 	publicKey := keys.PublicKey()
 
 	expectedJSON := fmt.Sprintf(
-		`{"public_key":"%s","address":"127.0.0.1:%d","num_accounts":3,"round":{"merkle_root":"1a822467f036f127afe8c3c4df987fa7","start_id":"0000000000000000000000000000000000000000000000000000000000000000","end_id":"403517ca121f7638349cc92d654d20ac0f63d1958c897bc0cbcc2cdfe8bc74cc","applied":0,"depth":0,"difficulty":8},"peers":null}`,
+		`{"public_key":"%s","account_address":"%s","address":"127.0.0.1:%d","mode":"validator","protocol_version":0,"num_accounts":3,"view_id":0,"difficulty":8,"root_id":"403517ca121f7638349cc92d654d20ac0f63d1958c897bc0cbcc2cdfe8bc74cc","height":1,"num_tx":1,"num_missing_tx":0,"missing":{"count":0,"oldest_age_ms":0},"num_tx_in_store":1,"preferred_id":null,"preferred_votes":0,"sync":{"syncing":false,"votes":0},"halted":false,"round":{"index":0,"merkle_root":"1a822467f036f127afe8c3c4df987fa7","start_id":"0000000000000000000000000000000000000000000000000000000000000000","end_id":"403517ca121f7638349cc92d654d20ac0f63d1958c897bc0cbcc2cdfe8bc74cc","applied":0,"depth":0,"difficulty":8},"peers":null}`,
 		hex.EncodeToString(publicKey[:]),
+		wavelet.FormatAddress(publicKey),
 		listener.Addr().(*net.TCPAddr).Port,
 	)
 
 	assert.NoError(t, compareJson([]byte(expectedJSON), response))
 }
 
+func TestSignedResponses(t *testing.T) {
+	gateway := New(WithSignedResponses())
+	gateway.setup()
+
+	gateway.ledger = createLedger(t)
+
+	keys, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+	gateway.signer = wallet.Key(keys.PrivateKey())
+
+	round := gateway.ledger.Rounds().Latest()
+
+	request := httptest.NewRequest("GET", "http://localhost/tx/"+hex.EncodeToString(round.End.ID[:]), nil)
+
+	w, err := serve(gateway.router, request)
+	assert.NoError(t, err)
+	assert.NotNil(t, w)
+
+	response, err := ioutil.ReadAll(w.Body)
+	assert.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, w.StatusCode)
+
+	publicKey := keys.PublicKey()
+
+	assert.Equal(t, hex.EncodeToString(publicKey[:]), w.Header.Get(HeaderPublicKey))
+	assert.Equal(t, "0", w.Header.Get(HeaderRound))
+	assert.Equal(t, hex.EncodeToString(round.ID[:]), w.Header.Get(HeaderRoundID))
+
+	buf, err := hex.DecodeString(w.Header.Get(HeaderSignature))
+	assert.NoError(t, err)
+
+	var signature wavelet.Signature
+	copy(signature[:], buf)
+
+	uri := []byte("/tx/" + hex.EncodeToString(round.End.ID[:]))
+
+	assert.True(t, VerifyResponse(publicKey, uri, round.Index, round.ID, response, signature))
+	assert.False(t, VerifyResponse(publicKey, []byte("/ledger"), round.Index, round.ID, response, signature))
+}
+
+func TestFollowerRejectsTransactions(t *testing.T) {
+	gateway := New()
+	gateway.setup()
+
+	keys, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	gateway.signer = wallet.Key(keys.PrivateKey())
+	gateway.ledger = wavelet.NewLedger(store.NewInmem(), skademlia.NewClient(":0", keys), nil, wavelet.WithMode(wavelet.ModeFollower))
+
+	request := httptest.NewRequest("POST", "http://localhost/tx/send", bytes.NewReader([]byte("{}")))
+
+	w, err := serve(gateway.router, request)
+	assert.NoError(t, err)
+	assert.NotNil(t, w)
+
+	assert.Equal(t, http.StatusBadRequest, w.StatusCode)
+
+	response, err := ioutil.ReadAll(w.Body)
+	assert.NoError(t, err)
+	assert.Contains(t, string(response), wavelet.ErrReadOnly.Error())
+}
+
+func TestMultipleLedgers(t *testing.T) {
+	var gateways []*Gateway
+
+	for _, name := range []string{"mainnet", "testnet"} {
+		keys, err := skademlia.NewKeys(1, 1)
+		assert.NoError(t, err)
+
+		client := skademlia.NewClient(":0", keys)
+		ledger := wavelet.NewLedger(store.NewInmem(), client, nil, wavelet.WithName(name))
+
+		gateway := New(WithPrefix(name))
+		gateway.Bind(client, ledger, ledger.Signer())
+
+		gateways = append(gateways, gateway)
+	}
+
+	handler := dispatch(gateways)
+
+	for _, gateway := range gateways {
+		w, err := serveHandler(handler, httptest.NewRequest("GET", "http://localhost/"+gateway.ledger.Name()+"/ledger", nil))
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, w.StatusCode)
+
+		response, err := ioutil.ReadAll(w.Body)
+		assert.NoError(t, err)
+
+		publicKey := gateway.signer.PublicKey()
+		assert.Contains(t, string(response), hex.EncodeToString(publicKey[:]))
+	}
+
+	w, err := serveHandler(handler, httptest.NewRequest("GET", "http://localhost/devnet/ledger", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, w.StatusCode)
+
+	w, err = serveHandler(handler, httptest.NewRequest("GET", "http://localhost/mainnetx/ledger", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, w.StatusCode)
+}
+
+func TestExportAccounts(t *testing.T) {
+	gateway := New()
+	gateway.setup()
+
+	gateway.ledger = createLedger(t)
+
+	request := httptest.NewRequest("GET", "http://localhost/export/accounts?format=json", nil)
+
+	w, err := serve(gateway.router, request)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, w.StatusCode)
+	assert.Equal(t, "application/json", w.Header.Get("Content-Type"))
+
+	response, err := ioutil.ReadAll(w.Body)
+	assert.NoError(t, err)
+
+	var accounts []struct {
+		ID       string `json:"id"`
+		Balance  uint64 `json:"balance"`
+		Stake    uint64 `json:"stake"`
+		Contract bool   `json:"contract"`
+	}
+
+	assert.NoError(t, json.Unmarshal(response, &accounts))
+	assert.Len(t, accounts, 3)
+
+	request = httptest.NewRequest("GET", "http://localhost/export/accounts?round=0", nil)
+
+	w, err = serve(gateway.router, request)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, w.StatusCode, "exporting as of a past round requires archival")
+
+	request = httptest.NewRequest("GET", "http://localhost/export/accounts?format=xml", nil)
+
+	w, err = serve(gateway.router, request)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, w.StatusCode)
+}
+
+func TestExportGraph(t *testing.T) {
+	gateway := New()
+	gateway.setup()
+
+	gateway.ledger = createLedger(t)
+
+	request := httptest.NewRequest("GET", "http://localhost/export/graph", nil)
+
+	w, err := serve(gateway.router, request)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, w.StatusCode)
+	assert.Equal(t, "text/vnd.graphviz", w.Header.Get("Content-Type"))
+
+	response, err := ioutil.ReadAll(w.Body)
+	assert.NoError(t, err)
+	assert.Contains(t, string(response), "digraph wavelet {")
+
+	request = httptest.NewRequest("GET", "http://localhost/export/graph?format=json&depths=1", nil)
+
+	w, err = serve(gateway.router, request)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, w.StatusCode)
+	assert.Equal(t, "application/json", w.Header.Get("Content-Type"))
+
+	response, err = ioutil.ReadAll(w.Body)
+	assert.NoError(t, err)
+
+	var nodes []struct {
+		ID    string `json:"id"`
+		Depth uint64 `json:"depth"`
+	}
+
+	assert.NoError(t, json.Unmarshal(response, &nodes))
+	assert.Len(t, nodes, 1)
+
+	request = httptest.NewRequest("GET", "http://localhost/export/graph?depths=-1", nil)
+
+	w, err = serve(gateway.router, request)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, w.StatusCode)
+
+	request = httptest.NewRequest("GET", "http://localhost/export/graph?format=png", nil)
+
+	w, err = serve(gateway.router, request)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, w.StatusCode)
+}
+
+func TestGetNetwork(t *testing.T) {
+	gateway := New()
+	gateway.setup()
+
+	gateway.ledger = createLedger(t)
+
+	keys, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	gateway.client = skademlia.NewClient("127.0.0.1:0", keys)
+
+	request := httptest.NewRequest("GET", "http://localhost/network", nil)
+
+	w, err := serve(gateway.router, request)
+	assert.NoError(t, err)
+	assert.NotNil(t, w)
+
+	response, err := ioutil.ReadAll(w.Body)
+	assert.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, w.StatusCode)
+	assert.NoError(t, compareJson([]byte(`{"num_inbound":0,"num_outbound":0,"peers":null}`), response))
+}
+
+func TestGetConsensus(t *testing.T) {
+	gateway := New()
+	gateway.setup()
+
+	gateway.ledger = createLedger(t)
+
+	round := gateway.ledger.Rounds().Latest()
+
+	gateway.ledger.Finalizer().Tick(round)
+	gateway.ledger.Finalizer().Tick(round)
+
+	request := httptest.NewRequest("GET", "http://localhost/consensus", nil)
+
+	w, err := serve(gateway.router, request)
+	assert.NoError(t, err)
+	assert.NotNil(t, w)
+
+	response, err := ioutil.ReadAll(w.Body)
+	assert.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, w.StatusCode)
+
+	id := hex.EncodeToString(round.ID[:])
+	candidate := fmt.Sprintf(`"id":"%s","view_id":0,"start_id":"%s","end_id":"%s"`, id, hex.EncodeToString(round.Start.ID[:]), hex.EncodeToString(round.End.ID[:]))
+	zero := hex.EncodeToString(wavelet.ZeroRoundID[:])
+
+	expected := fmt.Sprintf(
+		`{"view_id":0,"finalizer":{"preferred":{%s},"last_id":"%s","count":1,"beta":%d,"decided":false,"liveness_faults":0,"candidates":[{%s,"count":2}]},"syncer":{"preferred":null,"last_id":"%s","count":0,"beta":%d,"decided":false,"liveness_faults":0,"candidates":[]}}`,
+		candidate, id, sys.SnowballBeta, candidate, zero, sys.SnowballBeta,
+	)
+
+	assert.NoError(t, compareJson([]byte(expected), response))
+}
+
+func TestGetMempool(t *testing.T) {
+	gateway := New()
+	gateway.setup()
+
+	gateway.ledger = createLedger(t)
+
+	keys, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	tx := wavelet.AttachSenderToTransaction(keys, wavelet.NewTransaction(keys, sys.TagTransfer, nil))
+	assert.NoError(t, gateway.ledger.BroadcastQueue().Push(tx, false))
+
+	request := httptest.NewRequest("GET", "http://localhost/mempool", nil)
+
+	w, err := serve(gateway.router, request)
+	assert.NoError(t, err)
+	assert.NotNil(t, w)
+
+	response, err := ioutil.ReadAll(w.Body)
+	assert.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, w.StatusCode)
+
+	config := wavelet.DefaultBroadcastConfig()
+	sender := hex.EncodeToString(tx.Sender[:])
+
+	expected := fmt.Sprintf(
+		`{"capacity":%d,"max_per_sender":%d,"overflow":"%s","size":1,"local":0,"relayed":1,"senders":[{"sender":"%s","pending":1}],"transactions":[{"id":"%s","sender":"%s","tag":%d,"logical_units":1,"local":false}]}`,
+		config.Capacity, config.MaxPerSender, config.Policy, sender, hex.EncodeToString(tx.ID[:]), sender, sys.TagTransfer,
+	)
+
+	assert.NoError(t, compareJson([]byte(expected), response))
+}
+
+func TestAdminPeers(t *testing.T) {
+	gateway := New()
+	gateway.setup()
+
+	request := httptest.NewRequest("GET", "http://localhost/admin/peers/banned", nil)
+
+	w, err := serve(gateway.router, request)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, w.StatusCode, "admin endpoints must be disabled without an admin token")
+
+	gateway = New(WithAdminToken("secret"))
+	gateway.setup()
+
+	gateway.ledger = createLedger(t)
+
+	peer := hex.EncodeToString(bytes.Repeat([]byte{1}, wavelet.SizeAccountID))
+
+	admin := func(method, path, token, body string) (*http.Response, string) {
+		request := httptest.NewRequest(method, "http://localhost"+path, bytes.NewBufferString(body))
+
+		if len(token) > 0 {
+			request.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		w, err := serve(gateway.router, request)
+		assert.NoError(t, err)
+
+		response, err := ioutil.ReadAll(w.Body)
+		assert.NoError(t, err)
+
+		return w, string(response)
+	}
+
+	w, _ = admin("POST", "/admin/peers/ban", "", `{"public_key":"`+peer+`"}`)
+	assert.Equal(t, http.StatusUnauthorized, w.StatusCode)
+
+	w, _ = admin("POST", "/admin/peers/ban", "wrong", `{"public_key":"`+peer+`"}`)
+	assert.Equal(t, http.StatusUnauthorized, w.StatusCode)
+
+	w, _ = admin("POST", "/admin/peers/ban", "secret", `{"public_key":"`+peer+`"}`)
+	assert.Equal(t, http.StatusOK, w.StatusCode)
+
+	w, response := admin("GET", "/admin/peers/banned", "secret", "")
+	assert.Equal(t, http.StatusOK, w.StatusCode)
+	assert.NoError(t, compareJson([]byte(`["`+peer+`"]`), []byte(response)))
+
+	w, _ = admin("POST", "/admin/peers/unban", "secret", `{"public_key":"`+peer+`"}`)
+	assert.Equal(t, http.StatusOK, w.StatusCode)
+
+	w, _ = admin("POST", "/admin/peers/unban", "secret", `{"public_key":"`+peer+`"}`)
+	assert.Equal(t, http.StatusNotFound, w.StatusCode, "peers which are not banned may not be unbanned")
+
+	w, _ = admin("POST", "/admin/peers/disconnect", "secret", `{"address":"127.0.0.1:1"}`)
+	assert.Equal(t, http.StatusBadRequest, w.StatusCode, "peers which are not connected may not be disconnected")
+
+	w, _ = admin("POST", "/admin/peers/connect", "secret", `{}`)
+	assert.Equal(t, http.StatusBadRequest, w.StatusCode)
+
+	// Only authorized requests are recorded into the audit log.
+
+	w, _ = admin("GET", "/admin/audit", "wrong", "")
+	assert.Equal(t, http.StatusUnauthorized, w.StatusCode)
+
+	w, response = admin("GET", "/admin/audit?offset=1&limit=2", "secret", "")
+	assert.Equal(t, http.StatusOK, w.StatusCode)
+
+	v, err := fastjson.Parse(response)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, 6, v.GetInt("length"))
+
+	entries := v.GetArray("entries")
+	if assert.Len(t, entries, 2) {
+		assert.Equal(t, "peers.list_banned", string(entries[0].GetStringBytes("action")))
+		assert.Equal(t, "peers.unban", string(entries[1].GetStringBytes("action")))
+		assert.Equal(t, `{"public_key":"`+peer+`"}`, string(entries[1].GetStringBytes("details")))
+		assert.Equal(t, http.StatusOK, entries[1].GetInt("status"))
+		assert.Equal(t, string(entries[0].GetStringBytes("hash")), string(entries[1].GetStringBytes("prev_hash")))
+	}
+
+	w, _ = admin("GET", "/admin/audit?limit=1001", "secret", "")
+	assert.Equal(t, http.StatusBadRequest, w.StatusCode)
+
+	assert.EqualValues(t, 8, gateway.ledger.AuditLog().Len())
+	assert.NoError(t, gateway.ledger.AuditLog().Verify())
+}
+
+func TestListValidators(t *testing.T) {
+	gateway := New()
+	gateway.setup()
+
+	keys, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	genesis := `{
+		"400056ee68a7cc2695222df05ea76875bc27ec6e61e8e62317c336157019c405": {"balance": 100, "stake": 300, "reward": 5},
+		"696937c2c8df35dba0169de72990b80761e51dd9e2411fa1fce147f68ade830a": {"balance": 100, "stake": 100},
+		"f03bb6f98c4dfd31f3d448c7ec79fa3eaa92250112ada43471812f4b1ace6467": {"balance": 100}
+	}`
+
+	gateway.ledger = wavelet.NewLedger(store.NewInmem(), skademlia.NewClient(":0", keys), &genesis)
+
+	request := httptest.NewRequest("GET", "http://localhost/validators", nil)
+
+	w, err := serve(gateway.router, request)
+	assert.NoError(t, err)
+	assert.NotNil(t, w)
+
+	response, err := ioutil.ReadAll(w.Body)
+	assert.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, w.StatusCode)
+
+	expectedJSON := `[` +
+		`{"public_key":"400056ee68a7cc2695222df05ea76875bc27ec6e61e8e62317c336157019c405","account_address":"twav1gqq9dmng5lxzd9fz9hc9afmgwk7z0mrwv85wvgchcvmp2uqecszsx4sutl","stake":300,"delegated_stake":0,"reward":5,"weight":0.75},` +
+		`{"public_key":"696937c2c8df35dba0169de72990b80761e51dd9e2411fa1fce147f68ade830a","account_address":"twav1d95n0skgmu6ahgqknhnjny9cqas728weufq3lg0uu9rldzk7sv9qf6k9hj","stake":100,"delegated_stake":0,"reward":0,"weight":0.25}` +
+		`]`
+
+	assert.NoError(t, compareJson([]byte(expectedJSON), response))
+}
+
 // Test the rate limit on all endpoints
 func TestEndpointsRateLimit(t *testing.T) {
 	gateway := New()
@@ -611,7 +1224,7 @@ func TestEndpointsRateLimit(t *testing.T) {
 
 	keys, err := skademlia.NewKeys(1, 1)
 	assert.NoError(t, err)
-	gateway.keys = keys
+	gateway.signer = wallet.Key(keys.PrivateKey())
 
 	listener, err := net.Listen("tcp", ":0")
 	assert.NoError(t, err)
@@ -637,6 +1250,11 @@ func TestEndpointsRateLimit(t *testing.T) {
 			method:        "GET",
 			isRateLimited: true,
 		},
+		{
+			url:           "/poll/rounds",
+			method:        "GET",
+			isRateLimited: true,
+		},
 		{
 			url:           "/poll/stake",
 			method:        "GET",
@@ -662,6 +1280,11 @@ func TestEndpointsRateLimit(t *testing.T) {
 			method:        "GET",
 			isRateLimited: true,
 		},
+		{
+			url:           "/poll/queues",
+			method:        "GET",
+			isRateLimited: true,
+		},
 		{
 			url:           "/ledger",
 			method:        "GET",
@@ -672,6 +1295,51 @@ func TestEndpointsRateLimit(t *testing.T) {
 			method:        "GET",
 			isRateLimited: false,
 		},
+		{
+			url:           "/network",
+			method:        "GET",
+			isRateLimited: true,
+		},
+		{
+			url:           "/validators",
+			method:        "GET",
+			isRateLimited: true,
+		},
+		{
+			url:           "/export/accounts",
+			method:        "GET",
+			isRateLimited: true,
+		},
+		{
+			url:           "/accounts/1/transactions",
+			method:        "GET",
+			isRateLimited: true,
+		},
+		{
+			url:           "/export/graph",
+			method:        "GET",
+			isRateLimited: true,
+		},
+		{
+			url:           "/conflicts",
+			method:        "GET",
+			isRateLimited: true,
+		},
+		{
+			url:           "/forks",
+			method:        "GET",
+			isRateLimited: true,
+		},
+		{
+			url:           "/mempool",
+			method:        "GET",
+			isRateLimited: true,
+		},
+		{
+			url:           "/rounds/1/certificate",
+			method:        "GET",
+			isRateLimited: true,
+		},
 		{
 			url:           "/contract/1/page/1",
 			method:        "GET",
@@ -692,6 +1360,11 @@ func TestEndpointsRateLimit(t *testing.T) {
 			method:        "POST",
 			isRateLimited: false,
 		},
+		{
+			url:           "/tx/batch",
+			method:        "POST",
+			isRateLimited: false,
+		},
 		{
 			url:           "/tx/1",
 			method:        "GET",
@@ -702,6 +1375,16 @@ func TestEndpointsRateLimit(t *testing.T) {
 			method:        "GET",
 			isRateLimited: true,
 		},
+		{
+			url:           "/graphql",
+			method:        "GET",
+			isRateLimited: true,
+		},
+		{
+			url:           "/rpc",
+			method:        "POST",
+			isRateLimited: true,
+		},
 	}
 
 	maxPerSecond := 10
@@ -749,17 +1432,25 @@ func createLedger(t *testing.T) *wavelet.Ledger {
 }
 
 type testErrResponse struct {
-	StatusText string `json:"status"`          // user-level status message
-	ErrorText  string `json:"error,omitempty"` // application-level error message, for debugging
+	Code      ErrorCode         `json:"code"`
+	Message   string            `json:"message"`
+	Details   map[string]string `json:"details,omitempty"`
+	Retryable bool              `json:"retryable"`
 }
 
 func (t testErrResponse) marshalJSON(arena *fastjson.Arena) ([]byte, error) {
-	return json.Marshal(t)
+	return json.Marshal(struct {
+		Error testErrResponse `json:"error"`
+	}{t})
 }
 
 func serve(router *fasthttprouter.Router, req *http.Request) (*http.Response, error) {
+	return serveHandler(router.Handler, req)
+}
+
+func serveHandler(handler fasthttp.RequestHandler, req *http.Request) (*http.Response, error) {
 	server := &fasthttp.Server{
-		Handler: router.Handler,
+		Handler: handler,
 	}
 
 	requestString, err := httputil.DumpRequestOut(req, true)
@@ -784,7 +1475,24 @@ func serve(router *fasthttprouter.Router, req *http.Request) (*http.Response, er
 		return nil, errors.New("timeout")
 	}
 
-	return http.ReadResponse(bufio.NewReader(&rw.w), req)
+	res, err := http.ReadResponse(bufio.NewReader(&rw.w), req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Transparently decompress gzip responses as the transport of net/http would,
+	// given that the dumped request asks for them.
+	if res.Header.Get("Content-Encoding") == "gzip" && req.Header.Get("Accept-Encoding") == "" {
+		body, err := gzip.NewReader(res.Body)
+		if err != nil {
+			return nil, err
+		}
+
+		res.Body = body
+		res.Header.Del("Content-Encoding")
+	}
+
+	return res, nil
 }
 
 type readWriter struct {
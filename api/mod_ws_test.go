@@ -46,7 +46,7 @@ func TestPollLog(t *testing.T) {
 
 	ledger := wavelet.NewLedger(store.NewInmem(), skademlia.NewClient(":0", keys), nil)
 
-	go gateway.StartHTTP(8080, nil, ledger, keys)
+	go gateway.StartHTTP(8080, nil, ledger, ledger.Signer())
 	defer gateway.Shutdown()
 
 	time.Sleep(100 * time.Millisecond)
@@ -69,6 +69,14 @@ func TestPollLog(t *testing.T) {
 				}
 
 				_, msg, err := c.ReadMessage()
+				if err != nil {
+					// The gateway disconnects clients as it shuts down.
+					select {
+					case <-stop:
+						return
+					default:
+					}
+				}
 				if !assert.NoError(t, err) {
 					return
 				}
@@ -108,6 +116,14 @@ func TestPollLog(t *testing.T) {
 				}
 
 				_, msg, err := c.ReadMessage()
+				if err != nil {
+					// The gateway disconnects clients as it shuts down.
+					select {
+					case <-stop:
+						return
+					default:
+					}
+				}
 				if !assert.NoError(t, err) {
 					return
 				}
@@ -144,4 +160,118 @@ func TestPollLog(t *testing.T) {
 
 		assert.Equal(t, 2, len(vals))
 	})
+
+	t.Run("rounds", func(t *testing.T) {
+		u := url.URL{Scheme: "ws", Host: ":8080", Path: `/poll/rounds`}
+		c, _, err := websocket.DefaultDialer.Dial(u.String(), nil)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		response := make(chan []byte, 10)
+		stop := make(chan struct{})
+
+		go func() {
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+
+				_, msg, err := c.ReadMessage()
+				if err != nil {
+					// The gateway disconnects clients as it shuts down.
+					select {
+					case <-stop:
+						return
+					default:
+					}
+				}
+				if !assert.NoError(t, err) {
+					return
+				}
+				response <- msg
+			}
+		}()
+
+		logger := log.Consensus("round_end")
+		logger.Info().Uint64("new_round", 1).Msg("")
+
+		logger = log.Rounds("finalized")
+		logger.Info().Uint64("new_round", 1).Int("num_applied_tx", 3).Msg("")
+
+		time.Sleep(1000 * time.Millisecond)
+		close(stop)
+
+		if !assert.Equal(t, 1, len(response)) {
+			return
+		}
+
+		v, err := fastjson.ParseBytes(<-response)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		assert.Equal(t, "finalized", string(v.GetStringBytes(log.KeyEvent)))
+		assert.Equal(t, 3, v.GetInt("num_applied_tx"))
+	})
+
+	t.Run("stake-account-filter", func(t *testing.T) {
+		u := url.URL{Scheme: "ws", Host: ":8080", Path: `/poll/stake`, RawQuery: "id=abcd"}
+		c, _, err := websocket.DefaultDialer.Dial(u.String(), nil)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		response := make(chan []byte, 10)
+		stop := make(chan struct{})
+
+		go func() {
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+
+				_, msg, err := c.ReadMessage()
+				if err != nil {
+					// The gateway disconnects clients as it shuts down.
+					select {
+					case <-stop:
+						return
+					default:
+					}
+				}
+				if !assert.NoError(t, err) {
+					return
+				}
+				response <- msg
+			}
+		}()
+
+		logger := log.Stake("stake_placed")
+		logger.Info().Str("account_id", "abcd").Uint64("amount", 100).Msg("")
+		logger.Info().Str("account_id", "ef01").Uint64("amount", 100).Msg("")
+
+		logger = log.Stake("reward_withdrawn")
+		logger.Info().Str("account_id", "abcd").Uint64("amount", 50).Msg("")
+
+		time.Sleep(1000 * time.Millisecond)
+		close(stop)
+
+		if !assert.Equal(t, 2, len(response)) {
+			return
+		}
+
+		for i := 0; i < 2; i++ {
+			v, err := fastjson.ParseBytes(<-response)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			assert.Equal(t, "abcd", string(v.GetStringBytes("account_id")))
+		}
+	})
 }
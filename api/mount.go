@@ -0,0 +1,100 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package api
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/perlin-network/wavelet/log"
+	"github.com/pkg/errors"
+	"github.com/valyala/fasthttp"
+	"github.com/valyala/fastjson"
+)
+
+// WithPrefix has the gateway mount all of its routes under prefix, such that
+// the HTTP APIs of several ledgers hosted within the same process may be served
+// on a single port via StartMultiHTTP.
+func WithPrefix(prefix string) GatewayOption {
+	return func(g *Gateway) {
+		g.prefix = "/" + strings.Trim(prefix, "/")
+
+		if g.prefix == "/" {
+			g.prefix = ""
+		}
+	}
+}
+
+// StartMultiHTTP serves the HTTP APIs of several gateways on a single port.
+// Every gateway must have been bound to a ledger via Bind, and be mounted under
+// a distinct prefix via WithPrefix. Requests are dispatched to the gateway with
+// the longest matching prefix. Shutting down any of the gateways shuts down the
+// server for all of them.
+func StartMultiHTTP(port int, gateways ...*Gateway) {
+	server := &fasthttp.Server{
+		Handler: dispatch(gateways),
+	}
+
+	for _, g := range gateways {
+		stop := g.rateLimiter.cleanup(10 * time.Minute)
+		defer stop()
+
+		stopReporting := g.reportSinkMetrics(1 * time.Second)
+		defer stopReporting()
+
+		g.server = server
+	}
+
+	logger := log.Node()
+	logger.Info().Int("port", port).Int("num_ledgers", len(gateways)).Msg("Started HTTP API server.")
+
+	if err := server.ListenAndServe(":" + strconv.Itoa(port)); err != nil {
+		logger.Fatal().Err(err).Msg("Failed to start HTTP server.")
+	}
+}
+
+func dispatch(gateways []*Gateway) fasthttp.RequestHandler {
+	return func(ctx *fasthttp.RequestCtx) {
+		path := ctx.Path()
+
+		var target *Gateway
+
+		for _, g := range gateways {
+			prefix := []byte(g.prefix)
+
+			if !bytes.HasPrefix(path, prefix) || (len(path) > len(prefix) && path[len(prefix)] != '/') {
+				continue
+			}
+
+			if target == nil || len(g.prefix) > len(target.prefix) {
+				target = g
+			}
+		}
+
+		if target == nil {
+			writeError(ctx, new(fastjson.Arena), ErrNotFound(errors.Errorf("no ledger is mounted at %s", ctx.Path())))
+			return
+		}
+
+		target.router.Handler(ctx)
+	}
+}
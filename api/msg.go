@@ -20,16 +20,20 @@
 package api
 
 import (
+	"bytes"
 	"encoding/base64"
 	"encoding/hex"
 	"net/http"
+	"sort"
 	"strconv"
+	"time"
 
 	"github.com/perlin-network/noise/edwards25519"
 	"github.com/perlin-network/noise/skademlia"
 	"github.com/perlin-network/wavelet"
 	"github.com/perlin-network/wavelet/sys"
 	"github.com/pkg/errors"
+	"github.com/valyala/fasthttp"
 	"github.com/valyala/fastjson"
 )
 
@@ -42,9 +46,38 @@ var (
 
 	_ marshalableJSON = (*ledgerStatusResponse)(nil)
 
+	_ marshalableJSON = (*networkStatusResponse)(nil)
+
+	_ marshalableJSON = (*consensusResponse)(nil)
+
+	_ marshalableJSON = (*mempoolResponse)(nil)
+
 	_ marshalableJSON = (*transaction)(nil)
 
+	_ marshalableJSON = (*rawTransaction)(nil)
+
+	_ marshalableJSON = (conflictList)(nil)
+
+	_ marshalableJSON = (contractEventList)(nil)
+
+	_ marshalableJSON = (forkList)(nil)
+
+	_ marshalableJSON = (*certificate)(nil)
+
 	_ marshalableJSON = (*account)(nil)
+
+	_ marshalableJSON = (*accountHistory)(nil)
+
+	_ marshalableJSON = (*accountRecovery)(nil)
+
+	_ marshalableJSON = (*proposalResponse)(nil)
+
+	_ marshalableJSON = (validatorList)(nil)
+
+	_ marshalableJSON = (*adminResponse)(nil)
+	_ marshalableJSON = (*auditEntryList)(nil)
+
+	_ marshalableJSON = (bannedPeerList)(nil)
 )
 
 type sendTransactionRequest struct {
@@ -52,10 +85,13 @@ type sendTransactionRequest struct {
 	Tag       byte   `json:"tag"`
 	Payload   string `json:"payload"`
 	Signature string `json:"signature"`
+	Scheme    string `json:"scheme"`
+	Nonce     uint64 `json:"nonce"`
 
 	// Internal fields.
 	creator   wavelet.AccountID
 	signature wavelet.Signature
+	scheme    wavelet.SignatureScheme
 	payload   []byte
 }
 
@@ -69,6 +105,14 @@ func (s *sendTransactionRequest) bind(parser *fastjson.Parser, body []byte) erro
 		return err
 	}
 
+	return s.bindValue(v)
+}
+
+func (s *sendTransactionRequest) bindValue(v *fastjson.Value) error {
+	if v.Type() != fastjson.TypeObject {
+		return errors.New("transaction is not an object")
+	}
+
 	senderVal := v.Get("sender")
 	if senderVal == nil {
 		return errors.New("missing sender")
@@ -117,21 +161,40 @@ func (s *sendTransactionRequest) bind(parser *fastjson.Parser, body []byte) erro
 		return errors.Wrap(err, "invalid tag")
 	}
 
+	// The signature scheme is optional, and defaults to ed25519.
+
+	var schemeStr []byte
+
+	if schemeVal := v.Get("scheme"); schemeVal != nil {
+		if schemeStr, err = schemeVal.StringBytes(); err != nil {
+			return errors.Wrap(err, "invalid scheme")
+		}
+	}
+
+	// The nonce the creator signed under is optional, and defaults to zero
+	// such that the transaction is unsequenced.
+
+	var nonce uint64
+
+	if nonceVal := v.Get("nonce"); nonceVal != nil {
+		if nonce, err = nonceVal.Uint64(); err != nil {
+			return errors.Wrap(err, "invalid nonce")
+		}
+	}
+
 	s.Sender = string(senderStr)
 	s.Payload = string(payloadStr)
 	s.Signature = string(signatureStr)
 	s.Tag = byte(tag)
+	s.Scheme = string(schemeStr)
+	s.Nonce = nonce
 
-	senderBuf, err := hex.DecodeString(s.Sender)
+	s.creator, err = wavelet.ParseAccountID(s.Sender)
 	if err != nil {
-		return errors.Wrap(err, "sender public key provided is not hex-formatted")
-	}
-
-	if len(senderBuf) != wavelet.SizeAccountID {
-		return errors.Errorf("sender public key must be size %d", wavelet.SizeAccountID)
+		return errors.Wrap(err, "invalid sender")
 	}
 
-	if sys.Tag(s.Tag) > sys.TagBatch {
+	if sys.Tag(s.Tag) > sys.TagGovernance {
 		return errors.New("unknown transaction tag specified")
 	}
 
@@ -149,7 +212,11 @@ func (s *sendTransactionRequest) bind(parser *fastjson.Parser, body []byte) erro
 		return errors.Errorf("sender signature must be size %d", wavelet.SizeSignature)
 	}
 
-	copy(s.creator[:], senderBuf)
+	s.scheme, err = wavelet.ParseSignatureScheme(s.Scheme)
+	if err != nil {
+		return err
+	}
+
 	copy(s.signature[:], signatureBuf)
 
 	return nil
@@ -162,6 +229,15 @@ type sendTransactionResponse struct {
 }
 
 func (s *sendTransactionResponse) marshalJSON(arena *fastjson.Arena) ([]byte, error) {
+	o, err := s.getObject(arena)
+	if err != nil {
+		return nil, err
+	}
+
+	return o.MarshalTo(nil), nil
+}
+
+func (s *sendTransactionResponse) getObject(arena *fastjson.Arena) (*fastjson.Value, error) {
 	if s.ledger == nil || s.tx == nil {
 		return nil, errors.New("insufficient parameters were provided")
 	}
@@ -182,12 +258,102 @@ func (s *sendTransactionResponse) marshalJSON(arena *fastjson.Arena) ([]byte, er
 
 	round := s.ledger.Rounds().Latest()
 
-	if s.tx.IsCritical(round.ExpectedDifficulty(sys.MinDifficulty, sys.DifficultyScaleFactor)) {
+	if s.tx.IsCritical(s.ledger.Difficulty(round)) {
 		o.Set("is_critical", arena.NewTrue())
 	} else {
 		o.Set("is_critical", arena.NewFalse())
 	}
 
+	return o, nil
+}
+
+type sendBatchRequest struct {
+	// Internal fields.
+	txs  []sendTransactionRequest
+	errs []error
+}
+
+func (s *sendBatchRequest) bind(parser *fastjson.Parser, body []byte) error {
+	if err := fastjson.ValidateBytes(body); err != nil {
+		return errors.Wrap(err, "invalid json")
+	}
+
+	v, err := parser.ParseBytes(body)
+	if err != nil {
+		return err
+	}
+
+	txsVal := v.Get("transactions")
+	if txsVal == nil {
+		return errors.New("missing transactions")
+	}
+
+	items, err := txsVal.Array()
+	if err != nil {
+		return errors.New("transactions is not an array")
+	}
+
+	if len(items) == 0 {
+		return errors.New("no transactions were provided")
+	}
+
+	if len(items) > MaxBatchSize {
+		return errors.Errorf("at most %d transactions may be sent in one batch", MaxBatchSize)
+	}
+
+	s.txs = make([]sendTransactionRequest, len(items))
+	s.errs = make([]error, len(items))
+
+	for i, item := range items {
+		s.errs[i] = s.txs[i].bindValue(item)
+	}
+
+	return nil
+}
+
+type sendBatchResult struct {
+	tx  *wavelet.Transaction
+	err *errResponse
+}
+
+type sendBatchResponse struct {
+	// Internal fields.
+	ledger  *wavelet.Ledger
+	results []sendBatchResult
+}
+
+func (s *sendBatchResponse) marshalJSON(arena *fastjson.Arena) ([]byte, error) {
+	o := arena.NewObject()
+
+	results := arena.NewArray()
+	accepted := 0
+
+	for i, result := range s.results {
+		item := arena.NewObject()
+
+		if result.err != nil {
+			item.Set("accepted", arena.NewFalse())
+			item.Set("error", result.err.getObject(arena))
+		} else {
+			var err error
+
+			res := &sendTransactionResponse{ledger: s.ledger, tx: result.tx}
+
+			if item, err = res.getObject(arena); err != nil {
+				return nil, err
+			}
+
+			item.Set("accepted", arena.NewTrue())
+			accepted++
+		}
+
+		results.SetArrayItem(i, item)
+	}
+
+	o.Set("accepted", arena.NewNumberInt(accepted))
+	o.Set("rejected", arena.NewNumberInt(len(s.results)-accepted))
+	o.Set("results", results)
+
 	return o.MarshalTo(nil), nil
 }
 
@@ -212,16 +378,64 @@ func (s *ledgerStatusResponse) marshalJSON(arena *fastjson.Arena) ([]byte, error
 	o := arena.NewObject()
 
 	o.Set("public_key", arena.NewString(hex.EncodeToString(s.publicKey[:])))
+	o.Set("account_address", arena.NewString(wavelet.FormatAddress(s.publicKey)))
 	o.Set("address", arena.NewString(s.client.ID().Address()))
+	o.Set("mode", arena.NewString(string(s.ledger.Mode())))
+	o.Set("protocol_version", arena.NewNumberString(strconv.FormatUint(uint64(wavelet.ReadProtocolVersion(snapshot)), 10)))
 	o.Set("num_accounts", arena.NewNumberString(strconv.FormatUint(accountsLen, 10)))
 
+	rootDepth := s.ledger.Graph().RootDepth()
+
+	o.Set("view_id", arena.NewNumberString(strconv.FormatUint(round.Index, 10)))
+	o.Set("difficulty", arena.NewNumberString(strconv.FormatUint(uint64(s.ledger.Difficulty(round)), 10)))
+	o.Set("root_id", arena.NewString(hex.EncodeToString(round.End.ID[:])))
+	o.Set("height", arena.NewNumberString(strconv.FormatUint(s.ledger.Graph().Height(), 10)))
+	o.Set("num_tx", arena.NewNumberInt(s.ledger.Graph().DepthLen(&rootDepth, nil)))
+	o.Set("num_missing_tx", arena.NewNumberInt(s.ledger.Graph().MissingLen()))
+
+	stats := s.ledger.Graph().MissingStats()
+
+	missing := arena.NewObject()
+	missing.Set("count", arena.NewNumberInt(stats.Count))
+	missing.Set("oldest_age_ms", arena.NewNumberString(strconv.FormatInt(int64(stats.OldestAge/time.Millisecond), 10)))
+
+	o.Set("missing", missing)
+	o.Set("num_tx_in_store", arena.NewNumberInt(s.ledger.Graph().Len()))
+
+	if preferred := s.ledger.Finalizer().Preferred(); preferred != nil {
+		o.Set("preferred_id", arena.NewString(hex.EncodeToString(preferred.ID[:])))
+	} else {
+		o.Set("preferred_id", nil)
+	}
+
+	o.Set("preferred_votes", arena.NewNumberInt(s.ledger.Finalizer().Progress()))
+
+	sync := arena.NewObject()
+
+	if s.ledger.Syncing() {
+		sync.Set("syncing", arena.NewTrue())
+	} else {
+		sync.Set("syncing", arena.NewFalse())
+	}
+
+	sync.Set("votes", arena.NewNumberInt(s.ledger.Syncer().Progress()))
+
+	o.Set("sync", sync)
+
+	if s.ledger.Halted() {
+		o.Set("halted", arena.NewTrue())
+	} else {
+		o.Set("halted", arena.NewFalse())
+	}
+
 	r := arena.NewObject()
+	r.Set("index", arena.NewNumberString(strconv.FormatUint(round.Index, 10)))
 	r.Set("merkle_root", arena.NewString(hex.EncodeToString(round.Merkle[:])))
 	r.Set("start_id", arena.NewString(hex.EncodeToString(round.Start.ID[:])))
 	r.Set("end_id", arena.NewString(hex.EncodeToString(round.End.ID[:])))
 	r.Set("applied", arena.NewNumberString(strconv.FormatUint(round.Applied, 10)))
 	r.Set("depth", arena.NewNumberString(strconv.FormatUint(round.End.Depth-round.Start.Depth, 10)))
-	r.Set("difficulty", arena.NewNumberString(strconv.FormatUint(uint64(round.ExpectedDifficulty(sys.MinDifficulty, sys.DifficultyScaleFactor)), 10)))
+	r.Set("difficulty", arena.NewNumberString(strconv.FormatUint(uint64(s.ledger.Difficulty(round)), 10)))
 
 	o.Set("round", r)
 
@@ -246,6 +460,268 @@ func (s *ledgerStatusResponse) marshalJSON(arena *fastjson.Arena) ([]byte, error
 	return o.MarshalTo(nil), nil
 }
 
+type consensusResponse struct {
+	// Internal fields.
+
+	ledger *wavelet.Ledger
+}
+
+func (s *consensusResponse) marshalJSON(arena *fastjson.Arena) ([]byte, error) {
+	if s.ledger == nil {
+		return nil, errors.New("insufficient parameters were provided")
+	}
+
+	o := arena.NewObject()
+
+	o.Set("view_id", arena.NewNumberString(strconv.FormatUint(s.ledger.Rounds().Latest().Index, 10)))
+	o.Set("finalizer", marshalSnowballProgress(arena, s.ledger.Finalizer().Snapshot()))
+	o.Set("syncer", marshalSnowballProgress(arena, s.ledger.Syncer().Snapshot()))
+
+	return o.MarshalTo(nil), nil
+}
+
+type mempoolResponse struct {
+	// Internal fields.
+
+	snapshot wavelet.BroadcastSnapshot
+}
+
+func (s *mempoolResponse) marshalJSON(arena *fastjson.Arena) ([]byte, error) {
+	o := arena.NewObject()
+
+	o.Set("capacity", arena.NewNumberInt(s.snapshot.Config.Capacity))
+	o.Set("max_per_sender", arena.NewNumberInt(s.snapshot.Config.MaxPerSender))
+	o.Set("overflow", arena.NewString(string(s.snapshot.Config.Policy)))
+	o.Set("size", arena.NewNumberInt(len(s.snapshot.Local)+len(s.snapshot.Relayed)))
+	o.Set("local", arena.NewNumberInt(len(s.snapshot.Local)))
+	o.Set("relayed", arena.NewNumberInt(len(s.snapshot.Relayed)))
+
+	pending := make(map[wavelet.AccountID]int)
+
+	for _, tx := range s.snapshot.Relayed {
+		pending[tx.Sender]++
+	}
+
+	senders := make([]wavelet.AccountID, 0, len(pending))
+
+	for sender := range pending {
+		senders = append(senders, sender)
+	}
+
+	sort.Slice(senders, func(i, j int) bool {
+		if pending[senders[i]] != pending[senders[j]] {
+			return pending[senders[i]] > pending[senders[j]]
+		}
+
+		return bytes.Compare(senders[i][:], senders[j][:]) < 0
+	})
+
+	list := arena.NewArray()
+
+	for i, sender := range senders {
+		v := arena.NewObject()
+
+		v.Set("sender", arena.NewString(hex.EncodeToString(sender[:])))
+		v.Set("pending", arena.NewNumberInt(pending[sender]))
+
+		list.SetArrayItem(i, v)
+	}
+
+	o.Set("senders", list)
+
+	txs := arena.NewArray()
+
+	for i, tx := range append(append([]wavelet.Transaction(nil), s.snapshot.Local...), s.snapshot.Relayed...) {
+		v := arena.NewObject()
+
+		v.Set("id", arena.NewString(hex.EncodeToString(tx.ID[:])))
+		v.Set("sender", arena.NewString(hex.EncodeToString(tx.Sender[:])))
+		v.Set("tag", arena.NewNumberInt(int(tx.Tag)))
+		v.Set("logical_units", arena.NewNumberInt(tx.LogicalUnits()))
+
+		if i < len(s.snapshot.Local) {
+			v.Set("local", arena.NewTrue())
+		} else {
+			v.Set("local", arena.NewFalse())
+		}
+
+		txs.SetArrayItem(i, v)
+	}
+
+	o.Set("transactions", txs)
+
+	return o.MarshalTo(nil), nil
+}
+
+func marshalSnowballProgress(arena *fastjson.Arena, progress wavelet.SnowballProgress) *fastjson.Value {
+	o := arena.NewObject()
+
+	if progress.Preferred != nil {
+		o.Set("preferred", marshalSnowballRound(arena, progress.Preferred))
+	} else {
+		o.Set("preferred", nil)
+	}
+
+	o.Set("last_id", arena.NewString(hex.EncodeToString(progress.Last[:])))
+	o.Set("count", arena.NewNumberInt(progress.Count))
+	o.Set("beta", arena.NewNumberInt(progress.Beta))
+
+	if progress.Decided {
+		o.Set("decided", arena.NewTrue())
+	} else {
+		o.Set("decided", arena.NewFalse())
+	}
+
+	o.Set("liveness_faults", arena.NewNumberInt(progress.LivenessFaults))
+
+	candidates := arena.NewArray()
+
+	for i, candidate := range progress.Candidates {
+		c := marshalSnowballRound(arena, candidate.Round)
+		c.Set("count", arena.NewNumberInt(candidate.Count))
+
+		candidates.SetArrayItem(i, c)
+	}
+
+	o.Set("candidates", candidates)
+
+	return o
+}
+
+func marshalSnowballRound(arena *fastjson.Arena, round *wavelet.Round) *fastjson.Value {
+	o := arena.NewObject()
+
+	o.Set("id", arena.NewString(hex.EncodeToString(round.ID[:])))
+	o.Set("view_id", arena.NewNumberString(strconv.FormatUint(round.Index, 10)))
+	o.Set("start_id", arena.NewString(hex.EncodeToString(round.Start.ID[:])))
+	o.Set("end_id", arena.NewString(hex.EncodeToString(round.End.ID[:])))
+
+	return o
+}
+
+type networkStatusResponse struct {
+	// Internal fields.
+
+	client *skademlia.Client
+	ledger *wavelet.Ledger
+}
+
+func (s *networkStatusResponse) marshalJSON(arena *fastjson.Arena) ([]byte, error) {
+	if s.client == nil || s.ledger == nil {
+		return nil, errors.New("insufficient parameters were provided")
+	}
+
+	type entry struct {
+		info     wavelet.PeerInfo
+		inbound  bool
+		outbound bool
+	}
+
+	var entries []*entry
+
+	index := make(map[wavelet.AccountID]*entry)
+
+	for _, id := range s.client.ClosestPeerIDs() {
+		publicKey := id.PublicKey()
+
+		info, _ := s.ledger.Peers().Get(publicKey)
+		info.ID, info.Address = publicKey, id.Address()
+
+		e := &entry{info: info, outbound: true}
+
+		entries = append(entries, e)
+		index[publicKey] = e
+	}
+
+	numOutbound, numInbound := len(entries), 0
+
+	lags := make(map[string]wavelet.GossipLag)
+
+	for _, lag := range s.ledger.GossipLag() {
+		lags[lag.Target] = lag
+	}
+
+	for _, info := range s.ledger.Peers().Inbound() {
+		numInbound++
+
+		if e, exists := index[info.ID]; exists {
+			e.inbound = true
+			continue
+		}
+
+		entries = append(entries, &entry{info: info, inbound: true})
+	}
+
+	o := arena.NewObject()
+
+	o.Set("num_inbound", arena.NewNumberInt(numInbound))
+	o.Set("num_outbound", arena.NewNumberInt(numOutbound))
+
+	if len(entries) > 0 {
+		peers := arena.NewArray()
+
+		for i, e := range entries {
+			peer := arena.NewObject()
+
+			peer.Set("address", arena.NewString(e.info.Address))
+			peer.Set("public_key", arena.NewString(hex.EncodeToString(e.info.ID[:])))
+
+			if len(e.info.Version) > 0 {
+				peer.Set("version", arena.NewString(e.info.Version))
+			} else {
+				peer.Set("version", nil)
+			}
+
+			if !e.info.LastGossip.IsZero() {
+				peer.Set("last_gossip", arena.NewString(e.info.LastGossip.Format(time.RFC3339)))
+			} else {
+				peer.Set("last_gossip", nil)
+			}
+
+			peer.Set("latency_ms", arena.NewNumberFloat64(float64(e.info.Latency)/float64(time.Millisecond)))
+
+			if lag, exists := lags[e.info.Address]; exists {
+				gossip := arena.NewObject()
+
+				gossip.Set("queued", arena.NewNumberInt(lag.Queued))
+				gossip.Set("sent", arena.NewNumberString(strconv.FormatUint(lag.Sent, 10)))
+				gossip.Set("dropped", arena.NewNumberString(strconv.FormatUint(lag.Dropped, 10)))
+				gossip.Set("lag_ms", arena.NewNumberFloat64(float64(lag.Lag)/float64(time.Millisecond)))
+
+				peer.Set("gossip", gossip)
+			} else {
+				peer.Set("gossip", nil)
+			}
+
+			if !e.info.ClockSampled.IsZero() {
+				peer.Set("clock_offset_ms", arena.NewNumberFloat64(float64(e.info.ClockOffset)/float64(time.Millisecond)))
+			} else {
+				peer.Set("clock_offset_ms", nil)
+			}
+
+			if e.inbound {
+				peer.Set("inbound", arena.NewTrue())
+			} else {
+				peer.Set("inbound", arena.NewFalse())
+			}
+
+			if e.outbound {
+				peer.Set("outbound", arena.NewTrue())
+			} else {
+				peer.Set("outbound", arena.NewFalse())
+			}
+
+			peers.SetArrayItem(i, peer)
+		}
+
+		o.Set("peers", peers)
+	} else {
+		o.Set("peers", nil)
+	}
+
+	return o.MarshalTo(nil), nil
+}
+
 type transaction struct {
 	// Internal fields.
 	tx     *wavelet.Transaction
@@ -270,7 +746,9 @@ func (s *transaction) getObject(arena *fastjson.Arena) (*fastjson.Value, error)
 
 	o.Set("id", arena.NewString(hex.EncodeToString(s.tx.ID[:])))
 	o.Set("sender", arena.NewString(hex.EncodeToString(s.tx.Sender[:])))
+	o.Set("sender_address", arena.NewString(wavelet.FormatAddress(s.tx.Sender)))
 	o.Set("creator", arena.NewString(hex.EncodeToString(s.tx.Creator[:])))
+	o.Set("creator_address", arena.NewString(wavelet.FormatAddress(s.tx.Creator)))
 	o.Set("status", arena.NewString(s.status))
 	o.Set("nonce", arena.NewNumberString(strconv.FormatUint(s.tx.Nonce, 10)))
 	o.Set("depth", arena.NewNumberString(strconv.FormatUint(s.tx.Depth, 10)))
@@ -278,6 +756,7 @@ func (s *transaction) getObject(arena *fastjson.Arena) (*fastjson.Value, error)
 	o.Set("payload", arena.NewString(base64.StdEncoding.EncodeToString(s.tx.Payload)))
 	o.Set("sender_signature", arena.NewString(hex.EncodeToString(s.tx.SenderSignature[:])))
 	o.Set("creator_signature", arena.NewString(hex.EncodeToString(s.tx.CreatorSignature[:])))
+	o.Set("creator_scheme", arena.NewString(s.tx.CreatorScheme.String()))
 
 	if s.tx.ParentIDs != nil {
 		parents := arena.NewArray()
@@ -292,9 +771,219 @@ func (s *transaction) getObject(arena *fastjson.Arena) (*fastjson.Value, error)
 	return o, nil
 }
 
+// rawTransaction renders every field of a stored transaction alongside its wire
+// encoding, and the round it was finalized in, such that it may be independently
+// re-verified.
+type rawTransaction struct {
+	transaction
+
+	round      *wavelet.Round
+	difficulty byte
+}
+
+func (s *rawTransaction) marshalJSON(arena *fastjson.Arena) ([]byte, error) {
+	o, err := s.getObject(arena)
+	if err != nil {
+		return nil, err
+	}
+
+	o.Set("payload", arena.NewString(hex.EncodeToString(s.tx.Payload)))
+	o.Set("seed", arena.NewString(hex.EncodeToString(s.tx.Seed[:])))
+	o.Set("seed_len", arena.NewNumberInt(int(s.tx.SeedLen)))
+	o.Set("raw", arena.NewString(hex.EncodeToString(s.tx.Marshal())))
+
+	if s.round != nil {
+		r := arena.NewObject()
+		r.Set("id", arena.NewString(hex.EncodeToString(s.round.ID[:])))
+		r.Set("index", arena.NewNumberString(strconv.FormatUint(s.round.Index, 10)))
+		r.Set("merkle_root", arena.NewString(hex.EncodeToString(s.round.Merkle[:])))
+		r.Set("start_id", arena.NewString(hex.EncodeToString(s.round.Start.ID[:])))
+		r.Set("end_id", arena.NewString(hex.EncodeToString(s.round.End.ID[:])))
+		r.Set("difficulty", arena.NewNumberString(strconv.FormatUint(uint64(s.difficulty), 10)))
+
+		o.Set("round", r)
+	} else {
+		o.Set("round", nil)
+	}
+
+	return o.MarshalTo(nil), nil
+}
+
+// transactionStatus renders how far along a transaction is in being finalized.
+type transactionStatus struct {
+	id    wavelet.TransactionID
+	state wavelet.TransactionState
+}
+
+func (s *transactionStatus) marshalJSON(arena *fastjson.Arena) ([]byte, error) {
+	o := arena.NewObject()
+
+	o.Set("id", arena.NewString(hex.EncodeToString(s.id[:])))
+	o.Set("status", arena.NewString(s.state.Status.String()))
+
+	switch s.state.Status {
+	case wavelet.TxFinalized, wavelet.TxApplied, wavelet.TxRejected:
+		o.Set("round", arena.NewNumberString(strconv.FormatUint(s.state.Round, 10)))
+	}
+
+	if s.state.Err != nil {
+		o.Set("error", arena.NewString(s.state.Err.Error()))
+	}
+
+	return o.MarshalTo(nil), nil
+}
+
+type conflictList []wavelet.ConflictSet
+
+func (s conflictList) marshalJSON(arena *fastjson.Arena) ([]byte, error) {
+	list := arena.NewArray()
+
+	for i, conflict := range s {
+		o := arena.NewObject()
+
+		o.Set("creator", arena.NewString(hex.EncodeToString(conflict.Creator[:])))
+		o.Set("nonce", arena.NewNumberString(strconv.FormatUint(conflict.Nonce, 10)))
+
+		ids := arena.NewArray()
+		for j := range conflict.TransactionIDs {
+			ids.SetArrayItem(j, arena.NewString(hex.EncodeToString(conflict.TransactionIDs[j][:])))
+		}
+		o.Set("tx_ids", ids)
+
+		list.SetArrayItem(i, o)
+	}
+
+	return list.MarshalTo(nil), nil
+}
+
+type contractEventList []wavelet.ContractEvent
+
+func (s contractEventList) marshalJSON(arena *fastjson.Arena) ([]byte, error) {
+	list := arena.NewArray()
+
+	for i, event := range s {
+		o := arena.NewObject()
+
+		o.Set("tx_id", arena.NewString(hex.EncodeToString(event.TxID[:])))
+		o.Set("contract_id", arena.NewString(hex.EncodeToString(event.Contract[:])))
+		o.Set("topic", arena.NewString(hex.EncodeToString(event.Topic)))
+		o.Set("data", arena.NewString(hex.EncodeToString(event.Data)))
+
+		list.SetArrayItem(i, o)
+	}
+
+	return list.MarshalTo(nil), nil
+}
+
+type forkList []wavelet.Fork
+
+func (s forkList) marshalJSON(arena *fastjson.Arena) ([]byte, error) {
+	list := arena.NewArray()
+
+	for i, fork := range s {
+		o := arena.NewObject()
+
+		o.Set("view_id", arena.NewNumberString(strconv.FormatUint(fork.Local.Index, 10)))
+		o.Set("local", marshalForkedRound(arena, fork.Local))
+		o.Set("remote", marshalForkedRound(arena, fork.Remote))
+		o.Set("time", arena.NewString(fork.Time.Format(time.RFC3339)))
+
+		list.SetArrayItem(i, o)
+	}
+
+	return list.MarshalTo(nil), nil
+}
+
+func marshalForkedRound(arena *fastjson.Arena, round wavelet.Round) *fastjson.Value {
+	o := arena.NewObject()
+
+	o.Set("id", arena.NewString(hex.EncodeToString(round.ID[:])))
+	o.Set("merkle_root", arena.NewString(hex.EncodeToString(round.Merkle[:])))
+	o.Set("start_id", arena.NewString(hex.EncodeToString(round.Start.ID[:])))
+	o.Set("end_id", arena.NewString(hex.EncodeToString(round.End.ID[:])))
+	o.Set("applied", arena.NewNumberString(strconv.FormatUint(round.Applied, 10)))
+
+	return o
+}
+
+type round struct {
+	// Internal fields.
+	round *wavelet.Round
+}
+
+func (s *round) marshalJSON(arena *fastjson.Arena) ([]byte, error) {
+	if s.round == nil {
+		return nil, errors.New("insufficient fields specified")
+	}
+
+	o := arena.NewObject()
+
+	o.Set("id", arena.NewString(hex.EncodeToString(s.round.ID[:])))
+	o.Set("index", arena.NewNumberString(strconv.FormatUint(s.round.Index, 10)))
+	o.Set("merkle_root", arena.NewString(hex.EncodeToString(s.round.Merkle[:])))
+	o.Set("applied", arena.NewNumberString(strconv.FormatUint(s.round.Applied, 10)))
+	o.Set("start_id", arena.NewString(hex.EncodeToString(s.round.Start.ID[:])))
+	o.Set("end_id", arena.NewString(hex.EncodeToString(s.round.End.ID[:])))
+	o.Set("start_depth", arena.NewNumberString(strconv.FormatUint(s.round.Start.Depth, 10)))
+	o.Set("end_depth", arena.NewNumberString(strconv.FormatUint(s.round.End.Depth, 10)))
+
+	return o.MarshalTo(nil), nil
+}
+
+type certificate struct {
+	// Internal fields.
+	cert wavelet.Certificate
+}
+
+func (s *certificate) marshalJSON(arena *fastjson.Arena) ([]byte, error) {
+	o := arena.NewObject()
+
+	o.Set("round_id", arena.NewString(hex.EncodeToString(s.cert.RoundID[:])))
+	o.Set("view_id", arena.NewNumberString(strconv.FormatUint(s.cert.RoundIndex, 10)))
+	o.Set("total_stake", arena.NewNumberString(strconv.FormatUint(s.cert.TotalStake, 10)))
+
+	var stake uint64
+
+	votes := arena.NewArray()
+
+	for i, vote := range s.cert.Votes {
+		v := arena.NewObject()
+
+		v.Set("voter", arena.NewString(hex.EncodeToString(vote.Voter[:])))
+		v.Set("stake", arena.NewNumberString(strconv.FormatUint(vote.Stake, 10)))
+		v.Set("bls_public_key", arena.NewString(hex.EncodeToString(vote.BLSPublicKey)))
+		v.Set("bls_key_proof", arena.NewString(hex.EncodeToString(vote.BLSKeyProof)))
+		v.Set("key_signature", arena.NewString(hex.EncodeToString(vote.KeySignature[:])))
+
+		votes.SetArrayItem(i, v)
+
+		stake += vote.Stake
+	}
+
+	o.Set("votes", votes)
+	o.Set("voted_stake", arena.NewNumberString(strconv.FormatUint(stake, 10)))
+
+	if len(s.cert.Aggregate) > 0 {
+		o.Set("aggregate_signature", arena.NewString(hex.EncodeToString(s.cert.Aggregate)))
+	} else {
+		o.Set("aggregate_signature", nil)
+	}
+
+	return o.MarshalTo(nil), nil
+}
+
 type transactionList []*transaction
 
 func (s transactionList) marshalJSON(arena *fastjson.Arena) ([]byte, error) {
+	list, err := s.getArray(arena)
+	if err != nil {
+		return nil, err
+	}
+
+	return list.MarshalTo(nil), nil
+}
+
+func (s transactionList) getArray(arena *fastjson.Arena) (*fastjson.Value, error) {
 	list := arena.NewArray()
 
 	for i, v := range s {
@@ -306,13 +995,43 @@ func (s transactionList) marshalJSON(arena *fastjson.Arena) ([]byte, error) {
 		list.SetArrayItem(i, o)
 	}
 
-	return list.MarshalTo(nil), nil
+	return list, nil
+}
+
+// transactionPage is a page of transactions listed by cursor, alongside an opaque
+// cursor to the next page. The cursor to the next page is null should there be no
+// more transactions to list.
+type transactionPage struct {
+	transactions transactionList
+	nextCursor   string
+}
+
+func (s *transactionPage) marshalJSON(arena *fastjson.Arena) ([]byte, error) {
+	o := arena.NewObject()
+
+	list, err := s.transactions.getArray(arena)
+	if err != nil {
+		return nil, err
+	}
+
+	o.Set("transactions", list)
+
+	if len(s.nextCursor) > 0 {
+		o.Set("next_cursor", arena.NewString(s.nextCursor))
+	} else {
+		o.Set("next_cursor", arena.NewNull())
+	}
+
+	return o.MarshalTo(nil), nil
 }
 
 type account struct {
 	// Internal fields.
 	id     wavelet.AccountID
 	ledger *wavelet.Ledger
+
+	// Only set if the account is queried as of a past round.
+	state *wavelet.AccountState
 }
 
 func (s *account) marshalJSON(arena *fastjson.Arena) ([]byte, error) {
@@ -320,11 +1039,20 @@ func (s *account) marshalJSON(arena *fastjson.Arena) ([]byte, error) {
 		return nil, errors.New("insufficient fields specified")
 	}
 
-	snapshot := s.ledger.Snapshot()
-
 	o := arena.NewObject()
 
 	o.Set("public_key", arena.NewString(hex.EncodeToString(s.id[:])))
+	o.Set("account_address", arena.NewString(wavelet.FormatAddress(s.id)))
+
+	if s.state != nil {
+		o.Set("round", arena.NewNumberString(strconv.FormatUint(s.state.Round, 10)))
+		o.Set("balance", arena.NewNumberString(strconv.FormatUint(s.state.Balance, 10)))
+		o.Set("stake", arena.NewNumberString(strconv.FormatUint(s.state.Stake, 10)))
+
+		return o.MarshalTo(nil), nil
+	}
+
+	snapshot := s.ledger.Snapshot()
 
 	balance, _ := wavelet.ReadAccountBalance(snapshot, s.id)
 	o.Set("balance", arena.NewNumberString(strconv.FormatUint(balance, 10)))
@@ -338,6 +1066,9 @@ func (s *account) marshalJSON(arena *fastjson.Arena) ([]byte, error) {
 	nonce, _ := wavelet.ReadAccountNonce(snapshot, s.id)
 	o.Set("nonce", arena.NewNumberString(strconv.FormatUint(nonce, 10)))
 
+	scheme, _ := wavelet.ReadAccountSignatureScheme(snapshot, s.id)
+	o.Set("signature_scheme", arena.NewString(scheme.String()))
+
 	_, isContract := wavelet.ReadAccountContractCode(snapshot, s.id)
 	if isContract {
 		o.Set("is_contract", arena.NewTrue())
@@ -350,30 +1081,307 @@ func (s *account) marshalJSON(arena *fastjson.Arena) ([]byte, error) {
 		o.Set("num_mem_pages", arena.NewNumberString(strconv.FormatUint(numPages, 10)))
 	}
 
+	if freeze, frozen := wavelet.ReadAccountFrozen(snapshot, s.id); frozen {
+		v := arena.NewObject()
+
+		v.Set("authority", arena.NewString(hex.EncodeToString(freeze.Authority[:])))
+		v.Set("round", arena.NewNumberString(strconv.FormatUint(freeze.Round, 10)))
+
+		o.Set("frozen", v)
+	}
+
 	return o.MarshalTo(nil), nil
 }
 
+type accountHistory struct {
+	// Internal fields.
+	total   uint64
+	entries []wavelet.JournalEntry
+}
+
+func (s *accountHistory) marshalJSON(arena *fastjson.Arena) ([]byte, error) {
+	o := arena.NewObject()
+
+	o.Set("total", arena.NewNumberString(strconv.FormatUint(s.total, 10)))
+
+	list := arena.NewArray()
+
+	for i, entry := range s.entries {
+		v := arena.NewObject()
+
+		v.Set("round", arena.NewNumberString(strconv.FormatUint(entry.Round, 10)))
+
+		if entry.TxID != wavelet.ZeroTransactionID {
+			v.Set("tx_id", arena.NewString(hex.EncodeToString(entry.TxID[:])))
+		} else {
+			v.Set("tx_id", nil)
+		}
+
+		if entry.Credit {
+			v.Set("delta", arena.NewNumberString(strconv.FormatUint(entry.Amount, 10)))
+		} else {
+			v.Set("delta", arena.NewNumberString("-"+strconv.FormatUint(entry.Amount, 10)))
+		}
+
+		v.Set("reason", arena.NewString(entry.Reason.String()))
+
+		list.SetArrayItem(i, v)
+	}
+
+	o.Set("entries", list)
+
+	return o.MarshalTo(nil), nil
+}
+
+type accountRecovery struct {
+	// Internal fields.
+	guardians   *wavelet.Guardians
+	pending     *wavelet.PendingRecovery
+	recoveredTo *wavelet.AccountID
+}
+
+func (s *accountRecovery) marshalJSON(arena *fastjson.Arena) ([]byte, error) {
+	o := arena.NewObject()
+
+	if s.guardians != nil {
+		v := arena.NewObject()
+
+		v.Set("threshold", arena.NewNumberInt(int(s.guardians.Threshold)))
+		v.Set("delay", arena.NewNumberString(strconv.FormatUint(s.guardians.Delay, 10)))
+
+		accounts := arena.NewArray()
+
+		for i, id := range s.guardians.Accounts {
+			accounts.SetArrayItem(i, arena.NewString(hex.EncodeToString(id[:])))
+		}
+
+		v.Set("accounts", accounts)
+
+		o.Set("guardians", v)
+	} else {
+		o.Set("guardians", nil)
+	}
+
+	if s.pending != nil {
+		v := arena.NewObject()
+
+		v.Set("new_key", arena.NewString(hex.EncodeToString(s.pending.NewKey[:])))
+
+		approvals := arena.NewArray()
+
+		for i, id := range s.pending.Approvals {
+			approvals.SetArrayItem(i, arena.NewString(hex.EncodeToString(id[:])))
+		}
+
+		v.Set("approvals", approvals)
+
+		if s.pending.Ready {
+			v.Set("ready", arena.NewTrue())
+			v.Set("ready_round", arena.NewNumberString(strconv.FormatUint(s.pending.ReadyRound, 10)))
+		} else {
+			v.Set("ready", arena.NewFalse())
+			v.Set("ready_round", nil)
+		}
+
+		o.Set("pending", v)
+	} else {
+		o.Set("pending", nil)
+	}
+
+	if s.recoveredTo != nil {
+		o.Set("recovered_to", arena.NewString(hex.EncodeToString(s.recoveredTo[:])))
+	} else {
+		o.Set("recovered_to", nil)
+	}
+
+	return o.MarshalTo(nil), nil
+}
+
+type proposalResponse struct {
+	// Internal fields.
+	id       wavelet.TransactionID
+	proposal wavelet.Proposal
+}
+
+func (s *proposalResponse) marshalJSON(arena *fastjson.Arena) ([]byte, error) {
+	o := arena.NewObject()
+
+	o.Set("id", arena.NewString(hex.EncodeToString(s.id[:])))
+	o.Set("proposer", arena.NewString(hex.EncodeToString(s.proposal.Proposer[:])))
+	o.Set("param", arena.NewString(sys.ParamLabel(s.proposal.Param)))
+	o.Set("value", arena.NewNumberString(strconv.FormatUint(s.proposal.Value, 10)))
+	o.Set("activation_round", arena.NewNumberString(strconv.FormatUint(s.proposal.ActivationRound, 10)))
+
+	voters := arena.NewArray()
+
+	for i, id := range s.proposal.Voters {
+		voters.SetArrayItem(i, arena.NewString(hex.EncodeToString(id[:])))
+	}
+
+	o.Set("voters", voters)
+	o.Set("voted_stake", arena.NewNumberString(strconv.FormatUint(s.proposal.VotedStake, 10)))
+
+	if s.proposal.Passed {
+		o.Set("passed", arena.NewTrue())
+	} else {
+		o.Set("passed", arena.NewFalse())
+	}
+
+	return o.MarshalTo(nil), nil
+}
+
+type validator struct {
+	// Internal fields.
+	id     wavelet.AccountID
+	stake  uint64
+	reward uint64
+	weight float64
+}
+
+func (s *validator) getObject(arena *fastjson.Arena) *fastjson.Value {
+	o := arena.NewObject()
+
+	o.Set("public_key", arena.NewString(hex.EncodeToString(s.id[:])))
+	o.Set("account_address", arena.NewString(wavelet.FormatAddress(s.id)))
+	o.Set("stake", arena.NewNumberString(strconv.FormatUint(s.stake, 10)))
+	o.Set("delegated_stake", arena.NewNumberInt(0)) // Stake may not yet be delegated; all stake is self-bonded.
+	o.Set("reward", arena.NewNumberString(strconv.FormatUint(s.reward, 10)))
+	o.Set("weight", arena.NewNumberFloat64(s.weight))
+
+	return o
+}
+
+type validatorList []*validator
+
+func (s validatorList) marshalJSON(arena *fastjson.Arena) ([]byte, error) {
+	list := arena.NewArray()
+
+	for i, v := range s {
+		list.SetArrayItem(i, v.getObject(arena))
+	}
+
+	return list.MarshalTo(nil), nil
+}
+
+// ErrorCode is a machine-readable code identifying why a request failed, such
+// that clients may branch on the kind of failure without parsing messages.
+type ErrorCode string
+
+const (
+	CodeBadRequest      ErrorCode = "bad_request"
+	CodeUnauthorized    ErrorCode = "unauthorized"
+	CodeNotFound        ErrorCode = "not_found"
+	CodeRateLimited     ErrorCode = "rate_limited"
+	CodeQueueFull       ErrorCode = "queue_full"
+	CodeGraphFull       ErrorCode = "graph_full"
+	CodeReadOnly        ErrorCode = "read_only"
+	CodeTimeout         ErrorCode = "timeout"
+	CodeUnavailable     ErrorCode = "unavailable"
+	CodeInternal        ErrorCode = "internal"
+	CodeMessagesDropped ErrorCode = "messages_dropped"
+)
+
+// errResponse is the error envelope rendered by every failed HTTP request, and
+// pushed to websocket clients should they miss out on any messages. Retryable
+// denotes whether the request may succeed should it be retried as-is later on.
 type errResponse struct {
 	Err            error `json:"-"` // low-level runtime error
 	HTTPStatusCode int   `json:"-"` // http response status code
+
+	Code      ErrorCode
+	Details   map[string]string
+	Retryable bool
 }
 
-func (e *errResponse) marshalJSON(arena *fastjson.Arena) ([]byte, error) {
+func (e *errResponse) getObject(arena *fastjson.Arena) *fastjson.Value {
 	o := arena.NewObject()
 
-	o.Set("status", arena.NewString("Bad request."))
+	o.Set("code", arena.NewString(string(e.Code)))
+	o.Set("message", arena.NewString(e.message()))
+
+	if len(e.Details) > 0 {
+		keys := make([]string, 0, len(e.Details))
+
+		for key := range e.Details {
+			keys = append(keys, key)
+		}
+
+		sort.Strings(keys)
 
+		details := arena.NewObject()
+
+		for _, key := range keys {
+			details.Set(key, arena.NewString(e.Details[key]))
+		}
+
+		o.Set("details", details)
+	}
+
+	if e.Retryable {
+		o.Set("retryable", arena.NewTrue())
+	} else {
+		o.Set("retryable", arena.NewFalse())
+	}
+
+	return o
+}
+
+// message returns the message of the low-level error, or the status text of
+// the response should there be none.
+func (e *errResponse) message() string {
 	if e.Err != nil {
-		o.Set("error", arena.NewString(e.Err.Error()))
+		return e.Err.Error()
 	}
 
+	return http.StatusText(e.HTTPStatusCode)
+}
+
+func (e *errResponse) marshalJSON(arena *fastjson.Arena) ([]byte, error) {
+	o := arena.NewObject()
+	o.Set("error", e.getObject(arena))
+
 	return o.MarshalTo(nil), nil
 }
 
+// withCode overrides the error code of the response.
+func (e *errResponse) withCode(code ErrorCode) *errResponse {
+	e.Code = code
+	return e
+}
+
+// withDetail attaches a detail to the response under key.
+func (e *errResponse) withDetail(key, value string) *errResponse {
+	if e.Details == nil {
+		e.Details = make(map[string]string)
+	}
+
+	e.Details[key] = value
+
+	return e
+}
+
+// writeError writes e as the response to ctx.
+func writeError(ctx *fasthttp.RequestCtx, arena *fastjson.Arena, e *errResponse) {
+	ctx.SetContentType("application/json")
+	ctx.Response.SetStatusCode(e.HTTPStatusCode)
+
+	b, _ := e.marshalJSON(arena)
+	ctx.Response.SetBody(b)
+}
+
 func ErrBadRequest(err error) *errResponse {
 	return &errResponse{
 		Err:            err,
 		HTTPStatusCode: http.StatusBadRequest,
+		Code:           CodeBadRequest,
+	}
+}
+
+func ErrUnauthorized(err error) *errResponse {
+	return &errResponse{
+		Err:            err,
+		HTTPStatusCode: http.StatusUnauthorized,
+		Code:           CodeUnauthorized,
 	}
 }
 
@@ -381,6 +1389,34 @@ func ErrNotFound(err error) *errResponse {
 	return &errResponse{
 		Err:            err,
 		HTTPStatusCode: http.StatusNotFound,
+		Code:           CodeNotFound,
+	}
+}
+
+func ErrTooManyRequests(err error) *errResponse {
+	return &errResponse{
+		Err:            err,
+		HTTPStatusCode: http.StatusTooManyRequests,
+		Code:           CodeRateLimited,
+		Retryable:      true,
+	}
+}
+
+func ErrTimeout(err error) *errResponse {
+	return &errResponse{
+		Err:            err,
+		HTTPStatusCode: http.StatusRequestTimeout,
+		Code:           CodeTimeout,
+		Retryable:      true,
+	}
+}
+
+func ErrUnavailable(err error) *errResponse {
+	return &errResponse{
+		Err:            err,
+		HTTPStatusCode: http.StatusServiceUnavailable,
+		Code:           CodeUnavailable,
+		Retryable:      true,
 	}
 }
 
@@ -388,5 +1424,6 @@ func ErrInternal(err error) *errResponse {
 	return &errResponse{
 		Err:            err,
 		HTTPStatusCode: http.StatusInternalServerError,
+		Code:           CodeInternal,
 	}
 }
@@ -20,6 +20,7 @@
 package api
 
 import (
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/valyala/fastjson"
 	"testing"
@@ -72,6 +73,33 @@ func TestSendTransactionRequestTag(t *testing.T) {
 	assert.Error(t, req.bind(&fastjson.Parser{}, []byte(typeInvalid)))
 }
 
+func TestSendTransactionRequestNonce(t *testing.T) {
+	req := new(sendTransactionRequest)
+
+	sequenced := `
+		{
+			"tag": 1,
+			"nonce": 42,
+			"sender": "3132333435363738393031323334353637383930313233343536373839303132",
+			"payload": "7061796C6F6164",
+			"signature": "31323334353637383930313233343536373839303132333435363738393031323132333435363738393031323334353637383930313233343536373839303132"
+		}
+	`
+	assert.NoError(t, req.bind(&fastjson.Parser{}, []byte(sequenced)))
+	assert.EqualValues(t, 42, req.Nonce)
+
+	invalid := `
+		{
+			"tag": 1,
+			"nonce": -1,
+			"sender": "3132333435363738393031323334353637383930313233343536373839303132",
+			"payload": "7061796C6F6164",
+			"signature": "31323334353637383930313233343536373839303132333435363738393031323132333435363738393031323334353637383930313233343536373839303132"
+		}
+	`
+	assert.Error(t, req.bind(&fastjson.Parser{}, []byte(invalid)))
+}
+
 func TestSendTransactionRequestMissingFields(t *testing.T) {
 	req := new(sendTransactionRequest)
 
@@ -105,3 +133,13 @@ func TestSendTransactionRequestMissingFields(t *testing.T) {
 	`
 	assert.Error(t, req.bind(&fastjson.Parser{}, []byte(missingSignature)))
 }
+
+func TestErrResponse(t *testing.T) {
+	buf, err := ErrTooManyRequests(errors.New("slow down")).withDetail("limit_per_second", "10").withDetail("a", "b").marshalJSON(new(fastjson.Arena))
+	assert.NoError(t, err)
+	assert.Equal(t, `{"error":{"code":"rate_limited","message":"slow down","details":{"a":"b","limit_per_second":"10"},"retryable":true}}`, string(buf))
+
+	buf, err = ErrNotFound(nil).marshalJSON(new(fastjson.Arena))
+	assert.NoError(t, err)
+	assert.Equal(t, `{"error":{"code":"not_found","message":"Not Found","retryable":false}}`, string(buf))
+}
@@ -20,10 +20,12 @@
 package api
 
 import (
+	"github.com/pkg/errors"
 	"github.com/valyala/fasthttp"
+	"github.com/valyala/fastjson"
 	"golang.org/x/time/rate"
 	"math"
-	"net/http"
+	"strconv"
 	"sync"
 	"time"
 )
@@ -120,7 +122,9 @@ func (r *rateLimiter) limit(key string) func(fasthttp.RequestHandler) fasthttp.R
 			l := r.getLimiter(key + addr)
 
 			if !l.limiter.Allow() {
-				ctx.Error(http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+				ctx.Response.Header.Set("Retry-After", "1")
+				writeError(ctx, new(fastjson.Arena), ErrTooManyRequests(errors.New("too many requests")).
+					withDetail("limit_per_second", strconv.FormatFloat(r.max, 'f', -1, 64)))
 				return
 			}
 
@@ -0,0 +1,108 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/perlin-network/wavelet/log"
+	"github.com/rs/zerolog"
+	"github.com/valyala/fasthttp"
+)
+
+const (
+	headerRequestID = "X-Request-ID"
+
+	// maxRequestIDLen is the longest request ID accepted from clients. Longer
+	// request IDs are replaced by ones generated by the node.
+	maxRequestIDLen = 128
+)
+
+// userValueRequestID is the key under which the ID of a request is stored as a
+// user value of its context.
+const userValueRequestID = "request_id"
+
+// tagRequestID assigns every request an ID, which is echoed back under the
+// X-Request-ID response header. Clients may pick the ID of their request by
+// specifying the X-Request-ID request header, such that a request may be traced
+// through the logs of the node by an ID both the client and operator know of.
+func tagRequestID(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+	return func(ctx *fasthttp.RequestCtx) {
+		id := string(ctx.Request.Header.Peek(headerRequestID))
+
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+
+		ctx.SetUserValue(userValueRequestID, id)
+
+		next(ctx)
+
+		// Set the header only once the request is served, as the response may
+		// have been reset while being served.
+		ctx.Response.Header.Set(headerRequestID, id)
+	}
+}
+
+// requestID returns the ID assigned to the request being served by ctx, or an
+// empty string should the request not have been assigned one.
+func requestID(ctx *fasthttp.RequestCtx) string {
+	id, _ := ctx.UserValue(userValueRequestID).(string)
+	return id
+}
+
+// requestLogger returns logger, with its logs tagged with the ID of the request
+// being served by ctx.
+func requestLogger(ctx *fasthttp.RequestCtx, logger zerolog.Logger) zerolog.Logger {
+	id := requestID(ctx)
+	if len(id) == 0 {
+		return logger
+	}
+
+	return logger.With().Str(log.KeyRequestID, id).Logger()
+}
+
+// validRequestID checks that id is non-empty, not too long, and only comprised
+// of printable ASCII characters, such that it may safely be logged and echoed
+// back as a header.
+func validRequestID(id string) bool {
+	if len(id) == 0 || len(id) > maxRequestIDLen {
+		return false
+	}
+
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+
+	return true
+}
+
+func newRequestID() string {
+	var buf [16]byte
+
+	if _, err := rand.Read(buf[:]); err != nil {
+		panic(err)
+	}
+
+	return hex.EncodeToString(buf[:])
+}
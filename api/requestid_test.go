@@ -0,0 +1,72 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package api
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fasthttp"
+)
+
+func TestTagRequestID(t *testing.T) {
+	var seen string
+
+	handler := tagRequestID(func(ctx *fasthttp.RequestCtx) {
+		seen = requestID(ctx)
+	})
+
+	tests := []struct {
+		name     string
+		header   string
+		generate bool
+	}{
+		{name: "missing", header: "", generate: true},
+		{name: "supplied", header: "abc-123", generate: false},
+		{name: "whitespace", header: "abc def", generate: true},
+		{name: "too long", header: strings.Repeat("a", maxRequestIDLen+1), generate: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			request := httptest.NewRequest("GET", "http://localhost/ledger", nil)
+			if len(tc.header) > 0 {
+				request.Header.Set(headerRequestID, tc.header)
+			}
+
+			w, err := serveHandler(handler, request)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			id := w.Header.Get(headerRequestID)
+			assert.Equal(t, seen, id)
+
+			if tc.generate {
+				assert.Len(t, id, 32)
+				assert.NotEqual(t, tc.header, id)
+			} else {
+				assert.Equal(t, tc.header, id)
+			}
+		})
+	}
+}
@@ -0,0 +1,326 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package api
+
+import (
+	"encoding/hex"
+	"net/http"
+	"strconv"
+
+	"github.com/perlin-network/wavelet"
+	"github.com/pkg/errors"
+	"github.com/valyala/fasthttp"
+	"github.com/valyala/fastjson"
+)
+
+// Error codes defined by the JSON-RPC 2.0 specification.
+const (
+	rpcParseError     = -32700
+	rpcInvalidRequest = -32600
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+	rpcInternalError  = -32603
+
+	// Reserved for implementation-defined server errors.
+	rpcServerError = -32000
+)
+
+// rpcMethod serves a JSON-RPC method. params is nil should the request not
+// specify any, and is otherwise either an object or an array.
+type rpcMethod func(g *Gateway, ctx *fasthttp.RequestCtx, params *fastjson.Value) (marshalableJSON, *errResponse)
+
+// rpcMethods lists the JSON-RPC methods served, each mirroring an endpoint of
+// the REST API.
+var rpcMethods = map[string]rpcMethod{
+	"getAccount":      rpcGetAccount,
+	"getTransaction":  rpcGetTransaction,
+	"sendTransaction": rpcSendTransaction,
+	"getRound":        rpcGetRound,
+}
+
+// rpc serves JSON-RPC 2.0 requests, either one at a time or in batches.
+// Notifications are served, but are never responded to.
+func (g *Gateway) rpc(ctx *fasthttp.RequestCtx) {
+	parser := g.parserPool.Get()
+	defer g.parserPool.Put(parser)
+
+	arena := g.arenaPool.Get()
+	defer g.arenaPool.Put(arena)
+
+	req, err := parser.ParseBytes(ctx.PostBody())
+	if err != nil {
+		g.renderRPC(ctx, rpcError(arena, nil, rpcParseError, errors.Wrap(err, "could not parse request body").Error(), nil))
+		return
+	}
+
+	if req.Type() != fastjson.TypeArray {
+		g.renderRPC(ctx, g.serveRPC(ctx, arena, req))
+		return
+	}
+
+	batch := req.GetArray()
+
+	if len(batch) == 0 {
+		g.renderRPC(ctx, rpcError(arena, nil, rpcInvalidRequest, "batch must not be empty", nil))
+		return
+	}
+
+	var body []byte
+
+	for _, req := range batch {
+		res := g.serveRPC(ctx, arena, req)
+		if res == nil {
+			continue
+		}
+
+		if len(body) == 0 {
+			body = append(body, '[')
+		} else {
+			body = append(body, ',')
+		}
+
+		body = append(body, res...)
+	}
+
+	if len(body) > 0 {
+		body = append(body, ']')
+	}
+
+	g.renderRPC(ctx, body)
+}
+
+// serveRPC serves a single JSON-RPC request, and returns its response. It
+// returns nil should the request be a notification.
+func (g *Gateway) serveRPC(ctx *fasthttp.RequestCtx, arena *fastjson.Arena, req *fastjson.Value) []byte {
+	if req.Type() != fastjson.TypeObject {
+		return rpcError(arena, nil, rpcInvalidRequest, "request must be an object", nil)
+	}
+
+	id := req.Get("id")
+
+	if id != nil {
+		switch id.Type() {
+		case fastjson.TypeString, fastjson.TypeNumber, fastjson.TypeNull:
+		default:
+			return rpcError(arena, nil, rpcInvalidRequest, "id must be a string, a number, or null", nil)
+		}
+	}
+
+	if version := req.GetStringBytes("jsonrpc"); string(version) != "2.0" {
+		return rpcError(arena, id, rpcInvalidRequest, `jsonrpc must be "2.0"`, nil)
+	}
+
+	name := req.GetStringBytes("method")
+	if name == nil {
+		return rpcError(arena, id, rpcInvalidRequest, "method must be a string", nil)
+	}
+
+	method, exists := rpcMethods[string(name)]
+	if !exists {
+		return rpcError(arena, id, rpcMethodNotFound, "method "+strconv.Quote(string(name))+" does not exist", nil)
+	}
+
+	params := req.Get("params")
+
+	if params != nil && params.Type() != fastjson.TypeObject && params.Type() != fastjson.TypeArray {
+		return rpcError(arena, id, rpcInvalidParams, "params must be an object or an array", nil)
+	}
+
+	res, e := method(g, ctx, params)
+
+	if id == nil {
+		return nil
+	}
+
+	if e != nil {
+		return rpcError(arena, id, rpcErrorCode(e), e.message(), e)
+	}
+
+	result, err := res.marshalJSON(arena)
+	if err != nil {
+		return rpcError(arena, id, rpcInternalError, errors.Wrap(err, "render error").Error(), nil)
+	}
+
+	buf := append([]byte(`{"jsonrpc":"2.0","id":`), id.MarshalTo(nil)...)
+	buf = append(buf, `,"result":`...)
+	buf = append(buf, result...)
+
+	return append(buf, '}')
+}
+
+// renderRPC writes body as the response to ctx, or responds with no content
+// should body be empty.
+func (g *Gateway) renderRPC(ctx *fasthttp.RequestCtx, body []byte) {
+	if len(body) == 0 {
+		ctx.Response.SetStatusCode(http.StatusNoContent)
+		return
+	}
+
+	g.sign(ctx, body)
+
+	ctx.SetContentType("application/json")
+	ctx.Response.SetStatusCode(http.StatusOK)
+	ctx.Response.SetBody(body)
+}
+
+// rpcError marshals a JSON-RPC error response. The error the REST API would
+// have responded with, should there be one, is attached as its data.
+func rpcError(arena *fastjson.Arena, id *fastjson.Value, code int, message string, e *errResponse) []byte {
+	o := arena.NewObject()
+
+	o.Set("jsonrpc", arena.NewString("2.0"))
+
+	if id != nil {
+		o.Set("id", id)
+	} else {
+		o.Set("id", arena.NewNull())
+	}
+
+	obj := arena.NewObject()
+	obj.Set("code", arena.NewNumberInt(code))
+	obj.Set("message", arena.NewString(message))
+
+	if e != nil {
+		obj.Set("data", e.getObject(arena))
+	}
+
+	o.Set("error", obj)
+
+	return o.MarshalTo(nil)
+}
+
+// rpcErrorCode maps an error the REST API would have responded with to a
+// JSON-RPC error code.
+func rpcErrorCode(e *errResponse) int {
+	switch {
+	case e.Code == CodeBadRequest:
+		return rpcInvalidParams
+	case e.HTTPStatusCode == http.StatusInternalServerError:
+		return rpcInternalError
+	default:
+		return rpcServerError
+	}
+}
+
+// rpcParam returns the parameter either under name should params be an object,
+// or at position should params be an array. It returns nil should the
+// parameter not be given.
+func rpcParam(params *fastjson.Value, name string, position int) *fastjson.Value {
+	if params == nil {
+		return nil
+	}
+
+	if params.Type() == fastjson.TypeArray {
+		return params.Get(strconv.Itoa(position))
+	}
+
+	return params.Get(name)
+}
+
+func rpcStringParam(params *fastjson.Value, name string, position int) (string, *errResponse) {
+	v := rpcParam(params, name, position)
+	if v == nil {
+		return "", ErrBadRequest(errors.Errorf("missing param %q", name))
+	}
+
+	buf, err := v.StringBytes()
+	if err != nil {
+		return "", ErrBadRequest(errors.Errorf("param %q must be a string", name))
+	}
+
+	return string(buf), nil
+}
+
+func rpcGetAccount(g *Gateway, _ *fasthttp.RequestCtx, params *fastjson.Value) (marshalableJSON, *errResponse) {
+	param, e := rpcStringParam(params, "id", 0)
+	if e != nil {
+		return nil, e
+	}
+
+	id, err := wavelet.ParseAccountID(param)
+	if err != nil {
+		return nil, ErrBadRequest(err)
+	}
+
+	return &account{ledger: g.ledger, id: id}, nil
+}
+
+func rpcGetTransaction(g *Gateway, _ *fasthttp.RequestCtx, params *fastjson.Value) (marshalableJSON, *errResponse) {
+	param, e := rpcStringParam(params, "id", 0)
+	if e != nil {
+		return nil, e
+	}
+
+	var id wavelet.TransactionID
+
+	buf, err := hex.DecodeString(param)
+	if err != nil || len(buf) != wavelet.SizeTransactionID {
+		return nil, ErrBadRequest(errors.Errorf("transaction ID must be %d bytes long and presented as valid hex", wavelet.SizeTransactionID))
+	}
+
+	copy(id[:], buf)
+
+	tx, e := g.lookupTransaction(id)
+	if e != nil {
+		return nil, e
+	}
+
+	return g.newTransaction(tx), nil
+}
+
+// rpcSendTransaction accepts the same fields as /tx/send, given either as the
+// params object itself or as the first element of the params array.
+func rpcSendTransaction(g *Gateway, ctx *fasthttp.RequestCtx, params *fastjson.Value) (marshalableJSON, *errResponse) {
+	if params != nil && params.Type() == fastjson.TypeArray {
+		params = params.Get("0")
+	}
+
+	if params == nil || params.Type() != fastjson.TypeObject {
+		return nil, ErrBadRequest(errors.New("params must hold the transaction to send as an object"))
+	}
+
+	res, e := g.submitTransaction(ctx, params.MarshalTo(nil))
+	if e != nil {
+		return nil, e
+	}
+
+	return res, nil
+}
+
+// rpcGetRound returns the finalized round at an index, or the latest finalized
+// round should no index be given.
+func rpcGetRound(g *Gateway, _ *fasthttp.RequestCtx, params *fastjson.Value) (marshalableJSON, *errResponse) {
+	v := rpcParam(params, "index", 0)
+	if v == nil {
+		return &round{round: g.ledger.Rounds().Latest()}, nil
+	}
+
+	index, err := v.Uint64()
+	if err != nil {
+		return nil, ErrBadRequest(errors.New(`param "index" must be an unsigned integer`))
+	}
+
+	r, err := g.ledger.Rounds().GetByIndex(index)
+	if err != nil {
+		return nil, ErrNotFound(err)
+	}
+
+	return &round{round: r}, nil
+}
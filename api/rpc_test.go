@@ -0,0 +1,192 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package api
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/perlin-network/noise/skademlia"
+	"github.com/perlin-network/wavelet"
+	"github.com/perlin-network/wavelet/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRPC(t *testing.T) {
+	gateway := New()
+	gateway.setup()
+
+	keys, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	genesis := `{"400056ee68a7cc2695222df05ea76875bc27ec6e61e8e62317c336157019c405": {"balance": 100, "stake": 300}}`
+
+	gateway.ledger = wavelet.NewLedger(store.NewInmem(), skademlia.NewClient(":0", keys), &genesis)
+
+	round := gateway.ledger.Rounds().Latest()
+	rootID := hex.EncodeToString(round.End.ID[:])
+
+	call := func(body string) (int, []byte) {
+		w, err := serve(gateway.router, httptest.NewRequest("POST", "http://localhost/rpc", bytes.NewReader([]byte(body))))
+		assert.NoError(t, err)
+
+		response, err := ioutil.ReadAll(w.Body)
+		assert.NoError(t, err)
+
+		return w.StatusCode, response
+	}
+
+	single := func(body string) map[string]interface{} {
+		code, response := call(body)
+		assert.Equal(t, http.StatusOK, code)
+
+		var res map[string]interface{}
+		assert.NoError(t, json.Unmarshal(response, &res), string(response))
+		assert.Equal(t, "2.0", res["jsonrpc"])
+
+		return res
+	}
+
+	errorCode := func(res map[string]interface{}) float64 {
+		if !assert.Contains(t, res, "error") {
+			return 0
+		}
+
+		return res["error"].(map[string]interface{})["code"].(float64)
+	}
+
+	t.Run("getAccount", func(t *testing.T) {
+		res := single(`{"jsonrpc":"2.0","id":1,"method":"getAccount","params":{"id":"400056ee68a7cc2695222df05ea76875bc27ec6e61e8e62317c336157019c405"}}`)
+		assert.EqualValues(t, 1, res["id"])
+
+		result := res["result"].(map[string]interface{})
+		assert.EqualValues(t, 100, result["balance"])
+		assert.EqualValues(t, 300, result["stake"])
+
+		res = single(`{"jsonrpc":"2.0","id":"a","method":"getAccount","params":["400056ee68a7cc2695222df05ea76875bc27ec6e61e8e62317c336157019c405"]}`)
+		assert.Equal(t, "a", res["id"])
+		assert.EqualValues(t, 100, res["result"].(map[string]interface{})["balance"])
+
+		res = single(`{"jsonrpc":"2.0","id":2,"method":"getAccount","params":{"id":"nope"}}`)
+		assert.EqualValues(t, rpcInvalidParams, errorCode(res))
+
+		res = single(`{"jsonrpc":"2.0","id":3,"method":"getAccount"}`)
+		assert.EqualValues(t, rpcInvalidParams, errorCode(res))
+	})
+
+	t.Run("getTransaction", func(t *testing.T) {
+		res := single(`{"jsonrpc":"2.0","id":1,"method":"getTransaction","params":["` + rootID + `"]}`)
+
+		result := res["result"].(map[string]interface{})
+		assert.Equal(t, rootID, result["id"])
+		assert.Equal(t, "applied", result["status"])
+
+		res = single(`{"jsonrpc":"2.0","id":2,"method":"getTransaction","params":["` + hex.EncodeToString(make([]byte, wavelet.SizeTransactionID)) + `"]}`)
+		assert.EqualValues(t, rpcServerError, errorCode(res))
+		assert.Equal(t, string(CodeNotFound), res["error"].(map[string]interface{})["data"].(map[string]interface{})["code"])
+	})
+
+	t.Run("getRound", func(t *testing.T) {
+		res := single(`{"jsonrpc":"2.0","id":1,"method":"getRound"}`)
+
+		result := res["result"].(map[string]interface{})
+		assert.EqualValues(t, 0, result["index"])
+		assert.Equal(t, rootID, result["end_id"])
+
+		res = single(`{"jsonrpc":"2.0","id":2,"method":"getRound","params":{"index":0}}`)
+		assert.Equal(t, hex.EncodeToString(round.ID[:]), res["result"].(map[string]interface{})["id"])
+
+		res = single(`{"jsonrpc":"2.0","id":3,"method":"getRound","params":{"index":100}}`)
+		assert.EqualValues(t, rpcServerError, errorCode(res))
+	})
+
+	t.Run("sendTransaction", func(t *testing.T) {
+		ledger := gateway.ledger
+		defer func() { gateway.ledger = ledger }()
+
+		// Detach the ledger such that the transaction is validated without
+		// having to wait for the ledger to have send quota available.
+
+		gateway.ledger = nil
+
+		res := single(`{"jsonrpc":"2.0","id":1,"method":"sendTransaction","params":{}}`)
+		assert.EqualValues(t, rpcInvalidParams, errorCode(res))
+		assert.Contains(t, res["error"].(map[string]interface{})["message"], "missing sender")
+
+		res = single(`{"jsonrpc":"2.0","id":2,"method":"sendTransaction","params":[1]}`)
+		assert.EqualValues(t, rpcInvalidParams, errorCode(res))
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		res := single(`{"jsonrpc":"2.0","id":1,"method":"getAccount"`)
+		assert.EqualValues(t, rpcParseError, errorCode(res))
+		assert.Nil(t, res["id"])
+
+		res = single(`{"id":1,"method":"getRound"}`)
+		assert.EqualValues(t, rpcInvalidRequest, errorCode(res))
+
+		res = single(`{"jsonrpc":"2.0","id":1,"method":"getBlock"}`)
+		assert.EqualValues(t, rpcMethodNotFound, errorCode(res))
+
+		res = single(`{"jsonrpc":"2.0","id":1,"method":"getRound","params":1}`)
+		assert.EqualValues(t, rpcInvalidParams, errorCode(res))
+
+		res = single(`[]`)
+		assert.EqualValues(t, rpcInvalidRequest, errorCode(res))
+	})
+
+	t.Run("batch", func(t *testing.T) {
+		code, response := call(`[
+			{"jsonrpc":"2.0","id":1,"method":"getRound"},
+			{"jsonrpc":"2.0","method":"getRound"},
+			{"jsonrpc":"2.0","id":2,"method":"getBlock"},
+			1
+		]`)
+		assert.Equal(t, http.StatusOK, code)
+
+		var res []map[string]interface{}
+		assert.NoError(t, json.Unmarshal(response, &res), string(response))
+
+		if assert.Len(t, res, 3) {
+			assert.EqualValues(t, 1, res[0]["id"])
+			assert.Contains(t, res[0], "result")
+
+			assert.EqualValues(t, 2, res[1]["id"])
+			assert.EqualValues(t, rpcMethodNotFound, errorCode(res[1]))
+
+			assert.Nil(t, res[2]["id"])
+			assert.EqualValues(t, rpcInvalidRequest, errorCode(res[2]))
+		}
+	})
+
+	t.Run("notifications", func(t *testing.T) {
+		code, response := call(`{"jsonrpc":"2.0","method":"getRound"}`)
+		assert.Equal(t, http.StatusNoContent, code)
+		assert.Empty(t, response)
+
+		code, _ = call(`[{"jsonrpc":"2.0","method":"getRound"}]`)
+		assert.Equal(t, http.StatusNoContent, code)
+	})
+}
@@ -0,0 +1,100 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package api
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"strconv"
+
+	"github.com/perlin-network/noise/edwards25519"
+	"github.com/perlin-network/wavelet"
+	"github.com/valyala/fasthttp"
+	"golang.org/x/crypto/blake2b"
+)
+
+const (
+	HeaderPublicKey = "X-Wavelet-Public-Key"
+	HeaderSignature = "X-Wavelet-Signature"
+	HeaderRound     = "X-Wavelet-Round"
+	HeaderRoundID   = "X-Wavelet-Round-ID"
+)
+
+type GatewayOption func(*Gateway)
+
+// WithSignedResponses has the gateway sign the body of every successful response
+// with the nodes key, alongside the request URI and the latest round of the
+// ledger at the time of responding. The signature and round are attached to the
+// response as headers, such that downstream services may prove what a specific
+// node told them.
+func WithSignedResponses() GatewayOption {
+	return func(g *Gateway) {
+		g.signResponses = true
+	}
+}
+
+// ResponseMessage returns the message which a node signs over when responding
+// to a request for uri with body, at the time when the ledger was at the round
+// with the given index and ID.
+func ResponseMessage(uri []byte, roundIndex uint64, roundID wavelet.RoundID, body []byte) []byte {
+	hash, _ := blake2b.New256(nil)
+
+	var buf [8]byte
+
+	binary.BigEndian.PutUint64(buf[:], uint64(len(uri)))
+	_, _ = hash.Write(buf[:])
+	_, _ = hash.Write(uri)
+
+	binary.BigEndian.PutUint64(buf[:], roundIndex)
+	_, _ = hash.Write(buf[:])
+	_, _ = hash.Write(roundID[:])
+
+	_, _ = hash.Write(body)
+
+	return hash.Sum(nil)
+}
+
+// VerifyResponse verifies that a response was signed by the node with the given
+// public key, given the headers that the node attached to it.
+func VerifyResponse(publicKey wavelet.AccountID, uri []byte, roundIndex uint64, roundID wavelet.RoundID, body []byte, signature wavelet.Signature) bool {
+	return edwards25519.Verify(publicKey, ResponseMessage(uri, roundIndex, roundID, body), signature)
+}
+
+func (g *Gateway) sign(ctx *fasthttp.RequestCtx, body []byte) {
+	if !g.signResponses || g.signer == nil || g.ledger == nil {
+		return
+	}
+
+	round := g.ledger.Rounds().Latest()
+	publicKey := g.signer.PublicKey()
+
+	signature, err := g.signer.Sign(ResponseMessage(ctx.RequestURI(), round.Index, round.ID, body))
+	if err != nil {
+		logger := g.logs().Node()
+		logger.Warn().Err(err).Msg("Failed to sign a response.")
+
+		return
+	}
+
+	ctx.Response.Header.Set(HeaderPublicKey, hex.EncodeToString(publicKey[:]))
+	ctx.Response.Header.Set(HeaderSignature, hex.EncodeToString(signature[:]))
+	ctx.Response.Header.Set(HeaderRound, strconv.FormatUint(round.Index, 10))
+	ctx.Response.Header.Set(HeaderRoundID, hex.EncodeToString(round.ID[:]))
+}
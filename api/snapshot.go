@@ -0,0 +1,98 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package api
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"github.com/valyala/fasthttp"
+)
+
+// WithStateSnapshots has the gateway serve the state snapshot of the latest
+// finalized round, such that new nodes may bootstrap from it, or from a mirror
+// of it. Requests for state snapshots must carry token as a bearer token in
+// their Authorization header.
+func WithStateSnapshots(token string) GatewayOption {
+	return func(g *Gateway) {
+		g.snapshotToken = token
+	}
+}
+
+func (g *Gateway) snapshotScope(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+	expected := []byte("Bearer " + g.snapshotToken)
+
+	return fasthttp.RequestHandler(func(ctx *fasthttp.RequestCtx) {
+		if subtle.ConstantTimeCompare(ctx.Request.Header.Peek("Authorization"), expected) != 1 {
+			g.renderError(ctx, ErrUnauthorized(errors.New("a valid snapshot token must be provided as a bearer token")))
+			return
+		}
+
+		next(ctx)
+	})
+}
+
+// getStateSnapshot serves the state snapshot of the latest finalized round.
+// Partial downloads may be resumed with range requests, made conditional on
+// the state snapshot not having changed with an If-Range header.
+func (g *Gateway) getStateSnapshot(ctx *fasthttp.RequestCtx) {
+	round, buf, err := g.ledger.StateSnapshot()
+	if err != nil {
+		g.renderError(ctx, ErrUnavailable(errors.Wrap(err, "failed to build state snapshot")))
+		return
+	}
+
+	tag := []byte(`"` + hex.EncodeToString(round.ID[:]) + `"`)
+
+	ctx.Response.Header.SetBytesV("ETag", tag)
+	ctx.Response.Header.Set("Accept-Ranges", "bytes")
+	ctx.Response.Header.Set("Content-Type", "application/octet-stream")
+	ctx.Response.Header.Set("Content-Disposition", "attachment; filename=snapshot-"+strconv.FormatUint(round.Index, 10)+".bin")
+	ctx.Response.Header.Set("X-Round-Index", strconv.FormatUint(round.Index, 10))
+
+	byteRange := ctx.Request.Header.Peek("Range")
+
+	// Serve the entire state snapshot should the state snapshot the client
+	// has partially downloaded be out of date.
+
+	if ifRange := ctx.Request.Header.Peek("If-Range"); len(ifRange) > 0 && !bytes.Equal(ifRange, tag) {
+		byteRange = nil
+	}
+
+	if len(byteRange) == 0 {
+		ctx.Response.SetBody(buf)
+		return
+	}
+
+	start, end, err := fasthttp.ParseByteRange(byteRange, len(buf))
+	if err != nil {
+		ctx.Response.Header.Set("Content-Range", "bytes */"+strconv.Itoa(len(buf)))
+		ctx.Response.SetStatusCode(http.StatusRequestedRangeNotSatisfiable)
+		return
+	}
+
+	ctx.Response.Header.SetContentRange(start, end, len(buf))
+	ctx.Response.SetStatusCode(http.StatusPartialContent)
+	ctx.Response.SetBody(buf[start : end+1])
+}
@@ -0,0 +1,94 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package api
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/perlin-network/wavelet"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetStateSnapshot(t *testing.T) {
+	gateway := New()
+	gateway.setup()
+
+	w, err := serve(gateway.router, httptest.NewRequest("GET", "http://localhost/snapshot", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, w.StatusCode, "state snapshots must not be served without a snapshot token")
+
+	gateway = New(WithStateSnapshots("secret"))
+	gateway.setup()
+
+	gateway.ledger = createLedger(t)
+	defer gateway.ledger.Close()
+
+	get := func(token string, headers map[string]string) (*http.Response, []byte) {
+		request := httptest.NewRequest("GET", "http://localhost/snapshot", nil)
+
+		if len(token) > 0 {
+			request.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		for key, value := range headers {
+			request.Header.Set(key, value)
+		}
+
+		w, err := serve(gateway.router, request)
+		assert.NoError(t, err)
+
+		body, err := ioutil.ReadAll(w.Body)
+		assert.NoError(t, err)
+
+		return w, body
+	}
+
+	w, _ = get("wrong", nil)
+	assert.Equal(t, http.StatusUnauthorized, w.StatusCode)
+
+	w, full := get("secret", nil)
+	assert.Equal(t, http.StatusOK, w.StatusCode)
+	assert.Equal(t, "bytes", w.Header.Get("Accept-Ranges"))
+
+	snapshot, err := wavelet.UnmarshalStateSnapshot(bytes.NewReader(full))
+	assert.NoError(t, err)
+	assert.Equal(t, gateway.ledger.Rounds().Latest().ID, snapshot.Round.ID)
+
+	tag := w.Header.Get("ETag")
+
+	// Downloads may be resumed with range requests.
+
+	w, part := get("secret", map[string]string{"Range": "bytes=4-", "If-Range": tag})
+	assert.Equal(t, http.StatusPartialContent, w.StatusCode)
+	assert.Equal(t, full[4:], part)
+
+	// The entire state snapshot is served should it have changed.
+
+	w, part = get("secret", map[string]string{"Range": "bytes=4-", "If-Range": `"stale"`})
+	assert.Equal(t, http.StatusOK, w.StatusCode)
+	assert.Equal(t, full, part)
+
+	w, _ = get("secret", map[string]string{"Range": "bytes=100000-"})
+	assert.Equal(t, http.StatusRequestedRangeNotSatisfiable, w.StatusCode)
+}
@@ -22,9 +22,12 @@ package api
 import (
 	"github.com/fasthttp/websocket"
 	"github.com/perlin-network/wavelet/debounce"
+	"github.com/perlin-network/wavelet/log"
+	"github.com/pkg/errors"
 	"github.com/valyala/fasthttp"
 	"github.com/valyala/fastjson"
 	"strconv"
+	"sync/atomic"
 	"time"
 )
 
@@ -48,8 +51,14 @@ type client struct {
 	sink *sink
 	conn *websocket.Conn
 
+	permissions ClientPermissions
+
 	filters map[string]string
 	queue   chan []byte
+
+	// Number of messages dropped since the client was last notified of any
+	// being dropped. Accessed atomically.
+	dropped uint64
 }
 
 func (c *client) readWorker() {
@@ -79,9 +88,13 @@ func (c *client) writeWorker() {
 		_ = c.conn.Close()
 	}()
 
+	// The sink resets the queue of the client once it leaves. Hold onto the
+	// queue such that messages still queued up are delivered before closing.
+	queue := c.queue
+
 	for {
 		select {
-		case msg, ok := <-c.queue:
+		case msg, ok := <-queue:
 			if !ok {
 				_ = c.conn.WriteMessage(websocket.CloseMessage, []byte{})
 				return
@@ -93,6 +106,12 @@ func (c *client) writeWorker() {
 
 			_ = c.conn.SetWriteDeadline(time.Now().Add(writeWait))
 
+			if dropped := atomic.SwapUint64(&c.dropped, 0); dropped > 0 {
+				if err := c.conn.WriteMessage(websocket.TextMessage, droppedMessage(dropped)); err != nil {
+					return
+				}
+			}
+
 			err := c.conn.WriteMessage(websocket.TextMessage, msg)
 			if err != nil {
 				return
@@ -107,7 +126,27 @@ func (c *client) writeWorker() {
 	}
 }
 
-func (s *sink) serve(ctx *fasthttp.RequestCtx) error {
+// wsSession describes how a websocket connection is to be set up once the
+// request for it is upgraded.
+type wsSession struct {
+	// Should auth be non-nil and permissions be nil, the client must present
+	// a token as its first message before it joins the sink.
+	auth        *wsAuth
+	permissions *ClientPermissions
+
+	// release, should it be non-nil, is called once the connection is closed.
+	release func()
+}
+
+func (s wsSession) close() {
+	if s.release != nil {
+		s.release()
+	}
+}
+
+// serve upgrades ctx into a websocket connection which streams the events of
+// the sink.
+func (s *sink) serve(ctx *fasthttp.RequestCtx, session wsSession) error {
 	values := ctx.QueryArgs()
 
 	filters := make(map[string]string)
@@ -118,6 +157,26 @@ func (s *sink) serve(ctx *fasthttp.RequestCtx) error {
 	}
 
 	return upgrader.Upgrade(ctx, func(conn *websocket.Conn) {
+		defer session.close()
+
+		permissions := session.permissions
+
+		if session.auth != nil && permissions == nil {
+			p, err := session.auth.handshake(conn, s.name)
+			if err != nil {
+				buf, _ := ErrUnauthorized(err).marshalJSON(new(fastjson.Arena))
+
+				_ = conn.SetWriteDeadline(time.Now().Add(writeWait))
+				_ = conn.WriteMessage(websocket.TextMessage, buf)
+				_ = conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "unauthorized"))
+				_ = conn.Close()
+
+				return
+			}
+
+			permissions = &p
+		}
+
 		client := &client{
 			filters: filters,
 			sink:    s,
@@ -125,6 +184,10 @@ func (s *sink) serve(ctx *fasthttp.RequestCtx) error {
 			queue:   make(chan []byte, 256),
 		}
 
+		if permissions != nil {
+			client.permissions = *permissions
+		}
+
 		s.join <- client
 
 		go client.readWorker()
@@ -138,29 +201,67 @@ type broadcastItem struct {
 }
 
 type sink struct {
+	name string
+
 	clients map[*client]struct{}
 	filters map[string]string
 
+	metrics sinkMetrics
+
+	// Types of events which bypass the debouncer of the sink.
+	priority map[string]struct{}
+
 	broadcast   chan broadcastItem
 	join, leave chan *client
+	shutdown    chan chan struct{}
 
 	debouncer debounce.Debouncer
 }
 
 func (s *sink) run() {
+	ticker := time.NewTicker(1 * time.Second)
+	defer ticker.Stop()
+
 	for {
 		select {
 		case client := <-s.join:
 			s.clients[client] = struct{}{}
+			s.metrics.clients.Update(int64(len(s.clients)))
 		case client := <-s.leave:
 			if _, ok := s.clients[client]; ok {
-				delete(s.clients, client)
-				close(client.queue)
-				client.queue = nil
+				// Deliver messages pending to be debounced to the client
+				// before it leaves, rather than dropping them.
+				if s.debouncer != nil {
+					s.debouncer.Flush()
+				}
+
+				s.remove(client)
 			}
+
+			s.metrics.clients.Update(int64(len(s.clients)))
+		case done := <-s.shutdown:
+			if s.debouncer != nil {
+				s.debouncer.Flush()
+			}
+
+			for client := range s.clients {
+				s.remove(client)
+			}
+
+			s.metrics.clients.Update(0)
+
+			close(done)
+		case <-ticker.C:
+			s.measureQueues()
 		case msg := <-s.broadcast:
 			if s.debouncer != nil {
-				s.debouncer.Add(debounce.Bytes(msg.buf))
+				opts := []debounce.PayloadOption{debounce.Bytes(msg.buf)}
+
+				if _, priority := s.priority[string(msg.value.GetStringBytes(log.KeyEvent))]; priority {
+					opts = append(opts, debounce.Priority())
+				}
+
+				s.debouncer.Add(opts...)
 			} else {
 				s.send(msg.buf)
 			}
@@ -168,6 +269,22 @@ func (s *sink) run() {
 	}
 }
 
+// remove removes a client from the sink. The connection of the client is closed
+// once all messages queued up for it are delivered.
+func (s *sink) remove(client *client) {
+	delete(s.clients, client)
+	close(client.queue)
+	client.queue = nil
+}
+
+// close flushes all messages pending to be debounced, and disconnects all
+// clients of the sink once the messages queued up for them are delivered.
+func (s *sink) close() {
+	done := make(chan struct{})
+	s.shutdown <- done
+	<-done
+}
+
 func (s *sink) send(buf []byte) {
 	o, err := fastjson.ParseBytes(buf)
 	if err != nil {
@@ -190,11 +307,33 @@ SENDING:
 
 		select {
 		case c.queue <- buf:
+			s.metrics.broadcast.Mark(1)
 		default:
+			atomic.AddUint64(&c.dropped, 1)
+			s.metrics.dropped.Mark(1)
 		}
 	}
 }
 
+// measureQueues measures how many messages are queued up to be delivered to
+// the clients of the sink.
+func (s *sink) measureQueues() {
+	var total, max int
+
+	for c := range s.clients {
+		depth := len(c.queue)
+
+		total += depth
+
+		if depth > max {
+			max = depth
+		}
+	}
+
+	s.metrics.queueDepth.Update(int64(total))
+	s.metrics.queueDepthMax.Update(int64(max))
+}
+
 func (s *sink) debounce(batch [][]byte) {
 SENDING:
 	for c := range s.clients {
@@ -231,11 +370,27 @@ SENDING:
 
 		select {
 		case c.queue <- buf:
+			s.metrics.broadcast.Mark(1)
 		default:
+			atomic.AddUint64(&c.dropped, 1)
+			s.metrics.dropped.Mark(1)
 		}
 	}
 }
 
+// droppedMessage renders the error pushed to a client before the next message it
+// receives, should it have been too slow to receive some messages before it.
+func droppedMessage(dropped uint64) []byte {
+	e := &errResponse{
+		Err:  errors.Errorf("%d messages were dropped, as they could not be delivered fast enough", dropped),
+		Code: CodeMessagesDropped,
+	}
+
+	buf, _ := e.withDetail("dropped", strconv.FormatUint(dropped, 10)).marshalJSON(new(fastjson.Arena))
+
+	return buf
+}
+
 func fastjsonEquals(v *fastjson.Value, filter string) bool {
 	switch v.Type() {
 	case fastjson.TypeArray:
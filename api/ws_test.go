@@ -20,9 +20,14 @@
 package api
 
 import (
+	"context"
+	"github.com/perlin-network/wavelet/debounce"
+	"github.com/rcrowley/go-metrics"
 	"github.com/stretchr/testify/assert"
 	"github.com/valyala/fastjson"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func TestSinkEqual(t *testing.T) {
@@ -44,3 +49,140 @@ func TestSinkEqual(t *testing.T) {
 	assert.True(t, fastjsonEquals(v.Get("obj"), `{"key":"value"}`))
 	assert.True(t, fastjsonEquals(v.Get("arr"), `[1,"str"]`))
 }
+
+func TestDroppedMessage(t *testing.T) {
+	var p fastjson.Parser
+
+	v, err := p.ParseBytes(droppedMessage(3))
+	assert.NoError(t, err)
+
+	assert.Equal(t, string(CodeMessagesDropped), string(v.GetStringBytes("error", "code")))
+	assert.Equal(t, "3", string(v.GetStringBytes("error", "details", "dropped")))
+	assert.False(t, v.GetBool("error", "retryable"))
+}
+
+func TestSinkMetrics(t *testing.T) {
+	registry := metrics.NewRegistry()
+
+	s := &sink{
+		name:    "tx",
+		clients: make(map[*client]struct{}),
+		metrics: newSinkMetrics(registry, "tx"),
+	}
+
+	slow := &client{sink: s, queue: make(chan []byte, 1)}
+	fast := &client{sink: s, queue: make(chan []byte, 8)}
+
+	s.clients[slow] = struct{}{}
+	s.clients[fast] = struct{}{}
+
+	s.send([]byte(`{"event":"applied"}`))
+	s.send([]byte(`{"event":"applied"}`))
+	s.debounce([][]byte{[]byte(`{"event":"applied"}`)})
+
+	assert.EqualValues(t, 4, s.metrics.broadcast.Count())
+	assert.EqualValues(t, 2, s.metrics.dropped.Count())
+	assert.EqualValues(t, 2, atomic.LoadUint64(&slow.dropped))
+
+	s.measureQueues()
+
+	assert.EqualValues(t, 4, s.metrics.queueDepth.Value())
+	assert.EqualValues(t, 3, s.metrics.queueDepthMax.Value())
+
+	assert.NotNil(t, registry.Get("ws.tx.dropped"))
+}
+
+func TestSinkFlushesPendingMessages(t *testing.T) {
+	g := New(WithSinkDebouncer("tx", SinkDebouncer{Type: debounce.TypeLimiter, Period: 1 * time.Hour}))
+	s := g.registerWebsocketSink("ws://tx/?id=tx_id", nil)
+
+	receive := func(queue chan []byte) []string {
+		var received []string
+
+		for msg := range queue {
+			received = append(received, string(msg))
+		}
+
+		return received
+	}
+
+	// Messages pending to be debounced are delivered to clients as they leave.
+
+	c := &client{sink: s, queue: make(chan []byte, 8)}
+	queue := c.queue
+
+	s.join <- c
+	s.broadcast <- broadcastItem{buf: []byte(`{"tx_id":"a"}`)}
+	s.leave <- c
+
+	assert.Equal(t, []string{`[{"tx_id":"a"}]`}, receive(queue))
+
+	// Messages pending to be debounced are delivered to clients as the gateway
+	// shuts down.
+
+	c = &client{sink: s, queue: make(chan []byte, 8)}
+	queue = c.queue
+
+	s.join <- c
+	s.broadcast <- broadcastItem{buf: []byte(`{"tx_id":"b"}`)}
+
+	g.Shutdown()
+
+	assert.Equal(t, []string{`[{"tx_id":"b"}]`}, receive(queue))
+}
+
+func TestSinkDebouncerKeys(t *testing.T) {
+	var grouped [][]byte
+
+	cfg := SinkDebouncer{Type: debounce.TypeDeduper, Period: 1 * time.Hour}
+	d := cfg.factory(map[string]string{"id": "tx_id", "sender": "sender_id"}).Init(context.Background(), debounce.WithAction(func(batch [][]byte) {
+		grouped = batch
+	}))
+
+	d.Add(debounce.Bytes([]byte(`{"tx_id":"a","sender_id":"x","event":"applied"}`)))
+	d.Add(debounce.Bytes([]byte(`{"tx_id":"a","sender_id":"x","event":"applied","n":2}`)))
+	d.Add(debounce.Bytes([]byte(`{"tx_id":"a","sender_id":"x","event":"rejected"}`)))
+	d.Add(debounce.Bytes([]byte(`{"tx_id":"b","sender_id":"x","event":"applied"}`)))
+	d.Flush()
+
+	assert.Len(t, grouped, 3, "events must be grouped by the fields the sink filters on, and their type")
+}
+
+func TestSinkPriorityEvents(t *testing.T) {
+	g := New(WithSinkDebouncer("consensus", SinkDebouncer{Type: debounce.TypeLimiter, Period: 1 * time.Hour}))
+	s := g.registerWebsocketSink("ws://consensus/", nil)
+
+	c := &client{sink: s, queue: make(chan []byte, 8)}
+	s.join <- c
+
+	broadcast := func(buf string) {
+		s.broadcast <- broadcastItem{value: fastjson.MustParse(buf), buf: []byte(buf)}
+	}
+
+	broadcast(`{"event":"sampled"}`)
+	broadcast(`{"event":"round_end"}`)
+
+	select {
+	case msg := <-c.queue:
+		assert.Equal(t, `[{"event":"sampled"},{"event":"round_end"}]`, string(msg))
+	case <-time.After(1 * time.Second):
+		assert.Fail(t, "priority events must bypass debouncing")
+	}
+
+	broadcast(`{"event":"conflict"}`)
+
+	select {
+	case msg := <-c.queue:
+		assert.Equal(t, `[{"event":"conflict"}]`, string(msg))
+	case <-time.After(1 * time.Second):
+		assert.Fail(t, "conflicting transactions must be reported without being debounced")
+	}
+
+	broadcast(`{"event":"sampled"}`)
+
+	select {
+	case <-c.queue:
+		assert.Fail(t, "events must otherwise be debounced")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
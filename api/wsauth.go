@@ -0,0 +1,224 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package api
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/fasthttp/websocket"
+	"github.com/pkg/errors"
+	"github.com/valyala/fasthttp"
+	"github.com/valyala/fastjson"
+)
+
+// handshakeWait is how long a websocket client is given to send its token
+// as its first message, should it not have provided it upon upgrading.
+const handshakeWait = 10 * time.Second
+
+// ClientPermissions denotes which websocket sinks a client may receive events
+// from. Permissions which name no sinks in particular allow for every sink.
+type ClientPermissions struct {
+	Sinks []string
+}
+
+func (p ClientPermissions) allows(sink string) bool {
+	if len(p.Sinks) == 0 {
+		return true
+	}
+
+	for _, s := range p.Sinks {
+		if s == sink {
+			return true
+		}
+	}
+
+	return false
+}
+
+// WithWebsocketAuth requires every websocket connection to the gateway to
+// authenticate with a token issued via the admin endpoint POST /admin/ws/token.
+// Tokens expire after ttl, and may only be redeemed once. Tokens may only be
+// issued should an admin token be configured through WithAdminToken.
+func WithWebsocketAuth(ttl time.Duration) GatewayOption {
+	return func(g *Gateway) {
+		g.wsAuth = &wsAuth{ttl: ttl, tokens: make(map[string]wsToken)}
+	}
+}
+
+type wsToken struct {
+	permissions ClientPermissions
+	expiresAt   time.Time
+}
+
+// wsAuth keeps track of the tokens issued to websocket clients which have yet
+// to be redeemed.
+type wsAuth struct {
+	sync.Mutex
+
+	ttl    time.Duration
+	tokens map[string]wsToken
+}
+
+// issue issues a token which grants permissions to whoever redeems it before
+// it expires.
+func (a *wsAuth) issue(permissions ClientPermissions) (string, time.Time, error) {
+	var buf [32]byte
+
+	if _, err := rand.Read(buf[:]); err != nil {
+		return "", time.Time{}, errors.Wrap(err, "failed to generate token")
+	}
+
+	token := hex.EncodeToString(buf[:])
+
+	a.Lock()
+	defer a.Unlock()
+
+	now := time.Now()
+
+	for t, issued := range a.tokens {
+		if now.After(issued.expiresAt) {
+			delete(a.tokens, t)
+		}
+	}
+
+	expiresAt := now.Add(a.ttl)
+	a.tokens[token] = wsToken{permissions: permissions, expiresAt: expiresAt}
+
+	return token, expiresAt, nil
+}
+
+// redeem exchanges a token for the permissions it was issued with, provided
+// that the token has not expired, and that it permits subscribing to sink.
+func (a *wsAuth) redeem(sink, token string) (ClientPermissions, error) {
+	a.Lock()
+	defer a.Unlock()
+
+	for t, issued := range a.tokens {
+		if subtle.ConstantTimeCompare([]byte(t), []byte(token)) != 1 {
+			continue
+		}
+
+		delete(a.tokens, t)
+
+		if time.Now().After(issued.expiresAt) {
+			return ClientPermissions{}, errors.New("token has expired")
+		}
+
+		if !issued.permissions.allows(sink) {
+			return ClientPermissions{}, errors.Errorf("token does not permit subscribing to %q", sink)
+		}
+
+		return issued.permissions, nil
+	}
+
+	return ClientPermissions{}, errors.New("token is invalid or was already redeemed")
+}
+
+// handshake reads the token of a websocket client from its first message, and
+// redeems it. The first message must be of the form {"token": "..."}.
+func (a *wsAuth) handshake(conn *websocket.Conn, sink string) (ClientPermissions, error) {
+	conn.SetReadLimit(maxMessageSize)
+	_ = conn.SetReadDeadline(time.Now().Add(handshakeWait))
+
+	_, msg, err := conn.ReadMessage()
+	if err != nil {
+		return ClientPermissions{}, errors.Wrap(err, "failed to read token")
+	}
+
+	_ = conn.SetReadDeadline(time.Time{})
+
+	token := fastjson.GetString(msg, "token")
+	if len(token) == 0 {
+		return ClientPermissions{}, errors.New(`the first message must be of the form {"token": "..."}`)
+	}
+
+	return a.redeem(sink, token)
+}
+
+type wsTokenRequest struct {
+	permissions ClientPermissions
+}
+
+func (s *wsTokenRequest) bind(parser *fastjson.Parser, body []byte, sinks map[string]*sink) error {
+	if len(body) == 0 {
+		return nil
+	}
+
+	v, err := parser.ParseBytes(body)
+	if err != nil {
+		return errors.Wrap(err, "invalid json")
+	}
+
+	for _, item := range v.GetArray("sinks") {
+		name := string(item.GetStringBytes())
+
+		if _, exists := sinks[name]; !exists {
+			return errors.Errorf("unknown sink %q", name)
+		}
+
+		s.permissions.Sinks = append(s.permissions.Sinks, name)
+	}
+
+	return nil
+}
+
+type wsTokenResponse struct {
+	token     string
+	expiresAt time.Time
+}
+
+func (s *wsTokenResponse) marshalJSON(arena *fastjson.Arena) ([]byte, error) {
+	o := arena.NewObject()
+
+	o.Set("token", arena.NewString(s.token))
+	o.Set("expires_at", arena.NewString(s.expiresAt.UTC().Format(time.RFC3339)))
+
+	return o.MarshalTo(nil), nil
+}
+
+func (g *Gateway) issueWebsocketToken(ctx *fasthttp.RequestCtx) {
+	if g.wsAuth == nil {
+		g.renderError(ctx, ErrNotFound(errors.New("websocket authentication is disabled")))
+		return
+	}
+
+	req := new(wsTokenRequest)
+
+	parser := g.parserPool.Get()
+	err := req.bind(parser, ctx.PostBody(), g.sinks)
+	g.parserPool.Put(parser)
+
+	if err != nil {
+		g.renderError(ctx, ErrBadRequest(err))
+		return
+	}
+
+	token, expiresAt, err := g.wsAuth.issue(req.permissions)
+	if err != nil {
+		g.renderError(ctx, ErrInternal(err))
+		return
+	}
+
+	g.render(ctx, &wsTokenResponse{token: token, expiresAt: expiresAt})
+}
@@ -0,0 +1,154 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package api
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/fasthttp/websocket"
+	"github.com/perlin-network/noise/skademlia"
+	"github.com/perlin-network/wavelet"
+	"github.com/perlin-network/wavelet/log"
+	"github.com/perlin-network/wavelet/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fastjson"
+)
+
+func TestWebsocketTokens(t *testing.T) {
+	auth := &wsAuth{ttl: time.Minute, tokens: make(map[string]wsToken)}
+
+	token, expiresAt, err := auth.issue(ClientPermissions{Sinks: []string{"tx"}})
+	assert.NoError(t, err)
+	assert.True(t, expiresAt.After(time.Now()))
+
+	_, err = auth.redeem("accounts", token)
+	assert.Error(t, err, "tokens must only permit the sinks they were issued for")
+
+	token, _, err = auth.issue(ClientPermissions{Sinks: []string{"tx"}})
+	assert.NoError(t, err)
+
+	permissions, err := auth.redeem("tx", token)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"tx"}, permissions.Sinks)
+
+	_, err = auth.redeem("tx", token)
+	assert.Error(t, err, "tokens must only be redeemable once")
+
+	auth.ttl = -time.Second
+
+	token, _, err = auth.issue(ClientPermissions{})
+	assert.NoError(t, err)
+
+	_, err = auth.redeem("tx", token)
+	assert.Error(t, err, "expired tokens must not be redeemable")
+
+	_, err = auth.redeem("tx", "")
+	assert.Error(t, err)
+}
+
+func TestPollWithToken(t *testing.T) {
+	gateway := New(WithAdminToken("secret"), WithWebsocketAuth(time.Minute))
+	gateway.setup()
+
+	log.SetWriter(log.LoggerWebsocket, gateway)
+
+	keys, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	ledger := wavelet.NewLedger(store.NewInmem(), skademlia.NewClient(":0", keys), nil)
+
+	go gateway.StartHTTP(8081, nil, ledger, ledger.Signer())
+	defer gateway.Shutdown()
+
+	time.Sleep(100 * time.Millisecond)
+
+	issue := func(sinks ...string) string {
+		token, _, err := gateway.wsAuth.issue(ClientPermissions{Sinks: sinks})
+		assert.NoError(t, err)
+
+		return token
+	}
+
+	u := url.URL{Scheme: "ws", Host: ":8081", Path: "/poll/network"}
+
+	// Upgrades without a token must send their token as their first message.
+
+	c, _, err := websocket.DefaultDialer.Dial(u.String(), nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.NoError(t, c.WriteMessage(websocket.TextMessage, []byte(`{"token":"invalid"}`)))
+
+	_, msg, err := c.ReadMessage()
+	assert.NoError(t, err)
+	assert.Equal(t, string(CodeUnauthorized), fastjson.GetString(msg, "error", "code"))
+
+	_, _, err = c.ReadMessage()
+	assert.Error(t, err, "the connection must be closed after failing to authenticate")
+
+	c, _, err = websocket.DefaultDialer.Dial(u.String(), nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.NoError(t, c.WriteMessage(websocket.TextMessage, []byte(`{"token":"`+issue("network")+`"}`)))
+
+	time.Sleep(100 * time.Millisecond)
+
+	logger := log.Network("test")
+	logger.Log().Msg("")
+
+	_, msg, err = c.ReadMessage()
+	assert.NoError(t, err)
+	assert.Equal(t, "test", fastjson.GetString(msg, "event"))
+
+	_ = c.Close()
+
+	// Tokens may also be specified upon upgrading.
+
+	u.RawQuery = url.Values{"token": {issue("consensus")}}.Encode()
+
+	_, res, err := websocket.DefaultDialer.Dial(u.String(), nil)
+	assert.Error(t, err, "tokens must only permit the sinks they were issued for")
+
+	if assert.NotNil(t, res) {
+		assert.Equal(t, 401, res.StatusCode)
+	}
+
+	u.RawQuery = url.Values{"token": {issue()}}.Encode()
+
+	c, _, err = websocket.DefaultDialer.Dial(u.String(), nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	time.Sleep(100 * time.Millisecond)
+
+	logger.Log().Msg("")
+
+	_, msg, err = c.ReadMessage()
+	assert.NoError(t, err)
+	assert.Equal(t, "test", fastjson.GetString(msg, "event"))
+
+	_ = c.Close()
+}
@@ -0,0 +1,109 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package api
+
+import (
+	"github.com/pkg/errors"
+	"strconv"
+	"sync"
+)
+
+// WithWebsocketLimits caps the number of websocket connections any single IP
+// may hold open with the gateway at once to maxConns, and the number of those
+// connections which may subscribe to any single sink to maxSubscriptions.
+// Connections beyond either cap are rejected before being upgraded. A cap of
+// zero disables it.
+func WithWebsocketLimits(maxConns, maxSubscriptions int) GatewayOption {
+	return func(g *Gateway) {
+		g.wsLimiter = newWSLimiter(maxConns, maxSubscriptions)
+	}
+}
+
+// wsLimiter tracks the number of websocket connections held open by every IP,
+// both in total, and to each sink.
+type wsLimiter struct {
+	maxConns         int
+	maxSubscriptions int
+
+	conns         map[string]int
+	subscriptions map[string]map[string]int
+
+	sync.Mutex
+}
+
+func newWSLimiter(maxConns, maxSubscriptions int) *wsLimiter {
+	return &wsLimiter{
+		maxConns:         maxConns,
+		maxSubscriptions: maxSubscriptions,
+		conns:            make(map[string]int),
+		subscriptions:    make(map[string]map[string]int),
+	}
+}
+
+// acquire reserves a connection to sink for ip. The returned function must be
+// called once the connection is closed to release the reservation.
+func (l *wsLimiter) acquire(ip, sink string) (func(), *errResponse) {
+	l.Lock()
+	defer l.Unlock()
+
+	if l.maxConns > 0 && l.conns[ip] >= l.maxConns {
+		return nil, ErrTooManyRequests(errors.Errorf("no more than %d websocket connections may be held open at once", l.maxConns)).
+			withDetail("max_connections", strconv.Itoa(l.maxConns))
+	}
+
+	subs := l.subscriptions[ip]
+
+	if l.maxSubscriptions > 0 && subs[sink] >= l.maxSubscriptions {
+		return nil, ErrTooManyRequests(errors.Errorf("no more than %d websocket connections may subscribe to %q at once", l.maxSubscriptions, sink)).
+			withDetail("max_subscriptions", strconv.Itoa(l.maxSubscriptions)).
+			withDetail("sink", sink)
+	}
+
+	if subs == nil {
+		subs = make(map[string]int)
+		l.subscriptions[ip] = subs
+	}
+
+	l.conns[ip]++
+	subs[sink]++
+
+	var once sync.Once
+
+	return func() { once.Do(func() { l.release(ip, sink) }) }, nil
+}
+
+func (l *wsLimiter) release(ip, sink string) {
+	l.Lock()
+	defer l.Unlock()
+
+	if l.conns[ip]--; l.conns[ip] <= 0 {
+		delete(l.conns, ip)
+	}
+
+	subs := l.subscriptions[ip]
+
+	if subs[sink]--; subs[sink] <= 0 {
+		delete(subs, sink)
+	}
+
+	if len(subs) == 0 {
+		delete(l.subscriptions, ip)
+	}
+}
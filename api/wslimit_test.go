@@ -0,0 +1,69 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package api
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+func TestWebsocketLimits(t *testing.T) {
+	l := newWSLimiter(3, 2)
+
+	releaseA, e := l.acquire("1.1.1.1", "tx")
+	assert.Nil(t, e)
+
+	_, e = l.acquire("1.1.1.1", "tx")
+	assert.Nil(t, e)
+
+	_, e = l.acquire("1.1.1.1", "tx")
+	if assert.NotNil(t, e, "subscriptions to a single sink must be capped") {
+		assert.Equal(t, http.StatusTooManyRequests, e.HTTPStatusCode)
+		assert.Equal(t, "tx", e.Details["sink"])
+	}
+
+	_, e = l.acquire("2.2.2.2", "tx")
+	assert.Nil(t, e, "limits must be tracked per IP")
+
+	_, e = l.acquire("1.1.1.1", "accounts")
+	assert.Nil(t, e)
+
+	_, e = l.acquire("1.1.1.1", "network")
+	if assert.NotNil(t, e, "connections must be capped across sinks") {
+		assert.Equal(t, "3", e.Details["max_connections"])
+	}
+
+	releaseA()
+	releaseA()
+
+	_, e = l.acquire("1.1.1.1", "network")
+	assert.Nil(t, e, "releasing a connection must free up its reservation exactly once")
+
+	_, e = l.acquire("1.1.1.1", "tx")
+	assert.NotNil(t, e)
+
+	unlimited := newWSLimiter(0, 0)
+
+	for i := 0; i < 100; i++ {
+		_, e = unlimited.acquire("1.1.1.1", "tx")
+		assert.Nil(t, e)
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package api
+
+import (
+	"github.com/rcrowley/go-metrics"
+	"sort"
+	"time"
+)
+
+// sinkMetrics instruments the delivery of messages by a websocket sink to its
+// clients, such that operators may tell which sinks have clients falling behind.
+type sinkMetrics struct {
+	// Number of clients connected to the sink.
+	clients metrics.Gauge
+
+	// Messages queued up to be delivered to clients, and messages dropped as
+	// the queue of the client they were destined for was full.
+	broadcast metrics.Meter
+	dropped   metrics.Meter
+
+	// Total number of messages queued up across all clients, and the number
+	// of messages queued up for the client which is the furthest behind.
+	queueDepth    metrics.Gauge
+	queueDepthMax metrics.Gauge
+}
+
+func newSinkMetrics(registry metrics.Registry, name string) sinkMetrics {
+	prefix := "ws." + name + "."
+
+	return sinkMetrics{
+		clients:       metrics.NewRegisteredGauge(prefix+"clients", registry),
+		broadcast:     metrics.NewRegisteredMeter(prefix+"broadcast", registry),
+		dropped:       metrics.NewRegisteredMeter(prefix+"dropped", registry),
+		queueDepth:    metrics.NewRegisteredGauge(prefix+"queue_depth", registry),
+		queueDepthMax: metrics.NewRegisteredGauge(prefix+"queue_depth.max", registry),
+	}
+}
+
+// reportSinkMetrics logs the metrics of every websocket sink of the gateway at
+// every interval, such that they are streamed through the /poll/metrics sink.
+func (g *Gateway) reportSinkMetrics(interval time.Duration) (stop func()) {
+	done := make(chan struct{})
+	stop = func() {
+		close(done)
+	}
+
+	ticker := time.NewTicker(interval)
+
+	logger := g.logs().Metrics()
+
+	names := make([]string, 0, len(g.sinks))
+
+	for name := range g.sinks {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	go func() {
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				e := logger.Info()
+
+				for _, name := range names {
+					m := g.sinks[name].metrics
+					prefix := "ws." + name + "."
+
+					e = e.Int64(prefix+"clients", m.clients.Value()).
+						Int64(prefix+"broadcast", m.broadcast.Count()).
+						Float64(prefix+"broadcast.rate", m.broadcast.RateMean()).
+						Int64(prefix+"dropped", m.dropped.Count()).
+						Int64(prefix+"queue_depth", m.queueDepth.Value()).
+						Int64(prefix+"queue_depth.max", m.queueDepthMax.Value())
+				}
+
+				e.Msg("Updated websocket sink metrics.")
+			}
+		}
+	}()
+
+	return
+}
@@ -0,0 +1,295 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/perlin-network/wavelet/store"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/blake2b"
+)
+
+// AuditEntry records an administrative action taken on a node. Every entry is
+// chained to the entry before it by Prev, which is the hash of the entry before
+// it, such that entries may not be modified or removed without breaking the
+// chain.
+type AuditEntry struct {
+	Index uint64
+	Time  time.Time
+
+	Action    string // what was done, such as peers.ban
+	Actor     string // who did it, such as the remote address of an API request
+	RequestID string // the ID of the API request which took the action, if any
+	Status    uint16 // the HTTP status code the action resulted in
+	Details   string // the parameters of the action
+
+	Prev [blake2b.Size256]byte
+	Hash [blake2b.Size256]byte
+}
+
+// maxAuditDetailsSize is the most bytes of details recorded in an audit entry.
+// Longer details are truncated.
+const maxAuditDetailsSize = 4096
+
+func (e AuditEntry) marshalContents() []byte {
+	var w bytes.Buffer
+
+	var buf [8]byte
+
+	binary.BigEndian.PutUint64(buf[:], e.Index)
+	w.Write(buf[:])
+
+	binary.BigEndian.PutUint64(buf[:], uint64(e.Time.UnixNano()))
+	w.Write(buf[:])
+
+	for _, field := range []string{e.Action, e.Actor, e.RequestID, e.Details} {
+		binary.BigEndian.PutUint32(buf[:4], uint32(len(field)))
+		w.Write(buf[:4])
+		w.WriteString(field)
+	}
+
+	binary.BigEndian.PutUint16(buf[:2], e.Status)
+	w.Write(buf[:2])
+
+	w.Write(e.Prev[:])
+
+	return w.Bytes()
+}
+
+// computeHash returns the hash of the contents of the entry, which includes the
+// hash of the entry before it.
+func (e AuditEntry) computeHash() [blake2b.Size256]byte {
+	return blake2b.Sum256(e.marshalContents())
+}
+
+func (e AuditEntry) Marshal() []byte {
+	return append(e.marshalContents(), e.Hash[:]...)
+}
+
+func UnmarshalAuditEntry(r io.Reader) (e AuditEntry, err error) {
+	var buf [8]byte
+
+	if _, err = io.ReadFull(r, buf[:]); err != nil {
+		err = errors.Wrap(err, "failed to decode audit entry index")
+		return
+	}
+
+	e.Index = binary.BigEndian.Uint64(buf[:])
+
+	if _, err = io.ReadFull(r, buf[:]); err != nil {
+		err = errors.Wrap(err, "failed to decode audit entry time")
+		return
+	}
+
+	e.Time = time.Unix(0, int64(binary.BigEndian.Uint64(buf[:])))
+
+	for _, field := range []*string{&e.Action, &e.Actor, &e.RequestID, &e.Details} {
+		if _, err = io.ReadFull(r, buf[:4]); err != nil {
+			err = errors.Wrap(err, "failed to decode audit entry field length")
+			return
+		}
+
+		raw := make([]byte, binary.BigEndian.Uint32(buf[:4]))
+
+		if _, err = io.ReadFull(r, raw); err != nil {
+			err = errors.Wrap(err, "failed to decode audit entry field")
+			return
+		}
+
+		*field = string(raw)
+	}
+
+	if _, err = io.ReadFull(r, buf[:2]); err != nil {
+		err = errors.Wrap(err, "failed to decode audit entry status")
+		return
+	}
+
+	e.Status = binary.BigEndian.Uint16(buf[:2])
+
+	if _, err = io.ReadFull(r, e.Prev[:]); err != nil {
+		err = errors.Wrap(err, "failed to decode hash of previous audit entry")
+		return
+	}
+
+	if _, err = io.ReadFull(r, e.Hash[:]); err != nil {
+		err = errors.Wrap(err, "failed to decode audit entry hash")
+		return
+	}
+
+	return
+}
+
+// AuditLog is an append-only log of administrative actions taken on a node,
+// persisted into its store. It is safe to use concurrently.
+type AuditLog struct {
+	sync.Mutex
+	kv store.KV
+}
+
+func NewAuditLog(kv store.KV) *AuditLog {
+	return &AuditLog{kv: kv}
+}
+
+// Append records entry at the end of the log, filling in its index, hash, and
+// the hash of the entry before it. Its time is set to now should it be unset.
+func (a *AuditLog) Append(entry AuditEntry) (AuditEntry, error) {
+	a.Lock()
+	defer a.Unlock()
+
+	length := a.Len()
+
+	if length > 0 {
+		prev, err := a.get(length - 1)
+		if err != nil {
+			return entry, err
+		}
+
+		entry.Prev = prev.Hash
+	} else {
+		entry.Prev = [blake2b.Size256]byte{}
+	}
+
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+
+	if len(entry.Details) > maxAuditDetailsSize {
+		entry.Details = entry.Details[:maxAuditDetailsSize]
+	}
+
+	entry.Index = length
+	entry.Hash = entry.computeHash()
+
+	var buf [8]byte
+
+	batch := a.kv.NewWriteBatch()
+	defer batch.Destroy()
+
+	binary.BigEndian.PutUint64(buf[:], length)
+	batch.Put(append(keyAudit[:], buf[:]...), entry.Marshal())
+
+	binary.BigEndian.PutUint64(buf[:], length+1)
+	batch.Put(keyAuditLen[:], buf[:])
+
+	if err := a.kv.CommitWriteBatch(batch); err != nil {
+		return entry, errors.Wrap(err, "audit: failed to append entry")
+	}
+
+	return entry, nil
+}
+
+// Len returns the number of entries in the log.
+func (a *AuditLog) Len() uint64 {
+	buf, err := a.kv.Get(keyAuditLen[:])
+	if err != nil || len(buf) != 8 {
+		return 0
+	}
+
+	return binary.BigEndian.Uint64(buf)
+}
+
+// Entries returns at most limit entries from the log starting from the entry
+// at offset, from the oldest to the most recent. An error is returned should
+// any of the entries returned have been tampered with.
+func (a *AuditLog) Entries(offset, limit uint64) ([]AuditEntry, error) {
+	length := a.Len()
+
+	if offset >= length {
+		return nil, nil
+	}
+
+	if limit > length-offset {
+		limit = length - offset
+	}
+
+	var prev [blake2b.Size256]byte
+
+	if offset > 0 {
+		entry, err := a.get(offset - 1)
+		if err != nil {
+			return nil, err
+		}
+
+		prev = entry.Hash
+	}
+
+	entries := make([]AuditEntry, 0, limit)
+
+	for i := offset; i < offset+limit; i++ {
+		entry, err := a.get(i)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := entry.verify(i, prev); err != nil {
+			return nil, err
+		}
+
+		prev = entry.Hash
+
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// Verify checks that no entry in the log has been tampered with.
+func (a *AuditLog) Verify() error {
+	_, err := a.Entries(0, a.Len())
+	return err
+}
+
+func (e AuditEntry) verify(index uint64, prev [blake2b.Size256]byte) error {
+	if e.Index != index {
+		return errors.Errorf("audit: entry %d is recorded as entry %d", index, e.Index)
+	}
+
+	if e.Prev != prev {
+		return errors.Errorf("audit: entry %d is not chained to the entry before it", index)
+	}
+
+	if e.Hash != e.computeHash() {
+		return errors.Errorf("audit: entry %d does not match its hash", index)
+	}
+
+	return nil
+}
+
+func (a *AuditLog) get(index uint64) (AuditEntry, error) {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], index)
+
+	raw, err := a.kv.Get(append(keyAudit[:], buf[:]...))
+	if err != nil {
+		return AuditEntry{}, errors.Wrapf(err, "audit: failed to read entry %d", index)
+	}
+
+	return UnmarshalAuditEntry(bytes.NewReader(raw))
+}
+
+// AuditLog returns the log of administrative actions taken on the ledger.
+func (l *Ledger) AuditLog() *AuditLog {
+	return l.audit
+}
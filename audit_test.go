@@ -0,0 +1,90 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"encoding/binary"
+	"strings"
+	"testing"
+
+	"github.com/perlin-network/wavelet/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuditLog(t *testing.T) {
+	kv := store.NewInmem()
+	audit := NewAuditLog(kv)
+
+	assert.NoError(t, audit.Verify())
+
+	actions := []string{"peers.connect", "peers.ban", "peers.unban"}
+
+	for _, action := range actions {
+		_, err := audit.Append(AuditEntry{Action: action, Actor: "127.0.0.1", Status: 200, Details: `{"a":"b"}`})
+		assert.NoError(t, err)
+	}
+
+	entry, err := audit.Append(AuditEntry{Action: "peers.banned", Details: strings.Repeat("a", maxAuditDetailsSize+1)})
+	assert.NoError(t, err)
+	assert.Len(t, entry.Details, maxAuditDetailsSize)
+
+	assert.EqualValues(t, 4, audit.Len())
+	assert.NoError(t, audit.Verify())
+
+	entries, err := audit.Entries(1, 2)
+	assert.NoError(t, err)
+
+	if assert.Len(t, entries, 2) {
+		assert.Equal(t, "peers.ban", entries[0].Action)
+		assert.Equal(t, "peers.unban", entries[1].Action)
+		assert.Equal(t, entries[0].Hash, entries[1].Prev)
+	}
+
+	entries, err = audit.Entries(4, 10)
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+
+	// Tamper with the action of the second entry.
+
+	tampered, err := audit.Entries(1, 1)
+	assert.NoError(t, err)
+
+	tampered[0].Action = "peers.connect"
+
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], 1)
+	assert.NoError(t, kv.Put(append(keyAudit[:], buf[:]...), tampered[0].Marshal()))
+
+	assert.Error(t, audit.Verify())
+
+	_, err = audit.Entries(2, 1)
+	assert.NoError(t, err)
+
+	// Rehashing the tampered entry breaks the chain to the entry after it.
+
+	tampered[0].Hash = tampered[0].computeHash()
+	assert.NoError(t, kv.Put(append(keyAudit[:], buf[:]...), tampered[0].Marshal()))
+
+	_, err = audit.Entries(1, 1)
+	assert.NoError(t, err)
+
+	_, err = audit.Entries(2, 1)
+	assert.Error(t, err)
+}
@@ -22,6 +22,7 @@ package avl
 import (
 	"container/list"
 	"sync"
+	"unsafe"
 )
 
 type lru struct {
@@ -29,13 +30,31 @@ type lru struct {
 
 	size int
 
+	// maxBytes bounds the approximate memory taken up by cached nodes should
+	// it be positive. bytes is the approximate memory they take up.
+	maxBytes, bytes int64
+
 	elements map[[MerkleHashSize]byte]*list.Element
 	access   *list.List // *objectInfo
 }
 
 type objectInfo struct {
-	key [MerkleHashSize]byte
-	obj interface{}
+	key  [MerkleHashSize]byte
+	obj  interface{}
+	size int64
+}
+
+// nodeOverhead is the approximate memory taken up by a cached node, not
+// counting its key and value.
+const nodeOverhead = int64(unsafe.Sizeof(node{}) + unsafe.Sizeof(objectInfo{}) + unsafe.Sizeof(list.Element{}))
+
+// sizeOf returns the approximate memory taken up by a cached object.
+func sizeOf(obj interface{}) int64 {
+	if n, ok := obj.(*node); ok {
+		return nodeOverhead + int64(cap(n.key)+cap(n.value))
+	}
+
+	return 0
 }
 
 func newLRU(size int) *lru {
@@ -64,25 +83,40 @@ func (l *lru) put(key [MerkleHashSize]byte, val interface{}) {
 	defer l.Unlock()
 
 	elem, ok := l.elements[key]
+	size := sizeOf(val)
 
 	if ok {
-		elem.Value.(*objectInfo).obj = val
+		info := elem.Value.(*objectInfo)
+		l.bytes += size - info.size
+		info.obj, info.size = val, size
 		l.access.MoveToFront(elem)
 	} else {
 		l.elements[key] = l.access.PushFront(&objectInfo{
-			key: key,
-			obj: val,
+			key:  key,
+			obj:  val,
+			size: size,
 		})
+		l.bytes += size
 	}
 
-	for len(l.elements) > l.size {
+	for len(l.elements) > l.size || (l.maxBytes > 0 && l.bytes > l.maxBytes && len(l.elements) > 0) {
 		back := l.access.Back()
 		info := back.Value.(*objectInfo)
 		delete(l.elements, info.key)
 		l.access.Remove(back)
+		l.bytes -= info.size
 	}
 }
 
+// setMaxBytes bounds the approximate memory taken up by cached nodes to at most
+// maxBytes bytes. It is unbounded should maxBytes not be positive.
+func (l *lru) setMaxBytes(maxBytes int64) {
+	l.Lock()
+	defer l.Unlock()
+
+	l.maxBytes = maxBytes
+}
+
 func (l *lru) remove(key [MerkleHashSize]byte) {
 	l.Lock()
 	defer l.Unlock()
@@ -91,5 +125,6 @@ func (l *lru) remove(key [MerkleHashSize]byte) {
 	if ok {
 		delete(l.elements, key)
 		l.access.Remove(elem)
+		l.bytes -= elem.Value.(*objectInfo).size
 	}
 }
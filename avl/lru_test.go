@@ -46,3 +46,26 @@ func TestLRU(t *testing.T) {
 	assert.True(t, ok)
 	assert.Equal(t, 3, val.(int))
 }
+
+func TestLRUMaxBytes(t *testing.T) {
+	a := &node{key: []byte("a"), value: []byte("1")}
+	b := &node{key: []byte("b"), value: []byte("2")}
+	c := &node{key: []byte("c"), value: []byte("3")}
+
+	lru := newLRU(16)
+	lru.setMaxBytes(sizeOf(a) + sizeOf(b) + sizeOf(c) - 1)
+
+	lru.put([MerkleHashSize]byte{'a'}, a)
+	lru.put([MerkleHashSize]byte{'b'}, b)
+	assert.Len(t, lru.elements, 2)
+
+	lru.put([MerkleHashSize]byte{'c'}, c)
+	assert.Len(t, lru.elements, 2)
+	assert.Equal(t, sizeOf(b)+sizeOf(c), lru.bytes)
+
+	_, ok := lru.load([MerkleHashSize]byte{'a'})
+	assert.False(t, ok)
+
+	lru.remove([MerkleHashSize]byte{'b'})
+	assert.Equal(t, sizeOf(c), lru.bytes)
+}
@@ -426,6 +426,12 @@ func (n *node) dfs(t *Tree, allowMissingNodes bool, cb func(*node) (bool, error)
 }
 
 func DeserializeFromDifference(r *bytes.Reader, localViewID uint64) (*node, error) {
+	return deserializeFromDifference(r, &localViewID)
+}
+
+// deserializeFromDifference decodes a node, rejecting it should localViewID be
+// given and the node not be of a later view.
+func deserializeFromDifference(r *bytes.Reader, localViewID *uint64) (*node, error) {
 	var buf64 [8]byte
 
 	var id [MerkleHashSize]byte
@@ -439,7 +445,7 @@ func DeserializeFromDifference(r *bytes.Reader, localViewID uint64) (*node, erro
 		return nil, err
 	}
 	viewID := binary.LittleEndian.Uint64(buf64[:])
-	if viewID <= localViewID {
+	if localViewID != nil && viewID <= *localViewID {
 		return nil, errors.New("got view id < local view id")
 	}
 
@@ -0,0 +1,145 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package avl
+
+import (
+	"bytes"
+
+	"github.com/pkg/errors"
+)
+
+// PruneOrphans deletes every node persisted by the tree that is reachable
+// neither from its root nor from any old root still pending garbage
+// collection, alongside any leftover garbage collection marks. It returns the
+// number of keys deleted, and the number of bytes they occupied.
+//
+// The tree must not hold any uncommitted changes.
+func (t *Tree) PruneOrphans() (int, int, error) {
+	alive := make(map[[MerkleHashSize]byte]struct{})
+
+	mark := func(n *node) (bool, error) {
+		if _, seen := alive[n.id]; seen {
+			return false, nil
+		}
+
+		alive[n.id] = struct{}{}
+		return true, nil
+	}
+
+	if t.root != nil {
+		if err := t.root.dfs(t, false, mark); err != nil {
+			return 0, 0, errors.Wrap(err, "tree is missing nodes reachable from its root")
+		}
+	}
+
+	var roots [][MerkleHashSize]byte
+
+	err := t.kv.IteratePrefix(OldRootsPrefix, func(key, value []byte) bool {
+		if len(value) == MerkleHashSize {
+			var id [MerkleHashSize]byte
+			copy(id[:], value)
+
+			roots = append(roots, id)
+		}
+
+		return true
+	})
+
+	if err != nil {
+		return 0, 0, errors.Wrap(err, "failed to iterate through old roots")
+	}
+
+	for _, id := range roots {
+		// Old roots may have already been partially garbage collected.
+		n, err := t.loadNode(id)
+		if err != nil {
+			continue
+		}
+
+		if err := n.dfs(t, true, mark); err != nil {
+			return 0, 0, err
+		}
+	}
+
+	var orphans [][]byte
+	var size int
+
+	collect := func(key, value []byte) bool {
+		orphans = append(orphans, append([]byte{}, key...))
+		size += len(key) + len(value)
+
+		return true
+	}
+
+	err = t.kv.IteratePrefix(NodeKeyPrefix, func(key, value []byte) bool {
+		var id [MerkleHashSize]byte
+		copy(id[:], key[len(NodeKeyPrefix):])
+
+		if _, exists := alive[id]; exists && len(key) == len(NodeKeyPrefix)+MerkleHashSize {
+			return true
+		}
+
+		return collect(key, value)
+	})
+
+	if err != nil {
+		return 0, 0, errors.Wrap(err, "failed to iterate through nodes")
+	}
+
+	if err := t.kv.IteratePrefix(GCAliveMarkPrefix, collect); err != nil {
+		return 0, 0, errors.Wrap(err, "failed to iterate through garbage collection marks")
+	}
+
+	batch := t.kv.NewWriteBatch()
+
+	for _, key := range orphans {
+		if bytes.HasPrefix(key, NodeKeyPrefix) && t.cache != nil {
+			var id [MerkleHashSize]byte
+			copy(id[:], key[len(NodeKeyPrefix):])
+
+			t.cache.remove(id)
+		}
+
+		batch.Delete(key)
+	}
+
+	if err := t.kv.CommitWriteBatch(batch); err != nil {
+		return 0, 0, errors.Wrap(err, "failed to delete orphaned nodes")
+	}
+
+	return len(orphans), size, nil
+}
+
+// Verify checks that every node reachable from the root of the tree is
+// persisted and may be decoded, returning the number of nodes reachable.
+func (t *Tree) Verify() (int, error) {
+	count := 0
+
+	if t.root == nil {
+		return count, nil
+	}
+
+	err := t.root.dfs(t, false, func(n *node) (bool, error) {
+		count++
+		return true, nil
+	})
+
+	return count, err
+}
@@ -0,0 +1,184 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package avl
+
+import (
+	"bytes"
+	"math"
+)
+
+// speculativeViewID is the view ID of nodes written to speculative snapshots,
+// which tells apart values written by a speculation from those it read from the
+// tree it speculates on top of.
+const speculativeViewID = math.MaxUint64
+
+// speculation records every key a speculative snapshot, and every snapshot taken
+// out of it, reads out of the tree the speculation started from.
+type speculation struct {
+	reads  []speculativeRead
+	keys   map[string]struct{}
+	opaque bool // Set should the speculation read the tree in ways that may not be validated.
+}
+
+type speculativeRead struct {
+	key    []byte
+	value  []byte
+	exists bool
+}
+
+// speculativeOp is a single write made to a speculative snapshot. Writes form a
+// persistent list, such that snapshots taken out of a speculative snapshot and
+// reverted to share the writes they have in common.
+type speculativeOp struct {
+	prev *speculativeOp
+
+	key    []byte
+	value  []byte
+	delete bool
+}
+
+// Speculate returns a snapshot of the tree which records all keys read from the
+// tree, alongside all writes made to the snapshot. The writes may then be
+// replayed on top of another tree via Replay should every key read hold the same
+// value in that tree, such that work may be carried out concurrently against
+// snapshots of the same tree and merged in a fixed order afterwards.
+//
+// A speculative snapshot, and all snapshots taken out of it, must only be used
+// by a single goroutine.
+func (t *Tree) Speculate() *Tree {
+	s := t.Snapshot()
+
+	s.viewID = speculativeViewID
+	s.speculation = &speculation{keys: make(map[string]struct{})}
+
+	return s
+}
+
+// Replay validates that every key the speculative snapshot speculative read
+// holds the same value in t, and should they all do, replays all writes made to
+// speculative onto t in the order they were made. It reports whether the writes
+// were replayed. t is left untouched otherwise.
+//
+// Replaying writes in the order they were made leaves t exactly as it would have
+// been should the same writes have been made to it directly, including the shape
+// of the tree and thus its checksum.
+func (t *Tree) Replay(speculative *Tree) bool {
+	s := speculative.speculation
+
+	if s == nil || s.opaque {
+		return false
+	}
+
+	for _, read := range s.reads {
+		value, exists := t.Lookup(read.key)
+
+		if exists != read.exists || !bytes.Equal(value, read.value) {
+			return false
+		}
+	}
+
+	var ops []*speculativeOp
+
+	for op := speculative.ops; op != nil; op = op.prev {
+		ops = append(ops, op)
+	}
+
+	for i := len(ops) - 1; i >= 0; i-- {
+		if ops[i].delete {
+			t.Delete(ops[i].key)
+		} else {
+			t.Insert(ops[i].key, ops[i].value)
+		}
+	}
+
+	return true
+}
+
+// lookupRecorded looks up key, recording the value it holds should it not have
+// been written by the speculation.
+func (t *Tree) lookupRecorded(key []byte) ([]byte, bool) {
+	var leaf *node
+
+	if t.root != nil {
+		leaf = t.root.lookupLeaf(t, key)
+	}
+
+	if leaf != nil && leaf.viewID == speculativeViewID {
+		return leaf.value, true
+	}
+
+	if _, recorded := t.speculation.keys[string(key)]; !recorded {
+		read := speculativeRead{key: append([]byte{}, key...)}
+
+		if leaf != nil {
+			read.value = append([]byte{}, leaf.value...)
+			read.exists = true
+		}
+
+		t.speculation.keys[string(key)] = struct{}{}
+		t.speculation.reads = append(t.speculation.reads, read)
+	}
+
+	if leaf == nil {
+		return nil, false
+	}
+
+	return leaf.value, true
+}
+
+// revertRecorded reverts the writes recorded by the speculation to those of
+// snapshot.
+func (t *Tree) revertRecorded(snapshot *Tree) {
+	if snapshot.speculation != t.speculation {
+		t.speculation.opaque = true
+		return
+	}
+
+	t.ops = snapshot.ops
+}
+
+func (t *Tree) recordWrite(key, value []byte, delete bool) {
+	t.ops = &speculativeOp{
+		prev:   t.ops,
+		key:    append([]byte{}, key...),
+		value:  append([]byte{}, value...),
+		delete: delete,
+	}
+}
+
+// lookupLeaf returns the leaf node holding key, or nil should key not be in the
+// tree.
+func (n *node) lookupLeaf(t *Tree, key []byte) *node {
+	for n.kind == NodeNonLeaf {
+		left := t.mustLoadLeft(n)
+
+		if bytes.Compare(key, left.key) <= 0 {
+			n = left
+		} else {
+			n = t.mustLoadRight(n)
+		}
+	}
+
+	if n.kind != NodeLeafValue || !bytes.Equal(n.key, key) {
+		return nil
+	}
+
+	return n
+}
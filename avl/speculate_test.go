@@ -0,0 +1,154 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package avl
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/perlin-network/wavelet/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTree_Speculate(t *testing.T) {
+	base := New(store.NewInmem())
+
+	for i := 0; i < 64; i++ {
+		base.Insert([]byte(fmt.Sprintf("k%d", i)), []byte(fmt.Sprintf("%d", i)))
+	}
+
+	assert.NoError(t, base.Commit())
+
+	// The same writes made either directly or speculatively and then replayed
+	// must leave the tree in the exact same shape.
+
+	apply := func(tree *Tree) {
+		v, _ := tree.Lookup([]byte("k1"))
+		tree.Insert([]byte("k1"), append(v, '!'))
+
+		ss := tree.Snapshot()
+		tree.Insert([]byte("reverted"), []byte("x"))
+		tree.Revert(ss)
+
+		for i := 0; i < 32; i++ {
+			tree.Insert([]byte(fmt.Sprintf("new%d", i)), []byte("y"))
+		}
+
+		tree.Delete([]byte("k2"))
+
+		v, exists := tree.Lookup([]byte("new3"))
+		assert.True(t, exists)
+		assert.Equal(t, []byte("y"), v)
+	}
+
+	direct := base.Snapshot()
+	apply(direct)
+
+	speculative := base.Speculate()
+	apply(speculative)
+
+	replayed := base.Snapshot()
+	assert.True(t, replayed.Replay(speculative))
+	assert.Equal(t, direct.Checksum(), replayed.Checksum())
+
+	_, exists := replayed.Lookup([]byte("reverted"))
+	assert.False(t, exists)
+
+	// Writes may not be replayed onto a tree in which a key read holds a
+	// different value.
+
+	changed := base.Snapshot()
+	changed.Insert([]byte("k1"), []byte("other"))
+
+	checksum := changed.Checksum()
+
+	assert.False(t, changed.Replay(speculative))
+	assert.Equal(t, checksum, changed.Checksum())
+
+	// Keys which were not read may hold different values.
+
+	unrelated := base.Snapshot()
+	unrelated.Insert([]byte("k5"), []byte("other"))
+
+	assert.True(t, unrelated.Replay(speculative))
+
+	// Iterating over a speculative snapshot reads keys in ways which may not be
+	// validated.
+
+	iterated := base.Speculate()
+	iterated.IteratePrefix([]byte("k"), func(key, value []byte) {})
+
+	assert.False(t, base.Snapshot().Replay(iterated))
+	assert.False(t, base.Snapshot().Replay(base.Snapshot()))
+}
+
+func TestTree_Concurrent(t *testing.T) {
+	for _, budget := range []int{0, 16} {
+		kv := store.NewInmem()
+		tree := New(kv)
+
+		for i := 0; i < 256; i++ {
+			tree.Insert([]byte(fmt.Sprintf("k%d", i)), []byte(fmt.Sprintf("%d", i)))
+		}
+
+		assert.NoError(t, tree.Commit())
+
+		// Reload the tree from the store, such that its nodes are loaded by every
+		// goroutine at once. Uncommitted nodes hold on to the children they load
+		// even under a node budget.
+
+		tree = New(kv)
+
+		if budget > 0 {
+			tree.WithNodeBudget(budget)
+		}
+
+		tree.Insert([]byte("uncommitted"), []byte("x"))
+
+		var wg sync.WaitGroup
+
+		start := make(chan struct{})
+
+		for w := 0; w < 8; w++ {
+			wg.Add(1)
+
+			go func(w int) {
+				defer wg.Done()
+
+				<-start
+
+				s := tree.Concurrent().Speculate()
+
+				for i := 0; i < 256; i++ {
+					key := []byte(fmt.Sprintf("k%d", (i+w*31)%256))
+
+					v, exists := s.Lookup(key)
+					assert.True(t, exists)
+
+					s.Insert(key, append(v, '!'))
+				}
+			}(w)
+		}
+
+		close(start)
+		wg.Wait()
+	}
+}
@@ -48,7 +48,25 @@ type Tree struct {
 
 	cache *lru
 
+	// budgeted is set should the number of nodes loaded from the store that
+	// are resident in memory be bounded by the size of the node cache.
+	budgeted bool
+
+	// prefetchDepth is how many levels of the subtree of a node loaded from the
+	// store are prefetched alongside it.
+	prefetchDepth int
+
 	viewID uint64
+
+	// concurrent is set should the tree be used alongside other snapshots of it
+	// by several goroutines at once, in which case it never writes the children
+	// it loads into the nodes it may share with them.
+	concurrent bool
+
+	// speculation and ops are set should the tree be a speculative snapshot,
+	// recording what is read from and written to the tree.
+	speculation *speculation
+	ops         *speculativeOp
 }
 
 func New(kv store.KV) *Tree {
@@ -75,12 +93,48 @@ func (t *Tree) WithLRUCache(size *int) *Tree {
 	return t
 }
 
+// WithNodeBudget has the tree keep at most nodes nodes loaded from the store
+// resident in memory. Committed nodes then do not hold on to the children they
+// load from the store, which are instead only kept in the node cache, such that
+// traversing a large tree does not eventually load all of it into memory.
+func (t *Tree) WithNodeBudget(nodes int) *Tree {
+	t.cache = newLRU(nodes)
+	t.budgeted = true
+
+	return t
+}
+
+// WithMemoryBudget bounds the approximate memory taken up by nodes resident in
+// memory across the tree and all of its snapshots to bytes bytes. Nodes are
+// then kept in memory only by the node cache once committed, and are evicted
+// from it in least-recently-used order once the budget is exceeded, to be
+// reloaded from the store on demand. Uncommitted nodes are never evicted, and
+// do not count towards the budget.
+func (t *Tree) WithMemoryBudget(bytes int64) *Tree {
+	t.cache.setMaxBytes(bytes)
+	t.budgeted = true
+
+	return t
+}
+
+// WithPrefetchDepth has the tree prefetch depth levels of the subtree of every
+// node it loads from the store, one batch of reads per level, such that nodes
+// along paths traversed next are already in the node cache.
+func (t *Tree) WithPrefetchDepth(depth int) *Tree {
+	t.prefetchDepth = depth
+	return t
+}
+
 func (t *Tree) WithMaxWriteBatchSize(maxWriteBatchSize int) *Tree {
 	t.maxWriteBatchSize = maxWriteBatchSize
 	return t
 }
 
 func (t *Tree) Insert(key, value []byte) {
+	if t.speculation != nil {
+		t.recordWrite(key, value, false)
+	}
+
 	if t.root == nil {
 		t.root = newLeafNode(t, key, value)
 	} else {
@@ -89,6 +143,10 @@ func (t *Tree) Insert(key, value []byte) {
 }
 
 func (t *Tree) Lookup(k []byte) ([]byte, bool) {
+	if t.speculation != nil {
+		return t.lookupRecorded(k)
+	}
+
 	if t.root == nil {
 		return nil, false
 	}
@@ -97,6 +155,11 @@ func (t *Tree) Lookup(k []byte) ([]byte, bool) {
 }
 
 func (t *Tree) Delete(k []byte) bool {
+	if t.speculation != nil {
+		t.lookupRecorded(k)
+		t.recordWrite(k, nil, true)
+	}
+
 	if t.root == nil {
 		return false
 	}
@@ -108,18 +171,58 @@ func (t *Tree) Delete(k []byte) bool {
 }
 
 func (t *Tree) Snapshot() *Tree {
-	return &Tree{kv: t.kv, cache: t.cache, maxWriteBatchSize: t.maxWriteBatchSize, root: t.root}
+	s := &Tree{
+		kv:                t.kv,
+		cache:             t.cache,
+		budgeted:          t.budgeted,
+		prefetchDepth:     t.prefetchDepth,
+		maxWriteBatchSize: t.maxWriteBatchSize,
+		root:              t.root,
+		concurrent:        t.concurrent,
+	}
+
+	if t.speculation != nil {
+		s.viewID = t.viewID
+		s.speculation = t.speculation
+		s.ops = t.ops
+	}
+
+	return s
+}
+
+// Concurrent returns a snapshot of the tree which, alongside all snapshots taken
+// out of it, may be used by one goroutine while other goroutines use snapshots
+// returned by Concurrent of the same tree. The tree itself must not be written
+// to meanwhile. Children loaded from the store are then only kept in the node
+// cache, rather than written into nodes the snapshots share.
+func (t *Tree) Concurrent() *Tree {
+	s := t.Snapshot()
+	s.concurrent = true
+
+	return s
 }
 
 func (t *Tree) Revert(snapshot *Tree) {
+	if t.speculation != nil {
+		t.revertRecorded(snapshot)
+	}
+
 	t.root = snapshot.root
 }
 
 func (t *Tree) Iterate(callback func(key, value []byte)) {
+	if t.speculation != nil {
+		t.speculation.opaque = true
+	}
+
 	t.doIterate(callback, t.root)
 }
 
 func (t *Tree) IterateFrom(key []byte, callback func(key, value []byte) bool) {
+	if t.speculation != nil {
+		t.speculation.opaque = true
+	}
+
 	if t.root == nil {
 		return
 	}
@@ -127,6 +230,10 @@ func (t *Tree) IterateFrom(key []byte, callback func(key, value []byte) bool) {
 }
 
 func (t *Tree) IteratePrefix(prefix []byte, callback func(key, value []byte)) {
+	if t.speculation != nil {
+		t.speculation.opaque = true
+	}
+
 	if t.root == nil {
 		return
 	}
@@ -182,13 +289,19 @@ func (t *Tree) Commit() error {
 		return nil
 	}
 
+	// All dirty nodes, alongside the new root and the record of the old root,
+	// are written in a single write batch such that a commit either persists
+	// entirely or not at all.
+
 	batch := t.kv.NewWriteBatch()
 
+	var written []*node
+
 	err := t.root.dfs(t, false, func(n *node) (bool, error) {
 		if n.wroteBack {
 			return false, nil
 		}
-		n.wroteBack = true
+		written = append(written, n)
 		var buf bytes.Buffer
 		n.serialize(&buf)
 
@@ -199,23 +312,41 @@ func (t *Tree) Commit() error {
 		return err
 	}
 
+	oldRootID, err := t.kv.Get(RootKey)
+
+	// If we want to include null roots here, getOldRoot() also needs to be fixed.
+	if err == nil && len(oldRootID) == MerkleHashSize {
+		nextOldRootIndex := t.getNextOldRootIndex()
+
+		var buf [8]byte
+
+		binary.LittleEndian.PutUint64(buf[:], nextOldRootIndex)
+		batch.Put(append(OldRootsPrefix, buf[:]...), oldRootID)
+
+		binary.LittleEndian.PutUint64(buf[:], nextOldRootIndex+1)
+		batch.Put(NextOldRootIndexKey, append([]byte{}, buf[:]...))
+	}
+
+	batch.Put(RootKey, append([]byte{}, t.root.id[:]...))
+
 	err = t.kv.CommitWriteBatch(batch)
 	if err != nil {
 		return errors.Wrap(err, "failed to commit write batch to db")
 	}
 
-	{
-		oldRootID, err := t.kv.Get(RootKey)
+	for _, n := range written {
+		n.wroteBack = true
+
+		// Under a budget, committed nodes let go of their children such that
+		// they may be evicted from memory, and be reloaded from the store.
 
-		// If we want to include null roots here, getOldRoot() also needs to be fixed.
-		if err == nil && len(oldRootID) == MerkleHashSize {
-			nextOldRootIndex := t.getNextOldRootIndex()
-			t.setOldRoot(nextOldRootIndex, oldRootID)
-			t.setNextOldRootIndex(nextOldRootIndex + 1)
+		if t.budgeted {
+			n.leftObj, n.rightObj = nil, nil
+			t.cache.put(n.id, n)
 		}
 	}
 
-	return t.kv.Put(RootKey, t.root.id[:])
+	return nil
 }
 
 func (t *Tree) getNextOldRootIndex() uint64 {
@@ -227,12 +358,6 @@ func (t *Tree) getNextOldRootIndex() uint64 {
 	}
 }
 
-func (t *Tree) setNextOldRootIndex(x uint64) {
-	var buf [8]byte
-	binary.LittleEndian.PutUint64(buf[:], x)
-	_ = t.kv.Put(NextOldRootIndexKey, buf[:])
-}
-
 func (t *Tree) getOldRoot(idx uint64) ([MerkleHashSize]byte, bool) {
 	var buf [8]byte
 	binary.LittleEndian.PutUint64(buf[:], idx)
@@ -247,21 +372,18 @@ func (t *Tree) getOldRoot(idx uint64) ([MerkleHashSize]byte, bool) {
 	}
 }
 
-func (t *Tree) setOldRoot(idx uint64, value []byte) {
-	var buf [8]byte
-	binary.LittleEndian.PutUint64(buf[:], idx)
-
-	_ = t.kv.Put(append(OldRootsPrefix, buf[:]...), value)
-}
-
-func (t *Tree) deleteOldRoot(idx uint64) {
+func (t *Tree) deleteOldRoot(batch store.WriteBatch, idx uint64) {
 	var buf [8]byte
 	binary.LittleEndian.PutUint64(buf[:], idx)
 
-	_ = t.kv.Delete(append(OldRootsPrefix, buf[:]...))
+	batch.Delete(append(OldRootsPrefix, buf[:]...))
 }
 
 func (t *Tree) Checksum() [MerkleHashSize]byte {
+	if t.speculation != nil {
+		t.speculation.opaque = true
+	}
+
 	if t.root == nil {
 		return [MerkleHashSize]byte{}
 	}
@@ -284,6 +406,10 @@ func (t *Tree) loadNode(id [MerkleHashSize]byte) (*node, error) {
 	n.wroteBack = true
 	t.cache.put(id, n)
 
+	if t.prefetchDepth > 0 {
+		t.prefetch(n)
+	}
+
 	return n, nil
 }
 
@@ -295,6 +421,72 @@ func (t *Tree) mustLoadNode(id [MerkleHashSize]byte) *node {
 	return n
 }
 
+// prefetch loads the subtree of n up to the prefetch depth of the tree into the
+// node cache, one level at a time. At most half of the node cache is filled by
+// a single prefetch. Prefetching is best-effort, and stops at the first level
+// that could not be read.
+func (t *Tree) prefetch(n *node) {
+	var frontier []*node
+
+	if n.kind == NodeNonLeaf {
+		frontier = append(frontier, n)
+	}
+
+	limit := t.cache.size / 2
+	fetched := 0
+
+	for depth := 0; depth < t.prefetchDepth && len(frontier) > 0; depth++ {
+		var ids [][MerkleHashSize]byte
+		var keys [][]byte
+
+		for _, parent := range frontier {
+			for _, id := range [][MerkleHashSize]byte{parent.left, parent.right} {
+				if _, cached := t.cache.load(id); cached {
+					continue
+				}
+
+				ids = append(ids, id)
+				keys = append(keys, append(NodeKeyPrefix, id[:]...))
+			}
+		}
+
+		if len(keys) == 0 || fetched+len(keys) > limit {
+			return
+		}
+
+		bufs, err := t.kv.MultiGet(keys...)
+		if err != nil {
+			return
+		}
+
+		frontier = frontier[:0]
+
+		for i, buf := range bufs {
+			if len(buf) == 0 {
+				continue
+			}
+
+			child := mustDeserialize(bytes.NewReader(buf))
+			child.wroteBack = true
+			t.cache.put(ids[i], child)
+
+			if child.kind == NodeNonLeaf {
+				frontier = append(frontier, child)
+			}
+		}
+
+		fetched += len(keys)
+	}
+}
+
+// pins returns whether n should hold on to the children it loads. Nodes that
+// have yet to be committed do, as their children may not be in the store,
+// unless n may be shared with snapshots used by other goroutines. Children that
+// were not yet loaded into an uncommitted node are then always in the store.
+func (t *Tree) pins(n *node) bool {
+	return !t.concurrent && (!t.budgeted || !n.wroteBack)
+}
+
 func (t *Tree) loadLeft(n *node) (*node, error) {
 	if n.leftObj != nil {
 		return n.leftObj, nil
@@ -304,7 +496,9 @@ func (t *Tree) loadLeft(n *node) (*node, error) {
 	if err != nil {
 		return nil, err
 	}
-	n.leftObj = ret
+	if t.pins(n) {
+		n.leftObj = ret
+	}
 	return ret, nil
 }
 
@@ -317,7 +511,9 @@ func (t *Tree) loadRight(n *node) (*node, error) {
 	if err != nil {
 		return nil, err
 	}
-	n.rightObj = ret
+	if t.pins(n) {
+		n.rightObj = ret
+	}
 	return ret, nil
 }
 
@@ -326,7 +522,9 @@ func (t *Tree) mustLoadLeft(n *node) *node {
 		return n.leftObj
 	}
 	ret := t.mustLoadNode(n.left)
-	n.leftObj = ret
+	if t.pins(n) {
+		n.leftObj = ret
+	}
 	return ret
 }
 
@@ -335,20 +533,35 @@ func (t *Tree) mustLoadRight(n *node) *node {
 		return n.rightObj
 	}
 	ret := t.mustLoadNode(n.right)
-	n.rightObj = ret
+	if t.pins(n) {
+		n.rightObj = ret
+	}
 	return ret
 }
 
-func (t *Tree) deleteNodeAndMetadata(id [MerkleHashSize]byte) {
+func (t *Tree) deleteNodeAndMetadata(batch store.WriteBatch, id [MerkleHashSize]byte) {
 	t.cache.remove(id)
-	_ = t.kv.Delete(append(NodeKeyPrefix, id[:]...))
-	_ = t.kv.Delete(append(GCAliveMarkPrefix, id[:]...))
+	batch.Delete(append(NodeKeyPrefix, id[:]...))
+	batch.Delete(append(GCAliveMarkPrefix, id[:]...))
 }
 
 func (t *Tree) SetViewID(viewID uint64) {
+	if t.speculation != nil {
+		t.speculation.opaque = true
+	}
+
 	t.viewID = viewID
 }
 
+// ViewID returns the ID of the view the tree was last modified in.
+func (t *Tree) ViewID() uint64 {
+	if t.root == nil {
+		return 0
+	}
+
+	return t.root.viewID
+}
+
 func (t *Tree) iterateDiff(prevViewID uint64, callback func(n *node) bool) {
 	var stack queue.Queue
 	stack.PushBack(t.root)
@@ -391,7 +604,44 @@ func (t *Tree) IterateLeafDiff(prevViewID uint64, callback func(key, value []byt
 	})
 }
 
+// Dump serializes every node of the tree, such that the tree may be restored
+// in full through Restore, even into an empty store.
+func (t *Tree) Dump() []byte {
+	buf := bytes.NewBuffer(nil)
+
+	if t.root == nil {
+		return buf.Bytes()
+	}
+
+	var stack queue.Queue
+	stack.PushBack(t.root)
+
+	for stack.Len() > 0 {
+		current := stack.PopBack().(*node)
+		current.serializeForDifference(buf)
+
+		if current.size > 1 {
+			stack.PushBack(t.mustLoadRight(current))
+			stack.PushBack(t.mustLoadLeft(current))
+		}
+	}
+
+	return buf.Bytes()
+}
+
+// Restore replaces the tree with the tree serialized by Dump. Unlike ApplyDiff,
+// nodes of views up to and including the trees own view are accepted.
+func (t *Tree) Restore(dump []byte) error {
+	return t.applyDiff(dump, nil, nil)
+}
+
 func (t *Tree) ApplyDiffWithUpdateNotifier(diff []byte, updateNotifier func(key, value []byte)) error {
+	viewID := t.viewID
+
+	return t.applyDiff(diff, &viewID, updateNotifier)
+}
+
+func (t *Tree) applyDiff(diff []byte, localViewID *uint64, updateNotifier func(key, value []byte)) error {
 	reader := bytes.NewReader(diff)
 
 	var root *node
@@ -399,7 +649,7 @@ func (t *Tree) ApplyDiffWithUpdateNotifier(diff []byte, updateNotifier func(key,
 	preloaded := make(map[[MerkleHashSize]byte]*node)
 
 	for reader.Len() > 0 {
-		n, err := DeserializeFromDifference(reader, t.viewID)
+		n, err := deserializeFromDifference(reader, localViewID)
 		if err != nil {
 			return err
 		}
@@ -482,6 +732,10 @@ func (profile *GCProfile) PerformFullGC() (int, error) {
 		}
 	}
 
+	// The nodes only reachable from an old root are deleted alongside the record
+	// of the old root in a single write batch, such that the record is never
+	// left pointing to a partially deleted tree.
+
 	deleteCount := 0
 	for ; i >= 0; i-- {
 		i := uint64(i)
@@ -494,12 +748,15 @@ func (profile *GCProfile) PerformFullGC() (int, error) {
 		if err != nil {
 			return 0, err
 		}
+
+		batch := profile.t.kv.NewWriteBatch()
+
 		err = n.dfs(profile.t, true, func(n *node) (bool, error) {
 			gotMark, _ := profile.t.kv.Get(append(GCAliveMarkPrefix, n.id[:]...))
 			if bytes.Equal(gotMark, mark[:]) {
 				return false, nil
 			}
-			profile.t.deleteNodeAndMetadata(n.id)
+			profile.t.deleteNodeAndMetadata(batch, n.id)
 			deleteCount++
 			return true, nil
 		})
@@ -507,7 +764,11 @@ func (profile *GCProfile) PerformFullGC() (int, error) {
 			return 0, err
 		}
 
-		profile.t.deleteOldRoot(i)
+		profile.t.deleteOldRoot(batch, i)
+
+		if err := profile.t.kv.CommitWriteBatch(batch); err != nil {
+			return 0, errors.Wrap(err, "failed to commit garbage collection write batch to db")
+		}
 	}
 
 	return deleteCount, nil
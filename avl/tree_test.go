@@ -22,7 +22,9 @@ package avl
 import (
 	"bytes"
 	"encoding/binary"
+	"fmt"
 	"github.com/perlin-network/wavelet/store"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"math/rand"
 	"os"
@@ -68,6 +70,117 @@ func TestTree_Commit(t *testing.T) {
 	}
 }
 
+// batchOnlyKV is a KV which refuses writes made outside of a write batch, and
+// which fails committing write batches while failing is set.
+type batchOnlyKV struct {
+	store.KV
+
+	failing bool
+	batches int
+}
+
+func (kv *batchOnlyKV) Put(key, value []byte) error {
+	return errors.New("writes must be made in a write batch")
+}
+
+func (kv *batchOnlyKV) CommitWriteBatch(batch store.WriteBatch) error {
+	if kv.failing {
+		return errors.New("failed to commit write batch")
+	}
+
+	kv.batches++
+
+	return kv.KV.CommitWriteBatch(batch)
+}
+
+func TestTree_CommitSingleBatch(t *testing.T) {
+	kv := &batchOnlyKV{KV: store.NewInmem()}
+
+	tree := New(kv)
+	tree.Insert([]byte("a"), []byte("1"))
+	assert.NoError(t, tree.Commit())
+
+	first := tree.root.id
+
+	tree.Insert([]byte("b"), []byte("2"))
+
+	// Nodes of a commit that failed are written by the next commit.
+
+	kv.failing = true
+	assert.Error(t, tree.Commit())
+
+	kv.failing = false
+	assert.NoError(t, tree.Commit())
+
+	assert.Equal(t, 2, kv.batches)
+
+	old, exists := tree.getOldRoot(0)
+	assert.True(t, exists)
+	assert.Equal(t, first, old)
+	assert.EqualValues(t, 1, tree.getNextOldRootIndex())
+
+	reloaded := New(kv)
+
+	val, ok := reloaded.Lookup([]byte("a"))
+	assert.True(t, ok)
+	assert.EqualValues(t, []byte("1"), val)
+
+	val, ok = reloaded.Lookup([]byte("b"))
+	assert.True(t, ok)
+	assert.EqualValues(t, []byte("2"), val)
+}
+
+func TestTree_PruneOrphans(t *testing.T) {
+	kv := store.NewInmem()
+
+	tree := New(kv)
+	tree.Insert([]byte("a"), []byte("1"))
+	assert.NoError(t, tree.Commit())
+
+	tree.Insert([]byte("b"), []byte("2"))
+	assert.NoError(t, tree.Commit())
+
+	var orphan [MerkleHashSize]byte
+	orphan[0] = 0xff
+
+	assert.NoError(t, kv.Put(append(NodeKeyPrefix, orphan[:]...), []byte("orphan")))
+	assert.NoError(t, kv.Put(append(GCAliveMarkPrefix, orphan[:]...), []byte("mark")))
+
+	count, size, err := tree.PruneOrphans()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+	assert.Equal(t, 2*(len(NodeKeyPrefix)+MerkleHashSize)+len("orphan")+len("mark"), size)
+
+	_, err = kv.Get(append(NodeKeyPrefix, orphan[:]...))
+	assert.Error(t, err)
+
+	// Nodes reachable from the old root pending garbage collection are kept.
+	oldRoot, ok := tree.getOldRoot(0)
+	assert.True(t, ok)
+
+	_, err = New(kv).loadNode(oldRoot)
+	assert.NoError(t, err)
+
+	tree = New(kv)
+
+	val, ok := tree.Lookup([]byte("a"))
+	assert.True(t, ok)
+	assert.Equal(t, []byte("1"), val)
+
+	count, _, err = tree.PruneOrphans()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, count)
+
+	reachable, err := tree.Verify()
+	assert.NoError(t, err)
+	assert.Equal(t, 3, reachable)
+
+	assert.NoError(t, kv.Delete(append(NodeKeyPrefix, tree.root.left[:]...)))
+
+	_, err = New(kv).Verify()
+	assert.Error(t, err)
+}
+
 func TestTree_DeleteUntilEmpty(t *testing.T) {
 	kv, cleanup := GetKV("level", "db")
 	defer cleanup()
@@ -264,6 +377,49 @@ func TestTree_ApplyEmptyDiff(t *testing.T) {
 	assert.Equal(t, tree1.root.id, tree2.root.id)
 }
 
+func TestTree_DumpRestore(t *testing.T) {
+	kv, cleanup1 := GetKV("level", "db")
+	defer cleanup1()
+
+	kv2, cleanup2 := GetKV("level", "db2")
+	defer cleanup2()
+
+	tree1 := New(kv)
+
+	for i := uint64(0); i < 100; i++ {
+		tree1.Insert([]byte(fmt.Sprintf("key-%d", i)), []byte(fmt.Sprintf("value-%d", i)))
+
+		if i%10 == 0 {
+			tree1.viewID++
+		}
+	}
+
+	assert.NoError(t, tree1.Commit())
+
+	// Nodes of view zero may not be applied as a difference onto an empty tree,
+	// but may be restored.
+
+	tree2 := New(kv2)
+	assert.Error(t, tree2.ApplyDiff(tree1.Dump()))
+
+	tree2 = New(kv2)
+	assert.NoError(t, tree2.Restore(tree1.Dump()))
+	assert.NoError(t, tree2.Commit())
+
+	assert.Equal(t, tree1.Checksum(), tree2.Checksum())
+	assert.Equal(t, tree1.ViewID(), tree2.ViewID())
+
+	tree3 := New(kv2)
+	assert.Equal(t, tree1.Checksum(), tree3.Checksum())
+
+	for i := uint64(0); i < 100; i++ {
+		value, exists := tree3.Lookup([]byte(fmt.Sprintf("key-%d", i)))
+		assert.True(t, exists)
+		assert.Equal(t, fmt.Sprintf("value-%d", i), string(value))
+	}
+
+}
+
 func TestTree_Difference(t *testing.T) {
 	kv, cleanup := GetKV("level", "db")
 	defer cleanup()
@@ -452,3 +608,100 @@ func GetKV(kv string, path string) (store.KV, func()) {
 
 	panic("unknown kv " + kv)
 }
+
+func TestTree_NodeBudget(t *testing.T) {
+	kv := store.NewInmem()
+
+	tree := New(kv)
+	for i := 0; i < 1024; i++ {
+		var buf [8]byte
+		binary.BigEndian.PutUint64(buf[:], uint64(i))
+		tree.Insert(buf[:], buf[:])
+	}
+	assert.NoError(t, tree.Commit())
+
+	budgeted := New(kv).WithNodeBudget(64)
+
+	for i := 0; i < 1024; i++ {
+		var buf [8]byte
+		binary.BigEndian.PutUint64(buf[:], uint64(i))
+
+		val, ok := budgeted.Lookup(buf[:])
+		assert.True(t, ok)
+		assert.Equal(t, buf[:], val)
+	}
+
+	assert.True(t, len(budgeted.cache.elements) <= 64)
+
+	// Committed nodes do not hold on to the children they load from the store.
+
+	assert.Nil(t, budgeted.root.leftObj)
+	assert.Nil(t, budgeted.root.rightObj)
+
+	// Nodes that have yet to be committed still do.
+
+	budgeted.Insert([]byte("key"), []byte("value"))
+	assert.False(t, budgeted.root.wroteBack)
+
+	val, ok := budgeted.Lookup([]byte("key"))
+	assert.True(t, ok)
+	assert.Equal(t, []byte("value"), val)
+	assert.NoError(t, budgeted.Commit())
+}
+
+func TestTree_Prefetch(t *testing.T) {
+	kv := store.NewInmem()
+
+	tree := New(kv)
+	for i := 0; i < 1024; i++ {
+		var buf [8]byte
+		binary.BigEndian.PutUint64(buf[:], uint64(i))
+		tree.Insert(buf[:], buf[:])
+	}
+	assert.NoError(t, tree.Commit())
+
+	// Loading a node prefetches two levels of nodes beneath it.
+
+	prefetched := (&Tree{kv: kv, cache: newLRU(DefaultCacheSize)}).WithPrefetchDepth(2)
+	root := prefetched.mustLoadNode(tree.root.id)
+
+	assert.Len(t, prefetched.cache.elements, 1+2+4)
+
+	_, cached := prefetched.cache.load(prefetched.mustLoadLeft(root).left)
+	assert.True(t, cached)
+}
+
+func TestTree_MemoryBudget(t *testing.T) {
+	kv := store.NewInmem()
+
+	budget := 64 * nodeOverhead
+
+	tree := New(kv).WithMemoryBudget(budget)
+
+	for i := 0; i < 1024; i++ {
+		var buf [8]byte
+		binary.BigEndian.PutUint64(buf[:], uint64(i))
+		tree.Insert(buf[:], buf[:])
+	}
+	assert.NoError(t, tree.Commit())
+
+	// Committed nodes let go of their children, and are kept in memory only
+	// by the node cache.
+
+	assert.Nil(t, tree.root.leftObj)
+	assert.Nil(t, tree.root.rightObj)
+	assert.True(t, tree.cache.bytes <= budget)
+
+	snapshot := tree.Snapshot()
+
+	for i := 0; i < 1024; i++ {
+		var buf [8]byte
+		binary.BigEndian.PutUint64(buf[:], uint64(i))
+
+		val, ok := snapshot.Lookup(buf[:])
+		assert.True(t, ok)
+		assert.Equal(t, buf[:], val)
+
+		assert.True(t, tree.cache.bytes <= budget)
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"encoding/binary"
+)
+
+// bloomBitsPerAccount and bloomHashes size an accounts bloom filter for a false
+// positive rate of roughly 1%.
+const (
+	bloomBitsPerAccount = 10
+	bloomHashes         = 7
+
+	// bloomMinCapacity is the least number of accounts a bloom filter is sized
+	// for.
+	bloomMinCapacity = 1 << 16
+)
+
+// accountBloom is a bloom filter over the IDs of existing accounts. As account
+// IDs are public keys, which are uniformly distributed, the bit positions are
+// derived directly from the bytes of IDs rather than from hashing them.
+type accountBloom struct {
+	bits     []uint64
+	count    int
+	capacity int
+}
+
+func newAccountBloom(capacity int) *accountBloom {
+	if capacity < bloomMinCapacity {
+		capacity = bloomMinCapacity
+	}
+
+	return &accountBloom{
+		bits:     make([]uint64, (capacity*bloomBitsPerAccount+63)/64),
+		capacity: capacity,
+	}
+}
+
+func (b *accountBloom) positions(id AccountID, fn func(bit uint64)) {
+	h1 := binary.LittleEndian.Uint64(id[0:8])
+	h2 := binary.LittleEndian.Uint64(id[8:16]) | 1
+
+	n := uint64(len(b.bits) * 64)
+
+	for i := uint64(0); i < bloomHashes; i++ {
+		fn((h1 + i*h2) % n)
+	}
+}
+
+// add records id as existing. It reports false should the filter have exceeded
+// the number of accounts it was sized for, in which case it ought to be rebuilt.
+func (b *accountBloom) add(id AccountID) bool {
+	if !b.mayContain(id) {
+		b.positions(id, func(bit uint64) {
+			b.bits[bit/64] |= 1 << (bit % 64)
+		})
+
+		b.count++
+	}
+
+	return b.count <= b.capacity
+}
+
+// mayContain returns false should id definitely not be an existing account.
+func (b *accountBloom) mayContain(id AccountID) bool {
+	contained := true
+
+	b.positions(id, func(bit uint64) {
+		if b.bits[bit/64]&(1<<(bit%64)) == 0 {
+			contained = false
+		}
+	})
+
+	return contained
+}
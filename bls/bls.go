@@ -0,0 +1,237 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+// Package bls implements BLS signatures over the BLS12-381 pairing-friendly
+// curve, such that signatures by many signers over the same message may be
+// aggregated into a single signature that is verified with a single pairing
+// check.
+//
+// Public keys live in G1, and signatures live in G2, following the proof of
+// possession ciphersuite of the IETF BLS signature draft. To defend against
+// rogue public key attacks, every public key must be accompanied by a proof of
+// possession of its private key before it may be aggregated.
+package bls
+
+import (
+	"crypto/rand"
+	"math/big"
+
+	bls12381 "github.com/kilic/bls12-381"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/blake2b"
+)
+
+const (
+	SizePublicKey = 48
+	SizeSignature = 96
+)
+
+var (
+	// Domains which separate the hashes of messages signed as votes from the
+	// hashes of public keys signed as proofs of possession.
+	domainMessage = []byte("BLS_SIG_BLS12381G2_XMD:SHA-256_SSWU_RO_POP_")
+	domainProof   = []byte("BLS_POP_BLS12381G2_XMD:SHA-256_SSWU_RO_POP_")
+)
+
+type PrivateKey struct {
+	k *bls12381.Fr
+}
+
+type PublicKey struct {
+	p *bls12381.PointG1
+}
+
+type Signature struct {
+	p *bls12381.PointG2
+}
+
+// NewPrivateKey deterministically derives a private key from a seed, such that
+// a node may derive its BLS private key from its existing private key.
+func NewPrivateKey(seed []byte) *PrivateKey {
+	digest := blake2b.Sum512(seed)
+
+	order := bls12381.NewG1().Q()
+
+	k := new(big.Int).SetBytes(digest[:])
+	k.Mod(k, new(big.Int).Sub(order, big.NewInt(1)))
+	k.Add(k, big.NewInt(1)) // Private keys must be non-zero.
+
+	return &PrivateKey{k: bls12381.NewFr().FromBytes(k.Bytes())}
+}
+
+func (k *PrivateKey) PublicKey() *PublicKey {
+	g := bls12381.NewG1()
+	return &PublicKey{p: g.MulScalar(g.New(), g.One(), k.k)}
+}
+
+// Sign signs a message.
+func (k *PrivateKey) Sign(msg []byte) *Signature {
+	return k.sign(msg, domainMessage)
+}
+
+// Prove produces a proof that we possess the private key to our public key.
+func (k *PrivateKey) Prove() *Signature {
+	return k.sign(k.PublicKey().Marshal(), domainProof)
+}
+
+// sign multiplies the hash of a message by the private key. Scalar
+// multiplication is not constant-time, so the private key is split into two
+// randomly blinded shares which are multiplied separately, such that the time
+// taken does not leak the private key.
+func (k *PrivateKey) sign(msg, domain []byte) *Signature {
+	g := bls12381.NewG2()
+
+	h, err := g.HashToCurve(msg, domain)
+	if err != nil {
+		panic(errors.Wrap(err, "bls: failed to hash message to curve"))
+	}
+
+	blind, err := bls12381.NewFr().Rand(rand.Reader)
+	if err != nil {
+		panic(errors.Wrap(err, "bls: failed to read randomness"))
+	}
+
+	rest := bls12381.NewFr()
+	rest.Sub(k.k, blind)
+
+	sig := g.MulScalar(g.New(), h, blind)
+	g.Add(sig, sig, g.MulScalar(g.New(), h, rest))
+
+	return &Signature{p: sig}
+}
+
+// Verify verifies a signature over a message.
+func (pk *PublicKey) Verify(msg []byte, sig *Signature) bool {
+	return verify(pk, msg, domainMessage, sig)
+}
+
+// VerifyProof verifies a proof of possession of the private key to pk.
+func (pk *PublicKey) VerifyProof(proof *Signature) bool {
+	return verify(pk, pk.Marshal(), domainProof, proof)
+}
+
+// Marshal encodes the public key in its 48-byte compressed form.
+func (pk *PublicKey) Marshal() []byte {
+	return bls12381.NewG1().ToCompressed(pk.p)
+}
+
+// Marshal encodes the signature in its 96-byte compressed form.
+func (sig *Signature) Marshal() []byte {
+	return bls12381.NewG2().ToCompressed(sig.p)
+}
+
+// UnmarshalPublicKey decodes a compressed public key, checking that it lies
+// within the correct subgroup.
+func UnmarshalPublicKey(buf []byte) (*PublicKey, error) {
+	if len(buf) != SizePublicKey {
+		return nil, errors.Errorf("bls: public key must be %d bytes long", SizePublicKey)
+	}
+
+	g := bls12381.NewG1()
+
+	p, err := g.FromCompressed(buf)
+	if err != nil {
+		return nil, errors.Wrap(err, "bls: public key is not a point within G1")
+	}
+
+	if g.IsZero(p) {
+		return nil, errors.New("bls: public key must not be the point at infinity")
+	}
+
+	return &PublicKey{p: p}, nil
+}
+
+// UnmarshalSignature decodes a compressed signature, checking that it lies
+// within the correct subgroup.
+func UnmarshalSignature(buf []byte) (*Signature, error) {
+	if len(buf) != SizeSignature {
+		return nil, errors.Errorf("bls: signature must be %d bytes long", SizeSignature)
+	}
+
+	g := bls12381.NewG2()
+
+	p, err := g.FromCompressed(buf)
+	if err != nil {
+		return nil, errors.Wrap(err, "bls: signature is not a point within G2")
+	}
+
+	if g.IsZero(p) {
+		return nil, errors.New("bls: signature must not be the point at infinity")
+	}
+
+	return &Signature{p: p}, nil
+}
+
+// AggregateSignatures aggregates many signatures into a single signature.
+func AggregateSignatures(sigs ...*Signature) *Signature {
+	if len(sigs) == 0 {
+		return nil
+	}
+
+	g := bls12381.NewG2()
+	agg := g.New().Set(sigs[0].p)
+
+	for _, sig := range sigs[1:] {
+		g.Add(agg, agg, sig.p)
+	}
+
+	return &Signature{p: agg}
+}
+
+// AggregatePublicKeys aggregates many public keys into a single public key, which
+// verifies signatures aggregated from signatures by all keys over the same message.
+// All public keys must have had their proofs of possession verified beforehand.
+func AggregatePublicKeys(pks ...*PublicKey) *PublicKey {
+	if len(pks) == 0 {
+		return nil
+	}
+
+	g := bls12381.NewG1()
+	agg := g.New().Set(pks[0].p)
+
+	for _, pk := range pks[1:] {
+		g.Add(agg, agg, pk.p)
+	}
+
+	return &PublicKey{p: agg}
+}
+
+// verify checks that e(pk, H(msg)) = e(G1, sig).
+func verify(pk *PublicKey, msg, domain []byte, sig *Signature) bool {
+	if pk == nil || sig == nil {
+		return false
+	}
+
+	g1, g2 := bls12381.NewG1(), bls12381.NewG2()
+
+	if g1.IsZero(pk.p) || g2.IsZero(sig.p) {
+		return false
+	}
+
+	h, err := g2.HashToCurve(msg, domain)
+	if err != nil {
+		return false
+	}
+
+	engine := bls12381.NewEngine()
+	engine.AddPair(g1.New().Set(pk.p), h)
+	engine.AddPairInv(g1.One(), g2.New().Set(sig.p))
+
+	return engine.Check()
+}
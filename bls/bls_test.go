@@ -0,0 +1,71 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package bls
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignAndAggregate(t *testing.T) {
+	msg := []byte("round")
+
+	var pks []*PublicKey
+	var sigs []*Signature
+
+	for i := 0; i < 3; i++ {
+		k := NewPrivateKey([]byte{byte(i)})
+
+		pk, err := UnmarshalPublicKey(k.PublicKey().Marshal())
+		assert.NoError(t, err)
+
+		proof, err := UnmarshalSignature(k.Prove().Marshal())
+		assert.NoError(t, err)
+		assert.True(t, pk.VerifyProof(proof))
+
+		sig, err := UnmarshalSignature(k.Sign(msg).Marshal())
+		assert.NoError(t, err)
+		assert.Equal(t, sig.Marshal(), k.Sign(msg).Marshal(), "signatures must be deterministic")
+
+		assert.True(t, pk.Verify(msg, sig))
+		assert.False(t, pk.Verify([]byte("other"), sig))
+
+		pks = append(pks, pk)
+		sigs = append(sigs, sig)
+	}
+
+	agg := AggregateSignatures(sigs...)
+	assert.True(t, AggregatePublicKeys(pks...).Verify(msg, agg))
+	assert.False(t, AggregatePublicKeys(pks[:2]...).Verify(msg, agg))
+
+	// A proof of possession is not a valid signature over a message, and vice versa.
+	assert.False(t, pks[0].VerifyProof(sigs[0]))
+
+	_, err := UnmarshalPublicKey(make([]byte, SizePublicKey))
+	assert.Error(t, err)
+
+	// The point at infinity is a valid encoding, yet must never be accepted.
+	infinity := make([]byte, SizePublicKey)
+	infinity[0] = 0xc0
+
+	_, err = UnmarshalPublicKey(infinity)
+	assert.Error(t, err)
+}
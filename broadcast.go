@@ -0,0 +1,484 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/perlin-network/wavelet/sys"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrQueueFull = errors.New("broadcast queue is full")
+
+	// ErrSenderQueueFull is caused by ErrQueueFull, such that callers tolerating a
+	// full broadcast queue also tolerate a sender having exhausted its share of it.
+	ErrSenderQueueFull = errors.Wrap(ErrQueueFull, "sender has too many transactions pending to be gossiped")
+)
+
+// OverflowPolicy decides what happens to a transaction pushed into a full
+// broadcast queue.
+type OverflowPolicy string
+
+const (
+	// Reject the transaction being pushed.
+	OverflowRejectNewest OverflowPolicy = "reject-newest"
+
+	// Evict whichever queued transaction pays the lowest fee, so long as it pays
+	// less than the transaction being pushed. Every transaction pays the same
+	// flat fee, such that transactions are set apart by the gas they offer to
+	// pay for invoking smart contracts.
+	OverflowEvictLowestFee OverflowPolicy = "evict-lowest-fee"
+
+	// Block until the queue has room, or until a deadline passes.
+	OverflowBlock OverflowPolicy = "block-with-deadline"
+)
+
+func ParseOverflowPolicy(policy string) (OverflowPolicy, error) {
+	switch p := OverflowPolicy(policy); p {
+	case OverflowRejectNewest, OverflowEvictLowestFee, OverflowBlock:
+		return p, nil
+	}
+
+	return "", errors.Errorf("unknown broadcast queue overflow policy %q", policy)
+}
+
+// BroadcastConfig describes the capacity of the queue of transactions pending to
+// be gossiped, and what happens once it overflows. Any field left at zero falls
+// back to its default.
+type BroadcastConfig struct {
+	Capacity int
+	Policy   OverflowPolicy
+
+	// How long to block for under OverflowBlock before giving up.
+	Deadline time.Duration
+
+	// Maximum number of relayed transactions any single sender may have pending
+	// to be gossiped, such that no one sender may crowd out everyone else.
+	MaxPerSender int
+}
+
+func DefaultBroadcastConfig() BroadcastConfig {
+	return BroadcastConfig{
+		Capacity: 16384,
+		Policy:   OverflowRejectNewest,
+		Deadline: 1 * time.Second,
+
+		MaxPerSender: 1024,
+	}
+}
+
+func (c BroadcastConfig) withDefaults() BroadcastConfig {
+	defaults := DefaultBroadcastConfig()
+
+	if c.Capacity <= 0 {
+		c.Capacity = defaults.Capacity
+	}
+
+	if len(c.Policy) == 0 {
+		c.Policy = defaults.Policy
+	}
+
+	if c.Deadline <= 0 {
+		c.Deadline = defaults.Deadline
+	}
+
+	if c.MaxPerSender <= 0 {
+		c.MaxPerSender = defaults.MaxPerSender
+	}
+
+	return c
+}
+
+// BroadcastQueue is a bounded queue of transactions pending to be gossiped to
+// our peers. Transactions originating from our own node are queued in a separate
+// lane, which is always gossiped ahead of transactions relayed from our peers.
+type BroadcastQueue struct {
+	sync.Mutex
+
+	config  BroadcastConfig
+	metrics *Metrics
+
+	local   []Transaction
+	relayed []Transaction
+
+	senders map[AccountID]int // Number of relayed transactions pending per sender.
+
+	reserved int // Number of reservations neither committed nor released yet.
+
+	space chan struct{} // Closed and replaced whenever transactions are dequeued.
+}
+
+func NewBroadcastQueue(config BroadcastConfig, metrics *Metrics) *BroadcastQueue {
+	return &BroadcastQueue{
+		config:  config.withDefaults(),
+		metrics: metrics,
+		senders: make(map[AccountID]int),
+		space:   make(chan struct{}),
+	}
+}
+
+func (q *BroadcastQueue) configure(config BroadcastConfig) {
+	q.Lock()
+	q.config = config.withDefaults()
+	q.Unlock()
+}
+
+// BroadcastReservation holds room in a broadcast queue for a transaction which
+// is yet to be queued up. A reservation must either be committed or released.
+type BroadcastReservation struct {
+	queue *BroadcastQueue
+
+	tx    Transaction
+	local bool
+
+	done bool
+}
+
+// Commit queues up the transaction room was reserved for.
+func (r *BroadcastReservation) Commit() {
+	q := r.queue
+
+	q.Lock()
+	defer q.Unlock()
+
+	if r.done {
+		return
+	}
+
+	r.done = true
+	q.reserved--
+
+	if r.local {
+		q.local = append(q.local, r.tx)
+	} else {
+		q.relayed = append(q.relayed, r.tx)
+	}
+
+	q.updateDepth()
+
+	if q.metrics != nil {
+		q.metrics.gossipedTX.Mark(int64(r.tx.LogicalUnits()))
+	}
+}
+
+// Release gives up the room reserved, should the transaction no longer need to
+// be gossiped.
+func (r *BroadcastReservation) Release() {
+	q := r.queue
+
+	q.Lock()
+	defer q.Unlock()
+
+	if r.done {
+		return
+	}
+
+	r.done = true
+	q.reserved--
+	q.track(r.tx, r.local, -1)
+
+	close(q.space)
+	q.space = make(chan struct{})
+}
+
+// Push queues up a transaction to be gossiped. Should the queue be full, a local
+// transaction evicts the most recently queued relayed transaction. Otherwise,
+// ErrQueueFull is returned should the transaction not be queued as per the
+// queues overflow policy. Local transactions are never evicted in favor of
+// relayed transactions.
+//
+// ErrSenderQueueFull is returned should a relayed transaction be pushed whose
+// sender already has the maximum number of relayed transactions pending.
+func (q *BroadcastQueue) Push(tx Transaction, local bool) error {
+	reservation, err := q.Reserve(tx, local)
+	if err != nil {
+		return err
+	}
+
+	reservation.Commit()
+
+	return nil
+}
+
+// Reserve makes room for a transaction in the queue as per Push, without queuing
+// it up just yet, such that callers may find out whether a transaction may be
+// gossiped before accepting it.
+func (q *BroadcastQueue) Reserve(tx Transaction, local bool) (*BroadcastReservation, error) {
+	var deadline <-chan time.Time
+
+	lane := &q.relayed
+
+	if local {
+		lane = &q.local
+	}
+
+	reserve := func() *BroadcastReservation {
+		q.reserved++
+		q.track(tx, local, 1)
+
+		return &BroadcastReservation{queue: q, tx: tx, local: local}
+	}
+
+	for {
+		q.Lock()
+
+		if !local && q.senders[tx.Sender] >= q.config.MaxPerSender {
+			q.Unlock()
+
+			q.drop(1)
+			return nil, ErrSenderQueueFull
+		}
+
+		if len(q.local)+len(q.relayed)+q.reserved < q.config.Capacity {
+			reservation := reserve()
+			q.Unlock()
+
+			return reservation, nil
+		}
+
+		if local && len(q.relayed) > 0 {
+			q.track(q.relayed[len(q.relayed)-1], false, -1)
+
+			q.relayed[len(q.relayed)-1] = Transaction{}
+			q.relayed = q.relayed[:len(q.relayed)-1]
+
+			reservation := reserve()
+			q.Unlock()
+
+			q.drop(1)
+			return reservation, nil
+		}
+
+		switch q.config.Policy {
+		case OverflowEvictLowestFee:
+			var reservation *BroadcastReservation
+
+			victim, evicted := evictLowestFee(lane, transactionFee(tx))
+			if evicted {
+				q.track(victim, local, -1)
+
+				reservation = reserve()
+			}
+			q.Unlock()
+
+			q.drop(1)
+
+			if !evicted {
+				return nil, ErrQueueFull
+			}
+
+			return reservation, nil
+		case OverflowBlock:
+			if deadline == nil {
+				deadline = time.After(q.config.Deadline)
+			}
+			space := q.space
+			q.Unlock()
+
+			select {
+			case <-space:
+				continue
+			case <-deadline:
+				q.drop(1)
+				return nil, ErrQueueFull
+			}
+		default:
+			q.Unlock()
+
+			q.drop(1)
+			return nil, ErrQueueFull
+		}
+	}
+}
+
+// Pop dequeues local transactions, followed by relayed transactions, in the
+// order they were pushed up until their marshaled size totals to at most limit
+// bytes. At least one transaction is dequeued should the queue not be empty.
+func (q *BroadcastQueue) Pop(limit int) [][]byte {
+	q.Lock()
+	defer q.Unlock()
+
+	var batch [][]byte
+	size := 0
+
+	for i, lane := range []*[]Transaction{&q.local, &q.relayed} {
+		for len(*lane) > 0 {
+			buf := (*lane)[0].Marshal()
+
+			if len(batch) > 0 && size+len(buf) > limit {
+				break
+			}
+
+			batch = append(batch, buf)
+			size += len(buf)
+
+			q.track((*lane)[0], i == 0, -1)
+
+			(*lane)[0] = Transaction{}
+			*lane = (*lane)[1:]
+		}
+	}
+
+	if len(batch) > 0 {
+		q.updateDepth()
+
+		close(q.space)
+		q.space = make(chan struct{})
+	}
+
+	return batch
+}
+
+// Lanes returns the number of local and relayed transactions pending to be
+// gossiped.
+func (q *BroadcastQueue) Lanes() (local, relayed int) {
+	q.Lock()
+	defer q.Unlock()
+
+	return len(q.local), len(q.relayed)
+}
+
+// Capacity returns the maximum number of transactions the queue may hold.
+func (q *BroadcastQueue) Capacity() int {
+	q.Lock()
+	defer q.Unlock()
+
+	return q.config.Capacity
+}
+
+// Len returns the number of transactions pending to be gossiped.
+func (q *BroadcastQueue) Len() int {
+	q.Lock()
+	defer q.Unlock()
+
+	return len(q.local) + len(q.relayed)
+}
+
+// BroadcastSnapshot is a point-in-time copy of the transactions pending to be
+// gossiped, in the order they are to be gossiped, alongside the configuration
+// of the queue holding them.
+type BroadcastSnapshot struct {
+	Config BroadcastConfig
+
+	Local   []Transaction
+	Relayed []Transaction
+}
+
+// Snapshot returns a copy of the transactions pending to be gossiped.
+func (q *BroadcastQueue) Snapshot() BroadcastSnapshot {
+	q.Lock()
+	defer q.Unlock()
+
+	return BroadcastSnapshot{
+		Config:  q.config,
+		Local:   append([]Transaction(nil), q.local...),
+		Relayed: append([]Transaction(nil), q.relayed...),
+	}
+}
+
+// evictLowestFee evicts and returns the transaction in lane paying the lowest
+// fee, so long as it is lower than fee.
+func evictLowestFee(lane *[]Transaction, fee uint64) (Transaction, bool) {
+	lowest, lowestFee := -1, uint64(0)
+
+	for i := range *lane {
+		if f := transactionFee((*lane)[i]); lowest == -1 || f < lowestFee {
+			lowest, lowestFee = i, f
+		}
+	}
+
+	if lowest == -1 || lowestFee >= fee {
+		return Transaction{}, false
+	}
+
+	victim := (*lane)[lowest]
+	*lane = append((*lane)[:lowest], (*lane)[lowest+1:]...)
+
+	return victim, true
+}
+
+// track adjusts the number of relayed transactions pending from the sender of
+// tx by delta. Local transactions are not tracked.
+func (q *BroadcastQueue) track(tx Transaction, local bool, delta int) {
+	if local {
+		return
+	}
+
+	if q.senders[tx.Sender] += delta; q.senders[tx.Sender] <= 0 {
+		delete(q.senders, tx.Sender)
+	}
+}
+
+func (q *BroadcastQueue) updateDepth() {
+	if q.metrics != nil {
+		q.metrics.broadcastDepth.Update(int64(len(q.local) + len(q.relayed)))
+	}
+}
+
+func (q *BroadcastQueue) drop(count int64) {
+	if q.metrics != nil {
+		q.metrics.broadcastDropped.Mark(count)
+	}
+}
+
+// transactionFee returns the most a transaction may pay beyond the flat fee every
+// transaction pays, which is the gas it offers to pay for invoking smart
+// contracts. Entries of a batch pay for their gas separately.
+func transactionFee(tx Transaction) uint64 {
+	return gasOffered(tx.Tag, tx.Payload)
+}
+
+func gasOffered(tag sys.Tag, payload []byte) uint64 {
+	switch tag {
+	case sys.TagTransfer:
+		if params, err := ParseTransferTransaction(payload); err == nil {
+			return params.GasLimit
+		}
+	case sys.TagContract:
+		if params, err := ParseContractTransaction(payload); err == nil {
+			return params.GasLimit
+		}
+	case sys.TagBatch:
+		params, err := ParseBatchTransaction(payload)
+		if err != nil {
+			return 0
+		}
+
+		var gas uint64
+
+		for i := uint8(0); i < params.Size; i++ {
+			offered := gasOffered(sys.Tag(params.Tags[i]), params.Payloads[i])
+
+			if gas+offered < gas {
+				return math.MaxUint64
+			}
+
+			gas += offered
+		}
+
+		return gas
+	}
+
+	return 0
+}
@@ -0,0 +1,215 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"testing"
+	"time"
+
+	"github.com/perlin-network/noise/skademlia"
+	"github.com/perlin-network/wavelet/sys"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBroadcastQueueOverflow(t *testing.T) {
+	t.Parallel()
+
+	keys, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	single := AttachSenderToTransaction(keys, NewTransaction(keys, sys.TagTransfer, nil))
+	batch := AttachSenderToTransaction(keys, NewTransaction(keys, sys.TagBatch, []byte{2}))
+
+	// Reject the newest transaction.
+
+	queue := NewBroadcastQueue(BroadcastConfig{Capacity: 1, Policy: OverflowRejectNewest}, nil)
+
+	assert.NoError(t, queue.Push(single, false))
+	assert.Equal(t, ErrQueueFull, queue.Push(single, false))
+	assert.Equal(t, 1, queue.Len())
+
+	// Evict the transaction paying the least, regardless of how many logical
+	// units it is comprised of.
+
+	cheap := AttachSenderToTransaction(keys, NewTransaction(keys, sys.TagContract, Contract{GasLimit: 10, Code: []byte{1}}.Marshal()))
+	costly := AttachSenderToTransaction(keys, NewTransaction(keys, sys.TagContract, Contract{GasLimit: 20, Code: []byte{1}}.Marshal()))
+
+	queue = NewBroadcastQueue(BroadcastConfig{Capacity: 1, Policy: OverflowEvictLowestFee}, nil)
+
+	assert.NoError(t, queue.Push(cheap, false))
+	assert.Equal(t, ErrQueueFull, queue.Push(single, false))
+	assert.Equal(t, ErrQueueFull, queue.Push(batch, false))
+	assert.NoError(t, queue.Push(costly, false))
+	assert.Equal(t, ErrQueueFull, queue.Push(cheap, false))
+	assert.Equal(t, [][]byte{costly.Marshal()}, queue.Pop(0))
+
+	// Block until the queue has room, or until the deadline passes.
+
+	queue = NewBroadcastQueue(BroadcastConfig{Capacity: 1, Policy: OverflowBlock, Deadline: 10 * time.Millisecond}, nil)
+
+	assert.NoError(t, queue.Push(single, false))
+	assert.Equal(t, ErrQueueFull, queue.Push(single, false))
+
+	queue.configure(BroadcastConfig{Capacity: 1, Policy: OverflowBlock, Deadline: 1 * time.Minute})
+
+	go queue.Pop(0)
+
+	assert.NoError(t, queue.Push(batch, false))
+	assert.Equal(t, [][]byte{batch.Marshal()}, queue.Pop(0))
+}
+
+func TestBroadcastQueueReserve(t *testing.T) {
+	t.Parallel()
+
+	keys, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	tx := AttachSenderToTransaction(keys, NewTransaction(keys, sys.TagTransfer, nil))
+
+	queue := NewBroadcastQueue(BroadcastConfig{Capacity: 1, Policy: OverflowRejectNewest}, nil)
+
+	reservation, err := queue.Reserve(tx, false)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, queue.Len())
+
+	_, err = queue.Reserve(tx, false)
+	assert.Equal(t, ErrQueueFull, err, "a reservation must hold room in the queue")
+
+	reservation.Release()
+	reservation.Release()
+
+	reservation, err = queue.Reserve(tx, false)
+	assert.NoError(t, err, "a released reservation must free up room in the queue")
+
+	reservation.Commit()
+	reservation.Release()
+
+	assert.Equal(t, 1, queue.Len())
+	assert.Equal(t, [][]byte{tx.Marshal()}, queue.Pop(0))
+}
+
+func TestBroadcastQueuePop(t *testing.T) {
+	t.Parallel()
+
+	keys, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	queue := NewBroadcastQueue(DefaultBroadcastConfig(), nil)
+
+	tx := AttachSenderToTransaction(keys, NewTransaction(keys, sys.TagTransfer, nil))
+	size := len(tx.Marshal())
+
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, queue.Push(tx, false))
+	}
+
+	assert.Len(t, queue.Pop(2*size), 2)
+	assert.Len(t, queue.Pop(size-1), 1)
+	assert.Len(t, queue.Pop(10*size), 2)
+	assert.Len(t, queue.Pop(10*size), 0)
+}
+
+func TestBroadcastQueueLocalLane(t *testing.T) {
+	t.Parallel()
+
+	keys, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	relayed := AttachSenderToTransaction(keys, NewTransaction(keys, sys.TagTransfer, nil))
+	local := AttachSenderToTransaction(keys, NewTransaction(keys, sys.TagStake, nil))
+
+	queue := NewBroadcastQueue(BroadcastConfig{Capacity: 2, Policy: OverflowRejectNewest}, nil)
+
+	assert.NoError(t, queue.Push(relayed, false))
+	assert.NoError(t, queue.Push(relayed, false))
+
+	// Local transactions evict relayed transactions should the queue be full,
+	// and are gossiped ahead of them.
+
+	assert.NoError(t, queue.Push(local, true))
+	assert.NoError(t, queue.Push(local, true))
+	assert.Equal(t, ErrQueueFull, queue.Push(local, true))
+	assert.Equal(t, ErrQueueFull, queue.Push(relayed, false))
+
+	assert.Equal(t, [][]byte{local.Marshal()}, queue.Pop(0))
+	assert.NoError(t, queue.Push(relayed, false))
+	assert.Equal(t, [][]byte{local.Marshal(), relayed.Marshal()}, queue.Pop(1<<20))
+}
+
+func TestBroadcastQueueMaxPerSender(t *testing.T) {
+	t.Parallel()
+
+	alice, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	bob, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	fromAlice := AttachSenderToTransaction(alice, NewTransaction(alice, sys.TagTransfer, nil))
+	fromBob := AttachSenderToTransaction(bob, NewTransaction(bob, sys.TagTransfer, nil))
+
+	queue := NewBroadcastQueue(BroadcastConfig{Capacity: 3, MaxPerSender: 2}, nil)
+
+	// Relayed transactions are limited per sender.
+
+	assert.NoError(t, queue.Push(fromAlice, false))
+	assert.NoError(t, queue.Push(fromAlice, false))
+	assert.Equal(t, ErrSenderQueueFull, queue.Push(fromAlice, false))
+	assert.Equal(t, ErrQueueFull, errors.Cause(queue.Push(fromAlice, false)))
+	assert.NoError(t, queue.Push(fromBob, false))
+
+	// Local transactions are not, and evict relayed transactions which then no
+	// longer count towards their sender.
+
+	assert.NoError(t, queue.Push(fromAlice, true))
+	assert.NoError(t, queue.Push(fromAlice, true))
+
+	snapshot := queue.Snapshot()
+	assert.Equal(t, []Transaction{fromAlice, fromAlice}, snapshot.Local)
+	assert.Equal(t, []Transaction{fromAlice}, snapshot.Relayed)
+
+	assert.Len(t, queue.Pop(0), 1)
+	assert.NoError(t, queue.Push(fromAlice, false))
+	assert.Equal(t, ErrSenderQueueFull, queue.Push(fromAlice, false))
+
+	// Neither do dequeued transactions.
+
+	assert.Len(t, queue.Pop(1<<20), 3)
+	assert.NoError(t, queue.Push(fromAlice, false))
+	assert.NoError(t, queue.Push(fromAlice, false))
+}
+
+func BenchmarkBroadcastQueuePop(b *testing.B) {
+	keys, err := skademlia.NewKeys(1, 1)
+	assert.NoError(b, err)
+
+	tx := AttachSenderToTransaction(keys, NewTransaction(keys, sys.TagTransfer, make([]byte, 256)))
+
+	queue := NewBroadcastQueue(DefaultBroadcastConfig(), nil)
+
+	b.ResetTimer()
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		assert.NoError(b, queue.Push(tx, true))
+		queue.Pop(16384)
+	}
+}
@@ -0,0 +1,558 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"io"
+	"sort"
+	"sync"
+
+	"github.com/perlin-network/noise/edwards25519"
+	"github.com/perlin-network/noise/skademlia"
+	"github.com/perlin-network/wavelet/bls"
+	"github.com/perlin-network/wavelet/store"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	// Metadata key under which a node attaches its BLS signature over the round
+	// it prefers in response to a query.
+	KeyVoteSignature = "wavelet-vote-signature"
+
+	// Metadata keys under which a node attaches its BLS public key, a proof of
+	// possession of its BLS private key, and its signature binding its BLS public
+	// key to its account in response to a query.
+	KeyBLSPublicKey    = "wavelet-bls-public-key"
+	KeyBLSKeyProof     = "wavelet-bls-key-proof"
+	KeyBLSKeySignature = "wavelet-bls-key-signature"
+)
+
+// blsKeyPrefix is prepended to every BLS public key an account signs to bind it
+// to the account, such that the signature may never be replayed as a signed
+// transaction or message.
+const blsKeyPrefix = "wavelet bls key:\n"
+
+// maxVoterKeys caps the number of verified BLS keys of voters that are cached.
+const maxVoterKeys = 1024
+
+// CertificateVote is a single vote for a round. Votes carry no signature of
+// their own, as the BLS signatures of all votes in a certificate are aggregated
+// into a single signature over the round.
+type CertificateVote struct {
+	Voter AccountID
+	Stake uint64 // Voting stake of the voter at the time the round was finalized.
+
+	// BLS public key of the voter, a proof of possession of its private key, and
+	// the signature of the voter binding the key to its account. They are the
+	// same across every certificate the voter votes in, such that verifiers only
+	// need to verify them once per voter.
+	BLSPublicKey []byte
+	BLSKeyProof  []byte
+	KeySignature Signature
+
+	signature *bls.Signature // Only available while assembling a certificate.
+}
+
+// Certificate comprises of all votes a node collected which led it to finalize
+// a round, such that external verifiers may check that the round was finalized
+// by a sufficient amount of stake without replaying consensus.
+type Certificate struct {
+	RoundID    RoundID
+	RoundIndex uint64
+
+	// Sum of the voting stake of all accounts at the time the round was finalized.
+	TotalStake uint64
+
+	Votes []CertificateVote
+
+	// Aggregate of the BLS signatures of all votes over the round ID. Empty if
+	// the certificate has no votes.
+	Aggregate []byte
+}
+
+func blsKeyDigest(publicKey []byte) []byte {
+	buf := make([]byte, 0, len(blsKeyPrefix)+len(publicKey))
+	buf = append(buf, blsKeyPrefix...)
+	buf = append(buf, publicKey...)
+
+	return buf
+}
+
+// SignBLSKey signs a BLS public key with the private key of an account, binding
+// the BLS public key to the account.
+func SignBLSKey(keys *skademlia.Keypair, publicKey []byte) Signature {
+	return edwards25519.Sign(keys.PrivateKey(), blsKeyDigest(publicKey))
+}
+
+// VerifyKey verifies that the BLS public key of a vote is bound to its voter,
+// and that the voter possesses the private key to it.
+func (v CertificateVote) VerifyKey() bool {
+	if !edwards25519.Verify(v.Voter, blsKeyDigest(v.BLSPublicKey), v.KeySignature) {
+		return false
+	}
+
+	publicKey, err := bls.UnmarshalPublicKey(v.BLSPublicKey)
+	if err != nil {
+		return false
+	}
+
+	proof, err := bls.UnmarshalSignature(v.BLSKeyProof)
+	if err != nil {
+		return false
+	}
+
+	return publicKey.VerifyProof(proof)
+}
+
+// voterKey is the BLS key a node signs its votes with, alongside the proof of
+// possession and the signature which bind it to the account of the node.
+type voterKey struct {
+	key *bls.PrivateKey
+
+	publicKey []byte
+	proof     []byte
+	signature Signature
+}
+
+// newVoterKey derives the BLS key of a node from its private key.
+func newVoterKey(keys *skademlia.Keypair) *voterKey {
+	privateKey := keys.PrivateKey()
+	key := bls.NewPrivateKey(privateKey[:])
+
+	publicKey := key.PublicKey().Marshal()
+
+	return &voterKey{
+		key:       key,
+		publicKey: publicKey,
+		proof:     key.Prove().Marshal(),
+		signature: SignBLSKey(keys, publicKey),
+	}
+}
+
+// vote signs a vote for a round.
+func (k *voterKey) vote(voter AccountID, roundID RoundID) CertificateVote {
+	return CertificateVote{
+		Voter:        voter,
+		BLSPublicKey: k.publicKey,
+		BLSKeyProof:  k.proof,
+		KeySignature: k.signature,
+		signature:    k.key.Sign(roundID[:]),
+	}
+}
+
+// header returns the metadata a node attaches to its response to a query to
+// sign its vote for a round.
+func (k *voterKey) header(roundID RoundID) metadata.MD {
+	return metadata.Pairs(
+		KeyVoteSignature, hex.EncodeToString(k.key.Sign(roundID[:]).Marshal()),
+		KeyBLSPublicKey, hex.EncodeToString(k.publicKey),
+		KeyBLSKeyProof, hex.EncodeToString(k.proof),
+		KeyBLSKeySignature, hex.EncodeToString(k.signature[:]),
+	)
+}
+
+// voterKeys caches the BLS keys and proofs of voters that have been verified to
+// be bound to them, such that the proofs of a voter are only verified once.
+type voterKeys struct {
+	sync.Mutex
+	keys map[AccountID][]byte
+}
+
+func newVoterKeys() *voterKeys {
+	return &voterKeys{keys: make(map[AccountID][]byte)}
+}
+
+func (c *voterKeys) verify(vote CertificateVote) bool {
+	key := make([]byte, 0, len(vote.BLSPublicKey)+len(vote.BLSKeyProof)+SizeSignature)
+	key = append(key, vote.BLSPublicKey...)
+	key = append(key, vote.BLSKeyProof...)
+	key = append(key, vote.KeySignature[:]...)
+
+	c.Lock()
+	cached, exists := c.keys[vote.Voter]
+	c.Unlock()
+
+	if exists && bytes.Equal(cached, key) {
+		return true
+	}
+
+	if !vote.VerifyKey() {
+		return false
+	}
+
+	c.Lock()
+	if len(c.keys) >= maxVoterKeys {
+		c.keys = make(map[AccountID][]byte)
+	}
+	c.keys[vote.Voter] = key
+	c.Unlock()
+
+	return true
+}
+
+// voteFromHeader reads the vote a voter attached to its response to a query,
+// verifying that the BLS key it signed its vote with is bound to it. The BLS
+// signature itself is only verified once all votes for a round are aggregated.
+func voteFromHeader(md metadata.MD, voter AccountID, keys *voterKeys) (CertificateVote, bool) {
+	vote := CertificateVote{Voter: voter}
+
+	rawSignature, ok := headerBytes(md, KeyVoteSignature)
+	if !ok {
+		return vote, false
+	}
+
+	if vote.BLSPublicKey, ok = headerBytes(md, KeyBLSPublicKey); !ok {
+		return vote, false
+	}
+
+	if vote.BLSKeyProof, ok = headerBytes(md, KeyBLSKeyProof); !ok {
+		return vote, false
+	}
+
+	rawKeySignature, ok := headerBytes(md, KeyBLSKeySignature)
+	if !ok || len(rawKeySignature) != SizeSignature {
+		return vote, false
+	}
+
+	copy(vote.KeySignature[:], rawKeySignature)
+
+	signature, err := bls.UnmarshalSignature(rawSignature)
+	if err != nil {
+		return vote, false
+	}
+
+	vote.signature = signature
+
+	if !keys.verify(vote) {
+		return vote, false
+	}
+
+	return vote, true
+}
+
+func headerBytes(md metadata.MD, key string) ([]byte, bool) {
+	values := md.Get(key)
+	if len(values) == 0 {
+		return nil, false
+	}
+
+	buf, err := hex.DecodeString(values[0])
+	if err != nil {
+		return nil, false
+	}
+
+	return buf, true
+}
+
+// Verify verifies that the BLS keys of all votes are bound to their voters, and
+// that the aggregate signature of the certificate covers all votes. It returns
+// the sum of the voting stake of all votes.
+func (c Certificate) Verify() (uint64, bool) {
+	for _, vote := range c.Votes {
+		if !vote.VerifyKey() {
+			return 0, false
+		}
+	}
+
+	return c.VerifyAggregate()
+}
+
+// VerifyAggregate verifies the aggregate BLS signature of the certificate against
+// the BLS public keys of all votes using a single pairing check. It returns the
+// sum of the voting stake of all votes. The BLS keys of all votes are assumed to
+// have already been verified via VerifyKey, as they only need to be verified once
+// per voter.
+func (c Certificate) VerifyAggregate() (uint64, bool) {
+	if len(c.Aggregate) == 0 || len(c.Votes) == 0 {
+		return 0, false
+	}
+
+	aggregate, err := bls.UnmarshalSignature(c.Aggregate)
+	if err != nil {
+		return 0, false
+	}
+
+	publicKeys := make([]*bls.PublicKey, 0, len(c.Votes))
+
+	var stake uint64
+
+	for _, vote := range c.Votes {
+		publicKey, err := bls.UnmarshalPublicKey(vote.BLSPublicKey)
+		if err != nil {
+			return 0, false
+		}
+
+		publicKeys = append(publicKeys, publicKey)
+		stake += vote.Stake
+	}
+
+	if !bls.AggregatePublicKeys(publicKeys...).Verify(c.RoundID[:], aggregate) {
+		return 0, false
+	}
+
+	return stake, true
+}
+
+func (c Certificate) Marshal() []byte {
+	w := bytes.NewBuffer(make([]byte, 0, SizeRoundID+8+8+4+len(c.Votes)*(SizeAccountID+8+bls.SizePublicKey+bls.SizeSignature+SizeSignature)+1+bls.SizeSignature))
+
+	var buf [8]byte
+
+	w.Write(c.RoundID[:])
+
+	binary.BigEndian.PutUint64(buf[:], c.RoundIndex)
+	w.Write(buf[:])
+
+	binary.BigEndian.PutUint64(buf[:], c.TotalStake)
+	w.Write(buf[:])
+
+	binary.BigEndian.PutUint32(buf[:4], uint32(len(c.Votes)))
+	w.Write(buf[:4])
+
+	for _, vote := range c.Votes {
+		w.Write(vote.Voter[:])
+
+		binary.BigEndian.PutUint64(buf[:], vote.Stake)
+		w.Write(buf[:])
+
+		w.Write(vote.BLSPublicKey)
+		w.Write(vote.BLSKeyProof)
+		w.Write(vote.KeySignature[:])
+	}
+
+	if len(c.Aggregate) > 0 {
+		w.WriteByte(1)
+		w.Write(c.Aggregate)
+	} else {
+		w.WriteByte(0)
+	}
+
+	return w.Bytes()
+}
+
+func UnmarshalCertificate(r io.Reader) (c Certificate, err error) {
+	if _, err = io.ReadFull(r, c.RoundID[:]); err != nil {
+		err = errors.Wrap(err, "failed to decode certificate round ID")
+		return
+	}
+
+	var buf [8]byte
+
+	if _, err = io.ReadFull(r, buf[:]); err != nil {
+		err = errors.Wrap(err, "failed to decode certificate round index")
+		return
+	}
+
+	c.RoundIndex = binary.BigEndian.Uint64(buf[:])
+
+	if _, err = io.ReadFull(r, buf[:]); err != nil {
+		err = errors.Wrap(err, "failed to decode certificate total stake")
+		return
+	}
+
+	c.TotalStake = binary.BigEndian.Uint64(buf[:])
+
+	if _, err = io.ReadFull(r, buf[:4]); err != nil {
+		err = errors.Wrap(err, "failed to decode number of votes in certificate")
+		return
+	}
+
+	c.Votes = make([]CertificateVote, binary.BigEndian.Uint32(buf[:4]))
+
+	for i := range c.Votes {
+		if _, err = io.ReadFull(r, c.Votes[i].Voter[:]); err != nil {
+			err = errors.Wrapf(err, "failed to decode voter of vote %d", i)
+			return
+		}
+
+		if _, err = io.ReadFull(r, buf[:]); err != nil {
+			err = errors.Wrapf(err, "failed to decode stake of vote %d", i)
+			return
+		}
+
+		c.Votes[i].Stake = binary.BigEndian.Uint64(buf[:])
+
+		c.Votes[i].BLSPublicKey = make([]byte, bls.SizePublicKey)
+		c.Votes[i].BLSKeyProof = make([]byte, bls.SizeSignature)
+
+		if _, err = io.ReadFull(r, c.Votes[i].BLSPublicKey); err != nil {
+			err = errors.Wrapf(err, "failed to decode BLS public key of vote %d", i)
+			return
+		}
+
+		if _, err = io.ReadFull(r, c.Votes[i].BLSKeyProof); err != nil {
+			err = errors.Wrapf(err, "failed to decode BLS key proof of vote %d", i)
+			return
+		}
+
+		if _, err = io.ReadFull(r, c.Votes[i].KeySignature[:]); err != nil {
+			err = errors.Wrapf(err, "failed to decode BLS key signature of vote %d", i)
+			return
+		}
+	}
+
+	if _, err = io.ReadFull(r, buf[:1]); err != nil {
+		err = errors.Wrap(err, "failed to decode flag to see if certificate has an aggregate signature")
+		return
+	}
+
+	if buf[0] == 1 {
+		c.Aggregate = make([]byte, bls.SizeSignature)
+
+		if _, err = io.ReadFull(r, c.Aggregate); err != nil {
+			err = errors.Wrap(err, "failed to decode aggregate signature of certificate")
+			return
+		}
+	}
+
+	return
+}
+
+// Certificates persists the finalization certificates of rounds.
+type Certificates struct {
+	kv store.KV
+}
+
+func NewCertificates(kv store.KV) *Certificates {
+	return &Certificates{kv: kv}
+}
+
+func (c *Certificates) Save(cert Certificate) error {
+	if err := c.kv.Put(certificateKey(cert.RoundIndex), cert.Marshal()); err != nil {
+		return errors.Wrapf(err, "certificates: failed to save certificate for round %d", cert.RoundIndex)
+	}
+
+	return nil
+}
+
+func (c *Certificates) Get(index uint64) (Certificate, error) {
+	buf, err := c.kv.Get(certificateKey(index))
+	if err != nil {
+		return Certificate{}, errors.Wrapf(err, "certificates: no certificate found for round %d", index)
+	}
+
+	return UnmarshalCertificate(bytes.NewReader(buf))
+}
+
+// PruneBelow deletes the certificates of all rounds whose index is below index,
+// returning the number of certificates deleted.
+func (c *Certificates) PruneBelow(index uint64) (int, error) {
+	var keys [][]byte
+
+	err := c.kv.IteratePrefix(keyCertificates[:], func(key, value []byte) bool {
+		if len(key) != len(keyCertificates)+8 || binary.BigEndian.Uint64(key[len(keyCertificates):]) >= index {
+			return false
+		}
+
+		keys = append(keys, append([]byte{}, key...))
+		return true
+	})
+
+	if err != nil {
+		return 0, errors.Wrap(err, "certificates: failed to iterate through certificates")
+	}
+
+	for _, key := range keys {
+		if err := c.kv.Delete(key); err != nil {
+			return 0, errors.Wrap(err, "certificates: failed to delete certificate")
+		}
+	}
+
+	return len(keys), nil
+}
+
+func certificateKey(index uint64) []byte {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], index)
+
+	return append(keyCertificates[:], buf[:]...)
+}
+
+// newCertificate assembles the certificate of a round out of the votes of all
+// voters which voted for it, weighing their votes using weighting and the given
+// snapshot of all accounts, and aggregating all of their BLS signatures. Should
+// the aggregate fail to verify, votes whose signatures are invalid are dropped.
+func newCertificate(round *Round, accounts *Accounts, weighting Weighting, minimumStake uint64, votes map[AccountID]CertificateVote) Certificate {
+	snapshot := accounts.Snapshot()
+
+	cert := Certificate{RoundID: round.ID, RoundIndex: round.Index, Votes: make([]CertificateVote, 0, len(votes))}
+	cert.TotalStake = weighting.TotalWeight(snapshot, minimumStake)
+
+	for voter, vote := range votes {
+		weight, eligible := weighting.Weigh(snapshot, voter, minimumStake)
+		if !eligible || vote.signature == nil {
+			continue
+		}
+
+		vote.Stake = weight
+
+		cert.Votes = append(cert.Votes, vote)
+	}
+
+	cert.aggregate()
+
+	if _, ok := cert.VerifyAggregate(); !ok && len(cert.Votes) > 0 {
+		valid := cert.Votes[:0]
+
+		for _, vote := range cert.Votes {
+			publicKey, err := bls.UnmarshalPublicKey(vote.BLSPublicKey)
+			if err != nil || !publicKey.Verify(round.ID[:], vote.signature) {
+				continue
+			}
+
+			valid = append(valid, vote)
+		}
+
+		cert.Votes = valid
+		cert.aggregate()
+	}
+
+	for i := range cert.Votes {
+		cert.Votes[i].signature = nil
+	}
+
+	sort.Slice(cert.Votes, func(i, j int) bool {
+		return bytes.Compare(cert.Votes[i].Voter[:], cert.Votes[j].Voter[:]) < 0
+	})
+
+	return cert
+}
+
+// aggregate aggregates the BLS signatures of all votes of a certificate which is
+// being assembled.
+func (c *Certificate) aggregate() {
+	c.Aggregate = nil
+
+	if len(c.Votes) == 0 {
+		return
+	}
+
+	signatures := make([]*bls.Signature, 0, len(c.Votes))
+
+	for _, vote := range c.Votes {
+		signatures = append(signatures, vote.signature)
+	}
+
+	c.Aggregate = bls.AggregateSignatures(signatures...).Marshal()
+}
@@ -0,0 +1,143 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/perlin-network/noise/skademlia"
+	"github.com/perlin-network/wavelet/store"
+	"github.com/perlin-network/wavelet/sys"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestCertificate(t *testing.T) {
+	keys, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	other, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	roundID := RoundID{1, 2, 3}
+
+	key, otherKey := newVoterKey(keys), newVoterKey(other)
+	voterKeys := newVoterKeys()
+
+	_, ok := voteFromHeader(metadata.Pairs(KeyVoteSignature, "zz"), keys.PublicKey(), voterKeys)
+	assert.False(t, ok)
+
+	// A voter may not claim the BLS key of another account as its own.
+
+	_, ok = voteFromHeader(key.header(roundID), other.PublicKey(), voterKeys)
+	assert.False(t, ok)
+
+	signed, ok := voteFromHeader(key.header(roundID), keys.PublicKey(), voterKeys)
+	assert.True(t, ok)
+	assert.True(t, signed.VerifyKey())
+
+	// Proofs that were not verified may not pass for a previously verified key.
+
+	forged := key.header(roundID)
+	forged.Set(KeyBLSKeySignature, hex.EncodeToString(make([]byte, SizeSignature)))
+
+	_, ok = voteFromHeader(forged, keys.PublicKey(), voterKeys)
+	assert.False(t, ok)
+
+	otherSigned, ok := voteFromHeader(otherKey.header(roundID), other.PublicKey(), voterKeys)
+	assert.True(t, ok)
+
+	// A vote signed over another round is dropped when assembling the certificate.
+
+	third, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	stale, ok := voteFromHeader(newVoterKey(third).header(RoundID{4}), third.PublicKey(), voterKeys)
+	assert.True(t, ok)
+
+	accounts := NewAccounts(store.NewInmem())
+	WriteAccountStake(accounts.tree, keys.PublicKey(), sys.MinimumStake*3)
+	assert.NoError(t, accounts.Commit(nil))
+
+	round := &Round{ID: roundID, Index: 7}
+
+	cert := newCertificate(round, accounts, WeightByStake, sys.MinimumStake, map[AccountID]CertificateVote{
+		keys.PublicKey():  signed,
+		other.PublicKey(): otherSigned,
+		third.PublicKey(): stale,
+	})
+
+	stake, ok := cert.Verify()
+	assert.True(t, ok)
+	assert.EqualValues(t, sys.MinimumStake*4, stake)
+
+	assert.EqualValues(t, 7, cert.RoundIndex)
+	assert.EqualValues(t, sys.MinimumStake*3, cert.TotalStake)
+	assert.Len(t, cert.Votes, 2)
+
+	certs := NewCertificates(store.NewInmem())
+	assert.NoError(t, certs.Save(cert))
+
+	loaded, err := certs.Get(7)
+	assert.NoError(t, err)
+	assert.Equal(t, cert, loaded)
+
+	stake, ok = loaded.Verify()
+	assert.True(t, ok)
+	assert.EqualValues(t, sys.MinimumStake*4, stake)
+
+	// Dropping a vote or tampering with the round invalidates the aggregate.
+
+	tampered := loaded
+	tampered.RoundID = RoundID{4}
+
+	_, ok = tampered.VerifyAggregate()
+	assert.False(t, ok)
+
+	loaded.Votes = loaded.Votes[:1]
+
+	_, ok = loaded.VerifyAggregate()
+	assert.False(t, ok)
+
+	_, err = certs.Get(8)
+	assert.Error(t, err)
+
+	_, err = UnmarshalCertificate(bytes.NewReader(cert.Marshal()[:10]))
+	assert.Error(t, err)
+}
+
+func TestCertificatesPruneBelow(t *testing.T) {
+	certs := NewCertificates(store.NewInmem())
+
+	for i := uint64(1); i <= 5; i++ {
+		assert.NoError(t, certs.Save(Certificate{RoundIndex: i}))
+	}
+
+	count, err := certs.PruneBelow(3)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	for i := uint64(1); i <= 5; i++ {
+		_, err := certs.Get(i)
+		assert.Equal(t, i < 3, err != nil)
+	}
+}
@@ -0,0 +1,290 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+// Package client is a Go SDK for the HTTP and WebSocket API of a wavelet node.
+// It signs transactions on behalf of an account, submits them, waits for them
+// to be finalized, and decodes events streamed by the node into typed values,
+// such that integrators need not encode payloads and signatures by hand.
+package client
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/perlin-network/wavelet"
+	"github.com/perlin-network/wavelet/wctl"
+	"github.com/pkg/errors"
+)
+
+// Client creates transactions on behalf of the account controlled by its
+// signer, and submits them to a single node.
+type Client struct {
+	api    *wctl.Client
+	signer Signer
+
+	pollInterval time.Duration
+
+	mu     sync.Mutex
+	nonce  uint64
+	synced bool
+}
+
+type Option func(c *Client)
+
+// WithHTTPS connects to the node over HTTPS and secure WebSockets.
+func WithHTTPS() Option {
+	return func(c *Client) {
+		c.api.UseHTTPS = true
+	}
+}
+
+// WithPollInterval sets how often the status of a transaction is polled for
+// while waiting for it to be finalized. It is 1 second by default.
+func WithPollInterval(interval time.Duration) Option {
+	return func(c *Client) {
+		c.pollInterval = interval
+	}
+}
+
+// New connects to the API of the node at host:port. Transactions are created
+// on behalf of the account controlled by signer.
+func New(host string, port uint16, signer Signer, opts ...Option) (*Client, error) {
+	api, err := wctl.NewClient(wctl.Config{APIHost: host, APIPort: port})
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Client{api: api, signer: signer, pollInterval: 1 * time.Second}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c, nil
+}
+
+// API returns the underlying API client, for endpoints the SDK does not wrap.
+func (c *Client) API() *wctl.Client {
+	return c.api
+}
+
+// ID returns the ID of the account transactions are created on behalf of.
+func (c *Client) ID() wavelet.AccountID {
+	return c.signer.ID()
+}
+
+// Account queries the state of an account.
+func (c *Client) Account(id wavelet.AccountID) (wctl.Account, error) {
+	return c.api.GetAccount(hex.EncodeToString(id[:]))
+}
+
+// Sign signs a transaction under nonce without submitting it, such that it may
+// be submitted later, possibly as part of a batch. Transactions signed under
+// the same non-zero nonce conflict with one another, and a nonce of zero leaves
+// the transaction unsequenced.
+func (c *Client) Sign(p Payload, nonce uint64) wctl.SendTransactionRequest {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], nonce)
+
+	msg := append(buf[:], append([]byte{byte(p.Tag)}, p.Body...)...)
+
+	id := c.signer.ID()
+	signature := c.signer.Sign(msg)
+
+	req := wctl.SendTransactionRequest{
+		Sender:    hex.EncodeToString(id[:]),
+		Tag:       byte(p.Tag),
+		Payload:   hex.EncodeToString(p.Body),
+		Signature: hex.EncodeToString(signature[:]),
+		Nonce:     nonce,
+	}
+
+	if scheme := c.signer.Scheme(); scheme != wavelet.SignatureEd25519 {
+		req.Scheme = scheme.String()
+	}
+
+	return req
+}
+
+// Send signs and submits a transaction, returning its ID once the node has
+// accepted it.
+func (c *Client) Send(p Payload) (string, error) {
+	if err := c.syncNonce(); err != nil {
+		return "", err
+	}
+
+	c.mu.Lock()
+	nonce := c.nonce
+	c.mu.Unlock()
+
+	req := c.Sign(p, nonce)
+
+	var res wctl.SendTransactionResponse
+
+	if err := c.api.RequestJSON(wctl.RouteTxSend, wctl.ReqPost, &req, &res); err != nil {
+		c.resetNonce()
+		return "", err
+	}
+
+	c.mu.Lock()
+	c.nonce++
+	c.mu.Unlock()
+
+	return res.ID, nil
+}
+
+// SendAndWait signs and submits a transaction, and waits for it to be
+// finalized.
+func (c *Client) SendAndWait(ctx context.Context, p Payload) (Receipt, error) {
+	id, err := c.Send(p)
+	if err != nil {
+		return Receipt{}, err
+	}
+
+	return c.Wait(ctx, id)
+}
+
+// Nonce returns the nonce of the account, counting transactions which were
+// submitted by the client but have yet to be finalized. The nonce is fetched
+// from the node the first time it is needed, and again after any submission
+// fails or any transaction is rejected.
+func (c *Client) Nonce() (uint64, error) {
+	if err := c.syncNonce(); err != nil {
+		return 0, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.nonce, nil
+}
+
+func (c *Client) syncNonce() error {
+	c.mu.Lock()
+	synced := c.synced
+	c.mu.Unlock()
+
+	if synced {
+		return nil
+	}
+
+	account, err := c.Account(c.signer.ID())
+	if err != nil {
+		return errors.Wrap(err, "failed to fetch nonce")
+	}
+
+	c.mu.Lock()
+	c.nonce, c.synced = account.Nonce, true
+	c.mu.Unlock()
+
+	return nil
+}
+
+func (c *Client) resetNonce() {
+	c.mu.Lock()
+	c.synced = false
+	c.mu.Unlock()
+}
+
+const (
+	// StatusApplied denotes that a transaction was finalized and applied.
+	StatusApplied = "applied"
+
+	// StatusRejected denotes that a transaction was finalized, but failed to
+	// be applied.
+	StatusRejected = "rejected"
+
+	// StatusFinalized denotes that a transaction was finalized, though the
+	// node was not observed to either apply or reject it. This happens should
+	// the node have finalized the transaction before the client subscribed to
+	// its outcome, or should events have been dropped by the node.
+	StatusFinalized = "finalized"
+)
+
+// Receipt describes the outcome of a finalized transaction.
+type Receipt struct {
+	ID     string
+	Status string
+
+	// Error is the reason the transaction was rejected.
+	Error string
+}
+
+// Wait blocks until the transaction id is finalized, or until ctx is
+// canceled. The outcome of the transaction is observed by subscribing to
+// transaction events. The status of the transaction is additionally polled
+// for, should the node have dropped the events, or should the transaction
+// have been finalized before the subscription was established.
+func (c *Client) Wait(ctx context.Context, id string) (Receipt, error) {
+	var txID wavelet.TransactionID
+
+	if err := decodeHex(txID[:], []byte(id)); err != nil {
+		return Receipt{}, errors.Wrap(err, "invalid transaction ID")
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	events, err := c.SubscribeTransactions(ctx, TransactionFilter{ID: &txID})
+	if err != nil {
+		events = nil // Fall back to polling.
+	}
+
+	ticker := time.NewTicker(c.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return Receipt{}, errors.Wrapf(ctx.Err(), "gave up waiting for transaction %s", id)
+		case ev, ok := <-events:
+			if !ok {
+				events = nil
+				continue
+			}
+
+			if ev.ID != txID {
+				continue
+			}
+
+			switch ev.Event {
+			case StatusApplied:
+				return Receipt{ID: id, Status: StatusApplied}, nil
+			case StatusRejected:
+				if ev.Sender == c.signer.ID() {
+					c.resetNonce()
+				}
+
+				return Receipt{ID: id, Status: StatusRejected, Error: ev.Error}, nil
+			}
+		case <-ticker.C:
+			tx, err := c.api.GetTransaction(id)
+			if err != nil {
+				continue // The node may not have received the transaction yet.
+			}
+
+			if tx.Status == "applied" {
+				return Receipt{ID: id, Status: StatusFinalized}, nil
+			}
+		}
+	}
+}
@@ -0,0 +1,274 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package client
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/perlin-network/wavelet"
+	"github.com/perlin-network/wavelet/secp256k1"
+	"github.com/perlin-network/wavelet/sys"
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fastjson"
+)
+
+func TestPayloads(t *testing.T) {
+	var recipient wavelet.AccountID
+	recipient[0] = 1
+
+	transfer, err := wavelet.ParseTransferTransaction(Transfer(recipient, 42).Body)
+	assert.NoError(t, err)
+	assert.Equal(t, recipient, transfer.Recipient)
+	assert.EqualValues(t, 42, transfer.Amount)
+
+	p := Invoke(recipient, 1, 100000, "on_money_received", []byte{1, 2, 3})
+	assert.Equal(t, sys.TagTransfer, p.Tag)
+
+	invoke, err := wavelet.ParseTransferTransaction(p.Body)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 100000, invoke.GasLimit)
+	assert.Equal(t, "on_money_received", string(invoke.FuncName))
+	assert.Equal(t, []byte{1, 2, 3}, invoke.FuncParams)
+
+	stake, err := wavelet.ParseStakeTransaction(WithdrawReward(700).Body)
+	assert.NoError(t, err)
+	assert.Equal(t, sys.WithdrawReward, stake.Opcode)
+	assert.EqualValues(t, 700, stake.Amount)
+
+	batch, err := Batch(Transfer(recipient, 1), PlaceStake(2))
+	assert.NoError(t, err)
+	assert.Equal(t, sys.TagBatch, batch.Tag)
+
+	parsed, err := wavelet.ParseBatchTransaction(batch.Body)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, parsed.Size)
+	assert.Equal(t, []uint8{uint8(sys.TagTransfer), uint8(sys.TagStake)}, parsed.Tags)
+	assert.Equal(t, PlaceStake(2).Body, parsed.Payloads[1])
+
+	_, err = Batch()
+	assert.Error(t, err)
+
+	_, err = Batch(batch)
+	assert.Error(t, err)
+}
+
+func TestSign(t *testing.T) {
+	ed, _, err := GenerateEd25519()
+	assert.NoError(t, err)
+
+	key, err := secp256k1.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+
+	for _, signer := range []Signer{ed, Secp256k1(key)} {
+		c, err := New("127.0.0.1", 9000, signer)
+		assert.NoError(t, err)
+
+		p := PlaceStake(10)
+		req := c.Sign(p, 7)
+
+		id := signer.ID()
+		assert.Equal(t, hex.EncodeToString(id[:]), req.Sender)
+		assert.EqualValues(t, 7, req.Nonce)
+
+		var signature wavelet.Signature
+
+		raw, err := hex.DecodeString(req.Signature)
+		assert.NoError(t, err)
+		copy(signature[:], raw)
+
+		msg := append([]byte{0, 0, 0, 0, 0, 0, 0, 7}, append([]byte{byte(p.Tag)}, p.Body...)...)
+		assert.True(t, wavelet.VerifySignature(signer.Scheme(), id, msg, signature))
+
+		scheme, err := wavelet.ParseSignatureScheme(req.Scheme)
+		assert.NoError(t, err)
+		assert.Equal(t, signer.Scheme(), scheme)
+	}
+}
+
+// node mocks the API of a node which finalizes every transaction sent to it
+// after it is polled for twice.
+type node struct {
+	sync.Mutex
+
+	nonce uint64
+	sent  int
+	polls map[string]int
+	fail  bool
+}
+
+func (n *node) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	n.Lock()
+	defer n.Unlock()
+
+	switch {
+	case r.URL.Path == "/tx/send":
+		if n.fail {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":{"code":"invalid_request","message":"nope","retryable":false}}`))
+			return
+		}
+
+		body, _ := ioutil.ReadAll(r.Body)
+		if fastjson.GetString(body, "sender") == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		n.sent++
+		_, _ = fmt.Fprintf(w, `{"tx_id":"%064x","parent_ids":[],"is_critical":false}`, n.sent)
+	case len(r.URL.Path) > len("/accounts/") && r.URL.Path[:len("/accounts/")] == "/accounts/":
+		_, _ = fmt.Fprintf(w, `{"public_key":"%s","nonce":%d}`, r.URL.Path[len("/accounts/"):], n.nonce)
+	case len(r.URL.Path) > len("/tx/") && r.URL.Path[:len("/tx/")] == "/tx/":
+		id := r.URL.Path[len("/tx/"):]
+
+		if n.polls == nil {
+			n.polls = make(map[string]int)
+		}
+
+		n.polls[id]++
+
+		status := "received"
+		if n.polls[id] > 2 {
+			status = "applied"
+		}
+
+		_, _ = fmt.Fprintf(w, `{"id":"%s","status":"%s"}`, id, status)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func newTestClient(t *testing.T, n *node) (*Client, *httptest.Server) {
+	server := httptest.NewServer(n)
+
+	host, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	assert.NoError(t, err)
+
+	p, err := strconv.ParseUint(port, 10, 16)
+	assert.NoError(t, err)
+
+	signer, _, err := GenerateEd25519()
+	assert.NoError(t, err)
+
+	c, err := New(host, uint16(p), signer, WithPollInterval(10*time.Millisecond))
+	assert.NoError(t, err)
+
+	return c, server
+}
+
+func TestSendTracksNonce(t *testing.T) {
+	n := &node{nonce: 5}
+	c, server := newTestClient(t, n)
+	defer server.Close()
+
+	nonce, err := c.Nonce()
+	assert.NoError(t, err)
+	assert.EqualValues(t, 5, nonce)
+
+	id, err := c.Send(Transfer(c.ID(), 1))
+	assert.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("%064x", 1), id)
+
+	nonce, err = c.Nonce()
+	assert.NoError(t, err)
+	assert.EqualValues(t, 6, nonce)
+
+	// A failed submission resyncs the nonce with the node.
+
+	n.Lock()
+	n.fail, n.nonce = true, 9
+	n.Unlock()
+
+	_, err = c.Send(Transfer(c.ID(), 1))
+	assert.Error(t, err)
+
+	nonce, err = c.Nonce()
+	assert.NoError(t, err)
+	assert.EqualValues(t, 9, nonce)
+}
+
+func TestSendAndWait(t *testing.T) {
+	c, server := newTestClient(t, &node{})
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	receipt, err := c.SendAndWait(ctx, PlaceStake(1))
+	assert.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("%064x", 1), receipt.ID)
+	assert.Equal(t, StatusFinalized, receipt.Status)
+
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+
+	_, err = c.Wait(ctx, fmt.Sprintf("%064x", 2))
+	assert.Error(t, err)
+
+	_, err = c.Wait(context.Background(), "not hex")
+	assert.Error(t, err)
+}
+
+func TestDecodeEvents(t *testing.T) {
+	var parser fastjson.Parser
+
+	txID := fmt.Sprintf("%064x", 1)
+	accountID := fmt.Sprintf("%064x", 2)
+
+	msg := `[` +
+		`{"mod":"tx","event":"rejected","tx_id":"` + txID + `","sender_id":"` + accountID + `","creator_id":"` + accountID + `","depth":3,"tag":1,"error":"insufficient balance"},` +
+		`{"error":{"code":"messages_dropped","message":"dropped","details":{"dropped":"3"},"retryable":false}}` +
+		`]`
+
+	values := decodeEvents(&parser, []byte(msg))
+	assert.Len(t, values, 1)
+
+	var tx TransactionEvent
+	assert.NoError(t, tx.parse(values[0]))
+	assert.Equal(t, "rejected", tx.Event)
+	assert.Equal(t, txID, hex.EncodeToString(tx.ID[:]))
+	assert.Equal(t, accountID, hex.EncodeToString(tx.Sender[:]))
+	assert.EqualValues(t, 3, tx.Depth)
+	assert.Equal(t, sys.TagTransfer, tx.Tag)
+	assert.Equal(t, "insufficient balance", tx.Error)
+
+	values = decodeEvents(&parser, []byte(`{"mod":"accounts","event":"stake_updated","account_id":"`+accountID+`","stake":100}`))
+	assert.Len(t, values, 1)
+
+	var account AccountEvent
+	assert.NoError(t, account.parse(values[0]))
+	assert.Equal(t, "stake_updated", account.Event)
+	assert.EqualValues(t, 100, account.Value)
+
+	values = decodeEvents(&parser, []byte(`{"mod":"accounts","event":"unknown","account_id":"`+accountID+`"}`))
+	assert.Error(t, account.parse(values[0]))
+	assert.Empty(t, decodeEvents(&parser, []byte(`not json`)))
+}
@@ -0,0 +1,278 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package client
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+
+	"github.com/fasthttp/websocket"
+	"github.com/perlin-network/wavelet"
+	"github.com/perlin-network/wavelet/sys"
+	"github.com/perlin-network/wavelet/wctl"
+	"github.com/pkg/errors"
+	"github.com/valyala/fastjson"
+)
+
+// TransactionEvent is emitted whenever a node applies or rejects a
+// transaction while finalizing a round.
+type TransactionEvent struct {
+	Event string // Either "applied" or "rejected".
+
+	ID      wavelet.TransactionID
+	Sender  wavelet.AccountID
+	Creator wavelet.AccountID
+
+	Depth uint64
+	Tag   sys.Tag
+
+	// Error is the reason the transaction was rejected.
+	Error string
+
+	// RequestID is the ID of the API request the transaction was submitted
+	// through, should it have been submitted to the node emitting the event.
+	RequestID string
+}
+
+// AccountEvent is emitted whenever the state of an account changes. Event is
+// one of "balance_updated", "stake_updated", "reward_updated", or
+// "num_pages_updated", and Value is the new balance, stake, reward, or number
+// of memory pages of the account respectively.
+type AccountEvent struct {
+	Event string
+
+	AccountID wavelet.AccountID
+	Value     uint64
+}
+
+// TransactionFilter narrows down which transaction events are streamed. Unset
+// fields match any transaction.
+type TransactionFilter struct {
+	ID      *wavelet.TransactionID
+	Sender  *wavelet.AccountID
+	Creator *wavelet.AccountID
+	Tag     *sys.Tag
+}
+
+func (f TransactionFilter) query() url.Values {
+	v := url.Values{}
+
+	if f.ID != nil {
+		v.Set("id", hex.EncodeToString(f.ID[:]))
+	}
+
+	if f.Sender != nil {
+		v.Set("sender", hex.EncodeToString(f.Sender[:]))
+	}
+
+	if f.Creator != nil {
+		v.Set("creator", hex.EncodeToString(f.Creator[:]))
+	}
+
+	if f.Tag != nil {
+		v.Set("tag", fmt.Sprintf("%d", *f.Tag))
+	}
+
+	return v
+}
+
+// SubscribeTransactions streams events about transactions matching filter
+// until ctx is canceled, or the connection to the node is lost. The returned
+// channel is closed afterwards.
+func (c *Client) SubscribeTransactions(ctx context.Context, filter TransactionFilter) (<-chan TransactionEvent, error) {
+	conn, err := c.api.EstablishWS(wctl.RouteWSTransactions, filter.query())
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to subscribe to transactions")
+	}
+
+	events := make(chan TransactionEvent)
+
+	go stream(ctx, conn, func(v *fastjson.Value) bool {
+		var ev TransactionEvent
+
+		if err := ev.parse(v); err != nil {
+			return true
+		}
+
+		select {
+		case events <- ev:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}, func() { close(events) })
+
+	return events, nil
+}
+
+// SubscribeAccounts streams changes to the state of the account id, or of all
+// accounts should id be nil, until ctx is canceled, or the connection to the
+// node is lost. The returned channel is closed afterwards.
+func (c *Client) SubscribeAccounts(ctx context.Context, id *wavelet.AccountID) (<-chan AccountEvent, error) {
+	v := url.Values{}
+
+	if id != nil {
+		v.Set("id", hex.EncodeToString(id[:]))
+	}
+
+	conn, err := c.api.EstablishWS(wctl.RouteWSAccounts, v)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to subscribe to accounts")
+	}
+
+	events := make(chan AccountEvent)
+
+	go stream(ctx, conn, func(v *fastjson.Value) bool {
+		var ev AccountEvent
+
+		if err := ev.parse(v); err != nil {
+			return true
+		}
+
+		select {
+		case events <- ev:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}, func() { close(events) })
+
+	return events, nil
+}
+
+// stream reads messages from conn until ctx is canceled, the connection is
+// lost, or handle returns false. Debounced sinks send their events batched
+// into arrays, which are flattened out before being handled. Error envelopes,
+// such as those reporting that the node dropped events, are skipped.
+func stream(ctx context.Context, conn *websocket.Conn, handle func(v *fastjson.Value) bool, done func()) {
+	defer done()
+
+	closed := make(chan struct{})
+	defer close(closed)
+
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-closed:
+		}
+
+		_ = conn.Close()
+	}()
+
+	var parser fastjson.Parser
+
+	for {
+		_, msg, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		for _, v := range decodeEvents(&parser, msg) {
+			if !handle(v) {
+				return
+			}
+		}
+	}
+}
+
+func decodeEvents(parser *fastjson.Parser, msg []byte) []*fastjson.Value {
+	v, err := parser.ParseBytes(msg)
+	if err != nil {
+		return nil
+	}
+
+	var values []*fastjson.Value
+
+	switch v.Type() {
+	case fastjson.TypeArray:
+		values = v.GetArray()
+	case fastjson.TypeObject:
+		values = []*fastjson.Value{v}
+	}
+
+	events := values[:0]
+
+	for _, v := range values {
+		if v.Get("error") != nil && v.Get("error").Type() == fastjson.TypeObject {
+			continue
+		}
+
+		events = append(events, v)
+	}
+
+	return events
+}
+
+func (ev *TransactionEvent) parse(v *fastjson.Value) error {
+	ev.Event = string(v.GetStringBytes("event"))
+
+	if err := decodeHex(ev.ID[:], v.GetStringBytes("tx_id")); err != nil {
+		return errors.Wrap(err, "invalid transaction ID")
+	}
+
+	if err := decodeHex(ev.Sender[:], v.GetStringBytes("sender_id")); err != nil {
+		return errors.Wrap(err, "invalid sender ID")
+	}
+
+	if err := decodeHex(ev.Creator[:], v.GetStringBytes("creator_id")); err != nil {
+		return errors.Wrap(err, "invalid creator ID")
+	}
+
+	ev.Depth = v.GetUint64("depth")
+	ev.Tag = sys.Tag(v.GetUint("tag"))
+	ev.Error = string(v.GetStringBytes("error"))
+	ev.RequestID = string(v.GetStringBytes("request_id"))
+
+	return nil
+}
+
+func (ev *AccountEvent) parse(v *fastjson.Value) error {
+	ev.Event = string(v.GetStringBytes("event"))
+
+	if err := decodeHex(ev.AccountID[:], v.GetStringBytes("account_id")); err != nil {
+		return errors.Wrap(err, "invalid account ID")
+	}
+
+	switch ev.Event {
+	case "balance_updated":
+		ev.Value = v.GetUint64("balance")
+	case "stake_updated":
+		ev.Value = v.GetUint64("stake")
+	case "reward_updated":
+		ev.Value = v.GetUint64("reward")
+	case "num_pages_updated":
+		ev.Value = v.GetUint64("num_pages")
+	default:
+		return errors.Errorf("unknown account event %q", ev.Event)
+	}
+
+	return nil
+}
+
+func decodeHex(dst []byte, src []byte) error {
+	if hex.DecodedLen(len(src)) != len(dst) {
+		return errors.Errorf("expected %d bytes, but got %d", len(dst), hex.DecodedLen(len(src)))
+	}
+
+	_, err := hex.Decode(dst, src)
+	return err
+}
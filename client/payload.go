@@ -0,0 +1,131 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package client
+
+import (
+	"encoding/binary"
+	"math"
+
+	"github.com/perlin-network/wavelet"
+	"github.com/perlin-network/wavelet/sys"
+	"github.com/pkg/errors"
+)
+
+// Payload is the tag and payload of a transaction to be created.
+type Payload struct {
+	Tag  sys.Tag
+	Body []byte
+}
+
+// Transfer transfers amount PERLs to recipient.
+func Transfer(recipient wavelet.AccountID, amount uint64) Payload {
+	buf := make([]byte, wavelet.SizeAccountID+8)
+
+	copy(buf, recipient[:])
+	binary.LittleEndian.PutUint64(buf[wavelet.SizeAccountID:], amount)
+
+	return Payload{Tag: sys.TagTransfer, Body: buf}
+}
+
+// Invoke transfers amount PERLs to a smart contract, and invokes its function fn
+// with params under the given gas limit.
+func Invoke(contract wavelet.AccountID, amount, gasLimit uint64, fn string, params []byte) Payload {
+	buf := make([]byte, wavelet.SizeAccountID+8+8+4+len(fn)+4+len(params))
+
+	copy(buf, contract[:])
+
+	b := buf[wavelet.SizeAccountID:]
+
+	binary.LittleEndian.PutUint64(b[:8], amount)
+	binary.LittleEndian.PutUint64(b[8:16], gasLimit)
+
+	binary.LittleEndian.PutUint32(b[16:20], uint32(len(fn)))
+	copy(b[20:], fn)
+
+	b = b[20+len(fn):]
+
+	binary.LittleEndian.PutUint32(b[:4], uint32(len(params)))
+	copy(b[4:], params)
+
+	return Payload{Tag: sys.TagTransfer, Body: buf}
+}
+
+// SpawnContract spawns a smart contract out of its WebAssembly code, and
+// initializes it with params under the given gas limit.
+func SpawnContract(gasLimit uint64, code, params []byte) Payload {
+	buf := make([]byte, 8+4+len(params)+len(code))
+
+	binary.LittleEndian.PutUint64(buf[:8], gasLimit)
+	binary.LittleEndian.PutUint32(buf[8:12], uint32(len(params)))
+
+	copy(buf[12:], params)
+	copy(buf[12+len(params):], code)
+
+	return Payload{Tag: sys.TagContract, Body: buf}
+}
+
+// PlaceStake places amount PERLs as stake.
+func PlaceStake(amount uint64) Payload {
+	return stake(sys.PlaceStake, amount)
+}
+
+// WithdrawStake withdraws amount PERLs of stake.
+func WithdrawStake(amount uint64) Payload {
+	return stake(sys.WithdrawStake, amount)
+}
+
+// WithdrawReward withdraws amount PERLs of validator rewards.
+func WithdrawReward(amount uint64) Payload {
+	return stake(sys.WithdrawReward, amount)
+}
+
+func stake(opcode byte, amount uint64) Payload {
+	buf := make([]byte, 9)
+
+	buf[0] = opcode
+	binary.LittleEndian.PutUint64(buf[1:], amount)
+
+	return Payload{Tag: sys.TagStake, Body: buf}
+}
+
+// Batch atomically applies several payloads as a single transaction.
+func Batch(payloads ...Payload) (Payload, error) {
+	if len(payloads) == 0 || len(payloads) >= math.MaxUint8 {
+		return Payload{}, errors.Errorf("a batch must comprise of between 1 and %d payloads", math.MaxUint8-1)
+	}
+
+	buf := []byte{byte(len(payloads))}
+
+	var size [4]byte
+
+	for _, p := range payloads {
+		if p.Tag == sys.TagBatch {
+			return Payload{}, errors.New("batches may not be nested")
+		}
+
+		binary.BigEndian.PutUint32(size[:], uint32(len(p.Body)))
+
+		buf = append(buf, byte(p.Tag))
+		buf = append(buf, size[:]...)
+		buf = append(buf, p.Body...)
+	}
+
+	return Payload{Tag: sys.TagBatch, Body: buf}, nil
+}
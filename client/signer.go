@@ -0,0 +1,109 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package client
+
+import (
+	"crypto/rand"
+
+	"github.com/perlin-network/noise/edwards25519"
+	"github.com/perlin-network/wavelet"
+	"github.com/perlin-network/wavelet/secp256k1"
+	"github.com/perlin-network/wavelet/wallet"
+	"github.com/pkg/errors"
+)
+
+// Signer signs the transactions created on behalf of an account.
+type Signer interface {
+	// ID returns the ID of the account the signer controls.
+	ID() wavelet.AccountID
+
+	// Scheme returns the signature scheme the signer signs under.
+	Scheme() wavelet.SignatureScheme
+
+	// Sign signs a message.
+	Sign(msg []byte) wavelet.Signature
+}
+
+type ed25519Signer struct {
+	privateKey edwards25519.PrivateKey
+	publicKey  edwards25519.PublicKey
+}
+
+// Ed25519 returns a signer for the account controlled by an Ed25519 private key.
+func Ed25519(privateKey edwards25519.PrivateKey) Signer {
+	return ed25519Signer{privateKey: privateKey, publicKey: privateKey.Public()}
+}
+
+// GenerateEd25519 generates a new Ed25519 private key, and returns a signer for
+// the account it controls alongside it such that it may be persisted.
+func GenerateEd25519() (Signer, edwards25519.PrivateKey, error) {
+	_, privateKey, err := edwards25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, privateKey, errors.Wrap(err, "failed to generate private key")
+	}
+
+	return Ed25519(privateKey), privateKey, nil
+}
+
+// Load returns a signer for the account controlled by the Ed25519 private key
+// loaded from a wallet file, environment variable, or keystore.
+func Load(source wallet.Source) (Signer, error) {
+	privateKey, err := source.PrivateKey()
+	if err != nil {
+		return nil, err
+	}
+
+	return Ed25519(privateKey), nil
+}
+
+func (s ed25519Signer) ID() wavelet.AccountID {
+	return s.publicKey
+}
+
+func (s ed25519Signer) Scheme() wavelet.SignatureScheme {
+	return wavelet.SignatureEd25519
+}
+
+func (s ed25519Signer) Sign(msg []byte) wavelet.Signature {
+	return edwards25519.Sign(s.privateKey, msg)
+}
+
+type secp256k1Signer struct {
+	key *secp256k1.PrivateKey
+	id  wavelet.AccountID
+}
+
+// Secp256k1 returns a signer for the account controlled by a secp256k1 private
+// key, such as that of an existing Bitcoin or Ethereum wallet.
+func Secp256k1(key *secp256k1.PrivateKey) Signer {
+	return secp256k1Signer{key: key, id: key.PublicKey().ID()}
+}
+
+func (s secp256k1Signer) ID() wavelet.AccountID {
+	return s.id
+}
+
+func (s secp256k1Signer) Scheme() wavelet.SignatureScheme {
+	return wavelet.SignatureSecp256k1
+}
+
+func (s secp256k1Signer) Sign(msg []byte) wavelet.Signature {
+	return s.key.Sign(msg)
+}
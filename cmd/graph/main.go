@@ -107,7 +107,7 @@ func main() {
 	client := skademlia.NewClient(addr, keys,
 		skademlia.WithC1(sys.SKademliaC1),
 		skademlia.WithC2(sys.SKademliaC2),
-		skademlia.WithDialOptions(grpc.WithDefaultCallOptions(grpc.UseCompressor(snappy.Name))),
+		skademlia.WithDialOptions(append(wavelet.VersionDialOptions(), grpc.WithDefaultCallOptions(grpc.UseCompressor(snappy.Name)))...),
 	)
 
 	client.SetCredentials(noise.NewCredentials(addr, handshake.NewECDH(), cipher.NewAEAD(), client.Protocol()))
@@ -125,7 +125,7 @@ func main() {
 	}()
 
 	if *apiPortFlag > 0 {
-		go api.New().StartHTTP(*apiPortFlag, client, ledger, keys)
+		go api.New().StartHTTP(*apiPortFlag, client, ledger, ledger.Signer())
 	}
 
 	if len(flag.Args()) > 0 {
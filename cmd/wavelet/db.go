@@ -0,0 +1,477 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/perlin-network/wavelet"
+	"github.com/perlin-network/wavelet/avl"
+	"github.com/perlin-network/wavelet/store"
+	"github.com/pkg/errors"
+	"gopkg.in/urfave/cli.v1"
+)
+
+// dbCommand inspects the contents of a stopped nodes database.
+func dbCommand() cli.Command {
+	return cli.Command{
+		Name:  "db",
+		Usage: "Inspect the database specified by --db. The node must not be running.",
+		Subcommands: []cli.Command{
+			{
+				Name:   "spaces",
+				Usage:  "List every key space in the store and in the accounts tree, alongside the number of keys and bytes it holds.",
+				Action: listKeySpaces,
+			},
+			{
+				Name:      "dump",
+				Usage:     "Dump every entry of the accounts tree under a prefix, given either as the name of a key space or in hex.",
+				ArgsUsage: "[prefix]",
+				Flags: []cli.Flag{
+					cli.BoolFlag{
+						Name:  "store",
+						Usage: "Dump entries of the underlying store instead of the accounts tree.",
+					},
+					cli.IntFlag{
+						Name:  "limit",
+						Usage: "Stop after dumping this many entries. Zero dumps every entry.",
+					},
+				},
+				Action: dumpKeySpace,
+			},
+			{
+				Name:      "account",
+				Usage:     "Print every record held in the accounts tree about an account.",
+				ArgsUsage: "<account id>",
+				Action:    printAccount,
+			},
+			{
+				Name:      "tx",
+				Usage:     "Print a transaction that starts or ends one of the rounds held in the store.",
+				ArgsUsage: "<tx id>",
+				Action:    printStoredTransaction,
+			},
+			{
+				Name:   "compact",
+				Usage:  "Delete tree nodes no longer reachable from any root and the certificates of pruned rounds, compact the store, and report the space reclaimed.",
+				Action: compactDatabase,
+			},
+			{
+				Name:  "repair",
+				Usage: "Verify that the accounts tree is intact, and that the rounds held in the store are consistent with their indices, repairing the latter.",
+				Flags: []cli.Flag{
+					cli.BoolFlag{
+						Name:  "dry-run",
+						Usage: "Report inconsistencies without repairing them.",
+					},
+				},
+				Action: repairDatabase,
+			},
+		},
+	}
+}
+
+type keySpaceUsage struct {
+	keys, keyBytes, valueBytes int
+}
+
+func listKeySpaces(c *cli.Context) error {
+	kv, closer, err := openDatabase(c)
+	if err != nil {
+		return err
+	}
+
+	defer closer()
+
+	storeUsage := make(map[string]*keySpaceUsage)
+
+	err = kv.IteratePrefix(nil, func(key, value []byte) bool {
+		addKeySpaceUsage(storeUsage, wavelet.StoreKeySpaces, key, value)
+		return true
+	})
+
+	if err != nil {
+		return errors.Wrap(err, "failed to iterate through the store")
+	}
+
+	treeUsage := make(map[string]*keySpaceUsage)
+
+	avl.New(kv).Iterate(func(key, value []byte) {
+		addKeySpaceUsage(treeUsage, wavelet.TreeKeySpaces, key, value)
+	})
+
+	w := bufio.NewWriter(os.Stdout)
+
+	fmt.Fprintln(w, "store:")
+	printKeySpaceUsage(w, wavelet.StoreKeySpaces, storeUsage)
+
+	fmt.Fprintln(w, "accounts tree:")
+	printKeySpaceUsage(w, wavelet.TreeKeySpaces, treeUsage)
+
+	return w.Flush()
+}
+
+func addKeySpaceUsage(usage map[string]*keySpaceUsage, spaces []wavelet.KeySpace, key, value []byte) {
+	name := "unknown"
+
+	if space, ok := wavelet.FindKeySpace(spaces, key); ok {
+		name = space.Name
+	}
+
+	u, exists := usage[name]
+	if !exists {
+		u = new(keySpaceUsage)
+		usage[name] = u
+	}
+
+	u.keys++
+	u.keyBytes += len(key)
+	u.valueBytes += len(value)
+}
+
+func printKeySpaceUsage(w io.Writer, spaces []wavelet.KeySpace, usage map[string]*keySpaceUsage) {
+	fmt.Fprintf(w, "  %-30s %-30s %10s %14s %14s\n", "name", "prefix", "keys", "key bytes", "value bytes")
+
+	names := make([]string, 0, len(spaces)+1)
+	prefixes := make(map[string]string, len(spaces))
+
+	for _, space := range spaces {
+		names = append(names, space.Name)
+		prefixes[space.Name] = hex.EncodeToString(space.Prefix)
+	}
+
+	names = append(names, "unknown")
+
+	for _, name := range names {
+		u, exists := usage[name]
+		if !exists {
+			if name == "unknown" {
+				continue
+			}
+
+			u = new(keySpaceUsage)
+		}
+
+		fmt.Fprintf(w, "  %-30s %-30s %10d %14d %14d\n", name, prefixes[name], u.keys, u.keyBytes, u.valueBytes)
+	}
+}
+
+func dumpKeySpace(c *cli.Context) error {
+	spaces := wavelet.TreeKeySpaces
+	if c.Bool("store") {
+		spaces = wavelet.StoreKeySpaces
+	}
+
+	var prefix []byte
+
+	if arg := c.Args().First(); len(arg) > 0 {
+		if space, ok := wavelet.LookupKeySpace(spaces, arg); ok {
+			prefix = space.Prefix
+		} else if buf, err := hex.DecodeString(arg); err == nil {
+			prefix = buf
+		} else {
+			return errors.Errorf("%q is neither the name of a key space nor a hex-encoded prefix", arg)
+		}
+	}
+
+	kv, closer, err := openDatabase(c)
+	if err != nil {
+		return err
+	}
+
+	defer closer()
+
+	w := bufio.NewWriter(os.Stdout)
+
+	limit := c.Int("limit")
+	count := 0
+
+	dump := func(key, value []byte) bool {
+		fmt.Fprintf(w, "%x %x\n", key, value)
+
+		count++
+		return limit <= 0 || count < limit
+	}
+
+	if c.Bool("store") {
+		if err := kv.IteratePrefix(prefix, dump); err != nil {
+			return errors.Wrap(err, "failed to iterate through the store")
+		}
+	} else {
+		avl.New(kv).IterateFrom(prefix, func(key, value []byte) bool {
+			if !bytes.HasPrefix(key, prefix) {
+				return false
+			}
+
+			return dump(key, value)
+		})
+	}
+
+	return w.Flush()
+}
+
+func printAccount(c *cli.Context) error {
+	var id wavelet.AccountID
+
+	buf, err := hex.DecodeString(c.Args().First())
+	if err != nil || len(buf) != wavelet.SizeAccountID {
+		return errors.New("account id must be a hex-encoded 32-byte public key")
+	}
+
+	copy(id[:], buf)
+
+	kv, closer, err := openDatabase(c)
+	if err != nil {
+		return err
+	}
+
+	defer closer()
+
+	tree := avl.New(kv)
+
+	w := bufio.NewWriter(os.Stdout)
+
+	printUint := func(name string, read func(*avl.Tree, wavelet.AccountID) (uint64, bool)) {
+		if value, exists := read(tree, id); exists {
+			fmt.Fprintf(w, "%-18s %d\n", name+":", value)
+		} else {
+			fmt.Fprintf(w, "%-18s -\n", name+":")
+		}
+	}
+
+	fmt.Fprintf(w, "%-18s %x\n", "id:", id)
+
+	printUint("nonce", wavelet.ReadAccountNonce)
+	printUint("balance", wavelet.ReadAccountBalance)
+	printUint("stake", wavelet.ReadAccountStake)
+	printUint("reward", wavelet.ReadAccountReward)
+
+	if code, exists := wavelet.ReadAccountContractCode(tree, id); exists {
+		numPages, _ := wavelet.ReadAccountContractNumPages(tree, id)
+		fmt.Fprintf(w, "%-18s %d bytes of code, %d memory pages\n", "contract:", len(code), numPages)
+	}
+
+	if guardians, exists := wavelet.ReadAccountGuardians(tree, id); exists {
+		fmt.Fprintf(w, "%-18s %d of %d after %d rounds\n", "guardians:", guardians.Threshold, len(guardians.Accounts), guardians.Delay)
+
+		for _, guardian := range guardians.Accounts {
+			fmt.Fprintf(w, "  - %x\n", guardian)
+		}
+	}
+
+	if recovery, exists := wavelet.ReadAccountRecovery(tree, id); exists {
+		fmt.Fprintf(w, "%-18s to %x with %d approvals", "recovery:", recovery.NewKey, len(recovery.Approvals))
+
+		if recovery.Ready {
+			fmt.Fprintf(w, ", ready at round %d", recovery.ReadyRound)
+		}
+
+		fmt.Fprintln(w)
+	}
+
+	if to, exists := wavelet.ReadAccountRecoveredTo(tree, id); exists {
+		fmt.Fprintf(w, "%-18s %x\n", "recovered_to:", to)
+	}
+
+	if freeze, exists := wavelet.ReadAccountFrozen(tree, id); exists {
+		fmt.Fprintf(w, "%-18s by %x at round %d\n", "frozen:", freeze.Authority, freeze.Round)
+	}
+
+	fmt.Fprintf(w, "%-18s %t\n", "freeze_authority:", wavelet.ReadAccountFreezeAuthority(tree, id))
+	fmt.Fprintf(w, "%-18s %t\n", "validator:", wavelet.ReadAccountValidator(tree, id))
+
+	return w.Flush()
+}
+
+func printStoredTransaction(c *cli.Context) error {
+	var id wavelet.TransactionID
+
+	buf, err := hex.DecodeString(c.Args().First())
+	if err != nil || len(buf) != wavelet.SizeTransactionID {
+		return errors.New("transaction id must be hex-encoded and 32 bytes long")
+	}
+
+	copy(id[:], buf)
+
+	kv, closer, err := openDatabase(c)
+	if err != nil {
+		return err
+	}
+
+	defer closer()
+
+	rounds, _, _, err := wavelet.LoadRounds(kv)
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(os.Stdout)
+
+	for _, round := range rounds {
+		for _, position := range []string{"start", "end"} {
+			tx := round.Start
+			if position == "end" {
+				tx = round.End
+			}
+
+			if tx.ID != id {
+				continue
+			}
+
+			fmt.Fprintf(w, "round:             %d (%s)\n", round.Index, position)
+
+			if err := printTransaction(w, tx); err != nil {
+				fmt.Fprintf(w, "  malformed payload: %v\n", err)
+			}
+
+			return w.Flush()
+		}
+	}
+
+	return errors.Errorf("transaction %x neither starts nor ends any round held in the store", id)
+}
+
+func compactDatabase(c *cli.Context) error {
+	kv, closer, err := openDatabase(c)
+	if err != nil {
+		return err
+	}
+
+	defer closer()
+
+	before, err := diskUsage(c.GlobalString("db"))
+	if err != nil {
+		return err
+	}
+
+	count, size, err := avl.New(kv).PruneOrphans()
+	if err != nil {
+		return errors.Wrap(err, "failed to prune orphaned tree nodes")
+	}
+
+	fmt.Printf("Deleted %d orphaned tree nodes and marks totalling %d bytes.\n", count, size)
+
+	if rounds, _, _, err := wavelet.LoadRounds(kv); err == nil && len(rounds) > 0 {
+		oldest := rounds[0].Index
+
+		for _, round := range rounds {
+			if round.Index < oldest {
+				oldest = round.Index
+			}
+		}
+
+		count, err := wavelet.NewCertificates(kv).PruneBelow(oldest)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Deleted %d certificates of rounds pruned below round %d.\n", count, oldest)
+	} else {
+		fmt.Println("Skipped deleting certificates as the rounds held in the store could not be loaded.")
+	}
+
+	if compactor, ok := kv.(store.Compactor); ok {
+		if err := compactor.Compact(); err != nil {
+			return errors.Wrap(err, "failed to compact the store")
+		}
+	}
+
+	after, err := diskUsage(c.GlobalString("db"))
+	if err != nil {
+		return err
+	}
+
+	if after < before {
+		fmt.Printf("Reclaimed %d bytes: the database now occupies %d bytes, down from %d bytes.\n", before-after, after, before)
+	} else {
+		fmt.Printf("No space was reclaimed: the database occupies %d bytes.\n", after)
+	}
+
+	return nil
+}
+
+func repairDatabase(c *cli.Context) error {
+	kv, closer, err := openDatabase(c)
+	if err != nil {
+		return err
+	}
+
+	defer closer()
+
+	dryRun := c.Bool("dry-run")
+
+	if reachable, err := avl.New(kv).Verify(); err != nil {
+		fmt.Printf("The accounts tree is corrupt and may not be repaired, and the node must be re-synced: %v\n", err)
+	} else {
+		fmt.Printf("The accounts tree is intact with %d nodes.\n", reachable)
+	}
+
+	issues, err := wavelet.RepairRounds(kv, dryRun)
+	if err != nil {
+		return err
+	}
+
+	if len(issues) == 0 {
+		fmt.Println("The rounds held in the store are consistent.")
+		return nil
+	}
+
+	verb := "Repaired"
+	if dryRun {
+		verb = "Found"
+	}
+
+	fmt.Printf("%s %d inconsistencies amongst the rounds held in the store:\n", verb, len(issues))
+
+	for _, issue := range issues {
+		fmt.Printf("  - %s\n", issue)
+	}
+
+	return nil
+}
+
+// diskUsage returns the total size of all files under path.
+func diskUsage(path string) (int64, error) {
+	var size int64
+
+	err := filepath.Walk(path, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !info.IsDir() {
+			size += info.Size()
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to measure the size of %q", path)
+	}
+
+	return size, nil
+}
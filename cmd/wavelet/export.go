@@ -0,0 +1,125 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package main
+
+import (
+	"bufio"
+	"os"
+
+	"github.com/perlin-network/wavelet"
+	"github.com/perlin-network/wavelet/avl"
+	"github.com/perlin-network/wavelet/store"
+	"github.com/pkg/errors"
+	"gopkg.in/urfave/cli.v1"
+)
+
+// exportCommand exports the state of a stopped nodes database to stdout.
+func exportCommand() cli.Command {
+	return cli.Command{
+		Name:  "export",
+		Usage: "Export the state stored in the database specified by --db. The node must not be running.",
+		Subcommands: []cli.Command{
+			{
+				Name:  "accounts",
+				Usage: "Export the balance and stake of every account, and whether or not it is a smart contract.",
+				Flags: []cli.Flag{
+					cli.StringFlag{
+						Name:  "format",
+						Value: string(wavelet.ExportCSV),
+						Usage: "Format to export accounts in: csv or json.",
+					},
+					cli.Int64Flag{
+						Name:  "round",
+						Value: -1,
+						Usage: "Export accounts as of the end of this round instead of the latest round. Requires the database of an archival node.",
+					},
+				},
+				Action: exportAccounts,
+			},
+		},
+	}
+}
+
+func exportAccounts(c *cli.Context) error {
+	format, err := wavelet.ParseExportFormat(c.String("format"))
+	if err != nil {
+		return err
+	}
+
+	kv, closer, err := openDatabase(c)
+	if err != nil {
+		return err
+	}
+
+	defer closer()
+
+	var history *wavelet.History
+	var round *uint64
+
+	if index := c.Int64("round"); index >= 0 {
+		r := uint64(index)
+
+		history = wavelet.NewHistory(kv)
+		round = &r
+	}
+
+	w := bufio.NewWriter(os.Stdout)
+
+	if err := wavelet.ExportAccounts(w, format, avl.New(kv), history, round); err != nil {
+		return err
+	}
+
+	return w.Flush()
+}
+
+// openDatabase opens the database specified by --db under the backend specified
+// by --db.backend, namespaced by --db.namespace if given. The returned function
+// closes the database.
+func openDatabase(c *cli.Context) (store.KV, func(), error) {
+	path := c.GlobalString("db")
+	if len(path) == 0 {
+		return nil, nil, errors.New("the path to the database must be specified via --db")
+	}
+
+	backend := c.GlobalString("db.backend")
+	if len(backend) == 0 {
+		backend = store.DefaultBackend
+	}
+
+	db, err := store.Open(backend, path)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "failed to open %s database located at %q", backend, path)
+	}
+
+	closer := func() {
+		_ = db.Close()
+	}
+
+	var kv store.KV = db
+
+	if namespace := c.GlobalString("db.namespace"); len(namespace) > 0 {
+		if kv, err = store.NewPrefixed(db, namespace); err != nil {
+			closer()
+			return nil, nil, err
+		}
+	}
+
+	return kv, closer, nil
+}
@@ -0,0 +1,267 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/chzyer/readline"
+	"github.com/perlin-network/noise/edwards25519"
+	"github.com/perlin-network/noise/skademlia"
+	"github.com/perlin-network/wavelet/keystore"
+	"github.com/perlin-network/wavelet/sys"
+	"github.com/pkg/errors"
+	"gopkg.in/urfave/cli.v1"
+)
+
+// envKeystorePassphrase is the environment variable read for the keystore
+// passphrase before prompting for one.
+const envKeystorePassphrase = "WAVELET_KEYSTORE_PASSPHRASE"
+
+// keystoreCommand moves wallets between machines by way of a passphrase
+// encrypted keystore file.
+func keystoreCommand() cli.Command {
+	return cli.Command{
+		Name:  "keystore",
+		Usage: "Export wallets into a passphrase-encrypted keystore file, and import them back out on another machine.",
+		Subcommands: []cli.Command{
+			{
+				Name:      "export",
+				Usage:     "Encrypt wallets, or the wallet specified by --wallet if none are given, into a keystore file. Accounts are added to the keystore file should it already exist.",
+				ArgsUsage: "<keystore file> [wallets...]",
+				Action:    exportKeystore,
+			},
+			{
+				Name:      "import",
+				Usage:     "Decrypt accounts from a keystore file, verify that their key pairs re-derive from their seeds, and write each into a wallet file.",
+				ArgsUsage: "<keystore file>",
+				Flags: []cli.Flag{
+					cli.StringFlag{
+						Name:  "dir",
+						Value: ".",
+						Usage: "Directory to write wallet files into, each named after the public key of its account.",
+					},
+					cli.StringSliceFlag{
+						Name:  "account",
+						Usage: "Hex-encoded ID of an account to import. May be repeated. Imports every account if not specified.",
+					},
+				},
+				Action: importKeystore,
+			},
+			{
+				Name:      "list",
+				Usage:     "List the accounts held in a keystore file without decrypting them.",
+				ArgsUsage: "<keystore file>",
+				Action:    listKeystore,
+			},
+		},
+	}
+}
+
+func exportKeystore(c *cli.Context) error {
+	path := c.Args().First()
+	if len(path) == 0 {
+		return errors.New("the path to the keystore file to export to must be specified")
+	}
+
+	wallets := c.Args().Tail()
+	if len(wallets) == 0 {
+		wallets = []string{c.GlobalString("wallet")}
+	}
+
+	ks := keystore.New()
+
+	if f, err := os.Open(path); err == nil {
+		ks, err = keystore.Read(f)
+		_ = f.Close()
+
+		if err != nil {
+			return errors.Wrapf(err, "failed to read existing keystore %q", path)
+		}
+	} else if !os.IsNotExist(err) {
+		return errors.Wrapf(err, "failed to open keystore %q", path)
+	}
+
+	var keys []*skademlia.Keypair
+
+	for _, wallet := range wallets {
+		k, err := readWallet(wallet)
+		if err != nil {
+			return err
+		}
+
+		keys = append(keys, k)
+	}
+
+	passphrase, err := readPassphrase(true)
+	if err != nil {
+		return err
+	}
+
+	for _, k := range keys {
+		if err := ks.Add(k.PrivateKey(), passphrase, keystore.DefaultKDF); err != nil {
+			return err
+		}
+
+		publicKey := k.PublicKey()
+		fmt.Printf("Exported account %x.\n", publicKey)
+	}
+
+	var buf bytes.Buffer
+
+	if err := ks.Write(&buf); err != nil {
+		return err
+	}
+
+	if err := ioutil.WriteFile(path, buf.Bytes(), 0600); err != nil {
+		return errors.Wrapf(err, "failed to write keystore %q", path)
+	}
+
+	return nil
+}
+
+func importKeystore(c *cli.Context) error {
+	ks, err := openKeystore(c.Args().First())
+	if err != nil {
+		return err
+	}
+
+	accounts := ks.Accounts
+
+	if ids := c.StringSlice("account"); len(ids) > 0 {
+		accounts = accounts[:0:0]
+
+		for _, id := range ids {
+			var publicKey edwards25519.PublicKey
+
+			buf, err := hex.DecodeString(id)
+			if err != nil || len(buf) != edwards25519.SizePublicKey {
+				return errors.Errorf("account id %q must be a hex-encoded 32-byte public key", id)
+			}
+
+			copy(publicKey[:], buf)
+
+			account, exists := ks.Find(publicKey)
+			if !exists {
+				return errors.Errorf("account %s is not held in the keystore", id)
+			}
+
+			accounts = append(accounts, account)
+		}
+	}
+
+	passphrase, err := readPassphrase(false)
+	if err != nil {
+		return err
+	}
+
+	dir := c.String("dir")
+
+	for _, account := range accounts {
+		privateKey, err := account.Decrypt(passphrase)
+		if err != nil {
+			return errors.Wrapf(err, "failed to import account %s", account.PublicKey)
+		}
+
+		if _, err := skademlia.LoadKeys(privateKey, sys.SKademliaC1, sys.SKademliaC2); err != nil {
+			return errors.Wrapf(err, "account %s is not a valid identity on this network", account.PublicKey)
+		}
+
+		path := filepath.Join(dir, account.PublicKey+".txt")
+
+		if _, err := os.Stat(path); err == nil {
+			fmt.Printf("Skipped account %s as %q already exists.\n", account.PublicKey, path)
+			continue
+		}
+
+		if err := ioutil.WriteFile(path, []byte(hex.EncodeToString(privateKey[:])), 0600); err != nil {
+			return errors.Wrapf(err, "failed to write wallet %q", path)
+		}
+
+		fmt.Printf("Imported account %s into %q.\n", account.PublicKey, path)
+	}
+
+	return nil
+}
+
+func listKeystore(c *cli.Context) error {
+	ks, err := openKeystore(c.Args().First())
+	if err != nil {
+		return err
+	}
+
+	for _, account := range ks.Accounts {
+		fmt.Println(account.PublicKey)
+	}
+
+	return nil
+}
+
+func openKeystore(path string) (*keystore.Keystore, error) {
+	if len(path) == 0 {
+		return nil, errors.New("the path to the keystore file must be specified")
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open keystore %q", path)
+	}
+
+	defer func() {
+		_ = f.Close()
+	}()
+
+	return keystore.Read(f)
+}
+
+// readPassphrase reads the keystore passphrase from the environment, or
+// prompts for it, asking for it twice should confirm be set.
+func readPassphrase(confirm bool) ([]byte, error) {
+	if passphrase := os.Getenv(envKeystorePassphrase); len(passphrase) > 0 {
+		return []byte(passphrase), nil
+	}
+
+	passphrase, err := readline.Password("Keystore passphrase: ")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read passphrase")
+	}
+
+	if len(passphrase) == 0 {
+		return nil, errors.New("passphrase must not be empty")
+	}
+
+	if confirm {
+		again, err := readline.Password("Repeat passphrase: ")
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read passphrase")
+		}
+
+		if !bytes.Equal(passphrase, again) {
+			return nil, errors.New("passphrases do not match")
+		}
+	}
+
+	return passphrase, nil
+}
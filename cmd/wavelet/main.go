@@ -20,12 +20,10 @@
 package main
 
 import (
-	"encoding/hex"
 	"errors"
 	"fmt"
 	"github.com/perlin-network/noise"
 	"github.com/perlin-network/noise/cipher"
-	"github.com/perlin-network/noise/edwards25519"
 	"github.com/perlin-network/noise/handshake"
 	"github.com/perlin-network/noise/nat"
 	"github.com/perlin-network/noise/skademlia"
@@ -35,6 +33,7 @@ import (
 	"github.com/perlin-network/wavelet/log"
 	"github.com/perlin-network/wavelet/store"
 	"github.com/perlin-network/wavelet/sys"
+	"github.com/perlin-network/wavelet/wallet"
 	"google.golang.org/grpc"
 	"gopkg.in/urfave/cli.v1"
 	"gopkg.in/urfave/cli.v1/altsrc"
@@ -42,22 +41,59 @@ import (
 	"net"
 	"net/http"
 	"os"
+	"os/signal"
 	"sort"
 	"strconv"
+	"strings"
+	"syscall"
 	"time"
 )
 
 import _ "net/http/pprof"
 
 type Config struct {
-	NAT      bool
-	Host     string
-	Port     uint
-	Wallet   string
-	Genesis  *string
-	APIPort  uint
-	Peers    []string
-	Database string
+	NAT                bool
+	Host               string
+	Port               uint
+	Wallet             string
+	WalletVault        string
+	Keystore           string
+	KeystoreAccount    string
+	KeystorePassphrase string
+	Genesis            *string
+	APIPort            uint
+	APISign            bool
+	APIAdmin           string
+	APISnapshot        string
+	APIWebsocketAuth   bool
+	APIWebsocketConns  int
+	APIWebsocketSubs   int
+	APILevel           int
+	Peers              []string
+	Database           string
+	Backend            string
+	Namespace          string
+	Archival           bool
+	Bootstrap          string
+	BootstrapToken     string
+	BootstrapRound     string
+	Mode               wavelet.Mode
+	Weighting          wavelet.Weighting
+	ParentSelector     wavelet.ParentSelector
+	PeerSampler        wavelet.PeerSampler
+
+	Alerts    wavelet.AlertConfig
+	Watchdog  wavelet.WatchdogConfig
+	Timeouts  wavelet.TimeoutConfig
+	Broadcast wavelet.BroadcastConfig
+	Orphans   wavelet.OrphanConfig
+	State     wavelet.StateCacheConfig
+
+	MaxGraphSize int
+
+	NopIdleCutoff time.Duration
+
+	Sys sys.Config
 }
 
 func main() {
@@ -96,26 +132,149 @@ func main() {
 			Usage:  "Host a local HTTP API at port.",
 			EnvVar: "WAVELET_API_PORT",
 		}),
+		altsrc.NewBoolFlag(cli.BoolFlag{
+			Name:   "api.sign",
+			Usage:  "Sign the body of every HTTP API response with this nodes key, alongside the latest round.",
+			EnvVar: "WAVELET_API_SIGN",
+		}),
+		altsrc.NewStringFlag(cli.StringFlag{
+			Name:   "api.admin_token",
+			Usage:  "Bearer token which enables the admin endpoints of the HTTP API, used to manage peers at runtime. If empty, admin endpoints are disabled.",
+			EnvVar: "WAVELET_API_ADMIN_TOKEN",
+		}),
+		altsrc.NewStringFlag(cli.StringFlag{
+			Name:   "api.snapshot_token",
+			Usage:  "Bearer token which enables serving the state snapshot of the latest finalized round via GET /snapshot, for new nodes to bootstrap from. If empty, state snapshots are not served.",
+			EnvVar: "WAVELET_API_SNAPSHOT_TOKEN",
+		}),
+		altsrc.NewStringFlag(cli.StringFlag{
+			Name:   "bootstrap.snapshot",
+			Usage:  "URL of a state snapshot, served by a node or a mirror of it, to bootstrap from before syncing the remaining rounds from peers.",
+			EnvVar: "WAVELET_BOOTSTRAP_SNAPSHOT",
+		}),
+		altsrc.NewStringFlag(cli.StringFlag{
+			Name:   "bootstrap.snapshot_token",
+			Usage:  "Bearer token to authenticate with when downloading the state snapshot to bootstrap from.",
+			EnvVar: "WAVELET_BOOTSTRAP_SNAPSHOT_TOKEN",
+		}),
+		altsrc.NewStringFlag(cli.StringFlag{
+			Name:   "bootstrap.round",
+			Usage:  "Hex-encoded ID of the round the state snapshot to bootstrap from must have been taken at, obtained from a source trusted to only report rounds finalized by the network. Required to bootstrap from a state snapshot.",
+			EnvVar: "WAVELET_BOOTSTRAP_ROUND",
+		}),
+		altsrc.NewBoolFlag(cli.BoolFlag{
+			Name:   "api.ws_auth",
+			Usage:  "Require websocket connections to the HTTP API to authenticate with short-lived tokens issued via POST /admin/ws/token. Requires an admin token.",
+			EnvVar: "WAVELET_API_WS_AUTH",
+		}),
+		altsrc.NewIntFlag(cli.IntFlag{
+			Name:   "api.ws_max_conns",
+			Value:  64,
+			Usage:  "Maximum number of websocket connections any single IP may hold open with the HTTP API at once. Set to 0 to disable the limit.",
+			EnvVar: "WAVELET_API_WS_MAX_CONNS",
+		}),
+		altsrc.NewIntFlag(cli.IntFlag{
+			Name:   "api.ws_max_subscriptions",
+			Value:  16,
+			Usage:  "Maximum number of websocket connections any single IP may hold open to any single event stream of the HTTP API at once. Set to 0 to disable the limit.",
+			EnvVar: "WAVELET_API_WS_MAX_SUBSCRIPTIONS",
+		}),
+		altsrc.NewIntFlag(cli.IntFlag{
+			Name:   "api.compression_level",
+			Value:  6,
+			Usage:  "Compression level (1-9) used for gzip or deflate encoded HTTP API responses. Set to 0 to disable response compression.",
+			EnvVar: "WAVELET_API_COMPRESSION_LEVEL",
+		}),
 		altsrc.NewStringFlag(cli.StringFlag{
 			Name:   "wallet",
 			Value:  "config/wallet.txt",
-			Usage:  "Path to file containing hex-encoded private key. If the path specified is invalid, or no file exists at the specified path, a random wallet will be generated. Optionally, a 128-length hex-encoded private key to a wallet may also be specified.",
+			Usage:  "Path to file containing hex-encoded private key. If no file exists at the specified path, a random wallet will be generated. The private key may instead be specified through the WAVELET_PRIVATE_KEY environment variable, which takes precedence over any wallet file.",
 			EnvVar: "WAVELET_WALLET",
 		}),
+		altsrc.NewStringFlag(cli.StringFlag{
+			Name:   "wallet.vault",
+			Usage:  "Sign the transactions of the node through an Ed25519 key of the HashiCorp Vault transit secrets engine, given as the URL of the key, such as https://vault:8200/v1/transit/keys/wavelet. The private key never leaves Vault. The node still authenticates itself to its peers and votes with the key pair loaded through --wallet, --wallet.keystore, or WAVELET_PRIVATE_KEY. The Vault token is read from the VAULT_TOKEN environment variable.",
+			EnvVar: "WAVELET_WALLET_VAULT",
+		}),
+		altsrc.NewStringFlag(cli.StringFlag{
+			Name:   "wallet.keystore",
+			Usage:  "Load the private key of the wallet by decrypting it out of a passphrase-encrypted keystore file instead of a wallet file. The passphrase is read from --wallet.passphrase_file, the WAVELET_KEYSTORE_PASSPHRASE environment variable, or otherwise prompted for.",
+			EnvVar: "WAVELET_WALLET_KEYSTORE",
+		}),
+		altsrc.NewStringFlag(cli.StringFlag{
+			Name:   "wallet.keystore.account",
+			Usage:  "Hex-encoded public key of the account to load out of the keystore file. May be omitted should the keystore file hold a single account.",
+			EnvVar: "WAVELET_WALLET_KEYSTORE_ACCOUNT",
+		}),
+		altsrc.NewStringFlag(cli.StringFlag{
+			Name:   "wallet.passphrase_file",
+			Usage:  "Path to a file holding the passphrase of the keystore file, for nodes which may not be prompted for one, such as those run as a systemd service.",
+			EnvVar: "WAVELET_WALLET_PASSPHRASE_FILE",
+		}),
 		altsrc.NewStringFlag(cli.StringFlag{
 			Name:   "genesis",
 			Usage:  "Genesis JSON file contents representing initial fields of some set of accounts at round 0.",
 			EnvVar: "WAVELET_GENESIS",
 		}),
+		altsrc.NewStringFlag(cli.StringFlag{
+			Name:   "genesis.csv",
+			Usage:  "Path to a CSV file of the balances and stakes of accounts at round 0, with the header: id,balance,stake. May not be used alongside --genesis.",
+			EnvVar: "WAVELET_GENESIS_CSV",
+		}),
+		altsrc.NewUint64Flag(cli.Uint64Flag{
+			Name:   "genesis.supply",
+			Usage:  "Total supply of PERLs which the balances, stakes and rewards in the file specified by --genesis.csv must sum up to.",
+			EnvVar: "WAVELET_GENESIS_SUPPLY",
+		}),
 		altsrc.NewStringFlag(cli.StringFlag{
 			Name:   "db",
 			Usage:  "Directory path to the database. If empty, a temporary in-memory database will be used instead.",
 			EnvVar: "WAVELET_DB_PATH",
 		}),
-		altsrc.NewIntFlag(cli.IntFlag{
-			Name:  "sys.query_timeout",
-			Value: int(sys.QueryTimeout.Seconds()),
-			Usage: "Timeout in seconds for querying a transaction to K peers.",
+		altsrc.NewStringFlag(cli.StringFlag{
+			Name:   "db.backend",
+			Value:  store.DefaultBackend,
+			Usage:  fmt.Sprintf("Storage backend to keep the database in. Available backends: %s.", strings.Join(store.Backends(), ", ")),
+			EnvVar: "WAVELET_DB_BACKEND",
+		}),
+		altsrc.NewStringFlag(cli.StringFlag{
+			Name:   "db.namespace",
+			Usage:  "Namespace to prefix all keys stored in the database with, such that the state of several ledgers may coexist within one database. If empty, keys are not prefixed.",
+			EnvVar: "WAVELET_DB_NAMESPACE",
+		}),
+		altsrc.NewStringFlag(cli.StringFlag{
+			Name:   "mode",
+			Value:  string(wavelet.ModeValidator),
+			Usage:  "Whether to take part in consensus as a validator, to never gossip nor vote and merely follow the rounds finalized by peers as a follower, to follow peers without loading a wallet as an observer, or to follow peers while keeping every finalized transaction and historical account state as an archive.",
+			EnvVar: "WAVELET_MODE",
+		}),
+		altsrc.NewStringFlag(cli.StringFlag{
+			Name:   "weighting",
+			Value:  string(wavelet.WeightByStake),
+			Usage:  "Whether every account may vote with votes weighed by stake, or only the allowlist of validators set in the genesis may vote with votes weighed equally by authority. All nodes within a network must agree on the weighting.",
+			EnvVar: "WAVELET_WEIGHTING",
+		}),
+		altsrc.NewStringFlag(cli.StringFlag{
+			Name:   "parent_selection",
+			Value:  wavelet.ParentsDeepest,
+			Usage:  "How parents are picked for new transactions out of those eligible: the deepest first (deepest), uniformly at random (uniform), at random weighed by depth (depth_weighted), or those with the fewest conflicts first (low_conflict).",
+			EnvVar: "WAVELET_PARENT_SELECTION",
+		}),
+		altsrc.NewStringFlag(cli.StringFlag{
+			Name:   "snowball.sampling",
+			Value:  wavelet.SampleUniform,
+			Usage:  "How peers are sampled to be queried in consensus: uniformly at random (uniform), at random weighed by stake (stake), or at random favoring peers that respond the quickest (latency).",
+			EnvVar: "WAVELET_SNOWBALL_SAMPLING",
+		}),
+		altsrc.NewBoolFlag(cli.BoolFlag{
+			Name:   "archival",
+			Usage:  "Record the balance and stake of accounts as of every finalized round, such that they may be queried through the HTTP API.",
+			EnvVar: "WAVELET_ARCHIVAL",
+		}),
+		altsrc.NewStringFlag(cli.StringFlag{
+			Name:   "sys.config",
+			Usage:  "Path to a JSON file of consensus parameters. Parameters set through their own sys.* flags take precedence over the file.",
+			EnvVar: "WAVELET_SYS_CONFIG",
 		}),
 		altsrc.NewUint64Flag(cli.Uint64Flag{
 			Name:  "sys.max_depth_diff",
@@ -159,6 +318,146 @@ func main() {
 			Value: sys.DifficultyScaleFactor,
 			Usage: "Factor to scale a transactions confidence down by to compute the difficulty needed to define a critical transaction",
 		}),
+		altsrc.NewIntFlag(cli.IntFlag{
+			Name:   "alert.round_timeout",
+			Usage:  "Alert if no consensus round has been finalized for this many seconds. Disabled if zero.",
+			EnvVar: "WAVELET_ALERT_ROUND_TIMEOUT",
+		}),
+		altsrc.NewIntFlag(cli.IntFlag{
+			Name:   "alert.min_peers",
+			Usage:  "Alert if connected to fewer than this many peers. Disabled if zero.",
+			EnvVar: "WAVELET_ALERT_MIN_PEERS",
+		}),
+		altsrc.NewIntFlag(cli.IntFlag{
+			Name:   "alert.max_sync_failures",
+			Usage:  "Alert if syncing to the latest round fails this many times in a row. Disabled if zero.",
+			EnvVar: "WAVELET_ALERT_MAX_SYNC_FAILURES",
+		}),
+		altsrc.NewIntFlag(cli.IntFlag{
+			Name:   "alert.max_clock_skew",
+			Usage:  "Alert if the median clock of our peers is ahead or behind ours by at least this many seconds. Disabled if zero.",
+			EnvVar: "WAVELET_ALERT_MAX_CLOCK_SKEW",
+		}),
+		altsrc.NewDurationFlag(cli.DurationFlag{
+			Name:   "watchdog.timeout",
+			Usage:  "Reset consensus, check if we are out of sync, and bootstrap to peers anew should no round be finalized and no sync be triggered for this long. Disabled if zero.",
+			EnvVar: "WAVELET_WATCHDOG_TIMEOUT",
+		}),
+		altsrc.NewStringFlag(cli.StringFlag{
+			Name:   "alert.webhook",
+			Usage:  "URL to POST alerts to as JSON whenever a liveness rule is violated or resolved.",
+			EnvVar: "WAVELET_ALERT_WEBHOOK",
+		}),
+		altsrc.NewDurationFlag(cli.DurationFlag{
+			Name:   "timeout.query",
+			Value:  wavelet.DefaultTimeoutConfig().Query,
+			Usage:  "Deadline for a single peer to respond to a consensus query.",
+			EnvVar: "WAVELET_TIMEOUT_QUERY",
+		}),
+		altsrc.NewDurationFlag(cli.DurationFlag{
+			Name:   "timeout.out_of_sync",
+			Value:  wavelet.DefaultTimeoutConfig().OutOfSync,
+			Usage:  "Deadline for a single peer to respond when checking whether we are out of sync.",
+			EnvVar: "WAVELET_TIMEOUT_OUT_OF_SYNC",
+		}),
+		altsrc.NewDurationFlag(cli.DurationFlag{
+			Name:   "timeout.download",
+			Value:  wavelet.DefaultTimeoutConfig().Download,
+			Usage:  "Deadline for a single peer to respond with transactions we are missing.",
+			EnvVar: "WAVELET_TIMEOUT_DOWNLOAD",
+		}),
+		altsrc.NewDurationFlag(cli.DurationFlag{
+			Name:   "timeout.retry_backoff",
+			Value:  wavelet.DefaultTimeoutConfig().RetryBackoff,
+			Usage:  "Delay before retrying after a failed attempt, doubled with every consecutive failure.",
+			EnvVar: "WAVELET_TIMEOUT_RETRY_BACKOFF",
+		}),
+		altsrc.NewDurationFlag(cli.DurationFlag{
+			Name:   "timeout.max_retry_backoff",
+			Value:  wavelet.DefaultTimeoutConfig().MaxRetryBackoff,
+			Usage:  "Maximum delay before retrying after consecutive failed attempts.",
+			EnvVar: "WAVELET_TIMEOUT_MAX_RETRY_BACKOFF",
+		}),
+		altsrc.NewFloat64Flag(cli.Float64Flag{
+			Name:   "timeout.retry_jitter",
+			Value:  wavelet.DefaultTimeoutConfig().RetryJitter,
+			Usage:  "Fraction in [0, 1] by which retry delays are randomly spread out.",
+			EnvVar: "WAVELET_TIMEOUT_RETRY_JITTER",
+		}),
+		altsrc.NewIntFlag(cli.IntFlag{
+			Name:   "broadcast.capacity",
+			Value:  wavelet.DefaultBroadcastConfig().Capacity,
+			Usage:  "Maximum number of transactions pending to be gossiped to our peers.",
+			EnvVar: "WAVELET_BROADCAST_CAPACITY",
+		}),
+		altsrc.NewStringFlag(cli.StringFlag{
+			Name:   "broadcast.overflow",
+			Value:  string(wavelet.DefaultBroadcastConfig().Policy),
+			Usage:  "What to do once the broadcast queue is full: reject-newest, evict-lowest-fee, or block-with-deadline.",
+			EnvVar: "WAVELET_BROADCAST_OVERFLOW",
+		}),
+		altsrc.NewDurationFlag(cli.DurationFlag{
+			Name:   "broadcast.deadline",
+			Value:  wavelet.DefaultBroadcastConfig().Deadline,
+			Usage:  "How long to block for under the block-with-deadline overflow policy.",
+			EnvVar: "WAVELET_BROADCAST_DEADLINE",
+		}),
+		altsrc.NewIntFlag(cli.IntFlag{
+			Name:   "broadcast.max_per_sender",
+			Value:  wavelet.DefaultBroadcastConfig().MaxPerSender,
+			Usage:  "Maximum number of relayed transactions any single sender may have pending to be gossiped.",
+			EnvVar: "WAVELET_BROADCAST_MAX_PER_SENDER",
+		}),
+		altsrc.NewIntFlag(cli.IntFlag{
+			Name:   "orphans.capacity",
+			Value:  wavelet.DefaultOrphanConfig().Capacity,
+			Usage:  "Maximum number of transactions with missing parents to hold on to until their parents arrive.",
+			EnvVar: "WAVELET_ORPHANS_CAPACITY",
+		}),
+		altsrc.NewDurationFlag(cli.DurationFlag{
+			Name:   "orphans.ttl",
+			Value:  wavelet.DefaultOrphanConfig().TTL,
+			Usage:  "How long to wait for the missing parents of a transaction to arrive before dropping it.",
+			EnvVar: "WAVELET_ORPHANS_TTL",
+		}),
+		altsrc.NewIntFlag(cli.IntFlag{
+			Name:   "state.cache_nodes",
+			Value:  wavelet.DefaultStateCacheConfig().Nodes,
+			Usage:  "Maximum number of nodes of the accounts tree loaded from the database to keep in memory.",
+			EnvVar: "WAVELET_STATE_CACHE_NODES",
+		}),
+		altsrc.NewInt64Flag(cli.Int64Flag{
+			Name:   "state.memory_budget",
+			Usage:  "Maximum number of bytes taken up by nodes of the accounts tree kept in memory across all snapshots, past which they are evicted and reloaded from the database on demand. Unbounded if zero.",
+			EnvVar: "WAVELET_STATE_MEMORY_BUDGET",
+		}),
+		altsrc.NewIntFlag(cli.IntFlag{
+			Name:   "state.prefetch_depth",
+			Value:  wavelet.DefaultStateCacheConfig().PrefetchDepth,
+			Usage:  "Levels of the accounts tree to prefetch from the database beneath every node loaded. Disabled if negative.",
+			EnvVar: "WAVELET_STATE_PREFETCH_DEPTH",
+		}),
+		altsrc.NewIntFlag(cli.IntFlag{
+			Name:   "graph.max_size",
+			Usage:  "Maximum number of transactions to hold in the graph, past which only critical transactions and transactions the graph is missing are admitted. Unbounded if zero.",
+			EnvVar: "WAVELET_GRAPH_MAX_SIZE",
+		}),
+		altsrc.NewDurationFlag(cli.DurationFlag{
+			Name:   "nop.idle_cutoff",
+			Value:  wavelet.DefaultNopIdleCutoff,
+			Usage:  "Stop broadcasting nops once no other transaction has been broadcasted for this long. Nops are broadcasted for as long as transactions are pending finalization if zero.",
+			EnvVar: "WAVELET_NOP_IDLE_CUTOFF",
+		}),
+		altsrc.NewStringSliceFlag(cli.StringSliceFlag{
+			Name:   "log.sink",
+			Usage:  "Additionally write logs to a sink, given as a URL such as file:///var/log/wavelet.log?max_size=104857600&max_backups=7, syslog://localhost:514?modules=node,consensus, or stdout?format=json. May be specified several times.",
+			EnvVar: "WAVELET_LOG_SINKS",
+		}),
+		altsrc.NewStringSliceFlag(cli.StringSliceFlag{
+			Name:   "log.sample",
+			Usage:  "Only log the first of every n events of some type, given as module.event=n, such as tx.applied=100. A summary of how many events were left unlogged is logged every round. May be specified several times.",
+			EnvVar: "WAVELET_LOG_SAMPLE",
+		}),
 		cli.StringFlag{
 			Name:  "config, c",
 			Usage: "Path to TOML config file, will override the arguments.",
@@ -185,34 +484,166 @@ func main() {
 	app.Action = func(c *cli.Context) error {
 		c.String("config")
 		config := &Config{
-			Host:     c.String("host"),
-			Port:     c.Uint("port"),
-			Wallet:   c.String("wallet"),
-			APIPort:  c.Uint("api.port"),
-			Peers:    c.Args(),
-			Database: c.String("db"),
+			Host:               c.String("host"),
+			Port:               c.Uint("port"),
+			Wallet:             c.String("wallet"),
+			WalletVault:        c.String("wallet.vault"),
+			Keystore:           c.String("wallet.keystore"),
+			KeystoreAccount:    c.String("wallet.keystore.account"),
+			KeystorePassphrase: c.String("wallet.passphrase_file"),
+			APIPort:            c.Uint("api.port"),
+			APISign:            c.Bool("api.sign"),
+			APIAdmin:           c.String("api.admin_token"),
+			APISnapshot:        c.String("api.snapshot_token"),
+			APIWebsocketAuth:   c.Bool("api.ws_auth"),
+			APIWebsocketConns:  c.Int("api.ws_max_conns"),
+			APIWebsocketSubs:   c.Int("api.ws_max_subscriptions"),
+			APILevel:           c.Int("api.compression_level"),
+			Peers:              c.Args(),
+			Database:           c.String("db"),
+			Backend:            c.String("db.backend"),
+			Namespace:          c.String("db.namespace"),
+			Archival:           c.Bool("archival"),
+			Bootstrap:          c.String("bootstrap.snapshot"),
+			BootstrapToken:     c.String("bootstrap.snapshot_token"),
+			BootstrapRound:     c.String("bootstrap.round"),
+
+			Alerts: wavelet.AlertConfig{
+				RoundTimeout:    time.Duration(c.Int("alert.round_timeout")) * time.Second,
+				MinPeers:        c.Int("alert.min_peers"),
+				MaxSyncFailures: c.Int("alert.max_sync_failures"),
+				MaxClockSkew:    time.Duration(c.Int("alert.max_clock_skew")) * time.Second,
+				Webhook:         c.String("alert.webhook"),
+			},
+
+			Watchdog: wavelet.WatchdogConfig{
+				Timeout: c.Duration("watchdog.timeout"),
+				Peers:   c.Args(),
+			},
+
+			Timeouts: wavelet.TimeoutConfig{
+				Query:           c.Duration("timeout.query"),
+				OutOfSync:       c.Duration("timeout.out_of_sync"),
+				Download:        c.Duration("timeout.download"),
+				RetryBackoff:    c.Duration("timeout.retry_backoff"),
+				MaxRetryBackoff: c.Duration("timeout.max_retry_backoff"),
+				RetryJitter:     c.Float64("timeout.retry_jitter"),
+			},
+
+			NopIdleCutoff: c.Duration("nop.idle_cutoff"),
 		}
 
+		mode, err := wavelet.ParseMode(c.String("mode"))
+		if err != nil {
+			return err
+		}
+
+		config.Mode = mode
+
+		weighting, err := wavelet.ParseWeighting(c.String("weighting"))
+		if err != nil {
+			return err
+		}
+
+		config.Weighting = weighting
+
+		parents, err := wavelet.ParseParentSelector(c.String("parent_selection"))
+		if err != nil {
+			return err
+		}
+
+		config.ParentSelector = parents
+
+		sampler, err := wavelet.ParsePeerSampler(c.String("snowball.sampling"))
+		if err != nil {
+			return err
+		}
+
+		config.PeerSampler = sampler
+
+		if config.Mode == wavelet.ModeObserver && config.APISign {
+			return errors.New("observers hold no wallet to sign HTTP API responses with")
+		}
+
+		if config.APILevel < 0 || config.APILevel > 9 {
+			return fmt.Errorf("api compression level must be between 0 and 9, but got %d", config.APILevel)
+		}
+
+		policy, err := wavelet.ParseOverflowPolicy(c.String("broadcast.overflow"))
+		if err != nil {
+			return err
+		}
+
+		config.Broadcast = wavelet.BroadcastConfig{
+			Capacity: c.Int("broadcast.capacity"),
+			Policy:   policy,
+			Deadline: c.Duration("broadcast.deadline"),
+
+			MaxPerSender: c.Int("broadcast.max_per_sender"),
+		}
+
+		config.Orphans = wavelet.OrphanConfig{
+			Capacity: c.Int("orphans.capacity"),
+			TTL:      c.Duration("orphans.ttl"),
+		}
+
+		config.State = wavelet.StateCacheConfig{
+			Nodes:         c.Int("state.cache_nodes"),
+			MemoryBudget:  c.Int64("state.memory_budget"),
+			PrefetchDepth: c.Int("state.prefetch_depth"),
+		}
+
+		config.MaxGraphSize = c.Int("graph.max_size")
+
 		if genesis := c.String("genesis"); len(genesis) > 0 {
 			config.Genesis = &genesis
 		}
 
-		// set the the sys variables
-		sys.SnowballK = c.Int("sys.snowball.k")
-		sys.SnowballAlpha = c.Float64("sys.snowball.alpha")
-		sys.SnowballBeta = c.Int("sys.snowball.beta")
-		sys.QueryTimeout = time.Duration(c.Int("sys.query_timeout")) * time.Second
-		sys.MaxDepthDiff = c.Uint64("sys.max_depth_diff")
-		sys.MinDifficulty = byte(c.Int("sys.difficulty.min"))
-		sys.DifficultyScaleFactor = c.Float64("sys.difficulty.scale")
-		sys.TransactionFeeAmount = c.Uint64("sys.transaction_fee_amount")
-		sys.MinimumStake = c.Uint64("sys.min_stake")
+		if path := c.String("genesis.csv"); len(path) > 0 {
+			if config.Genesis != nil {
+				return errors.New("only one of --genesis or --genesis.csv may be specified")
+			}
+
+			genesis, err := loadGenesisCSV(path, c.Uint64("genesis.supply"))
+			if err != nil {
+				return err
+			}
+
+			config.Genesis = &genesis
+		}
+
+		if err := openLogSinks(c.StringSlice("log.sink")); err != nil {
+			return err
+		}
+
+		for _, spec := range c.StringSlice("log.sample") {
+			if err := log.ParseSampling(spec); err != nil {
+				return err
+			}
+		}
+
+		params, err := loadSysConfig(c)
+		if err != nil {
+			return err
+		}
+
+		config.Sys = params
 
 		start(config)
 
 		return nil
 	}
 
+	app.Commands = []cli.Command{
+		exportCommand(),
+		dbCommand(),
+		snapshotCommand(),
+		keystoreCommand(),
+		txCommand(),
+		signCommand(),
+		verifyCommand(),
+	}
+
 	sort.Sort(cli.FlagsByName(app.Flags))
 	sort.Sort(cli.CommandsByName(app.Commands))
 
@@ -222,6 +653,74 @@ func main() {
 	}
 }
 
+// openLogSinks writes logs to every sink specified, alongside the console.
+func openLogSinks(specs []string) error {
+	for i, spec := range specs {
+		sink, err := log.OpenSink(spec)
+		if err != nil {
+			return err
+		}
+
+		log.SetWriter(fmt.Sprintf("%s.sink.%d", log.LoggerWavelet, i), sink)
+	}
+
+	return nil
+}
+
+// loadSysConfig reads the consensus parameters from the file given through
+// --sys.config, should there be one, and overrides them with any parameters
+// explicitly set through their own flags.
+func loadSysConfig(c *cli.Context) (sys.Config, error) {
+	params := sys.DefaultConfig()
+
+	if path := c.String("sys.config"); len(path) > 0 {
+		loaded, err := sys.LoadConfig(path)
+		if err != nil {
+			return params, err
+		}
+
+		params = loaded
+	}
+
+	if c.IsSet("sys.snowball.k") {
+		params.SnowballK = c.Int("sys.snowball.k")
+	}
+
+	if c.IsSet("sys.snowball.alpha") {
+		params.SnowballAlpha = c.Float64("sys.snowball.alpha")
+	}
+
+	if c.IsSet("sys.snowball.beta") {
+		params.SnowballBeta = c.Int("sys.snowball.beta")
+	}
+
+	if c.IsSet("sys.max_depth_diff") {
+		params.MaxDepthDiff = c.Uint64("sys.max_depth_diff")
+	}
+
+	if c.IsSet("sys.difficulty.min") {
+		params.MinDifficulty = byte(c.Int("sys.difficulty.min"))
+	}
+
+	if c.IsSet("sys.difficulty.scale") {
+		params.DifficultyScaleFactor = c.Float64("sys.difficulty.scale")
+	}
+
+	if c.IsSet("sys.transaction_fee_amount") {
+		params.TransactionFeeAmount = c.Uint64("sys.transaction_fee_amount")
+	}
+
+	if c.IsSet("sys.min_stake") {
+		params.MinimumStake = c.Uint64("sys.min_stake")
+	}
+
+	if err := params.Validate(); err != nil {
+		return params, err
+	}
+
+	return params, nil
+}
+
 func start(cfg *Config) {
 	logger := log.Node()
 
@@ -264,7 +763,15 @@ func start(cfg *Config) {
 
 	logger.Info().Str("addr", addr).Msg("Listening for peers.")
 
-	keys, err := keys(cfg.Wallet)
+	var keys *skademlia.Keypair
+	var signer wallet.Signer
+
+	if cfg.Mode == wavelet.ModeObserver {
+		keys, err = ephemeralKeys()
+	} else if keys, err = loadKeys(cfg); err == nil {
+		signer, err = loadVaultSigner(cfg)
+	}
+
 	if err != nil {
 		panic(err)
 	}
@@ -273,7 +780,7 @@ func start(cfg *Config) {
 		addr, keys,
 		skademlia.WithC1(sys.SKademliaC1),
 		skademlia.WithC2(sys.SKademliaC2),
-		skademlia.WithDialOptions(grpc.WithDefaultCallOptions(grpc.UseCompressor(snappy.Name))),
+		skademlia.WithDialOptions(append(wavelet.VersionDialOptions(), grpc.WithDefaultCallOptions(grpc.UseCompressor(snappy.Name)))...),
 	)
 
 	client.SetCredentials(noise.NewCredentials(addr, handshake.NewECDH(), cipher.NewAEAD(), client.Protocol()))
@@ -299,12 +806,66 @@ func start(cfg *Config) {
 			Msg("Peer has left.")
 	})
 
-	kv, err := store.NewLevelDB(cfg.Database)
+	db, err := store.Open(cfg.Backend, cfg.Database)
 	if err != nil {
-		logger.Fatal().Err(err).Msgf("Failed to create/open database located at %q.", cfg.Database)
+		logger.Fatal().Err(err).Msgf("Failed to create/open %s database located at %q.", cfg.Backend, cfg.Database)
+	}
+
+	var kv store.KV = db
+
+	if len(cfg.Namespace) > 0 {
+		if kv, err = store.NewPrefixed(db, cfg.Namespace); err != nil {
+			logger.Fatal().Err(err).Msgf("Failed to namespace the database located at %q.", cfg.Database)
+		}
+	}
+
+	opts := []wavelet.LedgerOption{
+		wavelet.WithAlerts(cfg.Alerts),
+		wavelet.WithWatchdog(cfg.Watchdog),
+		wavelet.WithTimeouts(cfg.Timeouts),
+		wavelet.WithBroadcast(cfg.Broadcast),
+		wavelet.WithOrphans(cfg.Orphans),
+		wavelet.WithStateCache(cfg.State),
+		wavelet.WithMaxGraphSize(cfg.MaxGraphSize),
+		wavelet.WithNopIdleCutoff(cfg.NopIdleCutoff),
+		wavelet.WithMode(cfg.Mode),
+		wavelet.WithWeighting(cfg.Weighting),
+		wavelet.WithConfig(cfg.Sys),
+	}
+
+	if signer != nil {
+		opts = append(opts, wavelet.WithSigner(signer))
+	}
+
+	if cfg.ParentSelector != nil {
+		opts = append(opts, wavelet.WithParentSelector(cfg.ParentSelector))
+	}
+
+	if cfg.PeerSampler != nil {
+		opts = append(opts, wavelet.WithPeerSampler(cfg.PeerSampler))
+	}
+
+	if cfg.Archival {
+		opts = append(opts, wavelet.WithArchival(kv))
 	}
 
-	ledger := wavelet.NewLedger(kv, client, cfg.Genesis)
+	if len(cfg.Bootstrap) > 0 {
+		dir := cfg.Database
+		if len(dir) == 0 {
+			dir = os.TempDir()
+		}
+
+		trusted, err := wavelet.ParseRoundID(cfg.BootstrapRound)
+		if err != nil {
+			logger.Warn().Err(err).Msg("The ID of the round the state snapshot must have been taken at must be specified with --bootstrap.round. Syncing from peers instead.")
+		} else if snapshot, err := downloadStateSnapshot(cfg.Bootstrap, cfg.BootstrapToken, dir); err != nil {
+			logger.Warn().Err(err).Msgf("Failed to download the state snapshot located at %q. Syncing from peers instead.", cfg.Bootstrap)
+		} else {
+			opts = append(opts, wavelet.WithStateSnapshot(snapshot, trusted))
+		}
+	}
+
+	ledger := wavelet.NewLedger(kv, client, cfg.Genesis, opts...)
 
 	go func() {
 		server := client.Listen()
@@ -333,81 +894,141 @@ func start(cfg *Config) {
 	}
 
 	if cfg.APIPort > 0 {
-		go api.New().StartHTTP(int(cfg.APIPort), client, ledger, keys)
+		var opts []api.GatewayOption
+
+		if cfg.APISign {
+			opts = append(opts, api.WithSignedResponses())
+		}
+
+		if len(cfg.APIAdmin) > 0 {
+			opts = append(opts, api.WithAdminToken(cfg.APIAdmin))
+		}
+
+		if len(cfg.APISnapshot) > 0 {
+			opts = append(opts, api.WithStateSnapshots(cfg.APISnapshot))
+		}
+
+		if cfg.APIWebsocketAuth {
+			if len(cfg.APIAdmin) == 0 {
+				logger.Fatal().Msg("Websocket authentication requires an admin token, as tokens are issued through the admin endpoints of the HTTP API.")
+			}
+
+			opts = append(opts, api.WithWebsocketAuth(30*time.Second))
+		}
+
+		if cfg.APIWebsocketConns > 0 || cfg.APIWebsocketSubs > 0 {
+			opts = append(opts, api.WithWebsocketLimits(cfg.APIWebsocketConns, cfg.APIWebsocketSubs))
+		}
+
+		opts = append(opts, api.WithCompressionLevel(cfg.APILevel))
+
+		go api.New(opts...).StartHTTP(int(cfg.APIPort), client, ledger, ledger.Signer())
 	}
 
-	shell, err := NewCLI(client, ledger, keys)
+	// Zero the private key of the node once it shuts down, be it by the shell
+	// being exited or by the node being signalled to stop.
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		<-signals
+
+		ledger.Close()
+
+		wallet.Destroy(keys)
+		os.Exit(0)
+	}()
+
+	shell, err := NewCLI(client, ledger, ledger.Signer())
 	if err != nil {
 		panic(err)
 	}
 
 	shell.Start()
-}
 
-func keys(wallet string) (*skademlia.Keypair, error) {
-	var keys *skademlia.Keypair
+	ledger.Close()
 
-	logger := log.Node()
+	wallet.Destroy(keys)
+}
+
+// loadGenesisCSV converts the CSV file located at path into genesis JSON, after
+// validating it against the expected total supply.
+func loadGenesisCSV(path string, supply uint64) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open genesis csv located at %q: %v", path, err)
+	}
 
-	privateKeyBuf, err := ioutil.ReadFile(wallet)
+	defer func() {
+		_ = file.Close()
+	}()
 
-	if err == nil {
-		var privateKey edwards25519.PrivateKey
+	return wavelet.GenesisFromCSV(file, supply)
+}
 
-		n, err := hex.Decode(privateKey[:], privateKeyBuf)
-		if err != nil {
-			return nil, fmt.Errorf("failed to decode your private key from %q", wallet)
-		}
+// ephemeralKeys generates a throwaway key pair which observers solely use to
+// authenticate themselves to their peers. The key pair is never persisted.
+func ephemeralKeys() (*skademlia.Keypair, error) {
+	keys, err := skademlia.NewKeys(sys.SKademliaC1, sys.SKademliaC2)
+	if err != nil {
+		return nil, errors.New("failed to generate an ephemeral key pair")
+	}
 
-		if n != edwards25519.SizePrivateKey {
-			return nil, fmt.Errorf("private key located in %q is not of the right length", wallet)
-		}
+	publicKey := keys.PublicKey()
 
-		keys, err = skademlia.LoadKeys(privateKey, sys.SKademliaC1, sys.SKademliaC2)
-		if err != nil {
-			return nil, fmt.Errorf("the private key specified in %q is invalid", wallet)
-		}
+	logger := log.Node()
+	logger.Info().
+		Hex("publicKey", publicKey[:]).
+		Msg("Running as an observer: no wallet has been loaded.")
 
-		publicKey := keys.PublicKey()
+	return keys, nil
+}
 
-		logger.Info().
-			Hex("privateKey", privateKey[:]).
-			Hex("publicKey", publicKey[:]).
-			Msg("Wallet loaded.")
+// walletSource picks where the private key of the wallet of the node is loaded
+// from: the WAVELET_PRIVATE_KEY environment variable should it be set, a
+// keystore file should one be specified, or otherwise a wallet file. No source
+// is returned should the wallet file not exist.
+func walletSource(cfg *Config) (wallet.Source, error) {
+	path := cfg.Wallet
 
-		return keys, nil
+	if _, set := os.LookupEnv(wallet.EnvPrivateKey); set {
+		return wallet.Env(wallet.EnvPrivateKey), nil
 	}
 
-	if os.IsNotExist(err) {
-		// If a private key is specified instead of a path to a wallet, then simply use the provided private key instead.
-		if len(wallet) == hex.EncodedLen(edwards25519.SizePrivateKey) {
-			var privateKey edwards25519.PrivateKey
+	if len(cfg.Keystore) > 0 {
+		passphrase := wallet.PassphraseFunc(func() ([]byte, error) {
+			return readPassphrase(false)
+		})
 
-			n, err := hex.Decode(privateKey[:], []byte(wallet))
-			if err != nil {
-				return nil, fmt.Errorf("failed to decode the private key specified: %s", wallet)
-			}
+		if len(cfg.KeystorePassphrase) > 0 {
+			passphrase = wallet.PassphraseFile(cfg.KeystorePassphrase)
+		}
 
-			if n != edwards25519.SizePrivateKey {
-				return nil, fmt.Errorf("private key %s is not of the right length", wallet)
-			}
+		return wallet.Keystore(cfg.Keystore, cfg.KeystoreAccount, passphrase), nil
+	}
 
-			keys, err = skademlia.LoadKeys(privateKey, sys.SKademliaC1, sys.SKademliaC2)
-			if err != nil {
-				return nil, fmt.Errorf("the private key specified is invalid: %s", wallet)
-			}
+	if wallet.LooksLikePrivateKey(path) {
+		return nil, fmt.Errorf("private keys may not be specified in place of a wallet file, as they leak into shell histories and process listings: specify it through the %s environment variable instead", wallet.EnvPrivateKey)
+	}
 
-			publicKey := keys.PublicKey()
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil, nil
+	}
 
-			logger.Info().
-				Hex("privateKey", privateKey[:]).
-				Hex("publicKey", publicKey[:]).
-				Msg("A private key was provided instead of a wallet file.")
+	return wallet.File(path), nil
+}
 
-			return keys, nil
-		}
+func loadKeys(cfg *Config) (*skademlia.Keypair, error) {
+	logger := log.Node()
+
+	source, err := walletSource(cfg)
+	if err != nil {
+		return nil, err
+	}
 
-		keys, err = skademlia.NewKeys(sys.SKademliaC1, sys.SKademliaC2)
+	if source == nil {
+		keys, err := skademlia.NewKeys(sys.SKademliaC1, sys.SKademliaC2)
 		if err != nil {
 			return nil, errors.New("failed to generate a new wallet")
 		}
@@ -423,5 +1044,48 @@ func keys(wallet string) (*skademlia.Keypair, error) {
 		return keys, nil
 	}
 
-	return keys, err
+	privateKey, err := source.PrivateKey()
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := skademlia.LoadKeys(privateKey, sys.SKademliaC1, sys.SKademliaC2)
+	wallet.Zero(privateKey[:])
+
+	if err != nil {
+		return nil, fmt.Errorf("the private key loaded from %s is invalid", source)
+	}
+
+	publicKey := keys.PublicKey()
+
+	logger.Info().
+		Str("source", source.String()).
+		Hex("publicKey", publicKey[:]).
+		Msg("Wallet loaded.")
+
+	return keys, nil
+}
+
+// loadVaultSigner has the transactions of the node be signed through a Vault
+// transit key should one be specified, such that the private key of the wallet
+// of the node never leaves Vault. No signer is returned otherwise.
+func loadVaultSigner(cfg *Config) (wallet.Signer, error) {
+	if len(cfg.WalletVault) == 0 {
+		return nil, nil
+	}
+
+	signer, err := wallet.Vault(cfg.WalletVault, os.Getenv(wallet.EnvVaultToken))
+	if err != nil {
+		return nil, err
+	}
+
+	publicKey := signer.PublicKey()
+
+	logger := log.Node()
+	logger.Info().
+		Str("key", cfg.WalletVault).
+		Hex("publicKey", publicKey[:]).
+		Msg("Transactions will be signed through Vault.")
+
+	return signer, nil
 }
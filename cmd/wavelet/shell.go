@@ -34,6 +34,7 @@ import (
 	"github.com/perlin-network/wavelet"
 	"github.com/perlin-network/wavelet/log"
 	"github.com/perlin-network/wavelet/sys"
+	"github.com/perlin-network/wavelet/wallet"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
 )
@@ -43,11 +44,11 @@ type CLI struct {
 	client *skademlia.Client
 	ledger *wavelet.Ledger
 	logger zerolog.Logger
-	keys   *skademlia.Keypair
+	signer wallet.Signer
 	tree   string
 }
 
-func NewCLI(client *skademlia.Client, ledger *wavelet.Ledger, keys *skademlia.Keypair) (*CLI, error) {
+func NewCLI(client *skademlia.Client, ledger *wavelet.Ledger, signer wallet.Signer) (*CLI, error) {
 	completer := readline.NewPrefixCompleter(
 		readline.PcItem("l"), readline.PcItem("status"),
 		readline.PcItem("p"), readline.PcItem("pay"),
@@ -57,6 +58,9 @@ func NewCLI(client *skademlia.Client, ledger *wavelet.Ledger, keys *skademlia.Ke
 		readline.PcItem("ps"), readline.PcItem("place-stake"),
 		readline.PcItem("ws"), readline.PcItem("withdraw-stake"),
 		readline.PcItem("wr"), readline.PcItem("withdraw-reward"),
+		readline.PcItem("resume"),
+		readline.PcItem("connect"), readline.PcItem("disconnect"),
+		readline.PcItem("ban"), readline.PcItem("unban"), readline.PcItem("banned"),
 		readline.PcItem("help"),
 	)
 
@@ -82,7 +86,7 @@ func NewCLI(client *skademlia.Client, ledger *wavelet.Ledger, keys *skademlia.Ke
 		ledger: ledger,
 		logger: log.Node(),
 		tree:   completer.Tree("    "),
-		keys:   keys,
+		signer: signer,
 	}, nil
 }
 
@@ -144,6 +148,18 @@ func (cli *CLI) Start() {
 			cli.withdrawReward(toCMD(line, 3))
 		case strings.HasPrefix(line, "withdraw-reward "):
 			cli.withdrawReward(toCMD(line, 16))
+		case line == "resume":
+			cli.resume()
+		case strings.HasPrefix(line, "connect "):
+			cli.connect(toCMD(line, 8))
+		case strings.HasPrefix(line, "disconnect "):
+			cli.disconnect(toCMD(line, 11))
+		case strings.HasPrefix(line, "ban "):
+			cli.ban(toCMD(line, 4))
+		case strings.HasPrefix(line, "unban "):
+			cli.unban(toCMD(line, 6))
+		case line == "banned":
+			cli.banned()
 		case line == "":
 			fallthrough
 		case line == "help":
@@ -169,7 +185,7 @@ func (cli *CLI) status() {
 	count := cli.ledger.Finalizer().Progress()
 
 	snapshot := cli.ledger.Snapshot()
-	publicKey := cli.keys.PublicKey()
+	publicKey := cli.signer.PublicKey()
 
 	accountsLen := wavelet.ReadAccountsLen(snapshot)
 
@@ -189,11 +205,12 @@ func (cli *CLI) status() {
 	}
 
 	cli.logger.Info().
-		Uint8("difficulty", round.ExpectedDifficulty(sys.MinDifficulty, sys.DifficultyScaleFactor)).
+		Uint8("difficulty", cli.ledger.Difficulty(round)).
 		Uint64("round", round.Index).
 		Hex("root_id", round.End.ID[:]).
 		Uint64("height", cli.ledger.Graph().Height()).
 		Str("id", hex.EncodeToString(publicKey[:])).
+		Str("address", wavelet.FormatAddress(publicKey)).
 		Uint64("balance", balance).
 		Uint64("stake", stake).
 		Uint64("reward", reward).
@@ -201,6 +218,7 @@ func (cli *CLI) status() {
 		Strs("peers", peerIDs).
 		Int("num_tx", cli.ledger.Graph().DepthLen(&rootDepth, nil)).
 		Int("num_missing_tx", cli.ledger.Graph().MissingLen()).
+		Dur("missing_oldest_age", cli.ledger.Graph().MissingStats().OldestAge).
 		Int("num_tx_in_store", cli.ledger.Graph().Len()).
 		Uint64("num_accounts_in_store", accountsLen).
 		Str("preferred_id", preferredID).
@@ -214,17 +232,12 @@ func (cli *CLI) pay(cmd []string) {
 		return
 	}
 
-	recipient, err := hex.DecodeString(cmd[0])
+	recipient, err := wavelet.ParseAccountID(cmd[0])
 	if err != nil {
 		cli.logger.Error().Err(err).Msg("The recipient you specified is invalid.")
 		return
 	}
 
-	if len(recipient) != wavelet.SizeAccountID {
-		cli.logger.Error().Int("length", len(recipient)).Msg("You have specified an invalid account ID to find.")
-		return
-	}
-
 	amount, err := strconv.ParseUint(cmd[1], 10, 64)
 	if err != nil {
 		cli.logger.Error().Err(err).Msg("Failed to convert payment amount to a uint64.")
@@ -233,13 +246,11 @@ func (cli *CLI) pay(cmd []string) {
 
 	snapshot := cli.ledger.Snapshot()
 
-	var recipientID wavelet.AccountID
-	copy(recipientID[:], recipient)
-
-	balance, _ := wavelet.ReadAccountBalance(snapshot, cli.keys.PublicKey())
-	_, codeAvailable := wavelet.ReadAccountContractCode(snapshot, recipientID)
+	balance, _ := wavelet.ReadAccountBalance(snapshot, cli.signer.PublicKey())
+	_, codeAvailable := wavelet.ReadAccountContractCode(snapshot, recipient)
+	fee := cli.ledger.Param(sys.ParamTransactionFeeAmount)
 
-	if balance < amount+sys.TransactionFeeAmount {
+	if balance < amount+fee {
 		cli.logger.Error().Uint64("your_balance", balance).Uint64("amount_to_send", amount).Msg("You do not have enough PERLs to send.")
 		return
 	}
@@ -252,7 +263,7 @@ func (cli *CLI) pay(cmd []string) {
 	payload.Write(intBuf[:])
 
 	if codeAvailable {
-		binary.LittleEndian.PutUint64(intBuf[:], balance-sys.TransactionFeeAmount) // Set gas limit by default to the balance the user has.
+		binary.LittleEndian.PutUint64(intBuf[:], balance-fee) // Set gas limit by default to the balance the user has.
 		payload.Write(intBuf[:])
 
 		defaultFuncName := "on_money_received"
@@ -262,7 +273,7 @@ func (cli *CLI) pay(cmd []string) {
 		payload.WriteString(defaultFuncName)
 	}
 
-	tx, err := cli.sendTransaction(wavelet.NewTransaction(cli.keys, sys.TagTransfer, payload.Bytes()))
+	tx, err := cli.sendTransaction(sys.TagTransfer, payload.Bytes())
 	if err != nil {
 		return
 	}
@@ -276,23 +287,15 @@ func (cli *CLI) call(cmd []string) {
 		return
 	}
 
-	recipient, err := hex.DecodeString(cmd[0])
+	recipientID, err := wavelet.ParseAccountID(cmd[0])
 	if err != nil {
 		cli.logger.Error().Err(err).Msg("The smart contract address you specified is invalid.")
 		return
 	}
 
-	if len(recipient) != wavelet.SizeAccountID {
-		cli.logger.Error().Int("length", len(recipient)).Msg("You have specified an invalid account ID to find.")
-		return
-	}
-
-	var recipientID wavelet.AccountID
-	copy(recipientID[:], recipient)
-
 	snapshot := cli.ledger.Snapshot()
 
-	balance, _ := wavelet.ReadAccountBalance(snapshot, cli.keys.PublicKey())
+	balance, _ := wavelet.ReadAccountBalance(snapshot, cli.signer.PublicKey())
 	_, codeAvailable := wavelet.ReadAccountContractCode(snapshot, recipientID)
 
 	if !codeAvailable {
@@ -324,7 +327,7 @@ func (cli *CLI) call(cmd []string) {
 	payload := bytes.NewBuffer(nil)
 
 	// Recipient address 32 bytes.
-	payload.Write(recipient[:])
+	payload.Write(recipientID[:])
 
 	// Amount to send.
 	binary.LittleEndian.PutUint64(intBuf[:8], amount)
@@ -394,7 +397,7 @@ func (cli *CLI) call(cmd []string) {
 	payload.Write(intBuf[:4])
 	payload.Write(funcParams)
 
-	tx, err := cli.sendTransaction(wavelet.NewTransaction(cli.keys, sys.TagTransfer, payload.Bytes()))
+	tx, err := cli.sendTransaction(sys.TagTransfer, payload.Bytes())
 	if err != nil {
 		return
 	}
@@ -414,8 +417,15 @@ func (cli *CLI) find(cmd []string) {
 
 	buf, err := hex.DecodeString(address)
 	if err != nil {
-		cli.logger.Error().Err(err).Msg("Cannot decode address")
-		return
+		// Otherwise, the address may be the bech32 address of an account.
+
+		id, err := wavelet.ParseAccountID(address)
+		if err != nil {
+			cli.logger.Error().Err(err).Msg("Cannot decode address")
+			return
+		}
+
+		buf = id[:]
 	}
 
 	if len(buf) != wavelet.SizeTransactionID && len(buf) != wavelet.SizeAccountID {
@@ -500,9 +510,7 @@ func (cli *CLI) spawn(cmd []string) {
 
 	w.Write(code) // Smart contract code.
 
-	tx := wavelet.NewTransaction(cli.keys, sys.TagContract, w.Bytes())
-
-	tx, err = cli.sendTransaction(tx)
+	tx, err := cli.sendTransaction(sys.TagContract, w.Bytes())
 	if err != nil {
 		return
 	}
@@ -528,7 +536,7 @@ func (cli *CLI) placeStake(cmd []string) {
 	binary.LittleEndian.PutUint64(intBuf[:8], uint64(amount))
 	payload.Write(intBuf[:8])
 
-	tx, err := cli.sendTransaction(wavelet.NewTransaction(cli.keys, sys.TagStake, payload.Bytes()))
+	tx, err := cli.sendTransaction(sys.TagStake, payload.Bytes())
 	if err != nil {
 		return
 	}
@@ -555,7 +563,7 @@ func (cli *CLI) withdrawStake(cmd []string) {
 	binary.LittleEndian.PutUint64(intBuf[:8], uint64(amount))
 	payload.Write(intBuf[:8])
 
-	tx, err := cli.sendTransaction(wavelet.NewTransaction(cli.keys, sys.TagStake, payload.Bytes()))
+	tx, err := cli.sendTransaction(sys.TagStake, payload.Bytes())
 	if err != nil {
 		return
 	}
@@ -582,7 +590,7 @@ func (cli *CLI) withdrawReward(cmd []string) {
 	binary.LittleEndian.PutUint64(intBuf[:8], uint64(amount))
 	payload.Write(intBuf[:8])
 
-	tx, err := cli.sendTransaction(wavelet.NewTransaction(cli.keys, sys.TagStake, payload.Bytes()))
+	tx, err := cli.sendTransaction(sys.TagStake, payload.Bytes())
 	if err != nil {
 		return
 	}
@@ -591,8 +599,117 @@ func (cli *CLI) withdrawReward(cmd []string) {
 		Msgf("Success! Your reward withdrawal transaction ID: %x", tx.ID)
 }
 
-func (cli *CLI) sendTransaction(tx wavelet.Transaction) (wavelet.Transaction, error) {
-	tx = wavelet.AttachSenderToTransaction(cli.keys, tx, cli.ledger.Graph().FindEligibleParents()...)
+func (cli *CLI) resume() {
+	if !cli.ledger.Halted() {
+		cli.logger.Info().Msg("Your node is not halted.")
+		return
+	}
+
+	if err := cli.ledger.Resume(); err != nil {
+		cli.logger.Error().Err(err).Msg("Failed to resume your node.")
+		return
+	}
+
+	cli.logger.Info().Msg("Resumed committing changes to your nodes state.")
+}
+
+func (cli *CLI) connect(cmd []string) {
+	if len(cmd) != 1 {
+		cli.logger.Error().Msg("Usage: connect <address>")
+		return
+	}
+
+	if err := cli.ledger.ConnectPeer(cmd[0]); err != nil {
+		cli.logger.Error().Err(err).Msg("Failed to connect to peer.")
+		return
+	}
+
+	cli.logger.Info().Msgf("Connected to peer at %s.", cmd[0])
+}
+
+func (cli *CLI) disconnect(cmd []string) {
+	if len(cmd) != 1 {
+		cli.logger.Error().Msg("Usage: disconnect <address>")
+		return
+	}
+
+	if err := cli.ledger.DisconnectPeer(cmd[0]); err != nil {
+		cli.logger.Error().Err(err).Msg("Failed to disconnect from peer.")
+		return
+	}
+
+	cli.logger.Info().Msgf("Disconnected from peer at %s.", cmd[0])
+}
+
+func (cli *CLI) parsePeerPublicKey(cmd []string, usage string) (wavelet.AccountID, bool) {
+	var id wavelet.AccountID
+
+	if len(cmd) != 1 {
+		cli.logger.Error().Msg(usage)
+		return id, false
+	}
+
+	buf, err := hex.DecodeString(cmd[0])
+	if err != nil || len(buf) != wavelet.SizeAccountID {
+		cli.logger.Error().Msgf("The public key of the peer must be %d bytes long and hex-encoded.", wavelet.SizeAccountID)
+		return id, false
+	}
+
+	copy(id[:], buf)
+
+	return id, true
+}
+
+func (cli *CLI) ban(cmd []string) {
+	id, ok := cli.parsePeerPublicKey(cmd, "Usage: ban <public key>")
+	if !ok {
+		return
+	}
+
+	cli.ledger.BanPeer(id)
+
+	cli.logger.Info().Msgf("Banned peer %x.", id)
+}
+
+func (cli *CLI) unban(cmd []string) {
+	id, ok := cli.parsePeerPublicKey(cmd, "Usage: unban <public key>")
+	if !ok {
+		return
+	}
+
+	if !cli.ledger.UnbanPeer(id) {
+		cli.logger.Error().Msgf("Peer %x is not banned.", id)
+		return
+	}
+
+	cli.logger.Info().Msgf("Unbanned peer %x.", id)
+}
+
+func (cli *CLI) banned() {
+	banned := cli.ledger.Peers().Banned()
+
+	if len(banned) == 0 {
+		cli.logger.Info().Msg("No peers are banned.")
+		return
+	}
+
+	for _, id := range banned {
+		cli.logger.Info().Msgf("Banned peer: %x", id)
+	}
+}
+
+// sendTransaction creates a transaction which the signer of the node signs, and
+// adds it to the graph.
+func (cli *CLI) sendTransaction(tag sys.Tag, payload []byte) (wavelet.Transaction, error) {
+	tx, err := wavelet.SignTransaction(cli.signer, cli.ledger.NextNonce(cli.signer.PublicKey()), tag, payload)
+	if err == nil {
+		tx, err = wavelet.AttachSignerToTransaction(cli.signer, tx, cli.ledger.Graph().FindEligibleParents()...)
+	}
+
+	if err != nil {
+		cli.logger.Error().Err(err).Msg("Failed to sign your transaction.")
+		return tx, err
+	}
 
 	if err := cli.ledger.AddTransaction(tx); err != nil && errors.Cause(err) != wavelet.ErrMissingParents {
 		cli.logger.
@@ -0,0 +1,158 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/perlin-network/noise/skademlia"
+	"github.com/perlin-network/wavelet"
+	"github.com/perlin-network/wavelet/sys"
+	"github.com/perlin-network/wavelet/wallet"
+	"github.com/pkg/errors"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var messageFileFlag = cli.StringFlag{
+	Name:  "file",
+	Usage: "Path to a file holding the message, used instead of the message given as an argument.",
+}
+
+// signCommand signs arbitrary messages with the wallet specified by --wallet.
+func signCommand() cli.Command {
+	return cli.Command{
+		Name:      "sign",
+		Usage:     "Sign a message with the wallet specified by --wallet to prove ownership of its account.",
+		ArgsUsage: "[message]",
+		Flags:     []cli.Flag{messageFileFlag},
+		Action:    signMessage,
+	}
+}
+
+// verifyCommand verifies signatures of messages produced by signCommand.
+func verifyCommand() cli.Command {
+	return cli.Command{
+		Name:      "verify",
+		Usage:     "Verify that a message was signed by the owner of an account.",
+		ArgsUsage: "[message]",
+		Flags: []cli.Flag{
+			messageFileFlag,
+			cli.StringFlag{
+				Name:  "account",
+				Usage: "Hex-encoded ID of the account which supposedly signed the message.",
+			},
+			cli.StringFlag{
+				Name:  "signature",
+				Usage: "Hex-encoded signature of the message.",
+			},
+		},
+		Action: verifyMessage,
+	}
+}
+
+func readMessage(c *cli.Context) ([]byte, error) {
+	if path := c.String("file"); len(path) > 0 {
+		message, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read message from %q", path)
+		}
+
+		return message, nil
+	}
+
+	if c.NArg() != 1 {
+		return nil, errors.New("exactly one message must be given, either as an argument or via --file")
+	}
+
+	return []byte(c.Args().First()), nil
+}
+
+// readWallet loads the keys of an existing wallet from a file holding a
+// hex-encoded private key. Unlike loadKeys, a new wallet is never generated.
+func readWallet(path string) (*skademlia.Keypair, error) {
+	if wallet.LooksLikePrivateKey(path) {
+		return nil, errors.New("private keys may not be specified in place of a wallet file, as they leak into shell histories and process listings")
+	}
+
+	privateKey, err := wallet.File(path).PrivateKey()
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := skademlia.LoadKeys(privateKey, sys.SKademliaC1, sys.SKademliaC2)
+	if err != nil {
+		return nil, errors.Wrap(err, "the private key held by the wallet is invalid")
+	}
+
+	return keys, nil
+}
+
+func signMessage(c *cli.Context) error {
+	message, err := readMessage(c)
+	if err != nil {
+		return err
+	}
+
+	keys, err := readWallet(c.GlobalString("wallet"))
+	if err != nil {
+		return err
+	}
+
+	account := keys.PublicKey()
+	signature := wavelet.SignMessage(keys, message)
+
+	fmt.Printf("account:   %x\n", account)
+	fmt.Printf("signature: %x\n", signature)
+
+	return nil
+}
+
+func verifyMessage(c *cli.Context) error {
+	message, err := readMessage(c)
+	if err != nil {
+		return err
+	}
+
+	var account wavelet.AccountID
+
+	if buf, err := hex.DecodeString(c.String("account")); err != nil || len(buf) != wavelet.SizeAccountID {
+		return errors.Errorf("--account must be a hex-encoded account ID of %d bytes", wavelet.SizeAccountID)
+	} else {
+		copy(account[:], buf)
+	}
+
+	var signature wavelet.Signature
+
+	if buf, err := hex.DecodeString(c.String("signature")); err != nil || len(buf) != wavelet.SizeSignature {
+		return errors.Errorf("--signature must be a hex-encoded signature of %d bytes", wavelet.SizeSignature)
+	} else {
+		copy(signature[:], buf)
+	}
+
+	if !wavelet.VerifyMessage(account, message, signature) {
+		return errors.Errorf("signature was not produced by %x for the message given", account)
+	}
+
+	fmt.Printf("signature is valid: message was signed by %x\n", account)
+
+	return nil
+}
@@ -0,0 +1,229 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/perlin-network/wavelet"
+	"gopkg.in/urfave/cli.v1"
+)
+
+// snapshotCommand exports and imports the state of a stopped nodes database as
+// a full state snapshot.
+func snapshotCommand() cli.Command {
+	return cli.Command{
+		Name:  "snapshot",
+		Usage: "Export or import the full state of the database specified by --db. The node must not be running.",
+		Subcommands: []cli.Command{
+			{
+				Name:      "export",
+				Usage:     "Export the full state of the accounts tree as of the latest finalized round into a file.",
+				ArgsUsage: "<file>",
+				Action:    exportStateSnapshot,
+			},
+			{
+				Name:      "import",
+				Usage:     "Replace the state of the database with the state snapshot in a file, after verifying that it was taken at the round with the ID specified, and against the merkle root of that round. The node resumes from that round once started.",
+				ArgsUsage: "<file> <round id>",
+				Action:    importStateSnapshot,
+			},
+		},
+	}
+}
+
+func exportStateSnapshot(c *cli.Context) error {
+	path := c.Args().First()
+	if len(path) == 0 {
+		return fmt.Errorf("the file to export the state snapshot into must be specified")
+	}
+
+	kv, closer, err := openDatabase(c)
+	if err != nil {
+		return err
+	}
+
+	defer closer()
+
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create state snapshot file: %v", err)
+	}
+
+	defer file.Close()
+
+	w := bufio.NewWriter(file)
+
+	round, err := wavelet.ExportState(kv, w)
+	if err != nil {
+		return err
+	}
+
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("failed to write state snapshot file: %v", err)
+	}
+
+	fmt.Printf("Exported the state as of round %d with ID %x and merkle root %x into %q.\n", round.Index, round.ID, round.Merkle, path)
+
+	return nil
+}
+
+func importStateSnapshot(c *cli.Context) error {
+	path := c.Args().First()
+	if len(path) == 0 {
+		return fmt.Errorf("the file to import the state snapshot from must be specified")
+	}
+
+	trusted, err := wavelet.ParseRoundID(c.Args().Get(1))
+	if err != nil {
+		return fmt.Errorf("the ID of the round the state snapshot must have been taken at must be specified: %v", err)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open state snapshot file: %v", err)
+	}
+
+	defer file.Close()
+
+	kv, closer, err := openDatabase(c)
+	if err != nil {
+		return err
+	}
+
+	defer closer()
+
+	round, err := wavelet.ImportState(kv, bufio.NewReader(file), trusted)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Imported the state as of round %d with merkle root %x from %q.\n", round.Index, round.Merkle, path)
+
+	return nil
+}
+
+// maxSnapshotDownloadAttempts is how many times downloading a state snapshot
+// is resumed after being interrupted before giving up.
+const maxSnapshotDownloadAttempts = 5
+
+// downloadStateSnapshot downloads the state snapshot served at url into dir,
+// authenticating with token. Interrupted downloads are resumed from where they
+// left off, including across restarts, so long as the state snapshot being
+// served has not changed since.
+func downloadStateSnapshot(url, token, dir string) (wavelet.StateSnapshot, error) {
+	path := filepath.Join(dir, "snapshot.partial")
+	tagPath := path + ".etag"
+
+	var err error
+
+	for attempt := 0; attempt < maxSnapshotDownloadAttempts; attempt++ {
+		if err = resumeStateSnapshotDownload(url, token, path, tagPath); err == nil {
+			break
+		}
+	}
+
+	if err != nil {
+		return wavelet.StateSnapshot{}, err
+	}
+
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return wavelet.StateSnapshot{}, fmt.Errorf("failed to read downloaded state snapshot: %v", err)
+	}
+
+	snapshot, err := wavelet.UnmarshalStateSnapshot(bytes.NewReader(buf))
+	if err != nil {
+		return wavelet.StateSnapshot{}, err
+	}
+
+	_ = os.Remove(path)
+	_ = os.Remove(tagPath)
+
+	return snapshot, nil
+}
+
+func resumeStateSnapshotDownload(url, token, path, tagPath string) error {
+	var offset int64
+
+	if info, err := os.Stat(path); err == nil {
+		offset = info.Size()
+	}
+
+	tag, _ := ioutil.ReadFile(tagPath)
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	if len(token) > 0 {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	if offset > 0 && len(tag) > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		req.Header.Set("If-Range", string(tag))
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to request state snapshot: %v", err)
+	}
+
+	defer res.Body.Close()
+
+	flags := os.O_CREATE | os.O_WRONLY
+
+	switch res.StatusCode {
+	case http.StatusPartialContent:
+		flags |= os.O_APPEND
+	case http.StatusOK:
+		flags |= os.O_TRUNC
+	case http.StatusRequestedRangeNotSatisfiable:
+		return nil // The state snapshot has already been downloaded in its entirety.
+	default:
+		return fmt.Errorf("failed to request state snapshot: got status %s", res.Status)
+	}
+
+	if err := ioutil.WriteFile(tagPath, []byte(res.Header.Get("ETag")), 0600); err != nil {
+		return fmt.Errorf("failed to record the version of the state snapshot: %v", err)
+	}
+
+	file, err := os.OpenFile(path, flags, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open state snapshot file: %v", err)
+	}
+
+	defer file.Close()
+
+	if _, err := io.Copy(file, res.Body); err != nil {
+		return fmt.Errorf("state snapshot download got interrupted: %v", err)
+	}
+
+	return nil
+}
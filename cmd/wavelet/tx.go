@@ -0,0 +1,247 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/perlin-network/wavelet"
+	"github.com/perlin-network/wavelet/sys"
+	"github.com/pkg/errors"
+	"gopkg.in/urfave/cli.v1"
+)
+
+// txCommand inspects transactions without requiring a running node.
+func txCommand() cli.Command {
+	return cli.Command{
+		Name:  "tx",
+		Usage: "Inspect transactions offline.",
+		Subcommands: []cli.Command{
+			{
+				Name:      "decode",
+				Usage:     "Decode hex-encoded transaction bytes, printing every field, the payload interpreted per its tag, and the results of validity checks. Reads from stdin if no argument is given.",
+				ArgsUsage: "[hex]",
+				Action:    decodeTransaction,
+			},
+		},
+	}
+}
+
+func decodeTransaction(c *cli.Context) error {
+	raw := c.Args().First()
+
+	if len(raw) == 0 {
+		buf, err := ioutil.ReadAll(os.Stdin)
+		if err != nil {
+			return errors.Wrap(err, "failed to read transaction from stdin")
+		}
+
+		raw = string(buf)
+	}
+
+	buf, err := hex.DecodeString(strings.TrimSpace(raw))
+	if err != nil {
+		return errors.Wrap(err, "transaction must be hex-encoded")
+	}
+
+	r := bytes.NewReader(buf)
+
+	tx, err := wavelet.UnmarshalTransaction(r)
+	if err != nil {
+		return errors.Wrap(err, "failed to decode transaction")
+	}
+
+	w := bufio.NewWriter(os.Stdout)
+
+	payloadErr := printTransaction(w, tx)
+
+	fmt.Fprintln(w, "checks:")
+
+	check := func(name string, err error) {
+		if err != nil {
+			fmt.Fprintf(w, "  %-11s FAIL: %v\n", name+":", err)
+		} else {
+			fmt.Fprintf(w, "  %-11s ok\n", name+":")
+		}
+	}
+
+	if r.Len() > 0 {
+		check("encoding", errors.Errorf("%d trailing bytes after transaction", r.Len()))
+	} else {
+		check("encoding", nil)
+	}
+
+	check("payload", payloadErr)
+	check("structure", wavelet.ValidateTransaction(tx, false))
+	check("signatures", wavelet.ValidateTransaction(tx, true))
+
+	return w.Flush()
+}
+
+// printTransaction prints every field of tx, followed by its payload
+// interpreted per its tag, returning an error should the payload be malformed.
+func printTransaction(w io.Writer, tx wavelet.Transaction) error {
+	fmt.Fprintf(w, "id:                %x\n", tx.ID)
+	fmt.Fprintf(w, "sender:            %x\n", tx.Sender)
+	fmt.Fprintf(w, "creator:           %x\n", tx.Creator)
+	fmt.Fprintf(w, "nonce:             %d\n", tx.Nonce)
+	fmt.Fprintf(w, "depth:             %d\n", tx.Depth)
+	fmt.Fprintf(w, "seed_len:          %d\n", tx.SeedLen)
+	fmt.Fprintf(w, "parents:           %d\n", len(tx.ParentIDs))
+
+	for _, parentID := range tx.ParentIDs {
+		fmt.Fprintf(w, "  - %x\n", parentID)
+	}
+
+	fmt.Fprintf(w, "sender_signature:  %x\n", tx.SenderSignature)
+
+	if tx.Creator != tx.Sender {
+		fmt.Fprintf(w, "creator_signature: %x\n", tx.CreatorSignature)
+		fmt.Fprintf(w, "creator_scheme:    %s\n", tx.CreatorScheme)
+	}
+
+	fmt.Fprintf(w, "tag:               %s (%d)\n", tagLabel(tx.Tag), tx.Tag)
+	fmt.Fprintf(w, "payload:           %d bytes\n", len(tx.Payload))
+
+	return printPayload(w, "  ", tx.Tag, tx.Payload)
+}
+
+func tagLabel(tag sys.Tag) string {
+	if label := tag.String(); len(label) > 0 {
+		return label
+	}
+
+	return "unknown"
+}
+
+// printPayload prints every field of payload interpreted as the payload of a
+// transaction with the given tag, returning an error should it be malformed.
+func printPayload(w io.Writer, indent string, tag sys.Tag, payload []byte) error {
+	switch tag {
+	case sys.TagNop:
+	case sys.TagTransfer:
+		params, err := wavelet.ParseTransferTransaction(payload)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(w, "%srecipient:   %x\n", indent, params.Recipient)
+		fmt.Fprintf(w, "%samount:      %d\n", indent, params.Amount)
+
+		if params.GasLimit > 0 {
+			fmt.Fprintf(w, "%sgas_limit:   %d\n", indent, params.GasLimit)
+		}
+
+		if len(params.FuncName) > 0 {
+			fmt.Fprintf(w, "%sfunc_name:   %s\n", indent, params.FuncName)
+			fmt.Fprintf(w, "%sfunc_params: %x\n", indent, params.FuncParams)
+		}
+	case sys.TagStake:
+		params, err := wavelet.ParseStakeTransaction(payload)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(w, "%sopcode: %s\n", indent, []string{"withdraw_stake", "place_stake", "withdraw_reward"}[params.Opcode])
+		fmt.Fprintf(w, "%samount: %d\n", indent, params.Amount)
+	case sys.TagContract:
+		params, err := wavelet.ParseContractTransaction(payload)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(w, "%sgas_limit: %d\n", indent, params.GasLimit)
+		fmt.Fprintf(w, "%sparams:    %x\n", indent, params.Params)
+		fmt.Fprintf(w, "%scode:      %d bytes\n", indent, len(params.Code))
+	case sys.TagBatch:
+		params, err := wavelet.ParseBatchTransaction(payload)
+		if err != nil {
+			return err
+		}
+
+		for i := uint8(0); i < params.Size; i++ {
+			tag := sys.Tag(params.Tags[i])
+
+			fmt.Fprintf(w, "%s- tag: %s (%d)\n", indent, tagLabel(tag), tag)
+
+			if err := printPayload(w, indent+"  ", tag, params.Payloads[i]); err != nil {
+				return errors.Wrapf(err, "entry %d of batch is malformed", i)
+			}
+		}
+	case sys.TagRecovery:
+		params, err := wavelet.ParseRecoveryTransaction(payload)
+		if err != nil {
+			return err
+		}
+
+		switch params.Opcode {
+		case sys.SetGuardians:
+			fmt.Fprintf(w, "%sopcode:    set_guardians\n", indent)
+			fmt.Fprintf(w, "%sthreshold: %d\n", indent, params.Threshold)
+			fmt.Fprintf(w, "%sdelay:     %d\n", indent, params.Delay)
+
+			for _, guardian := range params.Guardians {
+				fmt.Fprintf(w, "%s  - %x\n", indent, guardian)
+			}
+		case sys.ApproveRecovery:
+			fmt.Fprintf(w, "%sopcode:  approve_recovery\n", indent)
+			fmt.Fprintf(w, "%saccount: %x\n", indent, params.Account)
+			fmt.Fprintf(w, "%snew_key: %x\n", indent, params.NewKey)
+		case sys.CancelRecovery:
+			fmt.Fprintf(w, "%sopcode: cancel_recovery\n", indent)
+		case sys.ExecuteRecovery:
+			fmt.Fprintf(w, "%sopcode:  execute_recovery\n", indent)
+			fmt.Fprintf(w, "%saccount: %x\n", indent, params.Account)
+		}
+	case sys.TagGovernance:
+		params, err := wavelet.ParseGovernanceTransaction(payload)
+		if err != nil {
+			return err
+		}
+
+		switch params.Opcode {
+		case sys.ProposeParameter:
+			fmt.Fprintf(w, "%sopcode:           propose_parameter\n", indent)
+			fmt.Fprintf(w, "%sparam:            %s\n", indent, sys.ParamLabel(params.Param))
+			fmt.Fprintf(w, "%svalue:            %d\n", indent, params.Value)
+			fmt.Fprintf(w, "%sactivation_round: %d\n", indent, params.ActivationRound)
+		case sys.VoteParameter:
+			fmt.Fprintf(w, "%sopcode:      vote_parameter\n", indent)
+			fmt.Fprintf(w, "%sproposal_id: %x\n", indent, params.ProposalID)
+		case sys.FreezeAccount:
+			fmt.Fprintf(w, "%sopcode:  freeze_account\n", indent)
+			fmt.Fprintf(w, "%saccount: %x\n", indent, params.Account)
+		case sys.UnfreezeAccount:
+			fmt.Fprintf(w, "%sopcode:  unfreeze_account\n", indent)
+			fmt.Fprintf(w, "%saccount: %x\n", indent, params.Account)
+		}
+	default:
+		return errors.Errorf("unknown tag %d", tag)
+	}
+
+	return nil
+}
@@ -0,0 +1,133 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"time"
+)
+
+// sparkTicks are the glyphs used to draw a sparkline, from lowest to highest.
+var sparkTicks = []rune("▁▂▃▄▅▆▇█")
+
+// dashboardSeries describes a single metric plotted on the dashboard.
+type dashboardSeries struct {
+	label string
+	field string
+	unit  string
+}
+
+var dashboardPanes = []dashboardSeries{
+	{label: "TPS (accepted)", field: "tps.accepted", unit: "tx/s"},
+	{label: "TPS (received)", field: "tps.received", unit: "tx/s"},
+	{label: "Finality latency", field: "round.latency.mean.ms", unit: "ms"},
+	{label: "Query latency", field: "query.latency.mean.ms", unit: "ms"},
+	{label: "Broadcast queue", field: "broadcast.depth", unit: "tx"},
+	{label: "Peers", field: "peers.count", unit: ""},
+}
+
+// dashboard keeps a bounded history of samples for every pane, and renders
+// them as sparklines onto a terminal.
+type dashboard struct {
+	width   int
+	history map[string][]float64
+	updated time.Time
+}
+
+func newDashboard(width int) *dashboard {
+	return &dashboard{width: width, history: make(map[string][]float64)}
+}
+
+// push records a single metrics event emitted by the node's metrics sink.
+func (d *dashboard) push(buf []byte) error {
+	var ev map[string]interface{}
+
+	if err := json.Unmarshal(buf, &ev); err != nil {
+		return err
+	}
+
+	for _, pane := range dashboardPanes {
+		v, ok := ev[pane.field].(float64)
+		if !ok {
+			continue
+		}
+
+		samples := append(d.history[pane.field], v)
+		if len(samples) > d.width {
+			samples = samples[len(samples)-d.width:]
+		}
+
+		d.history[pane.field] = samples
+	}
+
+	d.updated = time.Now()
+
+	return nil
+}
+
+// render clears the terminal and redraws every pane.
+func (d *dashboard) render(w io.Writer) {
+	var b strings.Builder
+
+	b.WriteString("\033[H\033[2J")
+	fmt.Fprintf(&b, "wavelet metrics (updated %s)\n\n", d.updated.Format("15:04:05"))
+
+	for _, pane := range dashboardPanes {
+		samples := d.history[pane.field]
+
+		if len(samples) == 0 {
+			fmt.Fprintf(&b, "%-18s %s\n\n", pane.label, "no data")
+			continue
+		}
+
+		min, max := math.Inf(1), math.Inf(-1)
+
+		for _, v := range samples {
+			min, max = math.Min(min, v), math.Max(max, v)
+		}
+
+		fmt.Fprintf(&b, "%-18s %10.2f %-4s  min %.2f  max %.2f\n", pane.label, samples[len(samples)-1], pane.unit, min, max)
+		fmt.Fprintf(&b, "%-18s %s\n\n", "", sparkline(samples, min, max))
+	}
+
+	_, _ = io.WriteString(w, b.String())
+}
+
+// sparkline draws samples as a single line of block glyphs scaled between
+// min and max.
+func sparkline(samples []float64, min, max float64) string {
+	var b strings.Builder
+
+	for _, v := range samples {
+		i := 0
+
+		if max > min {
+			i = int((v - min) / (max - min) * float64(len(sparkTicks)-1))
+		}
+
+		b.WriteRune(sparkTicks[i])
+	}
+
+	return b.String()
+}
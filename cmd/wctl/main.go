@@ -28,10 +28,13 @@ import (
 	"os"
 	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/perlin-network/wavelet"
+	"github.com/perlin-network/wavelet/secp256k1"
 	"github.com/perlin-network/wavelet/sys"
+	"github.com/perlin-network/wavelet/wallet"
 	"github.com/perlin-network/wavelet/wctl"
 	"github.com/pkg/errors"
 	"github.com/urfave/cli"
@@ -65,12 +68,26 @@ func main() {
 			Usage: "Port a local HTTP API.",
 		},
 		cli.StringFlag{
-			Name:  "key",
-			Usage: "Private key hex-encoded",
+			Name:   "key",
+			Usage:  "No longer supported, as private keys specified on the command line leak into shell histories and process listings.",
+			Hidden: true,
 		},
 		cli.StringFlag{
 			Name:  "wallet",
-			Usage: "path to file containing hex-encoded private key",
+			Usage: "path to file containing hex-encoded private key. The private key may instead be specified through the WAVELET_PRIVATE_KEY environment variable",
+		},
+		cli.StringFlag{
+			Name:  "wallet.vault",
+			Usage: "sign transactions through an Ed25519 key of the HashiCorp Vault transit secrets engine, given as the URL of the key, such as https://vault:8200/v1/transit/keys/wavelet. The private key never leaves Vault. The Vault token is read from the VAULT_TOKEN environment variable",
+		},
+		cli.StringFlag{
+			Name:   "api.admin_token",
+			Usage:  "bearer token for the admin endpoints of the HTTP API, used to obtain tokens for websocket connections should the node require them",
+			EnvVar: "WAVELET_API_ADMIN_TOKEN",
+		},
+		cli.StringFlag{
+			Name:  "wallet.secp256k1",
+			Usage: "path to file containing a hex-encoded secp256k1 private key, such as that of an Ethereum or Bitcoin wallet. Transactions are created on behalf of the account controlled by the key",
 		},
 	}
 
@@ -117,17 +134,50 @@ func main() {
 				return nil
 			},
 		},
+		{
+			Name:  "poll_rounds",
+			Usage: "continuously receive consensus rounds as they are finalized",
+			Flags: commonFlags,
+			Action: func(c *cli.Context) error {
+				client, err := setup(c)
+				if err != nil {
+					return err
+				}
+
+				evChan, err := client.PollLoggerSink(nil, wctl.RouteWSRounds)
+				if err != nil {
+					return err
+				}
+
+				for ev := range evChan {
+					output(ev)
+				}
+				return nil
+			},
+		},
 		{
 			Name:  "poll_stake",
 			Usage: "continuously receive stake updates",
-			Flags: commonFlags,
+			Flags: append(commonFlags,
+				[]cli.Flag{
+					cli.StringFlag{
+						Name:  "account_id",
+						Usage: "account id to receive stake updates of (default: all)",
+					},
+				}...,
+			),
 			Action: func(c *cli.Context) error {
 				client, err := setup(c)
 				if err != nil {
 					return err
 				}
 
-				evChan, err := client.PollLoggerSink(nil, wctl.RouteWSStake)
+				accountID, err := accountIDFlag(c, "account_id")
+				if err != nil {
+					return err
+				}
+
+				evChan, err := client.PollStake(nil, accountID)
 				if err != nil {
 					return err
 				}
@@ -155,10 +205,9 @@ func main() {
 					return err
 				}
 
-				var accountID *string
-				if len(c.String("account_id")) > 0 {
-					tmp := c.String("account_id")
-					accountID = &tmp
+				accountID, err := accountIDFlag(c, "account_id")
+				if err != nil {
+					return err
 				}
 
 				evChan, err := client.PollAccounts(nil, accountID)
@@ -248,13 +297,11 @@ func main() {
 					tmp := c.String("tx_id")
 					txID = &tmp
 				}
-				if len(c.String("sender_id")) > 0 {
-					tmp := c.String("sender_id")
-					senderID = &tmp
+				if senderID, err = accountIDFlag(c, "sender_id"); err != nil {
+					return err
 				}
-				if len(c.String("creator_id")) > 0 {
-					tmp := c.String("creator_id")
-					creatorID = &tmp
+				if creatorID, err = accountIDFlag(c, "creator_id"); err != nil {
+					return err
 				}
 				if len(c.String("tag")) > 0 {
 					tmp := c.String("tag")
@@ -290,6 +337,10 @@ func main() {
 						Name:  "offset",
 						Usage: "an offset of the number of transactions to list",
 					},
+					cli.StringFlag{
+						Name:  "cursor",
+						Usage: "list the page of transactions after this cursor, and print the cursor to the next page (empty: start from the most recent)",
+					},
 					cli.IntFlag{
 						Name:  "limit",
 						Usage: "limit to max number of transactions to list",
@@ -307,13 +358,11 @@ func main() {
 				var creatorID *string
 				var offset *uint64
 				var limit *uint64
-				if len(c.String("sender_id")) > 0 {
-					tmp := c.String("sender_id")
-					senderID = &tmp
+				if senderID, err = accountIDFlag(c, "sender_id"); err != nil {
+					return err
 				}
-				if len(c.String("creator_id")) > 0 {
-					tmp := c.String("creator_id")
-					creatorID = &tmp
+				if creatorID, err = accountIDFlag(c, "creator_id"); err != nil {
+					return err
 				}
 				if c.Uint("offset") > 0 {
 					tmp := uint64(c.Uint("offset"))
@@ -478,6 +527,33 @@ func main() {
 				return nil
 			},
 		},
+		{
+			Name:      "get_transaction_status",
+			Usage:     "get how far along a transaction is in being finalized",
+			ArgsUsage: "<transaction ID>",
+			Flags:     commonFlags,
+			Action: func(c *cli.Context) error {
+				client, err := setup(c)
+				if err != nil {
+					return err
+				}
+				txID := c.Args().Get(0)
+
+				res, err := client.GetTransactionStatus(txID)
+				if err != nil {
+					return err
+				}
+
+				buf, err := json.Marshal(res)
+				if err != nil {
+					return err
+				}
+
+				output(buf)
+
+				return nil
+			},
+		},
 		{
 			Name:      "get_transaction",
 			Usage:     "get a transaction",
@@ -539,13 +615,11 @@ func main() {
 				var creatorID *string
 				var offset *uint64
 				var limit *uint64
-				if len(c.String("sender_id")) > 0 {
-					tmp := c.String("sender_id")
-					senderID = &tmp
+				if senderID, err = accountIDFlag(c, "sender_id"); err != nil {
+					return err
 				}
-				if len(c.String("creator_id")) > 0 {
-					tmp := c.String("creator_id")
-					creatorID = &tmp
+				if creatorID, err = accountIDFlag(c, "creator_id"); err != nil {
+					return err
 				}
 				if c.Uint("offset") > 0 {
 					tmp := uint64(c.Uint("offset"))
@@ -556,6 +630,22 @@ func main() {
 					limit = &tmp
 				}
 
+				if c.IsSet("cursor") {
+					res, err := client.ListTransactionsAfter(senderID, creatorID, c.String("cursor"), limit)
+					if err != nil {
+						return err
+					}
+
+					buf, err := json.Marshal(res)
+					if err != nil {
+						return err
+					}
+
+					output(buf)
+
+					return nil
+				}
+
 				res, err := client.ListTransactions(senderID, creatorID, offset, limit)
 				if err != nil {
 					return err
@@ -592,6 +682,98 @@ func main() {
 				return nil
 			},
 		},
+		{
+			Name:  "poll_queues",
+			Usage: "continuously receive snapshots of queue depths",
+			Flags: commonFlags,
+			Action: func(c *cli.Context) error {
+				client, err := setup(c)
+				if err != nil {
+					return err
+				}
+
+				evChan, err := client.PollLoggerSink(nil, wctl.RouteWSQueues)
+				if err != nil {
+					return err
+				}
+
+				for ev := range evChan {
+					output(ev)
+				}
+				return nil
+			},
+		},
+		{
+			Name:  "export_graph",
+			Usage: "export the graph of transactions for visualization",
+			Flags: append(commonFlags,
+				[]cli.Flag{
+					cli.StringFlag{
+						Name:  "format",
+						Value: "dot",
+						Usage: "format to export the graph in (dot or json)",
+					},
+					cli.IntFlag{
+						Name:  "depths",
+						Usage: "only export transactions within the last given number of depths (default: all)",
+					},
+				}...,
+			),
+			Action: func(c *cli.Context) error {
+				client, err := setup(c)
+				if err != nil {
+					return err
+				}
+
+				if c.Int("depths") < 0 {
+					return errors.New("depths must not be negative")
+				}
+
+				res, err := client.ExportGraph(c.String("format"), uint64(c.Int("depths")))
+				if err != nil {
+					return err
+				}
+
+				_, err = os.Stdout.Write(res)
+				return err
+			},
+		},
+		{
+			Name:  "dashboard",
+			Usage: "render a live dashboard of node metrics",
+			Flags: append(commonFlags, cli.IntFlag{
+				Name:  "width",
+				Value: 60,
+				Usage: "number of samples to plot per graph",
+			}),
+			Action: func(c *cli.Context) error {
+				client, err := setup(c)
+				if err != nil {
+					return err
+				}
+
+				width := c.Int("width")
+				if width <= 0 {
+					return errors.New("width must be positive")
+				}
+
+				evChan, err := client.PollLoggerSink(nil, wctl.RouteWSMetrics)
+				if err != nil {
+					return err
+				}
+
+				d := newDashboard(width)
+
+				for ev := range evChan {
+					if err := d.push(ev); err != nil {
+						continue
+					}
+
+					d.render(os.Stdout)
+				}
+				return nil
+			},
+		},
 	}
 
 	sort.Sort(cli.FlagsByName(app.Flags))
@@ -606,39 +788,51 @@ func setup(c *cli.Context) (*wctl.Client, error) {
 	host := c.String("api.host")
 	port := c.Uint("api.port")
 	privateKeyFile := c.String("wallet")
-	privateKey := c.String("key")
+	vault := c.String("wallet.vault")
+	secp256k1File := c.String("wallet.secp256k1")
 
 	if port == 0 {
 		return nil, errors.New("port is missing")
 	}
 
-	var privateKeyBytes []byte
+	if len(c.String("key")) > 0 {
+		return nil, errors.Errorf("--key is no longer supported, as private keys specified on the command line leak into shell histories and process listings: specify it through the %s environment variable, or --wallet instead", wallet.EnvPrivateKey)
+	}
+
+	var secp256k1Key *secp256k1.PrivateKey
 	var err error
 
-	if len(privateKey) != 0 {
-		privateKeyBytes = []byte(privateKey)
-	} else if len(privateKeyFile) != 0 {
-		privateKeyBytes, err = ioutil.ReadFile(privateKeyFile)
-		if err != nil {
-			return nil, errors.Wrapf(err, "failed to read private key %s", privateKeyFile)
+	if len(secp256k1File) > 0 {
+		if secp256k1Key, err = readSecp256k1Key(secp256k1File); err != nil {
+			return nil, err
 		}
 	}
 
-	if len(privateKeyBytes) == 0 {
+	var signer wallet.Signer
+
+	switch {
+	case len(os.Getenv(wallet.EnvPrivateKey)) > 0:
+		signer, err = wallet.Load(wallet.Env(wallet.EnvPrivateKey))
+	case len(vault) > 0:
+		signer, err = wallet.Vault(vault, os.Getenv(wallet.EnvVaultToken))
+	case len(privateKeyFile) > 0:
+		signer, err = wallet.Load(wallet.File(privateKeyFile))
+	case secp256k1Key == nil:
 		return nil, errors.New("private key is missing")
 	}
 
-	rawPrivateKey, err := hex.DecodeString(string(privateKeyBytes))
 	if err != nil {
-		return nil, errors.Wrapf(err, "failed to hex decode private key %s", privateKeyFile)
+		return nil, err
 	}
 
 	config := wctl.Config{
-		APIHost:  host,
-		APIPort:  uint16(port),
-		UseHTTPS: false,
+		APIHost:      host,
+		APIPort:      uint16(port),
+		UseHTTPS:     false,
+		Secp256k1Key: secp256k1Key,
+		AdminToken:   c.String("api.admin_token"),
+		Signer:       signer,
 	}
-	copy(config.PrivateKey[:], rawPrivateKey)
 
 	client, err := wctl.NewClient(config)
 	if err != nil {
@@ -648,6 +842,38 @@ func setup(c *cli.Context) (*wctl.Client, error) {
 	return client, nil
 }
 
+// accountIDFlag reads the account ID specified by a flag as hex, accepting
+// either hex or a bech32 address. Nil is returned should the flag be unset.
+func accountIDFlag(c *cli.Context, name string) (*string, error) {
+	if len(c.String(name)) == 0 {
+		return nil, nil
+	}
+
+	id, err := wavelet.ParseAccountID(c.String(name))
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid --%s", name)
+	}
+
+	encoded := hex.EncodeToString(id[:])
+
+	return &encoded, nil
+}
+
+// readSecp256k1Key reads a hex-encoded secp256k1 private key from a file.
+func readSecp256k1Key(path string) (*secp256k1.PrivateKey, error) {
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read secp256k1 private key from %q", path)
+	}
+
+	raw, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(string(buf)), "0x"))
+	if err != nil {
+		return nil, errors.Wrap(err, "secp256k1 private key is not hex-encoded")
+	}
+
+	return secp256k1.UnmarshalPrivateKey(raw)
+}
+
 // Write bytes to stdout; do JSON indent if possible.
 func output(buf []byte) {
 	var out bytes.Buffer
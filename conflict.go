@@ -0,0 +1,147 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"bytes"
+	"encoding/hex"
+	"sort"
+)
+
+type conflictKey struct {
+	creator AccountID
+	nonce   uint64
+}
+
+// ConflictSet comprises of transactions within the graph that were created by the
+// same creator under the same nonce, such that at most one of them is expected to
+// be honest. Transactions with a nonce of zero are unsequenced, and are never
+// considered to be in conflict with one another.
+type ConflictSet struct {
+	Creator AccountID
+	Nonce   uint64
+
+	TransactionIDs []TransactionID
+}
+
+// countConflicts returns the number of other transactions in the graph that tx
+// conflicts with. It must be called while holding the graphs lock.
+func (g *Graph) countConflicts(tx *Transaction) int {
+	if tx.Nonce == 0 {
+		return 0
+	}
+
+	ids := g.nonceIndex[conflictKey{creator: tx.Creator, nonce: tx.Nonce}]
+	if len(ids) < 2 {
+		return 0
+	}
+
+	return len(ids) - 1
+}
+
+// trackConflict indexes a transaction by its creator and nonce, emitting an event
+// should the transaction conflict with any other transaction in the graph. It
+// must be called while holding the graphs lock.
+func (g *Graph) trackConflict(tx *Transaction) {
+	if tx.Nonce == 0 {
+		return
+	}
+
+	key := conflictKey{creator: tx.Creator, nonce: tx.Nonce}
+
+	g.nonceIndex[key] = append(g.nonceIndex[key], tx.ID)
+
+	if len(g.nonceIndex[key]) < 2 {
+		return
+	}
+
+	ids := make([]string, 0, len(g.nonceIndex[key]))
+	for _, id := range g.nonceIndex[key] {
+		ids = append(ids, hex.EncodeToString(id[:]))
+	}
+
+	logger := g.logs.TX("conflict")
+	logger.Warn().
+		Hex("tx_id", tx.ID[:]).
+		Hex("creator_id", tx.Creator[:]).
+		Uint64("nonce", tx.Nonce).
+		Strs("tx_ids", ids).
+		Msg("Observed conflicting transactions from the same creator under the same nonce.")
+}
+
+// untrackConflict removes a transaction from the index of transactions by their
+// creator and nonce. It must be called while holding the graphs lock.
+func (g *Graph) untrackConflict(tx *Transaction) {
+	if tx.Nonce == 0 {
+		return
+	}
+
+	key := conflictKey{creator: tx.Creator, nonce: tx.Nonce}
+
+	ids := g.nonceIndex[key][:0]
+
+	for _, id := range g.nonceIndex[key] {
+		if id != tx.ID {
+			ids = append(ids, id)
+		}
+	}
+
+	if len(ids) == 0 {
+		delete(g.nonceIndex, key)
+	} else {
+		g.nonceIndex[key] = ids
+	}
+}
+
+// Conflicts returns all sets of conflicting transactions within the graph. If a
+// non-zero creator is specified, only conflicts from the creator are returned.
+func (g *Graph) Conflicts(creator AccountID) []ConflictSet {
+	g.RLock()
+
+	var conflicts []ConflictSet
+
+	for key, ids := range g.nonceIndex {
+		if len(ids) < 2 {
+			continue
+		}
+
+		if creator != ZeroAccountID && key.creator != creator {
+			continue
+		}
+
+		conflicts = append(conflicts, ConflictSet{
+			Creator:        key.creator,
+			Nonce:          key.nonce,
+			TransactionIDs: append([]TransactionID(nil), ids...),
+		})
+	}
+
+	g.RUnlock()
+
+	sort.Slice(conflicts, func(i, j int) bool {
+		if conflicts[i].Creator != conflicts[j].Creator {
+			return bytes.Compare(conflicts[i].Creator[:], conflicts[j].Creator[:]) < 0
+		}
+
+		return conflicts[i].Nonce < conflicts[j].Nonce
+	})
+
+	return conflicts
+}
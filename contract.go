@@ -23,6 +23,7 @@ import (
 	"crypto/sha256"
 	"crypto/sha512"
 	"encoding/binary"
+	"sort"
 
 	"github.com/perlin-network/life/compiler"
 	"github.com/perlin-network/life/exec"
@@ -38,6 +39,9 @@ import (
 var (
 	ErrNotSmartContract         = errors.New("contract: specified account ID is not a smart contract")
 	ErrContractFunctionNotFound = errors.New("contract: smart contract func not found")
+	ErrContractReentrancy       = errors.New("contract: smart contract is already being executed")
+	ErrContractCallDepth        = errors.New("contract: smart contract call depth exceeded")
+	ErrContractOutOfGas         = errors.New("contract: no gas left to call into smart contract")
 
 	_ exec.ImportResolver = (*ContractExecutor)(nil)
 	_ compiler.GasPolicy  = (*ContractExecutor)(nil)
@@ -45,6 +49,14 @@ var (
 
 const (
 	PageSize = 65536
+
+	MaxContractStateKeySize   = 256
+	MaxContractStateValueSize = 65536
+
+	MaxContractCallDepth = 8
+
+	MaxContractEventTopicSize = 256
+	MaxContractEventDataSize  = 65536
 )
 
 type ContractExecutor struct {
@@ -58,6 +70,48 @@ type ContractExecutor struct {
 	Error   []byte
 
 	Queue []*Transaction
+
+	// Entropy is a source of randomness shared by every validator executing
+	// the smart contract, derived from the critical transaction of the round
+	// finalizing the transaction invoking the smart contract, such that the
+	// creator of the transaction may not know it upfront.
+	Entropy [blake2b.Size256]byte
+
+	// RoundIndex is the index of the round the transaction invoking the smart
+	// contract was finalized on top of.
+	RoundIndex uint64
+
+	// State holds writes made by the smart contract to its local state, which
+	// are only committed should the smart contract exit successfully. A nil
+	// value marks a deleted key.
+	State map[string][]byte
+
+	// Events emitted by the smart contract, and by smart contracts it called
+	// into. Events are discarded should the smart contract fail.
+	Events []ContractEvent
+
+	round    *Round
+	tx       *Transaction
+	critical *Transaction // Critical transaction of the round being finalized.
+	logs     *log.Scope
+	version  uint32 // Protocol version the smart contract is executed under.
+
+	// depth is the number of smart contracts calling into this one, and active
+	// is the set of smart contracts being executed on the call stack.
+	depth  int
+	active map[AccountID]struct{}
+
+	exitError error
+}
+
+// scope returns the scope smart contracts log through, which is the scope of
+// the ledger executing them should one have been set.
+func (e *ContractExecutor) scope() *log.Scope {
+	if e.logs == nil {
+		return log.Root()
+	}
+
+	return e.logs
 }
 
 func (e *ContractExecutor) GetCost(key string) int64 {
@@ -72,6 +126,10 @@ func (e *ContractExecutor) GetCost(key string) int64 {
 func (e *ContractExecutor) ResolveFunc(module, field string) exec.FunctionImport {
 	switch module {
 	case "env":
+		if !sys.ImportActive(field, e.version) {
+			panic(errors.Errorf("%s may not be called under protocol version %d", field, e.version))
+		}
+
 		switch field {
 		case "abort":
 			return func(vm *exec.VirtualMachine) int64 {
@@ -124,7 +182,7 @@ func (e *ContractExecutor) ResolveFunc(module, field string) exec.FunctionImport
 				dataPtr := int(uint32(frame.Locals[0]))
 				dataLen := int(uint32(frame.Locals[1]))
 
-				logger := log.Contracts("log")
+				logger := e.scope().Contracts("log")
 				logger.Debug().
 					Hex("contract_id", e.ID[:]).
 					Msg(string(vm.Memory[dataPtr : dataPtr+dataLen]))
@@ -161,6 +219,137 @@ func (e *ContractExecutor) ResolveFunc(module, field string) exec.FunctionImport
 					return 1
 				}
 			}
+		case "_read_state":
+			return func(vm *exec.VirtualMachine) int64 {
+				frame := vm.GetCurrentFrame()
+				keyPtr, keyLen := int(uint32(frame.Locals[0])), int(uint32(frame.Locals[1]))
+				outPtr, outLen := int(uint32(frame.Locals[2])), int(uint32(frame.Locals[3]))
+
+				vm.Gas += uint64(e.GetCost("wavelet.state.read"))
+
+				value, exists := e.ReadState(vm.Memory[keyPtr : keyPtr+keyLen])
+				if !exists {
+					return -1
+				}
+
+				vm.Gas += uint64(len(value)) * uint64(e.GetCost("wavelet.state.byte"))
+
+				copy(vm.Memory[outPtr:outPtr+outLen], value)
+				return int64(len(value))
+			}
+		case "_write_state":
+			return func(vm *exec.VirtualMachine) int64 {
+				frame := vm.GetCurrentFrame()
+				keyPtr, keyLen := int(uint32(frame.Locals[0])), int(uint32(frame.Locals[1]))
+				valuePtr, valueLen := int(uint32(frame.Locals[2])), int(uint32(frame.Locals[3]))
+
+				if keyLen == 0 || keyLen > MaxContractStateKeySize || valueLen > MaxContractStateValueSize {
+					return 1
+				}
+
+				vm.Gas += uint64(e.GetCost("wavelet.state.write")) + uint64(keyLen+valueLen)*uint64(e.GetCost("wavelet.state.byte"))
+
+				e.WriteState(vm.Memory[keyPtr:keyPtr+keyLen], vm.Memory[valuePtr:valuePtr+valueLen])
+				return 0
+			}
+		case "_delete_state":
+			return func(vm *exec.VirtualMachine) int64 {
+				vm.Gas += uint64(e.GetCost("wavelet.state.delete"))
+
+				frame := vm.GetCurrentFrame()
+				keyPtr, keyLen := int(uint32(frame.Locals[0])), int(uint32(frame.Locals[1]))
+
+				e.DeleteState(vm.Memory[keyPtr : keyPtr+keyLen])
+				return 0
+			}
+		case "_call_contract":
+			return func(vm *exec.VirtualMachine) int64 {
+				vm.Gas += uint64(e.GetCost("wavelet.contract.call"))
+
+				frame := vm.GetCurrentFrame()
+				idPtr, idLen := int(uint32(frame.Locals[0])), int(uint32(frame.Locals[1]))
+				namePtr, nameLen := int(uint32(frame.Locals[2])), int(uint32(frame.Locals[3]))
+				paramsPtr, paramsLen := int(uint32(frame.Locals[4])), int(uint32(frame.Locals[5]))
+
+				if idLen != SizeAccountID {
+					return 1
+				}
+
+				var id AccountID
+				copy(id[:], vm.Memory[idPtr:idPtr+idLen])
+
+				name := string(vm.Memory[namePtr : namePtr+nameLen])
+				params := append([]byte{}, vm.Memory[paramsPtr:paramsPtr+paramsLen]...)
+
+				// A gas limit of zero leaves the callee unmetered, so never call
+				// into it should the caller have no gas left.
+
+				if vm.Config.GasLimit <= vm.Gas {
+					return 1
+				}
+
+				remaining := vm.Config.GasLimit - vm.Gas
+
+				gas, err := e.Call(id, name, params, remaining)
+				vm.Gas += gas
+
+				if err != nil {
+					logger := e.scope().Contracts("call")
+					logger.Debug().
+						Hex("contract_id", e.ID[:]).
+						Hex("callee_id", id[:]).
+						Str("func", name).
+						Err(err).
+						Msg("Failed to call smart contract.")
+
+					return 1
+				}
+
+				return 0
+			}
+		case "_emit_event":
+			return func(vm *exec.VirtualMachine) int64 {
+				frame := vm.GetCurrentFrame()
+				topicPtr, topicLen := int(uint32(frame.Locals[0])), int(uint32(frame.Locals[1]))
+				dataPtr, dataLen := int(uint32(frame.Locals[2])), int(uint32(frame.Locals[3]))
+
+				if topicLen > MaxContractEventTopicSize || dataLen > MaxContractEventDataSize {
+					return 1
+				}
+
+				vm.Gas += uint64(e.GetCost("wavelet.event.emit")) + uint64(topicLen+dataLen)*uint64(e.GetCost("wavelet.event.byte"))
+
+				event := ContractEvent{
+					Contract: e.ID,
+					Topic:    append([]byte{}, vm.Memory[topicPtr:topicPtr+topicLen]...),
+					Data:     append([]byte{}, vm.Memory[dataPtr:dataPtr+dataLen]...),
+				}
+
+				if e.tx != nil {
+					event.TxID = e.tx.ID
+				}
+
+				e.Events = append(e.Events, event)
+				return 0
+			}
+		case "_round_entropy":
+			return func(vm *exec.VirtualMachine) int64 {
+				vm.Gas += uint64(e.GetCost("wavelet.round.entropy"))
+
+				frame := vm.GetCurrentFrame()
+				outPtr, outLen := int(uint32(frame.Locals[0])), int(uint32(frame.Locals[1]))
+
+				if outLen != len(e.Entropy) {
+					return 1
+				}
+
+				copy(vm.Memory[outPtr:outPtr+outLen], e.Entropy[:])
+				return 0
+			}
+		case "_round_index":
+			return func(vm *exec.VirtualMachine) int64 {
+				return int64(e.RoundIndex)
+			}
 		case "_hash_blake2b_256":
 			return buildHashImpl(
 				uint64(e.GetCost("wavelet.hash.blake2b256")),
@@ -222,6 +411,8 @@ func (e *ContractExecutor) Execute(snapshot *avl.Tree, id AccountID, round *Roun
 		GasLimit:          gasLimit,
 	}
 
+	e.version = ReadProtocolVersion(snapshot)
+
 	vm, err := exec.NewVirtualMachine(code, config, e, e)
 	if err != nil {
 		return errors.Wrap(err, "could not init vm")
@@ -231,10 +422,30 @@ func (e *ContractExecutor) Execute(snapshot *avl.Tree, id AccountID, round *Roun
 		vm.Memory = mem
 	}
 
+	if e.active == nil {
+		e.active = make(map[AccountID]struct{})
+	}
+
+	e.active[id] = struct{}{}
+	defer delete(e.active, id)
+
 	e.ID = id
 	e.Snapshot = snapshot
 
+	e.round = round
+	e.tx = tx
+
+	// Checkpoint the snapshot such that changes made by smart contracts called
+	// into may be reverted should this smart contract fail.
+
+	checkpoint := snapshot.Snapshot()
+
 	e.Payload = buildContractPayload(round, tx, amount, params)
+	e.Entropy = buildContractEntropy(round, e.critical, tx)
+
+	if round != nil {
+		e.RoundIndex = round.Index
+	}
 
 	entry, exists := vm.GetFunctionExport("_contract_" + name)
 	if !exists {
@@ -252,8 +463,16 @@ func (e *ContractExecutor) Execute(snapshot *avl.Tree, id AccountID, round *Roun
 		}
 	}
 
+	if vm.ExitError != nil {
+		e.exitError = utils.UnifyError(vm.ExitError)
+	}
+
 	if vm.ExitError == nil && len(e.Error) == 0 {
 		SaveContractMemorySnapshot(snapshot, id, vm.Memory)
+		e.CommitState()
+	} else {
+		snapshot.Revert(checkpoint)
+		e.Events = nil
 	}
 
 	if vm.ExitError != nil && utils.UnifyError(vm.ExitError).Error() == "gas limit exceeded" {
@@ -267,6 +486,112 @@ func (e *ContractExecutor) Execute(snapshot *avl.Tree, id AccountID, round *Roun
 	return nil
 }
 
+// Call synchronously invokes the function name of the smart contract id with
+// params on behalf of the smart contract being executed, using up to gasLimit
+// gas, which must be non-zero. It returns the amount of gas used, and an error
+// should the smart contract not exist, already be on the call stack, or fail.
+// Transactions queued by the smart contract called into are queued by the
+// caller should the call succeed. The smart contract called into is presented
+// the caller as the creator of the transaction invoking it.
+func (e *ContractExecutor) Call(id AccountID, name string, params []byte, gasLimit uint64) (uint64, error) {
+	if gasLimit == 0 {
+		return 0, ErrContractOutOfGas
+	}
+
+	if e.depth+1 >= MaxContractCallDepth {
+		return 0, errors.Wrapf(ErrContractCallDepth, "max depth is %d", MaxContractCallDepth)
+	}
+
+	if _, active := e.active[id]; active {
+		return 0, errors.Wrapf(ErrContractReentrancy, "%x", id)
+	}
+
+	code, available := ReadAccountContractCode(e.Snapshot, id)
+	if !available {
+		return 0, errors.Wrapf(ErrNotSmartContract, "%x", id)
+	}
+
+	var caller Transaction
+	if e.tx != nil {
+		caller = *e.tx
+	}
+
+	caller.Creator = e.ID
+
+	callee := &ContractExecutor{depth: e.depth + 1, active: e.active, critical: e.critical, logs: e.logs}
+
+	if err := callee.Execute(e.Snapshot, id, e.round, &caller, 0, gasLimit, name, params, code); err != nil {
+		return 0, err
+	}
+
+	if callee.GasLimitExceeded {
+		return callee.Gas, errors.New("contract: gas limit exceeded")
+	}
+
+	if callee.exitError != nil {
+		return callee.Gas, errors.Wrap(callee.exitError, "contract: smart contract exited with an error")
+	}
+
+	if len(callee.Error) > 0 {
+		return callee.Gas, errors.Errorf("contract: %s", callee.Error)
+	}
+
+	e.Queue = append(e.Queue, callee.Queue...)
+	e.Events = append(e.Events, callee.Events...)
+
+	return callee.Gas, nil
+}
+
+// ReadState returns the value stored under key in the local state of the
+// smart contract, taking into account writes not yet committed.
+func (e *ContractExecutor) ReadState(key []byte) ([]byte, bool) {
+	if value, written := e.State[string(key)]; written {
+		return value, value != nil
+	}
+
+	return ReadAccountContractState(e.Snapshot, e.ID, key)
+}
+
+func (e *ContractExecutor) WriteState(key, value []byte) {
+	if e.State == nil {
+		e.State = make(map[string][]byte)
+	}
+
+	// Copy the value out, as it may point into the memory of the VM.
+
+	e.State[string(key)] = append([]byte{}, value...)
+}
+
+func (e *ContractExecutor) DeleteState(key []byte) {
+	if e.State == nil {
+		e.State = make(map[string][]byte)
+	}
+
+	e.State[string(key)] = nil
+}
+
+// CommitState writes all changes made to the local state of the smart
+// contract into its snapshot. Changes are written in lexicographical order of
+// their keys, such that every validator derives the same merkle root.
+func (e *ContractExecutor) CommitState() {
+	keys := make([]string, 0, len(e.State))
+	for key := range e.State {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if value := e.State[key]; value != nil {
+			WriteAccountContractState(e.Snapshot, e.ID, []byte(key), value)
+		} else {
+			DeleteAccountContractState(e.Snapshot, e.ID, []byte(key))
+		}
+	}
+
+	e.State = nil
+}
+
 func LoadContractMemorySnapshot(snapshot *avl.Tree, id AccountID) []byte {
 	numPages, exists := ReadAccountContractNumPages(snapshot, id)
 	if !exists {
@@ -365,6 +690,42 @@ func buildContractPayload(round *Round, tx *Transaction, amount uint64, params [
 	return p
 }
 
+// buildContractEntropy hashes together the ID of the round a transaction is
+// finalized on top of, the ID of the critical transaction of the round which
+// finalizes it, and the ID of the transaction. The critical transaction is a
+// descendant of the transaction, and is thus unknown to its creator, such that
+// the creator may not grind through transaction IDs for a favorable outcome.
+//
+// The creator of the critical transaction could still bias the entropy by
+// withholding critical transactions it creates, though doing so forfeits the
+// work spent on meeting the difficulty of a critical transaction.
+func buildContractEntropy(round *Round, critical, tx *Transaction) [blake2b.Size256]byte {
+	p := make([]byte, 0, SizeRoundID+SizeTransactionID*2)
+
+	var nilRoundID RoundID
+	var nilTransactionID TransactionID
+
+	if round != nil {
+		p = append(p, round.ID[:]...)
+	} else {
+		p = append(p, nilRoundID[:]...)
+	}
+
+	if critical != nil {
+		p = append(p, critical.ID[:]...)
+	} else {
+		p = append(p, nilTransactionID[:]...)
+	}
+
+	if tx != nil {
+		p = append(p, tx.ID[:]...)
+	} else {
+		p = append(p, nilTransactionID[:]...)
+	}
+
+	return blake2b.Sum256(p)
+}
+
 func buildHashImpl(gas uint64, size int, f func(data, out []byte)) func(vm *exec.VirtualMachine) int64 {
 	return func(vm *exec.VirtualMachine) int64 {
 		vm.Gas += gas
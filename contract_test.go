@@ -0,0 +1,175 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"testing"
+
+	"github.com/perlin-network/wavelet/avl"
+	"github.com/perlin-network/wavelet/store"
+	"github.com/perlin-network/wavelet/sys"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContractExecutorState(t *testing.T) {
+	tree := avl.New(store.NewInmem())
+
+	var a, b TransactionID
+	a[0], b[0] = 1, 2
+
+	WriteAccountContractState(tree, a, []byte("existing"), []byte("old"))
+	WriteAccountContractState(tree, b, []byte("key"), []byte("other"))
+
+	executor := &ContractExecutor{ID: a, Snapshot: tree}
+
+	value, exists := executor.ReadState([]byte("existing"))
+	assert.True(t, exists)
+	assert.Equal(t, []byte("old"), value)
+
+	_, exists = executor.ReadState([]byte("key"))
+	assert.False(t, exists, "state must be scoped to the contract")
+
+	executor.WriteState([]byte("key"), []byte("value"))
+	executor.DeleteState([]byte("existing"))
+
+	value, exists = executor.ReadState([]byte("key"))
+	assert.True(t, exists)
+	assert.Equal(t, []byte("value"), value)
+
+	_, exists = executor.ReadState([]byte("existing"))
+	assert.False(t, exists)
+
+	// Nothing is written into the tree until the changes are committed.
+
+	_, exists = ReadAccountContractState(tree, a, []byte("key"))
+	assert.False(t, exists)
+
+	executor.CommitState()
+
+	value, exists = ReadAccountContractState(tree, a, []byte("key"))
+	assert.True(t, exists)
+	assert.Equal(t, []byte("value"), value)
+
+	_, exists = ReadAccountContractState(tree, a, []byte("existing"))
+	assert.False(t, exists)
+
+	value, exists = ReadAccountContractState(tree, b, []byte("key"))
+	assert.True(t, exists)
+	assert.Equal(t, []byte("other"), value)
+}
+
+func TestContractExecutorStateDeterministic(t *testing.T) {
+	var id TransactionID
+
+	keys := []string{"d", "a", "c", "b", "e"}
+
+	roots := make([][avl.MerkleHashSize]byte, 2)
+
+	for i := range roots {
+		tree := avl.New(store.NewInmem())
+		executor := &ContractExecutor{ID: id, Snapshot: tree}
+
+		for j := range keys {
+			key := keys[j]
+			if i == 1 {
+				key = keys[len(keys)-1-j]
+			}
+
+			executor.WriteState([]byte(key), []byte(key))
+		}
+
+		executor.CommitState()
+
+		roots[i] = tree.Checksum()
+	}
+
+	assert.Equal(t, roots[0], roots[1])
+}
+
+func TestContractEntropy(t *testing.T) {
+	round := &Round{Index: 1}
+	round.ID[0] = 1
+
+	critical := &Transaction{}
+	critical.ID[0] = 3
+
+	tx := &Transaction{}
+	tx.ID[0] = 1
+
+	entropy := buildContractEntropy(round, critical, tx)
+	assert.Equal(t, entropy, buildContractEntropy(round, critical, tx), "entropy must be deterministic")
+
+	other := *tx
+	other.ID[0] = 2
+	assert.NotEqual(t, entropy, buildContractEntropy(round, critical, &other), "entropy must depend on the transaction")
+
+	next := *round
+	next.ID[0] = 2
+	assert.NotEqual(t, entropy, buildContractEntropy(&next, critical, tx), "entropy must depend on the round")
+
+	finalizer := *critical
+	finalizer.ID[0] = 4
+	assert.NotEqual(t, entropy, buildContractEntropy(round, &finalizer, tx), "entropy must depend on the critical transaction finalizing the transaction")
+
+	assert.NotPanics(t, func() { buildContractEntropy(nil, nil, nil) })
+}
+
+func TestContractImportsGatedByProtocolVersion(t *testing.T) {
+	executor := &ContractExecutor{}
+
+	assert.NotPanics(t, func() { executor.ResolveFunc("env", "_payload_len") }, "functions exposed since genesis must always resolve")
+
+	for name, version := range sys.ImportVersions {
+		executor.version = version - 1
+		assert.Panics(t, func() { executor.ResolveFunc("env", name) }, "%s must not resolve before its upgrade activates", name)
+
+		executor.version = version
+		assert.NotPanics(t, func() { executor.ResolveFunc("env", name) }, "%s must resolve once its upgrade activates", name)
+	}
+}
+
+func TestContractExecutorCall(t *testing.T) {
+	tree := avl.New(store.NewInmem())
+
+	var caller, callee AccountID
+	caller[0], callee[0] = 1, 2
+
+	executor := &ContractExecutor{ID: caller, Snapshot: tree, active: map[AccountID]struct{}{caller: {}}}
+
+	_, err := executor.Call(callee, "hello", nil, 1000)
+	assert.Equal(t, ErrNotSmartContract, errors.Cause(err))
+
+	_, err = executor.Call(callee, "hello", nil, 0)
+	assert.Equal(t, ErrContractOutOfGas, errors.Cause(err), "a zero gas limit must not leave the callee unmetered")
+
+	WriteAccountContractCode(tree, callee, []byte("not wasm"))
+
+	_, err = executor.Call(caller, "hello", nil, 1000)
+	assert.Equal(t, ErrContractReentrancy, errors.Cause(err))
+
+	_, err = executor.Call(callee, "hello", nil, 1000)
+	assert.Error(t, err, "code that is not valid wasm must fail to execute")
+
+	executor.depth = MaxContractCallDepth - 1
+
+	_, err = executor.Call(callee, "hello", nil, 1000)
+	assert.Equal(t, ErrContractCallDepth, errors.Cause(err))
+}
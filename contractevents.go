@@ -0,0 +1,176 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"io"
+
+	"github.com/perlin-network/wavelet/store"
+	"github.com/pkg/errors"
+)
+
+// ContractEvent is a structured log emitted by a smart contract while being
+// invoked by a transaction. Events are only recorded for transactions that
+// were applied in a finalized round.
+type ContractEvent struct {
+	TxID     TransactionID
+	Contract AccountID
+
+	Topic []byte
+	Data  []byte
+}
+
+func (e ContractEvent) Marshal() []byte {
+	w := bytes.NewBuffer(make([]byte, 0, SizeTransactionID+SizeAccountID+4+len(e.Topic)+4+len(e.Data)))
+
+	var buf [4]byte
+
+	w.Write(e.TxID[:])
+	w.Write(e.Contract[:])
+
+	binary.BigEndian.PutUint32(buf[:], uint32(len(e.Topic)))
+	w.Write(buf[:])
+	w.Write(e.Topic)
+
+	binary.BigEndian.PutUint32(buf[:], uint32(len(e.Data)))
+	w.Write(buf[:])
+	w.Write(e.Data)
+
+	return w.Bytes()
+}
+
+func UnmarshalContractEvent(r io.Reader) (e ContractEvent, err error) {
+	var buf [4]byte
+
+	if _, err = io.ReadFull(r, e.TxID[:]); err != nil {
+		err = errors.Wrap(err, "failed to decode contract event transaction ID")
+		return
+	}
+
+	if _, err = io.ReadFull(r, e.Contract[:]); err != nil {
+		err = errors.Wrap(err, "failed to decode contract event contract ID")
+		return
+	}
+
+	for _, field := range []*[]byte{&e.Topic, &e.Data} {
+		if _, err = io.ReadFull(r, buf[:]); err != nil {
+			err = errors.Wrap(err, "failed to decode contract event field length")
+			return
+		}
+
+		size := binary.BigEndian.Uint32(buf[:])
+		if size > MaxContractEventDataSize {
+			err = errors.Errorf("contract event field is %d bytes, which exceeds %d bytes", size, MaxContractEventDataSize)
+			return
+		}
+
+		*field = make([]byte, size)
+
+		if _, err = io.ReadFull(r, *field); err != nil {
+			err = errors.Wrap(err, "failed to decode contract event field")
+			return
+		}
+	}
+
+	return
+}
+
+// ContractEvents persists the events emitted by smart contracts into a store,
+// keyed by the ID of the transaction that emitted them.
+type ContractEvents struct {
+	kv store.KV
+}
+
+func NewContractEvents(kv store.KV) *ContractEvents {
+	return &ContractEvents{kv: kv}
+}
+
+// Record persists events, which must be ordered by when they were emitted.
+func (c *ContractEvents) Record(events []ContractEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	grouped := make(map[TransactionID][]byte)
+
+	for _, event := range events {
+		grouped[event.TxID] = append(grouped[event.TxID], event.Marshal()...)
+	}
+
+	batch := c.kv.NewWriteBatch()
+	defer batch.Destroy()
+
+	for id, buf := range grouped {
+		batch.Put(append(keyContractEvents[:], id[:]...), buf)
+	}
+
+	if err := c.kv.CommitWriteBatch(batch); err != nil {
+		return errors.Wrap(err, "contract events: failed to record events")
+	}
+
+	return nil
+}
+
+// Find returns the events emitted while applying the transaction id, in the
+// order they were emitted.
+func (c *ContractEvents) Find(id TransactionID) ([]ContractEvent, error) {
+	buf, err := c.kv.Get(append(keyContractEvents[:], id[:]...))
+	if err != nil || len(buf) == 0 {
+		return nil, nil
+	}
+
+	r := bytes.NewReader(buf)
+
+	var events []ContractEvent
+
+	for r.Len() > 0 {
+		event, err := UnmarshalContractEvent(r)
+		if err != nil {
+			return nil, err
+		}
+
+		events = append(events, event)
+	}
+
+	return events, nil
+}
+
+// ContractEvents returns the events emitted by smart contracts while applying
+// the finalized transaction id, in the order they were emitted.
+func (l *Ledger) ContractEvents(id TransactionID) ([]ContractEvent, error) {
+	return l.events.Find(id)
+}
+
+// logContractEvents streams out events emitted in a finalized round, such that
+// they may be subscribed to over Wavelet's HTTP API.
+func (l *Ledger) logContractEvents(events []ContractEvent) {
+	for _, event := range events {
+		logger := l.logs.ContractEvents("emitted")
+		logger.Info().
+			Hex("tx_id", event.TxID[:]).
+			Hex("contract_id", event.Contract[:]).
+			Str("topic", hex.EncodeToString(event.Topic)).
+			Str("data", hex.EncodeToString(event.Data)).
+			Msg("Smart contract emitted an event.")
+	}
+}
@@ -0,0 +1,60 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"testing"
+
+	"github.com/perlin-network/wavelet/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContractEvents(t *testing.T) {
+	events := NewContractEvents(store.NewInmem())
+
+	var a, b TransactionID
+	a[0], b[0] = 1, 2
+
+	var contract AccountID
+	contract[0] = 3
+
+	recorded := []ContractEvent{
+		{TxID: a, Contract: contract, Topic: []byte("first"), Data: []byte{1}},
+		{TxID: b, Contract: contract, Topic: []byte("other")},
+		{TxID: a, Contract: contract, Topic: []byte("second"), Data: []byte{2, 3}},
+	}
+
+	assert.NoError(t, events.Record(recorded))
+
+	found, err := events.Find(a)
+	assert.NoError(t, err)
+	assert.Equal(t, []ContractEvent{recorded[0], recorded[2]}, found)
+
+	found, err = events.Find(b)
+	assert.NoError(t, err)
+	if assert.Len(t, found, 1) {
+		assert.Equal(t, []byte("other"), found[0].Topic)
+		assert.Empty(t, found[0].Data)
+	}
+
+	found, err = events.Find(TransactionID{})
+	assert.NoError(t, err)
+	assert.Empty(t, found)
+}
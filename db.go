@@ -26,6 +26,7 @@ import (
 	"github.com/golang/snappy"
 	"github.com/perlin-network/wavelet/avl"
 	"github.com/perlin-network/wavelet/store"
+	"github.com/perlin-network/wavelet/sys"
 	"github.com/pkg/errors"
 	"io"
 	"strconv"
@@ -49,6 +50,47 @@ var (
 	keyRoundStoredCount = [...]byte{0x13}
 
 	keyRewardWithdrawals = [...]byte{0x14}
+
+	keyAccountHistoryLatest = [...]byte{0x15}
+	keyAccountHistory       = [...]byte{0x16}
+
+	keyAccountJournalLen = [...]byte{0x17}
+	keyAccountJournal    = [...]byte{0x18}
+
+	keyForks    = [...]byte{0x19}
+	keyForksLen = [...]byte{0x1a}
+	keyHalted   = [...]byte{0x1b}
+
+	keyCertificates = [...]byte{0x1c}
+
+	keyAccountGuardians   = [...]byte{0x1d}
+	keyAccountRecovery    = [...]byte{0x1e}
+	keyAccountRecoveredTo = [...]byte{0x1f}
+
+	keyProtocolVersion = [...]byte{0x20}
+
+	keyProposals = [...]byte{0x21}
+	keyParams    = [...]byte{0x22}
+
+	keyAccountFreezeAuthority = [...]byte{0x23}
+	keyAccountFrozen          = [...]byte{0x24}
+
+	keyAccountValidator = [...]byte{0x25}
+
+	keyAudit    = [...]byte{0x26}
+	keyAuditLen = [...]byte{0x27}
+
+	keyAccountSignatureScheme = [...]byte{0x28}
+
+	keyGraphTransactions    = [...]byte{0x29}
+	keyArchivedTransactions = [...]byte{0x2a}
+
+	keyAccountContractState = [...]byte{0x2b}
+
+	keyContractEvents = [...]byte{0x2c}
+
+	keyAccountStakeLock = [...]byte{0x2d}
+	keyTotalStake       = [...]byte{0x2e}
 )
 
 type RewardWithdrawalRequest struct {
@@ -152,11 +194,78 @@ func ReadAccountStake(tree *avl.Tree, id AccountID) (uint64, bool) {
 	return binary.LittleEndian.Uint64(buf), true
 }
 
+// WriteAccountStake records the stake of an account, and updates the total of
+// the stakes of all accounts accordingly.
 func WriteAccountStake(tree *avl.Tree, id AccountID, stake uint64) {
-	var buf [8]byte
-	binary.LittleEndian.PutUint64(buf[:], stake)
+	previous, _ := ReadAccountStake(tree, id)
+	total := ReadTotalStake(tree) - previous + stake
+
+	var buf, totalBuf [8]byte
 
+	binary.LittleEndian.PutUint64(buf[:], stake)
 	writeUnderAccounts(tree, id, keyAccountStake[:], buf[:])
+
+	binary.LittleEndian.PutUint64(totalBuf[:], total)
+	tree.Insert(keyTotalStake[:], totalBuf[:])
+}
+
+// ReadTotalStake returns the total of the stakes of all accounts. Should the
+// total not have been recorded yet, it is summed up from the stakes of all
+// accounts.
+func ReadTotalStake(tree *avl.Tree) uint64 {
+	if buf, exists := tree.Lookup(keyTotalStake[:]); exists && len(buf) == 8 {
+		return binary.LittleEndian.Uint64(buf)
+	}
+
+	var total uint64
+
+	IterateAccountStakes(tree, func(_ AccountID, stake uint64) {
+		total += stake
+	})
+
+	return total
+}
+
+// ReadAccountStakeLock returns the index of the round up until which the stake
+// of an account may not be withdrawn, as the account voted with its stake in a
+// vote which closes in that round.
+func ReadAccountStakeLock(tree *avl.Tree, id AccountID) (uint64, bool) {
+	buf, exists := readUnderAccounts(tree, id, keyAccountStakeLock[:])
+	if !exists || len(buf) != 8 {
+		return 0, false
+	}
+
+	return binary.LittleEndian.Uint64(buf), true
+}
+
+// LockAccountStake has the stake of an account not be withdrawable before the
+// round with the given index. Locks only ever get extended.
+func LockAccountStake(tree *avl.Tree, id AccountID, until uint64) {
+	if current, locked := ReadAccountStakeLock(tree, id); locked && current >= until {
+		return
+	}
+
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], until)
+
+	writeUnderAccounts(tree, id, keyAccountStakeLock[:], buf[:])
+}
+
+// IterateAccountStakes calls fn with the ID and stake of every account that has
+// a stake recorded in tree, in lexicographical order of account IDs.
+func IterateAccountStakes(tree *avl.Tree, fn func(id AccountID, stake uint64)) {
+	prefix := append(keyAccounts[:], keyAccountStake[:]...)
+
+	tree.IteratePrefix(prefix, func(key, value []byte) {
+		if len(key) != len(prefix)+SizeAccountID || len(value) != 8 {
+			return
+		}
+
+		var id AccountID
+		copy(id[:], key[len(prefix):])
+
+		fn(id, binary.LittleEndian.Uint64(value))
+	})
 }
 
 func ReadAccountReward(tree *avl.Tree, id AccountID) (uint64, bool) {
@@ -229,6 +338,34 @@ func WriteAccountContractPage(tree *avl.Tree, id TransactionID, idx uint64, page
 	writeUnderAccounts(tree, id, append(keyAccountContractPages[:], buf[:]...), encoded)
 }
 
+// ReadAccountContractState returns the value stored under key in the local
+// state of the smart contract id.
+func ReadAccountContractState(tree *avl.Tree, id TransactionID, key []byte) ([]byte, bool) {
+	return tree.Lookup(contractStateKey(id, key))
+}
+
+func WriteAccountContractState(tree *avl.Tree, id TransactionID, key, value []byte) {
+	tree.Insert(contractStateKey(id, key), value)
+}
+
+func DeleteAccountContractState(tree *avl.Tree, id TransactionID, key []byte) {
+	tree.Delete(contractStateKey(id, key))
+}
+
+// contractStateKey scopes key to the smart contract id. Unlike other account
+// keys, the account ID precedes key such that the state of different smart
+// contracts never overlaps.
+func contractStateKey(id TransactionID, key []byte) []byte {
+	buf := make([]byte, 0, len(keyAccounts)+len(keyAccountContractState)+len(id)+len(key))
+
+	buf = append(buf, keyAccounts[:]...)
+	buf = append(buf, keyAccountContractState[:]...)
+	buf = append(buf, id[:]...)
+	buf = append(buf, key...)
+
+	return buf
+}
+
 func readUnderAccounts(tree *avl.Tree, id AccountID, key []byte) ([]byte, bool) {
 	buf, exists := tree.Lookup(append(keyAccounts[:], append(key, id[:]...)...))
 
@@ -243,6 +380,10 @@ func writeUnderAccounts(tree *avl.Tree, id AccountID, key, value []byte) {
 	tree.Insert(append(keyAccounts[:], append(key, id[:]...)...), value[:])
 }
 
+func deleteUnderAccounts(tree *avl.Tree, id AccountID, key []byte) {
+	tree.Delete(append(keyAccounts[:], append(key, id[:]...)...))
+}
+
 func ReadAccountsLen(tree *avl.Tree) uint64 {
 	buf, exists := tree.Lookup(keyAccountsLen[:])
 	if !exists {
@@ -259,6 +400,24 @@ func WriteAccountsLen(tree *avl.Tree, size uint64) {
 	tree.Insert(keyAccountsLen[:], buf[:])
 }
 
+// ReadProtocolVersion returns the version of the protocol whose rules the state
+// in tree was last collapsed under.
+func ReadProtocolVersion(tree *avl.Tree) uint32 {
+	buf, exists := tree.Lookup(keyProtocolVersion[:])
+	if !exists || len(buf) != 4 {
+		return sys.ProtocolGenesis
+	}
+
+	return binary.BigEndian.Uint32(buf)
+}
+
+func WriteProtocolVersion(tree *avl.Tree, version uint32) {
+	var buf [4]byte
+	binary.BigEndian.PutUint32(buf[:], version)
+
+	tree.Insert(keyProtocolVersion[:], buf[:])
+}
+
 func StoreRound(kv store.KV, round Round, currentIx, oldestIx uint32, storedCount uint8) error {
 	if err := kv.Put(keyRoundStoredCount[:], []byte{byte(storedCount)}); err != nil {
 		return errors.Wrap(err, "error storing stored rounds count")
@@ -21,6 +21,7 @@ package debounce
 
 import (
 	"context"
+	"github.com/pkg/errors"
 	"github.com/valyala/fastjson"
 	"sync"
 	"time"
@@ -29,10 +30,39 @@ import (
 type Type int
 
 const (
+	// TypeDeduper groups items by their keys, and only keeps the latest item
+	// of every group.
 	TypeDeduper Type = iota
+
+	// TypeLimiter batches items together, up until a limit on the total size
+	// of a batch is reached.
 	TypeLimiter
 )
 
+// ParseType parses the type of a debouncer from its name, which is either
+// "group" for TypeDeduper, or "batch" for TypeLimiter.
+func ParseType(name string) (Type, error) {
+	switch name {
+	case "group":
+		return TypeDeduper, nil
+	case "batch":
+		return TypeLimiter, nil
+	}
+
+	return 0, errors.Errorf("unknown debouncer type %q: must be either \"group\" or \"batch\"", name)
+}
+
+func (t Type) String() string {
+	switch t {
+	case TypeDeduper:
+		return "group"
+	case TypeLimiter:
+		return "batch"
+	}
+
+	return "unknown"
+}
+
 type Factory struct {
 	t    Type
 	opts []ConfigOption
@@ -53,8 +83,14 @@ func (f Factory) Init(ctx context.Context, opts ...ConfigOption) Debouncer {
 	panic("Invalid debouncer type was specified.")
 }
 
+// Debouncer collects items, and passes them in bulk to an action. Debouncers
+// flush the items they have pending once their context is canceled.
 type Debouncer interface {
 	Add(...PayloadOption)
+
+	// Flush immediately passes all pending items to the action of the
+	// debouncer, and returns once the action has been called.
+	Flush()
 }
 
 var (
@@ -84,17 +120,12 @@ func NewDeduper(ctx context.Context, opts ...ConfigOption) *Deduper {
 		for {
 			select {
 			case <-ctx.Done():
+				d.Flush()
 				return
 			case <-d.timer.C:
-				d.mu.Lock()
-				payload := make([][]byte, 0, len(d.set))
-				for _, v := range d.set {
-					payload = append(payload, v)
+				if payload := d.take(); len(payload) > 0 {
+					go d.action(payload)
 				}
-
-				action := d.action
-				d.mu.Unlock()
-				go action(payload)
 			}
 		}
 	}()
@@ -102,6 +133,29 @@ func NewDeduper(ctx context.Context, opts ...ConfigOption) *Deduper {
 	return d
 }
 
+// take removes and returns all pending items.
+func (d *Deduper) take() [][]byte {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	payload := make([][]byte, 0, len(d.set))
+	for _, v := range d.set {
+		payload = append(payload, v)
+	}
+
+	d.set = make(map[string][]byte)
+
+	return payload
+}
+
+func (d *Deduper) Flush() {
+	d.timer.Stop()
+
+	if payload := d.take(); len(payload) > 0 {
+		d.action(payload)
+	}
+}
+
 func (d *Deduper) Add(oss ...PayloadOption) {
 	o := parsePayload(oss)
 
@@ -119,6 +173,10 @@ func (d *Deduper) Add(oss ...PayloadOption) {
 	d.set[key] = o.buf
 	d.timer.Reset(d.period)
 	d.mu.Unlock()
+
+	if o.priority {
+		d.Flush()
+	}
 }
 
 type Limiter struct {
@@ -142,21 +200,11 @@ func NewLimiter(ctx context.Context, opts ...ConfigOption) *Limiter {
 		for {
 			select {
 			case <-ctx.Done():
+				d.Flush()
 				return
 			case <-d.timer.C:
-				var buffer [][]byte
-				var action func([][]byte)
-
-				d.mu.Lock()
-				if d.bufferOffset > 0 {
-					action = d.action
-					buffer = d.buffer
-					d.buffer = nil
-					d.bufferOffset = 0
-				}
-				d.mu.Unlock()
-				if action != nil {
-					go action(buffer)
+				if buffer := d.take(); buffer != nil {
+					go d.action(buffer)
 				}
 			}
 		}
@@ -165,6 +213,30 @@ func NewLimiter(ctx context.Context, opts ...ConfigOption) *Limiter {
 	return d
 }
 
+// take removes and returns all pending items.
+func (d *Limiter) take() [][]byte {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.bufferOffset == 0 {
+		return nil
+	}
+
+	buffer := d.buffer
+	d.buffer = nil
+	d.bufferOffset = 0
+
+	return buffer
+}
+
+func (d *Limiter) Flush() {
+	d.timer.Stop()
+
+	if buffer := d.take(); buffer != nil {
+		d.action(buffer)
+	}
+}
+
 func (d *Limiter) Add(oss ...PayloadOption) {
 	o := parsePayload(oss)
 
@@ -190,6 +262,18 @@ func (d *Limiter) Add(oss ...PayloadOption) {
 
 	d.mu.Unlock()
 
+	// Preserve the order of items should the item bypass debouncing.
+
+	if o.priority {
+		if action != nil {
+			action(buffer)
+		}
+
+		d.Flush()
+
+		return
+	}
+
 	if action != nil {
 		go action(buffer)
 	}
@@ -134,3 +134,90 @@ func TestDeduper(t *testing.T) {
 	assert.Equal(t, 1, called)
 	assert.Equal(t, 2, size)
 }
+
+func TestFlush(t *testing.T) {
+	var batches [][][]byte
+
+	action := func(batch [][]byte) {
+		batches = append(batches, batch)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	for _, d := range []Debouncer{
+		NewLimiter(ctx, WithAction(action), WithPeriod(1*time.Hour)),
+		NewDeduper(ctx, WithAction(action), WithPeriod(1*time.Hour), WithKeys("test")),
+	} {
+		batches = nil
+
+		d.Add(Bytes([]byte(`{"test": "a"}`)))
+		d.Add(Bytes([]byte(`{"test": "b"}`)))
+
+		d.Flush()
+
+		assert.Len(t, batches, 1, "pending items must be flushed immediately")
+		assert.Len(t, batches[0], 2)
+
+		d.Flush()
+
+		assert.Len(t, batches, 1, "flushed items must not be flushed again")
+	}
+}
+
+func TestFlushOnCancel(t *testing.T) {
+	flushed := make(chan [][]byte, 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	d := NewLimiter(ctx, WithAction(func(batch [][]byte) { flushed <- batch }), WithPeriod(1*time.Hour))
+	d.Add(Bytes([]byte(`{}`)))
+
+	cancel()
+
+	select {
+	case batch := <-flushed:
+		assert.Len(t, batch, 1)
+	case <-time.After(1 * time.Second):
+		assert.Fail(t, "pending items must be flushed once the context is canceled")
+	}
+}
+
+func TestParseType(t *testing.T) {
+	for _, typ := range []Type{TypeDeduper, TypeLimiter} {
+		parsed, err := ParseType(typ.String())
+		assert.NoError(t, err)
+		assert.Equal(t, typ, parsed)
+	}
+
+	_, err := ParseType("nope")
+	assert.Error(t, err)
+}
+
+func TestPriority(t *testing.T) {
+	var batches [][][]byte
+
+	action := func(batch [][]byte) {
+		batches = append(batches, batch)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	for _, d := range []Debouncer{
+		NewLimiter(ctx, WithAction(action), WithPeriod(1*time.Hour)),
+		NewDeduper(ctx, WithAction(action), WithPeriod(1*time.Hour), WithKeys("test")),
+	} {
+		batches = nil
+
+		d.Add(Bytes([]byte(`{"test": "a"}`)))
+
+		assert.Len(t, batches, 0)
+
+		d.Add(Bytes([]byte(`{"test": "b"}`)), Priority())
+
+		if assert.Len(t, batches, 1, "priority items must bypass debouncing") {
+			assert.Len(t, batches[0], 2, "items pending before a priority item must be delivered alongside it")
+		}
+	}
+}
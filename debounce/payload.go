@@ -21,6 +21,8 @@ package debounce
 
 type Payload struct {
 	buf []byte
+
+	priority bool
 }
 
 type PayloadOption func(o *Payload)
@@ -40,3 +42,11 @@ func Bytes(buf []byte) PayloadOption {
 		o.buf = buf
 	}
 }
+
+// Priority has an item bypass debouncing. The item, alongside all items that
+// are pending before it, is immediately passed to the action of the debouncer.
+func Priority() PayloadOption {
+	return func(o *Payload) {
+		o.priority = true
+	}
+}
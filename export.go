@@ -0,0 +1,249 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/hex"
+	"io"
+	"strconv"
+
+	"github.com/perlin-network/wavelet/avl"
+	"github.com/pkg/errors"
+)
+
+// ExportFormat is the encoding which accounts are exported in.
+type ExportFormat string
+
+const (
+	ExportCSV  ExportFormat = "csv"
+	ExportJSON ExportFormat = "json"
+)
+
+func ParseExportFormat(format string) (ExportFormat, error) {
+	switch f := ExportFormat(format); f {
+	case ExportCSV, ExportJSON:
+		return f, nil
+	}
+
+	return "", errors.Errorf("unknown export format %q", format)
+}
+
+// ExportedAccount is a single row of an account export.
+type ExportedAccount struct {
+	ID       AccountID
+	Balance  uint64
+	Stake    uint64
+	Contract bool
+}
+
+// IterateAccounts calls fn with the ID of every account that has a balance,
+// stake, reward or smart contract recorded in tree, in lexicographical order of
+// account IDs, until fn returns false. Accounts are merged out of each key space
+// one at a time, such that no more than a handful of keys are held in memory at
+// any point regardless of the number of accounts.
+func IterateAccounts(tree *avl.Tree, fn func(id AccountID) bool) {
+	spaces := [][]byte{keyAccountBalance[:], keyAccountStake[:], keyAccountReward[:], keyAccountContractCode[:]}
+
+	prefixes := make([][]byte, len(spaces))
+	for i, space := range spaces {
+		prefixes[i] = append(keyAccounts[:], space...)
+	}
+
+	var after *AccountID
+
+	for {
+		var next AccountID
+		var found bool
+
+		for _, prefix := range prefixes {
+			id, exists := nextAccountUnder(tree, prefix, after)
+
+			if exists && (!found || bytes.Compare(id[:], next[:]) < 0) {
+				next, found = id, true
+			}
+		}
+
+		if !found || !fn(next) {
+			return
+		}
+
+		after = &next
+	}
+}
+
+// nextAccountUnder returns the smallest account ID recorded under prefix in
+// tree that is greater than after, or the smallest account ID should after be
+// nil.
+func nextAccountUnder(tree *avl.Tree, prefix []byte, after *AccountID) (AccountID, bool) {
+	start := prefix
+
+	if after != nil {
+		start = make([]byte, 0, len(prefix)+SizeAccountID+1)
+		start = append(start, prefix...)
+		start = append(start, after[:]...)
+		start = append(start, 0)
+	}
+
+	var id AccountID
+	var found bool
+
+	tree.IterateFrom(start, func(key, value []byte) bool {
+		if !bytes.HasPrefix(key, prefix) {
+			return false
+		}
+
+		if len(key) != len(prefix)+SizeAccountID {
+			return true
+		}
+
+		copy(id[:], key[len(prefix):])
+		found = true
+
+		return false
+	})
+
+	return id, found
+}
+
+// ExportAccounts streams the balance and stake of every account in tree to w,
+// alongside whether or not the account is a smart contract. Should round not
+// be nil, balances and stakes are exported as of the end of the specified round
+// from history instead, skipping accounts that did not yet exist at the time.
+func ExportAccounts(w io.Writer, format ExportFormat, tree *avl.Tree, history *History, round *uint64) error {
+	if round != nil && history == nil {
+		return errors.New("exporting accounts as of a past round is only supported by archival nodes")
+	}
+
+	var enc accountEncoder
+
+	switch format {
+	case ExportCSV:
+		enc = &csvAccountEncoder{w: csv.NewWriter(w)}
+	case ExportJSON:
+		enc = &jsonAccountEncoder{w: bufio.NewWriter(w)}
+	default:
+		return errors.Errorf("unknown export format %q", format)
+	}
+
+	if err := enc.begin(); err != nil {
+		return err
+	}
+
+	var err error
+
+	IterateAccounts(tree, func(id AccountID) bool {
+		account := ExportedAccount{ID: id}
+
+		if round != nil {
+			state, exists := history.Lookup(id, *round)
+			if !exists {
+				return true
+			}
+
+			account.Balance, account.Stake = state.Balance, state.Stake
+		} else {
+			account.Balance, _ = ReadAccountBalance(tree, id)
+			account.Stake, _ = ReadAccountStake(tree, id)
+		}
+
+		_, account.Contract = ReadAccountContractCode(tree, id)
+
+		err = enc.encode(account)
+
+		return err == nil
+	})
+
+	if err != nil {
+		return errors.Wrap(err, "failed to export account")
+	}
+
+	return enc.end()
+}
+
+type accountEncoder interface {
+	begin() error
+	encode(account ExportedAccount) error
+	end() error
+}
+
+type csvAccountEncoder struct {
+	w *csv.Writer
+}
+
+func (e *csvAccountEncoder) begin() error {
+	return e.w.Write([]string{"id", "balance", "stake", "contract"})
+}
+
+func (e *csvAccountEncoder) encode(account ExportedAccount) error {
+	return e.w.Write([]string{
+		hex.EncodeToString(account.ID[:]),
+		strconv.FormatUint(account.Balance, 10),
+		strconv.FormatUint(account.Stake, 10),
+		strconv.FormatBool(account.Contract),
+	})
+}
+
+func (e *csvAccountEncoder) end() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+type jsonAccountEncoder struct {
+	w     *bufio.Writer
+	count int
+}
+
+func (e *jsonAccountEncoder) begin() error {
+	return e.w.WriteByte('[')
+}
+
+func (e *jsonAccountEncoder) encode(account ExportedAccount) error {
+	if e.count > 0 {
+		_ = e.w.WriteByte(',')
+	}
+
+	e.count++
+
+	buf := make([]byte, 0, 128)
+
+	buf = append(buf, `{"id":"`...)
+	buf = append(buf, hex.EncodeToString(account.ID[:])...)
+	buf = append(buf, `","balance":`...)
+	buf = strconv.AppendUint(buf, account.Balance, 10)
+	buf = append(buf, `,"stake":`...)
+	buf = strconv.AppendUint(buf, account.Stake, 10)
+	buf = append(buf, `,"contract":`...)
+	buf = strconv.AppendBool(buf, account.Contract)
+	buf = append(buf, '}')
+
+	_, err := e.w.Write(buf)
+	return err
+}
+
+func (e *jsonAccountEncoder) end() error {
+	if err := e.w.WriteByte(']'); err != nil {
+		return err
+	}
+
+	return e.w.Flush()
+}
@@ -0,0 +1,120 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/perlin-network/wavelet/avl"
+	"github.com/perlin-network/wavelet/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExportAccounts(t *testing.T) {
+	kv := store.NewInmem()
+	tree := avl.New(kv)
+	history := NewHistory(kv)
+
+	alice, bob, contract := AccountID{1}, AccountID{2}, AccountID{3}
+
+	WriteAccountBalance(tree, alice, 100)
+	WriteAccountStake(tree, alice, 10)
+	assert.NoError(t, history.RecordAll(1, tree))
+
+	WriteAccountBalance(tree, alice, 60)
+	WriteAccountStake(tree, bob, 40)
+	WriteAccountContractCode(tree, contract, []byte("code"))
+	assert.NoError(t, history.Record(2, tree, 1))
+
+	var buf bytes.Buffer
+
+	assert.NoError(t, ExportAccounts(&buf, ExportCSV, tree, nil, nil))
+	assert.Equal(t, "id,balance,stake,contract\n"+
+		"01"+zeros(31)+",60,10,false\n"+
+		"02"+zeros(31)+",0,40,false\n"+
+		"03"+zeros(31)+",0,0,true\n", buf.String())
+
+	buf.Reset()
+
+	round := uint64(1)
+
+	assert.NoError(t, ExportAccounts(&buf, ExportJSON, tree, history, &round))
+	assert.Equal(t, `[{"id":"01`+zeros(31)+`","balance":100,"stake":10,"contract":false}]`, buf.String())
+
+	assert.Error(t, ExportAccounts(&buf, ExportJSON, tree, nil, &round))
+
+	_, err := ParseExportFormat("xml")
+	assert.Error(t, err)
+}
+
+func TestIterateAccounts(t *testing.T) {
+	tree := avl.New(store.NewInmem())
+
+	var expected []AccountID
+
+	for i := 0; i < 64; i++ {
+		id := AccountID{byte(i * 3), byte(i)}
+		expected = append(expected, id)
+
+		switch i % 4 {
+		case 0:
+			WriteAccountBalance(tree, id, 1)
+		case 1:
+			WriteAccountStake(tree, id, 1)
+			WriteAccountBalance(tree, id, 1)
+		case 2:
+			WriteAccountReward(tree, id, 1)
+		case 3:
+			WriteAccountContractCode(tree, id, []byte("code"))
+		}
+	}
+
+	// Keys which are not exactly an account ID long are not accounts.
+
+	tree.Insert(append(append(keyAccounts[:], keyAccountBalance[:]...), 0xff), []byte{1})
+
+	var ids []AccountID
+
+	IterateAccounts(tree, func(id AccountID) bool {
+		ids = append(ids, id)
+		return true
+	})
+
+	assert.Equal(t, expected, ids)
+
+	ids = ids[:0]
+
+	IterateAccounts(tree, func(id AccountID) bool {
+		ids = append(ids, id)
+		return len(ids) < 10
+	})
+
+	assert.Equal(t, expected[:10], ids)
+
+	IterateAccounts(avl.New(store.NewInmem()), func(id AccountID) bool {
+		t.Fatal("an empty tree has no accounts")
+		return false
+	})
+}
+
+func zeros(n int) string {
+	return string(bytes.Repeat([]byte("00"), n))
+}
@@ -0,0 +1,304 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math/bits"
+	"sync"
+	"time"
+
+	"github.com/perlin-network/wavelet/store"
+	"github.com/perlin-network/wavelet/sys"
+	"github.com/pkg/errors"
+)
+
+// Fork records that two different rounds have been observed to be finalized under
+// the same view ID. Local is the round we had finalized, and Remote is the round
+// our peers vouched for having finalized instead.
+type Fork struct {
+	Local  Round
+	Remote Round
+
+	Time time.Time
+}
+
+func (f Fork) Marshal() []byte {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], uint64(f.Time.UnixNano()))
+
+	return append(append(f.Local.Marshal(), f.Remote.Marshal()...), buf[:]...)
+}
+
+func UnmarshalFork(r io.Reader) (f Fork, err error) {
+	if f.Local, err = UnmarshalRound(r); err != nil {
+		err = errors.Wrap(err, "failed to decode local round of fork")
+		return
+	}
+
+	if f.Remote, err = UnmarshalRound(r); err != nil {
+		err = errors.Wrap(err, "failed to decode remote round of fork")
+		return
+	}
+
+	var buf [8]byte
+
+	if _, err = io.ReadFull(r, buf[:]); err != nil {
+		err = errors.Wrap(err, "failed to decode time of fork")
+		return
+	}
+
+	f.Time = time.Unix(0, int64(binary.BigEndian.Uint64(buf[:])))
+
+	return
+}
+
+// Forks persists all forks a node has observed, and whether or not the node has
+// halted committing any further changes to its state pending operator action.
+type Forks struct {
+	kv store.KV
+}
+
+func NewForks(kv store.KV) *Forks {
+	return &Forks{kv: kv}
+}
+
+// Record persists a fork, and halts the node.
+func (f *Forks) Record(fork Fork) error {
+	length := f.Len()
+
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], length)
+
+	batch := f.kv.NewWriteBatch()
+	defer batch.Destroy()
+
+	batch.Put(append(keyForks[:], buf[:]...), fork.Marshal())
+
+	binary.BigEndian.PutUint64(buf[:], length+1)
+	batch.Put(keyForksLen[:], buf[:])
+
+	batch.Put(keyHalted[:], []byte{1})
+
+	if err := f.kv.CommitWriteBatch(batch); err != nil {
+		return errors.Wrap(err, "forks: failed to record fork")
+	}
+
+	return nil
+}
+
+// Len returns the number of forks that have been recorded.
+func (f *Forks) Len() uint64 {
+	buf, err := f.kv.Get(keyForksLen[:])
+	if err != nil || len(buf) != 8 {
+		return 0
+	}
+
+	return binary.BigEndian.Uint64(buf)
+}
+
+// All returns all recorded forks, from the oldest to the most recent.
+func (f *Forks) All() ([]Fork, error) {
+	length := f.Len()
+	forks := make([]Fork, 0, length)
+
+	var buf [8]byte
+
+	for i := uint64(0); i < length; i++ {
+		binary.BigEndian.PutUint64(buf[:], i)
+
+		raw, err := f.kv.Get(append(keyForks[:], buf[:]...))
+		if err != nil {
+			return nil, errors.Wrapf(err, "forks: failed to read fork %d", i)
+		}
+
+		fork, err := UnmarshalFork(bytes.NewReader(raw))
+		if err != nil {
+			return nil, err
+		}
+
+		forks = append(forks, fork)
+	}
+
+	return forks, nil
+}
+
+// Halted returns true if the node has stopped committing changes to its state
+// due to a fork having been observed.
+func (f *Forks) Halted() bool {
+	buf, err := f.kv.Get(keyHalted[:])
+	return err == nil && len(buf) == 1 && buf[0] == 1
+}
+
+// Resume has the node resume committing changes to its state.
+func (f *Forks) Resume() error {
+	if err := f.kv.Put(keyHalted[:], []byte{0}); err != nil {
+		return errors.Wrap(err, "forks: failed to resume")
+	}
+
+	return nil
+}
+
+// maxForkWitnesses caps the number of conflicting rounds peers are tallied as
+// having vouched for at any given time.
+const maxForkWitnesses = 64
+
+// forkWitnesses tallies the peers which claim to have finalized rounds that
+// conflict with rounds we have finalized under the same view ID.
+type forkWitnesses struct {
+	sync.Mutex
+	rounds map[RoundID]*forkWitness
+}
+
+type forkWitness struct {
+	voters   map[AccountID]struct{}
+	weight   uint64
+	recorded bool
+}
+
+func newForkWitnesses() *forkWitnesses {
+	return &forkWitnesses{rounds: make(map[RoundID]*forkWitness)}
+}
+
+// witness tallies that voter, whose vote weighs weight, vouches for round. It
+// returns the total weight of all voters which vouched for round, and whether
+// or not a fork has already been recorded for round.
+func (w *forkWitnesses) witness(round RoundID, voter AccountID, weight uint64) (uint64, bool) {
+	w.Lock()
+	defer w.Unlock()
+
+	witness, exists := w.rounds[round]
+
+	if !exists {
+		if len(w.rounds) >= maxForkWitnesses {
+			w.rounds = make(map[RoundID]*forkWitness)
+		}
+
+		witness = &forkWitness{voters: make(map[AccountID]struct{})}
+		w.rounds[round] = witness
+	}
+
+	if _, voted := witness.voters[voter]; !voted {
+		witness.voters[voter] = struct{}{}
+		witness.weight += weight
+	}
+
+	return witness.weight, witness.recorded
+}
+
+// markRecorded marks that a fork has been recorded for round, such that the
+// same fork is not recorded again as more peers vouch for it.
+func (w *forkWitnesses) markRecorded(round RoundID) {
+	w.Lock()
+	defer w.Unlock()
+
+	if witness, exists := w.rounds[round]; exists {
+		witness.recorded = true
+	}
+}
+
+// exceedsThird returns true if weight is more than a third of total. Both sides
+// are compared as 128-bit products such that neither overflows.
+func exceedsThird(weight, total uint64) bool {
+	hi, lo := bits.Mul64(weight, 3)
+	return hi > 0 || lo > total
+}
+
+// witnessRound is called with a round that voter, whose identity is
+// authenticated by our connection to them, claims to have finalized. Should the
+// round conflict with a round we have finalized under the same view ID, voter
+// is tallied as having vouched for it. Once voters weighing more than a third
+// of the total weight of all voters vouch for the same conflicting round, at
+// least one honest voter must have finalized it, such that the fork is
+// recorded, a critical event is emitted, and the node is halted. It returns
+// true if a fork was recorded.
+func (l *Ledger) witnessRound(voter AccountID, round *Round) bool {
+	existing, err := l.rounds.GetByIndex(round.Index)
+	if err != nil || existing.ID == round.ID {
+		return false
+	}
+
+	minimumStake := l.Param(sys.ParamMinimumStake)
+
+	weight, eligible := l.weighting.WeighAccount(l.accounts, voter, minimumStake)
+	if !eligible {
+		return false
+	}
+
+	tallied, recorded := l.witnesses.witness(round.ID, voter, weight)
+	if recorded || !exceedsThird(tallied, l.weighting.TotalWeight(l.accounts.Snapshot(), minimumStake)) {
+		return false
+	}
+
+	l.witnesses.markRecorded(round.ID)
+	l.recordFork(existing, round)
+
+	return true
+}
+
+// recordFork records that remote was finalized under the same view ID as local,
+// emits a critical event, and halts the node.
+func (l *Ledger) recordFork(local, remote *Round) {
+	fork := Fork{Local: *local, Remote: *remote, Time: time.Now()}
+
+	if err := l.forks.Record(fork); err != nil {
+		logger := l.logs.Node()
+		logger.Error().Err(err).Msg("Failed to persist fork.")
+	}
+
+	logger := l.logs.Consensus("fork")
+	logger.Error().
+		Uint64("view_id", remote.Index).
+		Hex("local_round_id", local.ID[:]).
+		Hex("local_root", local.End.ID[:]).
+		Hex("local_merkle_root", local.Merkle[:]).
+		Hex("remote_round_id", remote.ID[:]).
+		Hex("remote_root", remote.End.ID[:]).
+		Hex("remote_merkle_root", remote.Merkle[:]).
+		Msg("CRITICAL: observed two different finalized rounds for the same view ID. Halting all state commits pending operator action.")
+
+	l.alerts.Forked(remote.Index)
+}
+
+// Halted returns true if the ledger has stopped committing changes to its state
+// due to a fork having been observed.
+func (l *Ledger) Halted() bool {
+	return l.forks.Halted()
+}
+
+// Forks returns all forks the ledger has observed.
+func (l *Ledger) Forks() ([]Fork, error) {
+	return l.forks.All()
+}
+
+// Resume has the ledger resume committing changes to its state after having
+// been halted due to a fork. It should only be called by an operator once the
+// fork has been investigated.
+func (l *Ledger) Resume() error {
+	if err := l.forks.Resume(); err != nil {
+		return err
+	}
+
+	l.alerts.ForkResolved()
+
+	return nil
+}
@@ -0,0 +1,97 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"testing"
+	"time"
+
+	"github.com/perlin-network/noise/skademlia"
+	"github.com/perlin-network/wavelet/store"
+	"github.com/perlin-network/wavelet/sys"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestForksRecordAndResume(t *testing.T) {
+	keys, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	start := AttachSenderToTransaction(keys, NewTransaction(keys, sys.TagNop, nil))
+	a := AttachSenderToTransaction(keys, NewTransaction(keys, sys.TagNop, nil), &start)
+	b := AttachSenderToTransaction(keys, NewTransaction(keys, sys.TagTransfer, []byte("b")), &start)
+
+	local := NewRound(1, MerkleNodeID{1}, 1, start, a)
+	remote := NewRound(1, MerkleNodeID{2}, 1, start, b)
+
+	forks := NewForks(store.NewInmem())
+	assert.False(t, forks.Halted())
+
+	fork := Fork{Local: local, Remote: remote, Time: time.Unix(0, 42)}
+	assert.NoError(t, forks.Record(fork))
+
+	assert.True(t, forks.Halted())
+
+	all, err := forks.All()
+	assert.NoError(t, err)
+	assert.Len(t, all, 1)
+	assert.Equal(t, local.ID, all[0].Local.ID)
+	assert.Equal(t, remote.ID, all[0].Remote.ID)
+	assert.Equal(t, fork.Time.UnixNano(), all[0].Time.UnixNano())
+
+	assert.NoError(t, forks.Resume())
+	assert.False(t, forks.Halted())
+	assert.EqualValues(t, 1, forks.Len())
+}
+
+func TestWitnessConflictingRounds(t *testing.T) {
+	keys, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	ledger := NewLedger(store.NewInmem(), skademlia.NewClient(":0", keys), nil)
+	defer ledger.Close()
+
+	voters := []AccountID{{1}, {2}, {3}, {4}}
+
+	snapshot := ledger.accounts.Snapshot()
+	for _, voter := range voters {
+		WriteAccountStake(snapshot, voter, 1000000000)
+	}
+	assert.NoError(t, ledger.accounts.Commit(snapshot))
+
+	local := ledger.rounds.Latest()
+	remote := NewRound(local.Index, MerkleNodeID{9}, local.Applied, local.Start, local.End)
+
+	assert.False(t, ledger.witnessRound(voters[0], local), "rounds we have finalized are not forks")
+
+	assert.False(t, ledger.witnessRound(voters[0], &remote), "a fork must not be recorded on the word of a minority of stake")
+	assert.False(t, ledger.witnessRound(voters[0], &remote), "a voter must not vouch for the same round twice")
+	assert.False(t, ledger.Halted())
+
+	assert.True(t, ledger.witnessRound(voters[1], &remote), "a fork must be recorded once more than a third of stake vouches for it")
+	assert.True(t, ledger.Halted())
+
+	assert.False(t, ledger.witnessRound(voters[2], &remote), "the same fork must only be recorded once")
+
+	forks, err := ledger.Forks()
+	assert.NoError(t, err)
+	assert.Len(t, forks, 1)
+	assert.Equal(t, local.ID, forks[0].Local.ID)
+	assert.Equal(t, remote.ID, forks[0].Remote.ID)
+}
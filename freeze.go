@@ -0,0 +1,86 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"encoding/binary"
+
+	"github.com/perlin-network/wavelet/avl"
+	"github.com/pkg/errors"
+)
+
+// Freeze records which freeze authority froze an account, and as of which round.
+// A frozen account may not move any of its PERLs, be it by transferring them,
+// staking them, or paying gas or fees with them, until an authority unfreezes
+// it. Recovering a frozen account freezes the account it is recovered to.
+type Freeze struct {
+	Authority AccountID
+	Round     uint64
+}
+
+func ReadAccountFreezeAuthority(tree *avl.Tree, id AccountID) bool {
+	buf, exists := readUnderAccounts(tree, id, keyAccountFreezeAuthority[:])
+	return exists && len(buf) == 1 && buf[0] == 1
+}
+
+func WriteAccountFreezeAuthority(tree *avl.Tree, id AccountID, authority bool) {
+	if !authority {
+		deleteUnderAccounts(tree, id, keyAccountFreezeAuthority[:])
+		return
+	}
+
+	writeUnderAccounts(tree, id, keyAccountFreezeAuthority[:], []byte{1})
+}
+
+func ReadAccountFrozen(tree *avl.Tree, id AccountID) (Freeze, bool) {
+	var freeze Freeze
+
+	buf, exists := readUnderAccounts(tree, id, keyAccountFrozen[:])
+	if !exists || len(buf) != SizeAccountID+8 {
+		return freeze, false
+	}
+
+	copy(freeze.Authority[:], buf[:SizeAccountID])
+	freeze.Round = binary.BigEndian.Uint64(buf[SizeAccountID:])
+
+	return freeze, true
+}
+
+func WriteAccountFrozen(tree *avl.Tree, id AccountID, freeze Freeze) {
+	buf := make([]byte, SizeAccountID+8)
+
+	copy(buf[:SizeAccountID], freeze.Authority[:])
+	binary.BigEndian.PutUint64(buf[SizeAccountID:], freeze.Round)
+
+	writeUnderAccounts(tree, id, keyAccountFrozen[:], buf)
+}
+
+func DeleteAccountFrozen(tree *avl.Tree, id AccountID) {
+	deleteUnderAccounts(tree, id, keyAccountFrozen[:])
+}
+
+// checkNotFrozen returns an error should the account have been frozen.
+func checkNotFrozen(tree *avl.Tree, id AccountID) error {
+	if freeze, frozen := ReadAccountFrozen(tree, id); frozen {
+		return errors.Errorf("%x was frozen by %x as of round %d", id, freeze.Authority, freeze.Round)
+	}
+
+	return nil
+}
@@ -0,0 +1,116 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/perlin-network/wavelet/avl"
+	"github.com/perlin-network/wavelet/store"
+	"github.com/perlin-network/wavelet/sys"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFreezeAccount(t *testing.T) {
+	authority, frozen, recipient := AccountID{1}, AccountID{2}, AccountID{3}
+
+	genesis := `{"` + "01" + zeros(31) + `": {"freeze_authority": true}, "` + "02" + zeros(31) + `": {"balance": 100}}`
+
+	tree := avl.New(store.NewInmem())
+	performInception(tree, &genesis)
+
+	assert.True(t, ReadAccountFreezeAuthority(tree, authority))
+	assert.False(t, ReadAccountFreezeAuthority(tree, frozen))
+
+	governance := func(creator AccountID, index uint64, opcode byte, account AccountID) error {
+		tx := &Transaction{Creator: creator, Sender: creator, Tag: sys.TagGovernance, Payload: append([]byte{opcode}, account[:]...)}
+		_, err := ApplyGovernanceTransaction(tree, &Round{Index: index}, tx)
+		return err
+	}
+
+	transfer := func() error {
+		payload := make([]byte, SizeAccountID+8)
+		copy(payload, recipient[:])
+		binary.LittleEndian.PutUint64(payload[SizeAccountID:], 10)
+
+		tx := &Transaction{Creator: frozen, Sender: frozen, Tag: sys.TagTransfer, Payload: payload}
+		_, err := ApplyTransferTransaction(tree, &Round{Index: 3}, tx, nil)
+		return err
+	}
+
+	assert.Error(t, governance(authority, 1, sys.FreezeAccount, frozen), "accounts may not be frozen before the upgrade introducing freezes activates")
+
+	WriteProtocolVersion(tree, sys.ProtocolFreeze)
+
+	assert.Error(t, governance(frozen, 1, sys.FreezeAccount, recipient), "only authorities may freeze accounts")
+	assert.Error(t, governance(authority, 1, sys.UnfreezeAccount, frozen), "accounts which are not frozen may not be unfrozen")
+
+	assert.NoError(t, governance(authority, 2, sys.FreezeAccount, frozen))
+	assert.Error(t, governance(authority, 2, sys.FreezeAccount, frozen), "frozen accounts may not be frozen twice")
+
+	freeze, exists := ReadAccountFrozen(tree, frozen)
+	assert.True(t, exists)
+	assert.Equal(t, Freeze{Authority: authority, Round: 2}, freeze)
+
+	assert.Error(t, transfer(), "frozen accounts may not transfer")
+
+	stake := &Transaction{Creator: frozen, Sender: frozen, Tag: sys.TagStake, Payload: []byte{sys.PlaceStake, 10, 0, 0, 0, 0, 0, 0, 0}}
+	_, err := ApplyStakeTransaction(tree, &Round{Index: 3}, stake)
+	assert.Error(t, err, "frozen accounts may not stake")
+
+	contract := &Transaction{Creator: frozen, Sender: frozen, Tag: sys.TagContract, Payload: Contract{GasLimit: 10, Code: []byte{1}}.Marshal()}
+	_, err = ApplyContractTransaction(tree, &Round{Index: 3}, contract, nil)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "frozen", "frozen accounts may not pay for gas")
+	}
+
+	balance, _ := ReadAccountBalance(tree, frozen)
+	assert.EqualValues(t, 100, balance)
+
+	assert.NoError(t, governance(authority, 4, sys.UnfreezeAccount, frozen))
+	assert.NoError(t, transfer())
+
+	balance, _ = ReadAccountBalance(tree, recipient)
+	assert.EqualValues(t, 10, balance)
+}
+
+func TestRecoveringFrozenAccountFreezesNewKey(t *testing.T) {
+	tree := avl.New(store.NewInmem())
+
+	lost, fresh, guardian, authority := AccountID{1}, AccountID{2}, AccountID{3}, AccountID{4}
+
+	WriteAccountBalance(tree, lost, 100)
+	WriteAccountFrozen(tree, lost, Freeze{Authority: authority, Round: 1})
+
+	apply := func(creator AccountID, payload []byte) error {
+		tx := &Transaction{Creator: creator, Sender: creator, Tag: sys.TagRecovery, Payload: payload}
+		_, err := ApplyRecoveryTransaction(tree, &Round{Index: 2}, tx)
+		return err
+	}
+
+	assert.NoError(t, apply(lost, setGuardiansPayload(1, 0, guardian)))
+	assert.NoError(t, apply(guardian, approveRecoveryPayload(lost, fresh)))
+	assert.NoError(t, apply(fresh, executeRecoveryPayload(lost)))
+
+	freeze, frozen := ReadAccountFrozen(tree, fresh)
+	assert.True(t, frozen, "recovering a frozen account must not lift its freeze")
+	assert.Equal(t, Freeze{Authority: authority, Round: 1}, freeze)
+}
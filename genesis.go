@@ -20,7 +20,13 @@
 package wavelet
 
 import (
+	"encoding/csv"
 	"encoding/hex"
+	"io"
+	"math/bits"
+	"strconv"
+	"strings"
+
 	"github.com/perlin-network/wavelet/avl"
 	"github.com/pkg/errors"
 	"github.com/valyala/fastjson"
@@ -42,7 +48,9 @@ const defaultGenesis = `
 `
 
 // performInception loads data expected to exist at the birth of any node in this ledgers network.
-// The data is fed in as .json.
+// The data is fed in as .json. Accounts with "freeze_authority" set to true may freeze
+// and unfreeze the transfers of other accounts, and accounts with "validator" set to true
+// make up the allowlist of validators of networks weighing votes by authority.
 func performInception(tree *avl.Tree, genesis *string) Round {
 	var buf []byte
 
@@ -79,6 +87,11 @@ func performInception(tree *avl.Tree, genesis *string) Round {
 		var id AccountID
 		var n int
 
+		if len(key) != hex.EncodedLen(SizeAccountID) {
+			err = errors.Errorf("got an invalid account ID: %x", key)
+			return
+		}
+
 		n, err = hex.Decode(id[:], key)
 
 		if n != cap(id) && err == nil {
@@ -136,6 +149,26 @@ func performInception(tree *avl.Tree, genesis *string) Round {
 				}
 
 				WriteAccountReward(tree, id, uint64(reward))
+			case "freeze_authority":
+				var authority bool
+
+				authority, err = v.Bool()
+				if err != nil {
+					err = errors.Wrapf(err, "failed to cast type for key %q", key)
+					return
+				}
+
+				WriteAccountFreezeAuthority(tree, id, authority)
+			case "validator":
+				var validator bool
+
+				validator, err = v.Bool()
+				if err != nil {
+					err = errors.Wrapf(err, "failed to cast type for key %q", key)
+					return
+				}
+
+				WriteAccountValidator(tree, id, validator)
 			}
 		})
 
@@ -154,3 +187,106 @@ func performInception(tree *avl.Tree, genesis *string) Round {
 
 	return NewRound(0, tree.Checksum(), 0, Transaction{}, tx)
 }
+
+// GenesisFromCSV converts an externally produced CSV of balances and stakes, such
+// as the results of a token sale, into genesis JSON which may be fed to NewLedger.
+//
+// The first row of the CSV must be a header naming its columns. An "id" column
+// holding hex-encoded account IDs is required, and "balance", "stake" and "reward"
+// columns are optional; all other columns are ignored, such that accounts exported
+// via ExportAccounts may be imported as is. Empty cells are treated as zero.
+//
+// An error is returned should any account ID be invalid or duplicated, or should
+// the sum of all balances, stakes and rewards not equal supply.
+func GenesisFromCSV(r io.Reader, supply uint64) (string, error) {
+	reader := csv.NewReader(r)
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return "", errors.Wrap(err, "genesis: failed to read csv header")
+	}
+
+	columns := make(map[string]int)
+
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	idColumn, exists := columns["id"]
+	if !exists {
+		return "", errors.New(`genesis: csv is missing an "id" column`)
+	}
+
+	var arena fastjson.Arena
+
+	o := arena.NewObject()
+	set := make(map[AccountID]struct{})
+
+	var total uint64
+	var overflow uint64
+
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return "", errors.Wrapf(err, "genesis: failed to read csv line %d", line)
+		}
+
+		var id AccountID
+
+		raw := []byte(strings.TrimSpace(record[idColumn]))
+
+		if len(raw) != hex.EncodedLen(SizeAccountID) {
+			return "", errors.Errorf("genesis: got an invalid account ID %q on csv line %d", record[idColumn], line)
+		}
+
+		if n, err := hex.Decode(id[:], raw); err != nil || n != SizeAccountID {
+			return "", errors.Errorf("genesis: got an invalid account ID %q on csv line %d", record[idColumn], line)
+		}
+
+		if _, exists := set[id]; exists {
+			return "", errors.Errorf("genesis: found duplicate entries for account ID %x on csv line %d", id, line)
+		}
+
+		set[id] = struct{}{}
+
+		fields := arena.NewObject()
+
+		for _, name := range []string{"balance", "stake", "reward"} {
+			column, exists := columns[name]
+			if !exists {
+				continue
+			}
+
+			raw := strings.TrimSpace(record[column])
+			if len(raw) == 0 {
+				continue
+			}
+
+			amount, err := strconv.ParseUint(raw, 10, 64)
+			if err != nil {
+				return "", errors.Wrapf(err, "genesis: got an invalid %s for account ID %x on csv line %d", name, id, line)
+			}
+
+			var carry uint64
+
+			total, carry = bits.Add64(total, amount, 0)
+			overflow += carry
+
+			fields.Set(name, arena.NewNumberString(raw))
+		}
+
+		o.Set(hex.EncodeToString(id[:]), fields)
+	}
+
+	if overflow > 0 || total != supply {
+		return "", errors.Errorf("genesis: csv allocates a total supply that does not equal the expected supply of %d", supply)
+	}
+
+	return string(o.MarshalTo(nil)), nil
+}
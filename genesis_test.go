@@ -0,0 +1,89 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/perlin-network/wavelet/avl"
+	"github.com/perlin-network/wavelet/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenesisFromCSV(t *testing.T) {
+	alice := "01" + zeros(31)
+	bob := "02" + zeros(31)
+
+	csv := "id,balance,stake,contract\n" +
+		alice + ",100,10,false\n" +
+		bob + ",,40,false\n"
+
+	genesis, err := GenesisFromCSV(strings.NewReader(csv), 150)
+	assert.NoError(t, err)
+
+	tree := avl.New(store.NewInmem())
+	performInception(tree, &genesis)
+
+	balance, _ := ReadAccountBalance(tree, AccountID{1})
+	assert.EqualValues(t, 100, balance)
+
+	stake, _ := ReadAccountStake(tree, AccountID{1})
+	assert.EqualValues(t, 10, stake)
+
+	stake, _ = ReadAccountStake(tree, AccountID{2})
+	assert.EqualValues(t, 40, stake)
+
+	assert.EqualValues(t, 2, ReadAccountsLen(tree))
+
+	_, err = GenesisFromCSV(strings.NewReader(csv), 149)
+	assert.Error(t, err, "total supply must match")
+
+	_, err = GenesisFromCSV(strings.NewReader(csv+alice+",1,0,false\n"), 151)
+	assert.Error(t, err, "duplicate accounts must be rejected")
+
+	_, err = GenesisFromCSV(strings.NewReader("balance\n100\n"), 100)
+	assert.Error(t, err, "an id column is required")
+
+	_, err = GenesisFromCSV(strings.NewReader("id,balance\nzz,100\n"), 100)
+	assert.Error(t, err)
+
+	_, err = GenesisFromCSV(strings.NewReader("id,balance\n"+alice+"00,100\n"), 100)
+	assert.Error(t, err, "account IDs which are too long must be rejected")
+
+	_, err = GenesisFromCSV(strings.NewReader("id,balance\n"+alice+",18446744073709551615\n"+bob+",1\n"), 0)
+	assert.Error(t, err, "overflowing the total supply must be rejected")
+}
+
+func TestGenesisInvalidAccountID(t *testing.T) {
+	for _, id := range []string{"01", "01" + zeros(31) + "00"} {
+		genesis := `{"` + id + `": {"balance": 1}}`
+
+		func() {
+			defer func() {
+				err, ok := recover().(error)
+				assert.True(t, ok, "an invalid account ID must be reported as an error")
+				assert.Contains(t, err.Error(), "invalid account ID")
+			}()
+
+			performInception(avl.New(store.NewInmem()), &genesis)
+		}()
+	}
+}
@@ -22,90 +22,478 @@ package wavelet
 import (
 	"context"
 	"github.com/perlin-network/noise/skademlia"
-	"github.com/perlin-network/wavelet/debounce"
 	"github.com/perlin-network/wavelet/log"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"io"
+	"sort"
 	"sync"
 	"time"
 )
 
+const (
+	// gossipKeepaliveInterval is how often an empty batch is sent over every
+	// gossip stream, such that broken streams are detected and recreated
+	// before any transactions are lost to them.
+	gossipKeepaliveInterval = 10 * time.Second
+
+	// gossipMaxRetries is how many times a batch is resent over a recreated
+	// gossip stream should sending it fail, before it is given up on.
+	gossipMaxRetries = 4
+
+	// gossipPeerQueueSize is how many batches may be queued up to be gossiped
+	// to a single peer. Should a peer fall further behind, the oldest batches
+	// queued up for it are dropped.
+	gossipPeerQueueSize = 64
+
+	// gossipPeerIdleTimeout is how long the send queue of a peer may sit empty
+	// before its worker stops, such that peers which are no longer gossiped
+	// to do not leak workers.
+	gossipPeerIdleTimeout = 5 * time.Minute
+)
+
+// gossipBackoff is the delay before first recreating a gossip stream which
+// failed to send a batch. The delay doubles after every failed retry, up to
+// maxGossipBackoff.
+var (
+	gossipBackoff    = 50 * time.Millisecond
+	maxGossipBackoff = 1 * time.Second
+)
+
 type Gossiper struct {
 	client  *skademlia.Client
+	peers   *Peers
 	metrics *Metrics
+	logs    *log.Scope
 
-	streams     map[string]Wavelet_GossipClient
+	streams     map[string]*gossipStream
 	streamsLock sync.Mutex
 
-	debouncer *debounce.Limiter
+	peerQueues     map[string]*gossipPeerQueue
+	peerQueuesLock sync.Mutex
+
+	queue *BroadcastQueue
+
+	ctx context.Context
 }
 
-func NewGossiper(ctx context.Context, client *skademlia.Client, metrics *Metrics) *Gossiper {
+func NewGossiper(ctx context.Context, client *skademlia.Client, peers *Peers, metrics *Metrics, logs *log.Scope) *Gossiper {
 	g := &Gossiper{
 		client:  client,
+		peers:   peers,
 		metrics: metrics,
+		logs:    logs,
+
+		streams:    make(map[string]*gossipStream),
+		peerQueues: make(map[string]*gossipPeerQueue),
 
-		streams: make(map[string]Wavelet_GossipClient),
+		queue: NewBroadcastQueue(DefaultBroadcastConfig(), metrics),
+
+		ctx: ctx,
 	}
 
-	g.debouncer = debounce.NewLimiter(
-		ctx,
-		debounce.WithAction(g.Gossip),
-		debounce.WithPeriod(100*time.Millisecond),
-		debounce.WithBufferLimit(16384),
-	)
+	go g.flush(ctx, 100*time.Millisecond, 16384)
+	go g.keepalive(ctx, gossipKeepaliveInterval)
 
 	return g
 }
 
-func (g *Gossiper) Push(tx Transaction) {
-	g.debouncer.Add(debounce.Bytes(tx.Marshal()))
+// Push queues up a transaction to be gossiped to our peers. Local transactions,
+// which originate from our own node, are gossiped ahead of relayed ones.
+// ErrQueueFull is returned should the broadcast queue overflow.
+func (g *Gossiper) Push(tx Transaction, local bool) error {
+	return g.queue.Push(tx, local)
+}
+
+// Reserve makes room for a transaction to be gossiped to our peers, without
+// queuing it up just yet. ErrQueueFull is returned should the broadcast queue
+// overflow.
+func (g *Gossiper) Reserve(tx Transaction, local bool) (*BroadcastReservation, error) {
+	return g.queue.Reserve(tx, local)
+}
+
+// Queue returns the queue of transactions pending to be gossiped.
+func (g *Gossiper) Queue() *BroadcastQueue {
+	return g.queue
+}
+
+// flush periodically gossips out all queued transactions in batches of at most
+// limit bytes. Batches are handed off to the send queue of every peer, such
+// that a slow peer only holds up gossip to itself.
+func (g *Gossiper) flush(ctx context.Context, period time.Duration, limit int) {
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
 
-	if g.metrics != nil {
-		g.metrics.gossipedTX.Mark(int64(tx.LogicalUnits()))
+		for {
+			batch := g.queue.Pop(limit)
+
+			if len(batch) == 0 {
+				break
+			}
+
+			g.Gossip(batch)
+		}
 	}
 }
 
+// Gossip queues up a batch of transactions to be sent to each of our closest
+// peers. Every peer is sent batches independently of the others by its own
+// worker, from a bounded queue which drops its oldest batches should the peer
+// fall behind.
 func (g *Gossiper) Gossip(transactions [][]byte) {
-	var err error
-
 	batch := &Transactions{Transactions: transactions}
 
-	conns := g.client.ClosestPeers()
+	ctx := g.rootContext()
 
-	var wg sync.WaitGroup
+	for _, conn := range g.peers.Closest(g.client) {
+		conn := conn
 
-	for _, conn := range conns {
-		target := conn.Target()
+		open := func() (Wavelet_GossipClient, error) {
+			return NewWaveletClient(conn).Gossip(ctx)
+		}
 
-		g.streamsLock.Lock()
-		stream, exists := g.streams[conn.Target()]
+		g.enqueue(conn.Target(), open, batch)
+	}
+}
+
+// rootContext returns the context every stream opened and every worker spawned
+// by the gossiper is bound to.
+func (g *Gossiper) rootContext() context.Context {
+	if g.ctx == nil {
+		return context.Background()
+	}
+
+	return g.ctx
+}
+
+// enqueue queues up a batch to be sent to target, starting a worker to send
+// batches to target should there not be one.
+func (g *Gossiper) enqueue(target string, open func() (Wavelet_GossipClient, error), batch *Transactions) {
+	g.peerQueuesLock.Lock()
+	defer g.peerQueuesLock.Unlock()
+
+	q, exists := g.peerQueues[target]
+
+	if !exists {
+		q = &gossipPeerQueue{signal: make(chan struct{}, 1)}
+		g.peerQueues[target] = q
+
+		go g.work(target, open, q)
+	}
 
-		if !exists {
-			client := NewWaveletClient(conn)
+	q.push(batch)
+}
+
+// work sends the batches queued up for target one after the other, until ctx
+// is cancelled or the queue sits empty for gossipPeerIdleTimeout.
+func (g *Gossiper) work(target string, open func() (Wavelet_GossipClient, error), q *gossipPeerQueue) {
+	ctx := g.rootContext()
+
+	idle := time.NewTimer(gossipPeerIdleTimeout)
+	defer idle.Stop()
+
+	for {
+		batch, ok := q.pop()
+
+		if !ok {
+			select {
+			case <-ctx.Done():
+				return
+			case <-q.signal:
+				continue
+			case <-idle.C:
+				// Only stop once it is certain that no batch was queued up
+				// in the meantime.
+
+				g.peerQueuesLock.Lock()
+				if q.len() == 0 {
+					delete(g.peerQueues, target)
+					g.peerQueuesLock.Unlock()
+					return
+				}
+				g.peerQueuesLock.Unlock()
 
-			if stream, err = client.Gossip(context.Background()); err != nil {
-				g.streamsLock.Unlock()
+				idle.Reset(gossipPeerIdleTimeout)
 				continue
 			}
+		}
 
-			g.streams[target] = stream
+		if err := g.gossipTo(target, open, batch); err != nil {
+			logger := g.logs.TX("gossip")
+			logger.Err(err).Str("target", target).Msg("Failed to send batch")
 		}
-		g.streamsLock.Unlock()
 
-		wg.Add(1)
+		q.markSent()
 
-		go func() {
-			if err := stream.Send(batch); err != nil {
-				logger := log.TX("gossip")
-				logger.Err(err).Msg("Failed to send batch")
+		if !idle.Stop() {
+			select {
+			case <-idle.C:
+			default:
+			}
+		}
+		idle.Reset(gossipPeerIdleTimeout)
+	}
+}
+
+// GossipLag describes how far behind gossip to a single peer is.
+type GossipLag struct {
+	Target string // Address of the peer.
+
+	Queued  int    // Number of batches queued up to be sent to the peer.
+	Sent    uint64 // Number of batches sent, or given up on sending, to the peer.
+	Dropped uint64 // Number of batches dropped as the queue of the peer was full.
+
+	Lag time.Duration // How long the oldest batch queued up for the peer has been waiting.
+}
+
+// Lag reports how far behind gossip to each peer is, sorted by address.
+func (g *Gossiper) Lag() []GossipLag {
+	g.peerQueuesLock.Lock()
+	lags := make([]GossipLag, 0, len(g.peerQueues))
+	for target, q := range g.peerQueues {
+		lags = append(lags, q.lag(target))
+	}
+	g.peerQueuesLock.Unlock()
+
+	sort.Slice(lags, func(i, j int) bool {
+		return lags[i].Target < lags[j].Target
+	})
+
+	return lags
+}
+
+type queuedGossip struct {
+	batch    *Transactions
+	queuedAt time.Time
+}
+
+// gossipPeerQueue is a bounded queue of batches to be sent to a single peer.
+type gossipPeerQueue struct {
+	sync.Mutex
+
+	batches []queuedGossip
+	signal  chan struct{}
+
+	numSent, numDropped uint64
+}
+
+// push queues up a batch, dropping the oldest batch queued up should the queue
+// be full.
+func (q *gossipPeerQueue) push(batch *Transactions) {
+	q.Lock()
+	if len(q.batches) >= gossipPeerQueueSize {
+		q.batches[0] = queuedGossip{}
+		q.batches = q.batches[1:]
+		q.numDropped++
+	}
+
+	q.batches = append(q.batches, queuedGossip{batch: batch, queuedAt: time.Now()})
+	q.Unlock()
+
+	select {
+	case q.signal <- struct{}{}:
+	default:
+	}
+}
+
+func (q *gossipPeerQueue) pop() (*Transactions, bool) {
+	q.Lock()
+	defer q.Unlock()
+
+	if len(q.batches) == 0 {
+		return nil, false
+	}
 
-				g.streamsLock.Lock()
-				delete(g.streams, target)
-				g.streamsLock.Unlock()
+	batch := q.batches[0].batch
+
+	q.batches[0] = queuedGossip{}
+	q.batches = q.batches[1:]
+
+	return batch, true
+}
+
+func (q *gossipPeerQueue) markSent() {
+	q.Lock()
+	q.numSent++
+	q.Unlock()
+}
+
+func (q *gossipPeerQueue) len() int {
+	q.Lock()
+	defer q.Unlock()
+
+	return len(q.batches)
+}
+
+func (q *gossipPeerQueue) lag(target string) GossipLag {
+	q.Lock()
+	defer q.Unlock()
+
+	lag := GossipLag{Target: target, Queued: len(q.batches), Sent: q.numSent, Dropped: q.numDropped}
+
+	if len(q.batches) > 0 {
+		lag.Lag = time.Since(q.batches[0].queuedAt)
+	}
+
+	return lag
+}
+
+// gossipStream is a gossip stream to a peer. Sends over the stream are
+// serialized, as gRPC streams may not be sent over concurrently.
+type gossipStream struct {
+	sync.Mutex
+	Wavelet_GossipClient
+
+	lastSent time.Time
+}
+
+func (s *gossipStream) send(batch *Transactions) error {
+	s.Lock()
+	defer s.Unlock()
+
+	if err := s.Send(batch); err != nil {
+		return err
+	}
+
+	s.lastSent = time.Now()
+
+	return nil
+}
+
+// status returns the error the peer closed the stream with should sending over
+// the stream have failed with err because the peer closed it.
+func (s *gossipStream) status(err error) error {
+	if err != io.EOF {
+		return err
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	if _, closed := s.CloseAndRecv(); closed != nil {
+		return closed
+	}
+
+	return err
+}
+
+// idle returns whether nothing was sent over the stream for at least d.
+func (s *gossipStream) idle(d time.Duration) bool {
+	s.Lock()
+	defer s.Unlock()
+
+	return time.Since(s.lastSent) >= d
+}
+
+// gossipTo sends a batch over the gossip stream to target, opening the stream
+// should there not be one. Should sending the batch fail, the stream is
+// recreated with exponential backoff, and the batch is resent over it. Should
+// the graph of target be full, it is given the longest backoff to catch up.
+func (g *Gossiper) gossipTo(target string, open func() (Wavelet_GossipClient, error), batch *Transactions) error {
+	backoff := gossipBackoff
+
+	for attempt := 0; ; attempt++ {
+		stream, err := g.stream(target, open)
+
+		if err == nil {
+			if err = stream.send(batch); err == nil {
+				return nil
 			}
 
-			wg.Done()
-		}()
+			err = stream.status(err)
+
+			g.drop(target, stream)
+
+			if status.Code(err) == codes.ResourceExhausted {
+				backoff = maxGossipBackoff
+			}
+		}
+
+		if attempt == gossipMaxRetries {
+			return err
+		}
+
+		time.Sleep(backoff)
+
+		if backoff *= 2; backoff > maxGossipBackoff {
+			backoff = maxGossipBackoff
+		}
+	}
+}
+
+// stream returns the gossip stream to target, opening it should there not be one.
+func (g *Gossiper) stream(target string, open func() (Wavelet_GossipClient, error)) (*gossipStream, error) {
+	g.streamsLock.Lock()
+	defer g.streamsLock.Unlock()
+
+	if stream, exists := g.streams[target]; exists {
+		return stream, nil
+	}
+
+	stream, err := open()
+	if err != nil {
+		return nil, err
+	}
+
+	s := &gossipStream{Wavelet_GossipClient: stream, lastSent: time.Now()}
+	g.streams[target] = s
+
+	return s, nil
+}
+
+// drop closes and forgets a broken gossip stream to target, such that it is
+// recreated the next time a batch is gossiped to target.
+func (g *Gossiper) drop(target string, stream *gossipStream) {
+	g.streamsLock.Lock()
+	if g.streams[target] == stream {
+		delete(g.streams, target)
 	}
+	g.streamsLock.Unlock()
+
+	_ = stream.CloseSend()
+}
+
+// keepalive periodically sends an empty batch over every gossip stream which
+// has been idle for interval until ctx is cancelled. Streams which fail to send
+// it are dropped.
+func (g *Gossiper) keepalive(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	ping := new(Transactions)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		g.streamsLock.Lock()
+		streams := make(map[string]*gossipStream, len(g.streams))
+		for target, stream := range g.streams {
+			streams[target] = stream
+		}
+		g.streamsLock.Unlock()
+
+		for target, stream := range streams {
+			if !stream.idle(interval) {
+				continue
+			}
 
-	wg.Wait()
+			if err := stream.send(ping); err != nil {
+				logger := g.logs.TX("gossip")
+				logger.Err(err).Str("target", target).Msg("Gossip stream failed its keepalive.")
+
+				g.drop(target, stream)
+			}
+		}
+	}
 }
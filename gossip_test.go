@@ -0,0 +1,253 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"context"
+	"github.com/perlin-network/wavelet/log"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"sync"
+	"testing"
+	"time"
+)
+
+type testGossipStream struct {
+	Wavelet_GossipClient
+
+	mu     sync.Mutex
+	fail   bool
+	sent   []*Transactions
+	closed bool
+
+	closedWith error // Error the peer closed the stream with.
+}
+
+func (s *testGossipStream) Send(batch *Transactions) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closedWith != nil {
+		return io.EOF
+	}
+
+	if s.fail {
+		return errors.New("broken stream")
+	}
+
+	s.sent = append(s.sent, batch)
+
+	return nil
+}
+
+func (s *testGossipStream) CloseSend() error {
+	s.mu.Lock()
+	s.closed = true
+	s.mu.Unlock()
+
+	return nil
+}
+
+func (s *testGossipStream) CloseAndRecv() (*Empty, error) {
+	return nil, s.closedWith
+}
+
+func newTestGossiper() *Gossiper {
+	return &Gossiper{streams: make(map[string]*gossipStream), peerQueues: make(map[string]*gossipPeerQueue), logs: log.NewScope("")}
+}
+
+func TestGossipResendsOverRecreatedStream(t *testing.T) {
+	defer func(initial time.Duration) { gossipBackoff = initial }(gossipBackoff)
+	gossipBackoff = time.Millisecond
+
+	g := newTestGossiper()
+
+	var (
+		opened   []*testGossipStream
+		attempts int
+	)
+
+	open := func() (Wavelet_GossipClient, error) {
+		if attempts++; attempts == 2 {
+			return nil, errors.New("peer is unreachable")
+		}
+
+		// Only the first stream to be opened is broken.
+		stream := &testGossipStream{fail: len(opened) == 0}
+		opened = append(opened, stream)
+
+		return stream, nil
+	}
+
+	batch := &Transactions{Transactions: [][]byte{{1, 2, 3}}}
+
+	assert.NoError(t, g.gossipTo("peer", open, batch))
+
+	if assert.Len(t, opened, 2) {
+		assert.True(t, opened[0].closed, "broken streams must be closed")
+		assert.Equal(t, []*Transactions{batch}, opened[1].sent, "the failed batch must be resent over the recreated stream")
+	}
+
+	assert.Equal(t, opened[1], g.streams["peer"].Wavelet_GossipClient)
+
+	// Batches are given up on after exhausting all retries.
+
+	attempts = 0
+
+	err := g.gossipTo("other", func() (Wavelet_GossipClient, error) {
+		attempts++
+		return &testGossipStream{fail: true}, nil
+	}, batch)
+
+	assert.Error(t, err)
+	assert.Equal(t, gossipMaxRetries+1, attempts)
+	assert.NotContains(t, g.streams, "other")
+}
+
+func TestGossipBacksOffFromFullPeers(t *testing.T) {
+	defer func(initial, max time.Duration) { gossipBackoff, maxGossipBackoff = initial, max }(gossipBackoff, maxGossipBackoff)
+	gossipBackoff, maxGossipBackoff = time.Millisecond, 50*time.Millisecond
+
+	g := newTestGossiper()
+
+	var opened []*testGossipStream
+
+	open := func() (Wavelet_GossipClient, error) {
+		stream := &testGossipStream{}
+
+		if len(opened) == 0 {
+			stream.closedWith = errGraphFull
+		}
+
+		opened = append(opened, stream)
+
+		return stream, nil
+	}
+
+	batch := &Transactions{Transactions: [][]byte{{1, 2, 3}}}
+
+	start := time.Now()
+
+	assert.NoError(t, g.gossipTo("full", open, batch))
+	assert.True(t, time.Since(start) >= maxGossipBackoff, "full peers must be backed off from for the longest")
+
+	if assert.Len(t, opened, 2) {
+		assert.Equal(t, []*Transactions{batch}, opened[1].sent)
+	}
+}
+
+func TestGossipKeepalive(t *testing.T) {
+	g := newTestGossiper()
+
+	healthy := &testGossipStream{}
+	broken := &testGossipStream{fail: true}
+
+	g.streams["healthy"] = &gossipStream{Wavelet_GossipClient: healthy}
+	g.streams["broken"] = &gossipStream{Wavelet_GossipClient: broken}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go g.keepalive(ctx, 10*time.Millisecond)
+
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+
+	g.streamsLock.Lock()
+	defer g.streamsLock.Unlock()
+
+	assert.Contains(t, g.streams, "healthy")
+	assert.NotContains(t, g.streams, "broken", "streams which fail their keepalive must be dropped")
+
+	healthy.mu.Lock()
+	defer healthy.mu.Unlock()
+
+	assert.NotEmpty(t, healthy.sent)
+	assert.Empty(t, healthy.sent[0].Transactions)
+}
+
+func TestGossipPeerQueueDropsOldest(t *testing.T) {
+	q := &gossipPeerQueue{signal: make(chan struct{}, 1)}
+
+	batches := make([]*Transactions, gossipPeerQueueSize+2)
+
+	for i := range batches {
+		batches[i] = &Transactions{Transactions: [][]byte{{byte(i)}}}
+		q.push(batches[i])
+	}
+
+	lag := q.lag("peer")
+	assert.Equal(t, gossipPeerQueueSize, lag.Queued)
+	assert.EqualValues(t, 2, lag.Dropped)
+
+	batch, ok := q.pop()
+	assert.True(t, ok)
+	assert.Equal(t, batches[2], batch, "the oldest batches must be dropped")
+}
+
+type blockingGossipStream struct {
+	Wavelet_GossipClient
+
+	unblock chan struct{}
+}
+
+func (s *blockingGossipStream) Send(*Transactions) error {
+	<-s.unblock
+	return nil
+}
+
+func TestGossipSlowPeerDoesNotBlockOthers(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	g := newTestGossiper()
+	g.ctx = ctx
+
+	slow := &blockingGossipStream{unblock: make(chan struct{})}
+	defer close(slow.unblock)
+
+	fast := &testGossipStream{}
+
+	batch := &Transactions{Transactions: [][]byte{{1}}}
+
+	for i := 0; i < 3; i++ {
+		g.enqueue("slow", func() (Wavelet_GossipClient, error) { return slow, nil }, batch)
+		g.enqueue("fast", func() (Wavelet_GossipClient, error) { return fast, nil }, batch)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+
+	fast.mu.Lock()
+	assert.Len(t, fast.sent, 3, "a slow peer must not hold up gossip to other peers")
+	fast.mu.Unlock()
+
+	lags := g.Lag()
+
+	if assert.Len(t, lags, 2) {
+		assert.Equal(t, "fast", lags[0].Target)
+		assert.EqualValues(t, 3, lags[0].Sent)
+		assert.Equal(t, 0, lags[0].Queued)
+
+		assert.Equal(t, "slow", lags[1].Target)
+		assert.Equal(t, 2, lags[1].Queued)
+		assert.True(t, lags[1].Lag > 0)
+	}
+}
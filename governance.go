@@ -0,0 +1,253 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math/bits"
+
+	"github.com/perlin-network/wavelet/avl"
+	"github.com/perlin-network/wavelet/sys"
+	"github.com/pkg/errors"
+)
+
+// Proposal is a change to a governable parameter of the protocol put up for a
+// vote by a staked account. The proposal passes once the accounts that voted
+// for it hold at least sys.GovernanceQuorum percent of all stake, after which
+// the parameter takes on Value from ActivationRound onwards.
+type Proposal struct {
+	Proposer AccountID
+
+	Param           byte
+	Value           uint64
+	ActivationRound uint64
+
+	Voters     []AccountID
+	VotedStake uint64
+
+	Passed bool
+}
+
+func (p Proposal) Voted(id AccountID) bool {
+	for _, voter := range p.Voters {
+		if voter == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p Proposal) Marshal() []byte {
+	var w bytes.Buffer
+
+	w.Write(p.Proposer[:])
+	w.WriteByte(p.Param)
+
+	var buf [8]byte
+
+	binary.BigEndian.PutUint64(buf[:], p.Value)
+	w.Write(buf[:])
+
+	binary.BigEndian.PutUint64(buf[:], p.ActivationRound)
+	w.Write(buf[:])
+
+	binary.BigEndian.PutUint32(buf[:4], uint32(len(p.Voters)))
+	w.Write(buf[:4])
+
+	for _, id := range p.Voters {
+		w.Write(id[:])
+	}
+
+	binary.BigEndian.PutUint64(buf[:], p.VotedStake)
+	w.Write(buf[:])
+
+	if p.Passed {
+		w.WriteByte(1)
+	} else {
+		w.WriteByte(0)
+	}
+
+	return w.Bytes()
+}
+
+func UnmarshalProposal(r io.Reader) (p Proposal, err error) {
+	if _, err = io.ReadFull(r, p.Proposer[:]); err != nil {
+		err = errors.Wrap(err, "failed to decode proposer")
+		return
+	}
+
+	var buf [8]byte
+
+	if _, err = io.ReadFull(r, buf[:1]); err != nil {
+		err = errors.Wrap(err, "failed to decode parameter")
+		return
+	}
+
+	p.Param = buf[0]
+
+	if _, err = io.ReadFull(r, buf[:]); err != nil {
+		err = errors.Wrap(err, "failed to decode proposed value")
+		return
+	}
+
+	p.Value = binary.BigEndian.Uint64(buf[:])
+
+	if _, err = io.ReadFull(r, buf[:]); err != nil {
+		err = errors.Wrap(err, "failed to decode activation round")
+		return
+	}
+
+	p.ActivationRound = binary.BigEndian.Uint64(buf[:])
+
+	if _, err = io.ReadFull(r, buf[:4]); err != nil {
+		err = errors.Wrap(err, "failed to decode number of voters")
+		return
+	}
+
+	p.Voters = make([]AccountID, binary.BigEndian.Uint32(buf[:4]))
+
+	for i := range p.Voters {
+		if _, err = io.ReadFull(r, p.Voters[i][:]); err != nil {
+			err = errors.Wrap(err, "failed to decode voter")
+			return
+		}
+	}
+
+	if _, err = io.ReadFull(r, buf[:]); err != nil {
+		err = errors.Wrap(err, "failed to decode voted stake")
+		return
+	}
+
+	p.VotedStake = binary.BigEndian.Uint64(buf[:])
+
+	if _, err = io.ReadFull(r, buf[:1]); err != nil {
+		err = errors.Wrap(err, "failed to decode whether proposal has passed")
+		return
+	}
+
+	p.Passed = buf[0] == 1
+
+	return
+}
+
+func ReadProposal(tree *avl.Tree, id TransactionID) (Proposal, bool) {
+	buf, exists := tree.Lookup(append(keyProposals[:], id[:]...))
+	if !exists || len(buf) == 0 {
+		return Proposal{}, false
+	}
+
+	proposal, err := UnmarshalProposal(bytes.NewReader(buf))
+	if err != nil {
+		return Proposal{}, false
+	}
+
+	return proposal, true
+}
+
+func WriteProposal(tree *avl.Tree, id TransactionID, proposal Proposal) {
+	tree.Insert(append(keyProposals[:], id[:]...), proposal.Marshal())
+}
+
+// ReadParam returns the value a governable parameter takes on in the round with
+// the given index, falling back to the parameters default in package sys should
+// no proposal to change it have passed.
+func ReadParam(tree *avl.Tree, param byte, round uint64) uint64 {
+	fallback, _ := sys.ParamDefault(param)
+	return readParam(tree, param, round, fallback)
+}
+
+// readParam returns the value a governable parameter takes on in the round with
+// the given index, falling back to fallback should no proposal to change it have
+// passed and activated as of the round.
+func readParam(tree *avl.Tree, param byte, round uint64, fallback uint64) uint64 {
+	if value, set := lookupParam(tree, param, round); set {
+		return value
+	}
+
+	return fallback
+}
+
+// lookupParam returns the value a governable parameter takes on in the round
+// with the given index, and false should the parameter take on its default.
+//
+// A parameter is stored as the value it takes on prior to activating, the
+// value it takes on once activated, and the round it activates in. The value
+// prior to activating is omitted should the parameter take on its default
+// prior to activating.
+func lookupParam(tree *avl.Tree, param byte, round uint64) (uint64, bool) {
+	buf, exists := tree.Lookup(append(keyParams[:], param))
+	if !exists {
+		return 0, false
+	}
+
+	switch len(buf) {
+	case 16:
+		if round < binary.BigEndian.Uint64(buf[8:16]) {
+			return 0, false
+		}
+
+		return binary.BigEndian.Uint64(buf[0:8]), true
+	case 24:
+		if round < binary.BigEndian.Uint64(buf[16:24]) {
+			return binary.BigEndian.Uint64(buf[0:8]), true
+		}
+
+		return binary.BigEndian.Uint64(buf[8:16]), true
+	}
+
+	return 0, false
+}
+
+// WriteParam schedules a governable parameter to take on value from the round
+// activationRound onwards. The value the parameter takes on as of round is kept
+// such that it still applies to all rounds prior to activationRound.
+func WriteParam(tree *avl.Tree, param byte, value, activationRound, round uint64) {
+	current, set := lookupParam(tree, param, round)
+
+	if !set {
+		var buf [16]byte
+
+		binary.BigEndian.PutUint64(buf[0:8], value)
+		binary.BigEndian.PutUint64(buf[8:16], activationRound)
+
+		tree.Insert(append(keyParams[:], param), buf[:])
+		return
+	}
+
+	var buf [24]byte
+
+	binary.BigEndian.PutUint64(buf[0:8], current)
+	binary.BigEndian.PutUint64(buf[8:16], value)
+	binary.BigEndian.PutUint64(buf[16:24], activationRound)
+
+	tree.Insert(append(keyParams[:], param), buf[:])
+}
+
+// reachedQuorum returns true if voted is at least sys.GovernanceQuorum percent of
+// total. Both sides are compared as 128-bit products such that neither overflows.
+func reachedQuorum(voted, total uint64) bool {
+	hiVoted, loVoted := bits.Mul64(voted, 100)
+	hiTotal, loTotal := bits.Mul64(total, sys.GovernanceQuorum)
+
+	return hiVoted > hiTotal || (hiVoted == hiTotal && loVoted >= loTotal)
+}
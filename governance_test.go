@@ -0,0 +1,174 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/perlin-network/wavelet/avl"
+	"github.com/perlin-network/wavelet/store"
+	"github.com/perlin-network/wavelet/sys"
+	"github.com/stretchr/testify/assert"
+)
+
+func proposeParameterPayload(param byte, value, activationRound uint64) []byte {
+	buf := []byte{sys.ProposeParameter, param}
+	buf = append(buf, make([]byte, 16)...)
+
+	binary.LittleEndian.PutUint64(buf[2:10], value)
+	binary.LittleEndian.PutUint64(buf[10:18], activationRound)
+
+	return buf
+}
+
+func voteParameterPayload(id TransactionID) []byte {
+	return append([]byte{sys.VoteParameter}, id[:]...)
+}
+
+func TestParseGovernanceTransaction(t *testing.T) {
+	params, err := ParseGovernanceTransaction(proposeParameterPayload(sys.ParamTransactionFeeAmount, 5, 10))
+	assert.NoError(t, err)
+	assert.Equal(t, sys.ParamTransactionFeeAmount, params.Param)
+	assert.EqualValues(t, 5, params.Value)
+	assert.EqualValues(t, 10, params.ActivationRound)
+
+	params, err = ParseGovernanceTransaction(voteParameterPayload(TransactionID{1}))
+	assert.NoError(t, err)
+	assert.Equal(t, TransactionID{1}, params.ProposalID)
+
+	_, err = ParseGovernanceTransaction(proposeParameterPayload(0xff, 5, 10))
+	assert.Error(t, err, "unknown parameters may not be proposed")
+
+	_, err = ParseGovernanceTransaction(append(voteParameterPayload(TransactionID{1}), 0))
+	assert.Error(t, err, "trailing bytes must be rejected")
+
+	_, err = ParseGovernanceTransaction(proposeParameterPayload(sys.ParamTransactionFeeAmount, 0, 10))
+	assert.Error(t, err, "parameters may not be changed to below their bounds")
+
+	_, err = ParseGovernanceTransaction(proposeParameterPayload(sys.ParamMinimumStake, ^uint64(0), 10))
+	assert.Error(t, err, "parameters may not be changed to above their bounds")
+
+	_, err = ParseGovernanceTransaction([]byte{0xff})
+	assert.Error(t, err, "unknown opcodes must be rejected")
+}
+
+func TestApplyGovernanceTransaction(t *testing.T) {
+	tree := avl.New(store.NewInmem())
+
+	a, b, c, unstaked, understaked := AccountID{1}, AccountID{2}, AccountID{3}, AccountID{4}, AccountID{5}
+
+	WriteAccountStake(tree, a, 400)
+	WriteAccountStake(tree, b, 300)
+	WriteAccountStake(tree, c, 300)
+
+	apply := func(id TransactionID, creator AccountID, index uint64, payload []byte) error {
+		tx := &Transaction{ID: id, Creator: creator, Sender: creator, Tag: sys.TagGovernance, Payload: payload}
+		_, err := ApplyGovernanceTransaction(tree, &Round{Index: index}, tx)
+		return err
+	}
+
+	proposalID := TransactionID{9}
+
+	assert.Error(t, apply(proposalID, unstaked, 1, proposeParameterPayload(sys.ParamTransactionFeeAmount, 7, 10)), "unstaked accounts may not propose")
+	assert.Error(t, apply(proposalID, a, 10, proposeParameterPayload(sys.ParamTransactionFeeAmount, 7, 10)), "proposals must activate in the future")
+
+	assert.NoError(t, apply(proposalID, a, 1, proposeParameterPayload(sys.ParamTransactionFeeAmount, 7, 10)))
+	assert.Error(t, apply(proposalID, a, 1, proposeParameterPayload(sys.ParamTransactionFeeAmount, 7, 10)), "proposal IDs must be unique")
+
+	assert.Error(t, apply(TransactionID{}, a, 2, voteParameterPayload(proposalID)), "proposers implicitly vote for their proposal")
+	assert.Error(t, apply(TransactionID{}, unstaked, 2, voteParameterPayload(proposalID)), "unstaked accounts may not vote")
+
+	WriteAccountStake(tree, understaked, sys.MinimumStake-1)
+	assert.Error(t, apply(TransactionID{}, understaked, 2, voteParameterPayload(proposalID)), "accounts staking less than the minimum stake may not vote")
+	WriteAccountStake(tree, understaked, 0)
+
+	assert.Error(t, apply(TransactionID{}, b, 2, voteParameterPayload(TransactionID{8})), "votes for missing proposals must be rejected")
+
+	assert.NoError(t, apply(TransactionID{}, b, 2, voteParameterPayload(proposalID)))
+
+	proposal, exists := ReadProposal(tree, proposalID)
+	assert.True(t, exists)
+	assert.True(t, proposal.Passed)
+	assert.EqualValues(t, 700, proposal.VotedStake)
+	assert.Equal(t, []AccountID{a, b}, proposal.Voters)
+
+	assert.Error(t, apply(TransactionID{}, c, 3, voteParameterPayload(proposalID)), "passed proposals may not be voted on")
+
+	assert.Equal(t, sys.TransactionFeeAmount, ReadParam(tree, sys.ParamTransactionFeeAmount, 9))
+	assert.EqualValues(t, 7, ReadParam(tree, sys.ParamTransactionFeeAmount, 10))
+	assert.Equal(t, sys.MinimumStake, ReadParam(tree, sys.ParamMinimumStake, 10))
+
+	// Stake voted with may not be withdrawn until voting closes.
+
+	withdraw := &Transaction{Creator: b, Tag: sys.TagStake, Payload: append([]byte{sys.WithdrawStake}, 44, 1, 0, 0, 0, 0, 0, 0)}
+
+	_, err := ApplyStakeTransaction(tree, &Round{Index: 9}, withdraw)
+	assert.Error(t, err, "stake voted with must be locked until voting closes")
+
+	_, err = ApplyStakeTransaction(tree, &Round{Index: 10}, withdraw)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 700, ReadTotalStake(tree))
+}
+
+func TestGovernanceVotingCloses(t *testing.T) {
+	tree := avl.New(store.NewInmem())
+
+	a, b := AccountID{1}, AccountID{2}
+
+	WriteAccountStake(tree, a, 100)
+	WriteAccountStake(tree, b, 900)
+
+	propose := &Transaction{ID: TransactionID{1}, Creator: a, Tag: sys.TagGovernance, Payload: proposeParameterPayload(sys.ParamMinimumStake, 1, 5)}
+	_, err := ApplyGovernanceTransaction(tree, &Round{Index: 1}, propose)
+	assert.NoError(t, err)
+
+	proposal, _ := ReadProposal(tree, propose.ID)
+	assert.False(t, proposal.Passed)
+
+	vote := &Transaction{Creator: b, Tag: sys.TagGovernance, Payload: voteParameterPayload(propose.ID)}
+	_, err = ApplyGovernanceTransaction(tree, &Round{Index: 5}, vote)
+	assert.Error(t, err, "votes may not be cast once a proposal was due to activate")
+
+	assert.Equal(t, sys.MinimumStake, ReadParam(tree, sys.ParamMinimumStake, 6))
+}
+
+func TestReachedQuorum(t *testing.T) {
+	assert.True(t, reachedQuorum(67, 100))
+	assert.False(t, reachedQuorum(66, 100))
+	assert.True(t, reachedQuorum(^uint64(0), ^uint64(0)))
+	assert.False(t, reachedQuorum(^uint64(0)/2, ^uint64(0)))
+}
+
+func TestReadParamFallsBackToLedgerDefault(t *testing.T) {
+	tree := avl.New(store.NewInmem())
+
+	assert.EqualValues(t, 3, readParam(tree, sys.ParamTransactionFeeAmount, 0, 3))
+
+	WriteParam(tree, sys.ParamTransactionFeeAmount, 7, 10, 1)
+
+	assert.EqualValues(t, 3, readParam(tree, sys.ParamTransactionFeeAmount, 9, 3), "the default of the ledger must apply until the change activates")
+	assert.EqualValues(t, 7, readParam(tree, sys.ParamTransactionFeeAmount, 10, 3))
+
+	WriteParam(tree, sys.ParamTransactionFeeAmount, 9, 20, 10)
+
+	assert.EqualValues(t, 7, readParam(tree, sys.ParamTransactionFeeAmount, 19, 3))
+	assert.EqualValues(t, 9, readParam(tree, sys.ParamTransactionFeeAmount, 20, 3))
+}
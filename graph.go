@@ -24,10 +24,13 @@ import (
 	"encoding/hex"
 	"github.com/google/btree"
 	"github.com/perlin-network/noise/edwards25519"
+	"github.com/perlin-network/wavelet/log"
+	"github.com/perlin-network/wavelet/store"
 	"github.com/perlin-network/wavelet/sys"
 	"github.com/pkg/errors"
 	"sort"
 	"sync"
+	"time"
 )
 
 type GraphOption func(*Graph)
@@ -48,18 +51,55 @@ func WithMetrics(metrics *Metrics) GraphOption {
 	}
 }
 
+func WithLogs(logs *log.Scope) GraphOption {
+	return func(graph *Graph) {
+		graph.logs = logs
+	}
+}
+
+// WithMaxDepthDiff has the graph reject transactions, and parents, which are
+// more than diff depths below the root of the graph.
+func WithMaxDepthDiff(diff uint64) GraphOption {
+	return func(graph *Graph) {
+		graph.maxDepthDiff = diff
+	}
+}
+
 func WithIndexer(indexer *Indexer) GraphOption {
 	return func(graph *Graph) {
 		graph.indexer = indexer
 	}
 }
 
+// WithStore persists transactions added to the graph into kv, and reloads
+// transactions persisted into kv from a prior run upon the graphs creation.
+func WithStore(kv store.KV) GraphOption {
+	return func(graph *Graph) {
+		graph.kv = kv
+	}
+}
+
+// ArchivePruned has the graph keep a copy of every transaction it prunes in its
+// store, such that it may still be found through FindArchivedTransaction.
+func ArchivePruned() GraphOption {
+	return func(graph *Graph) {
+		graph.archive = true
+	}
+}
+
 func VerifySignatures() GraphOption {
 	return func(graph *Graph) {
 		graph.verifySignatures = true
 	}
 }
 
+// missingTX describes a transaction that is referenced as a parent within the
+// graph, but whose contents we do not have.
+type missingTX struct {
+	depth uint64    // Depth of the child of the missing transaction.
+	since time.Time // When the transaction was first found to be missing.
+}
+
 type sortByDepthTX Transaction
 
 func (a *sortByDepthTX) Less(b btree.Item) bool {
@@ -76,10 +116,15 @@ func (a *sortBySeedTX) Less(b btree.Item) bool {
 	return a.Depth < b.(*sortBySeedTX).Depth
 }
 
+// maxParentCandidates is the number of eligible parents closest to the frontier
+// of the graph that a ParentSelector is given to choose from.
+var maxParentCandidates = 4 * sys.MaxParentsPerTransaction
+
 var (
 	ErrMissingParents     = errors.New("parents for transaction are not in graph")
 	ErrAlreadyExists      = errors.New("transaction already exists in the graph")
 	ErrDepthLimitExceeded = errors.New("transactions parents exceed depth limit")
+	ErrGraphFull          = errors.New("graph is full; only critical or missing transactions are admitted")
 )
 
 type Graph struct {
@@ -87,40 +132,62 @@ type Graph struct {
 
 	metrics *Metrics
 	indexer *Indexer
+	logs    *log.Scope
+	kv      store.KV
+	archive bool
 
 	transactions map[TransactionID]*Transaction    // All transactions. Includes incomplete transactions.
 	children     map[TransactionID][]TransactionID // Children of transactions. Includes incomplete/missing transactions.
 
-	missing    map[TransactionID]uint64   // Transactions that we are missing. Maps to depth of child of missing transaction.
-	incomplete map[TransactionID]struct{} // Transactions that don't have all parents available.
+	missing    map[TransactionID]missingTX // Transactions that we are missing.
+	incomplete map[TransactionID]struct{}  // Transactions that don't have all parents available.
+	orphans    *orphanPool                 // Bounds how many incomplete transactions are kept, and for how long.
 
-	eligibleIndex *btree.BTree              // Transactions that are eligible to be parent transactions.
-	seedIndex     *btree.BTree              // Indexes transactions by the number of zero bits prefixed of BLAKE2b(Sender || ParentIDs).
-	depthIndex    map[uint64][]*Transaction // Indexes transactions by their depth.
+	eligibleIndex *btree.BTree                    // Transactions that are eligible to be parent transactions.
+	seedIndex     *btree.BTree                    // Indexes transactions by the number of zero bits prefixed of BLAKE2b(Sender || ParentIDs).
+	depthIndex    map[uint64][]*Transaction       // Indexes transactions by their depth.
+	nonceIndex    map[conflictKey][]TransactionID // Indexes transactions by their creator and nonce.
 
 	height    uint64 // Height of the graph.
 	rootDepth uint64 // Depth of the graphs root.
 
+	parents ParentSelector // Picks parents for new transactions out of eligible parents.
+	maxSize int            // Number of transactions past which the graph is full. Unbounded if zero.
+
+	maxDepthDiff uint64 // Number of depths below the root past which transactions are rejected.
+
 	verifySignatures bool
 }
 
 func NewGraph(opts ...GraphOption) *Graph {
 	g := &Graph{
+		logs: log.NewScope(""),
+
+		maxDepthDiff: sys.MaxDepthDiff,
+
 		transactions: make(map[TransactionID]*Transaction),
 		children:     make(map[TransactionID][]TransactionID),
 
-		missing:    make(map[TransactionID]uint64),
+		missing:    make(map[TransactionID]missingTX),
 		incomplete: make(map[TransactionID]struct{}),
+		orphans:    newOrphanPool(DefaultOrphanConfig()),
 
 		eligibleIndex: btree.New(32),
 		seedIndex:     btree.New(32),
 		depthIndex:    make(map[uint64][]*Transaction),
+		nonceIndex:    make(map[conflictKey][]TransactionID),
+
+		parents: DeepestParents{},
 	}
 
 	for _, opt := range opts {
 		opt(g)
 	}
 
+	if g.kv != nil {
+		g.loadTransactions()
+	}
+
 	return g
 }
 
@@ -135,21 +202,36 @@ func (g *Graph) AddTransaction(tx Transaction) error {
 		return ErrAlreadyExists
 	}
 
-	if g.rootDepth > sys.MaxDepthDiff+tx.Depth {
+	if g.rootDepth > g.maxDepthDiff+tx.Depth {
 		return errors.Errorf("transactions depth is too low compared to root: root depth is %d, but tx depth is %d", g.rootDepth, tx.Depth)
 	}
 
-	if err := g.validateTransaction(tx); err != nil {
+	if err := ValidateTransaction(tx, g.verifySignatures); err != nil {
 		return errors.Wrap(err, "failed to validate transaction")
 	}
 
+	if g.kv != nil {
+		if err := g.kv.Put(graphTransactionKey(&tx), tx.Marshal()); err != nil {
+			return errors.Wrap(err, "failed to persist transaction")
+		}
+	}
+
+	return g.insertTransaction(tx)
+}
+
+func (g *Graph) insertTransaction(tx Transaction) error {
 	ptr := &tx
 
 	g.transactions[tx.ID] = ptr
 	delete(g.missing, tx.ID)
+	g.orphans.arrived(tx.ID)
+
+	g.trackConflict(ptr)
 
 	parentsMissing := false
 
+	var missingParents []TransactionID
+
 	// Do not consider transactions below root.depth by exactly DEPTH_DIFF to be incomplete
 	// at all. Permit them to have incomplete parent histories.
 
@@ -157,9 +239,10 @@ func (g *Graph) AddTransaction(tx Transaction) error {
 		for _, parentID := range tx.ParentIDs {
 			if _, stored := g.transactions[parentID]; !stored {
 				parentsMissing = true
+				missingParents = append(missingParents, parentID)
 
 				if _, recorded := g.missing[parentID]; !recorded {
-					g.missing[parentID] = tx.Depth
+					g.missing[parentID] = missingTX{depth: tx.Depth, since: time.Now()}
 				}
 			}
 
@@ -174,6 +257,16 @@ func (g *Graph) AddTransaction(tx Transaction) error {
 	if parentsMissing {
 		g.incomplete[tx.ID] = struct{}{}
 
+		g.orphans.add(tx.ID, missingParents, time.Now())
+
+		// Make room for the transaction by evicting the orphans that have been
+		// waiting on their parents for the longest.
+
+		for g.orphans.len() > g.orphans.config.Capacity {
+			oldest, _ := g.orphans.oldest()
+			g.deleteProgeny(oldest)
+		}
+
 		return ErrMissingParents
 	}
 
@@ -184,8 +277,14 @@ func (g *Graph) AddTransaction(tx Transaction) error {
 // missing.
 func (g *Graph) MarkTransactionAsMissing(id TransactionID, depth uint64) {
 	g.Lock()
-	if g.rootDepth <= sys.MaxDepthDiff+depth {
-		g.missing[id] = depth
+	if g.rootDepth <= g.maxDepthDiff+depth {
+		since := time.Now()
+
+		if recorded, exists := g.missing[id]; exists {
+			since = recorded.since
+		}
+
+		g.missing[id] = missingTX{depth: depth, since: since}
 	}
 	g.Unlock()
 }
@@ -221,8 +320,8 @@ func (g *Graph) UpdateRootDepth(rootDepth uint64) {
 
 	g.rootDepth = rootDepth
 
-	for id, depth := range g.missing {
-		if rootDepth <= sys.MaxDepthDiff+depth {
+	for id, missing := range g.missing {
+		if rootDepth <= g.maxDepthDiff+missing.depth {
 			continue
 		}
 
@@ -281,10 +380,13 @@ func (g *Graph) PruneBelowDepth(targetDepth uint64) int {
 
 			delete(g.missing, tx.ID)
 			delete(g.incomplete, tx.ID)
+			g.orphans.remove(tx.ID)
 
 			g.eligibleIndex.Delete((*sortByDepthTX)(tx))
 			g.seedIndex.Delete((*sortBySeedTX)(tx))
 
+			g.untrackConflict(tx)
+
 			if g.indexer != nil {
 				g.indexer.Index(hex.EncodeToString(tx.ID[:]))
 			}
@@ -293,8 +395,8 @@ func (g *Graph) PruneBelowDepth(targetDepth uint64) int {
 		delete(g.depthIndex, depth)
 	}
 
-	for id, depth := range g.missing {
-		if depth > targetDepth {
+	for id, missing := range g.missing {
+		if missing.depth > targetDepth {
 			continue
 		}
 
@@ -302,6 +404,10 @@ func (g *Graph) PruneBelowDepth(targetDepth uint64) int {
 		delete(g.missing, id)
 	}
 
+	if g.kv != nil {
+		g.deletePersistedBelowDepth(targetDepth)
+	}
+
 	g.Unlock()
 
 	return count
@@ -310,9 +416,10 @@ func (g *Graph) PruneBelowDepth(targetDepth uint64) int {
 // FindEligibleParents provides a set of transactions suited to be eligible
 // parents. We consider eligible parents to be transactions closest to the
 // graphs frontier by DEPTH_DIFF that have no children, such that they are
-// leaf nodes of the graph.
+// leaf nodes of the graph. Out of these, at most sys.MaxParentsPerTransaction
+// are picked by the graphs ParentSelector.
 func (g *Graph) FindEligibleParents() []*Transaction {
-	var eligibleParents []*Transaction
+	var candidates []ParentCandidate
 	var pending []*sortByDepthTX
 
 	g.Lock()
@@ -320,7 +427,7 @@ func (g *Graph) FindEligibleParents() []*Transaction {
 	g.eligibleIndex.Descend(func(i btree.Item) bool {
 		eligibleParent := i.(*sortByDepthTX)
 
-		if g.height-1 >= sys.MaxDepthDiff+eligibleParent.Depth {
+		if g.height-1 >= g.maxDepthDiff+eligibleParent.Depth {
 			pending = append(pending, eligibleParent)
 			return true
 		}
@@ -342,18 +449,23 @@ func (g *Graph) FindEligibleParents() []*Transaction {
 			}
 		}
 
-		eligibleParents = append(eligibleParents, (*Transaction)(eligibleParent))
+		candidates = append(candidates, ParentCandidate{
+			Tx:        (*Transaction)(eligibleParent),
+			Conflicts: g.countConflicts((*Transaction)(eligibleParent)),
+		})
 
-		return len(eligibleParents) != sys.MaxParentsPerTransaction
+		return len(candidates) != maxParentCandidates
 	})
 
 	for _, i := range pending {
 		g.eligibleIndex.Delete(i)
 	}
 
+	parents := g.parents.SelectParents(candidates, sys.MaxParentsPerTransaction)
+
 	g.Unlock()
 
-	return eligibleParents
+	return parents
 }
 
 // FindEligibleCritical looks through all transactions in the current
@@ -419,7 +531,7 @@ func (g *Graph) Missing() []TransactionID {
 	}
 
 	sort.Slice(missing, func(i, j int) bool {
-		return g.missing[missing[i]] < g.missing[missing[j]]
+		return g.missing[missing[i]].depth < g.missing[missing[j]].depth
 	})
 
 	g.RUnlock()
@@ -456,6 +568,41 @@ func (g *Graph) ListTransactions(offset, limit uint64, sender, creator AccountID
 	return
 }
 
+// ListTransactionsAfter lists up to limit transactions sent by sender or created by
+// creator, ordered from the deepest to the shallowest with ties broken by ascending
+// ID. Only transactions ordered strictly after the position (depth, id) are listed,
+// such that listings resumed from the last transaction of a prior listing remain
+// stable as new transactions are added to the graph. To list from the very top of
+// the graph, specify a depth of math.MaxUint64.
+func (g *Graph) ListTransactionsAfter(depth uint64, id TransactionID, limit uint64, sender, creator AccountID) (transactions []*Transaction) {
+	g.RLock()
+	defer g.RUnlock()
+
+	for _, tx := range g.transactions {
+		if tx.Depth > depth || (tx.Depth == depth && bytes.Compare(tx.ID[:], id[:]) <= 0) {
+			continue
+		}
+
+		if (sender == ZeroAccountID && creator == ZeroAccountID) || (sender != ZeroAccountID && tx.Sender == sender) || (creator != ZeroAccountID && tx.Creator == creator) {
+			transactions = append(transactions, tx)
+		}
+	}
+
+	sort.Slice(transactions, func(i, j int) bool {
+		if transactions[i].Depth != transactions[j].Depth {
+			return transactions[i].Depth > transactions[j].Depth
+		}
+
+		return bytes.Compare(transactions[i].ID[:], transactions[j].ID[:]) < 0
+	})
+
+	if limit != 0 && uint64(len(transactions)) > limit {
+		transactions = transactions[:limit]
+	}
+
+	return
+}
+
 // FindTransaction returns transaction with id from graph, and nil otherwise.
 func (g *Graph) FindTransaction(id TransactionID) *Transaction {
 	g.RLock()
@@ -483,6 +630,54 @@ func (g *Graph) Len() int {
 	return num
 }
 
+// MissingStats describes the transactions that the graph is missing.
+type MissingStats struct {
+	Count     int           // Number of missing transactions.
+	OldestAge time.Duration // How long the longest missing transaction has been missing for.
+}
+
+// MissingStats returns the number of transactions the graph is missing, and
+// how long the longest missing one of them has been missing for.
+func (g *Graph) MissingStats() MissingStats {
+	now := time.Now()
+
+	g.RLock()
+	defer g.RUnlock()
+
+	stats := MissingStats{Count: len(g.missing)}
+
+	for _, missing := range g.missing {
+		if age := now.Sub(missing.since); age > stats.OldestAge {
+			stats.OldestAge = age
+		}
+	}
+
+	return stats
+}
+
+// MissingOldest returns the IDs of at most limit transactions the graph is
+// missing, ordered from the one that has been missing for the longest.
+func (g *Graph) MissingOldest(limit int) []TransactionID {
+	g.RLock()
+	defer g.RUnlock()
+
+	missing := make([]TransactionID, 0, len(g.missing))
+
+	for id := range g.missing {
+		missing = append(missing, id)
+	}
+
+	sort.Slice(missing, func(i, j int) bool {
+		return g.missing[missing[i]].since.Before(g.missing[missing[j]].since)
+	})
+
+	if len(missing) > limit {
+		missing = missing[:limit]
+	}
+
+	return missing
+}
+
 // MissingLen returns the number of known missing transactions of the graph.
 func (g *Graph) MissingLen() int {
 	g.RLock()
@@ -510,6 +705,23 @@ func (g *Graph) DepthLen(start *uint64, end *uint64) int {
 	return count
 }
 
+// HasPendingTransactions returns true if there exists any transaction that is
+// not a nop above the graphs root, which has yet to be finalized.
+func (g *Graph) HasPendingTransactions() bool {
+	g.RLock()
+	defer g.RUnlock()
+
+	for depth := g.rootDepth + 1; depth < g.height; depth++ {
+		for _, tx := range g.depthIndex[depth] {
+			if tx.Tag != sys.TagNop {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
 // RootDepth returns the current depth of the root transaction of the graph.
 func (g *Graph) RootDepth() uint64 {
 	g.RLock()
@@ -564,6 +776,7 @@ func (g *Graph) updateGraph(tx *Transaction) error {
 
 		if complete {
 			delete(g.incomplete, childID)
+			g.orphans.remove(childID)
 
 			if g.indexer != nil {
 				g.indexer.Remove(hex.EncodeToString(tx.ID[:]))
@@ -586,6 +799,8 @@ func (g *Graph) deleteProgeny(id TransactionID) {
 		g.eligibleIndex.Delete((*sortByDepthTX)(tx))
 		g.seedIndex.Delete((*sortBySeedTX)(tx))
 
+		g.untrackConflict(tx)
+
 		if len(g.depthIndex[tx.Depth]) > 0 {
 			slice := g.depthIndex[tx.Depth][:0]
 
@@ -607,12 +822,100 @@ func (g *Graph) deleteProgeny(id TransactionID) {
 	delete(g.missing, id)
 	delete(g.incomplete, id)
 
+	if exists && g.kv != nil {
+		_ = g.kv.Delete(graphTransactionKey(tx))
+	}
+
+	// Stop pulling for parents that no transaction is waiting on anymore.
+
+	for _, parentID := range g.orphans.remove(id) {
+		if _, missing := g.missing[parentID]; !missing {
+			continue
+		}
+
+		awaited := false
+
+		for _, childID := range g.children[parentID] {
+			if _, exists := g.transactions[childID]; exists && childID != id {
+				awaited = true
+				break
+			}
+		}
+
+		if !awaited {
+			delete(g.missing, parentID)
+			delete(g.children, parentID)
+		}
+	}
+
 	for _, childID := range children {
 		g.deleteProgeny(childID)
 	}
 }
 
-func (g *Graph) validateTransaction(tx Transaction) error {
+// ExpireOrphans deletes all transactions, alongside their progeny, that have
+// been waiting on their missing parents to arrive for longer than the time to
+// live of orphans. It returns the number of transactions that expired.
+func (g *Graph) ExpireOrphans() int {
+	g.Lock()
+	defer g.Unlock()
+
+	count := 0
+
+	for _, id := range g.orphans.expired(time.Now()) {
+		if _, exists := g.transactions[id]; !exists {
+			continue
+		}
+
+		g.deleteProgeny(id)
+		count++
+	}
+
+	return count
+}
+
+// Full returns whether the graph holds as many transactions as it may hold.
+func (g *Graph) Full() bool {
+	g.RLock()
+	full := g.maxSize > 0 && len(g.transactions) >= g.maxSize
+	g.RUnlock()
+
+	return full
+}
+
+// IsMissing returns whether the transaction under id is known to be missing,
+// such that it is referenced by some transaction within the graph.
+func (g *Graph) IsMissing(id TransactionID) bool {
+	g.RLock()
+	_, missing := g.missing[id]
+	g.RUnlock()
+
+	return missing
+}
+
+// IsIncomplete returns whether the transaction under id is in the graph, but is
+// waiting on some of its ancestors to arrive.
+func (g *Graph) IsIncomplete(id TransactionID) bool {
+	g.RLock()
+	_, incomplete := g.incomplete[id]
+	g.RUnlock()
+
+	return incomplete
+}
+
+// OrphanLen returns the number of transactions in the graph that are waiting on
+// their parents to arrive.
+func (g *Graph) OrphanLen() int {
+	g.RLock()
+	num := g.orphans.len()
+	g.RUnlock()
+
+	return num
+}
+
+// ValidateTransaction performs all checks on tx that do not depend on the graph
+// it is to be added to, optionally verifying the signatures of its sender and creator.
+func ValidateTransaction(tx Transaction, verifySignatures bool) error {
 	if tx.ID == ZeroTransactionID {
 		return errors.New("tx must have an ID")
 	}
@@ -652,10 +955,18 @@ func (g *Graph) validateTransaction(tx Transaction) error {
 		set[tx.ParentIDs[i]] = struct{}{}
 	}
 
-	if tx.Tag > sys.TagBatch {
+	if tx.Tag > sys.TagGovernance {
 		return errors.New("tx has an unknown tag")
 	}
 
+	if tx.Sender == tx.Creator && tx.CreatorScheme != SignatureEd25519 {
+		return errors.New("tx whose creator is its sender must be signed under ed25519")
+	}
+
+	if tx.CreatorScheme > SignatureSecp256k1 {
+		return errors.New("tx creator signed under an unknown signature scheme")
+	}
+
 	if tx.Tag != sys.TagNop && len(tx.Payload) == 0 {
 		return errors.New("tx must have payload if not a nop transaction")
 	}
@@ -664,11 +975,9 @@ func (g *Graph) validateTransaction(tx Transaction) error {
 		return errors.New("tx must have no payload if is a nop transaction")
 	}
 
-	if g.verifySignatures {
-		var nonce [8]byte // TODO(kenta): nonce
-
+	if verifySignatures {
 		if tx.Sender != tx.Creator {
-			if !edwards25519.Verify(tx.Creator, append(nonce[:], append([]byte{byte(tx.Tag)}, tx.Payload...)...), tx.CreatorSignature) {
+			if !VerifySignature(tx.CreatorScheme, tx.Creator, tx.creatorMessage(), tx.CreatorSignature) {
 				return errors.New("tx has invalid creator signature")
 			}
 		}
@@ -676,7 +985,13 @@ func (g *Graph) validateTransaction(tx Transaction) error {
 		cpy := tx
 		cpy.SenderSignature = ZeroSignature
 
-		if !edwards25519.Verify(tx.Sender, cpy.Marshal(), tx.SenderSignature) {
+		buf := AcquireBuffer()
+		cpy.Write(buf)
+
+		valid := edwards25519.Verify(tx.Sender, buf.Bytes(), tx.SenderSignature)
+		ReleaseBuffer(buf)
+
+		if !valid {
 			return errors.New("tx has invalid sender signature")
 		}
 	}
@@ -688,7 +1003,7 @@ func (g *Graph) validateTransactionParents(tx *Transaction) error {
 	// Do not consider transactions below root.depth by exactly DEPTH_DIFF to be incomplete
 	// at all. Permit them to have incomplete parent histories.
 
-	if g.rootDepth == uint64(sys.MaxDepthDiff)+tx.Depth {
+	if g.rootDepth == g.maxDepthDiff+tx.Depth {
 		return nil
 	}
 
@@ -701,7 +1016,7 @@ func (g *Graph) validateTransactionParents(tx *Transaction) error {
 			return errors.New("parent not stored in graph")
 		}
 
-		if tx.Depth > sys.MaxDepthDiff+parent.Depth { // Check if the depth of each parents is acceptable.
+		if tx.Depth > g.maxDepthDiff+parent.Depth { // Check if the depth of each parents is acceptable.
 			return errors.Wrapf(ErrDepthLimitExceeded, "tx parent has ineligible depth: parents depth is %d, but tx depth is %d", parent.Depth, tx.Depth)
 		}
 
@@ -21,11 +21,14 @@ package wavelet
 
 import (
 	"github.com/perlin-network/noise/skademlia"
+	"github.com/perlin-network/wavelet/store"
 	"github.com/perlin-network/wavelet/sys"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
+	"math"
 	"math/rand"
 	"testing"
+	"time"
 )
 
 func TestNewGraph(t *testing.T) {
@@ -57,6 +60,77 @@ func TestNewGraph(t *testing.T) {
 	assert.Equal(t, graph.Height(), uint64(2))
 }
 
+func TestGraphConflicts(t *testing.T) {
+	t.Parallel()
+
+	keys, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	root := AttachSenderToTransaction(keys, NewTransaction(keys, sys.TagNop, nil))
+	graph := NewGraph(WithRoot(root))
+
+	newTX := func(nonce uint64, payload string) Transaction {
+		return AttachSenderToTransaction(keys, NewTransactionWithNonce(keys, nonce, sys.TagTransfer, []byte(payload)), &root)
+	}
+
+	// The creator signs over the nonce, such that it may not be tampered with
+	// by whoever sends the transaction.
+
+	sender, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	signed := NewTransactionWithNonce(keys, 3, sys.TagTransfer, []byte("a"))
+	assert.NoError(t, ValidateTransaction(AttachSenderToTransaction(sender, signed, &root), true))
+
+	tampered := signed
+	tampered.Nonce = 4
+	assert.Error(t, ValidateTransaction(AttachSenderToTransaction(sender, tampered, &root), true))
+
+	assert.NoError(t, graph.AddTransaction(newTX(0, "a")))
+	assert.NoError(t, graph.AddTransaction(newTX(0, "b")))
+	assert.NoError(t, graph.AddTransaction(newTX(1, "a")))
+	assert.NoError(t, graph.AddTransaction(newTX(2, "a")))
+
+	assert.Empty(t, graph.Conflicts(ZeroAccountID))
+
+	conflicting := newTX(2, "b")
+	assert.NoError(t, graph.AddTransaction(conflicting))
+
+	conflicts := graph.Conflicts(ZeroAccountID)
+	assert.Len(t, conflicts, 1)
+	assert.EqualValues(t, keys.PublicKey(), conflicts[0].Creator)
+	assert.EqualValues(t, 2, conflicts[0].Nonce)
+	assert.Len(t, conflicts[0].TransactionIDs, 2)
+
+	assert.Empty(t, graph.Conflicts(AccountID{1}))
+
+	graph.PruneBelowDepth(conflicting.Depth)
+	assert.Empty(t, graph.Conflicts(ZeroAccountID))
+}
+
+func TestGraphHasPendingTransactions(t *testing.T) {
+	t.Parallel()
+
+	keys, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	root := AttachSenderToTransaction(keys, NewTransaction(keys, sys.TagNop, nil))
+	graph := NewGraph(WithRoot(root))
+
+	assert.False(t, graph.HasPendingTransactions())
+
+	nop := AttachSenderToTransaction(keys, NewTransaction(keys, sys.TagNop, nil), &root)
+	assert.NoError(t, graph.AddTransaction(nop))
+	assert.False(t, graph.HasPendingTransactions())
+
+	tx := AttachSenderToTransaction(keys, NewTransaction(keys, sys.TagTransfer, []byte("a")), &nop)
+	assert.NoError(t, graph.AddTransaction(tx))
+	assert.True(t, graph.HasPendingTransactions())
+
+	graph.UpdateRoot(tx)
+	assert.False(t, graph.HasPendingTransactions())
+}
+
 func TestGraphFuzz(t *testing.T) {
 	t.Parallel()
 
@@ -195,6 +269,174 @@ func TestGraphPruneBelowDepth(t *testing.T) {
 	assert.Len(t, graph.missing, 0)
 }
 
+func TestGraphPersistence(t *testing.T) {
+	t.Parallel()
+
+	keys, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	kv := store.NewInmem()
+
+	root := AttachSenderToTransaction(keys, NewTransaction(keys, sys.TagNop, nil))
+	graph := NewGraph(WithStore(kv), WithRoot(root))
+
+	var added []Transaction
+
+	for i := 0; i < 50; i++ {
+		tx := AttachSenderToTransaction(keys, NewTransaction(keys, sys.TagTransfer, []byte{byte(i)}), graph.FindEligibleParents()...)
+		assert.NoError(t, graph.AddTransaction(tx))
+
+		added = append(added, tx)
+	}
+
+	// Assert that a graph created over the same store reloads all transactions.
+
+	reloaded := NewGraph(WithStore(kv), WithRoot(root))
+
+	assert.Equal(t, graph.Len(), reloaded.Len())
+	assert.Equal(t, graph.Height(), reloaded.Height())
+
+	for _, tx := range added {
+		assert.NotNil(t, reloaded.FindTransaction(tx.ID))
+	}
+
+	// Assert that pruned transactions are deleted from the store.
+
+	pruneDepth := added[24].Depth
+	reloaded.PruneBelowDepth(pruneDepth)
+
+	reloaded = NewGraph(WithStore(kv), WithRoot(root))
+
+	for _, tx := range added {
+		assert.Equal(t, tx.Depth > pruneDepth, reloaded.FindTransaction(tx.ID) != nil)
+	}
+
+	// Assert that transactions at or below the root depth are not reloaded,
+	// and are only deleted from the store once pruned.
+
+	newRoot := added[39]
+	reloaded = NewGraph(WithStore(kv), WithRoot(newRoot))
+
+	for _, tx := range added {
+		if tx.ID == newRoot.ID {
+			continue
+		}
+
+		assert.Equal(t, tx.Depth > newRoot.Depth, reloaded.FindTransaction(tx.ID) != nil)
+	}
+
+	assert.Equal(t, len(added)-25, countPersistedTransactions(t, kv))
+
+	reloaded.PruneBelowDepth(newRoot.Depth)
+	assert.Equal(t, len(added)-40, countPersistedTransactions(t, kv))
+}
+
+func TestGraphArchivePruned(t *testing.T) {
+	t.Parallel()
+
+	keys, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	kv := store.NewInmem()
+
+	root := AttachSenderToTransaction(keys, NewTransaction(keys, sys.TagNop, nil))
+	graph := NewGraph(WithStore(kv), WithRoot(root), ArchivePruned())
+
+	var added []Transaction
+
+	for i := 0; i < 20; i++ {
+		tx := AttachSenderToTransaction(keys, NewTransaction(keys, sys.TagTransfer, []byte{byte(i)}), graph.FindEligibleParents()...)
+		assert.NoError(t, graph.AddTransaction(tx))
+
+		added = append(added, tx)
+	}
+
+	pruneDepth := added[9].Depth
+	graph.PruneBelowDepth(pruneDepth)
+
+	for _, tx := range added {
+		archived := graph.FindArchivedTransaction(tx.ID)
+
+		if tx.Depth > pruneDepth {
+			assert.Nil(t, archived)
+			assert.NotNil(t, graph.FindTransaction(tx.ID))
+			continue
+		}
+
+		assert.Nil(t, graph.FindTransaction(tx.ID))
+
+		if assert.NotNil(t, archived) {
+			assert.Equal(t, tx.ID, archived.ID)
+			assert.Equal(t, tx.Depth, archived.Depth)
+		}
+	}
+
+	assert.Equal(t, 10, countPersistedTransactions(t, kv))
+}
+
+func countPersistedTransactions(t *testing.T, kv store.KV) int {
+	count := 0
+
+	assert.NoError(t, kv.IteratePrefix(keyGraphTransactions[:], func(key, value []byte) bool {
+		count++
+		return true
+	}))
+
+	return count
+}
+
+func TestGraphListTransactionsAfter(t *testing.T) {
+	t.Parallel()
+
+	keys, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	root := AttachSenderToTransaction(keys, NewTransaction(keys, sys.TagNop, nil))
+	graph := NewGraph(WithRoot(root))
+
+	for i := 0; i < 50; i++ {
+		for j := 0; j < rand.Intn(sys.MaxParentsPerTransaction)+1; j++ {
+			var payload [50]byte
+
+			_, err = rand.Read(payload[:])
+			assert.NoError(t, err)
+
+			assert.NoError(t, graph.AddTransaction(AttachSenderToTransaction(keys, NewTransaction(keys, sys.TagTransfer, payload[:]), graph.FindEligibleParents()...)))
+		}
+	}
+
+	all := graph.ListTransactionsAfter(math.MaxUint64, ZeroTransactionID, 0, ZeroAccountID, ZeroAccountID)
+	assert.Len(t, all, graph.Len())
+
+	// Page through the graph while new transactions are being added, and assert
+	// that every page picks up exactly where the last one left off.
+
+	var paged []*Transaction
+
+	depth, id := uint64(math.MaxUint64), ZeroTransactionID
+
+	for {
+		page := graph.ListTransactionsAfter(depth, id, 7, ZeroAccountID, ZeroAccountID)
+		if len(page) == 0 {
+			break
+		}
+
+		assert.True(t, len(page) <= 7)
+
+		paged = append(paged, page...)
+		depth, id = page[len(page)-1].Depth, page[len(page)-1].ID
+
+		var payload [50]byte
+
+		_, err = rand.Read(payload[:])
+		assert.NoError(t, err)
+
+		assert.NoError(t, graph.AddTransaction(AttachSenderToTransaction(keys, NewTransaction(keys, sys.TagTransfer, payload[:]), graph.FindEligibleParents()...)))
+	}
+
+	assert.Equal(t, all, paged)
+}
+
 func TestGraphUpdateRoot(t *testing.T) {
 	t.Parallel()
 
@@ -391,3 +633,62 @@ func TestGraphFindEligibleCriticalInBigGraph(t *testing.T) {
 
 	assert.Equal(t, *graph.FindEligibleCritical(difficulty), eligible)
 }
+
+func TestMissingStats(t *testing.T) {
+	t.Parallel()
+
+	keys, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	root := AttachSenderToTransaction(keys, NewTransaction(keys, sys.TagNop, nil))
+	graph := NewGraph(WithRoot(root))
+
+	assert.Equal(t, MissingStats{}, graph.MissingStats())
+
+	var first, second TransactionID
+	first[0], second[0] = 1, 2
+
+	graph.MarkTransactionAsMissing(first, root.Depth+2)
+	time.Sleep(10 * time.Millisecond)
+	graph.MarkTransactionAsMissing(second, root.Depth+1)
+
+	stats := graph.MissingStats()
+	assert.Equal(t, 2, stats.Count)
+	assert.True(t, stats.OldestAge >= 10*time.Millisecond)
+
+	// Marking a transaction as missing again does not reset how long it has
+	// been missing for.
+	graph.MarkTransactionAsMissing(first, root.Depth+1)
+
+	assert.Equal(t, []TransactionID{first, second}, graph.MissingOldest(2))
+	assert.Equal(t, []TransactionID{first}, graph.MissingOldest(1))
+	assert.True(t, graph.MissingStats().OldestAge >= stats.OldestAge)
+}
+
+func TestGraphAdmission(t *testing.T) {
+	t.Parallel()
+
+	keys, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	ledger := NewLedger(store.NewInmem(), skademlia.NewClient(":0", keys), nil, WithMaxGraphSize(1))
+	assert.True(t, ledger.Graph().Full())
+
+	difficulty := ledger.Rounds().Latest().ExpectedDifficulty(sys.MinDifficulty, sys.DifficultyScaleFactor)
+
+	tx := AttachSenderToTransaction(keys, NewTransaction(keys, sys.TagNop, nil), ledger.Graph().FindEligibleParents()...)
+	for tx.IsCritical(difficulty) {
+		tx = AttachSenderToTransaction(keys, NewTransaction(keys, sys.TagNop, nil), ledger.Graph().FindEligibleParents()...)
+	}
+
+	assert.Equal(t, ErrGraphFull, ledger.AddTransaction(tx))
+	assert.Nil(t, ledger.Graph().FindTransaction(tx.ID))
+
+	// Transactions the graph is missing are still admitted.
+
+	ledger.Graph().MarkTransactionAsMissing(tx.ID, tx.Depth)
+	assert.True(t, ledger.Graph().IsMissing(tx.ID))
+
+	assert.NotEqual(t, ErrGraphFull, ledger.AddTransaction(tx))
+	assert.NotNil(t, ledger.Graph().FindTransaction(tx.ID))
+}
@@ -0,0 +1,147 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"io"
+	"sort"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+// GraphFormat is the encoding which the graph of transactions is exported in.
+type GraphFormat string
+
+const (
+	GraphDOT  GraphFormat = "dot"
+	GraphJSON GraphFormat = "json"
+)
+
+func ParseGraphFormat(format string) (GraphFormat, error) {
+	switch f := GraphFormat(format); f {
+	case GraphDOT, GraphJSON:
+		return f, nil
+	}
+
+	return "", errors.Errorf("unknown graph format %q", format)
+}
+
+// ExportGraph writes every transaction in graph alongside the edges to its
+// parents to w, for the purpose of visualizing consensus and debugging tip
+// selection. A transaction is flagged as critical should it be critical under
+// difficulty. Should depths be non-zero, only transactions within the last
+// depths depths of the graph are exported, and edges to parents outside of
+// those depths are omitted from DOT exports.
+func ExportGraph(w io.Writer, format GraphFormat, graph *Graph, depths uint64, difficulty byte) error {
+	var start *uint64
+
+	if height := graph.Height(); depths > 0 && height > depths {
+		depth := height - depths
+		start = &depth
+	}
+
+	transactions := graph.GetTransactionsByDepth(start, nil)
+
+	sort.Slice(transactions, func(i, j int) bool {
+		if transactions[i].Depth != transactions[j].Depth {
+			return transactions[i].Depth < transactions[j].Depth
+		}
+
+		return bytes.Compare(transactions[i].ID[:], transactions[j].ID[:]) < 0
+	})
+
+	buf := bufio.NewWriter(w)
+
+	switch format {
+	case GraphDOT:
+		writeGraphDOT(buf, transactions, difficulty)
+	case GraphJSON:
+		writeGraphJSON(buf, transactions, difficulty)
+	default:
+		return errors.Errorf("unknown graph format %q", format)
+	}
+
+	return errors.Wrap(buf.Flush(), "failed to export graph")
+}
+
+func writeGraphDOT(w *bufio.Writer, transactions []*Transaction, difficulty byte) {
+	exported := make(map[TransactionID]struct{}, len(transactions))
+
+	for _, tx := range transactions {
+		exported[tx.ID] = struct{}{}
+	}
+
+	_, _ = w.WriteString("digraph wavelet {\n\trankdir=RL;\n\tnode [shape=box];\n")
+
+	for _, tx := range transactions {
+		id := hex.EncodeToString(tx.ID[:])
+
+		_, _ = w.WriteString("\t\"" + id + "\" [label=\"" + id[:8] + "\\n" + tx.Tag.String() + " @ " + strconv.FormatUint(tx.Depth, 10) + "\"")
+
+		if tx.IsCritical(difficulty) {
+			_, _ = w.WriteString(", style=filled, fillcolor=gold")
+		}
+
+		_, _ = w.WriteString("];\n")
+	}
+
+	for _, tx := range transactions {
+		id := hex.EncodeToString(tx.ID[:])
+
+		for _, parentID := range tx.ParentIDs {
+			if _, ok := exported[parentID]; !ok {
+				continue
+			}
+
+			_, _ = w.WriteString("\t\"" + id + "\" -> \"" + hex.EncodeToString(parentID[:]) + "\";\n")
+		}
+	}
+
+	_, _ = w.WriteString("}\n")
+}
+
+func writeGraphJSON(w *bufio.Writer, transactions []*Transaction, difficulty byte) {
+	_ = w.WriteByte('[')
+
+	for i, tx := range transactions {
+		if i > 0 {
+			_ = w.WriteByte(',')
+		}
+
+		_, _ = w.WriteString(`{"id":"` + hex.EncodeToString(tx.ID[:]) + `","depth":` + strconv.FormatUint(tx.Depth, 10))
+		_, _ = w.WriteString(`,"critical":` + strconv.FormatBool(tx.IsCritical(difficulty)) + `,"tag":"` + tx.Tag.String() + `","parents":[`)
+
+		for j, parentID := range tx.ParentIDs {
+			if j > 0 {
+				_ = w.WriteByte(',')
+			}
+
+			_, _ = w.WriteString(`"` + hex.EncodeToString(parentID[:]) + `"`)
+		}
+
+		_, _ = w.WriteString("]}")
+	}
+
+	_ = w.WriteByte(']')
+}
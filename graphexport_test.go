@@ -0,0 +1,88 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/perlin-network/noise/skademlia"
+	"github.com/perlin-network/wavelet/sys"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExportGraph(t *testing.T) {
+	t.Parallel()
+
+	keys, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	root := AttachSenderToTransaction(keys, NewTransaction(keys, sys.TagNop, nil))
+	graph := NewGraph(WithRoot(root))
+
+	for i := 0; i < 3; i++ {
+		tx := AttachSenderToTransaction(keys, NewTransaction(keys, sys.TagTransfer, []byte{0}), graph.FindEligibleParents()...)
+		assert.NoError(t, graph.AddTransaction(tx))
+	}
+
+	var buf bytes.Buffer
+	assert.NoError(t, ExportGraph(&buf, GraphJSON, graph, 0, 0))
+
+	var nodes []struct {
+		ID       string   `json:"id"`
+		Depth    uint64   `json:"depth"`
+		Critical bool     `json:"critical"`
+		Tag      string   `json:"tag"`
+		Parents  []string `json:"parents"`
+	}
+
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &nodes))
+	assert.Len(t, nodes, 4)
+
+	assert.Equal(t, hex.EncodeToString(root.ID[:]), nodes[0].ID)
+	assert.Equal(t, "nop", nodes[0].Tag)
+	assert.Empty(t, nodes[0].Parents)
+
+	for i := 1; i < len(nodes); i++ {
+		assert.Equal(t, "transfer", nodes[i].Tag)
+		assert.Equal(t, nodes[i-1].Depth+1, nodes[i].Depth)
+		assert.Equal(t, []string{nodes[i-1].ID}, nodes[i].Parents)
+		assert.True(t, nodes[i].Critical, "every transaction is critical at zero difficulty")
+	}
+
+	buf.Reset()
+	assert.NoError(t, ExportGraph(&buf, GraphJSON, graph, 2, 255))
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &nodes))
+	assert.Len(t, nodes, 2, "only the last two depths should be exported")
+
+	buf.Reset()
+	assert.NoError(t, ExportGraph(&buf, GraphDOT, graph, 2, 0))
+
+	dot := buf.String()
+	assert.True(t, strings.HasPrefix(dot, "digraph wavelet {"))
+	assert.Equal(t, 1, strings.Count(dot, "->"), "edges to parents outside of the exported depths are omitted")
+	assert.Equal(t, 2, strings.Count(dot, "fillcolor=gold"))
+
+	_, err = ParseGraphFormat("xml")
+	assert.Error(t, err)
+}
@@ -0,0 +1,157 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// graphTransactionKey returns the key a transaction is persisted under. Keys
+// are ordered by depth so that transactions may be reloaded parents first, and
+// pruned by depth with a single ascending scan.
+func graphTransactionKey(tx *Transaction) []byte {
+	key := make([]byte, 0, len(keyGraphTransactions)+8+SizeTransactionID)
+
+	key = append(key, keyGraphTransactions[:]...)
+	key = append(key, 0, 0, 0, 0, 0, 0, 0, 0)
+	binary.BigEndian.PutUint64(key[len(keyGraphTransactions):], tx.Depth)
+
+	return append(key, tx.ID[:]...)
+}
+
+// graphTransactionKeyDepth returns the depth encoded in a persisted
+// transactions key, and false should the key be malformed.
+func graphTransactionKeyDepth(key []byte) (uint64, bool) {
+	if len(key) != len(keyGraphTransactions)+8+SizeTransactionID {
+		return 0, false
+	}
+
+	return binary.BigEndian.Uint64(key[len(keyGraphTransactions):]), true
+}
+
+// loadTransactions reloads all transactions persisted into the graphs store
+// above the graphs root depth. Transactions at or below the root depth have
+// already been finalized, and are left in the store until they are pruned.
+// Transactions that fail to decode or validate are deleted from the store.
+func (g *Graph) loadTransactions() {
+	var loaded []Transaction
+	var stale [][]byte
+
+	_ = g.kv.IteratePrefix(keyGraphTransactions[:], func(key, value []byte) bool {
+		depth, ok := graphTransactionKeyDepth(key)
+		if !ok {
+			stale = append(stale, append([]byte(nil), key...))
+			return true
+		}
+
+		if depth <= g.rootDepth {
+			return true
+		}
+
+		tx, err := UnmarshalTransaction(bytes.NewReader(value))
+		if err != nil || tx.Depth != depth {
+			stale = append(stale, append([]byte(nil), key...))
+			return true
+		}
+
+		loaded = append(loaded, tx)
+
+		return true
+	})
+
+	g.deletePersisted(stale)
+
+	for _, tx := range loaded {
+		if _, exists := g.transactions[tx.ID]; exists {
+			continue
+		}
+
+		if err := ValidateTransaction(tx, g.verifySignatures); err != nil {
+			g.deletePersisted([][]byte{graphTransactionKey(&tx)})
+			continue
+		}
+
+		_ = g.insertTransaction(tx)
+	}
+}
+
+// deletePersistedBelowDepth deletes all transactions persisted into the graphs
+// store that have a depth equal to or less than targetDepth. Should the graph
+// archive pruned transactions, they are copied over to the archive beforehand.
+func (g *Graph) deletePersistedBelowDepth(targetDepth uint64) {
+	batch := g.kv.NewWriteBatch()
+	defer batch.Destroy()
+
+	_ = g.kv.IteratePrefix(keyGraphTransactions[:], func(key, value []byte) bool {
+		depth, ok := graphTransactionKeyDepth(key)
+		if ok && depth > targetDepth {
+			return false
+		}
+
+		if ok && g.archive {
+			id := key[len(key)-SizeTransactionID:]
+			batch.Put(append(keyArchivedTransactions[:], id...), append([]byte(nil), value...))
+		}
+
+		batch.Delete(append([]byte(nil), key...))
+
+		return true
+	})
+
+	if batch.Count() > 0 {
+		_ = g.kv.CommitWriteBatch(batch)
+	}
+}
+
+func (g *Graph) deletePersisted(keys [][]byte) {
+	if len(keys) == 0 {
+		return
+	}
+
+	batch := g.kv.NewWriteBatch()
+	defer batch.Destroy()
+
+	for _, key := range keys {
+		batch.Delete(key)
+	}
+
+	_ = g.kv.CommitWriteBatch(batch)
+}
+
+// FindArchivedTransaction returns a transaction the graph has pruned and
+// archived, or nil should it not have been archived.
+func (g *Graph) FindArchivedTransaction(id TransactionID) *Transaction {
+	if g.kv == nil || !g.archive {
+		return nil
+	}
+
+	buf, err := g.kv.Get(append(keyArchivedTransactions[:], id[:]...))
+	if err != nil || len(buf) == 0 {
+		return nil
+	}
+
+	tx, err := UnmarshalTransaction(bytes.NewReader(buf))
+	if err != nil {
+		return nil
+	}
+
+	return &tx
+}
@@ -0,0 +1,293 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+
+	"github.com/perlin-network/wavelet/avl"
+	"github.com/perlin-network/wavelet/store"
+	"github.com/pkg/errors"
+)
+
+// AccountState is the balance and stake of an account as of the end of a
+// finalized round.
+type AccountState struct {
+	Round   uint64
+	Balance uint64
+	Stake   uint64
+
+	prev    uint64
+	hasPrev bool
+}
+
+func (s AccountState) Marshal() []byte {
+	var buf [33]byte
+
+	binary.BigEndian.PutUint64(buf[0:8], s.Round)
+	binary.LittleEndian.PutUint64(buf[8:16], s.Balance)
+	binary.LittleEndian.PutUint64(buf[16:24], s.Stake)
+	binary.BigEndian.PutUint64(buf[24:32], s.prev)
+
+	if s.hasPrev {
+		buf[32] = 1
+	}
+
+	return buf[:]
+}
+
+func UnmarshalAccountState(r io.Reader) (s AccountState, err error) {
+	var buf [33]byte
+
+	if _, err = io.ReadFull(r, buf[:]); err != nil {
+		err = errors.Wrap(err, "failed to decode account state")
+		return
+	}
+
+	s.Round = binary.BigEndian.Uint64(buf[0:8])
+	s.Balance = binary.LittleEndian.Uint64(buf[8:16])
+	s.Stake = binary.LittleEndian.Uint64(buf[16:24])
+	s.prev = binary.BigEndian.Uint64(buf[24:32])
+	s.hasPrev = buf[32] == 1
+
+	return
+}
+
+// History persists the balance and stake of every account modified by a finalized
+// round, such that the state of an account may later be queried as of any round
+// the node has witnessed. It additionally keeps a journal of every mutation made to
+// the balance of each account. Each account keeps its states as a linked list ordered
+// from the most recent round to the oldest, as the underlying store does not
+// support iterating over keys.
+type History struct {
+	kv store.KV
+}
+
+func NewHistory(kv store.KV) *History {
+	return &History{kv: kv}
+}
+
+// Record persists the state of all accounts whose balance or stake has been
+// modified in snapshot since the round prevRound.
+func (h *History) Record(round uint64, snapshot *avl.Tree, prevRound uint64) error {
+	balanceKey := append(keyAccounts[:], keyAccountBalance[:]...)
+	stakeKey := append(keyAccounts[:], keyAccountStake[:]...)
+
+	modified := make(map[AccountID]struct{})
+
+	snapshot.IterateLeafDiff(prevRound, func(key, value []byte) bool {
+		var id AccountID
+
+		switch {
+		case bytes.HasPrefix(key, balanceKey):
+			copy(id[:], key[len(balanceKey):])
+		case bytes.HasPrefix(key, stakeKey):
+			copy(id[:], key[len(stakeKey):])
+		default:
+			return true
+		}
+
+		modified[id] = struct{}{}
+
+		return true
+	})
+
+	return h.record(round, snapshot, modified)
+}
+
+// RecordAll persists the state of every single account in snapshot. It is
+// used to record the state of all accounts at genesis.
+func (h *History) RecordAll(round uint64, snapshot *avl.Tree) error {
+	modified := make(map[AccountID]struct{})
+
+	for _, subkey := range [][]byte{keyAccountBalance[:], keyAccountStake[:]} {
+		prefix := append(keyAccounts[:], subkey...)
+
+		snapshot.IteratePrefix(prefix, func(key, value []byte) {
+			var id AccountID
+			copy(id[:], key[len(prefix):])
+
+			modified[id] = struct{}{}
+		})
+	}
+
+	return h.record(round, snapshot, modified)
+}
+
+func (h *History) record(round uint64, snapshot *avl.Tree, modified map[AccountID]struct{}) error {
+	if len(modified) == 0 {
+		return nil
+	}
+
+	batch := h.kv.NewWriteBatch()
+	defer batch.Destroy()
+
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], round)
+
+	for id := range modified {
+		state := AccountState{Round: round}
+
+		state.Balance, _ = ReadAccountBalance(snapshot, id)
+		state.Stake, _ = ReadAccountStake(snapshot, id)
+
+		if latest, err := h.kv.Get(append(keyAccountHistoryLatest[:], id[:]...)); err == nil && len(latest) == 8 {
+			state.prev = binary.BigEndian.Uint64(latest)
+			state.hasPrev = true
+		}
+
+		batch.Put(historyKey(id, round), state.Marshal())
+		batch.Put(append(keyAccountHistoryLatest[:], id[:]...), buf[:])
+	}
+
+	if err := h.kv.CommitWriteBatch(batch); err != nil {
+		return errors.Wrap(err, "history: failed to write account states")
+	}
+
+	return nil
+}
+
+// RecordJournal appends a set of journal entries to the balance-change journals
+// of the accounts they pertain to.
+func (h *History) RecordJournal(entries []JournalEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	batch := h.kv.NewWriteBatch()
+	defer batch.Destroy()
+
+	lengths := make(map[AccountID]uint64)
+
+	for _, entry := range entries {
+		length, exists := lengths[entry.Account]
+		if !exists {
+			length = h.JournalLen(entry.Account)
+		}
+
+		batch.Put(journalKey(entry.Account, length), entry.Marshal())
+		lengths[entry.Account] = length + 1
+	}
+
+	for id, length := range lengths {
+		var buf [8]byte
+		binary.BigEndian.PutUint64(buf[:], length)
+
+		batch.Put(append(keyAccountJournalLen[:], id[:]...), buf[:])
+	}
+
+	if err := h.kv.CommitWriteBatch(batch); err != nil {
+		return errors.Wrap(err, "history: failed to write journal entries")
+	}
+
+	return nil
+}
+
+// JournalLen returns the number of balance-change journal entries recorded for
+// an account.
+func (h *History) JournalLen(id AccountID) uint64 {
+	buf, err := h.kv.Get(append(keyAccountJournalLen[:], id[:]...))
+	if err != nil || len(buf) != 8 {
+		return 0
+	}
+
+	return binary.BigEndian.Uint64(buf)
+}
+
+// Journal returns at most limit balance-change journal entries of an account in
+// the order they were recorded, skipping the first offset entries.
+func (h *History) Journal(id AccountID, offset, limit uint64) ([]JournalEntry, error) {
+	length := h.JournalLen(id)
+
+	if offset >= length {
+		return nil, nil
+	}
+
+	if limit == 0 || offset+limit > length {
+		limit = length - offset
+	}
+
+	entries := make([]JournalEntry, 0, limit)
+
+	for i := offset; i < offset+limit; i++ {
+		buf, err := h.kv.Get(journalKey(id, i))
+		if err != nil {
+			return nil, errors.Wrapf(err, "history: failed to read journal entry %d", i)
+		}
+
+		entry, err := UnmarshalJournalEntry(bytes.NewReader(buf))
+		if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// Lookup returns the state of an account as of the end of the specified round. It
+// returns false if there is no record of the account as of the specified round.
+func (h *History) Lookup(id AccountID, round uint64) (AccountState, bool) {
+	latest, err := h.kv.Get(append(keyAccountHistoryLatest[:], id[:]...))
+	if err != nil || len(latest) != 8 {
+		return AccountState{}, false
+	}
+
+	current := binary.BigEndian.Uint64(latest)
+
+	for {
+		buf, err := h.kv.Get(historyKey(id, current))
+		if err != nil {
+			return AccountState{}, false
+		}
+
+		state, err := UnmarshalAccountState(bytes.NewReader(buf))
+		if err != nil {
+			return AccountState{}, false
+		}
+
+		if state.Round <= round {
+			return state, true
+		}
+
+		if !state.hasPrev {
+			return AccountState{}, false
+		}
+
+		current = state.prev
+	}
+}
+
+func historyKey(id AccountID, round uint64) []byte {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], round)
+
+	return append(append(keyAccountHistory[:], id[:]...), buf[:]...)
+}
+
+func journalKey(id AccountID, index uint64) []byte {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], index)
+
+	return append(append(keyAccountJournal[:], id[:]...), buf[:]...)
+}
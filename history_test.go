@@ -0,0 +1,113 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"testing"
+
+	"github.com/perlin-network/wavelet/avl"
+	"github.com/perlin-network/wavelet/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHistoryLookup(t *testing.T) {
+	kv := store.NewInmem()
+	history := NewHistory(kv)
+
+	tree := avl.New(kv)
+
+	alice, bob := AccountID{1}, AccountID{2}
+
+	WriteAccountBalance(tree, alice, 100)
+	WriteAccountBalance(tree, bob, 50)
+	assert.NoError(t, tree.Commit())
+	assert.NoError(t, history.RecordAll(0, tree))
+
+	tree.SetViewID(3)
+	WriteAccountBalance(tree, alice, 70)
+	WriteAccountStake(tree, alice, 30)
+	assert.NoError(t, tree.Commit())
+	assert.NoError(t, history.Record(3, tree, 0))
+
+	tree.SetViewID(5)
+	WriteAccountBalance(tree, bob, 10)
+	assert.NoError(t, tree.Commit())
+	assert.NoError(t, history.Record(5, tree, 3))
+
+	state, exists := history.Lookup(alice, 2)
+	assert.True(t, exists)
+	assert.EqualValues(t, 0, state.Round)
+	assert.EqualValues(t, 100, state.Balance)
+	assert.EqualValues(t, 0, state.Stake)
+
+	state, exists = history.Lookup(alice, 5)
+	assert.True(t, exists)
+	assert.EqualValues(t, 3, state.Round)
+	assert.EqualValues(t, 70, state.Balance)
+	assert.EqualValues(t, 30, state.Stake)
+
+	state, exists = history.Lookup(bob, 4)
+	assert.True(t, exists)
+	assert.EqualValues(t, 50, state.Balance)
+
+	state, exists = history.Lookup(bob, 5)
+	assert.True(t, exists)
+	assert.EqualValues(t, 10, state.Balance)
+
+	_, exists = history.Lookup(AccountID{3}, 5)
+	assert.False(t, exists)
+}
+
+func TestHistoryJournal(t *testing.T) {
+	kv := store.NewInmem()
+	history := NewHistory(kv)
+
+	tree := avl.New(kv)
+
+	alice, bob := AccountID{1}, AccountID{2}
+
+	WriteAccountBalance(tree, alice, 100)
+
+	watch := watchBalances(tree, alice, bob, alice)
+
+	WriteAccountBalance(tree, alice, 60)
+	WriteAccountBalance(tree, bob, 40)
+
+	entries := watch.diff(tree, 1, TransactionID{9}, JournalTransfer)
+	assert.Len(t, entries, 2)
+
+	entries = append(entries, JournalEntry{Round: 2, Account: bob, Amount: 5, Credit: true, Reason: JournalReward})
+	assert.NoError(t, history.RecordJournal(entries))
+
+	assert.EqualValues(t, 1, history.JournalLen(alice))
+	assert.EqualValues(t, 2, history.JournalLen(bob))
+
+	journal, err := history.Journal(alice, 0, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []JournalEntry{{Round: 1, TxID: TransactionID{9}, Account: alice, Amount: 40, Reason: JournalTransfer}}, journal)
+
+	journal, err = history.Journal(bob, 1, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []JournalEntry{{Round: 2, Account: bob, Amount: 5, Credit: true, Reason: JournalReward}}, journal)
+
+	journal, err = history.Journal(bob, 2, 10)
+	assert.NoError(t, err)
+	assert.Empty(t, journal)
+}
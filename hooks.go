@@ -0,0 +1,150 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"sync"
+)
+
+// FinalizedRound describes a round that the ledger has just finalized, or has
+// just synced to.
+type FinalizedRound struct {
+	Round     *Round
+	StateRoot MerkleNodeID // Merkle root of the ledger state as of the round.
+
+	// Transactions applied and rejected by the round. Both are empty should
+	// the ledger have synced to the round, as the transactions of rounds that
+	// were synced to are never seen.
+	Applied  []*Transaction
+	Rejected []*Transaction
+
+	Synced bool
+}
+
+// RoundFinalizedHook is called whenever the ledger finalizes or syncs to a round.
+type RoundFinalizedHook func(round FinalizedRound)
+
+// TransactionAppliedHook is called for every transaction applied by a round
+// that the ledger finalizes, in the order they were applied in.
+type TransactionAppliedHook func(round *Round, tx *Transaction)
+
+// hooks keeps track of the callbacks registered against a ledger. Callbacks
+// are called in the order they were registered in.
+type hooks struct {
+	sync.RWMutex
+
+	nextID uint64
+
+	rounds []roundHook
+	txs    []txHook
+}
+
+type roundHook struct {
+	id uint64
+	fn RoundFinalizedHook
+}
+
+type txHook struct {
+	id uint64
+	fn TransactionAppliedHook
+}
+
+// OnRoundFinalized registers hook to be called synchronously by the ledger
+// every time after it finalizes or syncs to a round, once the state as of the
+// round has been committed. Hooks should return quickly, as consensus does not
+// proceed until they do. Calling the returned function unregisters hook.
+func (l *Ledger) OnRoundFinalized(hook RoundFinalizedHook) func() {
+	l.hooks.Lock()
+	defer l.hooks.Unlock()
+
+	id := l.hooks.nextID
+	l.hooks.nextID++
+
+	l.hooks.rounds = append(l.hooks.rounds, roundHook{id: id, fn: hook})
+
+	return func() {
+		l.hooks.Lock()
+		defer l.hooks.Unlock()
+
+		for i, it := range l.hooks.rounds {
+			if it.id == id {
+				l.hooks.rounds = append(l.hooks.rounds[:i:i], l.hooks.rounds[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+// OnTransactionApplied registers hook to be called synchronously by the ledger
+// for every transaction applied by a round it finalizes, before any hook
+// registered through OnRoundFinalized is called. Calling the returned function
+// unregisters hook.
+func (l *Ledger) OnTransactionApplied(hook TransactionAppliedHook) func() {
+	l.hooks.Lock()
+	defer l.hooks.Unlock()
+
+	id := l.hooks.nextID
+	l.hooks.nextID++
+
+	l.hooks.txs = append(l.hooks.txs, txHook{id: id, fn: hook})
+
+	return func() {
+		l.hooks.Lock()
+		defer l.hooks.Unlock()
+
+		for i, it := range l.hooks.txs {
+			if it.id == id {
+				l.hooks.txs = append(l.hooks.txs[:i:i], l.hooks.txs[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+// runHooks calls every registered hook with round. A hook that panics is
+// logged, and does not prevent any other hook from being called.
+func (l *Ledger) runHooks(round FinalizedRound) {
+	l.hooks.RLock()
+	rounds, txs := l.hooks.rounds, l.hooks.txs
+	l.hooks.RUnlock()
+
+	for _, hook := range txs {
+		for _, tx := range round.Applied {
+			l.callHook(func() { hook.fn(round.Round, tx) })
+		}
+	}
+
+	for _, hook := range rounds {
+		l.callHook(func() { hook.fn(round) })
+	}
+}
+
+func (l *Ledger) callHook(fn func()) {
+	defer func() {
+		if r := recover(); r != nil {
+			logger := l.logs.Node()
+			logger.Error().
+				Interface("panic", r).
+				Msg("A round finalization hook panicked.")
+		}
+	}()
+
+	fn()
+}
@@ -0,0 +1,70 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/perlin-network/noise/skademlia"
+	"github.com/perlin-network/wavelet/store"
+	"github.com/perlin-network/wavelet/sys"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLedgerHooks(t *testing.T) {
+	keys, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	ledger := NewLedger(store.NewInmem(), skademlia.NewClient(":0", keys), nil)
+
+	round := ledger.Rounds().Latest()
+
+	a := AttachSenderToTransaction(keys, NewTransaction(keys, sys.TagNop, nil))
+	b := AttachSenderToTransaction(keys, NewTransaction(keys, sys.TagNop, nil), &a)
+
+	var calls []string
+
+	ledger.OnRoundFinalized(func(finalized FinalizedRound) {
+		assert.Equal(t, round, finalized.Round)
+		calls = append(calls, "round")
+	})
+
+	unregister := ledger.OnTransactionApplied(func(_ *Round, tx *Transaction) {
+		calls = append(calls, "tx:"+hex.EncodeToString(tx.ID[:]))
+	})
+
+	ledger.OnTransactionApplied(func(*Round, *Transaction) {
+		panic("hooks that panic must not stop other hooks from being called")
+	})
+
+	ledger.runHooks(FinalizedRound{Round: round, StateRoot: round.Merkle, Applied: []*Transaction{&a, &b}})
+
+	assert.Equal(t, []string{"tx:" + hex.EncodeToString(a.ID[:]), "tx:" + hex.EncodeToString(b.ID[:]), "round"}, calls)
+
+	// Unregistered hooks are no longer called.
+
+	unregister()
+	calls = nil
+
+	ledger.runHooks(FinalizedRound{Round: round, StateRoot: round.Merkle, Applied: []*Transaction{&a}, Synced: true})
+
+	assert.Equal(t, []string{"round"}, calls)
+}
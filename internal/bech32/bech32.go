@@ -0,0 +1,187 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+// Package bech32 implements the bech32 encoding specified by BIP 173, whose
+// checksum detects any single-character error as well as any transposition of
+// two adjacent characters.
+package bech32
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+// MaxLength is the maximum length of a bech32 string.
+const MaxLength = 90
+
+var generator = [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+
+// Encode encodes data under the human-readable part hrp.
+func Encode(hrp string, data []byte) (string, error) {
+	values, err := convertBits(data, 8, 5, true)
+	if err != nil {
+		return "", err
+	}
+
+	if len(hrp)+1+len(values)+6 > MaxLength {
+		return "", errors.Errorf("bech32: encoding would be longer than %d characters", MaxLength)
+	}
+
+	hrp = strings.ToLower(hrp)
+
+	var b strings.Builder
+
+	b.WriteString(hrp)
+	b.WriteByte('1')
+
+	for _, v := range append(values, checksum(hrp, values)...) {
+		b.WriteByte(charset[v])
+	}
+
+	return b.String(), nil
+}
+
+// Decode decodes a bech32 string into its human-readable part and data,
+// verifying its checksum.
+func Decode(s string) (string, []byte, error) {
+	if len(s) > MaxLength {
+		return "", nil, errors.Errorf("bech32: string is longer than %d characters", MaxLength)
+	}
+
+	if strings.ToLower(s) != s && strings.ToUpper(s) != s {
+		return "", nil, errors.New("bech32: string must not mix upper and lower case")
+	}
+
+	s = strings.ToLower(s)
+
+	sep := strings.LastIndexByte(s, '1')
+	if sep < 1 || sep+7 > len(s) {
+		return "", nil, errors.New("bech32: separator is missing or misplaced")
+	}
+
+	hrp := s[:sep]
+
+	for i := 0; i < len(hrp); i++ {
+		if hrp[i] < 33 || hrp[i] > 126 {
+			return "", nil, errors.Errorf("bech32: invalid character in human-readable part at position %d", i)
+		}
+	}
+
+	values := make([]byte, 0, len(s)-sep-1)
+
+	for i := sep + 1; i < len(s); i++ {
+		v := strings.IndexByte(charset, s[i])
+		if v < 0 {
+			return "", nil, errors.Errorf("bech32: invalid character %q at position %d", s[i], i)
+		}
+
+		values = append(values, byte(v))
+	}
+
+	if polymod(append(expandHRP(hrp), values...)) != 1 {
+		return "", nil, errors.New("bech32: invalid checksum")
+	}
+
+	data, err := convertBits(values[:len(values)-6], 5, 8, false)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return hrp, data, nil
+}
+
+func polymod(values []byte) uint32 {
+	chk := uint32(1)
+
+	for _, v := range values {
+		top := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(v)
+
+		for i := 0; i < 5; i++ {
+			if (top>>uint(i))&1 == 1 {
+				chk ^= generator[i]
+			}
+		}
+	}
+
+	return chk
+}
+
+func expandHRP(hrp string) []byte {
+	buf := make([]byte, 0, len(hrp)*2+1)
+
+	for i := 0; i < len(hrp); i++ {
+		buf = append(buf, hrp[i]>>5)
+	}
+
+	buf = append(buf, 0)
+
+	for i := 0; i < len(hrp); i++ {
+		buf = append(buf, hrp[i]&31)
+	}
+
+	return buf
+}
+
+func checksum(hrp string, values []byte) []byte {
+	mod := polymod(append(append(expandHRP(hrp), values...), 0, 0, 0, 0, 0, 0)) ^ 1
+
+	sum := make([]byte, 6)
+
+	for i := range sum {
+		sum[i] = byte(mod>>uint(5*(5-i))) & 31
+	}
+
+	return sum
+}
+
+// convertBits regroups data from groups of from bits into groups of to bits.
+func convertBits(data []byte, from, to uint, pad bool) ([]byte, error) {
+	var acc, bits uint
+
+	maxv := uint(1)<<to - 1
+	out := make([]byte, 0, len(data)*int(from)/int(to)+1)
+
+	for _, b := range data {
+		if uint(b)>>from != 0 {
+			return nil, errors.New("bech32: invalid data range")
+		}
+
+		acc = acc<<from | uint(b)
+		bits += from
+
+		for bits >= to {
+			bits -= to
+			out = append(out, byte(acc>>bits&maxv))
+		}
+	}
+
+	if pad {
+		if bits > 0 {
+			out = append(out, byte(acc<<(to-bits)&maxv))
+		}
+	} else if bits >= from || acc<<(to-bits)&maxv != 0 {
+		return nil, errors.New("bech32: invalid padding")
+	}
+
+	return out, nil
+}
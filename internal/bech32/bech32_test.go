@@ -0,0 +1,97 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package bech32
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValid(t *testing.T) {
+	// Test vectors from BIP 173.
+	vectors := []string{
+		"A12UEL5L",
+		"a12uel5l",
+		"abcdef1qpzry9x8gf2tvdw0s3jn54khce6mua7lmqqqxw",
+		"split1checkupstagehandshakeupstreamerranterredcaperred2y9e3w",
+		"?1ezyfcl",
+	}
+
+	for _, vector := range vectors {
+		hrp, data, err := Decode(vector)
+		if !assert.NoError(t, err, vector) {
+			continue
+		}
+
+		encoded, err := Encode(hrp, data)
+		assert.NoError(t, err)
+		assert.Equal(t, strings.ToLower(vector), encoded)
+	}
+}
+
+func TestInvalid(t *testing.T) {
+	// Test vectors from BIP 173.
+	vectors := []string{
+		"\x201nwldj5",
+		"an84characterslonghumanreadablepartthatcontainsthenumber1andtheexcludedcharactersbio1569pvx",
+		"pzry9x0s0muk",
+		"1pzry9x0s0muk",
+		"x1b4n0q5v",
+		"li1dgmt3",
+		"A1G7SGD8",
+		"10a06t8",
+		"1qzzfhee",
+		"split1checkupstagehandshakeupstreamerranterredcaperred2y9e3x",
+		"Abcdef1qpzry9x8gf2tvdw0s3jn54khce6mua7lmqqqxw",
+	}
+
+	for _, vector := range vectors {
+		_, _, err := Decode(vector)
+		assert.Error(t, err, vector)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	data := []byte{0x40, 0x00, 0x56, 0xee, 0x68, 0xa7, 0xcc, 0x26, 0x95, 0x22, 0x2d, 0xf0, 0x5e, 0xa7, 0x68, 0x75}
+
+	encoded, err := Encode("wav", data)
+	assert.NoError(t, err)
+
+	hrp, decoded, err := Decode(encoded)
+	assert.NoError(t, err)
+	assert.Equal(t, "wav", hrp)
+	assert.Equal(t, data, decoded)
+
+	// Any single-character typo must be detected.
+	for i := len("wav1"); i < len(encoded); i++ {
+		typo := []byte(encoded)
+
+		if typo[i] == 'q' {
+			typo[i] = 'p'
+		} else {
+			typo[i] = 'q'
+		}
+
+		_, _, err := Decode(string(typo))
+		assert.Error(t, err)
+	}
+}
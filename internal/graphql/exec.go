@@ -0,0 +1,682 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package graphql
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/valyala/fastjson"
+)
+
+// Location is a 1-indexed line and column within a GraphQL document.
+type Location struct {
+	Line   int
+	Column int
+}
+
+// Error is an error encountered while parsing, validating, or executing a
+// GraphQL document. Path is set to the response path of the field which failed
+// to resolve, should the error have occurred during execution.
+type Error struct {
+	Message   string
+	Locations []Location
+	Path      []interface{}
+}
+
+func (e *Error) Error() string {
+	var b strings.Builder
+
+	b.WriteString(e.Message)
+
+	for _, loc := range e.Locations {
+		fmt.Fprintf(&b, " (line %d, column %d)", loc.Line, loc.Column)
+	}
+
+	return b.String()
+}
+
+// Params are the parameters of a GraphQL request.
+type Params struct {
+	Query         string
+	OperationName string
+
+	// Variables hold the values of variables as decoded from JSON, being one of
+	// nil, bool, int64, float64, string, []interface{}, or map[string]interface{}.
+	Variables map[string]interface{}
+
+	// Context is passed to every resolver.
+	Context interface{}
+}
+
+// Result is the result of a GraphQL request. Data is nil should the request have
+// failed before being executed.
+type Result struct {
+	Data   *fastjson.Value
+	Errors []*Error
+}
+
+// Value renders the result as a JSON object in the format described by the
+// GraphQL specification.
+func (r *Result) Value(arena *fastjson.Arena) *fastjson.Value {
+	o := arena.NewObject()
+
+	if len(r.Errors) > 0 {
+		list := arena.NewArray()
+
+		for i, err := range r.Errors {
+			item := arena.NewObject()
+			item.Set("message", arena.NewString(err.Message))
+
+			if len(err.Locations) > 0 {
+				locations := arena.NewArray()
+
+				for j, loc := range err.Locations {
+					l := arena.NewObject()
+					l.Set("line", arena.NewNumberInt(loc.Line))
+					l.Set("column", arena.NewNumberInt(loc.Column))
+
+					locations.SetArrayItem(j, l)
+				}
+
+				item.Set("locations", locations)
+			}
+
+			if len(err.Path) > 0 {
+				path := arena.NewArray()
+
+				for j, key := range err.Path {
+					switch key := key.(type) {
+					case string:
+						path.SetArrayItem(j, arena.NewString(key))
+					case int:
+						path.SetArrayItem(j, arena.NewNumberInt(key))
+					}
+				}
+
+				item.Set("path", path)
+			}
+
+			list.SetArrayItem(i, item)
+		}
+
+		o.Set("errors", list)
+	}
+
+	if r.Data != nil {
+		o.Set("data", r.Data)
+	}
+
+	return o
+}
+
+// Do parses, validates, and executes a GraphQL request against schema. The data
+// of the result is allocated within arena.
+func Do(arena *fastjson.Arena, schema *Schema, p Params) *Result {
+	doc, err := parse(p.Query)
+	if err != nil {
+		return &Result{Errors: []*Error{toError(err)}}
+	}
+
+	if errs := validate(schema, doc); len(errs) > 0 {
+		return &Result{Errors: errs}
+	}
+
+	var op *operation
+
+	for _, candidate := range doc.operations {
+		if candidate.name == p.OperationName || (len(p.OperationName) == 0 && len(doc.operations) == 1) {
+			op = candidate
+			break
+		}
+	}
+
+	if op == nil {
+		if len(p.OperationName) == 0 {
+			return &Result{Errors: []*Error{{Message: "an operation name must be specified for documents with more than one operation"}}}
+		}
+
+		return &Result{Errors: []*Error{{Message: fmt.Sprintf("unknown operation %q", p.OperationName)}}}
+	}
+
+	vars, err := coerceVariables(schema, op, p.Variables)
+	if err != nil {
+		return &Result{Errors: []*Error{toError(err)}}
+	}
+
+	e := &executor{schema: schema, doc: doc, vars: vars, ctx: p.Context, arena: arena}
+
+	data, ok := e.executeSelections(schema.Query, nil, op.selections, nil)
+	if !ok {
+		data = arena.NewNull()
+	}
+
+	return &Result{Data: data, Errors: e.errs}
+}
+
+func toError(err error) *Error {
+	if err, ok := err.(*Error); ok {
+		return err
+	}
+
+	return &Error{Message: err.Error()}
+}
+
+// path is a response path, linked from leaf to root.
+type path struct {
+	prev *path
+	key  interface{}
+}
+
+func (p *path) list() []interface{} {
+	var keys []interface{}
+
+	for ; p != nil; p = p.prev {
+		keys = append(keys, p.key)
+	}
+
+	for i, j := 0, len(keys)-1; i < j; i, j = i+1, j-1 {
+		keys[i], keys[j] = keys[j], keys[i]
+	}
+
+	return keys
+}
+
+type executor struct {
+	schema *Schema
+	doc    *document
+	vars   map[string]interface{}
+	ctx    interface{}
+	arena  *fastjson.Arena
+
+	errs []*Error
+}
+
+func (e *executor) fail(p *path, pos int, err error) {
+	e.errs = append(e.errs, &Error{Message: err.Error(), Locations: []Location{locate(e.doc.src, pos)}, Path: p.list()})
+}
+
+// fieldGroup is a list of fields selected under the same response key, whose
+// selections are merged.
+type fieldGroup struct {
+	key    string
+	fields []*field
+}
+
+func (e *executor) collect(parent *Object, selections []selection, visited map[string]bool, groups []*fieldGroup) []*fieldGroup {
+	for _, sel := range selections {
+		switch sel := sel.(type) {
+		case *field:
+			if !e.included(sel.directives) {
+				continue
+			}
+
+			var group *fieldGroup
+
+			for _, g := range groups {
+				if g.key == sel.key() {
+					group = g
+					break
+				}
+			}
+
+			if group == nil {
+				group = &fieldGroup{key: sel.key()}
+				groups = append(groups, group)
+			}
+
+			group.fields = append(group.fields, sel)
+		case *inlineFragment:
+			if !e.included(sel.directives) || (len(sel.typeCondition) > 0 && sel.typeCondition != parent.Name) {
+				continue
+			}
+
+			groups = e.collect(parent, sel.selections, visited, groups)
+		case *fragmentSpread:
+			if !e.included(sel.directives) || visited[sel.name] {
+				continue
+			}
+
+			visited[sel.name] = true
+
+			frag := e.doc.fragments[sel.name]
+			if len(frag.typeCondition) > 0 && frag.typeCondition != parent.Name {
+				continue
+			}
+
+			groups = e.collect(parent, frag.selections, visited, groups)
+		}
+	}
+
+	return groups
+}
+
+// included evaluates the @skip and @include directives.
+func (e *executor) included(directives []*directive) bool {
+	for _, d := range directives {
+		cond, _ := coerceLiteral(Boolean, d.arguments[0].value, e.vars)
+
+		if b, ok := cond.(bool); ok && b == (d.name == "skip") {
+			return false
+		}
+	}
+
+	return true
+}
+
+// executeSelections resolves the fields selected on an object resolved as source.
+// It returns false should a non-null field have resolved to null, in which case
+// the object itself is null.
+func (e *executor) executeSelections(parent *Object, source interface{}, selections []selection, p *path) (*fastjson.Value, bool) {
+	o := e.arena.NewObject()
+
+	for _, group := range e.collect(parent, selections, make(map[string]bool), nil) {
+		f := group.fields[0]
+
+		if f.name == "__typename" {
+			o.Set(group.key, e.arena.NewString(parent.Name))
+			continue
+		}
+
+		def := parent.Fields[f.name]
+		fieldPath := &path{prev: p, key: group.key}
+
+		var res interface{}
+
+		args, err := coerceArguments(def.Args, f.arguments, e.vars)
+		if err == nil {
+			res, err = def.Resolve(ResolveParams{Source: source, Args: args, Context: e.ctx})
+		}
+
+		if err != nil {
+			e.fail(fieldPath, f.pos, err)
+
+			if _, nonNull := def.Type.(*NonNull); nonNull {
+				return nil, false
+			}
+
+			o.Set(group.key, e.arena.NewNull())
+			continue
+		}
+
+		v, ok := e.complete(def.Type, group, res, fieldPath)
+		if !ok {
+			return nil, false
+		}
+
+		o.Set(group.key, v)
+	}
+
+	return o, true
+}
+
+// complete converts the value res resolved for a group of fields into JSON. It
+// returns false should res be null where type t is non-null.
+func (e *executor) complete(t Type, group *fieldGroup, res interface{}, p *path) (*fastjson.Value, bool) {
+	if nonNull, ok := t.(*NonNull); ok {
+		errs := len(e.errs)
+
+		v, ok := e.complete(nonNull.OfType, group, res, p)
+		if !ok || v.Type() == fastjson.TypeNull {
+			if len(e.errs) == errs {
+				e.fail(p, group.fields[0].pos, errors.Errorf("field of type %s resolved to null", t))
+			}
+
+			return nil, false
+		}
+
+		return v, true
+	}
+
+	if isNil(res) {
+		return e.arena.NewNull(), true
+	}
+
+	switch t := t.(type) {
+	case *Scalar:
+		v, err := t.Serialize(e.arena, res)
+		if err != nil {
+			e.fail(p, group.fields[0].pos, err)
+			return e.arena.NewNull(), true
+		}
+
+		return v, true
+	case *List:
+		items, ok := res.([]interface{})
+		if !ok {
+			e.fail(p, group.fields[0].pos, errors.Errorf("expected a list, but got %T", res))
+			return e.arena.NewNull(), true
+		}
+
+		list := e.arena.NewArray()
+
+		for i, item := range items {
+			v, ok := e.complete(t.OfType, group, item, &path{prev: p, key: i})
+			if !ok {
+				return e.arena.NewNull(), true
+			}
+
+			list.SetArrayItem(i, v)
+		}
+
+		return list, true
+	case *Object:
+		var selections []selection
+
+		for _, f := range group.fields {
+			selections = append(selections, f.selections...)
+		}
+
+		v, ok := e.executeSelections(t, res, selections, p)
+		if !ok {
+			return e.arena.NewNull(), true
+		}
+
+		return v, true
+	}
+
+	return e.arena.NewNull(), true
+}
+
+func isNil(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+
+	switch rv := reflect.ValueOf(v); rv.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface, reflect.Func:
+		return rv.IsNil()
+	}
+
+	return false
+}
+
+// resolve resolves a type reference against the input types of the schema.
+func (s *Schema) resolve(ref *typeRef) (Type, error) {
+	var t Type
+
+	if ref.elem != nil {
+		elem, err := s.resolve(ref.elem)
+		if err != nil {
+			return nil, err
+		}
+
+		t = &List{OfType: elem}
+	} else {
+		named, exists := s.types[ref.name]
+		if !exists {
+			return nil, errors.Errorf("unknown type %q", ref.name)
+		}
+
+		if _, ok := named.(*Scalar); !ok {
+			return nil, errors.Errorf("type %s is not an input type", ref.name)
+		}
+
+		t = named
+	}
+
+	if ref.nonNull {
+		t = &NonNull{OfType: t}
+	}
+
+	return t, nil
+}
+
+func coerceVariables(schema *Schema, op *operation, provided map[string]interface{}) (map[string]interface{}, error) {
+	vars := make(map[string]interface{})
+
+	for _, def := range op.variables {
+		t, err := schema.resolve(def.typ)
+		if err != nil {
+			return nil, err
+		}
+
+		raw, exists := provided[def.name]
+
+		if !exists {
+			if def.defaultVal != nil {
+				if vars[def.name], err = coerceLiteral(t, def.defaultVal, nil); err != nil {
+					return nil, errors.Wrapf(err, "default value of variable $%s is invalid", def.name)
+				}
+			} else if def.typ.nonNull {
+				return nil, errors.Errorf("variable $%s of type %s was not provided", def.name, def.typ)
+			}
+
+			continue
+		}
+
+		if vars[def.name], err = coerceInput(t, raw); err != nil {
+			return nil, errors.Wrapf(err, "variable $%s is invalid", def.name)
+		}
+	}
+
+	return vars, nil
+}
+
+// coerceInput coerces a value decoded from JSON into type t.
+func coerceInput(t Type, v interface{}) (interface{}, error) {
+	if nonNull, ok := t.(*NonNull); ok {
+		if v == nil {
+			return nil, errors.Errorf("expected a non-null %s", nonNull.OfType)
+		}
+
+		return coerceInput(nonNull.OfType, v)
+	}
+
+	if v == nil {
+		return nil, nil
+	}
+
+	switch t := t.(type) {
+	case *List:
+		items, ok := v.([]interface{})
+		if !ok {
+			item, err := coerceInput(t.OfType, v)
+			if err != nil {
+				return nil, err
+			}
+
+			return []interface{}{item}, nil
+		}
+
+		list := make([]interface{}, 0, len(items))
+
+		for _, item := range items {
+			coerced, err := coerceInput(t.OfType, item)
+			if err != nil {
+				return nil, err
+			}
+
+			list = append(list, coerced)
+		}
+
+		return list, nil
+	case *Scalar:
+		return t.Parse(v)
+	}
+
+	return nil, errors.Errorf("type %s is not an input type", t)
+}
+
+// coerceLiteral coerces an input value literal into type t, substituting the
+// values of variables from vars.
+func coerceLiteral(t Type, lit value, vars map[string]interface{}) (interface{}, error) {
+	if name, ok := lit.(variable); ok {
+		v := vars[string(name)]
+
+		if _, nonNull := t.(*NonNull); nonNull && v == nil {
+			return nil, errors.Errorf("variable $%s must not be null", name)
+		}
+
+		return v, nil
+	}
+
+	if nonNull, ok := t.(*NonNull); ok {
+		if lit == nil {
+			return nil, errors.Errorf("expected a non-null %s", nonNull.OfType)
+		}
+
+		return coerceLiteral(nonNull.OfType, lit, vars)
+	}
+
+	if lit == nil {
+		return nil, nil
+	}
+
+	switch t := t.(type) {
+	case *List:
+		items, ok := lit.([]value)
+		if !ok {
+			item, err := coerceLiteral(t.OfType, lit, vars)
+			if err != nil {
+				return nil, err
+			}
+
+			return []interface{}{item}, nil
+		}
+
+		list := make([]interface{}, 0, len(items))
+
+		for _, item := range items {
+			coerced, err := coerceLiteral(t.OfType, item, vars)
+			if err != nil {
+				return nil, err
+			}
+
+			list = append(list, coerced)
+		}
+
+		return list, nil
+	case *Scalar:
+		switch lit := lit.(type) {
+		case []value:
+			return nil, errors.Errorf("expected a %s, but got a list", t.Name)
+		case []*objectField:
+			return nil, errors.Errorf("expected a %s, but got an object", t.Name)
+		case enumValue:
+			return nil, errors.Errorf("expected a %s, but got %s", t.Name, string(lit))
+		}
+
+		return t.Parse(lit)
+	}
+
+	return nil, errors.Errorf("type %s is not an input type", t)
+}
+
+func coerceArguments(defs Args, args []*argument, vars map[string]interface{}) (map[string]interface{}, error) {
+	coerced := make(map[string]interface{})
+
+	for name, def := range defs {
+		var arg *argument
+
+		for _, candidate := range args {
+			if candidate.name == name {
+				arg = candidate
+				break
+			}
+		}
+
+		if arg != nil {
+			if ref, ok := arg.value.(variable); ok {
+				if _, provided := vars[string(ref)]; !provided {
+					arg = nil
+				}
+			}
+		}
+
+		if arg == nil {
+			if def.Default != nil {
+				coerced[name] = def.Default
+			} else if _, nonNull := def.Type.(*NonNull); nonNull {
+				return nil, errors.Errorf("argument %q of type %s is required", name, def.Type)
+			}
+
+			continue
+		}
+
+		v, err := coerceLiteral(def.Type, arg.value, vars)
+		if err != nil {
+			return nil, errors.Wrapf(err, "argument %q is invalid", name)
+		}
+
+		coerced[name] = v
+	}
+
+	return coerced, nil
+}
+
+// Variables decodes the variables of a GraphQL request from a JSON object. A
+// null or absent JSON value decodes into no variables.
+func Variables(v *fastjson.Value) (map[string]interface{}, error) {
+	if v == nil || v.Type() == fastjson.TypeNull {
+		return nil, nil
+	}
+
+	o, err := v.Object()
+	if err != nil {
+		return nil, errors.New("variables must be a JSON object")
+	}
+
+	vars := make(map[string]interface{})
+
+	o.Visit(func(key []byte, v *fastjson.Value) {
+		vars[string(key)] = fromJSON(v)
+	})
+
+	return vars, nil
+}
+
+func fromJSON(v *fastjson.Value) interface{} {
+	switch v.Type() {
+	case fastjson.TypeString:
+		return string(v.GetStringBytes())
+	case fastjson.TypeNumber:
+		if n, err := v.Int64(); err == nil {
+			return n
+		}
+
+		return v.GetFloat64()
+	case fastjson.TypeTrue:
+		return true
+	case fastjson.TypeFalse:
+		return false
+	case fastjson.TypeArray:
+		items := v.GetArray()
+		list := make([]interface{}, 0, len(items))
+
+		for _, item := range items {
+			list = append(list, fromJSON(item))
+		}
+
+		return list
+	case fastjson.TypeObject:
+		o := make(map[string]interface{})
+
+		v.GetObject().Visit(func(key []byte, v *fastjson.Value) {
+			o[string(key)] = fromJSON(v)
+		})
+
+		return o
+	}
+
+	return nil
+}
@@ -0,0 +1,249 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package graphql
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fastjson"
+)
+
+type testUser struct {
+	name    string
+	age     int
+	friends []string
+}
+
+var testUsers = map[string]*testUser{
+	"alice": {name: "alice", age: 30, friends: []string{"bob", "carol"}},
+	"bob":   {name: "bob", age: 25, friends: []string{"alice"}},
+	"carol": {name: "carol", age: 35},
+}
+
+func testSchema(t *testing.T) *Schema {
+	user := &Object{Name: "User"}
+
+	user.Fields = Fields{
+		"name": {
+			Type: &NonNull{OfType: String},
+			Resolve: func(p ResolveParams) (interface{}, error) {
+				return p.Source.(*testUser).name, nil
+			},
+		},
+		"age": {
+			Type: Int,
+			Resolve: func(p ResolveParams) (interface{}, error) {
+				return p.Source.(*testUser).age, nil
+			},
+		},
+		"friends": {
+			Type: &NonNull{OfType: &List{OfType: &NonNull{OfType: user}}},
+			Args: Args{
+				"first": {Type: Int, Default: 10},
+			},
+			Resolve: func(p ResolveParams) (interface{}, error) {
+				var friends []interface{}
+
+				for _, name := range p.Source.(*testUser).friends {
+					if len(friends) < p.Args["first"].(int) {
+						friends = append(friends, testUsers[name])
+					}
+				}
+
+				return friends, nil
+			},
+		},
+		"secret": {
+			Type: &NonNull{OfType: String},
+			Resolve: func(p ResolveParams) (interface{}, error) {
+				return nil, errors.New("access denied")
+			},
+		},
+	}
+
+	query := &Object{
+		Name: "Query",
+		Fields: Fields{
+			"user": {
+				Type: user,
+				Args: Args{
+					"name": {Type: &NonNull{OfType: String}},
+				},
+				Resolve: func(p ResolveParams) (interface{}, error) {
+					if u, exists := testUsers[p.Args["name"].(string)]; exists {
+						return u, nil
+					}
+
+					return (*testUser)(nil), nil
+				},
+			},
+			"greeting": {
+				Type: String,
+				Resolve: func(p ResolveParams) (interface{}, error) {
+					return p.Context, nil
+				},
+			},
+		},
+	}
+
+	schema, err := NewSchema(query, 4)
+	assert.NoError(t, err)
+
+	return schema
+}
+
+func TestDo(t *testing.T) {
+	schema := testSchema(t)
+
+	tests := []struct {
+		name     string
+		params   Params
+		expected string
+	}{
+		{
+			name:     "shorthand",
+			params:   Params{Query: `{ greeting }`, Context: "hello"},
+			expected: `{"data":{"greeting":"hello"}}`,
+		},
+		{
+			name:     "nested with aliases and arguments",
+			params:   Params{Query: `query { a: user(name: "alice") { name, friends(first: 1) { name age } } b: user(name: "nobody") { name } }`},
+			expected: `{"data":{"a":{"name":"alice","friends":[{"name":"bob","age":25}]},"b":null}}`,
+		},
+		{
+			name: "fragments and typename",
+			params: Params{Query: `
+				query Q { user(name: "bob") { ...Fields ... on User { age } __typename } }
+				fragment Fields on User { name friends { name } }
+			`},
+			expected: `{"data":{"user":{"name":"bob","friends":[{"name":"alice"}],"age":25,"__typename":"User"}}}`,
+		},
+		{
+			name: "variables and directives",
+			params: Params{
+				Query:     `query Q($name: String!, $withAge: Boolean = false) { user(name: $name) { name age @include(if: $withAge) } }`,
+				Variables: map[string]interface{}{"name": "carol"},
+			},
+			expected: `{"data":{"user":{"name":"carol"}}}`,
+		},
+		{
+			name:     "errors null out the nearest nullable parent",
+			params:   Params{Query: `{ user(name: "alice") { name secret } greeting }`, Context: "hi"},
+			expected: `{"errors":[{"message":"access denied","locations":[{"line":1,"column":30}],"path":["user","secret"]}],"data":{"user":null,"greeting":"hi"}}`,
+		},
+		{
+			name:     "syntax error",
+			params:   Params{Query: `{ user(name: "alice") { name }`},
+			expected: `{"errors":[{"message":"expected a name, but got <EOF>","locations":[{"line":1,"column":31}]}]}`,
+		},
+		{
+			name:     "unknown field",
+			params:   Params{Query: `{ user(name: "alice") { email } }`},
+			expected: `{"errors":[{"message":"cannot query field \"email\" on type User","locations":[{"line":1,"column":25}]}]}`,
+		},
+		{
+			name:     "missing required argument",
+			params:   Params{Query: `{ user { name } }`},
+			expected: `{"errors":[{"message":"argument \"name\" of type String! on field Query.user is required","locations":[{"line":1,"column":3}]}]}`,
+		},
+		{
+			name:     "too deep",
+			params:   Params{Query: `{ user(name: "alice") { friends { friends { friends { name } } } } }`},
+			expected: `{"errors":[{"message":"selections may not be nested more than 4 levels deep","locations":[{"line":1,"column":55}]}]}`,
+		},
+		{
+			name:     "cyclic fragments",
+			params:   Params{Query: `{ user(name: "alice") { ...A } } fragment A on User { friends { ...A } }`},
+			expected: `{"errors":[{"message":"fragment \"A\" may not spread itself","locations":[{"line":1,"column":65}]}]}`,
+		},
+		{
+			name:     "missing variable",
+			params:   Params{Query: `query Q($name: String!) { user(name: $name) { name } }`},
+			expected: `{"errors":[{"message":"variable $name of type String! was not provided"}]}`,
+		},
+		{
+			name:     "mismatched variable",
+			params:   Params{Query: `query Q($name: Int!) { user(name: $name) { name } }`},
+			expected: `{"errors":[{"message":"variable $name of type Int! may not be used for argument \"name\" on field Query.user of type String!","locations":[{"line":1,"column":29}]}]}`,
+		},
+		{
+			name:     "mutations",
+			params:   Params{Query: `mutation { greeting }`},
+			expected: `{"errors":[{"message":"mutation operations are not supported","locations":[{"line":1,"column":1}]}]}`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var arena fastjson.Arena
+
+			res := Do(&arena, schema, tc.params)
+			assert.Equal(t, tc.expected, string(res.Value(&arena).MarshalTo(nil)))
+		})
+	}
+}
+
+func TestVariables(t *testing.T) {
+	v := fastjson.MustParse(`{"a":"x","b":1,"c":1.5,"d":[true,null],"e":{"f":false}}`)
+
+	vars, err := Variables(v)
+	assert.NoError(t, err)
+
+	assert.Equal(t, map[string]interface{}{
+		"a": "x",
+		"b": int64(1),
+		"c": 1.5,
+		"d": []interface{}{true, nil},
+		"e": map[string]interface{}{"f": false},
+	}, vars)
+
+	_, err = Variables(fastjson.MustParse(`[]`))
+	assert.Error(t, err)
+}
+
+func TestParseNesting(t *testing.T) {
+	deep := func(open, close string, n int) string {
+		return strings.Repeat(open, n) + strings.Repeat(close, n)
+	}
+
+	_, err := parse(`query { user(name: "alice") { name } }`)
+	assert.NoError(t, err)
+
+	documents := map[string]string{
+		"selection sets": "query " + deep("{ user(name: \"alice\") ", "}", maxNesting+1),
+		"list values":    `query { user(name: ` + deep("[", "]", maxNesting+1) + `) { name } }`,
+		"object values":  `query { user(name: ` + deep("{ a: ", "}", maxNesting+1) + `) { name } }`,
+		"list types":     `query ($name: ` + deep("[", "]", maxNesting+1)[:maxNesting+1] + `String` + deep("[", "]", maxNesting+1)[maxNesting+1:] + `) { greeting }`,
+	}
+
+	for name, src := range documents {
+		_, err := parse(src)
+		assert.Error(t, err, "%s may not be nested more than %d levels deep", name, maxNesting)
+	}
+
+	// Documents nested far beyond the limit must be rejected without the
+	// parser exhausting its stack.
+
+	_, err = parse("query " + deep("{ a ", "}", 1000000))
+	assert.Error(t, err)
+}
@@ -0,0 +1,704 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package graphql
+
+import (
+	"strconv"
+)
+
+// document is a parsed GraphQL document.
+type document struct {
+	src string
+
+	operations []*operation
+	fragments  map[string]*fragment
+}
+
+type operation struct {
+	kind       string // One of "query", "mutation", or "subscription".
+	name       string
+	variables  []*variableDefinition
+	selections []selection
+	pos        int
+}
+
+type variableDefinition struct {
+	name       string
+	typ        *typeRef
+	defaultVal value
+	pos        int
+}
+
+// typeRef references a named type, a list of some type if elem is set, or a
+// non-null variant of either.
+type typeRef struct {
+	name    string
+	elem    *typeRef
+	nonNull bool
+}
+
+func (t *typeRef) String() string {
+	s := t.name
+
+	if t.elem != nil {
+		s = "[" + t.elem.String() + "]"
+	}
+
+	if t.nonNull {
+		s += "!"
+	}
+
+	return s
+}
+
+type selection interface {
+	position() int
+}
+
+type field struct {
+	alias      string
+	name       string
+	arguments  []*argument
+	directives []*directive
+	selections []selection
+	pos        int
+}
+
+// key returns the key under which the field is keyed in its response.
+func (f *field) key() string {
+	if len(f.alias) > 0 {
+		return f.alias
+	}
+
+	return f.name
+}
+
+type fragmentSpread struct {
+	name       string
+	directives []*directive
+	pos        int
+}
+
+type inlineFragment struct {
+	typeCondition string
+	directives    []*directive
+	selections    []selection
+	pos           int
+}
+
+type fragment struct {
+	name          string
+	typeCondition string
+	selections    []selection
+	pos           int
+}
+
+func (f *field) position() int          { return f.pos }
+func (f *fragmentSpread) position() int { return f.pos }
+func (f *inlineFragment) position() int { return f.pos }
+
+type argument struct {
+	name  string
+	value value
+	pos   int
+}
+
+type directive struct {
+	name      string
+	arguments []*argument
+	pos       int
+}
+
+// value is an input value literal, being one of nil for null, bool, int64,
+// float64, string, enumValue, variable, []value, or []*objectField.
+type value interface{}
+
+type enumValue string
+
+type variable string
+
+type objectField struct {
+	name  string
+	value value
+}
+
+// maxNesting caps how deeply selection sets, list and object values, and list
+// types may be nested within a document, such that parsing a maliciously deep
+// document may not exhaust the stack of the goroutine parsing it.
+const maxNesting = 64
+
+type parser struct {
+	lex *lexer
+	tok token
+
+	depth int // How deeply the current token is nested.
+}
+
+// parse parses src into a GraphQL document.
+func parse(src string) (*document, error) {
+	p := &parser{lex: &lexer{src: src}}
+
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	doc := &document{src: src, fragments: make(map[string]*fragment)}
+
+	for p.tok.kind != tokenEOF {
+		switch {
+		case p.peek("{"):
+			selections, err := p.parseSelectionSet()
+			if err != nil {
+				return nil, err
+			}
+
+			doc.operations = append(doc.operations, &operation{kind: "query", selections: selections})
+		case p.tok.kind == tokenName && (p.tok.value == "query" || p.tok.value == "mutation" || p.tok.value == "subscription"):
+			op, err := p.parseOperation()
+			if err != nil {
+				return nil, err
+			}
+
+			doc.operations = append(doc.operations, op)
+		case p.tok.kind == tokenName && p.tok.value == "fragment":
+			frag, err := p.parseFragment()
+			if err != nil {
+				return nil, err
+			}
+
+			if _, exists := doc.fragments[frag.name]; exists {
+				return nil, p.errorf(frag.pos, "fragment %q is defined more than once", frag.name)
+			}
+
+			doc.fragments[frag.name] = frag
+		default:
+			return nil, p.unexpected()
+		}
+	}
+
+	if len(doc.operations) == 0 {
+		return nil, p.errorf(0, "document does not contain any operations")
+	}
+
+	return doc, nil
+}
+
+func (p *parser) errorf(pos int, format string, args ...interface{}) *Error {
+	return p.lex.errorf(pos, format, args...)
+}
+
+func (p *parser) unexpected() error {
+	return p.errorf(p.tok.pos, "unexpected %s", p.tok)
+}
+
+func (p *parser) advance() error {
+	tok, err := p.lex.next()
+	if err != nil {
+		return err
+	}
+
+	p.tok = tok
+
+	return nil
+}
+
+// enter marks that the parser has descended into a nested selection set, value
+// or type, returning an error should it be nested more than maxNesting levels
+// deep. Every call to enter must be paired with a call to leave.
+func (p *parser) enter() error {
+	p.depth++
+
+	if p.depth > maxNesting {
+		return p.errorf(p.tok.pos, "document may not be nested more than %d levels deep", maxNesting)
+	}
+
+	return nil
+}
+
+func (p *parser) leave() {
+	p.depth--
+}
+
+func (p *parser) peek(punctuator string) bool {
+	return p.tok.kind == tokenPunctuator && p.tok.value == punctuator
+}
+
+// skip advances past the given punctuator, returning false if the current token
+// is not the punctuator.
+func (p *parser) skip(punctuator string) (bool, error) {
+	if !p.peek(punctuator) {
+		return false, nil
+	}
+
+	return true, p.advance()
+}
+
+func (p *parser) expect(punctuator string) error {
+	if !p.peek(punctuator) {
+		return p.errorf(p.tok.pos, "expected %q, but got %s", punctuator, p.tok)
+	}
+
+	return p.advance()
+}
+
+func (p *parser) expectName() (string, error) {
+	if p.tok.kind != tokenName {
+		return "", p.errorf(p.tok.pos, "expected a name, but got %s", p.tok)
+	}
+
+	name := p.tok.value
+
+	return name, p.advance()
+}
+
+func (p *parser) parseOperation() (*operation, error) {
+	op := &operation{kind: p.tok.value, pos: p.tok.pos}
+
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	if p.tok.kind == tokenName {
+		op.name = p.tok.value
+
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+	}
+
+	if ok, err := p.skip("("); err != nil {
+		return nil, err
+	} else if ok {
+		for !p.peek(")") {
+			def, err := p.parseVariableDefinition()
+			if err != nil {
+				return nil, err
+			}
+
+			op.variables = append(op.variables, def)
+		}
+
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.peek("@") {
+		return nil, p.errorf(p.tok.pos, "directives on operations are not supported")
+	}
+
+	selections, err := p.parseSelectionSet()
+	if err != nil {
+		return nil, err
+	}
+
+	op.selections = selections
+
+	return op, nil
+}
+
+func (p *parser) parseFragment() (*fragment, error) {
+	frag := &fragment{pos: p.tok.pos}
+
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	if p.tok.kind == tokenName && p.tok.value == "on" {
+		return nil, p.errorf(p.tok.pos, "fragments may not be named \"on\"")
+	}
+
+	name, err := p.expectName()
+	if err != nil {
+		return nil, err
+	}
+
+	frag.name = name
+
+	if p.tok.kind != tokenName || p.tok.value != "on" {
+		return nil, p.errorf(p.tok.pos, "expected \"on\", but got %s", p.tok)
+	}
+
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	if frag.typeCondition, err = p.expectName(); err != nil {
+		return nil, err
+	}
+
+	if p.peek("@") {
+		return nil, p.errorf(p.tok.pos, "directives on fragment definitions are not supported")
+	}
+
+	if frag.selections, err = p.parseSelectionSet(); err != nil {
+		return nil, err
+	}
+
+	return frag, nil
+}
+
+func (p *parser) parseVariableDefinition() (*variableDefinition, error) {
+	def := &variableDefinition{pos: p.tok.pos}
+
+	if err := p.expect("$"); err != nil {
+		return nil, err
+	}
+
+	name, err := p.expectName()
+	if err != nil {
+		return nil, err
+	}
+
+	def.name = name
+
+	if err := p.expect(":"); err != nil {
+		return nil, err
+	}
+
+	if def.typ, err = p.parseTypeRef(); err != nil {
+		return nil, err
+	}
+
+	if ok, err := p.skip("="); err != nil {
+		return nil, err
+	} else if ok {
+		if def.defaultVal, err = p.parseValue(true); err != nil {
+			return nil, err
+		}
+	}
+
+	return def, nil
+}
+
+func (p *parser) parseTypeRef() (*typeRef, error) {
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer p.leave()
+
+	t := &typeRef{}
+
+	if ok, err := p.skip("["); err != nil {
+		return nil, err
+	} else if ok {
+		if t.elem, err = p.parseTypeRef(); err != nil {
+			return nil, err
+		}
+
+		if err := p.expect("]"); err != nil {
+			return nil, err
+		}
+	} else {
+		if t.name, err = p.expectName(); err != nil {
+			return nil, err
+		}
+	}
+
+	ok, err := p.skip("!")
+	if err != nil {
+		return nil, err
+	}
+
+	t.nonNull = ok
+
+	return t, nil
+}
+
+func (p *parser) parseSelectionSet() ([]selection, error) {
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer p.leave()
+
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+
+	var selections []selection
+
+	for !p.peek("}") {
+		sel, err := p.parseSelection()
+		if err != nil {
+			return nil, err
+		}
+
+		selections = append(selections, sel)
+	}
+
+	if len(selections) == 0 {
+		return nil, p.errorf(p.tok.pos, "selection sets may not be empty")
+	}
+
+	return selections, p.advance()
+}
+
+func (p *parser) parseSelection() (selection, error) {
+	pos := p.tok.pos
+
+	if ok, err := p.skip("..."); err != nil {
+		return nil, err
+	} else if ok {
+		if p.tok.kind == tokenName && p.tok.value != "on" {
+			spread := &fragmentSpread{name: p.tok.value, pos: pos}
+
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+
+			if spread.directives, err = p.parseDirectives(); err != nil {
+				return nil, err
+			}
+
+			return spread, nil
+		}
+
+		inline := &inlineFragment{pos: pos}
+
+		if p.tok.kind == tokenName {
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+
+			if inline.typeCondition, err = p.expectName(); err != nil {
+				return nil, err
+			}
+		}
+
+		if inline.directives, err = p.parseDirectives(); err != nil {
+			return nil, err
+		}
+
+		if inline.selections, err = p.parseSelectionSet(); err != nil {
+			return nil, err
+		}
+
+		return inline, nil
+	}
+
+	f := &field{pos: pos}
+
+	name, err := p.expectName()
+	if err != nil {
+		return nil, err
+	}
+
+	if ok, err := p.skip(":"); err != nil {
+		return nil, err
+	} else if ok {
+		f.alias = name
+
+		if name, err = p.expectName(); err != nil {
+			return nil, err
+		}
+	}
+
+	f.name = name
+
+	if f.arguments, err = p.parseArguments(false); err != nil {
+		return nil, err
+	}
+
+	if f.directives, err = p.parseDirectives(); err != nil {
+		return nil, err
+	}
+
+	if p.peek("{") {
+		if f.selections, err = p.parseSelectionSet(); err != nil {
+			return nil, err
+		}
+	}
+
+	return f, nil
+}
+
+func (p *parser) parseArguments(constant bool) ([]*argument, error) {
+	if ok, err := p.skip("("); err != nil || !ok {
+		return nil, err
+	}
+
+	var args []*argument
+
+	for !p.peek(")") {
+		arg := &argument{pos: p.tok.pos}
+
+		name, err := p.expectName()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, other := range args {
+			if other.name == name {
+				return nil, p.errorf(arg.pos, "argument %q is specified more than once", name)
+			}
+		}
+
+		arg.name = name
+
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+
+		if arg.value, err = p.parseValue(constant); err != nil {
+			return nil, err
+		}
+
+		args = append(args, arg)
+	}
+
+	if len(args) == 0 {
+		return nil, p.errorf(p.tok.pos, "argument lists may not be empty")
+	}
+
+	return args, p.advance()
+}
+
+func (p *parser) parseDirectives() ([]*directive, error) {
+	var directives []*directive
+
+	for p.peek("@") {
+		d := &directive{pos: p.tok.pos}
+
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+
+		name, err := p.expectName()
+		if err != nil {
+			return nil, err
+		}
+
+		d.name = name
+
+		if d.arguments, err = p.parseArguments(false); err != nil {
+			return nil, err
+		}
+
+		directives = append(directives, d)
+	}
+
+	return directives, nil
+}
+
+func (p *parser) parseValue(constant bool) (value, error) {
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer p.leave()
+
+	tok := p.tok
+
+	switch tok.kind {
+	case tokenInt:
+		n, err := strconv.ParseInt(tok.value, 10, 64)
+		if err != nil {
+			return nil, p.errorf(tok.pos, "integer %s is out of range", tok.value)
+		}
+
+		return n, p.advance()
+	case tokenFloat:
+		f, err := strconv.ParseFloat(tok.value, 64)
+		if err != nil {
+			return nil, p.errorf(tok.pos, "float %s is out of range", tok.value)
+		}
+
+		return f, p.advance()
+	case tokenString:
+		return tok.value, p.advance()
+	case tokenName:
+		var v value
+
+		switch tok.value {
+		case "true":
+			v = true
+		case "false":
+			v = false
+		case "null":
+			v = nil
+		default:
+			v = enumValue(tok.value)
+		}
+
+		return v, p.advance()
+	}
+
+	switch {
+	case p.peek("$"):
+		if constant {
+			return nil, p.errorf(tok.pos, "variables may not be used in constant values")
+		}
+
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+
+		name, err := p.expectName()
+		if err != nil {
+			return nil, err
+		}
+
+		return variable(name), nil
+	case p.peek("["):
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+
+		list := []value{}
+
+		for !p.peek("]") {
+			item, err := p.parseValue(constant)
+			if err != nil {
+				return nil, err
+			}
+
+			list = append(list, item)
+		}
+
+		return list, p.advance()
+	case p.peek("{"):
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+
+		object := []*objectField{}
+
+		for !p.peek("}") {
+			name, err := p.expectName()
+			if err != nil {
+				return nil, err
+			}
+
+			if err := p.expect(":"); err != nil {
+				return nil, err
+			}
+
+			item, err := p.parseValue(constant)
+			if err != nil {
+				return nil, err
+			}
+
+			object = append(object, &objectField{name: name, value: item})
+		}
+
+		return object, p.advance()
+	}
+
+	return nil, p.unexpected()
+}
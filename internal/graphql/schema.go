@@ -0,0 +1,354 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+// Package graphql implements the subset of GraphQL needed to serve read-only
+// queries over ledger data. Only queries are supported; mutations, subscriptions,
+// interfaces, unions, enums, input objects, block strings, and introspection
+// beyond __typename are not.
+package graphql
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"github.com/valyala/fastjson"
+)
+
+// Type is a GraphQL output or input type, being either a *Scalar, an *Object, a
+// *List, or a *NonNull.
+type Type interface {
+	String() string
+}
+
+// Scalar is a leaf type. Serialize converts values returned by resolvers into
+// JSON, and Parse converts input values into the values passed to resolvers as
+// arguments. Input values are one of nil, bool, int64, float64, or string.
+type Scalar struct {
+	Name        string
+	Description string
+
+	Serialize func(arena *fastjson.Arena, v interface{}) (*fastjson.Value, error)
+	Parse     func(v interface{}) (interface{}, error)
+}
+
+func (s *Scalar) String() string {
+	return s.Name
+}
+
+// Object is a type composed of a set of named fields.
+type Object struct {
+	Name        string
+	Description string
+	Fields      Fields
+}
+
+func (o *Object) String() string {
+	return o.Name
+}
+
+// Fields maps the names of fields of an object to their definitions.
+type Fields map[string]*Field
+
+// Field is a field of an object, whose value is computed by Resolve given the
+// value of its parent object as the source.
+type Field struct {
+	Type        Type
+	Description string
+	Args        Args
+
+	Resolve func(p ResolveParams) (interface{}, error)
+}
+
+// Args maps the names of the arguments of a field to their definitions.
+type Args map[string]*Arg
+
+// Arg is an argument of a field. Should an argument not be specified, it takes
+// on the value of Default, if it is not nil.
+type Arg struct {
+	Type        Type
+	Description string
+	Default     interface{}
+}
+
+// ResolveParams are the parameters passed to the resolver of a field.
+type ResolveParams struct {
+	// Source is the value resolved for the parent object of the field.
+	Source interface{}
+
+	// Args holds the values of all specified arguments, alongside all arguments
+	// which have default values. Unspecified arguments are absent.
+	Args map[string]interface{}
+
+	// Context is the context passed in alongside the query.
+	Context interface{}
+}
+
+// List is a list of values of some type. Resolvers of list fields must return
+// a []interface{}.
+type List struct {
+	OfType Type
+}
+
+func (l *List) String() string {
+	return "[" + l.OfType.String() + "]"
+}
+
+// NonNull is a variant of some type which may never be null.
+type NonNull struct {
+	OfType Type
+}
+
+func (n *NonNull) String() string {
+	return n.OfType.String() + "!"
+}
+
+// Schema describes the query root type of a GraphQL API. Mutations and
+// subscriptions are not supported.
+type Schema struct {
+	Query *Object
+
+	// MaxDepth limits how deeply selection sets may be nested in a query. It
+	// is unlimited should it be zero.
+	MaxDepth int
+
+	types map[string]Type
+}
+
+// NewSchema validates the types reachable from the query root type, and
+// returns a schema for them.
+func NewSchema(query *Object, maxDepth int) (*Schema, error) {
+	s := &Schema{Query: query, MaxDepth: maxDepth, types: make(map[string]Type)}
+
+	for _, scalar := range []*Scalar{String, Int, Float, Boolean, ID} {
+		s.types[scalar.Name] = scalar
+	}
+
+	if err := s.register(query); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func (s *Schema) register(t Type) error {
+	switch t := t.(type) {
+	case *List:
+		return s.register(t.OfType)
+	case *NonNull:
+		if _, ok := t.OfType.(*NonNull); ok {
+			return errors.Errorf("type %s may not be non-null twice", t)
+		}
+
+		return s.register(t.OfType)
+	case *Scalar:
+		if existing, exists := s.types[t.Name]; exists && existing != t {
+			return errors.Errorf("type %s is defined more than once", t.Name)
+		}
+
+		if t.Serialize == nil || t.Parse == nil {
+			return errors.Errorf("scalar %s must be able to serialize and parse values", t.Name)
+		}
+
+		s.types[t.Name] = t
+	case *Object:
+		if existing, exists := s.types[t.Name]; exists {
+			if existing != t {
+				return errors.Errorf("type %s is defined more than once", t.Name)
+			}
+
+			return nil
+		}
+
+		s.types[t.Name] = t
+
+		for name, f := range t.Fields {
+			if f.Type == nil {
+				return errors.Errorf("field %s.%s has no type", t.Name, name)
+			}
+
+			if err := s.register(f.Type); err != nil {
+				return err
+			}
+
+			for argName, arg := range f.Args {
+				if _, ok := namedType(arg.Type).(*Scalar); !ok {
+					return errors.Errorf("argument %s of field %s.%s must be a scalar, or a list of scalars", argName, t.Name, name)
+				}
+
+				if err := s.register(arg.Type); err != nil {
+					return err
+				}
+			}
+		}
+	default:
+		return errors.Errorf("unknown type %T", t)
+	}
+
+	return nil
+}
+
+// namedType unwraps all list and non-null modifiers of t.
+func namedType(t Type) Type {
+	for {
+		switch u := t.(type) {
+		case *List:
+			t = u.OfType
+		case *NonNull:
+			t = u.OfType
+		default:
+			return t
+		}
+	}
+}
+
+var (
+	// String is a UTF-8 character sequence.
+	String = &Scalar{
+		Name: "String",
+		Serialize: func(arena *fastjson.Arena, v interface{}) (*fastjson.Value, error) {
+			switch v := v.(type) {
+			case string:
+				return arena.NewString(v), nil
+			case []byte:
+				return arena.NewStringBytes(v), nil
+			case fmt.Stringer:
+				return arena.NewString(v.String()), nil
+			}
+
+			return nil, errors.Errorf("cannot serialize %T as a String", v)
+		},
+		Parse: func(v interface{}) (interface{}, error) {
+			if s, ok := v.(string); ok {
+				return s, nil
+			}
+
+			return nil, errors.Errorf("expected a String, but got %v", v)
+		},
+	}
+
+	// ID is a unique identifier, serialized as a string.
+	ID = &Scalar{
+		Name:      "ID",
+		Serialize: String.Serialize,
+		Parse: func(v interface{}) (interface{}, error) {
+			switch v := v.(type) {
+			case string:
+				return v, nil
+			case int64:
+				return strconv.FormatInt(v, 10), nil
+			}
+
+			return nil, errors.Errorf("expected an ID, but got %v", v)
+		},
+	}
+
+	// Int is a signed 32-bit integer.
+	Int = &Scalar{
+		Name: "Int",
+		Serialize: func(arena *fastjson.Arena, v interface{}) (*fastjson.Value, error) {
+			var n int64
+
+			switch v := v.(type) {
+			case int:
+				n = int64(v)
+			case int32:
+				n = int64(v)
+			case int64:
+				n = v
+			case uint8:
+				n = int64(v)
+			case uint32:
+				n = int64(v)
+			case uint64:
+				if v > math.MaxInt32 {
+					return nil, errors.Errorf("%d does not fit in an Int", v)
+				}
+
+				n = int64(v)
+			default:
+				return nil, errors.Errorf("cannot serialize %T as an Int", v)
+			}
+
+			if n < math.MinInt32 || n > math.MaxInt32 {
+				return nil, errors.Errorf("%d does not fit in an Int", n)
+			}
+
+			return arena.NewNumberInt(int(n)), nil
+		},
+		Parse: func(v interface{}) (interface{}, error) {
+			if n, ok := v.(int64); ok && n >= math.MinInt32 && n <= math.MaxInt32 {
+				return int(n), nil
+			}
+
+			return nil, errors.Errorf("expected an Int, but got %v", v)
+		},
+	}
+
+	// Float is a double-precision floating-point number.
+	Float = &Scalar{
+		Name: "Float",
+		Serialize: func(arena *fastjson.Arena, v interface{}) (*fastjson.Value, error) {
+			switch v := v.(type) {
+			case float64:
+				return arena.NewNumberFloat64(v), nil
+			case float32:
+				return arena.NewNumberFloat64(float64(v)), nil
+			case int:
+				return arena.NewNumberInt(v), nil
+			}
+
+			return nil, errors.Errorf("cannot serialize %T as a Float", v)
+		},
+		Parse: func(v interface{}) (interface{}, error) {
+			switch v := v.(type) {
+			case float64:
+				return v, nil
+			case int64:
+				return float64(v), nil
+			}
+
+			return nil, errors.Errorf("expected a Float, but got %v", v)
+		},
+	}
+
+	// Boolean is either true or false.
+	Boolean = &Scalar{
+		Name: "Boolean",
+		Serialize: func(arena *fastjson.Arena, v interface{}) (*fastjson.Value, error) {
+			if b, ok := v.(bool); ok {
+				if b {
+					return arena.NewTrue(), nil
+				}
+
+				return arena.NewFalse(), nil
+			}
+
+			return nil, errors.Errorf("cannot serialize %T as a Boolean", v)
+		},
+		Parse: func(v interface{}) (interface{}, error) {
+			if b, ok := v.(bool); ok {
+				return b, nil
+			}
+
+			return nil, errors.Errorf("expected a Boolean, but got %v", v)
+		},
+	}
+)
@@ -0,0 +1,354 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package graphql
+
+import (
+	"fmt"
+)
+
+// maxFields limits the total number of fields validated in a single document,
+// with fragments expanded, guarding against documents which expand
+// exponentially through nested fragment spreads.
+const maxFields = 10000
+
+type validator struct {
+	schema *Schema
+	doc    *document
+	errs   []*Error
+
+	fields int
+}
+
+// validate validates all operations of doc against the schema. Fragments are
+// validated at every point they are spread.
+func validate(schema *Schema, doc *document) []*Error {
+	v := &validator{schema: schema, doc: doc}
+
+	names := make(map[string]bool)
+
+	for _, op := range doc.operations {
+		if len(op.name) == 0 && len(doc.operations) > 1 {
+			v.errorf(op.pos, "anonymous operations must be the only operation in a document")
+		}
+
+		if names[op.name] && len(op.name) > 0 {
+			v.errorf(op.pos, "operation %q is defined more than once", op.name)
+		}
+
+		names[op.name] = true
+
+		if op.kind != "query" {
+			v.errorf(op.pos, "%s operations are not supported", op.kind)
+			continue
+		}
+
+		vars := make(map[string]*variableDefinition)
+
+		for _, def := range op.variables {
+			if _, exists := vars[def.name]; exists {
+				v.errorf(def.pos, "variable $%s is defined more than once", def.name)
+				continue
+			}
+
+			vars[def.name] = def
+
+			t, err := schema.resolve(def.typ)
+			if err != nil {
+				v.errorf(def.pos, "variable $%s: %v", def.name, err)
+				continue
+			}
+
+			if def.defaultVal != nil {
+				if _, err := coerceLiteral(t, def.defaultVal, nil); err != nil {
+					v.errorf(def.pos, "default value of variable $%s is invalid: %v", def.name, err)
+				}
+			}
+		}
+
+		v.validateSelections(schema.Query, op.selections, 1, nil, vars)
+
+		if len(v.errs) > 0 {
+			break
+		}
+	}
+
+	return v.errs
+}
+
+func (v *validator) errorf(pos int, format string, args ...interface{}) {
+	v.errs = append(v.errs, &Error{Message: fmt.Sprintf(format, args...), Locations: []Location{locate(v.doc.src, pos)}})
+}
+
+// flatten lists every field selected within a selection set on parent, with
+// fragments expanded. Directives are not evaluated.
+func (v *validator) flatten(parent *Object, selections []selection, spreads []string, out []*field) []*field {
+	for _, sel := range selections {
+		switch sel := sel.(type) {
+		case *field:
+			out = append(out, sel)
+		case *inlineFragment:
+			if v.applies(parent, sel.typeCondition) {
+				out = v.flatten(parent, sel.selections, spreads, out)
+			}
+		case *fragmentSpread:
+			frag, exists := v.doc.fragments[sel.name]
+			if !exists || contains(spreads, sel.name) || !v.applies(parent, frag.typeCondition) {
+				continue
+			}
+
+			out = v.flatten(parent, frag.selections, append(spreads, sel.name), out)
+		}
+	}
+
+	return out
+}
+
+func (v *validator) applies(parent *Object, typeCondition string) bool {
+	return len(typeCondition) == 0 || typeCondition == parent.Name
+}
+
+func (v *validator) validateSelections(parent *Object, selections []selection, depth int, spreads []string, vars map[string]*variableDefinition) {
+	if v.schema.MaxDepth > 0 && depth > v.schema.MaxDepth {
+		v.errorf(selections[0].position(), "selections may not be nested more than %d levels deep", v.schema.MaxDepth)
+		return
+	}
+
+	for _, sel := range selections {
+		if len(v.errs) > 0 {
+			return
+		}
+
+		switch sel := sel.(type) {
+		case *field:
+			v.validateField(parent, sel, depth, spreads, vars)
+		case *inlineFragment:
+			if !v.validateTypeCondition(parent, sel.typeCondition, sel.pos) {
+				continue
+			}
+
+			v.validateDirectives(sel.directives, vars)
+			v.validateSelections(parent, sel.selections, depth, spreads, vars)
+		case *fragmentSpread:
+			frag, exists := v.doc.fragments[sel.name]
+			if !exists {
+				v.errorf(sel.pos, "unknown fragment %q", sel.name)
+				continue
+			}
+
+			if contains(spreads, sel.name) {
+				v.errorf(sel.pos, "fragment %q may not spread itself", sel.name)
+				continue
+			}
+
+			if !v.validateTypeCondition(parent, frag.typeCondition, frag.pos) {
+				continue
+			}
+
+			v.validateDirectives(sel.directives, vars)
+			v.validateSelections(parent, frag.selections, depth, append(spreads, sel.name), vars)
+		}
+	}
+
+	if len(v.errs) > 0 {
+		return
+	}
+
+	// Fields which share the same response key must select the same field.
+
+	keys := make(map[string]*field)
+
+	for _, f := range v.flatten(parent, selections, spreads, nil) {
+		if other, exists := keys[f.key()]; exists && other.name != f.name {
+			v.errorf(f.pos, "fields %q and %q conflict, as they are both keyed as %q", other.name, f.name, f.key())
+			return
+		}
+
+		keys[f.key()] = f
+	}
+}
+
+func (v *validator) validateTypeCondition(parent *Object, typeCondition string, pos int) bool {
+	if len(typeCondition) == 0 || typeCondition == parent.Name {
+		return true
+	}
+
+	if _, exists := v.schema.types[typeCondition]; !exists {
+		v.errorf(pos, "unknown type %q", typeCondition)
+	} else {
+		v.errorf(pos, "fragments on type %s may not be spread within type %s", typeCondition, parent.Name)
+	}
+
+	return false
+}
+
+func (v *validator) validateField(parent *Object, f *field, depth int, spreads []string, vars map[string]*variableDefinition) {
+	if v.fields++; v.fields > maxFields {
+		v.errorf(f.pos, "document selects more than %d fields", maxFields)
+		return
+	}
+
+	v.validateDirectives(f.directives, vars)
+
+	if f.name == "__typename" {
+		if len(f.arguments) > 0 || len(f.selections) > 0 {
+			v.errorf(f.pos, "field __typename may not have arguments or selections")
+		}
+
+		return
+	}
+
+	def, exists := parent.Fields[f.name]
+	if !exists {
+		v.errorf(f.pos, "cannot query field %q on type %s", f.name, parent.Name)
+		return
+	}
+
+	v.validateArguments(fmt.Sprintf("field %s.%s", parent.Name, f.name), def.Args, f.arguments, f.pos, vars)
+
+	switch t := namedType(def.Type).(type) {
+	case *Object:
+		if len(f.selections) == 0 {
+			v.errorf(f.pos, "field %q of type %s must have a selection of subfields", f.name, def.Type)
+			return
+		}
+
+		v.validateSelections(t, f.selections, depth+1, spreads, vars)
+	default:
+		if len(f.selections) > 0 {
+			v.errorf(f.pos, "field %q of type %s may not have a selection of subfields", f.name, def.Type)
+		}
+	}
+}
+
+var directiveArgs = Args{
+	"if": &Arg{Type: &NonNull{OfType: Boolean}},
+}
+
+func (v *validator) validateDirectives(directives []*directive, vars map[string]*variableDefinition) {
+	seen := make(map[string]bool)
+
+	for _, d := range directives {
+		if d.name != "skip" && d.name != "include" {
+			v.errorf(d.pos, "unknown directive @%s", d.name)
+			continue
+		}
+
+		if seen[d.name] {
+			v.errorf(d.pos, "directive @%s is specified more than once", d.name)
+			continue
+		}
+
+		seen[d.name] = true
+
+		v.validateArguments("directive @"+d.name, directiveArgs, d.arguments, d.pos, vars)
+	}
+}
+
+func (v *validator) validateArguments(owner string, defs Args, args []*argument, pos int, vars map[string]*variableDefinition) {
+	specified := make(map[string]bool)
+
+	for _, arg := range args {
+		specified[arg.name] = true
+
+		def, exists := defs[arg.name]
+		if !exists {
+			v.errorf(arg.pos, "unknown argument %q on %s", arg.name, owner)
+			continue
+		}
+
+		v.validateValue(def.Type, arg.value, def.Default != nil, arg.pos, vars, fmt.Sprintf("argument %q on %s", arg.name, owner))
+	}
+
+	for name, def := range defs {
+		if _, required := def.Type.(*NonNull); required && def.Default == nil && !specified[name] {
+			v.errorf(pos, "argument %q of type %s on %s is required", name, def.Type, owner)
+		}
+	}
+}
+
+// validateValue validates that an input value may be coerced into type t,
+// checking that any variables referenced are defined and of a compatible type.
+func (v *validator) validateValue(t Type, val value, hasDefault bool, pos int, vars map[string]*variableDefinition, owner string) {
+	switch val := val.(type) {
+	case variable:
+		def, exists := vars[string(val)]
+		if !exists {
+			v.errorf(pos, "variable $%s is not defined", val)
+			return
+		}
+
+		if !compatible(def.typ, t, def.defaultVal != nil || hasDefault) {
+			v.errorf(pos, "variable $%s of type %s may not be used for %s of type %s", val, def.typ, owner, t)
+		}
+	case []value:
+		if list, ok := unwrapNonNull(t).(*List); ok {
+			for _, item := range val {
+				v.validateValue(list.OfType, item, false, pos, vars, owner)
+			}
+
+			return
+		}
+
+		v.errorf(pos, "%s of type %s may not be a list", owner, t)
+	default:
+		if _, err := coerceLiteral(t, val, nil); err != nil {
+			v.errorf(pos, "%s is invalid: %v", owner, err)
+		}
+	}
+}
+
+// compatible reports whether a variable of type ref may be used where a value
+// of type t is expected.
+func compatible(ref *typeRef, t Type, hasDefault bool) bool {
+	if nonNull, ok := t.(*NonNull); ok {
+		if !ref.nonNull && !hasDefault {
+			return false
+		}
+
+		t = nonNull.OfType
+	}
+
+	switch t := t.(type) {
+	case *List:
+		return ref.elem != nil && compatible(ref.elem, t.OfType, false)
+	case *Scalar:
+		return ref.elem == nil && ref.name == t.Name
+	}
+
+	return false
+}
+
+func unwrapNonNull(t Type) Type {
+	if nonNull, ok := t.(*NonNull); ok {
+		return nonNull.OfType
+	}
+
+	return t
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,254 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+
+	"github.com/perlin-network/wavelet/avl"
+	"github.com/perlin-network/wavelet/sys"
+	"github.com/pkg/errors"
+)
+
+// JournalReason denotes why the balance of an account was modified.
+type JournalReason byte
+
+const (
+	JournalTransfer JournalReason = iota
+	JournalFee
+	JournalReward
+	JournalStake
+	JournalContract
+	JournalBatch
+	JournalRecovery
+)
+
+func (r JournalReason) String() string {
+	switch r {
+	case JournalTransfer:
+		return "transfer"
+	case JournalFee:
+		return "fee"
+	case JournalReward:
+		return "reward"
+	case JournalStake:
+		return "stake"
+	case JournalContract:
+		return "contract"
+	case JournalBatch:
+		return "batch"
+	case JournalRecovery:
+		return "recovery"
+	}
+
+	return "unknown"
+}
+
+// journalReasonForTag returns the reason recorded for balance mutations that
+// are a result of applying a transaction with the given tag.
+func journalReasonForTag(tag sys.Tag) JournalReason {
+	switch tag {
+	case sys.TagStake:
+		return JournalStake
+	case sys.TagContract:
+		return JournalContract
+	case sys.TagBatch:
+		return JournalBatch
+	case sys.TagRecovery:
+		return JournalRecovery
+	}
+
+	return JournalTransfer
+}
+
+// JournalEntry records a single mutation to the balance of an account that took
+// place while collapsing transactions for a round. Entries for reward withdrawals
+// do not have an associated transaction ID.
+type JournalEntry struct {
+	Round   uint64
+	TxID    TransactionID
+	Account AccountID
+
+	Amount uint64
+	Credit bool // True if Amount was added to the balance, and false if it was deducted.
+
+	Reason JournalReason
+}
+
+func (e JournalEntry) Marshal() []byte {
+	w := bytes.NewBuffer(make([]byte, 0, 8+SizeTransactionID+SizeAccountID+8+2))
+
+	var buf [8]byte
+
+	binary.BigEndian.PutUint64(buf[:], e.Round)
+	w.Write(buf[:])
+
+	w.Write(e.TxID[:])
+	w.Write(e.Account[:])
+
+	binary.BigEndian.PutUint64(buf[:], e.Amount)
+	w.Write(buf[:])
+
+	if e.Credit {
+		w.WriteByte(1)
+	} else {
+		w.WriteByte(0)
+	}
+
+	w.WriteByte(byte(e.Reason))
+
+	return w.Bytes()
+}
+
+func UnmarshalJournalEntry(r io.Reader) (e JournalEntry, err error) {
+	var buf [8]byte
+
+	if _, err = io.ReadFull(r, buf[:]); err != nil {
+		err = errors.Wrap(err, "failed to decode journal entry round")
+		return
+	}
+
+	e.Round = binary.BigEndian.Uint64(buf[:])
+
+	if _, err = io.ReadFull(r, e.TxID[:]); err != nil {
+		err = errors.Wrap(err, "failed to decode journal entry transaction ID")
+		return
+	}
+
+	if _, err = io.ReadFull(r, e.Account[:]); err != nil {
+		err = errors.Wrap(err, "failed to decode journal entry account ID")
+		return
+	}
+
+	if _, err = io.ReadFull(r, buf[:]); err != nil {
+		err = errors.Wrap(err, "failed to decode journal entry amount")
+		return
+	}
+
+	e.Amount = binary.BigEndian.Uint64(buf[:])
+
+	if _, err = io.ReadFull(r, buf[:2]); err != nil {
+		err = errors.Wrap(err, "failed to decode journal entry reason")
+		return
+	}
+
+	e.Credit = buf[0] == 1
+	e.Reason = JournalReason(buf[1])
+
+	return
+}
+
+// balanceWatch captures the balances of a set of accounts, such that changes made
+// to their balances may afterwards be recorded as journal entries.
+type balanceWatch struct {
+	ids      []AccountID
+	balances []uint64
+}
+
+func watchBalances(snapshot *avl.Tree, ids ...AccountID) balanceWatch {
+	w := balanceWatch{ids: make([]AccountID, 0, len(ids)), balances: make([]uint64, 0, len(ids))}
+
+	seen := make(map[AccountID]struct{}, len(ids))
+
+	for _, id := range ids {
+		if _, exists := seen[id]; exists {
+			continue
+		}
+
+		seen[id] = struct{}{}
+
+		balance, _ := ReadAccountBalance(snapshot, id)
+
+		w.ids = append(w.ids, id)
+		w.balances = append(w.balances, balance)
+	}
+
+	return w
+}
+
+// diff returns journal entries for all watched accounts whose balances have since
+// been modified in snapshot.
+func (w balanceWatch) diff(snapshot *avl.Tree, round uint64, txID TransactionID, reason JournalReason) []JournalEntry {
+	var entries []JournalEntry
+
+	for i, id := range w.ids {
+		balance, _ := ReadAccountBalance(snapshot, id)
+
+		if balance == w.balances[i] {
+			continue
+		}
+
+		entry := JournalEntry{Round: round, TxID: txID, Account: id, Reason: reason}
+
+		if balance > w.balances[i] {
+			entry.Amount, entry.Credit = balance-w.balances[i], true
+		} else {
+			entry.Amount = w.balances[i] - balance
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries
+}
+
+// balanceParticipants returns the IDs of all accounts whose balances may be
+// modified by applying a transaction to snapshot. Balance mutations caused by smart
+// contracts transferring PERLs to third parties are not attributed.
+func balanceParticipants(snapshot *avl.Tree, tx *Transaction) []AccountID {
+	ids := []AccountID{tx.Creator, tx.Sender}
+
+	switch tx.Tag {
+	case sys.TagTransfer:
+		if params, err := ParseTransferTransaction(tx.Payload); err == nil {
+			ids = append(ids, params.Recipient)
+		}
+	case sys.TagBatch:
+		params, err := ParseBatchTransaction(tx.Payload)
+		if err != nil {
+			break
+		}
+
+		for i := uint8(0); i < params.Size; i++ {
+			if sys.Tag(params.Tags[i]) != sys.TagTransfer {
+				continue
+			}
+
+			if transfer, err := ParseTransferTransaction(params.Payloads[i]); err == nil {
+				ids = append(ids, transfer.Recipient)
+			}
+		}
+	case sys.TagRecovery:
+		params, err := ParseRecoveryTransaction(tx.Payload)
+		if err != nil || params.Opcode != sys.ExecuteRecovery {
+			break
+		}
+
+		ids = append(ids, params.Account)
+
+		if recovery, pending := ReadAccountRecovery(snapshot, params.Account); pending {
+			ids = append(ids, recovery.NewKey)
+		}
+	}
+
+	return ids
+}
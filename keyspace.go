@@ -0,0 +1,114 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"bytes"
+
+	"github.com/perlin-network/wavelet/avl"
+)
+
+// KeySpace names a range of keys persisted by the ledger, either directly into
+// its store or into its accounts tree.
+type KeySpace struct {
+	Name   string
+	Prefix []byte
+}
+
+func accountsSpace(name string, key [1]byte) KeySpace {
+	return KeySpace{Name: "accounts." + name, Prefix: append(keyAccounts[:], key[:]...)}
+}
+
+// StoreKeySpaces lists the key spaces written directly into a ledgers store.
+var StoreKeySpaces = []KeySpace{
+	{Name: "rounds", Prefix: keyRounds[:]},
+	{Name: "rounds.latest_index", Prefix: keyRoundLatestIx[:]},
+	{Name: "rounds.oldest_index", Prefix: keyRoundOldestIx[:]},
+	{Name: "rounds.stored_count", Prefix: keyRoundStoredCount[:]},
+	{Name: "history.latest", Prefix: keyAccountHistoryLatest[:]},
+	{Name: "history", Prefix: keyAccountHistory[:]},
+	{Name: "journal.len", Prefix: keyAccountJournalLen[:]},
+	{Name: "journal", Prefix: keyAccountJournal[:]},
+	{Name: "forks", Prefix: keyForks[:]},
+	{Name: "forks.len", Prefix: keyForksLen[:]},
+	{Name: "halted", Prefix: keyHalted[:]},
+	{Name: "certificates", Prefix: keyCertificates[:]},
+	{Name: "audit", Prefix: keyAudit[:]},
+	{Name: "audit.len", Prefix: keyAuditLen[:]},
+	{Name: "graph", Prefix: keyGraphTransactions[:]},
+	{Name: "graph.archive", Prefix: keyArchivedTransactions[:]},
+	{Name: "contract_events", Prefix: keyContractEvents[:]},
+	{Name: "avl.nodes", Prefix: avl.NodeKeyPrefix},
+	{Name: "avl.gc_marks", Prefix: avl.GCAliveMarkPrefix},
+	{Name: "avl.old_roots", Prefix: avl.OldRootsPrefix},
+	{Name: "avl.root", Prefix: avl.RootKey},
+	{Name: "avl.next_old_root", Prefix: avl.NextOldRootIndexKey},
+}
+
+// TreeKeySpaces lists the key spaces written into a ledgers accounts tree.
+var TreeKeySpaces = []KeySpace{
+	{Name: "accounts", Prefix: keyAccounts[:]},
+	{Name: "accounts.len", Prefix: keyAccountsLen[:]},
+	accountsSpace("nonce", keyAccountNonce),
+	accountsSpace("balance", keyAccountBalance),
+	accountsSpace("stake", keyAccountStake),
+	accountsSpace("reward", keyAccountReward),
+	accountsSpace("contract_code", keyAccountContractCode),
+	accountsSpace("contract_num_pages", keyAccountContractNumPages),
+	accountsSpace("contract_pages", keyAccountContractPages),
+	accountsSpace("contract_state", keyAccountContractState),
+	accountsSpace("guardians", keyAccountGuardians),
+	accountsSpace("recovery", keyAccountRecovery),
+	accountsSpace("recovered_to", keyAccountRecoveredTo),
+	accountsSpace("freeze_authority", keyAccountFreezeAuthority),
+	accountsSpace("frozen", keyAccountFrozen),
+	accountsSpace("validator", keyAccountValidator),
+	accountsSpace("signature_scheme", keyAccountSignatureScheme),
+	{Name: "reward_withdrawals", Prefix: keyRewardWithdrawals[:]},
+	{Name: "protocol_version", Prefix: keyProtocolVersion[:]},
+	{Name: "proposals", Prefix: keyProposals[:]},
+	{Name: "params", Prefix: keyParams[:]},
+}
+
+// FindKeySpace returns the key space amongst spaces with the longest prefix
+// that key begins with.
+func FindKeySpace(spaces []KeySpace, key []byte) (KeySpace, bool) {
+	var found KeySpace
+	var ok bool
+
+	for _, space := range spaces {
+		if bytes.HasPrefix(key, space.Prefix) && (!ok || len(space.Prefix) > len(found.Prefix)) {
+			found, ok = space, true
+		}
+	}
+
+	return found, ok
+}
+
+// LookupKeySpace returns the key space amongst spaces with the given name.
+func LookupKeySpace(spaces []KeySpace, name string) (KeySpace, bool) {
+	for _, space := range spaces {
+		if space.Name == name {
+			return space, true
+		}
+	}
+
+	return KeySpace{}, false
+}
@@ -0,0 +1,71 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"testing"
+
+	"github.com/perlin-network/wavelet/avl"
+	"github.com/perlin-network/wavelet/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindKeySpace(t *testing.T) {
+	kv := store.NewInmem()
+	tree := avl.New(kv)
+
+	WriteAccountBalance(tree, AccountID{1}, 100)
+	WriteAccountsLen(tree, 1)
+
+	names := make(map[string]int)
+
+	tree.Iterate(func(key, value []byte) {
+		space, ok := FindKeySpace(TreeKeySpaces, key)
+		assert.True(t, ok)
+
+		names[space.Name]++
+	})
+
+	assert.Equal(t, map[string]int{"accounts.balance": 1, "accounts.len": 1}, names)
+
+	assert.NoError(t, tree.Commit())
+	assert.NoError(t, StoreRound(kv, Round{}, 0, 0, 1))
+
+	names = make(map[string]int)
+
+	assert.NoError(t, kv.IteratePrefix(nil, func(key, value []byte) bool {
+		space, ok := FindKeySpace(StoreKeySpaces, key)
+		assert.True(t, ok, "key %x belongs to no key space", key)
+
+		names[space.Name]++
+		return true
+	}))
+
+	assert.Equal(t, 1, names["rounds"])
+	assert.Equal(t, 1, names["avl.root"])
+	assert.NotZero(t, names["avl.nodes"])
+
+	space, ok := LookupKeySpace(TreeKeySpaces, "accounts.stake")
+	assert.True(t, ok)
+	assert.Equal(t, []byte{keyAccounts[0], keyAccountStake[0]}, space.Prefix)
+
+	_, ok = LookupKeySpace(TreeKeySpaces, "missing")
+	assert.False(t, ok)
+}
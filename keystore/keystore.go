@@ -0,0 +1,297 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+// Package keystore encrypts wallets under a passphrase into a portable file, so
+// that they may be moved between machines.
+package keystore
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+
+	"github.com/perlin-network/noise/edwards25519"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/ed25519"
+	"golang.org/x/crypto/nacl/secretbox"
+	"golang.org/x/crypto/scrypt"
+)
+
+// Version is the version of the keystore format written by this package.
+const Version = 1
+
+const (
+	sizeSalt  = 32
+	sizeNonce = 24
+	sizeKey   = 32
+)
+
+// Bounds on the scrypt parameters of an account, such that a crafted keystore
+// may not have us allocate or compute without bound when decrypting it.
+const (
+	MaxKDFN      = 1 << 20
+	MaxKDFR      = 32
+	MaxKDFP      = 16
+	MaxKDFMemory = 1 << 30 // scrypt allocates 128 * N * r bytes.
+)
+
+var (
+	ErrWrongPassphrase = errors.New("keystore: wrong passphrase, or the account has been tampered with")
+	ErrMismatchedKey   = errors.New("keystore: private key does not derive the public key it was exported with")
+)
+
+// KDF holds the parameters scrypt derives the key which encrypts an account
+// with.
+type KDF struct {
+	Salt string `json:"salt"`
+	N    int    `json:"n"`
+	R    int    `json:"r"`
+	P    int    `json:"p"`
+}
+
+// DefaultKDF are the scrypt parameters used when encrypting accounts.
+var DefaultKDF = KDF{N: 1 << 18, R: 8, P: 1}
+
+// Account is a single private key encrypted under a passphrase.
+type Account struct {
+	PublicKey  string `json:"public_key"`
+	KDF        KDF    `json:"kdf"`
+	Nonce      string `json:"nonce"`
+	Ciphertext string `json:"ciphertext"`
+}
+
+// Keystore is a portable collection of encrypted accounts.
+type Keystore struct {
+	Version  int       `json:"version"`
+	Accounts []Account `json:"accounts"`
+}
+
+// New returns an empty keystore.
+func New() *Keystore {
+	return &Keystore{Version: Version}
+}
+
+// Read decodes a keystore from r.
+func Read(r io.Reader) (*Keystore, error) {
+	var ks Keystore
+
+	if err := json.NewDecoder(r).Decode(&ks); err != nil {
+		return nil, errors.Wrap(err, "keystore: failed to decode")
+	}
+
+	if ks.Version != Version {
+		return nil, errors.Errorf("keystore: unsupported version %d", ks.Version)
+	}
+
+	return &ks, nil
+}
+
+// Write encodes the keystore into w.
+func (ks *Keystore) Write(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "\t")
+
+	return errors.Wrap(enc.Encode(ks), "keystore: failed to encode")
+}
+
+// Add encrypts privateKey under passphrase using kdf, and adds it to the
+// keystore, replacing any account with the same public key.
+func (ks *Keystore) Add(privateKey edwards25519.PrivateKey, passphrase []byte, kdf KDF) error {
+	account, err := Encrypt(privateKey, passphrase, kdf)
+	if err != nil {
+		return err
+	}
+
+	for i := range ks.Accounts {
+		if ks.Accounts[i].PublicKey == account.PublicKey {
+			ks.Accounts[i] = account
+			return nil
+		}
+	}
+
+	ks.Accounts = append(ks.Accounts, account)
+
+	return nil
+}
+
+// Find returns the account in the keystore with the given public key.
+func (ks *Keystore) Find(publicKey edwards25519.PublicKey) (Account, bool) {
+	encoded := hex.EncodeToString(publicKey[:])
+
+	for _, account := range ks.Accounts {
+		if account.PublicKey == encoded {
+			return account, true
+		}
+	}
+
+	return Account{}, false
+}
+
+// Encrypt encrypts privateKey under a key derived from passphrase using kdf,
+// whose salt is randomly generated.
+func Encrypt(privateKey edwards25519.PrivateKey, passphrase []byte, kdf KDF) (Account, error) {
+	if err := verifyKeyPair(privateKey); err != nil {
+		return Account{}, err
+	}
+
+	var salt [sizeSalt]byte
+	var nonce [sizeNonce]byte
+
+	if _, err := rand.Read(salt[:]); err != nil {
+		return Account{}, errors.Wrap(err, "keystore: failed to generate salt")
+	}
+
+	if _, err := rand.Read(nonce[:]); err != nil {
+		return Account{}, errors.Wrap(err, "keystore: failed to generate nonce")
+	}
+
+	kdf.Salt = hex.EncodeToString(salt[:])
+
+	key, err := kdf.derive(passphrase)
+	if err != nil {
+		return Account{}, err
+	}
+
+	defer zero(key[:])
+
+	publicKey := privateKey.Public()
+
+	return Account{
+		PublicKey:  hex.EncodeToString(publicKey[:]),
+		KDF:        kdf,
+		Nonce:      hex.EncodeToString(nonce[:]),
+		Ciphertext: hex.EncodeToString(secretbox.Seal(nil, privateKey[:], &nonce, &key)),
+	}, nil
+}
+
+// Decrypt decrypts the private key of the account using passphrase, and checks
+// that the key pair re-derived from its seed matches the public key the
+// account was exported with.
+func (a Account) Decrypt(passphrase []byte) (edwards25519.PrivateKey, error) {
+	var privateKey edwards25519.PrivateKey
+	var nonce [sizeNonce]byte
+
+	buf, err := hex.DecodeString(a.Nonce)
+	if err != nil || len(buf) != sizeNonce {
+		return privateKey, errors.New("keystore: malformed nonce")
+	}
+
+	copy(nonce[:], buf)
+
+	ciphertext, err := hex.DecodeString(a.Ciphertext)
+	if err != nil {
+		return privateKey, errors.New("keystore: malformed ciphertext")
+	}
+
+	key, err := a.KDF.derive(passphrase)
+	if err != nil {
+		return privateKey, err
+	}
+
+	plaintext, ok := secretbox.Open(nil, ciphertext, &nonce, &key)
+	zero(key[:])
+
+	if !ok {
+		return privateKey, ErrWrongPassphrase
+	}
+
+	defer zero(plaintext)
+
+	if len(plaintext) != edwards25519.SizePrivateKey {
+		return privateKey, errors.New("keystore: decrypted private key is not of the right length")
+	}
+
+	copy(privateKey[:], plaintext)
+
+	if err := verifyKeyPair(privateKey); err != nil {
+		return privateKey, err
+	}
+
+	if publicKey := privateKey.Public(); hex.EncodeToString(publicKey[:]) != a.PublicKey {
+		return privateKey, ErrMismatchedKey
+	}
+
+	return privateKey, nil
+}
+
+func (kdf KDF) derive(passphrase []byte) ([sizeKey]byte, error) {
+	var key [sizeKey]byte
+
+	salt, err := hex.DecodeString(kdf.Salt)
+	if err != nil || len(salt) != sizeSalt {
+		return key, errors.New("keystore: malformed salt")
+	}
+
+	if err := kdf.validate(); err != nil {
+		return key, err
+	}
+
+	buf, err := scrypt.Key(passphrase, salt, kdf.N, kdf.R, kdf.P, sizeKey)
+	if err != nil {
+		return key, errors.Wrap(err, "keystore: failed to derive key")
+	}
+
+	copy(key[:], buf)
+	zero(buf)
+
+	return key, nil
+}
+
+// validate checks that the scrypt parameters are well-formed and within bounds.
+func (kdf KDF) validate() error {
+	if kdf.N <= 1 || kdf.N&(kdf.N-1) != 0 || kdf.N > MaxKDFN {
+		return errors.Errorf("keystore: scrypt N must be a power of two between 2 and %d, but got %d", MaxKDFN, kdf.N)
+	}
+
+	if kdf.R < 1 || kdf.R > MaxKDFR {
+		return errors.Errorf("keystore: scrypt r must be between 1 and %d, but got %d", MaxKDFR, kdf.R)
+	}
+
+	if kdf.P < 1 || kdf.P > MaxKDFP {
+		return errors.Errorf("keystore: scrypt p must be between 1 and %d, but got %d", MaxKDFP, kdf.P)
+	}
+
+	if 128*kdf.N*kdf.R > MaxKDFMemory {
+		return errors.Errorf("keystore: scrypt parameters would use more than %d bytes of memory", MaxKDFMemory)
+	}
+
+	return nil
+}
+
+// zero overwrites buf with zeroes, such that key material does not linger in
+// memory once it is no longer needed.
+func zero(buf []byte) {
+	for i := range buf {
+		buf[i] = 0
+	}
+}
+
+// verifyKeyPair checks that the public key held in the latter half of
+// privateKey is the one derived from the seed held in its former half.
+func verifyKeyPair(privateKey edwards25519.PrivateKey) error {
+	derived := ed25519.NewKeyFromSeed(privateKey[:ed25519.SeedSize])
+
+	if !bytes.Equal(derived, privateKey[:]) {
+		return ErrMismatchedKey
+	}
+
+	return nil
+}
@@ -0,0 +1,99 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package keystore
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/perlin-network/noise/edwards25519"
+	"github.com/stretchr/testify/assert"
+)
+
+var testKDF = KDF{N: 1 << 10, R: 8, P: 1}
+
+func TestKeystore(t *testing.T) {
+	publicKey, privateKey, err := edwards25519.GenerateKey(nil)
+	assert.NoError(t, err)
+
+	ks := New()
+	assert.NoError(t, ks.Add(privateKey, []byte("passphrase"), testKDF))
+	assert.NoError(t, ks.Add(privateKey, []byte("passphrase"), testKDF))
+	assert.Len(t, ks.Accounts, 1)
+
+	var buf bytes.Buffer
+	assert.NoError(t, ks.Write(&buf))
+
+	imported, err := Read(&buf)
+	assert.NoError(t, err)
+
+	account, ok := imported.Find(publicKey)
+	assert.True(t, ok)
+
+	decrypted, err := account.Decrypt([]byte("passphrase"))
+	assert.NoError(t, err)
+	assert.Equal(t, privateKey, decrypted)
+
+	_, err = account.Decrypt([]byte("wrong"))
+	assert.Equal(t, ErrWrongPassphrase, err)
+
+	_, ok = imported.Find(edwards25519.PublicKey{})
+	assert.False(t, ok)
+
+	// An account whose public key was swapped out must not be imported.
+	other, _, err := edwards25519.GenerateKey(nil)
+	assert.NoError(t, err)
+
+	tampered := account
+	tampered.PublicKey = hex.EncodeToString(other[:])
+
+	_, err = tampered.Decrypt([]byte("passphrase"))
+	assert.Equal(t, ErrMismatchedKey, err)
+
+	// Private keys whose halves do not match may not be exported.
+	corrupted := privateKey
+	corrupted[edwards25519.SizePrivateKey-1] ^= 1
+
+	_, err = Encrypt(corrupted, []byte("passphrase"), testKDF)
+	assert.Equal(t, ErrMismatchedKey, err)
+
+	_, err = Read(bytes.NewReader([]byte(`{"version": 2}`)))
+	assert.Error(t, err)
+
+	// Scrypt parameters must be bounded, lest decrypting a crafted account
+	// exhaust our memory.
+	for _, kdf := range []KDF{
+		{N: 1 << 30, R: 8, P: 1},
+		{N: 1 << 20, R: 32, P: 1},
+		{N: 1000, R: 8, P: 1},
+		{N: 1 << 10, R: 0, P: 1},
+		{N: 1 << 10, R: 8, P: 1 << 20},
+	} {
+		kdf.Salt = account.KDF.Salt
+
+		crafted := account
+		crafted.KDF = kdf
+
+		_, err = crafted.Decrypt([]byte("passphrase"))
+		assert.Error(t, err)
+		assert.NotEqual(t, ErrWrongPassphrase, err)
+	}
+}
@@ -31,14 +31,18 @@ import (
 	"github.com/perlin-network/wavelet/log"
 	"github.com/perlin-network/wavelet/store"
 	"github.com/perlin-network/wavelet/sys"
+	"github.com/perlin-network/wavelet/wallet"
 	queue2 "github.com/phf/go-queue/queue"
 	"github.com/pkg/errors"
 	"golang.org/x/crypto/blake2b"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/peer"
 	"math/rand"
+	"runtime"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -46,6 +50,10 @@ type Ledger struct {
 	client  *skademlia.Client
 	metrics *Metrics
 	indexer *Indexer
+	logs    *log.Scope
+
+	name   string
+	config sys.Config
 
 	accounts *Accounts
 	rounds   *Rounds
@@ -57,30 +65,241 @@ type Ledger struct {
 
 	consensus sync.WaitGroup
 
-	broadcastNops      bool
-	broadcastNopsDelay time.Time
-	broadcastNopsLock  sync.Mutex
+	broadcastNops       bool
+	broadcastNopsDelay  time.Time
+	broadcastNopsCutoff time.Duration
+	broadcastNopsLock   sync.Mutex
 
 	sync      chan struct{}
 	syncTimer *time.Timer
+	syncNow   chan struct{}
 	syncVotes chan vote
+	syncing   int32
 
 	cacheCollapse *LRU
 	cacheChunks   *LRU
+	cacheVotes    *LRU
+
+	// Results of having applied some first number of the transactions of a round,
+	// keyed by collapsePrefixKey, such that collapsing towards competing critical
+	// transactions which share ancestry need not apply it all over again.
+	cacheCollapsePrefixes *LRU
+
+	// Number of goroutines the transactions of a round are applied by while
+	// collapsing. Transactions are applied sequentially should it be below 2.
+	collapseWorkers int
+
+	// IDs of the API requests which submitted transactions that are yet to be
+	// finalized, keyed by transaction ID.
+	requestIDs *LRU
+
+	// Statuses of the most recently seen transactions, keyed by transaction ID.
+	txStatuses *txStatuses
 
 	sendQuota chan struct{}
+
+	// Nonces most recently issued to transactions created by this node, keyed
+	// by creator, such that transactions pending to be finalized are never
+	// issued the same nonce.
+	nonces     map[AccountID]uint64
+	noncesLock sync.Mutex
+
+	alerts    *Alerter
+	peers     *Peers
+	history   *History
+	forks     *Forks
+	witnesses *forkWitnesses
+	audit     *AuditLog
+	events    *ContractEvents
+
+	certificates *Certificates
+	blsKey       *voterKey
+	voterKeys    *voterKeys
+
+	timeouts TimeoutConfig
+
+	mode      Mode
+	weighting Weighting
+
+	signer wallet.Signer
+
+	sampler PeerSampler
+
+	hooks hooks
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	parent context.Context
+
+	bootstrap      *StateSnapshot
+	bootstrapRound RoundID // ID of the round the state snapshot to bootstrap from is trusted to be of.
+	stateSnapshot  stateSnapshotCache
+
+	watchdog     WatchdogConfig
+	lastProgress int64 // Unix time in nanoseconds of when a round was last finalized, or a sync was last triggered.
+}
+
+// DefaultNopIdleCutoff is how long a ledger keeps broadcasting nops for after
+// it last broadcasted a transaction that is not a nop.
+const DefaultNopIdleCutoff = 30 * time.Second
+
+type LedgerOption func(*Ledger)
+
+// WithContext has the ledger stop all of its workers, and abort all requests
+// it has in flight to its peers, once ctx is cancelled.
+func WithContext(ctx context.Context) LedgerOption {
+	return func(ledger *Ledger) {
+		ledger.parent = ctx
+	}
+}
+
+// WithAlerts has the ledger check a set of liveness rules, firing alerts
+// whenever any of them are violated.
+func WithAlerts(config AlertConfig) LedgerOption {
+	return func(ledger *Ledger) {
+		ledger.alerts = NewAlerter(config, ledger.metrics)
+	}
+}
+
+// WithTimeouts has the ledger wait on its peers and back off before retrying
+// as described by config, rather than by DefaultTimeoutConfig().
+func WithTimeouts(config TimeoutConfig) LedgerOption {
+	return func(ledger *Ledger) {
+		ledger.timeouts = config.withDefaults()
+	}
+}
+
+// WithBroadcast has the ledger bound the queue of transactions pending to be
+// gossiped to its peers as described by config.
+func WithBroadcast(config BroadcastConfig) LedgerOption {
+	return func(ledger *Ledger) {
+		ledger.gossiper.queue.configure(config)
+	}
+}
+
+// WithOrphans has the ledger bound how many transactions with missing parents
+// it holds on to, and for how long, as described by config.
+func WithOrphans(config OrphanConfig) LedgerOption {
+	return func(ledger *Ledger) {
+		ledger.graph.orphans.configure(config)
+	}
+}
+
+// WithStateCache has the ledger bound how much of its accounts tree is kept in
+// memory, and how much of it is prefetched from storage, as described by config.
+func WithStateCache(config StateCacheConfig) LedgerOption {
+	return func(ledger *Ledger) {
+		ledger.accounts.configure(config)
+	}
+}
+
+// WithMaxGraphSize has the ledger turn away all transactions except critical
+// ones, and ones its graph is missing, once its graph holds size transactions.
+// The graph is unbounded should size be zero.
+func WithMaxGraphSize(size int) LedgerOption {
+	return func(ledger *Ledger) {
+		ledger.graph.maxSize = size
+	}
+}
+
+// WithNopIdleCutoff has the ledger stop broadcasting nops once it has not
+// broadcasted any transaction that is not a nop for longer than cutoff. Nops
+// are broadcasted for as long as transactions are pending finalization should
+// cutoff be zero.
+func WithNopIdleCutoff(cutoff time.Duration) LedgerOption {
+	return func(ledger *Ledger) {
+		ledger.broadcastNopsCutoff = cutoff
+	}
+}
+
+// WithMode has the ledger either take part in consensus as a validator, or
+// merely follow the rounds finalized by its peers.
+func WithMode(mode Mode) LedgerOption {
+	return func(ledger *Ledger) {
+		ledger.mode = mode
+	}
+}
+
+// WithSigner has the ledger sign the transactions it creates through signer,
+// rather than with the keys of its network identity, such that the wallet of
+// the node may be held by Vault.
+func WithSigner(signer wallet.Signer) LedgerOption {
+	return func(ledger *Ledger) {
+		ledger.signer = signer
+	}
+}
+
+// WithWeighting sets how the votes of validators are weighed in consensus.
+func WithWeighting(weighting Weighting) LedgerOption {
+	return func(ledger *Ledger) {
+		ledger.weighting = weighting
+	}
+}
+
+// WithParentSelector has the ledger pick the parents of the transactions it
+// creates using selector.
+func WithParentSelector(selector ParentSelector) LedgerOption {
+	return func(ledger *Ledger) {
+		ledger.graph.parents = selector
+	}
+}
+
+// WithPeerSampler has the ledger pick which of its peers to query every time
+// it ticks its Snowball instances using sampler.
+func WithPeerSampler(sampler PeerSampler) LedgerOption {
+	return func(ledger *Ledger) {
+		ledger.sampler = sampler
+	}
+}
+
+// WithName has the ledger tag every log it emits with name, such that several
+// ledgers hosted within the same process may be told apart.
+func WithName(name string) LedgerOption {
+	return func(ledger *Ledger) {
+		ledger.name = name
+		ledger.logs = log.NewScope(name)
+	}
+}
+
+// WithArchival has the ledger persist the balance and stake of accounts modified
+// by each finalized round, such that past account states may be queried.
+func WithArchival(kv store.KV) LedgerOption {
+	return func(ledger *Ledger) {
+		ledger.history = NewHistory(kv)
+	}
+}
+
+// WithConfig has the ledger follow the consensus parameters in config, rather
+// than those wavelet is compiled with. The parameters apply to this ledger only.
+func WithConfig(config sys.Config) LedgerOption {
+	return func(ledger *Ledger) {
+		ledger.config = config
+
+		WithMaxDepthDiff(config.MaxDepthDiff)(ledger.graph)
+
+		WithBeta(config.SnowballBeta)(ledger.finalizer)
+		WithBeta(config.SnowballBeta)(ledger.syncer)
+
+		ledger.syncVotes = make(chan vote, config.SnowballK)
+	}
 }
 
-func NewLedger(kv store.KV, client *skademlia.Client, genesis *string) *Ledger {
-	metrics := NewMetrics(context.TODO())
+func NewLedger(kv store.KV, client *skademlia.Client, genesis *string, opts ...LedgerOption) *Ledger {
+	logs := log.NewScope("")
+	config := sys.DefaultConfig()
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	metrics := newMetrics()
 	indexer := NewIndexer()
 
 	accounts := NewAccounts(kv)
-	go accounts.GC(context.Background())
+	go accounts.GC(ctx)
 
 	rounds, err := NewRounds(kv, sys.PruningLimit)
 
 	var round *Round
+	var incepted bool
 
 	if rounds != nil && err != nil {
 		genesis := performInception(accounts.tree, genesis)
@@ -95,6 +314,7 @@ func NewLedger(kv store.KV, client *skademlia.Client, genesis *string) *Ledger {
 		}
 
 		round = ptr
+		incepted = true
 	} else if rounds != nil {
 		round = rounds.Latest()
 	}
@@ -103,16 +323,20 @@ func NewLedger(kv store.KV, client *skademlia.Client, genesis *string) *Ledger {
 		panic("???: COULD NOT FIND GENESIS, OR STORAGE IS CORRUPTED.")
 	}
 
-	graph := NewGraph(WithMetrics(metrics), WithIndexer(indexer), WithRoot(round.End), VerifySignatures())
+	graph := NewGraph(WithMetrics(metrics), WithIndexer(indexer), WithLogs(logs), WithStore(kv), WithRoot(round.End), VerifySignatures())
 
-	gossiper := NewGossiper(context.TODO(), client, metrics)
-	finalizer := NewSnowball(WithBeta(sys.SnowballBeta))
-	syncer := NewSnowball(WithBeta(sys.SnowballBeta))
+	peers := NewPeers()
+
+	gossiper := NewGossiper(ctx, client, peers, metrics, logs)
+	finalizer := NewSnowball(WithBeta(config.SnowballBeta))
+	syncer := NewSnowball(WithBeta(config.SnowballBeta))
 
 	ledger := &Ledger{
 		client:  client,
 		metrics: metrics,
 		indexer: indexer,
+		logs:    logs,
+		config:  config,
 
 		accounts: accounts,
 		rounds:   rounds,
@@ -124,53 +348,283 @@ func NewLedger(kv store.KV, client *skademlia.Client, genesis *string) *Ledger {
 
 		sync:      make(chan struct{}),
 		syncTimer: time.NewTimer(0),
-		syncVotes: make(chan vote, sys.SnowballK),
+		syncNow:   make(chan struct{}, 1),
+		syncVotes: make(chan vote, config.SnowballK),
 
 		cacheCollapse: NewLRU(16),
 		cacheChunks:   NewLRU(1024), // In total, it will take up 1024 * 4MB.
+		cacheVotes:    NewLRU(64),
+
+		cacheCollapsePrefixes: NewLRU(256),
+
+		collapseWorkers: runtime.NumCPU(),
+
+		requestIDs: NewLRU(4096),
+		txStatuses: newTxStatuses(65536),
 
 		sendQuota: make(chan struct{}, 2000),
+
+		nonces: make(map[AccountID]uint64),
+
+		alerts:    NewAlerter(AlertConfig{}, metrics),
+		peers:     peers,
+		forks:     NewForks(kv),
+		witnesses: newForkWitnesses(),
+		audit:     NewAuditLog(kv),
+		events:    NewContractEvents(kv),
+
+		certificates: NewCertificates(kv),
+		voterKeys:    newVoterKeys(),
+
+		timeouts: DefaultTimeoutConfig(),
+
+		broadcastNopsCutoff: DefaultNopIdleCutoff,
+
+		mode:      ModeValidator,
+		weighting: WeightByStake,
+
+		sampler: UniformSampler{},
+
+		ctx:    ctx,
+		cancel: cancel,
+
+		lastProgress: time.Now().UnixNano(),
+	}
+
+	for _, opt := range opts {
+		opt(ledger)
+	}
+
+	if ledger.signer == nil {
+		ledger.signer = wallet.Key(client.Keys().PrivateKey())
+	}
+
+	// Only nodes that vote hold a BLS key to sign round certificates with.
+
+	if ledger.mode.Participates() {
+		ledger.blsKey = newVoterKey(client.Keys())
+	}
+
+	// Every component of the ledger logs through the scope of the ledger, which
+	// is only final once all options have been applied.
+
+	WithLogs(ledger.logs)(ledger.graph)
+
+	ledger.gossiper.logs = ledger.logs
+	ledger.finalizer.logs = ledger.logs
+	ledger.syncer.logs = ledger.logs
+	ledger.alerts.logs = ledger.logs
+
+	// Archive nodes keep every transaction they prune, and the state of accounts
+	// as of every finalized round.
+
+	if ledger.mode.Archives() {
+		ArchivePruned()(ledger.graph)
+
+		if ledger.history == nil {
+			ledger.history = NewHistory(kv)
+		}
+	}
+
+	if ledger.history != nil && incepted {
+		if err := ledger.history.RecordAll(round.Index, accounts.tree); err != nil {
+			panic(err)
+		}
+	}
+
+	if ledger.bootstrap != nil {
+		logger := ledger.logs.Sync("bootstrap")
+
+		if err := ledger.applyStateSnapshot(*ledger.bootstrap, ledger.bootstrapRound); err != nil {
+			logger.Warn().
+				Err(err).
+				Uint64("round", ledger.bootstrap.Round.Index).
+				Msg("Failed to bootstrap from state snapshot. Syncing from peers instead.")
+		} else {
+			logger.Info().
+				Uint64("round", ledger.bootstrap.Round.Index).
+				Hex("merkle_root", ledger.bootstrap.Round.Merkle[:]).
+				Msg("Bootstrapped from state snapshot.")
+		}
+
+		ledger.bootstrap = nil
+	}
+
+	if ledger.parent != nil {
+		go func() {
+			select {
+			case <-ledger.parent.Done():
+				ledger.Close()
+			case <-ctx.Done():
+			}
+		}()
 	}
 
+	go metrics.run(ctx, ledger.logs)
+	go ledger.alerts.Run(ctx, func() int { return len(peers.Closest(client)) })
+
+	ledger.PerformConsensus()
 	go ledger.SyncToLatestRound()
-	go ledger.PerformConsensus()
 	go ledger.PushSendQuota()
+	go ledger.LogQueues()
+	go ledger.ExpireOrphans()
+	go ledger.LogSnowball()
+	go ledger.Watchdog()
 
 	return ledger
 }
 
+// Close stops all workers of the ledger, and aborts all requests it has in
+// flight to its peers. It is safe to call Close more than once.
+func (l *Ledger) Close() {
+	l.cancel()
+}
+
+// Done returns a channel that is closed once the ledger has been closed.
+func (l *Ledger) Done() <-chan struct{} {
+	return l.ctx.Done()
+}
+
 // AddTransaction adds a transaction to the ledger. If the transaction has
 // never been added in the ledgers graph before, it is pushed to the gossip
 // mechanism to then be gossiped to this nodes peers. If the transaction is
 // invalid or fails any validation checks, an error is returned. No error
 // is returned if the transaction has already existed int he ledgers graph
-// beforehand.
+// beforehand. ErrQueueFull is returned, without the transaction being added,
+// should a transaction of our own not be able to be queued up to be gossiped.
+// ErrQueueFull is also returned should a transaction relayed from our peers
+// have been added to the graph, but not be able to be queued up to be gossiped;
+// peers may still pull it from us should they find it missing. ErrReadOnly is
+// returned should a node that is not a validator attempt to create a
+// transaction of its own. ErrGraphFull is returned should the graph be full,
+// and the transaction be neither critical nor missing from the graph.
 func (l *Ledger) AddTransaction(tx Transaction) error {
+	local := tx.Sender == l.signer.PublicKey()
+
+	if !l.mode.Participates() && local {
+		return ErrReadOnly
+	}
+
+	if err := l.admit(tx); err != nil {
+		return err
+	}
+
+	// Make sure there is room to gossip a transaction of our own before adding
+	// it, such that it is not left in our graph unbeknownst to our peers.
+
+	var reservation *BroadcastReservation
+
+	if local && l.mode.Participates() {
+		var err error
+
+		if reservation, err = l.gossiper.Reserve(tx, true); err != nil {
+			return err
+		}
+	}
+
 	err := l.graph.AddTransaction(tx)
 
+	if reservation != nil {
+		if err == nil {
+			reservation.Commit()
+		} else {
+			reservation.Release()
+		}
+	}
+
+	switch errors.Cause(err) {
+	case nil:
+		l.txStatuses.advance(tx.ID, TransactionState{Status: TxInGraph})
+	case ErrMissingParents:
+		l.txStatuses.advance(tx.ID, TransactionState{Status: TxReceived})
+	}
+
 	if err != nil && errors.Cause(err) != ErrAlreadyExists {
 		return err
 	}
 
+	if err == nil && !l.mode.Participates() {
+		return nil
+	}
+
 	if err == nil {
 		l.TakeSendQuota()
 
-		l.gossiper.Push(tx)
+		if reservation == nil {
+			err = l.gossiper.Push(tx, local)
+		}
+
+		if err == nil {
+			l.txStatuses.advance(tx.ID, TransactionState{Status: TxGossiped})
+		}
 
 		l.broadcastNopsLock.Lock()
 		if tx.Tag != sys.TagNop {
 			l.broadcastNopsDelay = time.Now()
 		}
 
-		if tx.Sender == l.client.Keys().PublicKey() && l.finalizer.Preferred() == nil {
+		if tx.Tag != sys.TagNop && local && l.finalizer.Preferred() == nil {
 			l.broadcastNops = true
 		}
 		l.broadcastNopsLock.Unlock()
+
+		return err
 	}
 
 	return nil
 }
 
+// admit decides whether tx may be added to the graph. Once the graph is full,
+// only critical transactions, which let the current round be finalized and the
+// graph be pruned, and transactions that the graph is missing are admitted.
+func (l *Ledger) admit(tx Transaction) error {
+	if !l.graph.Full() {
+		return nil
+	}
+
+	difficulty := l.Difficulty(l.rounds.Latest())
+
+	if tx.IsCritical(difficulty) || l.graph.IsMissing(tx.ID) {
+		return nil
+	}
+
+	l.metrics.graphRejected.Mark(1)
+
+	return ErrGraphFull
+}
+
+// TraceTransaction tags the logs emitted upon the transaction with ID id being
+// finalized with requestID under log.KeyRequestID, such that the API request
+// which submitted the transaction may be traced through to the transaction being
+// applied or rejected. Only the most recently traced transactions are kept track
+// of. It is safe to call this method concurrently.
+func (l *Ledger) TraceTransaction(id TransactionID, requestID string) {
+	if len(requestID) == 0 {
+		return
+	}
+
+	l.requestIDs.put(id, requestID)
+
+	logger := l.logs.TX("submitted")
+	logger.Log().
+		Hex("tx_id", id[:]).
+		Str(log.KeyRequestID, requestID).
+		Msg("")
+}
+
+// untrace stops tracing the transaction with ID id, returning the ID of the
+// request which submitted it, if any.
+func (l *Ledger) untrace(id TransactionID) requestID {
+	val, exists := l.requestIDs.load(id)
+	if !exists {
+		return ""
+	}
+
+	l.requestIDs.remove(id)
+
+	return requestID(val.(string))
+}
+
 // Find searches through complete transaction and account indices for a specified
 // query string. All indices that queried are in the form of tries. It is safe
 // to call this method concurrently.
@@ -211,7 +665,16 @@ func (l *Ledger) Find(query string, count int) []string {
 // PushSendQuota permits one token into this nodes send quota bucket every millisecond
 // such that the node may add one single transaction into its graph.
 func (l *Ledger) PushSendQuota() {
-	for range time.Tick(1 * time.Millisecond) {
+	ticker := time.NewTicker(1 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-l.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
 		select {
 		case l.sendQuota <- struct{}{}:
 		default:
@@ -219,6 +682,38 @@ func (l *Ledger) PushSendQuota() {
 	}
 }
 
+// LogQueues logs a snapshot of the depths of the queues of this node every
+// second, such that congestion may be observed and reacted upon.
+func (l *Ledger) LogQueues() {
+	ticker := time.NewTicker(1 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-l.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		queue := l.gossiper.Queue()
+		local, relayed := queue.Lanes()
+
+		pending := l.graph.RootDepth() + 1
+
+		logger := l.logs.Queues()
+		logger.Info().
+			Int("broadcast.depth", local+relayed).
+			Int("broadcast.capacity", queue.Capacity()).
+			Int("gossip.out.local", local).
+			Int("gossip.out.relayed", relayed).
+			Int("gossip.in.missing", l.graph.MissingLen()).
+			Int("gossip.in.orphans", l.graph.OrphanLen()).
+			Int("mempool.size", l.graph.DepthLen(&pending, nil)).
+			Int("graph.size", l.graph.Len()).
+			Msg("Updated queue depths.")
+	}
+}
+
 // TakeSendQuota removes one token from this nodes send quota bucket to signal
 // that the node has added one single transaction into its graph.
 func (l *Ledger) TakeSendQuota() bool {
@@ -230,6 +725,39 @@ func (l *Ledger) TakeSendQuota() bool {
 	}
 }
 
+// NextNonce issues the nonce the next transaction created on behalf of an
+// account should be signed under. Nonces follow the nonce of the account as of
+// the latest finalized round, and count transactions issued a nonce by this
+// node that have yet to be finalized. Issued nonces are never zero, as
+// transactions with a nonce of zero are unsequenced.
+func (l *Ledger) NextNonce(id AccountID) uint64 {
+	nonce, _ := ReadAccountNonce(l.Snapshot(), id)
+	if nonce == 0 {
+		nonce = 1
+	}
+
+	l.noncesLock.Lock()
+	defer l.noncesLock.Unlock()
+
+	if issued, exists := l.nonces[id]; exists && issued >= nonce {
+		nonce = issued + 1
+	}
+
+	l.nonces[id] = nonce
+
+	return nonce
+}
+
+// recordClock records how far ahead a peers clock is of ours, and checks whether
+// our own clock has drifted away from that of our peers.
+func (l *Ledger) recordClock(id *skademlia.ID, offset time.Duration) {
+	l.peers.RecordClock(id, offset)
+
+	if skew, sampled := l.peers.ClockSkew(); sampled >= l.config.SnowballK {
+		l.alerts.ClockSkewed(skew)
+	}
+}
+
 // Protocol returns an implementation of WaveletServer to handle incoming
 // RPC and streams for the ledger. The protocol is agnostic to whatever
 // choice of network stack is used with Wavelet, though by default it is
@@ -250,15 +778,161 @@ func (l *Ledger) Finalizer() *Snowball {
 	return l.finalizer
 }
 
+// Syncer returns the Snowball sampler which decides whether or not the ledger is
+// out of sync with its peers.
+func (l *Ledger) Syncer() *Snowball {
+	return l.syncer
+}
+
+// Syncing returns true if the ledger is currently downloading the latest state
+// from its peers, rather than participating in consensus.
+func (l *Ledger) Syncing() bool {
+	return atomic.LoadInt32(&l.syncing) == 1
+}
+
+// History returns the account history of the ledger, or nil should the ledger
+// not be an archival node.
+func (l *Ledger) History() *History {
+	return l.history
+}
+
+// Certificate returns the finalization certificate of a round.
+func (l *Ledger) Certificate(index uint64) (Certificate, error) {
+	return l.certificates.Get(index)
+}
+
 // Rounds returns the round manager for the ledger.
 func (l *Ledger) Rounds() *Rounds {
 	return l.rounds
 }
 
+// Peers returns what the ledger has witnessed about its peers.
+func (l *Ledger) Peers() *Peers {
+	return l.peers
+}
+
+// BroadcastQueue returns the queue of transactions pending to be gossiped to
+// our peers.
+func (l *Ledger) BroadcastQueue() *BroadcastQueue {
+	return l.gossiper.Queue()
+}
+
+// GossipLag reports how far behind gossip to each of our peers is.
+func (l *Ledger) GossipLag() []GossipLag {
+	return l.gossiper.Lag()
+}
+
+// ConnectPeer dials the peer located at addr, adding it to our routing table.
+func (l *Ledger) ConnectPeer(addr string) error {
+	if _, err := l.client.Dial(addr); err != nil {
+		return errors.Wrapf(err, "failed to connect to peer at %q", addr)
+	}
+
+	return nil
+}
+
+// DisconnectPeer closes our connection to the peer located at addr. The peer may
+// still be reconnected to afterwards, unless it is banned.
+func (l *Ledger) DisconnectPeer(addr string) error {
+	for _, conn := range l.client.AllPeers() {
+		if conn.Target() == addr {
+			return conn.Close()
+		}
+	}
+
+	return errors.Errorf("not connected to any peer at %q", addr)
+}
+
+// BanPeer has the ledger neither accept RPCs from nor send RPCs to the peer with
+// the given public key, closing any connection we have with it.
+func (l *Ledger) BanPeer(id AccountID) {
+	l.peers.Ban(id)
+
+	for _, peerID := range l.client.ClosestPeerIDs() {
+		if peerID.PublicKey() == id {
+			_ = l.DisconnectPeer(peerID.Address())
+		}
+	}
+
+	if info, exists := l.peers.Get(id); exists {
+		_ = l.DisconnectPeer(info.Address)
+	}
+}
+
+// UnbanPeer lifts the ban placed on a peer, returning false should it not have
+// been banned.
+func (l *Ledger) UnbanPeer(id AccountID) bool {
+	return l.peers.Unban(id)
+}
+
+// Config returns the consensus parameters the ledger follows.
+func (l *Ledger) Config() sys.Config {
+	return l.config
+}
+
+// Difficulty returns the difficulty a transaction must meet to be critical in
+// the round following round, as per the consensus parameters of the ledger.
+func (l *Ledger) Difficulty(round *Round) byte {
+	return round.ExpectedDifficulty(l.config.MinDifficulty, l.config.DifficultyScaleFactor)
+}
+
+// Param returns the value a governable parameter takes on as of the latest
+// finalized round.
+func (l *Ledger) Param(param byte) uint64 {
+	return l.param(l.accounts.Snapshot(), param, l.rounds.Latest().Index)
+}
+
+// minimumStake returns the minimum stake every voter is considered to have as of
+// the latest round.
+func (l *Ledger) minimumStake() uint64 {
+	return l.Param(sys.ParamMinimumStake)
+}
+
+// param returns the value a governable parameter takes on in the round with the
+// given index, falling back to the consensus parameters of the ledger should no
+// proposal to change it have passed.
+func (l *Ledger) param(snapshot *avl.Tree, param byte, round uint64) uint64 {
+	fallback, _ := l.config.ParamDefault(param)
+	return readParam(snapshot, param, round, fallback)
+}
+
+// Name returns the name the ledger tags its logs with, if any.
+func (l *Ledger) Name() string {
+	return l.name
+}
+
+// Logs returns the scope the ledger logs through.
+func (l *Ledger) Logs() *log.Scope {
+	return l.logs
+}
+
+// Weighting returns how the ledger weighs the votes of validators in consensus.
+func (l *Ledger) Weighting() Weighting {
+	return l.weighting
+}
+
+// Signer returns the signer the ledger signs the transactions it creates with.
+func (l *Ledger) Signer() wallet.Signer {
+	return l.signer
+}
+
+// Mode returns whether the ledger is a validator, or merely a follower.
+func (l *Ledger) Mode() Mode {
+	return l.mode
+}
+
+// Alerts returns the alerter checking liveness rules against the ledger.
+func (l *Ledger) Alerts() *Alerter {
+	return l.alerts
+}
+
 // PerformConsensus spawns workers related to performing consensus, such as pulling
 // missing transactions and incrementally finalizing intervals of transactions in
-// the ledgers graph.
+// the ledgers graph. Workers are counted before they are spawned, such that
+// waiting for them to shut down never races with them starting up.
 func (l *Ledger) PerformConsensus() {
+	l.consensus.Add(2)
+
 	go l.PullMissingTransactions()
 	go l.FinalizeRounds()
 }
@@ -267,32 +941,61 @@ func (l *Ledger) Snapshot() *avl.Tree {
 	return l.accounts.Snapshot()
 }
 
+// IterateAccounts calls fn with the ID of every account in the latest state
+// of the ledger in lexicographical order of account IDs, until fn returns false.
+// Account IDs are streamed out of a snapshot of the state without being loaded
+// into memory all at once, such that explorers may page through every account.
+func (l *Ledger) IterateAccounts(fn func(id AccountID) bool) {
+	IterateAccounts(l.accounts.Snapshot(), fn)
+}
+
 // BroadcastNop has the node send a nop transaction should they have sufficient
 // balance available. They are broadcasted if no other transaction that is not a nop transaction
-// is not broadcasted by the node after 500 milliseconds. These conditions only apply so long as
-// at least one transaction gets broadcasted by the node within the current round. Once a round
-// is tentatively being finalized, a node will stop broadcasting nops.
+// is not broadcasted by the node after 100 milliseconds. These conditions only apply so long as
+// at least one transaction that is not a nop gets broadcasted by the node within the current
+// round, and so long as transactions that are not nops are pending finalization in the graph.
+// Once a round is tentatively being finalized, or once no transaction that is not a nop has
+// been broadcasted for longer than the nop idle cutoff, a node will stop broadcasting nops.
 func (l *Ledger) BroadcastNop() *Transaction {
 	l.broadcastNopsLock.Lock()
 	broadcastNops := l.broadcastNops
 	broadcastNopsDelay := l.broadcastNopsDelay
+
+	if broadcastNops && l.broadcastNopsCutoff > 0 && time.Now().Sub(broadcastNopsDelay) > l.broadcastNopsCutoff {
+		l.broadcastNops = false
+		broadcastNops = false
+	}
 	l.broadcastNopsLock.Unlock()
 
 	if !broadcastNops || time.Now().Sub(broadcastNopsDelay) < 100*time.Millisecond {
 		return nil
 	}
 
-	keys := l.client.Keys()
-	publicKey := keys.PublicKey()
+	if !l.graph.HasPendingTransactions() {
+		return nil
+	}
+
+	publicKey := l.signer.PublicKey()
 
-	balance, _ := ReadAccountBalance(l.accounts.Snapshot(), publicKey)
+	balance, _ := l.accounts.ReadBalance(publicKey)
+	fee := l.Param(sys.ParamTransactionFeeAmount)
 
 	// FIXME(kenta): FOR TESTNET ONLY. FAUCET DOES NOT GET ANY PERLs DEDUCTED.
-	if balance < sys.TransactionFeeAmount && hex.EncodeToString(publicKey[:]) != sys.FaucetAddress {
+	if balance < fee && hex.EncodeToString(publicKey[:]) != sys.FaucetAddress {
 		return nil
 	}
 
-	nop := AttachSenderToTransaction(keys, NewTransaction(keys, sys.TagNop, nil), l.graph.FindEligibleParents()...)
+	nop, err := SignTransaction(l.signer, 0, sys.TagNop, nil)
+	if err == nil {
+		nop, err = AttachSignerToTransaction(l.signer, nop, l.graph.FindEligibleParents()...)
+	}
+
+	if err != nil {
+		logger := l.logs.Node()
+		logger.Warn().Err(err).Msg("Failed to sign a nop.")
+
+		return nil
+	}
 
 	if err := l.AddTransaction(nop); err != nil {
 		return nil
@@ -301,18 +1004,97 @@ func (l *Ledger) BroadcastNop() *Transaction {
 	return l.graph.FindTransaction(nop.ID)
 }
 
+// LogSnowball checks the progress of the Snowball instances used to finalize
+// and to sync rounds every 250 milliseconds, and logs it whenever it changed.
+func (l *Ledger) LogSnowball() {
+	samplers := []struct {
+		name     string
+		snowball *Snowball
+		last     SnowballProgress
+	}{
+		{name: "finalizer", snowball: l.finalizer},
+		{name: "syncer", snowball: l.syncer},
+	}
+
+	ticker := time.NewTicker(250 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-l.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		for i := range samplers {
+			progress := samplers[i].snowball.Snapshot()
+
+			if !progress.differs(samplers[i].last) {
+				continue
+			}
+
+			samplers[i].last = progress
+
+			logger := l.logs.Consensus("snowball")
+			event := logger.Info().
+				Str("sampler", samplers[i].name).
+				Hex("last_id", progress.Last[:]).
+				Int("count", progress.Count).
+				Int("beta", progress.Beta).
+				Bool("decided", progress.Decided).
+				Int("num_candidates", len(progress.Candidates))
+
+			if progress.Preferred != nil {
+				event = event.
+					Hex("preferred_id", progress.Preferred.ID[:]).
+					Uint64("preferred_round", progress.Preferred.Index)
+			}
+
+			event.Msg("Snowball sampler progressed.")
+		}
+	}
+}
+
+// ExpireOrphans drops every second the transactions that have been waiting on
+// their missing parents to arrive for too long, such that peers that never
+// deliver their parents may not fill up the graph.
+func (l *Ledger) ExpireOrphans() {
+	ticker := time.NewTicker(1 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-l.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if count := l.graph.ExpireOrphans(); count > 0 {
+			logger := l.logs.Node()
+			logger.Debug().
+				Int("num_expired", count).
+				Msg("Expired transactions whose parents never arrived.")
+		}
+	}
+}
+
 // PullMissingTransactions is an infinite loop continually sending RPC requests
 // to pull any transactions identified to be missing by the ledger. It periodically
 // samples a random peer from the network, and requests the peer for the contents
 // of all missing transactions by their respective IDs. When the ledger is in amidst
 // synchronizing/teleporting ahead to a new round, the infinite loop will be cleaned
-// up. It is intended to call PullMissingTransactions() in a new goroutine.
+// up. It is intended to be spawned by PerformConsensus.
 func (l *Ledger) PullMissingTransactions() {
-	l.consensus.Add(1)
 	defer l.consensus.Done()
 
+	attempts := 0
+
 	for {
 		missing := l.graph.Missing()
+		stats := l.graph.MissingStats()
+
+		l.metrics.missingCount.Update(int64(stats.Count))
+		l.metrics.missingAge.Update(int64(stats.OldestAge / time.Millisecond))
 
 		if len(missing) == 0 {
 			select {
@@ -324,13 +1106,13 @@ func (l *Ledger) PullMissingTransactions() {
 			continue
 		}
 
-		peers := l.client.ClosestPeers()
+		peers := l.peers.Closest(l.client)
 
 		if len(peers) == 0 {
 			select {
 			case <-l.sync:
 				return
-			case <-time.After(1 * time.Second):
+			case <-time.After(l.timeouts.Backoff(1)):
 			}
 
 			continue
@@ -340,52 +1122,140 @@ func (l *Ledger) PullMissingTransactions() {
 			peers[i], peers[j] = peers[j], peers[i]
 		})
 
-		fmt.Println("Trying to download missing transactions. count =", len(missing))
-		rand.Shuffle(len(missing), func(i, j int) {
-			missing[i], missing[j] = missing[j], missing[i]
-		})
-		if len(missing) > 256 {
-			missing = missing[:256]
+		var err error
+
+		// Should missing transactions pile up, or should any of them go missing for
+		// too long, fetch the longest missing ones from several peers at once rather
+		// than leave the graph stalled on a single peer.
+
+		if stats.Count >= sys.MissingRepairCount || stats.OldestAge >= sys.MissingRepairAge {
+			err = l.repairMissingTransactions(peers, stats)
+		} else {
+			fmt.Println("Trying to download missing transactions. count =", len(missing))
+			rand.Shuffle(len(missing), func(i, j int) {
+				missing[i], missing[j] = missing[j], missing[i]
+			})
+			if len(missing) > missingBatchSize {
+				missing = missing[:missingBatchSize]
+			}
+
+			err = l.downloadMissingTransactions(peers[0], missing)
 		}
 
-		req := &DownloadTxRequest{Ids: make([][]byte, len(missing))}
+		if err != nil {
+			fmt.Println("failed to download missing transactions:", err)
+
+			attempts++
 
-		for i, id := range missing {
-			req.Ids[i] = id[:]
+			select {
+			case <-l.sync:
+				return
+			case <-time.After(l.timeouts.Backoff(attempts)):
+			}
+
+			continue
 		}
 
-		conn := peers[0]
-		client := NewWaveletClient(conn)
+		attempts = 0
+	}
+}
+
+// missingBatchSize is the maximum number of missing transactions requested
+// from a single peer at once.
+const missingBatchSize = 256
+
+// downloadMissingTransactions requests the contents of the missing transactions
+// under ids from the peer behind conn, and adds them to the graph.
+func (l *Ledger) downloadMissingTransactions(conn *grpc.ClientConn, ids []TransactionID) error {
+	req := &DownloadTxRequest{Ids: make([][]byte, len(ids))}
+
+	for i, id := range ids {
+		req.Ids[i] = id[:]
+	}
+
+	client := NewWaveletClient(conn)
+
+	ctx, cancel := context.WithTimeout(l.ctx, l.timeouts.Download)
+	batch, err := client.DownloadTx(ctx, req)
+	cancel()
+
+	if err != nil {
+		return err
+	}
+
+	count := int64(0)
+
+	for _, buf := range batch.Transactions {
+		tx, err := UnmarshalTransaction(bytes.NewReader(buf))
 
-		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-		batch, err := client.DownloadTx(ctx, req)
 		if err != nil {
-			fmt.Println("failed to download missing transactions:", err)
-			cancel()
 			continue
 		}
-		cancel()
 
-		count := int64(0)
+		if err := l.AddTransaction(tx); err != nil && errors.Cause(err) != ErrMissingParents && errors.Cause(err) != ErrQueueFull {
+			fmt.Printf("error adding downloaded tx to graph [%v]: %+v\n", err, tx)
+			continue
+		}
 
-		for _, buf := range batch.Transactions {
-			tx, err := UnmarshalTransaction(bytes.NewReader(buf))
+		count += int64(tx.LogicalUnits())
+	}
 
-			if err != nil {
-				continue
-			}
+	l.metrics.downloadedTX.Mark(count)
+	l.metrics.receivedTX.Mark(count)
 
-			if err := l.AddTransaction(tx); err != nil && errors.Cause(err) != ErrMissingParents {
-				fmt.Printf("error adding downloaded tx to graph [%v]: %+v\n", err, tx)
-				continue
-			}
+	return nil
+}
 
-			count += int64(tx.LogicalUnits())
-		}
+// repairMissingTransactions splits the transactions that have been missing
+// for the longest across up to sys.MissingRepairFanout of peers, and downloads
+// them from all of those peers at once. It only fails should every peer fail.
+func (l *Ledger) repairMissingTransactions(peers []*grpc.ClientConn, stats MissingStats) error {
+	fanout := sys.MissingRepairFanout
+
+	if fanout > len(peers) {
+		fanout = len(peers)
+	}
+
+	missing := l.graph.MissingOldest(fanout * missingBatchSize)
 
-		l.metrics.downloadedTX.Mark(count)
-		l.metrics.receivedTX.Mark(count)
+	if len(missing) < fanout {
+		fanout = len(missing)
 	}
+
+	l.metrics.missingRepairs.Mark(1)
+
+	logger := l.logs.Sync("repair")
+	logger.Info().
+		Int("num_missing", stats.Count).
+		Dur("oldest_age", stats.OldestAge).
+		Int("num_requested", len(missing)).
+		Int("num_peers", fanout).
+		Msg("Missing transactions piled up. Fetching them from several peers at once.")
+
+	var wg sync.WaitGroup
+
+	errs := make([]error, fanout)
+
+	for i := 0; i < fanout; i++ {
+		batch := missing[i*len(missing)/fanout : (i+1)*len(missing)/fanout]
+
+		wg.Add(1)
+
+		go func(i int, conn *grpc.ClientConn) {
+			defer wg.Done()
+			errs[i] = l.downloadMissingTransactions(conn, batch)
+		}(i, peers[i])
+	}
+
+	wg.Wait()
+
+	for _, err := range errs {
+		if err == nil {
+			return nil
+		}
+	}
+
+	return errors.Wrap(errs[0], "failed to repair missing transactions from any peer")
 }
 
 // FinalizeRounds periodically attempts to find an eligible critical transaction suited for the
@@ -395,9 +1265,10 @@ func (l *Ledger) PullMissingTransactions() {
 // applied to the current ledger state, and the graph is updated to cleanup artifacts from
 // the old round.
 func (l *Ledger) FinalizeRounds() {
-	l.consensus.Add(1)
 	defer l.consensus.Done()
 
+	started := time.Now()
+
 FINALIZE_ROUNDS:
 	for {
 		select {
@@ -406,7 +1277,7 @@ FINALIZE_ROUNDS:
 		default:
 		}
 
-		if len(l.client.ClosestPeers()) < sys.SnowballK {
+		if l.Halted() {
 			select {
 			case <-l.sync:
 				return
@@ -416,8 +1287,28 @@ FINALIZE_ROUNDS:
 			continue FINALIZE_ROUNDS
 		}
 
+		if len(l.peers.Closest(l.client)) < l.config.SnowballK {
+			select {
+			case <-l.sync:
+				return
+			case <-time.After(l.timeouts.Backoff(1)):
+			}
+
+			continue FINALIZE_ROUNDS
+		}
+
 		current := l.rounds.Latest()
-		currentDifficulty := current.ExpectedDifficulty(sys.MinDifficulty, sys.DifficultyScaleFactor)
+		currentDifficulty := l.Difficulty(current)
+
+		// Only have Snowball fall back on a deterministic preference should the
+		// round being finalized be collapsed under a protocol version permitting
+		// it, such that all nodes start falling back as of the same round.
+
+		if l.config.ProtocolVersion(current.Index+1) >= sys.ProtocolSnowballFallback {
+			l.finalizer.SetLivenessLimit(SnowballDefaultLivenessLimit)
+		} else {
+			l.finalizer.SetLivenessLimit(0)
+		}
 
 		if preferred := l.finalizer.Preferred(); preferred == nil {
 			eligible := l.graph.FindEligibleCritical(currentDifficulty)
@@ -467,22 +1358,28 @@ FINALIZE_ROUNDS:
 		var workerWG sync.WaitGroup
 		workerWG.Add(cap(workerChan))
 
-		voteChan := make(chan vote, sys.SnowballK)
-		go CollectVotes(l.accounts, l.finalizer, voteChan, &workerWG)
+		voteChan := make(chan vote, l.config.SnowballK)
+		go CollectVotes(l.accounts, l.weighting, l.minimumStake, l.finalizer, l.config.SnowballK, l.config.SnowballAlpha, voteChan, &workerWG, l.metrics)
 
 		req := &QueryRequest{RoundIndex: current.Index + 1}
 
+		var signaturesLock sync.Mutex
+		signatures := make(map[RoundID]map[AccountID]CertificateVote)
+
 		for i := 0; i < cap(workerChan); i++ {
 			go func() {
 				for conn := range workerChan {
 					f := func() {
 						client := NewWaveletClient(conn)
 
-						ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+						ctx, cancel := context.WithTimeout(l.ctx, l.timeouts.Query)
 
 						p := &peer.Peer{}
+						header := metadata.MD{}
+
+						start := time.Now()
 
-						res, err := client.Query(ctx, req, grpc.Peer(p))
+						res, err := client.Query(ctx, req, grpc.Peer(p), grpc.Header(&header))
 						if err != nil {
 							cancel()
 							return
@@ -490,6 +1387,8 @@ FINALIZE_ROUNDS:
 
 						cancel()
 
+						latency := time.Since(start)
+
 						l.metrics.queried.Mark(1)
 
 						info := noise.InfoFromPeer(p)
@@ -502,14 +1401,20 @@ FINALIZE_ROUNDS:
 							return
 						}
 
+						l.peers.RecordLatency(voter, latency)
+
+						if offset, ok := ClockOffsetFromHeader(header, start, start.Add(latency)); ok {
+							l.recordClock(voter, offset)
+						}
+
 						round, err := UnmarshalRound(bytes.NewReader(res.Round))
 						if err != nil {
-							voteChan <- vote{voter: voter, preferred: nil}
+							voteChan <- vote{voter: voter, preferred: nil, viewID: req.RoundIndex}
 							return
 						}
 
 						if round.ID == ZeroRoundID || round.Start.ID == ZeroTransactionID || round.End.ID == ZeroTransactionID {
-							voteChan <- vote{voter: voter, preferred: nil}
+							voteChan <- vote{voter: voter, preferred: nil, viewID: req.RoundIndex}
 							return
 						}
 
@@ -517,10 +1422,15 @@ FINALIZE_ROUNDS:
 							return
 						}
 
+						if round.Index <= current.Index {
+							l.witnessRound(voter.PublicKey(), &round)
+							return
+						}
+
 						if round.Index != current.Index+1 {
 							if round.Index > sys.SyncIfRoundsDifferBy+current.Index {
 								select {
-								case l.syncVotes <- vote{voter: voter, preferred: &round}:
+								case l.syncVotes <- vote{voter: voter, preferred: &round, viewID: req.RoundIndex}:
 								default:
 								}
 							}
@@ -532,11 +1442,11 @@ FINALIZE_ROUNDS:
 							return
 						}
 
-						if err := l.AddTransaction(round.Start); err != nil {
+						if err := l.AddTransaction(round.Start); err != nil && errors.Cause(err) != ErrQueueFull {
 							return
 						}
 
-						if err := l.AddTransaction(round.End); err != nil {
+						if err := l.AddTransaction(round.End); err != nil && errors.Cause(err) != ErrQueueFull {
 							return
 						}
 
@@ -562,7 +1472,16 @@ FINALIZE_ROUNDS:
 							return
 						}
 
-						voteChan <- vote{voter: voter, preferred: &round}
+						if signed, ok := voteFromHeader(header, voter.PublicKey(), l.voterKeys); ok {
+							signaturesLock.Lock()
+							if signatures[round.ID] == nil {
+								signatures[round.ID] = make(map[AccountID]CertificateVote)
+							}
+							signatures[round.ID][voter.PublicKey()] = signed
+							signaturesLock.Unlock()
+						}
+
+						voteChan <- vote{voter: voter, preferred: &round, viewID: req.RoundIndex}
 					}
 
 					l.metrics.queryLatency.Time(f)
@@ -585,9 +1504,9 @@ FINALIZE_ROUNDS:
 			default:
 			}
 
-			// Randomly sample a peer to query. If no peers are available, stop querying.
+			// Sample peers to query. If no peers are available, stop querying.
 
-			peers, err := SelectPeers(l.client.ClosestPeers(), sys.SnowballK)
+			peers, err := l.samplePeers()
 			if err != nil {
 				close(workerChan)
 				workerWG.Wait()
@@ -612,6 +1531,10 @@ FINALIZE_ROUNDS:
 		finalized := l.finalizer.Preferred()
 		l.finalizer.Reset()
 
+		if finalized == nil { // The watchdog may have reset Snowball in the meantime.
+			continue
+		}
+
 		results, err := l.CollapseTransactions(finalized.Index, finalized.Start, finalized.End, true)
 		if err != nil {
 			if !strings.Contains(err.Error(), "missing ancestor") {
@@ -630,6 +1553,20 @@ FINALIZE_ROUNDS:
 			continue
 		}
 
+		signaturesLock.Lock()
+		votes := signatures[finalized.ID]
+		if votes == nil {
+			votes = make(map[AccountID]CertificateVote)
+		}
+		if l.blsKey != nil {
+			votes[l.client.Keys().PublicKey()] = l.blsKey.vote(l.client.Keys().PublicKey(), finalized.ID)
+		}
+		signaturesLock.Unlock()
+
+		if err := l.certificates.Save(newCertificate(finalized, l.accounts, l.weighting, l.Param(sys.ParamMinimumStake), votes)); err != nil {
+			fmt.Printf("Failed to save certificate of finalized round: %v\n", err)
+		}
+
 		pruned, err := l.rounds.Save(finalized)
 		if err != nil {
 			fmt.Printf("Failed to save finalized round to our database: %v\n", err)
@@ -638,7 +1575,7 @@ FINALIZE_ROUNDS:
 		if pruned != nil {
 			count := l.graph.PruneBelowDepth(pruned.End.Depth)
 
-			logger := log.Consensus("prune")
+			logger := l.logs.Consensus("prune")
 			logger.Debug().
 				Int("num_tx", count).
 				Uint64("current_round_id", finalized.Index).
@@ -652,19 +1589,46 @@ FINALIZE_ROUNDS:
 			fmt.Printf("Failed to commit collaped state to our database: %v\n", err)
 		}
 
+		if l.history != nil {
+			if err = l.history.Record(finalized.Index, results.snapshot, current.Index); err != nil {
+				fmt.Printf("Failed to record account history: %v\n", err)
+			}
+
+			if err = l.history.RecordJournal(results.journal); err != nil {
+				fmt.Printf("Failed to record balance-change journal: %v\n", err)
+			}
+		}
+
+		if err = l.events.Record(results.events); err != nil {
+			fmt.Printf("Failed to record contract events: %v\n", err)
+		}
+
 		l.metrics.acceptedTX.Mark(int64(results.appliedCount))
+		l.metrics.finalityLatency.UpdateSince(started)
+		l.metrics.peers.Update(int64(len(l.client.ClosestPeerIDs())))
+		started = time.Now()
+		l.alerts.RoundFinalized()
+		l.progressed()
 
 		l.LogChanges(results.snapshot, current.Index)
+		l.logContractEvents(results.events)
+
+		l.runHooks(FinalizedRound{
+			Round:     finalized,
+			StateRoot: finalized.Merkle,
+			Applied:   results.applied,
+			Rejected:  results.rejected,
+		})
 
-		logger := log.Consensus("round_end")
+		logger := l.logs.Consensus("round_end")
 		logger.Info().
 			Int("num_applied_tx", results.appliedCount).
 			Int("num_rejected_tx", results.rejectedCount).
 			Int("num_ignored_tx", results.ignoredCount).
 			Uint64("old_round", current.Index).
 			Uint64("new_round", finalized.Index).
-			Uint8("old_difficulty", current.ExpectedDifficulty(sys.MinDifficulty, sys.DifficultyScaleFactor)).
-			Uint8("new_difficulty", finalized.ExpectedDifficulty(sys.MinDifficulty, sys.DifficultyScaleFactor)).
+			Uint8("old_difficulty", l.Difficulty(current)).
+			Uint8("new_difficulty", l.Difficulty(finalized)).
 			Hex("new_root", finalized.End.ID[:]).
 			Hex("old_root", current.End.ID[:]).
 			Hex("new_merkle_root", finalized.Merkle[:]).
@@ -672,26 +1636,93 @@ FINALIZE_ROUNDS:
 			Uint64("round_depth", finalized.End.Depth-finalized.Start.Depth).
 			Msg("Finalized consensus round, and initialized a new round.")
 
+		logger = l.logs.Rounds("finalized")
+		logger.Info().
+			Uint64("old_round", current.Index).
+			Uint64("new_round", finalized.Index).
+			Hex("old_root", current.End.ID[:]).
+			Hex("new_root", finalized.End.ID[:]).
+			Hex("merkle_root", finalized.Merkle[:]).
+			Uint64("round_depth", finalized.End.Depth-finalized.Start.Depth).
+			Int("num_applied_tx", results.appliedCount).
+			Int("num_rejected_tx", results.rejectedCount).
+			Msg("Finalized consensus round.")
+
+		l.logSampled(finalized.Index)
+
 		//go ExportGraphDOT(finalized, l.graph)
 	}
 }
 
+// logSampled summarizes how many events of each type were left unlogged due to
+// being sampled since the last round was finalized.
+func (l *Ledger) logSampled(round uint64) {
+	dropped := l.logs.TakeDropped()
+	if len(dropped) == 0 {
+		return
+	}
+
+	fields := make(map[string]interface{}, len(dropped))
+
+	for key, n := range dropped {
+		fields[key] = n
+	}
+
+	logger := l.logs.Consensus("sampled")
+	logger.Info().
+		Uint64("round", round).
+		Fields(fields).
+		Msg("Dropped sampled logs during the last round.")
+}
+
+// samplePeers picks SnowballK of our peers to query using the peer sampler
+// of the ledger.
+func (l *Ledger) samplePeers() ([]*grpc.ClientConn, error) {
+	return l.sampler.Sample(l.peers.Candidates(l.client, l.accounts.Snapshot()), l.config.SnowballK)
+}
+
 func (l *Ledger) SyncToLatestRound() {
 	voteWG := new(sync.WaitGroup)
 
-	go CollectVotes(l.accounts, l.syncer, l.syncVotes, voteWG)
+	go CollectVotes(l.accounts, l.weighting, l.minimumStake, l.syncer, l.config.SnowballK, l.config.SnowballAlpha, l.syncVotes, voteWG, l.metrics)
+
+	// Once the ledger is closed, stop all consensus-related workers and the
+	// vote processor worker.
+
+	stop := func() {
+		close(l.sync)
+		l.consensus.Wait()
+
+		voteWG.Add(1)
+		close(l.syncVotes)
+		voteWG.Wait()
+	}
 
 	for {
 		for {
-			conns, err := SelectPeers(l.client.ClosestPeers(), sys.SnowballK)
-			if err != nil {
+			if l.Halted() {
 				select {
+				case <-l.ctx.Done():
+					stop()
+					return
 				case <-time.After(1 * time.Second):
 				}
 
 				continue
 			}
 
+			conns, err := l.samplePeers()
+			if err != nil {
+				select {
+				case <-l.ctx.Done():
+					stop()
+					return
+				case <-time.After(l.timeouts.Backoff(1)):
+				}
+
+				continue
+			}
+
 			current := l.rounds.Latest()
 
 			var wg sync.WaitGroup
@@ -701,11 +1732,14 @@ func (l *Ledger) SyncToLatestRound() {
 				client := NewWaveletClient(conn)
 
 				go func() {
-					ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+					ctx, cancel := context.WithTimeout(l.ctx, l.timeouts.OutOfSync)
 
 					p := &peer.Peer{}
+					header := metadata.MD{}
+
+					start := time.Now()
 
-					res, err := client.CheckOutOfSync(ctx, &OutOfSyncRequest{}, grpc.Peer(p))
+					res, err := client.CheckOutOfSync(ctx, &OutOfSyncRequest{}, grpc.Peer(p), grpc.Header(&header))
 					if err != nil {
 						cancel()
 						wg.Done()
@@ -714,6 +1748,8 @@ func (l *Ledger) SyncToLatestRound() {
 
 					cancel()
 
+					received := time.Now()
+
 					info := noise.InfoFromPeer(p)
 					if info == nil {
 						wg.Done()
@@ -726,6 +1762,10 @@ func (l *Ledger) SyncToLatestRound() {
 						return
 					}
 
+					if offset, ok := ClockOffsetFromHeader(header, start, received); ok {
+						l.recordClock(voter, offset)
+					}
+
 					round, err := UnmarshalRound(bytes.NewReader(res.Round))
 					if err != nil {
 						wg.Done()
@@ -742,12 +1782,16 @@ func (l *Ledger) SyncToLatestRound() {
 						return
 					}
 
+					if round.Index <= current.Index {
+						l.witnessRound(voter.PublicKey(), &round)
+					}
+
 					if round.Index < sys.SyncIfRoundsDifferBy+current.Index {
 						wg.Done()
 						return
 					}
 
-					l.syncVotes <- vote{voter: voter, preferred: &round}
+					l.syncVotes <- vote{voter: voter, preferred: &round, viewID: current.Index + 1}
 
 					wg.Done()
 				}()
@@ -762,7 +1806,11 @@ func (l *Ledger) SyncToLatestRound() {
 			l.syncTimer.Reset((1500 / (1 + 2*time.Duration(l.syncer.Progress()))) * time.Millisecond)
 
 			select {
+			case <-l.ctx.Done():
+				stop()
+				return
 			case <-l.syncTimer.C:
+			case <-l.syncNow:
 			}
 		}
 
@@ -771,12 +1819,14 @@ func (l *Ledger) SyncToLatestRound() {
 		current := l.rounds.Latest()
 		proposed := l.syncer.Preferred()
 
-		if proposed.Index < sys.SyncIfRoundsDifferBy+current.Index {
+		if proposed == nil || proposed.Index < sys.SyncIfRoundsDifferBy+current.Index {
 			l.syncer.Reset()
 			continue
 		}
 
 		shutdown := func() {
+			atomic.StoreInt32(&l.syncing, 1)
+
 			close(l.sync)
 			l.consensus.Wait() // Wait for all consensus-related workers to shutdown.
 
@@ -789,30 +1839,41 @@ func (l *Ledger) SyncToLatestRound() {
 		}
 
 		restart := func() { // Respawn all previously stopped workers.
-			l.syncVotes = make(chan vote, sys.SnowballK)
-			go CollectVotes(l.accounts, l.syncer, l.syncVotes, voteWG)
+			l.syncVotes = make(chan vote, l.config.SnowballK)
+			go CollectVotes(l.accounts, l.weighting, l.minimumStake, l.syncer, l.config.SnowballK, l.config.SnowballAlpha, l.syncVotes, voteWG, l.metrics)
 
 			l.sync = make(chan struct{})
-			go l.PerformConsensus()
+			l.PerformConsensus()
+
+			atomic.StoreInt32(&l.syncing, 0)
 		}
 
 		shutdown() // Shutdown all consensus-related workers.
 
-		logger := log.Sync("syncing")
+		l.progressed()
+
+		logger := l.logs.Sync("syncing")
 		logger.Info().
 			Uint64("current_round", current.Index).
 			Uint64("proposed_round", proposed.Index).
 			Msg("Noticed that we are out of sync; downloading latest state Snapshot from our peer(s).")
 
-	SYNC:
+		attempts := 0
 
-		conns, err := SelectPeers(l.client.ClosestPeers(), sys.SnowballK)
-		if err != nil {
-			logger.Warn().Msg("It looks like there are no peers for us to sync with. Retrying...")
+	SYNC:
+		if attempts > 0 {
+			l.alerts.SyncFailed()
 
 			select {
-			case <-time.After(1 * time.Second):
+			case <-time.After(l.timeouts.Backoff(attempts)):
 			}
+		}
+
+		attempts++
+
+		conns, err := SelectPeers(l.peers.Closest(l.client), l.config.SnowballK)
+		if err != nil {
+			logger.Warn().Msg("It looks like there are no peers for us to sync with. Retrying...")
 
 			goto SYNC
 		}
@@ -828,7 +1889,7 @@ func (l *Ledger) SyncToLatestRound() {
 		responses := make([]response, 0, len(conns))
 
 		for _, conn := range conns {
-			stream, err := NewWaveletClient(conn).Sync(context.Background())
+			stream, err := NewWaveletClient(conn).Sync(l.ctx)
 			if err != nil {
 				continue
 			}
@@ -1107,7 +2168,7 @@ func (l *Ledger) SyncToLatestRound() {
 		if pruned != nil {
 			count := l.graph.PruneBelowDepth(pruned.End.Depth)
 
-			logger := log.Consensus("prune")
+			logger := l.logs.Consensus("prune")
 			logger.Debug().
 				Int("num_tx", count).
 				Uint64("current_round_id", latest.Index).
@@ -1121,19 +2182,31 @@ func (l *Ledger) SyncToLatestRound() {
 			panic(errors.Wrap(err, "failed to commit collapsed state to our database"))
 		}
 
-		logger = log.Sync("apply")
+		if l.history != nil {
+			if err := l.history.Record(latest.Index, snapshot, current.Index); err != nil {
+				fmt.Printf("Failed to record account history: %v\n", err)
+			}
+		}
+
+		logger = l.logs.Sync("apply")
 		logger.Info().
 			Int("num_chunks", len(chunks)).
 			Uint64("old_round", current.Index).
 			Uint64("new_round", latest.Index).
-			Uint8("old_difficulty", current.ExpectedDifficulty(sys.MinDifficulty, sys.DifficultyScaleFactor)).
-			Uint8("new_difficulty", latest.ExpectedDifficulty(sys.MinDifficulty, sys.DifficultyScaleFactor)).
+			Uint8("old_difficulty", l.Difficulty(current)).
+			Uint8("new_difficulty", l.Difficulty(latest)).
 			Hex("new_root", latest.End.ID[:]).
 			Hex("old_root", current.End.ID[:]).
 			Hex("new_merkle_root", latest.Merkle[:]).
 			Hex("old_merkle_root", current.Merkle[:]).
 			Msg("Successfully built a new state Snapshot out of chunk(s) we have received from peers.")
 
+		l.alerts.SyncSucceeded()
+		l.alerts.RoundFinalized()
+		l.progressed()
+
+		l.runHooks(FinalizedRound{Round: latest, StateRoot: latest.Merkle, Synced: true})
+
 		restart()
 	}
 }
@@ -1141,33 +2214,66 @@ func (l *Ledger) SyncToLatestRound() {
 // ApplyTransactionToSnapshot applies a transactions intended changes to a snapshot
 // of the ledgers current state.
 func (l *Ledger) ApplyTransactionToSnapshot(snapshot *avl.Tree, tx *Transaction) error {
+	return l.applyTransactionToSnapshot(snapshot, tx, nil)
+}
+
+// applyTransactionToSnapshot applies tx to snapshot as part of the round ctx
+// describes, recording events emitted by smart contracts invoked by tx into ctx.
+func (l *Ledger) applyTransactionToSnapshot(snapshot *avl.Tree, tx *Transaction, ctx *applyContext) error {
 	round := l.Rounds().Latest()
 	original := snapshot.Snapshot()
 
+	version := ReadProtocolVersion(snapshot)
+
+	if !sys.TagActive(tx.Tag, version) {
+		return errors.Errorf("transactions tagged %s may not be applied under protocol version %d", tx.Tag, version)
+	}
+
+	if err := applySignatureScheme(snapshot, version, tx); err != nil {
+		snapshot.Revert(original)
+		return err
+	}
+
+	if tx.Tag != sys.TagNop {
+		if recoveredTo, recovered := ReadAccountRecoveredTo(snapshot, tx.Creator); recovered {
+			return errors.Errorf("account %x has been recovered to %x and may no longer create transactions", tx.Creator, recoveredTo)
+		}
+	}
+
 	switch tx.Tag {
 	case sys.TagNop:
 	case sys.TagTransfer:
-		if _, err := ApplyTransferTransaction(snapshot, round, tx, nil); err != nil {
+		if _, err := applyTransferTransaction(snapshot, round, tx, nil, ctx); err != nil {
 			snapshot.Revert(original)
 
 			fmt.Println(err)
 			return errors.Wrap(err, "could not apply transfer transaction")
 		}
 	case sys.TagStake:
-		if _, err := ApplyStakeTransaction(snapshot, round, tx); err != nil {
+		if _, err := applyStakeTransaction(snapshot, round, tx, ctx); err != nil {
 			snapshot.Revert(original)
 			return errors.Wrap(err, "could not apply stake transaction")
 		}
 	case sys.TagContract:
-		if _, err := ApplyContractTransaction(snapshot, round, tx, nil); err != nil {
+		if _, err := applyContractTransaction(snapshot, round, tx, nil, ctx); err != nil {
 			snapshot.Revert(original)
 			return errors.Wrap(err, "could not apply contract transaction")
 		}
 	case sys.TagBatch:
-		if _, err := ApplyBatchTransaction(snapshot, round, tx); err != nil {
+		if _, err := applyBatchTransaction(snapshot, round, tx, ctx); err != nil {
 			snapshot.Revert(original)
 			return errors.Wrap(err, "could not apply batch transaction")
 		}
+	case sys.TagRecovery:
+		if _, err := applyRecoveryTransaction(snapshot, round, tx, ctx); err != nil {
+			snapshot.Revert(original)
+			return errors.Wrap(err, "could not apply recovery transaction")
+		}
+	case sys.TagGovernance:
+		if _, err := applyGovernanceTransaction(snapshot, round, tx, ctx); err != nil {
+			snapshot.Revert(original)
+			return errors.Wrap(err, "could not apply governance transaction")
+		}
 	}
 
 	return nil
@@ -1185,6 +2291,9 @@ type CollapseResults struct {
 	rejectedCount int
 	ignoredCount  int
 
+	journal []JournalEntry
+	events  []ContractEvent
+
 	snapshot *avl.Tree
 }
 
@@ -1208,16 +2317,22 @@ func (l *Ledger) CollapseTransactions(round uint64, root Transaction, end Transa
 	defer func() {
 		if res != nil && logging {
 			for _, tx := range res.applied {
-				logEventTX("applied", tx)
+				logEventTX(l.logs, "applied", tx, l.untrace(tx.ID))
+				l.txStatuses.advance(tx.ID, TransactionState{Status: TxApplied, Round: round})
 			}
 
 			for i, tx := range res.rejected {
-				logEventTX("rejected", tx, res.rejectedErrors[i])
+				logEventTX(l.logs, "rejected", tx, res.rejectedErrors[i], l.untrace(tx.ID))
+				l.txStatuses.advance(tx.ID, TransactionState{Status: TxRejected, Round: round, Err: res.rejectedErrors[i]})
 			}
+
+			logEventStake(l.logs, round, res)
 		}
 	}()
 
-	if results, exists := l.cacheCollapse.load(end.ID); exists {
+	key := collapseKey(round, root, end)
+
+	if results, exists := l.cacheCollapse.load(key); exists {
 		res = results.(*CollapseResults)
 		return res, nil
 	}
@@ -1225,6 +2340,22 @@ func (l *Ledger) CollapseTransactions(round uint64, root Transaction, end Transa
 	res = &CollapseResults{snapshot: l.accounts.Snapshot()}
 	res.snapshot.SetViewID(round)
 
+	// Record the protocol version into the state at the round an upgrade activates,
+	// such that nodes which disagree on when an upgrade activates fork at that round.
+
+	if upgrade, activates := l.config.Activates(round); activates {
+		WriteProtocolVersion(res.snapshot, upgrade.Version)
+
+		if logging {
+			logger := l.logs.Consensus("upgrade")
+			logger.Info().
+				Uint64("round", round).
+				Uint32("version", upgrade.Version).
+				Str("upgrade", upgrade.Name).
+				Msg("Activated protocol upgrade.")
+		}
+	}
+
 	visited := map[TransactionID]struct{}{root.ID: {}}
 
 	queue := queue2.New()
@@ -1266,29 +2397,89 @@ func (l *Ledger) CollapseTransactions(round uint64, root Transaction, end Transa
 	// Apply transactions in reverse order from the end of the round
 	// all the way down to the beginning of the round.
 
+	pending := make([]*Transaction, 0, order.Len())
+
 	for order.Len() > 0 {
-		popped := order.PopBack().(*Transaction)
+		pending = append(pending, order.PopBack().(*Transaction))
+	}
 
-		// Update nonce.
+	// Resume from the results of having applied the longest prefix of pending
+	// which was applied before while collapsing towards some other critical
+	// transaction.
+
+	prefixes := collapsePrefixKeys(round, root, pending)
+
+	start := 0
+
+	for i := len(pending); i > 0; i-- {
+		if checkpoint, exists := l.cacheCollapsePrefixes.load(prefixes[i]); exists {
+			res, start = checkpoint.(*CollapseResults).resume(round), i
+			break
+		}
+	}
+
+	pending = pending[start:]
+
+	// Finding validators to reward only depends on the graph up until their
+	// stakes are checked, so search for them ahead of time concurrently.
+
+	ancestors := l.findRewardAncestors(pending)
+
+	// Apply all transactions speculatively and concurrently ahead of time. The
+	// writes of a transaction are then replayed in order below should nothing
+	// it read have been changed by the transactions before it, and it is applied
+	// again otherwise, such that the state collapsed into is always the same as
+	// should all transactions have been applied in order.
+
+	speculated := l.speculateTransactions(res.snapshot, round, &end, pending, ancestors)
+
+	// Results are checkpointed for only as long as nothing applied depends on
+	// the critical transaction the round is being collapsed towards.
 
-		nonce, exists := ReadAccountNonce(res.snapshot, popped.Creator)
-		if !exists {
-			WriteAccountsLen(res.snapshot, ReadAccountsLen(res.snapshot)+1)
+	independent := true
+
+	for i, popped := range pending {
+		if independent && i > 0 && (start+i)%collapseCheckpointInterval == 0 {
+			l.cacheCollapsePrefixes.put(prefixes[start+i], res.checkpoint())
 		}
-		WriteAccountNonce(res.snapshot, popped.Creator, nonce+1)
+
+		// Update nonce.
+
+		incrementNonce(res.snapshot, popped.Creator)
 
 		// FIXME(kenta): FOR TESTNET ONLY. FAUCET DOES NOT GET ANY PERLs DEDUCTED.
 		if hex.EncodeToString(popped.Creator[:]) != sys.FaucetAddress {
-			if err := l.RewardValidators(res.snapshot, root, popped, logging); err != nil {
+			watch := watchBalances(res.snapshot, popped.Creator)
+
+			if err := l.rewardValidators(res.snapshot, round, popped, ancestors[i], logging); err != nil {
 				res.rejected = append(res.rejected, popped)
 				res.rejectedErrors = append(res.rejectedErrors, err)
 				res.rejectedCount += popped.LogicalUnits()
 
 				continue
 			}
+
+			res.journal = append(res.journal, watch.diff(res.snapshot, round, popped.ID, JournalFee)...)
+		}
+
+		watch := watchBalances(res.snapshot, balanceParticipants(res.snapshot, popped)...)
+
+		ctx := &applyContext{critical: &end, logs: l.logs, config: &l.config}
+
+		var err error
+
+		if speculated != nil && speculated[i] != nil && res.snapshot.Replay(speculated[i].snapshot) {
+			ctx, err = speculated[i].ctx, speculated[i].err
+			speculated[i].flush()
+		} else {
+			err = l.applyTransactionToSnapshot(res.snapshot, popped, ctx)
+		}
+
+		if ctx.dependsOnCritical {
+			independent = false
 		}
 
-		if err := l.ApplyTransactionToSnapshot(res.snapshot, popped); err != nil {
+		if err != nil {
 			res.rejected = append(res.rejected, popped)
 			res.rejectedErrors = append(res.rejectedErrors, err)
 			res.rejectedCount += popped.LogicalUnits()
@@ -1298,12 +2489,19 @@ func (l *Ledger) CollapseTransactions(round uint64, root Transaction, end Transa
 			continue
 		}
 
+		res.journal = append(res.journal, watch.diff(res.snapshot, round, popped.ID, journalReasonForTag(popped.Tag))...)
+		res.events = append(res.events, ctx.events...)
+
 		// Update statistics.
 
 		res.applied = append(res.applied, popped)
 		res.appliedCount += popped.LogicalUnits()
 	}
 
+	if independent && len(pending) > 0 {
+		l.cacheCollapsePrefixes.put(prefixes[len(prefixes)-1], res.checkpoint())
+	}
+
 	startDepth, endDepth := root.Depth+1, end.Depth
 
 	for _, tx := range l.graph.GetTransactionsByDepth(&startDepth, &endDepth) {
@@ -1313,21 +2511,95 @@ func (l *Ledger) CollapseTransactions(round uint64, root Transaction, end Transa
 	res.ignoredCount -= res.appliedCount + res.rejectedCount
 
 	if round >= uint64(sys.RewardWithdrawalsRoundLimit) {
-		l.processRewardWithdrawals(round, res.snapshot)
+		res.journal = append(res.journal, l.processRewardWithdrawals(round, res.snapshot)...)
 	}
 
-	l.cacheCollapse.put(end.ID, res)
+	l.cacheCollapse.put(key, res)
 
 	return res, nil
 }
 
+// collapseKey returns the key the results of collapsing transactions from root
+// to end for a round are cached under. The results depend on the root, as the
+// state transactions are applied on top of is the state as of when root was
+// finalized.
+func collapseKey(round uint64, root Transaction, end Transaction) [blake2b.Size256]byte {
+	var buf [8 + SizeTransactionID*2]byte
+
+	binary.BigEndian.PutUint64(buf[:8], round)
+	copy(buf[8:8+SizeTransactionID], root.ID[:])
+	copy(buf[8+SizeTransactionID:], end.ID[:])
+
+	return blake2b.Sum256(buf[:])
+}
+
+// collapseCheckpointInterval is the number of transactions of a round applied in
+// between caching the results of having applied them while collapsing.
+const collapseCheckpointInterval = 64
+
+// collapsePrefixKeys returns the keys the results of having applied the first i
+// of txs on top of root for a round are cached under, for every i. Keys chain
+// over the IDs of the transactions applied, such that results are shared by all
+// critical transactions whose ancestry is applied in the same order up until
+// some point.
+func collapsePrefixKeys(round uint64, root Transaction, txs []*Transaction) [][blake2b.Size256]byte {
+	keys := make([][blake2b.Size256]byte, len(txs)+1)
+
+	var buf [8 + SizeTransactionID]byte
+
+	binary.BigEndian.PutUint64(buf[:8], round)
+	copy(buf[8:], root.ID[:])
+
+	keys[0] = blake2b.Sum256(buf[:])
+
+	var link [blake2b.Size256 + SizeTransactionID]byte
+
+	for i, tx := range txs {
+		copy(link[:blake2b.Size256], keys[i][:])
+		copy(link[blake2b.Size256:], tx.ID[:])
+
+		keys[i+1] = blake2b.Sum256(link[:])
+	}
+
+	return keys
+}
+
+// checkpoint returns a copy of the results of having applied some transactions
+// of a round, which is left unchanged by further transactions being applied.
+func (r *CollapseResults) checkpoint() *CollapseResults {
+	return &CollapseResults{
+		applied:        r.applied[:len(r.applied):len(r.applied)],
+		rejected:       r.rejected[:len(r.rejected):len(r.rejected)],
+		rejectedErrors: r.rejectedErrors[:len(r.rejectedErrors):len(r.rejectedErrors)],
+
+		appliedCount:  r.appliedCount,
+		rejectedCount: r.rejectedCount,
+
+		journal: r.journal[:len(r.journal):len(r.journal)],
+		events:  r.events[:len(r.events):len(r.events)],
+
+		snapshot: r.snapshot.Snapshot(),
+	}
+}
+
+// resume returns a copy of a checkpoint which further transactions of a round
+// may be applied to.
+func (r *CollapseResults) resume(round uint64) *CollapseResults {
+	res := *r
+
+	res.snapshot = r.snapshot.Snapshot()
+	res.snapshot.SetViewID(round)
+
+	return &res
+}
+
 // LogChanges logs all changes made to an AVL tree state snapshot for the purposes
 // of logging out changes to account state to Wavelet's HTTP API.
 func (l *Ledger) LogChanges(snapshot *avl.Tree, lastRound uint64) {
-	balanceLogger := log.Accounts("balance_updated")
-	stakeLogger := log.Accounts("stake_updated")
-	rewardLogger := log.Accounts("reward_updated")
-	numPagesLogger := log.Accounts("num_pages_updated")
+	balanceLogger := l.logs.Accounts("balance_updated")
+	stakeLogger := l.logs.Accounts("stake_updated")
+	rewardLogger := l.logs.Accounts("reward_updated")
+	numPagesLogger := l.logs.Accounts("num_pages_updated")
 
 	balanceKey := append(keyAccounts[:], keyAccountBalance[:]...)
 	stakeKey := append(keyAccounts[:], keyAccountStake[:]...)
@@ -1372,31 +2644,34 @@ func (l *Ledger) LogChanges(snapshot *avl.Tree, lastRound uint64) {
 	})
 }
 
-func (l *Ledger) processRewardWithdrawals(round uint64, snapshot *avl.Tree) {
+func (l *Ledger) processRewardWithdrawals(round uint64, snapshot *avl.Tree) []JournalEntry {
 	rws := GetRewardWithdrawalRequests(snapshot, round-uint64(sys.RewardWithdrawalsRoundLimit))
 
+	journal := make([]JournalEntry, 0, len(rws))
+
 	for _, rw := range rws {
 		balance, _ := ReadAccountBalance(snapshot, rw.account)
 		WriteAccountBalance(snapshot, rw.account, balance+rw.amount)
 
 		snapshot.Delete(rw.Key())
-	}
-}
-
-func (l *Ledger) RewardValidators(snapshot *avl.Tree, root Transaction, tx *Transaction, logging bool) error {
-	fee := sys.TransactionFeeAmount
-
-	creatorBalance, _ := ReadAccountBalance(snapshot, tx.Creator)
 
-	if creatorBalance < fee {
-		return errors.Errorf("stake: creator %x does not have enough PERLs to pay transaction fees (comprised of %d PERLs)", tx.Creator, fee)
+		journal = append(journal, JournalEntry{Round: round, Account: rw.account, Amount: rw.amount, Credit: true, Reason: JournalReward})
 	}
 
-	WriteAccountBalance(snapshot, tx.Creator, creatorBalance-fee)
+	return journal
+}
 
-	var candidates []*Transaction
-	var stakes []uint64
-	var totalStake uint64
+func (l *Ledger) RewardValidators(snapshot *avl.Tree, round uint64, root Transaction, tx *Transaction, logging bool) error {
+	return l.rewardValidators(snapshot, round, tx, nil, logging)
+}
+
+// rewardAncestors returns the ancestors of tx that were not sent by the sender
+// of tx, and that are within MaxDepthDiff graph depths of tx, in the order
+// they are considered as candidates to reward for tx. The ancestors of tx only
+// depend on the graph, such that they may be found for several transactions
+// at once before any of them are applied.
+func (l *Ledger) rewardAncestors(tx *Transaction) []*Transaction {
+	ancestors := make([]*Transaction, 0)
 
 	visited := make(map[TransactionID]struct{})
 
@@ -1411,8 +2686,6 @@ func (l *Ledger) RewardValidators(snapshot *avl.Tree, root Transaction, tx *Tran
 		visited[parentID] = struct{}{}
 	}
 
-	hasher, _ := blake2b.New256(nil)
-
 	var depthCounter uint64
 	var lastDepth = tx.Depth
 
@@ -1427,7 +2700,7 @@ func (l *Ledger) RewardValidators(snapshot *avl.Tree, root Transaction, tx *Tran
 		// If we exceed the max eligible depth we search for candidate
 		// validators to reward from, stop traversing.
 
-		if depthCounter >= sys.MaxDepthDiff {
+		if depthCounter >= l.config.MaxDepthDiff {
 			break
 		}
 
@@ -1435,19 +2708,7 @@ func (l *Ledger) RewardValidators(snapshot *avl.Tree, root Transaction, tx *Tran
 		// and within the desired graph depth.
 
 		if popped.Sender != tx.Sender {
-			stake, _ := ReadAccountStake(snapshot, popped.Sender)
-
-			if stake > sys.MinimumStake {
-				candidates = append(candidates, popped)
-				stakes = append(stakes, stake)
-
-				totalStake += stake
-
-				// Record entropy source.
-				if _, err := hasher.Write(popped.ID[:]); err != nil {
-					return errors.Wrap(err, "stake: failed to hash transaction ID for entropy source")
-				}
-			}
+			ancestors = append(ancestors, popped)
 		}
 
 		for _, parentID := range popped.ParentIDs {
@@ -1461,6 +2722,175 @@ func (l *Ledger) RewardValidators(snapshot *avl.Tree, root Transaction, tx *Tran
 		}
 	}
 
+	return ancestors
+}
+
+// findRewardAncestors finds the ancestors to reward for each of txs using as
+// many goroutines as transactions are applied by while collapsing.
+func (l *Ledger) findRewardAncestors(txs []*Transaction) [][]*Transaction {
+	ancestors := make([][]*Transaction, len(txs))
+
+	parallelize(len(txs), l.collapseWorkers, func(i int) {
+		ancestors[i] = l.rewardAncestors(txs[i])
+	})
+
+	return ancestors
+}
+
+// minSpeculativeCollapse is the fewest transactions a round must comprise of
+// for them to be applied speculatively and concurrently while collapsing.
+const minSpeculativeCollapse = 8
+
+// speculativeApply is the result of applying a transaction speculatively to
+// the state a round starts from.
+type speculativeApply struct {
+	snapshot *avl.Tree
+	ctx      *applyContext
+	err      error
+
+	flush func() // Writes out the logs held back while applying the transaction.
+}
+
+// speculateTransactions applies each of txs concurrently to its own speculative
+// snapshot of the state the round starts from. Once the round is collapsed in
+// order, transactions which did not read anything written by the transactions
+// before them then need not be applied again, as the writes they made may be
+// replayed instead. Results are left nil for transactions which may not be
+// speculated on.
+func (l *Ledger) speculateTransactions(snapshot *avl.Tree, round uint64, end *Transaction, txs []*Transaction, ancestors [][]*Transaction) []*speculativeApply {
+	if l.collapseWorkers < 2 || len(txs) < minSpeculativeCollapse {
+		return nil
+	}
+
+	results := make([]*speculativeApply, len(txs))
+
+	parallelize(len(txs), l.collapseWorkers, func(i int) {
+		results[i] = l.speculateTransaction(snapshot, round, end, txs[i], ancestors[i])
+	})
+
+	return results
+}
+
+func (l *Ledger) speculateTransaction(base *avl.Tree, round uint64, end *Transaction, tx *Transaction, ancestors []*Transaction) (result *speculativeApply) {
+	// Applying a transaction to state it was not meant to be applied to may
+	// panic. It is then simply applied again in order.
+
+	defer func() {
+		if r := recover(); r != nil {
+			result = nil
+		}
+	}()
+
+	snapshot := base.Concurrent()
+
+	// Charge the transaction its nonce and fee as it would be charged right
+	// before being applied, should nothing before it in the round change them.
+
+	incrementNonce(snapshot, tx.Creator)
+
+	if hex.EncodeToString(tx.Creator[:]) != sys.FaucetAddress {
+		if err := l.rewardValidators(snapshot, round, tx, ancestors, false); err != nil {
+			return nil
+		}
+	}
+
+	logs, flush := l.logs.Deferred()
+	ctx := &applyContext{critical: end, logs: logs, config: &l.config}
+
+	speculative := snapshot.Speculate()
+	err := l.applyTransactionToSnapshot(speculative, tx, ctx)
+
+	return &speculativeApply{snapshot: speculative, ctx: ctx, err: err, flush: flush}
+}
+
+// incrementNonce increments the nonce of an account, counting the account
+// towards the number of accounts should it not have had a nonce yet.
+func incrementNonce(snapshot *avl.Tree, id AccountID) {
+	nonce, exists := ReadAccountNonce(snapshot, id)
+	if !exists {
+		WriteAccountsLen(snapshot, ReadAccountsLen(snapshot)+1)
+	}
+	WriteAccountNonce(snapshot, id, nonce+1)
+}
+
+// parallelize calls fn for every index below n using at most workers goroutines.
+func parallelize(n int, workers int, fn func(i int)) {
+	if workers > n {
+		workers = n
+	}
+
+	if workers < 1 {
+		workers = 1
+	}
+
+	var next int64 = -1
+
+	var wg sync.WaitGroup
+	wg.Add(workers)
+
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+
+			for {
+				idx := atomic.AddInt64(&next, 1)
+				if idx >= int64(n) {
+					return
+				}
+
+				fn(int(idx))
+			}
+		}()
+	}
+
+	wg.Wait()
+}
+
+// rewardValidators has the creator of tx pay a transaction fee to a validator
+// picked out of ancestors, weighted by stake. The ancestors of tx are found
+// should ancestors be nil.
+func (l *Ledger) rewardValidators(snapshot *avl.Tree, round uint64, tx *Transaction, ancestors []*Transaction, logging bool) error {
+	fee := l.param(snapshot, sys.ParamTransactionFeeAmount, round)
+	minimumStake := l.param(snapshot, sys.ParamMinimumStake, round)
+
+	if err := checkNotFrozen(snapshot, tx.Creator); err != nil {
+		return errors.Wrap(err, "stake: creator may not pay transaction fees")
+	}
+
+	creatorBalance, _ := ReadAccountBalance(snapshot, tx.Creator)
+
+	if creatorBalance < fee {
+		return errors.Errorf("stake: creator %x does not have enough PERLs to pay transaction fees (comprised of %d PERLs)", tx.Creator, fee)
+	}
+
+	WriteAccountBalance(snapshot, tx.Creator, creatorBalance-fee)
+
+	if ancestors == nil {
+		ancestors = l.rewardAncestors(tx)
+	}
+
+	var candidates []*Transaction
+	var stakes []uint64
+	var totalStake uint64
+
+	hasher, _ := blake2b.New256(nil)
+
+	for _, ancestor := range ancestors {
+		stake, _ := ReadAccountStake(snapshot, ancestor.Sender)
+
+		if stake > minimumStake {
+			candidates = append(candidates, ancestor)
+			stakes = append(stakes, stake)
+
+			totalStake += stake
+
+			// Record entropy source.
+			if _, err := hasher.Write(ancestor.ID[:]); err != nil {
+				return errors.Wrap(err, "stake: failed to hash transaction ID for entropy source")
+			}
+		}
+	}
+
 	// If there are no eligible rewardee candidates, do not reward anyone.
 
 	if len(candidates) == 0 || len(stakes) == 0 || totalStake == 0 {
@@ -1495,8 +2925,11 @@ func (l *Ledger) RewardValidators(snapshot *avl.Tree, root Transaction, tx *Tran
 	WriteAccountReward(snapshot, rewardee.Sender, rewardeeBalance+fee)
 
 	if logging {
-		logger := log.Stake("reward_validator")
+		logger := l.logs.Stake("reward_validator")
 		logger.Info().
+			Hex("account_id", rewardee.Sender[:]).
+			Uint64("amount", fee).
+			Uint64("round", round).
 			Hex("creator", tx.Creator[:]).
 			Hex("recipient", rewardee.Sender[:]).
 			Hex("creator_tx_id", tx.ID[:]).
@@ -0,0 +1,345 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"context"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/perlin-network/noise/skademlia"
+	"github.com/perlin-network/wavelet/avl"
+	"github.com/perlin-network/wavelet/store"
+	"github.com/perlin-network/wavelet/sys"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLedgerClosesWithContext(t *testing.T) {
+	keys, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	ledger := NewLedger(store.NewInmem(), skademlia.NewClient(":0", keys), nil, WithContext(ctx))
+
+	select {
+	case <-ledger.Done():
+		t.Fatal("ledger must not be closed before its context is cancelled")
+	default:
+	}
+
+	cancel()
+
+	select {
+	case <-ledger.Done():
+	case <-time.After(1 * time.Second):
+		t.Fatal("ledger must be closed once its context is cancelled")
+	}
+
+	// Consensus-related workers are stopped once the ledger is closed.
+
+	select {
+	case <-ledger.sync:
+	case <-time.After(3 * time.Second):
+		t.Fatal("consensus workers must be stopped once the ledger is closed")
+	}
+
+	ledger.Close()
+}
+
+func TestFindRewardAncestors(t *testing.T) {
+	keys, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	ledger := NewLedger(store.NewInmem(), skademlia.NewClient(":0", keys), nil)
+	defer ledger.Close()
+
+	senders := make([]*skademlia.Keypair, 3)
+	for i := range senders {
+		senders[i], err = skademlia.NewKeys(1, 1)
+		assert.NoError(t, err)
+	}
+
+	var txs []*Transaction
+
+	for i := 0; i < 30; i++ {
+		sender := senders[i%len(senders)]
+
+		tx := AttachSenderToTransaction(sender, NewTransaction(sender, sys.TagNop, nil), ledger.graph.FindEligibleParents()...)
+		assert.NoError(t, ledger.graph.AddTransaction(tx))
+
+		txs = append(txs, &tx)
+	}
+
+	found := ledger.findRewardAncestors(txs)
+
+	if assert.Len(t, found, len(txs)) {
+		for i, tx := range txs {
+			assert.Equal(t, ledger.rewardAncestors(tx), found[i])
+
+			for _, ancestor := range found[i] {
+				assert.NotEqual(t, tx.Sender, ancestor.Sender, "transactions must not reward their own sender")
+			}
+		}
+	}
+
+	assert.NotEmpty(t, found[len(found)-1])
+}
+
+func TestCollapseKey(t *testing.T) {
+	var root, other, end Transaction
+	root.ID[0], other.ID[0], end.ID[0] = 1, 2, 3
+
+	assert.Equal(t, collapseKey(1, root, end), collapseKey(1, root, end))
+
+	assert.NotEqual(t, collapseKey(1, root, end), collapseKey(1, other, end), "results collapsed from a different root must not be shared")
+	assert.NotEqual(t, collapseKey(1, root, end), collapseKey(2, root, end), "results collapsed for a different round must not be shared")
+}
+
+func TestAddTransactionReservesRoomToGossipFirst(t *testing.T) {
+	keys, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	ledger := NewLedger(store.NewInmem(), skademlia.NewClient(":0", keys), nil, WithBroadcast(BroadcastConfig{Capacity: 1}))
+	defer ledger.Close()
+
+	filler := AttachSenderToTransaction(keys, NewTransaction(keys, sys.TagNop, nil), ledger.Graph().FindEligibleParents()...)
+
+	held, err := ledger.gossiper.Reserve(filler, true)
+	assert.NoError(t, err)
+
+	tx := AttachSenderToTransaction(keys, NewTransaction(keys, sys.TagTransfer, []byte{1}), ledger.Graph().FindEligibleParents()...)
+
+	assert.Equal(t, ErrQueueFull, errors.Cause(ledger.AddTransaction(tx)))
+	assert.Nil(t, ledger.Graph().FindTransaction(tx.ID), "a transaction that may not be gossiped must not be added")
+
+	held.Release()
+
+	assert.NoError(t, ledger.AddTransaction(tx))
+	assert.NotNil(t, ledger.Graph().FindTransaction(tx.ID))
+}
+
+func TestNextNonce(t *testing.T) {
+	keys, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	ledger := NewLedger(store.NewInmem(), skademlia.NewClient(":0", keys), nil)
+	defer ledger.Close()
+
+	id := keys.PublicKey()
+
+	nonce, _ := ReadAccountNonce(ledger.Snapshot(), id)
+	if nonce == 0 {
+		nonce = 1
+	}
+
+	assert.Equal(t, nonce, ledger.NextNonce(id), "nonces must follow the nonce of the account")
+	assert.Equal(t, nonce+1, ledger.NextNonce(id), "pending transactions must never be issued the same nonce")
+
+	assert.EqualValues(t, 1, ledger.NextNonce(AccountID{1}), "issued nonces must never be zero")
+}
+
+func TestCollapseTransactionsSpeculatively(t *testing.T) {
+	t.Run("unbounded", func(t *testing.T) {
+		testCollapseTransactionsSpeculatively(t, 0)
+	})
+
+	t.Run("node budget", func(t *testing.T) {
+		testCollapseTransactionsSpeculatively(t, 16)
+	})
+}
+
+func testCollapseTransactionsSpeculatively(t *testing.T, budget int) {
+	keys, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	kv := store.NewInmem()
+
+	ledger := NewLedger(kv, skademlia.NewClient(":0", keys), nil)
+	defer ledger.Close()
+
+	snapshot := ledger.accounts.Snapshot()
+
+	for i := 0; i < 512; i++ {
+		var id AccountID
+		binary.BigEndian.PutUint32(id[:], uint32(i))
+
+		WriteAccountBalance(snapshot, id, 1)
+	}
+
+	senders := make([]*skademlia.Keypair, 6)
+
+	for i := range senders {
+		senders[i], err = skademlia.NewKeys(1, 1)
+		assert.NoError(t, err)
+
+		WriteAccountBalance(snapshot, senders[i].PublicKey(), 1000000)
+		WriteAccountStake(snapshot, senders[i].PublicKey(), sys.MinimumStake*uint64(i+1))
+	}
+
+	assert.NoError(t, ledger.accounts.Commit(snapshot))
+
+	// Reload the accounts tree from the store, such that its nodes are lazily
+	// loaded while transactions are applied concurrently.
+
+	reloaded := avl.New(kv)
+	if budget > 0 {
+		reloaded.WithNodeBudget(budget)
+	}
+
+	assert.NoError(t, ledger.accounts.Commit(reloaded))
+
+	// Every sender sends several transactions, and some pay other senders, such
+	// that some transactions depend on the transactions before them.
+
+	var middle, end Transaction
+
+	for i := 0; i < 24; i++ {
+		sender := senders[i%len(senders)]
+
+		var recipient AccountID
+		recipient[0] = byte(i + 1)
+
+		if i%3 == 0 {
+			recipient = senders[(i+1)%len(senders)].PublicKey()
+		}
+
+		amount := uint64(100)
+		if i == 20 {
+			amount = 10000000 // Rejected, as the sender does not have enough PERLs.
+		}
+
+		payload := Transfer{Recipient: recipient, Amount: amount}.Marshal()
+
+		end = AttachSenderToTransaction(sender, NewTransaction(sender, sys.TagTransfer, payload), ledger.graph.FindEligibleParents()...)
+		assert.NoError(t, ledger.graph.AddTransaction(end))
+
+		if i == 11 {
+			middle = end
+		}
+	}
+
+	root := ledger.Rounds().Latest().End
+
+	// Collapse concurrently first, such that nodes are first loaded from the
+	// store by many goroutines at once. Collapsing towards end resumes from
+	// having collapsed towards middle, such that the round is applied on top of
+	// uncommitted nodes as well.
+
+	ledger.collapseWorkers = 4
+
+	_, err = ledger.CollapseTransactions(1, root, middle, false)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	concurrent, err := ledger.CollapseTransactions(1, root, end, false)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	ledger.cacheCollapse = NewLRU(16)
+	ledger.cacheCollapsePrefixes = NewLRU(256)
+	ledger.collapseWorkers = 1
+
+	sequential, err := ledger.CollapseTransactions(1, root, end, false)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, 23, sequential.appliedCount)
+	assert.Equal(t, 1, sequential.rejectedCount)
+
+	assert.Equal(t, sequential.snapshot.Checksum(), concurrent.snapshot.Checksum())
+	assert.Equal(t, sequential.applied, concurrent.applied)
+	assert.Equal(t, sequential.rejected, concurrent.rejected)
+	assert.Equal(t, sequential.journal, concurrent.journal)
+}
+
+func TestCollapseTransactionsResumesFromCheckpoint(t *testing.T) {
+	keys, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	ledger := NewLedger(store.NewInmem(), skademlia.NewClient(":0", keys), nil)
+	defer ledger.Close()
+
+	WriteAccountBalance(ledger.accounts.tree, keys.PublicKey(), 1000000)
+	assert.NoError(t, ledger.accounts.Commit(nil))
+
+	transfer := func(i int) Transaction {
+		var recipient AccountID
+		recipient[0] = byte(i + 1)
+
+		payload := Transfer{Recipient: recipient, Amount: 100}.Marshal()
+
+		tx := AttachSenderToTransaction(keys, NewTransaction(keys, sys.TagTransfer, payload), ledger.graph.FindEligibleParents()...)
+		assert.NoError(t, ledger.graph.AddTransaction(tx))
+
+		return tx
+	}
+
+	var first Transaction
+
+	for i := 0; i < collapseCheckpointInterval+8; i++ {
+		first = transfer(i)
+	}
+
+	root := ledger.Rounds().Latest().End
+
+	results, err := ledger.CollapseTransactions(1, root, first, false)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	prefixes := collapsePrefixKeys(1, root, results.applied)
+
+	for _, i := range []int{collapseCheckpointInterval, len(results.applied)} {
+		_, exists := ledger.cacheCollapsePrefixes.load(prefixes[i])
+		assert.True(t, exists)
+	}
+
+	// A competing critical transaction which builds on top of the ancestry of
+	// the first resumes from where collapsing towards the first left off.
+
+	second := transfer(0)
+
+	resumed, err := ledger.CollapseTransactions(1, root, second, false)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	ledger.cacheCollapse = NewLRU(16)
+	ledger.cacheCollapsePrefixes = NewLRU(256)
+
+	fresh, err := ledger.CollapseTransactions(1, root, second, false)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, len(results.applied)+1, resumed.appliedCount)
+	assert.Equal(t, results.applied, resumed.applied[:len(results.applied)])
+
+	assert.Equal(t, fresh.snapshot.Checksum(), resumed.snapshot.Checksum())
+	assert.Equal(t, fresh.applied, resumed.applied)
+	assert.Equal(t, fresh.journal, resumed.journal)
+	assert.Equal(t, fresh.ignoredCount, resumed.ignoredCount)
+}
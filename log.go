@@ -23,17 +23,22 @@ import (
 	"encoding/hex"
 
 	"github.com/perlin-network/wavelet/log"
+	"github.com/perlin-network/wavelet/sys"
 )
 
-func logEventTX(event string, tx *Transaction, other ...interface{}) {
+// requestID tags a transaction event with the ID of the API request which
+// submitted the transaction. Empty request IDs are not logged.
+type requestID string
+
+func logEventTX(logs *log.Scope, event string, tx *Transaction, other ...interface{}) {
 	var parents []string
 
 	for _, parentID := range tx.ParentIDs {
 		parents = append(parents, hex.EncodeToString(parentID[:]))
 	}
 
-	logger := log.TX(event)
-	log := logger.Log().
+	logger := logs.TX(event)
+	e := logger.Log().
 		Hex("tx_id", tx.ID[:]).
 		Hex("sender_id", tx.Sender[:]).
 		Hex("creator_id", tx.Creator[:]).
@@ -43,9 +48,77 @@ func logEventTX(event string, tx *Transaction, other ...interface{}) {
 	for _, o := range other {
 		switch o := o.(type) {
 		case error:
-			log = log.Err(o)
+			e = e.Err(o)
+		case requestID:
+			if len(o) > 0 {
+				e = e.Str(log.KeyRequestID, string(o))
+			}
+		}
+	}
+
+	e.Msg("")
+}
+
+// logEventStake logs out the stakes placed and withdrawn, and the rewards
+// requested to be withdrawn by transactions applied in a round, alongside the
+// rewards paid out to accounts from earlier withdrawal requests.
+func logEventStake(logs *log.Scope, round uint64, res *CollapseResults) {
+	for _, tx := range res.applied {
+		switch tx.Tag {
+		case sys.TagStake:
+			logEventStakePayload(logs, round, tx, tx.Payload)
+		case sys.TagBatch:
+			batch, err := ParseBatchTransaction(tx.Payload)
+			if err != nil {
+				continue
+			}
+
+			for i := range batch.Tags {
+				if sys.Tag(batch.Tags[i]) == sys.TagStake {
+					logEventStakePayload(logs, round, tx, batch.Payloads[i])
+				}
+			}
+		}
+	}
+
+	for _, entry := range res.journal {
+		if entry.Reason != JournalReward {
+			continue
 		}
+
+		logger := logs.Stake("reward_withdrawn")
+		logger.Info().
+			Hex("account_id", entry.Account[:]).
+			Uint64("round", round).
+			Uint64("amount", entry.Amount).
+			Msg("Paid out withdrawn rewards.")
+	}
+}
+
+func logEventStakePayload(logs *log.Scope, round uint64, tx *Transaction, payload []byte) {
+	params, err := ParseStakeTransaction(payload)
+	if err != nil {
+		return
 	}
 
-	log.Msg("")
+	var event, msg string
+
+	switch params.Opcode {
+	case sys.PlaceStake:
+		event, msg = "stake_placed", "Placed stake."
+	case sys.WithdrawStake:
+		event, msg = "stake_withdrawn", "Withdrew stake."
+	case sys.WithdrawReward:
+		event, msg = "reward_withdrawal_requested", "Requested to withdraw rewards."
+	default:
+		return
+	}
+
+	logger := logs.Stake(event)
+	logger.Info().
+		Hex("account_id", tx.Creator[:]).
+		Hex("tx_id", tx.ID[:]).
+		Uint64("round", round).
+		Uint64("amount", params.Amount).
+		Msg(msg)
 }
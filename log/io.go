@@ -36,6 +36,13 @@ func (t *multiWriter) SetWriter(key string, writer io.Writer) {
 	t.writers[key] = writer
 }
 
+func (t *multiWriter) RemoveWriter(key string) {
+	t.Lock()
+	defer t.Unlock()
+
+	delete(t.writers, key)
+}
+
 func (t *multiWriter) Write(p []byte) (n int, err error) {
 	t.RLock()
 	defer t.RUnlock()
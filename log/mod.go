@@ -30,87 +30,91 @@ var (
 	}
 	logger = zerolog.New(output).With().Timestamp().Logger()
 
-	node      zerolog.Logger
-	network   zerolog.Logger
-	accounts  zerolog.Logger
-	consensus zerolog.Logger
-	contract  zerolog.Logger
-	syncer    zerolog.Logger
-	stake     zerolog.Logger
-	tx        zerolog.Logger
-	metrics   zerolog.Logger
+	root = NewScope("")
 )
 
 const (
 	LoggerWavelet   = "wavelet"
 	LoggerWebsocket = "ws"
 
-	KeyModule = "mod"
-	KeyEvent  = "event"
-
-	ModuleNode      = "node"
-	ModuleNetwork   = "network"
-	ModuleAccounts  = "accounts"
-	ModuleConsensus = "consensus"
-	ModuleContract  = "contract"
-	ModuleSync      = "sync"
-	ModuleStake     = "stake"
-	ModuleTX        = "tx"
-	ModuleMetrics   = "metrics"
+	KeyModule    = "mod"
+	KeyEvent     = "event"
+	KeyLedger    = "ledger"
+	KeyRequestID = "request_id"
+
+	ModuleNode           = "node"
+	ModuleNetwork        = "network"
+	ModuleAccounts       = "accounts"
+	ModuleConsensus      = "consensus"
+	ModuleRounds         = "rounds"
+	ModuleContract       = "contract"
+	ModuleContractEvents = "contract_events"
+	ModuleSync           = "sync"
+	ModuleStake          = "stake"
+	ModuleTX             = "tx"
+	ModuleMetrics        = "metrics"
+	ModuleQueues         = "queues"
 )
 
-func init() {
-	setupChildLoggers()
+func SetWriter(key string, writer io.Writer) {
+	output.SetWriter(key, writer)
 }
 
-func setupChildLoggers() {
-	node = logger.With().Str(KeyModule, ModuleNode).Logger()
-	network = logger.With().Str(KeyModule, ModuleNetwork).Logger()
-	accounts = logger.With().Str(KeyModule, ModuleAccounts).Logger()
-	consensus = logger.With().Str(KeyModule, ModuleConsensus).Logger()
-	contract = logger.With().Str(KeyModule, ModuleContract).Logger()
-	syncer = logger.With().Str(KeyModule, ModuleSync).Logger()
-	stake = logger.With().Str(KeyModule, ModuleStake).Logger()
-	tx = logger.With().Str(KeyModule, ModuleTX).Logger()
-	metrics = logger.With().Str(KeyModule, ModuleMetrics).Logger()
+// RemoveWriter stops logs from being written to the writer registered under key.
+func RemoveWriter(key string) {
+	output.RemoveWriter(key)
 }
 
-func SetWriter(key string, writer io.Writer) {
-	output.SetWriter(key, writer)
+// Root returns the scope the package-level loggers log through, whose logs are
+// not tagged with the name of any ledger.
+func Root() *Scope {
+	return root
 }
 
 func Node() zerolog.Logger {
-	return node
+	return root.Node()
 }
 
 func Network(event string) zerolog.Logger {
-	return network.With().Str(KeyEvent, event).Logger()
+	return root.Network(event)
 }
 
 func Accounts(event string) zerolog.Logger {
-	return accounts.With().Str(KeyEvent, event).Logger()
+	return root.Accounts(event)
 }
 
 func Contracts(event string) zerolog.Logger {
-	return contract.With().Str(KeyEvent, event).Logger()
+	return root.Contracts(event)
+}
+
+func ContractEvents(event string) zerolog.Logger {
+	return root.ContractEvents(event)
 }
 
 func TX(event string) zerolog.Logger {
-	return tx.With().Str(KeyEvent, event).Logger()
+	return root.TX(event)
 }
 
 func Consensus(event string) zerolog.Logger {
-	return consensus.With().Str(KeyEvent, event).Logger()
+	return root.Consensus(event)
+}
+
+func Rounds(event string) zerolog.Logger {
+	return root.Rounds(event)
 }
 
 func Stake(event string) zerolog.Logger {
-	return stake.With().Str(KeyEvent, event).Logger()
+	return root.Stake(event)
 }
 
 func Sync(event string) zerolog.Logger {
-	return syncer.With().Str(KeyEvent, event).Logger()
+	return root.Sync(event)
 }
 
 func Metrics() zerolog.Logger {
-	return metrics
+	return root.Metrics()
+}
+
+func Queues() zerolog.Logger {
+	return root.Queues()
 }
@@ -0,0 +1,222 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package log
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// backupTimeFormat is the format of the timestamp suffixed to the path of
+// rotated log files.
+const backupTimeFormat = "20060102T150405.000"
+
+// RotatingFile is a log file which is rotated once it grows past some size, or
+// once it has been written to for too long. Rotated files are kept alongside the
+// log file, suffixed with the time they were rotated at. It is safe to write to
+// concurrently.
+type RotatingFile struct {
+	sync.Mutex
+
+	path string
+
+	maxSize    int64
+	maxAge     time.Duration
+	maxBackups int
+
+	file   *os.File
+	size   int64
+	opened time.Time
+}
+
+// RotateOption configures when a RotatingFile is rotated.
+type RotateOption func(*RotatingFile)
+
+// WithMaxSize rotates the file once writing to it would grow it past size bytes.
+// The file is not rotated by size should size be zero.
+func WithMaxSize(size int64) RotateOption {
+	return func(f *RotatingFile) {
+		f.maxSize = size
+	}
+}
+
+// WithMaxAge rotates the file once it has been written to for age. The file is
+// not rotated by age should age be zero.
+func WithMaxAge(age time.Duration) RotateOption {
+	return func(f *RotatingFile) {
+		f.maxAge = age
+	}
+}
+
+// WithMaxBackups keeps only the n most recently rotated files, deleting the
+// rest. Every rotated file is kept should n be zero.
+func WithMaxBackups(n int) RotateOption {
+	return func(f *RotatingFile) {
+		f.maxBackups = n
+	}
+}
+
+// NewRotatingFile opens the log file at path, creating it and its parent
+// directories should they not exist. Logs are appended to the file should it
+// already exist.
+func NewRotatingFile(path string, opts ...RotateOption) (*RotatingFile, error) {
+	f := &RotatingFile{path: path}
+
+	for _, opt := range opts {
+		opt(f)
+	}
+
+	if f.maxSize < 0 || f.maxAge < 0 || f.maxBackups < 0 {
+		return nil, errors.New("max size, max age, and max backups of a log file must not be negative")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, errors.Wrap(err, "failed to create directory for log file")
+	}
+
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.Lock()
+	defer f.Unlock()
+
+	if f.file == nil {
+		return 0, os.ErrClosed
+	}
+
+	if f.shouldRotate(len(p)) {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+
+	return n, err
+}
+
+// Close closes the log file. Logs written afterwards are dropped.
+func (f *RotatingFile) Close() error {
+	f.Lock()
+	defer f.Unlock()
+
+	if f.file == nil {
+		return nil
+	}
+
+	err := f.file.Close()
+	f.file = nil
+
+	return err
+}
+
+func (f *RotatingFile) shouldRotate(n int) bool {
+	if f.maxSize > 0 && f.size > 0 && f.size+int64(n) > f.maxSize {
+		return true
+	}
+
+	return f.maxAge > 0 && time.Since(f.opened) >= f.maxAge
+}
+
+func (f *RotatingFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return errors.Wrap(err, "failed to open log file")
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return errors.Wrap(err, "failed to stat log file")
+	}
+
+	f.file = file
+	f.size = info.Size()
+	f.opened = time.Now()
+
+	return nil
+}
+
+func (f *RotatingFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return errors.Wrap(err, "failed to close log file")
+	}
+
+	f.file = nil
+
+	backup := f.path + "." + time.Now().UTC().Format(backupTimeFormat)
+
+	if err := os.Rename(f.path, backup); err != nil {
+		return errors.Wrap(err, "failed to rotate log file")
+	}
+
+	if err := f.open(); err != nil {
+		return err
+	}
+
+	return f.prune()
+}
+
+// prune deletes the oldest rotated files, such that at most maxBackups rotated
+// files are kept around.
+func (f *RotatingFile) prune() error {
+	if f.maxBackups == 0 {
+		return nil
+	}
+
+	backups, err := filepath.Glob(f.path + ".*")
+	if err != nil {
+		return errors.Wrap(err, "failed to list rotated log files")
+	}
+
+	var rotated []string
+
+	for _, backup := range backups {
+		if _, err := time.Parse(backupTimeFormat, backup[len(f.path)+1:]); err == nil {
+			rotated = append(rotated, backup)
+		}
+	}
+
+	if len(rotated) <= f.maxBackups {
+		return nil
+	}
+
+	// Timestamps suffixed to rotated files sort in chronological order.
+	sort.Strings(rotated)
+
+	for _, backup := range rotated[:len(rotated)-f.maxBackups] {
+		if err := os.Remove(backup); err != nil {
+			return errors.Wrap(err, "failed to delete rotated log file")
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,142 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package log
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+)
+
+var sampling = struct {
+	sync.RWMutex
+	rates map[string]uint32
+}{rates: make(map[string]uint32)}
+
+// SetSampling only logs the first of every n events of type event emitted under
+// module, such as the "applied" events emitted under ModuleTX for every
+// transaction applied. Sampling should be configured before any events of the
+// type are emitted. Sampling is disabled should n be 0 or 1.
+func SetSampling(module, event string, n uint32) {
+	sampling.Lock()
+	defer sampling.Unlock()
+
+	if n <= 1 {
+		delete(sampling.rates, module+"."+event)
+		return
+	}
+
+	sampling.rates[module+"."+event] = n
+}
+
+// ParseSampling parses spec, in the form of module.event=n, such as
+// tx.applied=100, and configures events to be sampled as it specifies.
+func ParseSampling(spec string) error {
+	eq := strings.IndexByte(spec, '=')
+	dot := strings.IndexByte(spec, '.')
+
+	if eq == -1 || dot == -1 || dot > eq {
+		return errors.Errorf("log sampling %q must be in the form of module.event=n", spec)
+	}
+
+	module, event := spec[:dot], spec[dot+1:eq]
+
+	if !knownModule(module) {
+		return errors.Errorf("unknown log module %q: must be one of %s", module, strings.Join(Modules, ", "))
+	}
+
+	if len(event) == 0 {
+		return errors.Errorf("log sampling %q must specify an event", spec)
+	}
+
+	n, err := strconv.ParseUint(spec[eq+1:], 10, 32)
+	if err != nil {
+		return errors.Wrapf(err, "invalid log sampling rate in %q", spec)
+	}
+
+	SetSampling(module, event, uint32(n))
+
+	return nil
+}
+
+func samplingRate(key string) uint32 {
+	sampling.RLock()
+	defer sampling.RUnlock()
+
+	return sampling.rates[key]
+}
+
+// sampler logs the first of every n events, and counts how many events it has
+// dropped.
+type sampler struct {
+	n       uint32
+	counter uint32
+	dropped uint64
+}
+
+func (s *sampler) Sample(zerolog.Level) bool {
+	if (atomic.AddUint32(&s.counter, 1)-1)%s.n == 0 {
+		return true
+	}
+
+	atomic.AddUint64(&s.dropped, 1)
+
+	return false
+}
+
+// sample has logger sample events of type event emitted under module, should
+// they be configured to be sampled.
+func (s *Scope) sample(module, event string, logger zerolog.Logger) zerolog.Logger {
+	key := module + "." + event
+
+	v, exists := s.samplers.Load(key)
+
+	if !exists {
+		n := samplingRate(key)
+		if n <= 1 {
+			return logger
+		}
+
+		v, _ = s.samplers.LoadOrStore(key, &sampler{n: n})
+	}
+
+	return logger.Sample(v.(*sampler))
+}
+
+// TakeDropped returns how many events of each type were dropped by sampling
+// since it was last called, keyed by their module and event type in the form of
+// module.event. Event types of which no events were dropped are omitted.
+func (s *Scope) TakeDropped() map[string]uint64 {
+	dropped := make(map[string]uint64)
+
+	s.samplers.Range(func(key, v interface{}) bool {
+		if n := atomic.SwapUint64(&v.(*sampler).dropped, 0); n > 0 {
+			dropped[key.(string)] = n
+		}
+
+		return true
+	})
+
+	return dropped
+}
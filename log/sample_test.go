@@ -0,0 +1,64 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package log
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSampling(t *testing.T) {
+	var buf bytes.Buffer
+
+	SetWriter("test", &buf)
+	defer RemoveWriter("test")
+
+	assert.NoError(t, ParseSampling("tx.applied=3"))
+	defer SetSampling(ModuleTX, "applied", 0)
+
+	scope := NewScope("")
+
+	for i := 0; i < 7; i++ {
+		logger := scope.TX("applied")
+		logger.Log().Int("i", i).Msg("")
+
+		logger = scope.TX("rejected")
+		logger.Log().Int("i", i).Msg("")
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Len(t, lines, 3+7)
+
+	assert.Equal(t, map[string]uint64{"tx.applied": 4}, scope.TakeDropped())
+	assert.Empty(t, scope.TakeDropped())
+
+	for _, spec := range []string{
+		"tx.applied",
+		"applied=3",
+		"unknown.applied=3",
+		"tx.=3",
+		"tx.applied=-1",
+	} {
+		assert.Error(t, ParseSampling(spec), spec)
+	}
+}
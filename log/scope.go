@@ -0,0 +1,169 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package log
+
+import (
+	"sync"
+
+	"github.com/rs/zerolog"
+)
+
+// Scope holds a logger for every module. Should several ledgers be hosted within
+// the same process, each ledger logs through its own scope such that every log
+// it emits is tagged with the name of the ledger under KeyLedger.
+type Scope struct {
+	node      zerolog.Logger
+	network   zerolog.Logger
+	accounts  zerolog.Logger
+	consensus zerolog.Logger
+	rounds    zerolog.Logger
+	contract  zerolog.Logger
+	events    zerolog.Logger
+	syncer    zerolog.Logger
+	stake     zerolog.Logger
+	tx        zerolog.Logger
+	metrics   zerolog.Logger
+	queues    zerolog.Logger
+
+	samplers sync.Map // module.event -> *sampler
+}
+
+// NewScope creates loggers for every module which tag their logs with ledger.
+// Logs are left untagged should ledger be empty.
+func NewScope(ledger string) *Scope {
+	base := logger
+
+	if len(ledger) > 0 {
+		base = base.With().Str(KeyLedger, ledger).Logger()
+	}
+
+	return &Scope{
+		node:      base.With().Str(KeyModule, ModuleNode).Logger(),
+		network:   base.With().Str(KeyModule, ModuleNetwork).Logger(),
+		accounts:  base.With().Str(KeyModule, ModuleAccounts).Logger(),
+		consensus: base.With().Str(KeyModule, ModuleConsensus).Logger(),
+		rounds:    base.With().Str(KeyModule, ModuleRounds).Logger(),
+		contract:  base.With().Str(KeyModule, ModuleContract).Logger(),
+		events:    base.With().Str(KeyModule, ModuleContractEvents).Logger(),
+		syncer:    base.With().Str(KeyModule, ModuleSync).Logger(),
+		stake:     base.With().Str(KeyModule, ModuleStake).Logger(),
+		tx:        base.With().Str(KeyModule, ModuleTX).Logger(),
+		metrics:   base.With().Str(KeyModule, ModuleMetrics).Logger(),
+		queues:    base.With().Str(KeyModule, ModuleQueues).Logger(),
+	}
+}
+
+func (s *Scope) Node() zerolog.Logger {
+	return s.node
+}
+
+func (s *Scope) Network(event string) zerolog.Logger {
+	return s.sample(ModuleNetwork, event, s.network.With().Str(KeyEvent, event).Logger())
+}
+
+func (s *Scope) Accounts(event string) zerolog.Logger {
+	return s.sample(ModuleAccounts, event, s.accounts.With().Str(KeyEvent, event).Logger())
+}
+
+func (s *Scope) Contracts(event string) zerolog.Logger {
+	return s.sample(ModuleContract, event, s.contract.With().Str(KeyEvent, event).Logger())
+}
+
+func (s *Scope) ContractEvents(event string) zerolog.Logger {
+	return s.sample(ModuleContractEvents, event, s.events.With().Str(KeyEvent, event).Logger())
+}
+
+func (s *Scope) TX(event string) zerolog.Logger {
+	return s.sample(ModuleTX, event, s.tx.With().Str(KeyEvent, event).Logger())
+}
+
+func (s *Scope) Consensus(event string) zerolog.Logger {
+	return s.sample(ModuleConsensus, event, s.consensus.With().Str(KeyEvent, event).Logger())
+}
+
+func (s *Scope) Rounds(event string) zerolog.Logger {
+	return s.sample(ModuleRounds, event, s.rounds.With().Str(KeyEvent, event).Logger())
+}
+
+func (s *Scope) Stake(event string) zerolog.Logger {
+	return s.sample(ModuleStake, event, s.stake.With().Str(KeyEvent, event).Logger())
+}
+
+func (s *Scope) Sync(event string) zerolog.Logger {
+	return s.sample(ModuleSync, event, s.syncer.With().Str(KeyEvent, event).Logger())
+}
+
+func (s *Scope) Metrics() zerolog.Logger {
+	return s.metrics
+}
+
+func (s *Scope) Queues() zerolog.Logger {
+	return s.queues
+}
+
+// Deferred returns a copy of the scope whose logs are held back in memory,
+// alongside a function which writes them all out in the order they were logged.
+// Logs are dropped should the function never be called, such that work whose
+// results may be discarded logs only once its results are kept.
+func (s *Scope) Deferred() (*Scope, func()) {
+	w := &deferredWriter{}
+
+	d := &Scope{
+		node:      s.node.Output(w),
+		network:   s.network.Output(w),
+		accounts:  s.accounts.Output(w),
+		consensus: s.consensus.Output(w),
+		rounds:    s.rounds.Output(w),
+		contract:  s.contract.Output(w),
+		events:    s.events.Output(w),
+		syncer:    s.syncer.Output(w),
+		stake:     s.stake.Output(w),
+		tx:        s.tx.Output(w),
+		metrics:   s.metrics.Output(w),
+		queues:    s.queues.Output(w),
+	}
+
+	return d, w.flush
+}
+
+// deferredWriter holds back every log written to it until flushed.
+type deferredWriter struct {
+	sync.Mutex
+	logs [][]byte
+}
+
+func (w *deferredWriter) Write(p []byte) (int, error) {
+	w.Lock()
+	w.logs = append(w.logs, append([]byte{}, p...))
+	w.Unlock()
+
+	return len(p), nil
+}
+
+func (w *deferredWriter) flush() {
+	w.Lock()
+	logs := w.logs
+	w.logs = nil
+	w.Unlock()
+
+	for _, buf := range logs {
+		_, _ = output.Write(buf)
+	}
+}
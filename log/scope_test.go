@@ -0,0 +1,80 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package log
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fastjson"
+)
+
+func TestScopeTagsLedger(t *testing.T) {
+	var buf bytes.Buffer
+
+	SetWriter("test", &buf)
+	defer RemoveWriter("test")
+
+	logger := NewScope("testnet").Consensus("round_end")
+	logger.Info().Msg("")
+
+	v, err := fastjson.ParseBytes(buf.Bytes())
+	assert.NoError(t, err)
+	assert.Equal(t, "testnet", string(v.GetStringBytes(KeyLedger)))
+	assert.Equal(t, ModuleConsensus, string(v.GetStringBytes(KeyModule)))
+	assert.Equal(t, "round_end", string(v.GetStringBytes(KeyEvent)))
+
+	buf.Reset()
+
+	logger = Consensus("round_end")
+	logger.Info().Msg("")
+
+	v, err = fastjson.ParseBytes(buf.Bytes())
+	assert.NoError(t, err)
+	assert.Nil(t, v.Get(KeyLedger))
+}
+
+func TestScopeDeferred(t *testing.T) {
+	var buf bytes.Buffer
+
+	SetWriter("test", &buf)
+	defer RemoveWriter("test")
+
+	deferred, flush := NewScope("testnet").Deferred()
+
+	logger := deferred.Contracts("gas")
+	logger.Info().Msg("")
+
+	assert.Zero(t, buf.Len(), "deferred logs must not be written before being flushed")
+
+	flush()
+
+	v, err := fastjson.ParseBytes(buf.Bytes())
+	assert.NoError(t, err)
+	assert.Equal(t, "testnet", string(v.GetStringBytes(KeyLedger)))
+	assert.Equal(t, ModuleContract, string(v.GetStringBytes(KeyModule)))
+	assert.Equal(t, "gas", string(v.GetStringBytes(KeyEvent)))
+
+	buf.Reset()
+	flush()
+
+	assert.Zero(t, buf.Len(), "deferred logs must only be written once")
+}
@@ -0,0 +1,259 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package log
+
+import (
+	"io"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/valyala/fastjson"
+)
+
+// Format is the format logs are written to a sink in.
+type Format string
+
+const (
+	// FormatConsole writes logs in a human-friendly format.
+	FormatConsole Format = "console"
+
+	// FormatJSON writes logs as they are emitted, one JSON object per line.
+	FormatJSON Format = "json"
+)
+
+// Modules lists every module logs may be emitted under.
+var Modules = []string{
+	ModuleNode,
+	ModuleNetwork,
+	ModuleAccounts,
+	ModuleConsensus,
+	ModuleRounds,
+	ModuleContract,
+	ModuleContractEvents,
+	ModuleSync,
+	ModuleStake,
+	ModuleTX,
+	ModuleMetrics,
+	ModuleQueues,
+}
+
+// Sink is a destination logs are written to, opened via OpenSink.
+type Sink struct {
+	io.Writer
+
+	closer io.Closer
+}
+
+// Close closes the destination the sink writes logs to, should it need to be
+// closed.
+func (s *Sink) Close() error {
+	if s.closer == nil {
+		return nil
+	}
+
+	return s.closer.Close()
+}
+
+// OpenSink opens the sink described by spec, which is a URL comprised of a
+// destination followed by optional query parameters. The destination is one of:
+//
+//	stderr, stdout            the standard error or output of the process
+//	file:///path/to/log       a file, rotated by size and age (a plain path works too)
+//	syslog://                 the local syslog daemon
+//	syslog://host:port        a remote syslog daemon, over UDP
+//	syslog+tcp://host:port    a remote syslog daemon, over TCP
+//
+// The query parameters supported are:
+//
+//	format=console|json       defaults to console for stderr and stdout, and json otherwise
+//	modules=node,consensus    only writes logs emitted under these modules; defaults to all
+//	max_size=104857600        rotates a file once it would grow past this many bytes
+//	max_age=24h               rotates a file once it has been written to for this long
+//	max_backups=7             keeps only this many rotated files around
+//	tag=wavelet               tags logs sent to syslog; defaults to wavelet
+func OpenSink(spec string) (*Sink, error) {
+	u, err := url.Parse(spec)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid log sink %q", spec)
+	}
+
+	query := u.Query()
+
+	format := FormatJSON
+
+	if u.Scheme == "" && (u.Path == "stderr" || u.Path == "stdout") {
+		format = FormatConsole
+	}
+
+	if f := query.Get("format"); len(f) > 0 {
+		switch Format(f) {
+		case FormatConsole, FormatJSON:
+			format = Format(f)
+		default:
+			return nil, errors.Errorf("unknown log format %q: must be either console or json", f)
+		}
+	}
+
+	modules := Modules
+
+	if m := query.Get("modules"); len(m) > 0 {
+		modules = strings.Split(m, ",")
+
+		for _, module := range modules {
+			if !knownModule(module) {
+				return nil, errors.Errorf("unknown log module %q: must be one of %s", module, strings.Join(Modules, ", "))
+			}
+		}
+	}
+
+	var (
+		out    io.Writer
+		closer io.Closer
+	)
+
+	switch {
+	case u.Scheme == "" && u.Path == "stderr":
+		out = os.Stderr
+	case u.Scheme == "" && u.Path == "stdout":
+		out = os.Stdout
+	case u.Scheme == "" || u.Scheme == "file":
+		opts, err := parseRotateOptions(query)
+		if err != nil {
+			return nil, err
+		}
+
+		f, err := NewRotatingFile(u.Path, opts...)
+		if err != nil {
+			return nil, err
+		}
+
+		out, closer = f, f
+	case u.Scheme == "syslog" || u.Scheme == "syslog+udp" || u.Scheme == "syslog+tcp":
+		network := ""
+
+		if len(u.Host) > 0 {
+			network = "udp"
+
+			if u.Scheme == "syslog+tcp" {
+				network = "tcp"
+			}
+		}
+
+		tag := query.Get("tag")
+		if len(tag) == 0 {
+			tag = "wavelet"
+		}
+
+		w, err := dialSyslog(network, u.Host, tag, format)
+		if err != nil {
+			return nil, err
+		}
+
+		return &Sink{Writer: filter(w, modules), closer: w}, nil
+	default:
+		return nil, errors.Errorf("unknown log sink %q", spec)
+	}
+
+	if format == FormatConsole {
+		return &Sink{Writer: NewConsoleWriter(out, FilterFor(modules...)), closer: closer}, nil
+	}
+
+	return &Sink{Writer: filter(out, modules), closer: closer}, nil
+}
+
+func parseRotateOptions(query url.Values) ([]RotateOption, error) {
+	var opts []RotateOption
+
+	if s := query.Get("max_size"); len(s) > 0 {
+		size, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid max log file size %q", s)
+		}
+
+		opts = append(opts, WithMaxSize(size))
+	}
+
+	if s := query.Get("max_age"); len(s) > 0 {
+		age, err := time.ParseDuration(s)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid max log file age %q", s)
+		}
+
+		opts = append(opts, WithMaxAge(age))
+	}
+
+	if s := query.Get("max_backups"); len(s) > 0 {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid max number of rotated log files %q", s)
+		}
+
+		opts = append(opts, WithMaxBackups(n))
+	}
+
+	return opts, nil
+}
+
+func knownModule(module string) bool {
+	for _, m := range Modules {
+		if m == module {
+			return true
+		}
+	}
+
+	return false
+}
+
+// moduleFilter only writes logs emitted under some set of modules to out. Logs
+// not emitted under any module are always written.
+type moduleFilter struct {
+	out     io.Writer
+	modules map[string]struct{}
+}
+
+// filter only writes logs emitted under modules to out. All logs are written
+// should modules list every module.
+func filter(out io.Writer, modules []string) io.Writer {
+	f := moduleFilter{out: out, modules: make(map[string]struct{}, len(modules))}
+
+	for _, module := range modules {
+		f.modules[module] = struct{}{}
+	}
+
+	if len(f.modules) == len(Modules) {
+		return out
+	}
+
+	return f
+}
+
+func (f moduleFilter) Write(p []byte) (int, error) {
+	if module := fastjson.GetString(p, KeyModule); len(module) > 0 {
+		if _, ok := f.modules[module]; !ok {
+			return len(p), nil
+		}
+	}
+
+	return f.out.Write(p)
+}
@@ -0,0 +1,176 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package log
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRotatingFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wavelet-log")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "nested", "wavelet.log")
+
+	f, err := NewRotatingFile(path, WithMaxSize(10), WithMaxBackups(2))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	for i := 0; i < 4; i++ {
+		_, err := f.Write([]byte("0123456789"))
+		assert.NoError(t, err)
+
+		// Rotated files are named after the millisecond they were rotated at.
+		time.Sleep(2 * time.Millisecond)
+	}
+
+	assert.NoError(t, f.Close())
+
+	_, err = f.Write([]byte("closed"))
+	assert.Error(t, err)
+
+	backups, err := filepath.Glob(path + ".*")
+	assert.NoError(t, err)
+	assert.Len(t, backups, 2)
+
+	buf, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "0123456789", string(buf))
+}
+
+func TestRotatingFileMaxAge(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wavelet-log")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "wavelet.log")
+
+	f, err := NewRotatingFile(path, WithMaxAge(time.Millisecond))
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer f.Close()
+
+	_, err = f.Write([]byte("first"))
+	assert.NoError(t, err)
+
+	time.Sleep(5 * time.Millisecond)
+
+	_, err = f.Write([]byte("second"))
+	assert.NoError(t, err)
+
+	backups, err := filepath.Glob(path + ".*")
+	assert.NoError(t, err)
+	assert.Len(t, backups, 1)
+
+	buf, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "second", string(buf))
+}
+
+func TestOpenSink(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wavelet-log")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "wavelet.log")
+
+	sink, err := OpenSink("file://" + path + "?modules=consensus,tx&max_size=1048576")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	SetWriter("test", sink)
+
+	logger := Consensus("round_end")
+	logger.Info().Msg("kept")
+
+	logger = Network("connect")
+	logger.Info().Msg("dropped")
+
+	logger = TX("applied")
+	logger.Info().Msg("kept")
+
+	RemoveWriter("test")
+	assert.NoError(t, sink.Close())
+
+	buf, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(string(buf)), "\n")
+	assert.Len(t, lines, 2)
+
+	for _, line := range lines {
+		assert.Contains(t, line, `"message":"kept"`)
+	}
+
+	for _, spec := range []string{
+		"stderr?format=xml",
+		"stderr?modules=unknown",
+		"ftp://localhost",
+		"file://" + path + "?max_size=big",
+		"file://" + path + "?max_age=1",
+		"file://" + path + "?max_backups=-1",
+	} {
+		_, err := OpenSink(spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestOpenSinkConsole(t *testing.T) {
+	sink, err := OpenSink("stdout?modules=node")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	w, ok := sink.Writer.(ConsoleWriter)
+	if !assert.True(t, ok) {
+		return
+	}
+
+	var buf bytes.Buffer
+	w.Out = &buf
+	w.NoColor = true
+
+	_, err = w.Write([]byte(`{"level":"info","mod":"node","message":"kept"}`))
+	assert.NoError(t, err)
+
+	_, err = w.Write([]byte(`{"level":"info","mod":"network","message":"dropped"}`))
+	assert.NoError(t, err)
+
+	assert.Contains(t, buf.String(), "kept")
+	assert.NotContains(t, buf.String(), "dropped")
+}
@@ -0,0 +1,95 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+//go:build !windows && !plan9 && !nacl
+// +build !windows,!plan9,!nacl
+
+package log
+
+import (
+	"bytes"
+	"io"
+	"log/syslog"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/valyala/fastjson"
+)
+
+// syslogWriter writes logs to syslog, at the syslog priority matching the level
+// they were logged at.
+type syslogWriter struct {
+	w      *syslog.Writer
+	format Format
+}
+
+// dialSyslog connects to the syslog daemon at addr over network. The local
+// syslog daemon is connected to should network be empty.
+func dialSyslog(network, addr, tag string, format Format) (io.WriteCloser, error) {
+	w, err := syslog.Dial(network, addr, syslog.LOG_INFO|syslog.LOG_DAEMON, tag)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to connect to syslog")
+	}
+
+	return &syslogWriter{w: w, format: format}, nil
+}
+
+func (s *syslogWriter) Write(p []byte) (int, error) {
+	msg := string(bytes.TrimSpace(p))
+
+	if s.format == FormatConsole {
+		var buf bytes.Buffer
+
+		w := NewConsoleWriter(&buf, FilterFor(Modules...))
+		w.NoColor = true
+
+		if _, err := w.Write(p); err != nil {
+			return 0, err
+		}
+
+		msg = string(bytes.TrimSpace(buf.Bytes()))
+	}
+
+	var err error
+
+	switch fastjson.GetString(p, zerolog.LevelFieldName) {
+	case zerolog.DebugLevel.String():
+		err = s.w.Debug(msg)
+	case zerolog.WarnLevel.String():
+		err = s.w.Warning(msg)
+	case zerolog.ErrorLevel.String():
+		err = s.w.Err(msg)
+	case zerolog.FatalLevel.String():
+		err = s.w.Crit(msg)
+	case zerolog.PanicLevel.String():
+		err = s.w.Emerg(msg)
+	default:
+		err = s.w.Info(msg)
+	}
+
+	if err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+func (s *syslogWriter) Close() error {
+	return s.w.Close()
+}
@@ -0,0 +1,119 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"sync"
+	"testing"
+
+	"github.com/perlin-network/noise/skademlia"
+	"github.com/perlin-network/wavelet/log"
+	"github.com/perlin-network/wavelet/sys"
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fastjson"
+)
+
+type lockedBuffer struct {
+	sync.Mutex
+	bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.Lock()
+	defer b.Unlock()
+
+	return b.Buffer.Write(p)
+}
+
+func TestLogEventStake(t *testing.T) {
+	var buf lockedBuffer
+
+	log.SetWriter("stake_test", &buf)
+	defer log.RemoveWriter("stake_test")
+
+	keys, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	stake := func(opcode byte, amount uint64) []byte {
+		payload := make([]byte, 9)
+		payload[0] = opcode
+		binary.LittleEndian.PutUint64(payload[1:], amount)
+
+		return payload
+	}
+
+	place := AttachSenderToTransaction(keys, NewTransaction(keys, sys.TagStake, stake(sys.PlaceStake, 100)))
+	batch := AttachSenderToTransaction(keys, NewBatchTransaction(keys,
+		[]byte{byte(sys.TagStake), byte(sys.TagTransfer)},
+		[][]byte{stake(sys.WithdrawStake, 40), {0}},
+	))
+
+	rewardee := AccountID{1}
+
+	res := &CollapseResults{
+		applied: []*Transaction{&place, &batch},
+		journal: []JournalEntry{
+			{Round: 7, Account: rewardee, Amount: 250, Credit: true, Reason: JournalReward},
+			{Round: 7, Account: rewardee, Amount: 1, Reason: JournalFee},
+		},
+	}
+
+	logEventStake(log.NewScope("stake_test"), 7, res)
+
+	buf.Lock()
+	defer buf.Unlock()
+
+	var events []*fastjson.Value
+
+	scanner := bufio.NewScanner(&buf.Buffer)
+	for scanner.Scan() {
+		v, err := fastjson.ParseBytes(scanner.Bytes())
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		if string(v.GetStringBytes(log.KeyLedger)) == "stake_test" {
+			events = append(events, v)
+		}
+	}
+
+	if !assert.Len(t, events, 3) {
+		return
+	}
+
+	creator := hex.EncodeToString(place.Creator[:])
+
+	assert.Equal(t, "stake_placed", string(events[0].GetStringBytes(log.KeyEvent)))
+	assert.Equal(t, creator, string(events[0].GetStringBytes("account_id")))
+	assert.Equal(t, uint64(100), events[0].GetUint64("amount"))
+	assert.Equal(t, uint64(7), events[0].GetUint64("round"))
+
+	assert.Equal(t, "stake_withdrawn", string(events[1].GetStringBytes(log.KeyEvent)))
+	assert.Equal(t, hex.EncodeToString(batch.ID[:]), string(events[1].GetStringBytes("tx_id")))
+	assert.Equal(t, uint64(40), events[1].GetUint64("amount"))
+
+	assert.Equal(t, "reward_withdrawn", string(events[2].GetStringBytes(log.KeyEvent)))
+	assert.Equal(t, hex.EncodeToString(rewardee[:]), string(events[2].GetStringBytes("account_id")))
+	assert.Equal(t, uint64(250), events[2].GetUint64("amount"))
+}
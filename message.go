@@ -0,0 +1,55 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"encoding/binary"
+
+	"github.com/perlin-network/noise/edwards25519"
+	"github.com/perlin-network/noise/skademlia"
+)
+
+// messagePrefix is prepended to every message signed via SignMessage, such that
+// a signed message may never be replayed as a signed transaction or vote.
+const messagePrefix = "wavelet signed message:\n"
+
+func messageDigest(message []byte) []byte {
+	buf := make([]byte, 0, len(messagePrefix)+8+len(message))
+	buf = append(buf, messagePrefix...)
+
+	var size [8]byte
+	binary.BigEndian.PutUint64(size[:], uint64(len(message)))
+
+	buf = append(buf, size[:]...)
+	buf = append(buf, message...)
+
+	return buf
+}
+
+// SignMessage signs an arbitrary message with the private key of an account, such
+// that the owner of the account may prove ownership of it to third parties.
+func SignMessage(keys *skademlia.Keypair, message []byte) Signature {
+	return edwards25519.Sign(keys.PrivateKey(), messageDigest(message))
+}
+
+// VerifyMessage verifies that the owner of account has signed message.
+func VerifyMessage(account AccountID, message []byte, signature Signature) bool {
+	return edwards25519.Verify(account, messageDigest(message), signature)
+}
@@ -0,0 +1,50 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"testing"
+
+	"github.com/perlin-network/noise/skademlia"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignMessage(t *testing.T) {
+	keys, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	other, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	message := []byte("I own this account.")
+
+	signature := SignMessage(keys, message)
+
+	assert.True(t, VerifyMessage(keys.PublicKey(), message, signature))
+	assert.False(t, VerifyMessage(other.PublicKey(), message, signature), "signatures must not verify against other accounts")
+	assert.False(t, VerifyMessage(keys.PublicKey(), []byte("I own this account!"), signature), "signatures must not verify against other messages")
+
+	key := newVoterKey(keys)
+
+	vote := CertificateVote{Voter: keys.PublicKey(), BLSPublicKey: key.publicKey, BLSKeyProof: key.proof}
+	vote.KeySignature = SignMessage(keys, key.publicKey)
+
+	assert.False(t, vote.VerifyKey(), "signed messages must not bind BLS keys to accounts")
+}
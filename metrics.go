@@ -36,10 +36,32 @@ type Metrics struct {
 	acceptedTX   metrics.Meter
 	downloadedTX metrics.Meter
 
-	queryLatency metrics.Timer
+	queryLatency  metrics.Timer
+	rejectedVotes metrics.Meter
+
+	broadcastDepth   metrics.Gauge
+	broadcastDropped metrics.Meter
+
+	alerts metrics.Meter
+
+	finalityLatency metrics.Timer
+	peers           metrics.Gauge
+
+	missingCount   metrics.Gauge
+	missingAge     metrics.Gauge
+	missingRepairs metrics.Meter
+
+	graphRejected metrics.Meter
 }
 
 func NewMetrics(ctx context.Context) *Metrics {
+	m := newMetrics()
+	go m.run(ctx, log.NewScope(""))
+
+	return m
+}
+
+func newMetrics() *Metrics {
 	registry := metrics.NewRegistry()
 
 	queried := metrics.NewRegisteredMeter("round.queried", registry)
@@ -50,33 +72,21 @@ func NewMetrics(ctx context.Context) *Metrics {
 	downloadedTX := metrics.NewRegisteredMeter("tx.downloaded", registry)
 
 	queryLatency := metrics.NewRegisteredTimer("query.latency", registry)
+	rejectedVotes := metrics.NewRegisteredMeter("query.rejected", registry)
 
-	go func() {
-		logger := log.Metrics()
-
-		for {
-			select {
-			case <-time.After(1 * time.Second):
-				logger.Info().
-					Int64("round.queried", queried.Count()).
-					Int64("tx.gossiped", gossipedTX.Count()).
-					Int64("tx.received", receivedTX.Count()).
-					Int64("tx.accepted", acceptedTX.Count()).
-					Int64("tx.downloaded", downloadedTX.Count()).
-					Float64("rps.queried", queried.RateMean()).
-					Float64("tps.gossiped", gossipedTX.RateMean()).
-					Float64("tps.received", receivedTX.RateMean()).
-					Float64("tps.accepted", acceptedTX.RateMean()).
-					Float64("tps.downloaded", downloadedTX.RateMean()).
-					Int64("query.latency.max.ms", queryLatency.Max()/(1.0e+7)).
-					Int64("query.latency.min.ms", queryLatency.Min()/(1.0e+7)).
-					Float64("query.latency.mean.ms", queryLatency.Mean()/(1.0e+7)).
-					Msg("Updated metrics.")
-			case <-ctx.Done():
-				return
-			}
-		}
-	}()
+	broadcastDepth := metrics.NewRegisteredGauge("broadcast.depth", registry)
+	broadcastDropped := metrics.NewRegisteredMeter("broadcast.dropped", registry)
+
+	alerts := metrics.NewRegisteredMeter("alerts.fired", registry)
+
+	finalityLatency := metrics.NewRegisteredTimer("round.latency", registry)
+	peers := metrics.NewRegisteredGauge("peers.count", registry)
+
+	missingCount := metrics.NewRegisteredGauge("missing.count", registry)
+	missingAge := metrics.NewRegisteredGauge("missing.age.max.ms", registry)
+	missingRepairs := metrics.NewRegisteredMeter("missing.repairs", registry)
+
+	graphRejected := metrics.NewRegisteredMeter("graph.rejected", registry)
 
 	return &Metrics{
 		registry: registry,
@@ -88,7 +98,61 @@ func NewMetrics(ctx context.Context) *Metrics {
 		acceptedTX:   acceptedTX,
 		downloadedTX: downloadedTX,
 
-		queryLatency: queryLatency,
+		queryLatency:  queryLatency,
+		rejectedVotes: rejectedVotes,
+
+		broadcastDepth:   broadcastDepth,
+		broadcastDropped: broadcastDropped,
+
+		alerts: alerts,
+
+		finalityLatency: finalityLatency,
+		peers:           peers,
+
+		missingCount:   missingCount,
+		missingAge:     missingAge,
+		missingRepairs: missingRepairs,
+
+		graphRejected: graphRejected,
+	}
+}
+
+// run logs all metrics through logs every second until ctx is cancelled.
+func (m *Metrics) run(ctx context.Context, logs *log.Scope) {
+	logger := logs.Metrics()
+
+	for {
+		select {
+		case <-time.After(1 * time.Second):
+			logger.Info().
+				Int64("round.queried", m.queried.Count()).
+				Int64("tx.gossiped", m.gossipedTX.Count()).
+				Int64("tx.received", m.receivedTX.Count()).
+				Int64("tx.accepted", m.acceptedTX.Count()).
+				Int64("tx.downloaded", m.downloadedTX.Count()).
+				Float64("rps.queried", m.queried.RateMean()).
+				Float64("tps.gossiped", m.gossipedTX.RateMean()).
+				Float64("tps.received", m.receivedTX.RateMean()).
+				Float64("tps.accepted", m.acceptedTX.RateMean()).
+				Float64("tps.downloaded", m.downloadedTX.RateMean()).
+				Int64("query.latency.max.ms", m.queryLatency.Max()/(1.0e+7)).
+				Int64("query.latency.min.ms", m.queryLatency.Min()/(1.0e+7)).
+				Float64("query.latency.mean.ms", m.queryLatency.Mean()/(1.0e+7)).
+				Int64("query.rejected", m.rejectedVotes.Count()).
+				Int64("broadcast.depth", m.broadcastDepth.Value()).
+				Int64("broadcast.dropped", m.broadcastDropped.Count()).
+				Int64("alerts.fired", m.alerts.Count()).
+				Float64("round.latency.mean.ms", m.finalityLatency.Mean()/float64(time.Millisecond)).
+				Int64("round.latency.max.ms", m.finalityLatency.Max()/int64(time.Millisecond)).
+				Int64("peers.count", m.peers.Value()).
+				Int64("missing.count", m.missingCount.Value()).
+				Int64("missing.age.max.ms", m.missingAge.Value()).
+				Int64("missing.repairs", m.missingRepairs.Count()).
+				Int64("graph.rejected", m.graphRejected.Count()).
+				Msg("Updated metrics.")
+		case <-ctx.Done():
+			return
+		}
 	}
 }
 
@@ -101,4 +165,13 @@ func (m *Metrics) Stop() {
 	m.downloadedTX.Stop()
 
 	m.queryLatency.Stop()
+	m.rejectedVotes.Stop()
+
+	m.broadcastDropped.Stop()
+
+	m.alerts.Stop()
+
+	m.missingRepairs.Stop()
+
+	m.graphRejected.Stop()
 }
@@ -0,0 +1,70 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"github.com/pkg/errors"
+)
+
+// Mode decides whether or not a node takes part in consensus.
+type Mode string
+
+const (
+	// Gossip transactions, and vote on and finalize consensus rounds.
+	ModeValidator Mode = "validator"
+
+	// Never gossip nor vote, but continuously sync and finalize rounds alongside
+	// peers such that the full read API may be served without influencing consensus.
+	ModeFollower Mode = "follower"
+
+	// Follow peers as a follower would, but without a wallet. The node only holds
+	// an ephemeral key pair to authenticate itself to its peers, and never signs
+	// transactions, votes nor certificates.
+	ModeObserver Mode = "observer"
+
+	// Follow peers as a follower would, while additionally keeping every finalized
+	// transaction and the state of accounts as of every finalized round, such that
+	// explorers and analytics may be served without influencing consensus.
+	ModeArchive Mode = "archive"
+)
+
+// ErrReadOnly is returned when a transaction is submitted to a node that does
+// not take part in consensus.
+var ErrReadOnly = errors.New("nodes that are not validators do not accept transactions")
+
+func ParseMode(mode string) (Mode, error) {
+	switch m := Mode(mode); m {
+	case ModeValidator, ModeFollower, ModeObserver, ModeArchive:
+		return m, nil
+	}
+
+	return "", errors.Errorf("unknown node mode %q", mode)
+}
+
+// Participates returns true if nodes in this mode gossip, vote and sign.
+func (m Mode) Participates() bool {
+	return m == ModeValidator
+}
+
+// Archives returns true if nodes in this mode keep all finalized transactions
+// and historical account states.
+func (m Mode) Archives() bool {
+	return m == ModeArchive
+}
@@ -0,0 +1,95 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"context"
+	"testing"
+
+	"github.com/perlin-network/noise/skademlia"
+	"github.com/perlin-network/wavelet/store"
+	"github.com/perlin-network/wavelet/sys"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMode(t *testing.T) {
+	mode, err := ParseMode("follower")
+	assert.NoError(t, err)
+	assert.Equal(t, ModeFollower, mode)
+
+	mode, err = ParseMode("observer")
+	assert.NoError(t, err)
+	assert.Equal(t, ModeObserver, mode)
+
+	mode, err = ParseMode("archive")
+	assert.NoError(t, err)
+	assert.Equal(t, ModeArchive, mode)
+
+	_, err = ParseMode("spectator")
+	assert.Error(t, err)
+
+	assert.True(t, ModeValidator.Participates())
+	assert.False(t, ModeFollower.Participates())
+	assert.False(t, ModeObserver.Participates())
+	assert.False(t, ModeArchive.Participates())
+
+	assert.True(t, ModeArchive.Archives())
+	assert.False(t, ModeFollower.Archives())
+}
+
+func TestFollowerNeverVotes(t *testing.T) {
+	keys, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	ledger := NewLedger(store.NewInmem(), skademlia.NewClient(":0", keys), nil, WithMode(ModeFollower))
+	assert.Equal(t, ModeFollower, ledger.Mode())
+
+	_, err = ledger.Protocol().Query(context.Background(), &QueryRequest{RoundIndex: 0})
+	assert.Error(t, err)
+
+	_, err = ledger.Protocol().CheckOutOfSync(context.Background(), &OutOfSyncRequest{})
+	assert.Error(t, err)
+}
+
+func TestObserverNeverSigns(t *testing.T) {
+	keys, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	ledger := NewLedger(store.NewInmem(), skademlia.NewClient(":0", keys), nil, WithMode(ModeObserver))
+	assert.Nil(t, ledger.blsKey)
+
+	tx := AttachSenderToTransaction(keys, NewTransaction(keys, sys.TagNop, nil), ledger.Graph().FindEligibleParents()...)
+	assert.Equal(t, ErrReadOnly, ledger.AddTransaction(tx))
+	assert.Nil(t, ledger.Graph().FindTransaction(tx.ID))
+}
+
+func TestArchiveKeepsHistory(t *testing.T) {
+	keys, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	ledger := NewLedger(store.NewInmem(), skademlia.NewClient(":0", keys), nil, WithMode(ModeArchive))
+	assert.Equal(t, ModeArchive, ledger.Mode())
+
+	assert.NotNil(t, ledger.history)
+	assert.True(t, ledger.graph.archive)
+
+	_, err = ledger.Protocol().Query(context.Background(), &QueryRequest{RoundIndex: 0})
+	assert.Error(t, err)
+}
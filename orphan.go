@@ -0,0 +1,197 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import "time"
+
+// OrphanConfig describes how many transactions with missing parents the graph
+// holds on to, and for how long it waits for their parents to arrive. Any
+// field left at zero falls back to its default.
+type OrphanConfig struct {
+	Capacity int
+	TTL      time.Duration
+}
+
+func DefaultOrphanConfig() OrphanConfig {
+	return OrphanConfig{
+		Capacity: 8192,
+		TTL:      1 * time.Minute,
+	}
+}
+
+func (c OrphanConfig) withDefaults() OrphanConfig {
+	defaults := DefaultOrphanConfig()
+
+	if c.Capacity <= 0 {
+		c.Capacity = defaults.Capacity
+	}
+
+	if c.TTL <= 0 {
+		c.TTL = defaults.TTL
+	}
+
+	return c
+}
+
+// orphanPool tracks the transactions in the graph that do not have all of their
+// parents available. Orphans are indexed by the IDs of the parents they are
+// missing, and expire in the order they arrived in. It is guarded by the
+// graphs lock.
+type orphanPool struct {
+	config OrphanConfig
+
+	expiry   map[TransactionID]time.Time                  // Orphans, mapped to when they expire.
+	missing  map[TransactionID][]TransactionID            // Orphans, mapped to the IDs of the parents they are missing.
+	byParent map[TransactionID]map[TransactionID]struct{} // IDs of missing parents, mapped to the orphans awaiting them.
+
+	order []TransactionID // Orphans, in the order they arrived in. May contain orphans that were since removed.
+}
+
+func newOrphanPool(config OrphanConfig) *orphanPool {
+	return &orphanPool{
+		config: config.withDefaults(),
+
+		expiry:   make(map[TransactionID]time.Time),
+		missing:  make(map[TransactionID][]TransactionID),
+		byParent: make(map[TransactionID]map[TransactionID]struct{}),
+	}
+}
+
+func (p *orphanPool) configure(config OrphanConfig) {
+	p.config = config.withDefaults()
+}
+
+// add records id as an orphan awaiting the transactions under the IDs parents.
+func (p *orphanPool) add(id TransactionID, parents []TransactionID, now time.Time) {
+	if _, exists := p.expiry[id]; exists {
+		return
+	}
+
+	p.expiry[id] = now.Add(p.config.TTL)
+	p.order = append(p.order, id)
+
+	if len(parents) == 0 {
+		return
+	}
+
+	p.missing[id] = parents
+
+	for _, parentID := range parents {
+		if p.byParent[parentID] == nil {
+			p.byParent[parentID] = make(map[TransactionID]struct{})
+		}
+
+		p.byParent[parentID][id] = struct{}{}
+	}
+}
+
+// remove stops tracking id as an orphan. It returns the IDs of the missing
+// parents that no other orphan is awaiting anymore.
+func (p *orphanPool) remove(id TransactionID) []TransactionID {
+	if _, exists := p.expiry[id]; !exists {
+		return nil
+	}
+
+	delete(p.expiry, id)
+
+	var abandoned []TransactionID
+
+	for _, parentID := range p.missing[id] {
+		delete(p.byParent[parentID], id)
+
+		if len(p.byParent[parentID]) == 0 {
+			delete(p.byParent, parentID)
+			abandoned = append(abandoned, parentID)
+		}
+	}
+
+	delete(p.missing, id)
+
+	// Compact away orphans that were removed out of arrival order.
+	if len(p.order) > 2*len(p.expiry)+64 {
+		order := p.order[:0]
+
+		for _, orphanID := range p.order {
+			if _, exists := p.expiry[orphanID]; exists {
+				order = append(order, orphanID)
+			}
+		}
+
+		p.order = order
+	}
+
+	return abandoned
+}
+
+// arrived stops tracking parentID as missing, as it is now available.
+func (p *orphanPool) arrived(parentID TransactionID) {
+	for id := range p.byParent[parentID] {
+		missing := p.missing[id][:0]
+
+		for _, it := range p.missing[id] {
+			if it != parentID {
+				missing = append(missing, it)
+			}
+		}
+
+		p.missing[id] = missing
+	}
+
+	delete(p.byParent, parentID)
+}
+
+// oldest returns the orphan that arrived first, or false should there be none.
+func (p *orphanPool) oldest() (TransactionID, bool) {
+	for len(p.order) > 0 {
+		id := p.order[0]
+
+		if _, exists := p.expiry[id]; exists {
+			return id, true
+		}
+
+		p.order = p.order[1:]
+	}
+
+	return ZeroTransactionID, false
+}
+
+// expired returns the orphans whose deadline passed as of now.
+func (p *orphanPool) expired(now time.Time) []TransactionID {
+	var ids []TransactionID
+
+	for _, id := range p.order {
+		deadline, exists := p.expiry[id]
+		if !exists {
+			continue
+		}
+
+		if deadline.After(now) {
+			break
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids
+}
+
+func (p *orphanPool) len() int {
+	return len(p.expiry)
+}
@@ -0,0 +1,124 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/perlin-network/noise/skademlia"
+	"github.com/perlin-network/wavelet/sys"
+	"github.com/stretchr/testify/assert"
+)
+
+func randomTransaction(t *testing.T, keys *skademlia.Keypair, parents ...*Transaction) Transaction {
+	var payload [50]byte
+
+	_, err := rand.Read(payload[:])
+	assert.NoError(t, err)
+
+	return AttachSenderToTransaction(keys, NewTransaction(keys, sys.TagTransfer, payload[:]), parents...)
+}
+
+func TestOrphanPromotedOnceParentArrives(t *testing.T) {
+	keys, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	root := AttachSenderToTransaction(keys, NewTransaction(keys, sys.TagNop, nil))
+	graph := NewGraph(WithRoot(root))
+
+	parent := randomTransaction(t, keys, &root)
+	child := randomTransaction(t, keys, &parent)
+
+	assert.Equal(t, ErrMissingParents, graph.AddTransaction(child))
+	assert.Equal(t, 1, graph.OrphanLen())
+	assert.Equal(t, []TransactionID{parent.ID}, graph.Missing())
+
+	assert.NoError(t, graph.AddTransaction(parent))
+	assert.Equal(t, 0, graph.OrphanLen())
+	assert.Len(t, graph.incomplete, 0)
+	assert.Len(t, graph.Missing(), 0)
+
+	eligible := graph.FindEligibleParents()
+	assert.Len(t, eligible, 1)
+	assert.Equal(t, child.ID, eligible[0].ID)
+}
+
+func TestOrphanExpiry(t *testing.T) {
+	keys, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	root := AttachSenderToTransaction(keys, NewTransaction(keys, sys.TagNop, nil))
+
+	graph := NewGraph(WithRoot(root))
+	graph.orphans.configure(OrphanConfig{TTL: 50 * time.Millisecond})
+
+	parent := randomTransaction(t, keys, &root)
+	child := randomTransaction(t, keys, &parent)
+	grandchild := randomTransaction(t, keys, &child)
+
+	assert.Equal(t, ErrMissingParents, graph.AddTransaction(child))
+	assert.Equal(t, ErrMissingParents, graph.AddTransaction(grandchild))
+	assert.Equal(t, 2, graph.OrphanLen())
+
+	assert.Equal(t, 0, graph.ExpireOrphans())
+
+	time.Sleep(100 * time.Millisecond)
+
+	// The grandchild expires alongside its parent.
+	assert.Equal(t, 1, graph.ExpireOrphans())
+	assert.Equal(t, 0, graph.OrphanLen())
+
+	assert.Nil(t, graph.FindTransaction(child.ID))
+	assert.Nil(t, graph.FindTransaction(grandchild.ID))
+
+	assert.Len(t, graph.incomplete, 0)
+	assert.Len(t, graph.Missing(), 0)
+}
+
+func TestOrphanCapacity(t *testing.T) {
+	keys, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	root := AttachSenderToTransaction(keys, NewTransaction(keys, sys.TagNop, nil))
+
+	graph := NewGraph(WithRoot(root))
+	graph.orphans.configure(OrphanConfig{Capacity: 2})
+
+	var orphans []Transaction
+
+	for i := 0; i < 3; i++ {
+		parent := randomTransaction(t, keys, &root)
+		orphan := randomTransaction(t, keys, &parent)
+
+		assert.Equal(t, ErrMissingParents, graph.AddTransaction(orphan))
+
+		orphans = append(orphans, orphan)
+	}
+
+	assert.Equal(t, 2, graph.OrphanLen())
+	assert.Len(t, graph.Missing(), 2)
+
+	// The orphan that has been waiting for the longest is evicted first.
+	assert.Nil(t, graph.FindTransaction(orphans[0].ID))
+	assert.NotNil(t, graph.FindTransaction(orphans[1].ID))
+	assert.NotNil(t, graph.FindTransaction(orphans[2].ID))
+}
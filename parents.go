@@ -0,0 +1,166 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"math/rand"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// ParentCandidate is a transaction eligible to be a parent, alongside the
+// number of other transactions in the graph that it conflicts with.
+type ParentCandidate struct {
+	Tx        *Transaction
+	Conflicts int
+}
+
+// ParentSelector picks at most limit parents for a new transaction out of a
+// set of eligible candidates, ordered from the deepest candidate to the
+// shallowest.
+type ParentSelector interface {
+	SelectParents(candidates []ParentCandidate, limit int) []*Transaction
+}
+
+const (
+	ParentsDeepest       = "deepest"
+	ParentsUniform       = "uniform"
+	ParentsDepthWeighted = "depth_weighted"
+	ParentsLowConflict   = "low_conflict"
+)
+
+// ParseParentSelector returns the parent selection strategy registered under
+// name.
+func ParseParentSelector(name string) (ParentSelector, error) {
+	switch name {
+	case ParentsDeepest:
+		return DeepestParents{}, nil
+	case ParentsUniform:
+		return UniformParents{}, nil
+	case ParentsDepthWeighted:
+		return DepthWeightedParents{}, nil
+	case ParentsLowConflict:
+		return LowConflictParents{}, nil
+	}
+
+	return nil, errors.Errorf("unknown parent selection strategy %q", name)
+}
+
+// DeepestParents picks the deepest eligible parents. It is the default
+// strategy.
+type DeepestParents struct{}
+
+func (DeepestParents) SelectParents(candidates []ParentCandidate, limit int) []*Transaction {
+	if len(candidates) > limit {
+		candidates = candidates[:limit]
+	}
+
+	return candidateTxs(candidates)
+}
+
+// UniformParents picks eligible parents uniformly at random, spreading
+// approvals across the whole frontier of the graph.
+type UniformParents struct{}
+
+func (UniformParents) SelectParents(candidates []ParentCandidate, limit int) []*Transaction {
+	if len(candidates) <= limit {
+		return candidateTxs(candidates)
+	}
+
+	parents := make([]*Transaction, 0, limit)
+
+	for _, i := range rand.Perm(len(candidates))[:limit] {
+		parents = append(parents, candidates[i].Tx)
+	}
+
+	return parents
+}
+
+// DepthWeightedParents picks eligible parents at random, favoring deeper
+// candidates in proportion to how far above the shallowest candidate they sit.
+type DepthWeightedParents struct{}
+
+func (DepthWeightedParents) SelectParents(candidates []ParentCandidate, limit int) []*Transaction {
+	if len(candidates) <= limit {
+		return candidateTxs(candidates)
+	}
+
+	min := candidates[len(candidates)-1].Tx.Depth
+
+	pool := append([]ParentCandidate(nil), candidates...)
+	weights := make([]uint64, len(pool))
+
+	var total uint64
+
+	for i, candidate := range pool {
+		weights[i] = candidate.Tx.Depth - min + 1
+		total += weights[i]
+	}
+
+	parents := make([]*Transaction, 0, limit)
+
+	for len(parents) < limit {
+		pick := uint64(rand.Int63n(int64(total)))
+
+		i := 0
+		for ; pick >= weights[i]; i++ {
+			pick -= weights[i]
+		}
+
+		parents = append(parents, pool[i].Tx)
+		total -= weights[i]
+
+		pool = append(pool[:i], pool[i+1:]...)
+		weights = append(weights[:i], weights[i+1:]...)
+	}
+
+	return parents
+}
+
+// LowConflictParents picks the eligible parents that conflict with the
+// fewest other transactions, breaking ties by depth. Approving transactions
+// that are unlikely to be rejected keeps new transactions from being dragged
+// down alongside them.
+type LowConflictParents struct{}
+
+func (LowConflictParents) SelectParents(candidates []ParentCandidate, limit int) []*Transaction {
+	sorted := append([]ParentCandidate(nil), candidates...)
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Conflicts < sorted[j].Conflicts
+	})
+
+	return DeepestParents{}.SelectParents(sorted, limit)
+}
+
+func candidateTxs(candidates []ParentCandidate) []*Transaction {
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	txs := make([]*Transaction, 0, len(candidates))
+
+	for _, candidate := range candidates {
+		txs = append(txs, candidate.Tx)
+	}
+
+	return txs
+}
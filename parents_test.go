@@ -0,0 +1,113 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func parentCandidates(depths ...uint64) []ParentCandidate {
+	candidates := make([]ParentCandidate, 0, len(depths))
+
+	for i, depth := range depths {
+		tx := &Transaction{Depth: depth}
+		tx.ID[0] = byte(i)
+
+		candidates = append(candidates, ParentCandidate{Tx: tx})
+	}
+
+	return candidates
+}
+
+func TestParseParentSelector(t *testing.T) {
+	for name, expected := range map[string]ParentSelector{
+		ParentsDeepest:       DeepestParents{},
+		ParentsUniform:       UniformParents{},
+		ParentsDepthWeighted: DepthWeightedParents{},
+		ParentsLowConflict:   LowConflictParents{},
+	} {
+		selector, err := ParseParentSelector(name)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, selector)
+	}
+
+	_, err := ParseParentSelector("oldest")
+	assert.Error(t, err)
+}
+
+func TestDeepestParents(t *testing.T) {
+	candidates := parentCandidates(9, 8, 7, 6)
+
+	parents := DeepestParents{}.SelectParents(candidates, 2)
+	assert.Equal(t, []*Transaction{candidates[0].Tx, candidates[1].Tx}, parents)
+
+	assert.Len(t, DeepestParents{}.SelectParents(candidates, 8), 4)
+	assert.Nil(t, DeepestParents{}.SelectParents(nil, 8))
+}
+
+func TestRandomParents(t *testing.T) {
+	candidates := parentCandidates(20, 19, 18, 17, 16, 15, 14, 13, 12, 11)
+
+	for _, selector := range []ParentSelector{UniformParents{}, DepthWeightedParents{}} {
+		for i := 0; i < 100; i++ {
+			parents := selector.SelectParents(candidates, 4)
+			assert.Len(t, parents, 4)
+
+			seen := make(map[TransactionID]struct{})
+
+			for _, parent := range parents {
+				_, duplicate := seen[parent.ID]
+				assert.False(t, duplicate)
+
+				seen[parent.ID] = struct{}{}
+			}
+		}
+
+		assert.Len(t, selector.SelectParents(candidates, len(candidates)+1), len(candidates))
+	}
+}
+
+func TestDepthWeightedParentsFavorsDepth(t *testing.T) {
+	candidates := parentCandidates(100, 1)
+
+	deepest := 0
+
+	for i := 0; i < 1000; i++ {
+		if (DepthWeightedParents{}).SelectParents(candidates, 1)[0] == candidates[0].Tx {
+			deepest++
+		}
+	}
+
+	assert.True(t, deepest > 900)
+}
+
+func TestLowConflictParents(t *testing.T) {
+	candidates := parentCandidates(9, 8, 7, 6)
+	candidates[0].Conflicts = 2
+	candidates[2].Conflicts = 1
+
+	parents := LowConflictParents{}.SelectParents(candidates, 3)
+	assert.Equal(t, []*Transaction{candidates[1].Tx, candidates[3].Tx, candidates[2].Tx}, parents)
+
+	// The candidates given must be left untouched.
+	assert.Equal(t, 2, candidates[0].Conflicts)
+}
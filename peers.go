@@ -0,0 +1,383 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/perlin-network/noise"
+	"github.com/perlin-network/noise/skademlia"
+	"github.com/perlin-network/wavelet/avl"
+	"github.com/perlin-network/wavelet/sys"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+const (
+	// Metadata key under which a nodes version is attached to all of its RPCs.
+	KeyVersion = "wavelet-version"
+
+	// Metadata key under which a nodes local time, in nanoseconds since the Unix
+	// epoch, is attached to all of its RPCs and to responses to queries.
+	KeyTime = "wavelet-time"
+
+	// How long after a peer last sent us an RPC that we consider it to still
+	// be an inbound peer.
+	InboundPeerTTL = 1 * time.Minute
+)
+
+// PeerInfo describes what we have most recently witnessed about a single peer.
+type PeerInfo struct {
+	ID      AccountID
+	Address string
+	Version string
+
+	LastSeen   time.Time
+	LastGossip time.Time
+
+	Latency time.Duration // Exponentially-weighted moving average of query latencies.
+
+	ClockOffset  time.Duration // Exponentially-weighted moving average of how far ahead the peers clock is of ours.
+	ClockSampled time.Time
+}
+
+// Peers keeps track of information about peers which have either sent us RPCs,
+// or which we have sent RPCs to.
+type Peers struct {
+	sync.RWMutex
+
+	peers  map[AccountID]*PeerInfo
+	banned map[AccountID]struct{}
+}
+
+func NewPeers() *Peers {
+	return &Peers{peers: make(map[AccountID]*PeerInfo), banned: make(map[AccountID]struct{})}
+}
+
+// VersionDialOptions returns gRPC dial options that attach our nodes version
+// and local time to every outgoing RPC and stream, such that our peers may
+// record them.
+func VersionDialOptions() []grpc.DialOption {
+	unary := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return invoker(metadata.AppendToOutgoingContext(ctx, KeyVersion, sys.Version, KeyTime, now()), method, req, reply, cc, opts...)
+	}
+
+	stream := func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return streamer(metadata.AppendToOutgoingContext(ctx, KeyVersion, sys.Version, KeyTime, now()), desc, cc, method, opts...)
+	}
+
+	return []grpc.DialOption{grpc.WithUnaryInterceptor(unary), grpc.WithStreamInterceptor(stream)}
+}
+
+// ClockHeader returns metadata carrying our local time.
+func ClockHeader() metadata.MD {
+	return metadata.Pairs(KeyTime, now())
+}
+
+// ClockOffsetFromHeader returns how far ahead a peers clock is of ours, given
+// the time it reported in md, and the times at which we sent our request and
+// received its response. The peer is assumed to have reported its time halfway
+// through the round trip.
+func ClockOffsetFromHeader(md metadata.MD, sent, received time.Time) (time.Duration, bool) {
+	times := md.Get(KeyTime)
+	if len(times) == 0 {
+		return 0, false
+	}
+
+	nanos, err := strconv.ParseInt(times[0], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+
+	midpoint := sent.Add(received.Sub(sent) / 2)
+
+	return time.Unix(0, nanos).Sub(midpoint), true
+}
+
+func now() string {
+	return strconv.FormatInt(time.Now().UnixNano(), 10)
+}
+
+// Seen records that a peer has sent us an RPC, alongside the version the peer
+// has attached to it, should there be one. It returns the ID of the peer.
+func (p *Peers) Seen(ctx context.Context) (*skademlia.ID, bool) {
+	id := peerIDFromContext(ctx)
+	if id == nil {
+		return nil, false
+	}
+
+	info := p.load(id)
+
+	p.Lock()
+	info.LastSeen = time.Now()
+
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if versions := md.Get(KeyVersion); len(versions) > 0 {
+			info.Version = versions[0]
+		}
+
+		if offset, ok := ClockOffsetFromHeader(md, info.LastSeen, info.LastSeen); ok {
+			info.recordClock(offset)
+		}
+	}
+	p.Unlock()
+
+	return id, true
+}
+
+// Gossiped records that a peer has just gossiped transactions to us.
+func (p *Peers) Gossiped(id *skademlia.ID) {
+	info := p.load(id)
+
+	p.Lock()
+	info.LastGossip = time.Now()
+	p.Unlock()
+}
+
+// RecordLatency records how long it took for a peer to respond to one of our
+// queries.
+func (p *Peers) RecordLatency(id *skademlia.ID, latency time.Duration) {
+	info := p.load(id)
+
+	p.Lock()
+	if info.Latency == 0 {
+		info.Latency = latency
+	} else {
+		info.Latency = (4*info.Latency + latency) / 5
+	}
+	p.Unlock()
+}
+
+// RecordClock records how far ahead a peers clock is of ours.
+func (p *Peers) RecordClock(id *skademlia.ID, offset time.Duration) {
+	info := p.load(id)
+
+	p.Lock()
+	info.recordClock(offset)
+	p.Unlock()
+}
+
+// ClockSkew returns the median of how far ahead the clocks of peers sampled
+// within the last InboundPeerTTL are of ours, alongside the number of peers
+// sampled. A large skew in either direction suggests that our own clock is off.
+func (p *Peers) ClockSkew() (time.Duration, int) {
+	p.RLock()
+	defer p.RUnlock()
+
+	var offsets []time.Duration
+
+	for _, info := range p.peers {
+		if !info.ClockSampled.IsZero() && time.Since(info.ClockSampled) < InboundPeerTTL {
+			offsets = append(offsets, info.ClockOffset)
+		}
+	}
+
+	if len(offsets) == 0 {
+		return 0, 0
+	}
+
+	sort.Slice(offsets, func(i, j int) bool {
+		return offsets[i] < offsets[j]
+	})
+
+	if len(offsets)%2 == 0 {
+		return (offsets[len(offsets)/2-1] + offsets[len(offsets)/2]) / 2, len(offsets)
+	}
+
+	return offsets[len(offsets)/2], len(offsets)
+}
+
+// Get returns a copy of what we know about a peer given its public key.
+func (p *Peers) Get(id AccountID) (PeerInfo, bool) {
+	p.RLock()
+	defer p.RUnlock()
+
+	info, exists := p.peers[id]
+	if !exists {
+		return PeerInfo{}, false
+	}
+
+	return *info, true
+}
+
+// Inbound returns a copy of all peers that have sent us an RPC within the
+// last InboundPeerTTL, sorted by their public keys.
+func (p *Peers) Inbound() []PeerInfo {
+	p.RLock()
+	defer p.RUnlock()
+
+	var inbound []PeerInfo
+
+	for _, info := range p.peers {
+		if time.Since(info.LastSeen) < InboundPeerTTL {
+			inbound = append(inbound, *info)
+		}
+	}
+
+	sort.Slice(inbound, func(i, j int) bool {
+		return string(inbound[i].ID[:]) < string(inbound[j].ID[:])
+	})
+
+	return inbound
+}
+
+// Ban has us neither accept RPCs from, nor send RPCs to, a peer.
+func (p *Peers) Ban(id AccountID) {
+	p.Lock()
+	p.banned[id] = struct{}{}
+	p.Unlock()
+}
+
+// Unban lifts the ban placed on a peer, returning false should it not have been banned.
+func (p *Peers) Unban(id AccountID) bool {
+	p.Lock()
+	defer p.Unlock()
+
+	_, banned := p.banned[id]
+	delete(p.banned, id)
+
+	return banned
+}
+
+func (p *Peers) IsBanned(id AccountID) bool {
+	p.RLock()
+	_, banned := p.banned[id]
+	p.RUnlock()
+
+	return banned
+}
+
+// Banned returns the public keys of all banned peers, sorted.
+func (p *Peers) Banned() []AccountID {
+	p.RLock()
+	defer p.RUnlock()
+
+	banned := make([]AccountID, 0, len(p.banned))
+
+	for id := range p.banned {
+		banned = append(banned, id)
+	}
+
+	sort.Slice(banned, func(i, j int) bool {
+		return string(banned[i][:]) < string(banned[j][:])
+	})
+
+	return banned
+}
+
+// Closest dials the peers closest to us in the routing table of client, skipping
+// those that are banned.
+func (p *Peers) Closest(client *skademlia.Client) []*grpc.ClientConn {
+	var conns []*grpc.ClientConn
+
+	for _, id := range client.ClosestPeerIDs() {
+		if p.IsBanned(id.PublicKey()) {
+			continue
+		}
+
+		if conn, err := client.Dial(id.Address()); err == nil {
+			conns = append(conns, conn)
+		}
+	}
+
+	return conns
+}
+
+// Candidates dials the peers closest to us in the routing table of client like
+// Closest, describing each of them by their stake as of snapshot, and by how
+// quickly they have responded to our queries.
+func (p *Peers) Candidates(client *skademlia.Client, snapshot *avl.Tree) []PeerCandidate {
+	var candidates []PeerCandidate
+
+	for _, id := range client.ClosestPeerIDs() {
+		publicKey := id.PublicKey()
+
+		if p.IsBanned(publicKey) {
+			continue
+		}
+
+		conn, err := client.Dial(id.Address())
+		if err != nil {
+			continue
+		}
+
+		candidate := PeerCandidate{Conn: conn, ID: publicKey}
+		candidate.Stake, _ = ReadAccountStake(snapshot, publicKey)
+
+		if info, exists := p.Get(publicKey); exists {
+			candidate.Latency = info.Latency
+		}
+
+		candidates = append(candidates, candidate)
+	}
+
+	return candidates
+}
+
+func (p *Peers) load(id *skademlia.ID) *PeerInfo {
+	publicKey := id.PublicKey()
+
+	p.Lock()
+	defer p.Unlock()
+
+	info, exists := p.peers[publicKey]
+	if !exists {
+		info = &PeerInfo{ID: publicKey}
+		p.peers[publicKey] = info
+	}
+
+	info.Address = id.Address()
+
+	return info
+}
+
+func (info *PeerInfo) recordClock(offset time.Duration) {
+	if info.ClockSampled.IsZero() {
+		info.ClockOffset = offset
+	} else {
+		info.ClockOffset = (4*info.ClockOffset + offset) / 5
+	}
+
+	info.ClockSampled = time.Now()
+}
+
+func peerIDFromContext(ctx context.Context) *skademlia.ID {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil
+	}
+
+	info := noise.InfoFromPeer(p)
+	if info == nil {
+		return nil
+	}
+
+	id, ok := info.Get(skademlia.KeyID).(*skademlia.ID)
+	if !ok {
+		return nil
+	}
+
+	return id
+}
@@ -0,0 +1,91 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/perlin-network/noise/skademlia"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestPeersRecordLatency(t *testing.T) {
+	t.Parallel()
+
+	keys, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	id := keys.ID("127.0.0.1:3000")
+
+	peers := NewPeers()
+
+	_, exists := peers.Get(id.PublicKey())
+	assert.False(t, exists)
+
+	peers.RecordLatency(id, 100*time.Millisecond)
+	peers.RecordLatency(id, 200*time.Millisecond)
+	peers.Gossiped(id)
+
+	info, exists := peers.Get(id.PublicKey())
+	assert.True(t, exists)
+
+	assert.Equal(t, "127.0.0.1:3000", info.Address)
+	assert.Equal(t, 120*time.Millisecond, info.Latency)
+	assert.False(t, info.LastGossip.IsZero())
+
+	// Peers that have never sent us an RPC are not inbound.
+	assert.Empty(t, peers.Inbound())
+}
+
+func TestPeersClockSkew(t *testing.T) {
+	t.Parallel()
+
+	peers := NewPeers()
+
+	skew, sampled := peers.ClockSkew()
+	assert.Zero(t, skew)
+	assert.Zero(t, sampled)
+
+	for _, offset := range []time.Duration{-1 * time.Second, 4 * time.Second, 5 * time.Second} {
+		keys, err := skademlia.NewKeys(1, 1)
+		assert.NoError(t, err)
+
+		peers.RecordClock(keys.ID("127.0.0.1:3000"), offset)
+	}
+
+	skew, sampled = peers.ClockSkew()
+	assert.Equal(t, 4*time.Second, skew)
+	assert.Equal(t, 3, sampled)
+
+	sent := time.Now()
+	received := sent.Add(2 * time.Second)
+
+	header := metadata.Pairs(KeyTime, strconv.FormatInt(sent.Add(11*time.Second).UnixNano(), 10))
+
+	offset, ok := ClockOffsetFromHeader(header, sent, received)
+	assert.True(t, ok)
+	assert.Equal(t, 10*time.Second, offset)
+
+	_, ok = ClockOffsetFromHeader(metadata.MD{}, sent, received)
+	assert.False(t, ok)
+}
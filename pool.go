@@ -20,11 +20,18 @@
 package wavelet
 
 import (
-	"github.com/phf/go-queue/queue"
+	"bytes"
 	"sync"
+
+	"github.com/phf/go-queue/queue"
 )
 
 var queuePool sync.Pool
+var bufferPool sync.Pool
+
+// maxPooledBufferSize is the largest capacity a buffer may have to be put back
+// into the pool, such that buffers grown by large payloads are not retained.
+const maxPooledBufferSize = 64 * 1024
 
 func AcquireQueue() *queue.Queue {
 	q := queuePool.Get()
@@ -40,3 +47,24 @@ func ReleaseQueue(q *queue.Queue) {
 	q.Init()
 	queuePool.Put(q)
 }
+
+// AcquireBuffer returns an empty buffer from the pool. The buffer must be
+// released with ReleaseBuffer once its contents are no longer referenced.
+func AcquireBuffer() *bytes.Buffer {
+	buf := bufferPool.Get()
+
+	if buf == nil {
+		buf = new(bytes.Buffer)
+	}
+
+	return buf.(*bytes.Buffer)
+}
+
+func ReleaseBuffer(buf *bytes.Buffer) {
+	if buf.Cap() > maxPooledBufferSize {
+		return
+	}
+
+	buf.Reset()
+	bufferPool.Put(buf)
+}
@@ -23,17 +23,53 @@ import (
 	"bytes"
 	"context"
 	"fmt"
-	"github.com/perlin-network/wavelet/log"
+	"github.com/perlin-network/noise/skademlia"
 	"github.com/perlin-network/wavelet/sys"
 	"github.com/pkg/errors"
 	"golang.org/x/crypto/blake2b"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
+// errFollowerVote is returned to peers that query a follower or observer node
+// for its vote, so that such nodes never influence consensus.
+var errFollowerVote = status.Error(codes.Unavailable, "nodes that are not validators do not vote")
+
+// errNotAuthority is returned to peers that query a node which is not on the
+// allowlist of validators of a network weighing votes by authority.
+var errNotAuthority = status.Error(codes.PermissionDenied, "nodes that are not on the allowlist of validators do not vote")
+
+// errGraphFull is returned to peers gossiping transactions to a node whose
+// graph is full, such that they back off from gossiping to the node.
+var errGraphFull = status.Error(codes.ResourceExhausted, ErrGraphFull.Error())
+
+// errBanned is returned to peers which have been banned from sending us RPCs.
+var errBanned = status.Error(codes.PermissionDenied, "peer has been banned")
+
 type Protocol struct {
 	ledger *Ledger
 }
 
+// admit records that a peer has sent us an RPC, returning errBanned should the
+// peer be banned.
+func (p *Protocol) admit(ctx context.Context) (*skademlia.ID, error) {
+	if id := peerIDFromContext(ctx); id != nil && p.ledger.peers.IsBanned(id.PublicKey()) {
+		return nil, errBanned
+	}
+
+	id, _ := p.ledger.peers.Seen(ctx)
+
+	return id, nil
+}
+
 func (p *Protocol) Gossip(stream Wavelet_GossipServer) error {
+	id, err := p.admit(stream.Context())
+	if err != nil {
+		return err
+	}
+
 	for {
 		batch, err := stream.Recv()
 
@@ -41,16 +77,26 @@ func (p *Protocol) Gossip(stream Wavelet_GossipServer) error {
 			return err
 		}
 
+		if id != nil {
+			p.ledger.peers.Gossiped(id)
+		}
+
 		for _, buf := range batch.Transactions {
 			tx, err := UnmarshalTransaction(bytes.NewReader(buf))
 
 			if err != nil {
-				logger := log.TX("gossip")
+				logger := p.ledger.logs.TX("gossip")
 				logger.Err(err).Msg("Failed to unmarshal transaction")
 				continue
 			}
 
-			if err := p.ledger.AddTransaction(tx); err != nil && errors.Cause(err) != ErrMissingParents {
+			err = p.ledger.AddTransaction(tx)
+
+			if errors.Cause(err) == ErrGraphFull {
+				return errGraphFull
+			}
+
+			if err != nil && errors.Cause(err) != ErrMissingParents && errors.Cause(err) != ErrQueueFull {
 				fmt.Printf("error adding incoming tx to graph [%v]: %+v\n", err, tx)
 			}
 		}
@@ -58,26 +104,67 @@ func (p *Protocol) Gossip(stream Wavelet_GossipServer) error {
 }
 
 func (p *Protocol) Query(ctx context.Context, req *QueryRequest) (*QueryResponse, error) {
+	if _, err := p.admit(ctx); err != nil {
+		return nil, err
+	}
+
+	_ = grpc.SetHeader(ctx, ClockHeader())
+
+	if !p.ledger.mode.Participates() {
+		return nil, errFollowerVote
+	}
+
+	if _, eligible := p.ledger.weighting.WeighAccount(p.ledger.accounts, p.ledger.client.Keys().PublicKey(), p.ledger.Param(sys.ParamMinimumStake)); !eligible {
+		return nil, errNotAuthority
+	}
+
 	res := &QueryResponse{}
 
 	round, err := p.ledger.rounds.GetByIndex(req.RoundIndex)
 
-	if err == nil {
-		res.Round = round.Marshal()
-		return res, nil
+	if err != nil {
+		round = p.ledger.finalizer.Preferred()
 	}
 
-	preferred := p.ledger.finalizer.Preferred()
+	if round != nil {
+		cached := p.cachedVote(round)
 
-	if preferred != nil {
-		res.Round = preferred.Marshal()
-		return res, nil
+		res.Round = cached.round
+		_ = grpc.SetHeader(ctx, cached.header)
 	}
 
 	return res, nil
 }
 
+type cachedVote struct {
+	round  []byte
+	header metadata.MD
+}
+
+// cachedVote returns our marshaled vote for a round, alongside the header which
+// carries our signature over it such that the querier may include our vote in
+// the rounds certificate. Votes are cached by round ID, so that we neither
+// re-sign nor re-marshal a round whenever a peer queries us again for it.
+func (p *Protocol) cachedVote(round *Round) cachedVote {
+	if cached, ok := p.ledger.cacheVotes.load(round.ID); ok {
+		return cached.(cachedVote)
+	}
+
+	vote := cachedVote{
+		round:  round.Marshal(),
+		header: p.ledger.blsKey.header(round.ID),
+	}
+
+	p.ledger.cacheVotes.put(round.ID, vote)
+
+	return vote
+}
+
 func (p *Protocol) Sync(stream Wavelet_SyncServer) error {
+	if _, err := p.admit(stream.Context()); err != nil {
+		return err
+	}
+
 	req, err := stream.Recv()
 	if err != nil {
 		return err
@@ -122,7 +209,7 @@ func (p *Protocol) Sync(stream Wavelet_SyncServer) error {
 		if chunk, found := p.ledger.cacheChunks.load(checksum); found {
 			chunk := chunk.([]byte)
 
-			logger := log.Sync("provide_chunk")
+			logger := p.ledger.logs.Sync("provide_chunk")
 			logger.Info().
 				Hex("requested_hash", req.GetChecksum()).
 				Msg("Responded to sync chunk request.")
@@ -138,11 +225,25 @@ func (p *Protocol) Sync(stream Wavelet_SyncServer) error {
 	}
 }
 
-func (p *Protocol) CheckOutOfSync(context.Context, *OutOfSyncRequest) (*OutOfSyncResponse, error) {
+func (p *Protocol) CheckOutOfSync(ctx context.Context, req *OutOfSyncRequest) (*OutOfSyncResponse, error) {
+	if _, err := p.admit(ctx); err != nil {
+		return nil, err
+	}
+
+	_ = grpc.SetHeader(ctx, ClockHeader())
+
+	if !p.ledger.mode.Participates() {
+		return nil, errFollowerVote
+	}
+
 	return &OutOfSyncResponse{Round: p.ledger.rounds.Latest().Marshal()}, nil
 }
 
 func (p *Protocol) DownloadTx(ctx context.Context, req *DownloadTxRequest) (*DownloadTxResponse, error) {
+	if _, err := p.admit(ctx); err != nil {
+		return nil, err
+	}
+
 	res := &DownloadTxResponse{Transactions: make([][]byte, 0, len(req.Ids))}
 
 	for _, buf := range req.Ids {
@@ -0,0 +1,239 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+
+	"github.com/perlin-network/wavelet/avl"
+	"github.com/pkg/errors"
+)
+
+// Guardians are the accounts designated by an account to approve the rotation
+// of its key should it ever be lost. Once Threshold guardians approve of the
+// same new key, the accounts balance, stake and rewards may be moved over to
+// the new key after Delay rounds.
+type Guardians struct {
+	Threshold uint8
+	Delay     uint64
+	Accounts  []AccountID
+}
+
+func (g Guardians) Contains(id AccountID) bool {
+	for _, guardian := range g.Accounts {
+		if guardian == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (g Guardians) Marshal() []byte {
+	var w bytes.Buffer
+
+	w.WriteByte(g.Threshold)
+
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], g.Delay)
+	w.Write(buf[:])
+
+	w.WriteByte(byte(len(g.Accounts)))
+
+	for _, id := range g.Accounts {
+		w.Write(id[:])
+	}
+
+	return w.Bytes()
+}
+
+func UnmarshalGuardians(r io.Reader) (g Guardians, err error) {
+	var buf [8]byte
+
+	if _, err = io.ReadFull(r, buf[:1]); err != nil {
+		err = errors.Wrap(err, "failed to decode guardian threshold")
+		return
+	}
+
+	g.Threshold = buf[0]
+
+	if _, err = io.ReadFull(r, buf[:]); err != nil {
+		err = errors.Wrap(err, "failed to decode recovery delay")
+		return
+	}
+
+	g.Delay = binary.BigEndian.Uint64(buf[:])
+
+	if _, err = io.ReadFull(r, buf[:1]); err != nil {
+		err = errors.Wrap(err, "failed to decode number of guardians")
+		return
+	}
+
+	g.Accounts = make([]AccountID, buf[0])
+
+	for i := range g.Accounts {
+		if _, err = io.ReadFull(r, g.Accounts[i][:]); err != nil {
+			err = errors.Wrap(err, "failed to decode guardian")
+			return
+		}
+	}
+
+	return
+}
+
+// PendingRecovery is the rotation of an accounts key to NewKey that its
+// guardians are in the midst of approving. Once enough guardians have approved,
+// the recovery is marked ready as of the round ReadyRound.
+type PendingRecovery struct {
+	NewKey    AccountID
+	Approvals []AccountID
+
+	Ready      bool
+	ReadyRound uint64
+}
+
+func (p PendingRecovery) Marshal() []byte {
+	var w bytes.Buffer
+
+	w.Write(p.NewKey[:])
+
+	w.WriteByte(byte(len(p.Approvals)))
+
+	for _, id := range p.Approvals {
+		w.Write(id[:])
+	}
+
+	if p.Ready {
+		w.WriteByte(1)
+	} else {
+		w.WriteByte(0)
+	}
+
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], p.ReadyRound)
+	w.Write(buf[:])
+
+	return w.Bytes()
+}
+
+func UnmarshalPendingRecovery(r io.Reader) (p PendingRecovery, err error) {
+	if _, err = io.ReadFull(r, p.NewKey[:]); err != nil {
+		err = errors.Wrap(err, "failed to decode new key")
+		return
+	}
+
+	var buf [8]byte
+
+	if _, err = io.ReadFull(r, buf[:1]); err != nil {
+		err = errors.Wrap(err, "failed to decode number of approvals")
+		return
+	}
+
+	p.Approvals = make([]AccountID, buf[0])
+
+	for i := range p.Approvals {
+		if _, err = io.ReadFull(r, p.Approvals[i][:]); err != nil {
+			err = errors.Wrap(err, "failed to decode approval")
+			return
+		}
+	}
+
+	if _, err = io.ReadFull(r, buf[:1]); err != nil {
+		err = errors.Wrap(err, "failed to decode whether recovery is ready")
+		return
+	}
+
+	p.Ready = buf[0] == 1
+
+	if _, err = io.ReadFull(r, buf[:]); err != nil {
+		err = errors.Wrap(err, "failed to decode round recovery became ready")
+		return
+	}
+
+	p.ReadyRound = binary.BigEndian.Uint64(buf[:])
+
+	return
+}
+
+func ReadAccountGuardians(tree *avl.Tree, id AccountID) (Guardians, bool) {
+	buf, exists := readUnderAccounts(tree, id, keyAccountGuardians[:])
+	if !exists || len(buf) == 0 {
+		return Guardians{}, false
+	}
+
+	guardians, err := UnmarshalGuardians(bytes.NewReader(buf))
+	if err != nil {
+		return Guardians{}, false
+	}
+
+	return guardians, true
+}
+
+func WriteAccountGuardians(tree *avl.Tree, id AccountID, guardians Guardians) {
+	if len(guardians.Accounts) == 0 {
+		deleteUnderAccounts(tree, id, keyAccountGuardians[:])
+		return
+	}
+
+	writeUnderAccounts(tree, id, keyAccountGuardians[:], guardians.Marshal())
+}
+
+func ReadAccountRecovery(tree *avl.Tree, id AccountID) (PendingRecovery, bool) {
+	buf, exists := readUnderAccounts(tree, id, keyAccountRecovery[:])
+	if !exists || len(buf) == 0 {
+		return PendingRecovery{}, false
+	}
+
+	recovery, err := UnmarshalPendingRecovery(bytes.NewReader(buf))
+	if err != nil {
+		return PendingRecovery{}, false
+	}
+
+	return recovery, true
+}
+
+func WriteAccountRecovery(tree *avl.Tree, id AccountID, recovery PendingRecovery) {
+	writeUnderAccounts(tree, id, keyAccountRecovery[:], recovery.Marshal())
+}
+
+func DeleteAccountRecovery(tree *avl.Tree, id AccountID) {
+	deleteUnderAccounts(tree, id, keyAccountRecovery[:])
+}
+
+// ReadAccountRecoveredTo returns the new key an account has been recovered to,
+// should the account have been recovered.
+func ReadAccountRecoveredTo(tree *avl.Tree, id AccountID) (AccountID, bool) {
+	var newKey AccountID
+
+	buf, exists := readUnderAccounts(tree, id, keyAccountRecoveredTo[:])
+	if !exists || len(buf) != SizeAccountID {
+		return newKey, false
+	}
+
+	copy(newKey[:], buf)
+
+	return newKey, true
+}
+
+func WriteAccountRecoveredTo(tree *avl.Tree, id AccountID, newKey AccountID) {
+	writeUnderAccounts(tree, id, keyAccountRecoveredTo[:], newKey[:])
+}
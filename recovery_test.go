@@ -0,0 +1,155 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/perlin-network/wavelet/avl"
+	"github.com/perlin-network/wavelet/store"
+	"github.com/perlin-network/wavelet/sys"
+	"github.com/stretchr/testify/assert"
+)
+
+func setGuardiansPayload(threshold uint8, delay uint64, guardians ...AccountID) []byte {
+	payload := []byte{sys.SetGuardians, threshold}
+	payload = append(payload, make([]byte, 8)...)
+	binary.LittleEndian.PutUint64(payload[2:], delay)
+	payload = append(payload, uint8(len(guardians)))
+
+	for _, id := range guardians {
+		payload = append(payload, id[:]...)
+	}
+
+	return payload
+}
+
+func approveRecoveryPayload(account, newKey AccountID) []byte {
+	payload := []byte{sys.ApproveRecovery}
+	payload = append(payload, account[:]...)
+	return append(payload, newKey[:]...)
+}
+
+func executeRecoveryPayload(account AccountID) []byte {
+	return append([]byte{sys.ExecuteRecovery}, account[:]...)
+}
+
+func TestParseRecoveryTransaction(t *testing.T) {
+	alice, bob := AccountID{1}, AccountID{2}
+
+	params, err := ParseRecoveryTransaction(setGuardiansPayload(2, 10, alice, bob))
+	assert.NoError(t, err)
+	assert.EqualValues(t, sys.SetGuardians, params.Opcode)
+	assert.EqualValues(t, 2, params.Threshold)
+	assert.EqualValues(t, 10, params.Delay)
+	assert.Equal(t, []AccountID{alice, bob}, params.Guardians)
+
+	params, err = ParseRecoveryTransaction(approveRecoveryPayload(alice, bob))
+	assert.NoError(t, err)
+	assert.Equal(t, alice, params.Account)
+	assert.Equal(t, bob, params.NewKey)
+
+	_, err = ParseRecoveryTransaction(setGuardiansPayload(3, 10, alice, bob))
+	assert.Error(t, err, "threshold may not exceed the number of guardians")
+
+	_, err = ParseRecoveryTransaction(append(executeRecoveryPayload(alice), 0))
+	assert.Error(t, err, "trailing bytes must be rejected")
+
+	_, err = ParseRecoveryTransaction([]byte{0xff})
+	assert.Error(t, err)
+}
+
+func TestApplyRecoveryTransaction(t *testing.T) {
+	tree := avl.New(store.NewInmem())
+
+	lost, fresh := AccountID{1}, AccountID{2}
+	g1, g2, g3 := AccountID{3}, AccountID{4}, AccountID{5}
+
+	WriteAccountBalance(tree, lost, 100)
+	WriteAccountStake(tree, lost, 50)
+
+	apply := func(creator AccountID, index uint64, payload []byte) error {
+		tx := &Transaction{Creator: creator, Sender: creator, Tag: sys.TagRecovery, Payload: payload}
+		_, err := ApplyRecoveryTransaction(tree, &Round{Index: index}, tx)
+		return err
+	}
+
+	assert.NoError(t, apply(lost, 1, setGuardiansPayload(2, 5, g1, g2, g3)))
+
+	assert.Error(t, apply(fresh, 2, approveRecoveryPayload(lost, fresh)), "non-guardians may not approve")
+	assert.NoError(t, apply(g1, 2, approveRecoveryPayload(lost, fresh)))
+	assert.Error(t, apply(g1, 2, approveRecoveryPayload(lost, fresh)), "guardians may not approve twice")
+
+	assert.Error(t, apply(fresh, 3, executeRecoveryPayload(lost)), "recovery requires threshold approvals")
+
+	assert.NoError(t, apply(g2, 3, approveRecoveryPayload(lost, fresh)))
+
+	recovery, pending := ReadAccountRecovery(tree, lost)
+	assert.True(t, pending)
+	assert.True(t, recovery.Ready)
+	assert.EqualValues(t, 3, recovery.ReadyRound)
+
+	assert.Error(t, apply(g3, 4, approveRecoveryPayload(lost, AccountID{6})), "an approved recovery may not be redirected")
+	assert.Error(t, apply(fresh, 7, executeRecoveryPayload(lost)), "recovery must wait out the delay")
+
+	assert.NoError(t, apply(fresh, 8, executeRecoveryPayload(lost)))
+
+	balance, _ := ReadAccountBalance(tree, fresh)
+	assert.EqualValues(t, 100, balance)
+
+	stake, _ := ReadAccountStake(tree, fresh)
+	assert.EqualValues(t, 50, stake)
+
+	balance, _ = ReadAccountBalance(tree, lost)
+	assert.EqualValues(t, 0, balance)
+
+	recoveredTo, recovered := ReadAccountRecoveredTo(tree, lost)
+	assert.True(t, recovered)
+	assert.Equal(t, fresh, recoveredTo)
+
+	_, pending = ReadAccountRecovery(tree, lost)
+	assert.False(t, pending)
+
+	guardians, exists := ReadAccountGuardians(tree, fresh)
+	assert.True(t, exists)
+	assert.True(t, guardians.Contains(g3))
+}
+
+func TestCancelRecovery(t *testing.T) {
+	tree := avl.New(store.NewInmem())
+
+	owner, guardian := AccountID{1}, AccountID{2}
+
+	apply := func(creator AccountID, payload []byte) error {
+		tx := &Transaction{Creator: creator, Sender: creator, Tag: sys.TagRecovery, Payload: payload}
+		_, err := ApplyRecoveryTransaction(tree, &Round{Index: 1}, tx)
+		return err
+	}
+
+	assert.Error(t, apply(owner, []byte{sys.CancelRecovery}), "nothing to cancel")
+
+	assert.NoError(t, apply(owner, setGuardiansPayload(1, 10, guardian)))
+	assert.NoError(t, apply(guardian, approveRecoveryPayload(owner, AccountID{3})))
+	assert.NoError(t, apply(owner, []byte{sys.CancelRecovery}))
+
+	_, pending := ReadAccountRecovery(tree, owner)
+	assert.False(t, pending)
+}
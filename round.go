@@ -71,8 +71,8 @@ func (r Round) Marshal() []byte {
 	binary.BigEndian.PutUint64(buf[:], r.Applied)
 	w.Write(buf[:8])
 
-	w.Write(r.Start.Marshal())
-	w.Write(r.End.Marshal())
+	r.Start.Write(&w)
+	r.End.Write(&w)
 
 	return w.Bytes()
 }
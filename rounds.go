@@ -20,8 +20,13 @@
 package wavelet
 
 import (
+	"bytes"
+	"encoding/binary"
 	"fmt"
 	"github.com/perlin-network/wavelet/store"
+	"github.com/pkg/errors"
+	"sort"
+	"strconv"
 	"sync"
 )
 
@@ -120,3 +125,169 @@ func (r *Rounds) GetByIndex(ix uint64) (*Round, error) {
 
 	return round, nil
 }
+
+// GetByDepth returns the round whose graph depth interval covers the given depth. Only
+// rounds which have not yet been pruned may be looked up.
+func (r *Rounds) GetByDepth(depth uint64) (*Round, error) {
+	var round *Round
+
+	r.RLock()
+	for _, r := range r.buffer {
+		if depth == r.End.Depth || (r.Start.Depth < depth && depth < r.End.Depth) {
+			round = r
+			break
+		}
+	}
+	r.RUnlock()
+
+	if round == nil {
+		return nil, fmt.Errorf("no round found for depth - %d", depth)
+	}
+
+	return round, nil
+}
+
+// RepairRounds verifies that the rounds held in kv are consistent with the
+// indices tracking the oldest and latest amongst them. It returns a description
+// of every inconsistency found, which are all repaired unless dryRun is set.
+// Rounds missing from kv are reported, but may not be repaired.
+func RepairRounds(kv store.KV, dryRun bool) ([]string, error) {
+	var issues []string
+	var malformed [][]byte
+
+	positions := make(map[int]Round)
+
+	err := kv.IteratePrefix(keyRounds[:], func(key, value []byte) bool {
+		pos, err := strconv.Atoi(string(key[len(keyRounds):]))
+		if err != nil || pos < 0 {
+			issues = append(issues, fmt.Sprintf("round key %x is malformed", key))
+			malformed = append(malformed, append([]byte{}, key...))
+
+			return true
+		}
+
+		round, err := UnmarshalRound(bytes.NewReader(value))
+		if err != nil {
+			issues = append(issues, fmt.Sprintf("round at position %d could not be decoded: %v", pos, err))
+			malformed = append(malformed, append([]byte{}, key...))
+
+			return true
+		}
+
+		positions[pos] = round
+		return true
+	})
+
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to iterate through rounds")
+	}
+
+	if !dryRun {
+		for _, key := range malformed {
+			if err := kv.Delete(key); err != nil {
+				return nil, errors.Wrapf(err, "failed to delete round key %x", key)
+			}
+		}
+	}
+
+	if len(positions) == 0 {
+		return append(issues, "no rounds are stored"), nil
+	}
+
+	if len(positions) > 255 {
+		return append(issues, fmt.Sprintf("%d rounds are stored, which is more than may be tracked", len(positions))), nil
+	}
+
+	rounds := make([]Round, 0, len(positions))
+	contiguous := true
+
+	for pos, round := range positions {
+		if pos >= len(positions) {
+			contiguous = false
+		}
+
+		rounds = append(rounds, round)
+	}
+
+	sort.Slice(rounds, func(i, j int) bool {
+		return rounds[i].Index < rounds[j].Index
+	})
+
+	for i := 1; i < len(rounds); i++ {
+		switch prev, next := rounds[i-1].Index, rounds[i].Index; {
+		case next == prev:
+			issues = append(issues, fmt.Sprintf("round %d is stored more than once", next))
+		case next != prev+1:
+			issues = append(issues, fmt.Sprintf("rounds between %d and %d are missing", prev, next))
+		}
+	}
+
+	if !contiguous {
+		issues = append(issues, "rounds are not stored at contiguous positions")
+
+		if dryRun {
+			return issues, nil
+		}
+
+		for pos := range positions {
+			if err := kv.Delete(append(keyRounds[:], strconv.Itoa(pos)...)); err != nil {
+				return nil, errors.Wrapf(err, "failed to delete round at position %d", pos)
+			}
+		}
+
+		for i, round := range rounds {
+			if err := StoreRound(kv, round, uint32(i), 0, uint8(i+1)); err != nil {
+				return nil, err
+			}
+		}
+
+		return issues, nil
+	}
+
+	var latest, oldest int
+
+	for pos, round := range positions {
+		if round.Index == rounds[len(rounds)-1].Index {
+			latest = pos
+		}
+
+		if round.Index == rounds[0].Index {
+			oldest = pos
+		}
+	}
+
+	consistent := true
+
+	check := func(name string, key []byte, expected uint32) {
+		buf, err := kv.Get(key)
+
+		switch {
+		case err != nil:
+			issues = append(issues, fmt.Sprintf("%s is missing", name))
+		case len(buf) != 4:
+			issues = append(issues, fmt.Sprintf("%s is malformed", name))
+		case binary.BigEndian.Uint32(buf) != expected:
+			issues = append(issues, fmt.Sprintf("%s is %d, but should be %d", name, binary.BigEndian.Uint32(buf), expected))
+		default:
+			return
+		}
+
+		consistent = false
+	}
+
+	check("latest round position", keyRoundLatestIx[:], uint32(latest))
+	check("oldest round position", keyRoundOldestIx[:], uint32(oldest))
+
+	if buf, err := kv.Get(keyRoundStoredCount[:]); err != nil || len(buf) != 1 || int(buf[0]) != len(positions) {
+		issues = append(issues, fmt.Sprintf("stored round count does not match the %d rounds stored", len(positions)))
+		consistent = false
+	}
+
+	if !consistent && !dryRun {
+		if err := StoreRound(kv, positions[latest], uint32(latest), uint32(oldest), uint8(len(positions))); err != nil {
+			return nil, err
+		}
+	}
+
+	return issues, nil
+}
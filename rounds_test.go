@@ -98,3 +98,55 @@ func TestRoundsCircular(t *testing.T) {
 	assert.Equal(t, uint32(4), newRM.latest)
 	assert.Equal(t, uint32(5), newRM.oldest)
 }
+
+func TestRepairRounds(t *testing.T) {
+	storage := store.NewInmem()
+
+	issues, err := RepairRounds(storage, false)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"no rounds are stored"}, issues)
+
+	rm, _ := NewRounds(storage, 3)
+
+	for i := 0; i < 5; i++ {
+		_, err := rm.Save(&Round{Index: uint64(i + 1)})
+		assert.NoError(t, err)
+	}
+
+	issues, err = RepairRounds(storage, false)
+	assert.NoError(t, err)
+	assert.Empty(t, issues)
+
+	// Corrupt the position of the latest round.
+	assert.NoError(t, storage.Put(keyRoundLatestIx[:], []byte{0, 0, 0, 0}))
+
+	issues, err = RepairRounds(storage, true)
+	assert.NoError(t, err)
+	assert.Len(t, issues, 1)
+
+	issues, err = RepairRounds(storage, false)
+	assert.NoError(t, err)
+	assert.Len(t, issues, 1)
+
+	issues, err = RepairRounds(storage, false)
+	assert.NoError(t, err)
+	assert.Empty(t, issues)
+
+	repaired, err := NewRounds(storage, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(5), repaired.Latest().Index)
+	assert.Equal(t, uint64(3), repaired.Oldest().Index)
+
+	// Rounds stored at non-contiguous positions are renumbered.
+	assert.NoError(t, storage.Put(append(keyRounds[:], "7"...), rm.Latest().Marshal()))
+	assert.NoError(t, storage.Delete(append(keyRounds[:], "1"...)))
+	assert.NoError(t, storage.Put(append(keyRounds[:], "x"...), []byte{}))
+
+	issues, err = RepairRounds(storage, false)
+	assert.NoError(t, err)
+	assert.Len(t, issues, 2)
+
+	repaired, err = NewRounds(storage, 3)
+	assert.NoError(t, err)
+	assert.Len(t, repaired.buffer, 3)
+}
@@ -0,0 +1,173 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+)
+
+// PeerCandidate is a peer that may be sampled to be queried in consensus.
+type PeerCandidate struct {
+	Conn *grpc.ClientConn
+	ID   AccountID
+
+	Stake   uint64        // Stake of the peer as of the latest finalized round.
+	Latency time.Duration // Moving average of the latencies of our queries to the peer. Zero if unknown.
+}
+
+// PeerSampler picks which peers are queried every time a Snowball instance is
+// ticked. It returns an error should there be fewer than amount candidates.
+type PeerSampler interface {
+	Sample(candidates []PeerCandidate, amount int) ([]*grpc.ClientConn, error)
+}
+
+const (
+	SampleUniform = "uniform"
+	SampleStake   = "stake"
+	SampleLatency = "latency"
+)
+
+// ParsePeerSampler returns the peer sampling strategy registered under name.
+func ParsePeerSampler(name string) (PeerSampler, error) {
+	switch name {
+	case SampleUniform:
+		return UniformSampler{}, nil
+	case SampleStake:
+		return StakeSampler{}, nil
+	case SampleLatency:
+		return LatencySampler{}, nil
+	}
+
+	return nil, errors.Errorf("unknown peer sampling strategy %q", name)
+}
+
+// UniformSampler samples peers uniformly at random. It is the default
+// strategy.
+type UniformSampler struct{}
+
+func (UniformSampler) Sample(candidates []PeerCandidate, amount int) ([]*grpc.ClientConn, error) {
+	conns := make([]*grpc.ClientConn, 0, len(candidates))
+
+	for _, candidate := range candidates {
+		conns = append(conns, candidate.Conn)
+	}
+
+	return SelectPeers(conns, amount)
+}
+
+// StakeSampler samples peers at random weighed by their stake, such that the
+// peers whose votes count the most are queried the most often. Peers without
+// any stake may still be sampled.
+type StakeSampler struct{}
+
+func (StakeSampler) Sample(candidates []PeerCandidate, amount int) ([]*grpc.ClientConn, error) {
+	weights := make([]float64, len(candidates))
+
+	for i, candidate := range candidates {
+		weights[i] = float64(candidate.Stake) + 1
+	}
+
+	return sampleWeighted(candidates, weights, amount)
+}
+
+// LatencySampler samples peers at random weighed by how quickly they respond
+// to our queries. Peers we have yet to query are weighed as though they
+// respond as quickly as the average peer.
+type LatencySampler struct{}
+
+func (LatencySampler) Sample(candidates []PeerCandidate, amount int) ([]*grpc.ClientConn, error) {
+	var (
+		total time.Duration
+		known int
+	)
+
+	for _, candidate := range candidates {
+		if candidate.Latency > 0 {
+			total += candidate.Latency
+			known++
+		}
+	}
+
+	average := time.Millisecond
+
+	if known > 0 {
+		average = total / time.Duration(known)
+	}
+
+	weights := make([]float64, len(candidates))
+
+	for i, candidate := range candidates {
+		latency := candidate.Latency
+
+		if latency <= 0 {
+			latency = average
+		}
+
+		weights[i] = 1 / (float64(latency)/float64(time.Millisecond) + 1)
+	}
+
+	return sampleWeighted(candidates, weights, amount)
+}
+
+// sampleWeighted samples amount candidates without replacement, each with a
+// probability proportional to its weight.
+func sampleWeighted(candidates []PeerCandidate, weights []float64, amount int) ([]*grpc.ClientConn, error) {
+	if len(candidates) < amount {
+		conns := make([]*grpc.ClientConn, 0, len(candidates))
+
+		for _, candidate := range candidates {
+			conns = append(conns, candidate.Conn)
+		}
+
+		return conns, errors.Errorf("only connected to %d peer(s), but require a minimum of %d peer(s)", len(candidates), amount)
+	}
+
+	pool := append([]PeerCandidate(nil), candidates...)
+	weights = append([]float64(nil), weights...)
+
+	var total float64
+
+	for _, weight := range weights {
+		total += weight
+	}
+
+	conns := make([]*grpc.ClientConn, 0, amount)
+
+	for len(conns) < amount {
+		pick := rand.Float64() * total
+
+		i := 0
+		for ; i < len(pool)-1 && pick >= weights[i]; i++ {
+			pick -= weights[i]
+		}
+
+		conns = append(conns, pool[i].Conn)
+		total -= weights[i]
+
+		pool = append(pool[:i], pool[i+1:]...)
+		weights = append(weights[:i], weights[i+1:]...)
+	}
+
+	return conns, nil
+}
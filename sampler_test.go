@@ -0,0 +1,116 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+)
+
+func peerCandidates(n int) []PeerCandidate {
+	candidates := make([]PeerCandidate, n)
+
+	for i := range candidates {
+		candidates[i].Conn = new(grpc.ClientConn)
+		candidates[i].ID[0] = byte(i)
+	}
+
+	return candidates
+}
+
+func TestParsePeerSampler(t *testing.T) {
+	for name, expected := range map[string]PeerSampler{
+		SampleUniform: UniformSampler{},
+		SampleStake:   StakeSampler{},
+		SampleLatency: LatencySampler{},
+	} {
+		sampler, err := ParsePeerSampler(name)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, sampler)
+	}
+
+	_, err := ParsePeerSampler("closest")
+	assert.Error(t, err)
+}
+
+func TestPeerSamplers(t *testing.T) {
+	candidates := peerCandidates(8)
+
+	for _, sampler := range []PeerSampler{UniformSampler{}, StakeSampler{}, LatencySampler{}} {
+		for i := 0; i < 100; i++ {
+			conns, err := sampler.Sample(candidates, 3)
+			assert.NoError(t, err)
+			assert.Len(t, conns, 3)
+
+			seen := make(map[*grpc.ClientConn]struct{})
+
+			for _, conn := range conns {
+				_, duplicate := seen[conn]
+				assert.False(t, duplicate)
+
+				seen[conn] = struct{}{}
+			}
+		}
+
+		_, err := sampler.Sample(candidates[:2], 3)
+		assert.Error(t, err)
+	}
+}
+
+func TestStakeSamplerFavorsStake(t *testing.T) {
+	candidates := peerCandidates(2)
+	candidates[0].Stake = 1000
+
+	staked := 0
+
+	for i := 0; i < 1000; i++ {
+		conns, err := StakeSampler{}.Sample(candidates, 1)
+		assert.NoError(t, err)
+
+		if conns[0] == candidates[0].Conn {
+			staked++
+		}
+	}
+
+	assert.True(t, staked > 900)
+}
+
+func TestLatencySamplerFavorsFastPeers(t *testing.T) {
+	candidates := peerCandidates(3)
+	candidates[0].Latency = 100 * time.Millisecond
+	candidates[1].Latency = 900 * time.Millisecond
+
+	// Peers of unknown latency are weighed as though they are average.
+
+	counts := make(map[*grpc.ClientConn]int)
+
+	for i := 0; i < 1000; i++ {
+		conns, err := LatencySampler{}.Sample(candidates, 1)
+		assert.NoError(t, err)
+
+		counts[conns[0]]++
+	}
+
+	assert.True(t, counts[candidates[0].Conn] > counts[candidates[2].Conn])
+	assert.True(t, counts[candidates[2].Conn] > counts[candidates[1].Conn])
+}
@@ -0,0 +1,115 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"github.com/perlin-network/noise/edwards25519"
+	"github.com/perlin-network/wavelet/avl"
+	"github.com/perlin-network/wavelet/secp256k1"
+	"github.com/perlin-network/wavelet/sys"
+	"github.com/pkg/errors"
+)
+
+// SignatureScheme is the scheme under which an account signs the transactions
+// it creates. Accounts are controlled by Ed25519 keys by default, though may
+// instead be controlled by secp256k1 keys, in which case the ID of the account
+// is the BLAKE2b-256 hash of its compressed public key.
+type SignatureScheme byte
+
+const (
+	SignatureEd25519 SignatureScheme = iota
+	SignatureSecp256k1
+)
+
+var signatureSchemeLabels = []string{"ed25519", "secp256k1"}
+
+func (s SignatureScheme) String() string {
+	if int(s) < len(signatureSchemeLabels) {
+		return signatureSchemeLabels[s]
+	}
+
+	return "unknown"
+}
+
+// ParseSignatureScheme parses the label of a signature scheme. An empty label
+// parses as Ed25519.
+func ParseSignatureScheme(label string) (SignatureScheme, error) {
+	if len(label) == 0 {
+		return SignatureEd25519, nil
+	}
+
+	for i, l := range signatureSchemeLabels {
+		if l == label {
+			return SignatureScheme(i), nil
+		}
+	}
+
+	return 0, errors.Errorf("unknown signature scheme %q", label)
+}
+
+// VerifySignature verifies a signature by the account with the given ID over a
+// message under the specified scheme.
+func VerifySignature(scheme SignatureScheme, id AccountID, msg []byte, sig Signature) bool {
+	switch scheme {
+	case SignatureEd25519:
+		return edwards25519.Verify(id, msg, sig)
+	case SignatureSecp256k1:
+		return secp256k1.VerifyID(id, msg, sig)
+	default:
+		return false
+	}
+}
+
+// ReadAccountSignatureScheme returns the signature scheme recorded for an
+// account. Accounts which have no scheme recorded sign under Ed25519.
+func ReadAccountSignatureScheme(tree *avl.Tree, id AccountID) (SignatureScheme, bool) {
+	buf, exists := readUnderAccounts(tree, id, keyAccountSignatureScheme[:])
+	if !exists || len(buf) != 1 {
+		return SignatureEd25519, false
+	}
+
+	return SignatureScheme(buf[0]), true
+}
+
+func WriteAccountSignatureScheme(tree *avl.Tree, id AccountID, scheme SignatureScheme) {
+	writeUnderAccounts(tree, id, keyAccountSignatureScheme[:], []byte{byte(scheme)})
+}
+
+// applySignatureScheme records the signature scheme of the creator of a
+// transaction the first time the creator signs under a scheme other than
+// Ed25519, and rejects transactions whose creator signed under a scheme other
+// than the one recorded for it.
+func applySignatureScheme(snapshot *avl.Tree, version uint32, tx *Transaction) error {
+	if tx.CreatorScheme != SignatureEd25519 && version < sys.ProtocolSecp256k1 {
+		return errors.Errorf("transactions created under the %s signature scheme may not be applied under protocol version %d", tx.CreatorScheme, version)
+	}
+
+	scheme, recorded := ReadAccountSignatureScheme(snapshot, tx.Creator)
+
+	if recorded && scheme != tx.CreatorScheme {
+		return errors.Errorf("account %x signs under the %s signature scheme, but the transaction was signed under %s", tx.Creator, scheme, tx.CreatorScheme)
+	}
+
+	if !recorded && tx.CreatorScheme != SignatureEd25519 {
+		WriteAccountSignatureScheme(snapshot, tx.Creator, tx.CreatorScheme)
+	}
+
+	return nil
+}
@@ -0,0 +1,112 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+
+	"github.com/perlin-network/noise/skademlia"
+	"github.com/perlin-network/wavelet/avl"
+	"github.com/perlin-network/wavelet/secp256k1"
+	"github.com/perlin-network/wavelet/store"
+	"github.com/perlin-network/wavelet/sys"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSecp256k1Transaction(t *testing.T) {
+	keys, err := skademlia.NewKeys(1, 1)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	creator, err := secp256k1.GenerateKey(rand.Reader)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	parent := AttachSenderToTransaction(keys, NewTransaction(keys, sys.TagNop, nil))
+
+	tx := AttachSenderToTransaction(keys, NewSecp256k1Transaction(creator, sys.TagNop, nil), &parent)
+
+	assert.Equal(t, creator.PublicKey().ID(), tx.Creator)
+	assert.NoError(t, ValidateTransaction(tx, true))
+
+	decoded, err := UnmarshalTransaction(bytes.NewReader(tx.Marshal()))
+	assert.NoError(t, err)
+	assert.Equal(t, SignatureSecp256k1, decoded.CreatorScheme)
+	assert.Equal(t, tx.ID, decoded.ID)
+	assert.NoError(t, ValidateTransaction(decoded, true))
+
+	// The creator signature may not be verified under another scheme.
+	wrong := tx
+	wrong.CreatorScheme = SignatureEd25519
+	wrong = AttachSenderToTransaction(keys, wrong, &parent)
+
+	assert.Error(t, ValidateTransaction(wrong, true))
+
+	// Transactions created by their sender must be signed under Ed25519.
+	own := NewTransaction(keys, sys.TagNop, nil)
+	own.CreatorScheme = SignatureSecp256k1
+	own = AttachSenderToTransaction(keys, own, &parent)
+
+	assert.Error(t, ValidateTransaction(own, true))
+
+	buf := tx.Marshal()
+	buf[SizeAccountID] = 1 + byte(SignatureSecp256k1) + 1
+
+	_, err = UnmarshalTransaction(bytes.NewReader(buf))
+	assert.Error(t, err)
+}
+
+func TestApplySignatureScheme(t *testing.T) {
+	tree := avl.New(store.NewInmem())
+	id := AccountID{1}
+
+	scheme, recorded := ReadAccountSignatureScheme(tree, id)
+	assert.False(t, recorded)
+	assert.Equal(t, SignatureEd25519, scheme)
+
+	ed25519 := &Transaction{Creator: id}
+	secp256k1 := &Transaction{Creator: id, CreatorScheme: SignatureSecp256k1}
+
+	assert.NoError(t, applySignatureScheme(tree, sys.ProtocolSecp256k1, ed25519))
+
+	_, recorded = ReadAccountSignatureScheme(tree, id)
+	assert.False(t, recorded, "ed25519 is not recorded as it is the default")
+
+	assert.Error(t, applySignatureScheme(tree, sys.ProtocolGovernance, secp256k1), "secp256k1 is not active until its upgrade")
+	assert.NoError(t, applySignatureScheme(tree, sys.ProtocolSecp256k1, secp256k1))
+
+	scheme, recorded = ReadAccountSignatureScheme(tree, id)
+	assert.True(t, recorded)
+	assert.Equal(t, SignatureSecp256k1, scheme)
+
+	assert.Error(t, applySignatureScheme(tree, sys.ProtocolSecp256k1, ed25519), "accounts may not switch signature schemes")
+	assert.NoError(t, applySignatureScheme(tree, sys.ProtocolSecp256k1, secp256k1))
+
+	parsed, err := ParseSignatureScheme("secp256k1")
+	assert.NoError(t, err)
+	assert.Equal(t, SignatureSecp256k1, parsed)
+
+	_, err = ParseSignatureScheme("rsa")
+	assert.Error(t, err)
+}
@@ -0,0 +1,208 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+// Package secp256k1 implements ECDSA signatures over the secp256k1 curve, such
+// that accounts may be controlled by the same keys, hardware security modules
+// and signing infrastructure used for Bitcoin and Ethereum. Curve arithmetic
+// is delegated to decred's constant-time implementation of secp256k1.
+//
+// Messages are hashed with BLAKE2b-256 before being signed. Signatures are the
+// 64-byte concatenation of r and s, where s must be within the lower half of
+// the order of the curve such that signatures may not be malleated.
+//
+// Accounts controlled by secp256k1 keys are identified by the BLAKE2b-256 hash
+// of their compressed public key, which is recovered out of signatures rather
+// than being carried alongside them.
+package secp256k1
+
+import (
+	"io"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/blake2b"
+)
+
+const (
+	SizePrivateKey = 32
+	SizePublicKey  = 33
+	SizeSignature  = 64
+	SizeID         = blake2b.Size256
+)
+
+// Offset added to the public key recovery code prefixed to compact signatures.
+const compactSigMagicOffset = 27
+
+type PrivateKey struct {
+	k *secp256k1.PrivateKey
+}
+
+type PublicKey struct {
+	pk *secp256k1.PublicKey
+}
+
+// GenerateKey generates a new private key out of randomness read from r.
+func GenerateKey(r io.Reader) (*PrivateKey, error) {
+	var buf [SizePrivateKey]byte
+
+	for {
+		if _, err := io.ReadFull(r, buf[:]); err != nil {
+			return nil, errors.Wrap(err, "secp256k1: failed to read randomness")
+		}
+
+		if k, err := UnmarshalPrivateKey(buf[:]); err == nil {
+			return k, nil
+		}
+	}
+}
+
+// UnmarshalPrivateKey decodes a 32-byte big-endian private key, such as those
+// used by Bitcoin and Ethereum wallets.
+func UnmarshalPrivateKey(buf []byte) (*PrivateKey, error) {
+	if len(buf) != SizePrivateKey {
+		return nil, errors.Errorf("secp256k1: private key must be %d bytes long", SizePrivateKey)
+	}
+
+	var d secp256k1.ModNScalar
+
+	if overflow := d.SetByteSlice(buf); overflow || d.IsZero() {
+		return nil, errors.New("secp256k1: private key is out of range")
+	}
+
+	return &PrivateKey{k: secp256k1.NewPrivateKey(&d)}, nil
+}
+
+func (k *PrivateKey) Marshal() []byte {
+	return k.k.Serialize()
+}
+
+func (k *PrivateKey) PublicKey() *PublicKey {
+	return &PublicKey{pk: k.k.PubKey()}
+}
+
+// Sign signs the BLAKE2b-256 hash of a message. The nonce of the signature is
+// derived deterministically as specified by RFC 6979.
+func (k *PrivateKey) Sign(msg []byte) [SizeSignature]byte {
+	digest := Digest(msg)
+
+	var sig [SizeSignature]byte
+	copy(sig[:], ecdsa.SignCompact(k.k, digest[:], true)[1:])
+
+	return sig
+}
+
+// Marshal encodes the public key in its 33-byte compressed form.
+func (pk *PublicKey) Marshal() []byte {
+	return pk.pk.SerializeCompressed()
+}
+
+// ID returns the BLAKE2b-256 hash of the compressed public key, which is the
+// ID of the account the key controls.
+func (pk *PublicKey) ID() [SizeID]byte {
+	return blake2b.Sum256(pk.Marshal())
+}
+
+// Verify verifies a signature over a message.
+func (pk *PublicKey) Verify(msg []byte, sig [SizeSignature]byte) bool {
+	r, s, ok := parseSignature(sig)
+	if !ok {
+		return false
+	}
+
+	digest := Digest(msg)
+
+	return ecdsa.NewSignature(&r, &s).Verify(digest[:], pk.pk)
+}
+
+// UnmarshalPublicKey decodes a public key in either its 33-byte compressed or
+// 65-byte uncompressed form.
+func UnmarshalPublicKey(buf []byte) (*PublicKey, error) {
+	switch {
+	case len(buf) == SizePublicKey && (buf[0] == secp256k1.PubKeyFormatCompressedEven || buf[0] == secp256k1.PubKeyFormatCompressedOdd):
+	case len(buf) == secp256k1.PubKeyBytesLenUncompressed && buf[0] == secp256k1.PubKeyFormatUncompressed:
+	default:
+		return nil, errors.Errorf("secp256k1: public key must be %d bytes long compressed, or 65 bytes long uncompressed", SizePublicKey)
+	}
+
+	pk, err := secp256k1.ParsePubKey(buf)
+	if err != nil {
+		return nil, errors.Wrap(err, "secp256k1: public key is not a point on the curve")
+	}
+
+	return &PublicKey{pk: pk}, nil
+}
+
+// Digest returns the BLAKE2b-256 hash of a message, which is what is signed.
+// External signers such as hardware security modules sign this digest.
+func Digest(msg []byte) [blake2b.Size256]byte {
+	return blake2b.Sum256(msg)
+}
+
+// Recover recovers the public keys that may have produced a signature over a
+// message. As signatures carry no recovery code, every candidate is returned.
+func Recover(msg []byte, sig [SizeSignature]byte) []*PublicKey {
+	if _, _, ok := parseSignature(sig); !ok {
+		return nil
+	}
+
+	digest := Digest(msg)
+
+	var compact [1 + SizeSignature]byte
+	copy(compact[1:], sig[:])
+
+	var keys []*PublicKey
+
+	for code := byte(0); code < 4; code++ {
+		compact[0] = compactSigMagicOffset + code
+
+		pk, _, err := ecdsa.RecoverCompact(compact[:], digest[:])
+		if err != nil {
+			continue
+		}
+
+		keys = append(keys, &PublicKey{pk: pk})
+	}
+
+	return keys
+}
+
+// VerifyID verifies that a signature over a message was produced by the key
+// which controls the account with the given ID.
+func VerifyID(id [SizeID]byte, msg []byte, sig [SizeSignature]byte) bool {
+	for _, pk := range Recover(msg, sig) {
+		if pk.ID() == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+func parseSignature(sig [SizeSignature]byte) (r, s secp256k1.ModNScalar, ok bool) {
+	if overflow := r.SetByteSlice(sig[:32]); overflow || r.IsZero() {
+		return r, s, false
+	}
+
+	if overflow := s.SetByteSlice(sig[32:]); overflow || s.IsZero() || s.IsOverHalfOrder() {
+		return r, s, false
+	}
+
+	return r, s, true
+}
@@ -0,0 +1,115 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package secp256k1
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"testing"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPublicKey(t *testing.T) {
+	buf := make([]byte, SizePrivateKey)
+	buf[SizePrivateKey-1] = 2
+
+	k, err := UnmarshalPrivateKey(buf)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// The public key of a private key of 2 is twice the base point.
+	pk := k.PublicKey()
+	assert.Equal(t, "02c6047f9441ed7d6d3045406e95c07cd85c778e4b8cef3ca7abac09b95c709ee5", hex.EncodeToString(pk.Marshal()))
+
+	decoded, err := UnmarshalPublicKey(pk.Marshal())
+	assert.NoError(t, err)
+	assert.Equal(t, pk.Marshal(), decoded.Marshal())
+
+	_, err = UnmarshalPrivateKey(make([]byte, SizePrivateKey))
+	assert.Error(t, err)
+
+	order, _ := hex.DecodeString("fffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd0364141")
+
+	_, err = UnmarshalPrivateKey(order)
+	assert.Error(t, err)
+
+	prime, _ := hex.DecodeString("fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2f")
+
+	_, err = UnmarshalPublicKey(append([]byte{2}, prime...))
+	assert.Error(t, err)
+}
+
+func TestSignVector(t *testing.T) {
+	buf, _ := hex.DecodeString("c9afa9d845ba75166b5c215767b1d6934e50c3db36e89b127b8a622b120f6721")
+
+	k, err := UnmarshalPrivateKey(buf)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, buf, k.Marshal())
+	assert.Equal(t, "032c8c31fc9f990c6b55e3865a184a4ce50e09481f2eaeb3e60ec1cea13a6ae645", hex.EncodeToString(k.PublicKey().Marshal()))
+
+	sig := k.Sign([]byte("wavelet"))
+	assert.Equal(t, "72741834ffab2d87d905ab5f91d9670a26f546c0e985ead241ccd9ea94cda4f2"+
+		"49a24c40a4bb74987e2b94d2af69fdd04a31078ff9214ec4da48f54a74e8f70a", hex.EncodeToString(sig[:]))
+}
+
+func TestSign(t *testing.T) {
+	k, err := GenerateKey(rand.Reader)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	pk := k.PublicKey()
+	msg := []byte("hello world")
+
+	sig := k.Sign(msg)
+	assert.Equal(t, sig, k.Sign(msg), "signatures must be deterministic")
+
+	assert.True(t, pk.Verify(msg, sig))
+	assert.True(t, VerifyID(pk.ID(), msg, sig))
+
+	assert.False(t, pk.Verify([]byte("hello world!"), sig))
+	assert.False(t, VerifyID(pk.ID(), []byte("hello world!"), sig))
+
+	other, err := GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+
+	assert.False(t, other.PublicKey().Verify(msg, sig))
+	assert.False(t, VerifyID(other.PublicKey().ID(), msg, sig))
+
+	// Signatures whose s lies within the upper half of the order of the curve
+	// are malleated, and must be rejected.
+	var s secp256k1.ModNScalar
+	s.SetByteSlice(sig[32:])
+	s.Negate()
+
+	malleated := sig
+	s.PutBytesUnchecked(malleated[32:])
+
+	assert.False(t, pk.Verify(msg, malleated))
+	assert.False(t, VerifyID(pk.ID(), msg, malleated))
+
+	assert.False(t, VerifyID(pk.ID(), msg, [SizeSignature]byte{}))
+}
@@ -20,8 +20,11 @@
 package wavelet
 
 import (
-	"fmt"
+	"bytes"
+	"sort"
 	"sync"
+
+	"github.com/perlin-network/wavelet/log"
 )
 
 type SnowballOption func(*Snowball)
@@ -32,8 +35,22 @@ func WithBeta(beta int) SnowballOption {
 	}
 }
 
+// WithLivenessLimit has Snowball fall back to preferring the candidate with
+// the lowest critical transaction ID once it has been ticked with a round other
+// than the one it was last ticked with limit times without progressing, that
+// is without being ticked with the same round limit times in a row. Such
+// liveness faults happen should the network be split between several rounds.
+// The fallback is disabled if limit is zero.
+func WithLivenessLimit(limit int) SnowballOption {
+	return func(snowball *Snowball) {
+		snowball.livenessLimit = limit
+	}
+}
+
 const (
 	SnowballDefaultBeta = 150
+
+	SnowballDefaultLivenessLimit = 50
 )
 
 type Snowball struct {
@@ -46,6 +63,11 @@ type Snowball struct {
 	counts  map[RoundID]int
 	count   int
 	decided bool
+
+	livenessLimit  int
+	livenessFaults int
+
+	logs *log.Scope
 }
 
 func NewSnowball(opts ...SnowballOption) *Snowball {
@@ -53,6 +75,7 @@ func NewSnowball(opts ...SnowballOption) *Snowball {
 		beta:       SnowballDefaultBeta,
 		candidates: make(map[RoundID]*Round),
 		counts:     make(map[RoundID]int),
+		logs:       log.NewScope(""),
 	}
 
 	for _, opt := range opts {
@@ -62,6 +85,15 @@ func NewSnowball(opts ...SnowballOption) *Snowball {
 	return s
 }
 
+// SetLivenessLimit sets how many times Snowball may be ticked with a round other
+// than the one it was last ticked with before falling back, as described by
+// WithLivenessLimit. The fallback is disabled if limit is zero.
+func (s *Snowball) SetLivenessLimit(limit int) {
+	s.Lock()
+	s.livenessLimit = limit
+	s.Unlock()
+}
+
 func (s *Snowball) Reset() {
 	s.Lock()
 
@@ -73,6 +105,7 @@ func (s *Snowball) Reset() {
 	s.count = 0
 
 	s.decided = false
+	s.livenessFaults = 0
 
 	s.Unlock()
 }
@@ -104,20 +137,75 @@ func (s *Snowball) Tick(round *Round) {
 
 	if s.lastID != round.ID { // Handle termination case.
 		if s.lastID != ZeroRoundID {
-			fmt.Printf("Snowball liveness fault: Last ID is %x with count %d, and new ID is %x.\n", s.lastID, s.count, round.ID)
+			logger := s.logs.Consensus("liveness_fault")
+			logger.Debug().
+				Hex("last_round_id", s.lastID[:]).
+				Int("last_count", s.count).
+				Hex("new_round_id", round.ID[:]).
+				Msg("Snowball was ticked with a different round.")
+
+			s.livenessFaults++
 		}
 
 		s.lastID = round.ID
 		s.count = 0
+
+		if s.livenessLimit > 0 && s.livenessFaults >= s.livenessLimit {
+			s.fallback()
+		}
 	} else {
 		s.count++
 
+		if s.count >= s.livenessLimit { // Snowball is progressing.
+			s.livenessFaults = 0
+		}
+
 		if s.count > s.beta {
 			s.decided = true
 		}
 	}
 }
 
+// fallback has Snowball prefer the candidate whose critical transaction has
+// the lowest ID out of all candidates it was ever ticked with, regardless of
+// how many times it was ticked with each. Nodes which were ticked with the same
+// candidates thus prefer the same round, though nodes which have yet to see
+// some candidate may prefer another. The preferred round is given a head start
+// over all other candidates, and Snowball resumes being ticked anew.
+func (s *Snowball) fallback() {
+	var chosen *Round
+	var max int
+
+	for id, round := range s.candidates {
+		if count := s.counts[id]; count > max {
+			max = count
+		}
+
+		if chosen == nil || bytes.Compare(round.End.ID[:], chosen.End.ID[:]) < 0 {
+			chosen = round
+		}
+	}
+
+	if chosen == nil {
+		return
+	}
+
+	logger := s.logs.Consensus("liveness_fallback")
+	logger.Warn().
+		Int("num_faults", s.livenessFaults).
+		Int("num_candidates", len(s.candidates)).
+		Hex("preferred_round_id", chosen.ID[:]).
+		Hex("preferred_critical_tx_id", chosen.End.ID[:]).
+		Msg("Snowball made no progress. Preferring the round with the lowest critical transaction ID.")
+
+	s.preferredID = chosen.ID
+	s.counts[chosen.ID] = max + 1
+
+	s.lastID = ZeroRoundID
+	s.count = 0
+	s.livenessFaults = 0
+}
+
 func (s *Snowball) Prefer(round *Round) {
 	s.Lock()
 	if _, exists := s.candidates[round.ID]; !exists {
@@ -154,3 +242,75 @@ func (s *Snowball) Progress() int {
 
 	return progress
 }
+
+// SnowballCandidate is a round that a Snowball instance was ticked with,
+// alongside how many times it was ticked with it.
+type SnowballCandidate struct {
+	Round *Round
+	Count int
+}
+
+// SnowballProgress describes how close a Snowball instance is to deciding on
+// a round.
+type SnowballProgress struct {
+	Preferred *Round  // Round preferred so far. Nil should there be none.
+	Last      RoundID // Round the instance was last ticked with.
+
+	Count   int  // Number of times in a row the instance was ticked with Last.
+	Beta    int  // Number of times in a row the instance must be ticked with Last to decide.
+	Decided bool // Whether the instance decided on Preferred.
+
+	LivenessFaults int // Number of times the instance was ticked with a round other than Last since it was last ticked with the same round as many times in a row as its liveness limit.
+
+	Candidates []SnowballCandidate // Rounds ticked with, from the most ticked with.
+}
+
+// differs returns whether p and other describe different progress.
+func (p SnowballProgress) differs(other SnowballProgress) bool {
+	if p.Count != other.Count || p.Last != other.Last || p.Decided != other.Decided || p.LivenessFaults != other.LivenessFaults || len(p.Candidates) != len(other.Candidates) {
+		return true
+	}
+
+	if (p.Preferred == nil) != (other.Preferred == nil) {
+		return true
+	}
+
+	return p.Preferred != nil && p.Preferred.ID != other.Preferred.ID
+}
+
+// Snapshot returns how close the Snowball instance is to deciding on a round.
+func (s *Snowball) Snapshot() SnowballProgress {
+	s.RLock()
+	defer s.RUnlock()
+
+	progress := SnowballProgress{
+		Last:    s.lastID,
+		Count:   s.count,
+		Beta:    s.beta,
+		Decided: s.decided,
+
+		LivenessFaults: s.livenessFaults,
+
+		Candidates: make([]SnowballCandidate, 0, len(s.candidates)),
+	}
+
+	if s.preferredID != ZeroRoundID {
+		progress.Preferred = s.candidates[s.preferredID]
+	}
+
+	for id, round := range s.candidates {
+		progress.Candidates = append(progress.Candidates, SnowballCandidate{Round: round, Count: s.counts[id]})
+	}
+
+	sort.Slice(progress.Candidates, func(i, j int) bool {
+		a, b := progress.Candidates[i], progress.Candidates[j]
+
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+
+		return bytes.Compare(a.Round.ID[:], b.Round.ID[:]) < 0
+	})
+
+	return progress
+}
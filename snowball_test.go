@@ -20,6 +20,7 @@
 package wavelet
 
 import (
+	"bytes"
 	"github.com/perlin-network/noise/skademlia"
 	"github.com/perlin-network/wavelet/sys"
 	"github.com/stretchr/testify/assert"
@@ -61,9 +62,9 @@ func TestNewSnowball(t *testing.T) {
 
 	// Try tick once more. Does absolutely nothing.
 
-	cloned := *snowball
+	progress := snowball.Snapshot()
 	snowball.Tick(&a)
-	assert.Equal(t, cloned, *snowball)
+	assert.Equal(t, progress, snowball.Snapshot())
 
 	// Reset Snowball and assert everything is cleared properly.
 
@@ -171,3 +172,118 @@ func TestNewSnowball(t *testing.T) {
 	assert.Equal(t, 0, snowball.Progress())
 	assert.Len(t, snowball.counts, 1)
 }
+
+func TestSnowballSnapshot(t *testing.T) {
+	t.Parallel()
+
+	snowball := NewSnowball(WithBeta(10))
+
+	keys, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	start := AttachSenderToTransaction(keys, NewTransaction(keys, sys.TagTransfer, nil))
+
+	a := NewRound(1, ZeroMerkleNodeID, 1337, start, AttachSenderToTransaction(keys, NewTransaction(keys, sys.TagStake, nil)))
+	b := NewRound(1, ZeroMerkleNodeID, 1010, start, AttachSenderToTransaction(keys, NewTransaction(keys, sys.TagContract, nil)))
+
+	progress := snowball.Snapshot()
+	assert.Nil(t, progress.Preferred)
+	assert.Empty(t, progress.Candidates)
+	assert.Equal(t, 10, progress.Beta)
+
+	snowball.Tick(&a)
+	snowball.Tick(&b)
+	snowball.Tick(&b)
+	snowball.Tick(&b)
+
+	progress = snowball.Snapshot()
+
+	assert.Equal(t, b, *progress.Preferred)
+	assert.Equal(t, b.ID, progress.Last)
+	assert.Equal(t, 2, progress.Count)
+	assert.False(t, progress.Decided)
+
+	if assert.Len(t, progress.Candidates, 2) {
+		assert.Equal(t, SnowballCandidate{Round: &b, Count: 3}, progress.Candidates[0])
+		assert.Equal(t, a.ID, progress.Candidates[1].Round.ID)
+		assert.Equal(t, 1, progress.Candidates[1].Count)
+	}
+
+	assert.False(t, progress.differs(snowball.Snapshot()))
+
+	snowball.Tick(&b)
+	assert.True(t, progress.differs(snowball.Snapshot()))
+}
+
+func TestSnowballLivenessFallback(t *testing.T) {
+	t.Parallel()
+
+	snowball := NewSnowball(WithBeta(10), WithLivenessLimit(3))
+
+	keys, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	start := AttachSenderToTransaction(keys, NewTransaction(keys, sys.TagTransfer, nil))
+
+	endA := AttachSenderToTransaction(keys, NewTransaction(keys, sys.TagStake, nil))
+	endB := AttachSenderToTransaction(keys, NewTransaction(keys, sys.TagContract, nil))
+
+	a := NewRound(1, ZeroMerkleNodeID, 1337, start, endA)
+	b := NewRound(1, ZeroMerkleNodeID, 1010, start, endB)
+
+	lowest, highest := a, b
+	if bytes.Compare(endB.ID[:], endA.ID[:]) < 0 {
+		lowest, highest = b, a
+	}
+
+	// Have the network be split between rounds A and B, such that Snowball
+	// is ticked with a different round three times in a row.
+
+	snowball.Tick(&a)
+	snowball.Tick(&b)
+	snowball.Tick(&a)
+
+	assert.Equal(t, 2, snowball.Snapshot().LivenessFaults)
+	assert.Equal(t, a, *snowball.Preferred())
+
+	snowball.Tick(&b)
+
+	assert.False(t, snowball.Decided())
+	assert.Equal(t, lowest, *snowball.Preferred(), "must fall back to the round with the lowest critical transaction ID")
+	assert.Equal(t, 0, snowball.Snapshot().LivenessFaults)
+
+	// A single tick with the other round must not overturn the fallback.
+
+	snowball.Tick(&highest)
+	assert.Equal(t, lowest, *snowball.Preferred())
+
+	for i := 0; i < 12; i++ {
+		snowball.Tick(&lowest)
+	}
+
+	assert.True(t, snowball.Decided())
+	assert.Equal(t, lowest, *snowball.Preferred())
+
+	snowball.Reset()
+	assert.Equal(t, 0, snowball.Snapshot().LivenessFaults)
+
+	// Rounds ticked with only once are fallen back on as well, such that nodes
+	// do not diverge based on how many times each saw a round.
+
+	snowball.Tick(&highest)
+	snowball.Tick(&highest)
+	snowball.Tick(&lowest)
+	snowball.Tick(&highest)
+	snowball.Tick(&highest)
+	snowball.Tick(&highest)
+	snowball.Tick(&highest)
+
+	assert.Equal(t, 0, snowball.Snapshot().LivenessFaults, "progress must reset liveness faults")
+	assert.Equal(t, highest, *snowball.Preferred())
+
+	snowball.Tick(&lowest)
+	snowball.Tick(&highest)
+	snowball.Tick(&lowest)
+
+	assert.Equal(t, lowest, *snowball.Preferred(), "must fall back to the round with the lowest critical transaction ID")
+}
@@ -0,0 +1,370 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"io"
+	"sync"
+
+	"github.com/perlin-network/wavelet/avl"
+	"github.com/perlin-network/wavelet/store"
+	"github.com/perlin-network/wavelet/sys"
+	"github.com/pkg/errors"
+)
+
+// stateSnapshotMagic prefixes every marshaled state snapshot, followed by the
+// version of the encoding it was marshaled under.
+var stateSnapshotMagic = [...]byte{'W', 'V', 'S', 'S'}
+
+// Versions of the state snapshot encoding. Full state snapshots hold every node
+// of the accounts tree rather than its difference against genesis.
+const (
+	stateSnapshotVersion     = 1
+	stateSnapshotVersionFull = 2
+)
+
+// maxStateSnapshotAttempts is how many times building a state snapshot is
+// retried should a round be finalized while it is being built.
+const maxStateSnapshotAttempts = 3
+
+// StateSnapshot is the state of all accounts as of the end of a finalized round,
+// encoded as the difference between it and the state at genesis. New nodes may
+// bootstrap from a state snapshot, and only sync the rounds finalized since from
+// their peers.
+//
+// A full state snapshot instead holds the entire state, such that it may also
+// be imported into a store which has not performed genesis.
+type StateSnapshot struct {
+	Round Round
+	State []byte
+	Full  bool
+}
+
+func (s StateSnapshot) Marshal() []byte {
+	round := s.Round.Marshal()
+
+	w := bytes.NewBuffer(make([]byte, 0, len(stateSnapshotMagic)+1+4+len(round)+len(s.State)))
+
+	w.Write(stateSnapshotMagic[:])
+
+	if s.Full {
+		w.WriteByte(stateSnapshotVersionFull)
+	} else {
+		w.WriteByte(stateSnapshotVersion)
+	}
+
+	var buf [4]byte
+
+	binary.BigEndian.PutUint32(buf[:], uint32(len(round)))
+	w.Write(buf[:])
+	w.Write(round)
+
+	w.Write(s.State)
+
+	return w.Bytes()
+}
+
+func UnmarshalStateSnapshot(r io.Reader) (StateSnapshot, error) {
+	var snapshot StateSnapshot
+
+	var header [len(stateSnapshotMagic) + 1 + 4]byte
+
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return snapshot, errors.Wrap(err, "failed to decode state snapshot header")
+	}
+
+	if !bytes.Equal(header[:len(stateSnapshotMagic)], stateSnapshotMagic[:]) {
+		return snapshot, errors.New("not a state snapshot")
+	}
+
+	switch version := header[len(stateSnapshotMagic)]; version {
+	case stateSnapshotVersion:
+	case stateSnapshotVersionFull:
+		snapshot.Full = true
+	default:
+		return snapshot, errors.Errorf("unsupported state snapshot version %d", version)
+	}
+
+	round := make([]byte, binary.BigEndian.Uint32(header[len(stateSnapshotMagic)+1:]))
+
+	if _, err := io.ReadFull(r, round); err != nil {
+		return snapshot, errors.Wrap(err, "failed to decode state snapshot round")
+	}
+
+	var err error
+
+	if snapshot.Round, err = UnmarshalRound(bytes.NewReader(round)); err != nil {
+		return snapshot, errors.Wrap(err, "failed to decode state snapshot round")
+	}
+
+	var state bytes.Buffer
+
+	if _, err := state.ReadFrom(r); err != nil {
+		return snapshot, errors.Wrap(err, "failed to decode state snapshot state")
+	}
+
+	snapshot.State = state.Bytes()
+
+	return snapshot, nil
+}
+
+// stateSnapshotCache holds the marshaled state snapshot of the latest round a
+// state snapshot was requested for.
+type stateSnapshotCache struct {
+	sync.Mutex
+
+	round Round
+	buf   []byte
+}
+
+// WithStateSnapshot has the ledger bootstrap from snapshot, should snapshot be
+// of a round later than the latest round the ledger has finalized, and should
+// it be of the round with the ID trusted. The ledger falls back to syncing from
+// its peers should snapshot fail to be applied.
+func WithStateSnapshot(snapshot StateSnapshot, trusted RoundID) LedgerOption {
+	return func(ledger *Ledger) {
+		ledger.bootstrap = &snapshot
+		ledger.bootstrapRound = trusted
+	}
+}
+
+// ParseRoundID parses a round ID presented as hex.
+func ParseRoundID(s string) (RoundID, error) {
+	var id RoundID
+
+	buf, err := hex.DecodeString(s)
+	if err != nil {
+		return id, errors.Wrap(err, "round ID must be presented as hex")
+	}
+
+	if len(buf) != SizeRoundID {
+		return id, errors.Errorf("round ID must be %d bytes, but is %d bytes", SizeRoundID, len(buf))
+	}
+
+	copy(id[:], buf)
+
+	return id, nil
+}
+
+// StateSnapshot returns the marshaled state snapshot of the latest finalized
+// round, alongside the round. The state snapshot is only built once per round.
+func (l *Ledger) StateSnapshot() (Round, []byte, error) {
+	l.stateSnapshot.Lock()
+	defer l.stateSnapshot.Unlock()
+
+	for i := 0; i < maxStateSnapshotAttempts; i++ {
+		latest := l.rounds.Latest()
+
+		if l.stateSnapshot.buf != nil && l.stateSnapshot.round.ID == latest.ID {
+			return l.stateSnapshot.round, l.stateSnapshot.buf, nil
+		}
+
+		// The latest round may be saved before, or after, its state is
+		// committed. Retry should they not match.
+
+		tree := l.accounts.Snapshot()
+
+		if tree.Checksum() != latest.Merkle {
+			continue
+		}
+
+		l.stateSnapshot.round = *latest
+		l.stateSnapshot.buf = StateSnapshot{Round: *latest, State: tree.DumpDiff(0)}.Marshal()
+
+		return l.stateSnapshot.round, l.stateSnapshot.buf, nil
+	}
+
+	return Round{}, nil, errors.New("state changed while building its snapshot")
+}
+
+// applyStateSnapshot replaces the state of the ledger with the state in
+// snapshot, after verifying that the snapshot was taken at the round trusted,
+// and that it matches the merkle root of the round. It must be called before
+// the ledger starts any of its workers.
+func (l *Ledger) applyStateSnapshot(snapshot StateSnapshot, trusted RoundID) error {
+	current := l.rounds.Latest()
+	latest := snapshot.Round
+
+	if err := snapshot.verify(trusted); err != nil {
+		return err
+	}
+
+	if latest.Index <= current.Index {
+		return errors.Errorf("state snapshot of round %d is not ahead of our latest round %d", latest.Index, current.Index)
+	}
+
+	tree := l.accounts.Snapshot()
+
+	if err := snapshot.applyTo(tree); err != nil {
+		return err
+	}
+
+	if _, err := l.rounds.Save(&latest); err != nil {
+		return errors.Wrap(err, "failed to save the round of the state snapshot")
+	}
+
+	l.graph.UpdateRoot(latest.End)
+
+	if err := l.accounts.Commit(tree); err != nil {
+		return errors.Wrap(err, "failed to commit state snapshot")
+	}
+
+	if l.history != nil {
+		if err := l.history.Record(latest.Index, tree, current.Index); err != nil {
+			return errors.Wrap(err, "failed to record account history")
+		}
+	}
+
+	return nil
+}
+
+// verify returns an error should the state snapshot not have been taken at the
+// round with the ID trusted. Anyone serving a state snapshot may forge a round
+// whose merkle root matches any state they please, whereas the ID of a round
+// commits to its merkle root. The ID of the round must thus be obtained from a
+// source trusted to only report rounds finalized by the network.
+func (s StateSnapshot) verify(trusted RoundID) error {
+	if trusted == ZeroRoundID {
+		return errors.New("the ID of the round the state snapshot was taken at must be trusted beforehand")
+	}
+
+	if s.Round.ID != trusted {
+		return errors.Errorf("state snapshot was taken at round %x, but round %x is trusted", s.Round.ID, trusted)
+	}
+
+	return nil
+}
+
+// applyTo applies the state held in the state snapshot onto tree, and verifies
+// that it matches the merkle root of the round the snapshot was taken at.
+func (s StateSnapshot) applyTo(tree *avl.Tree) error {
+	var err error
+
+	if s.Full {
+		err = tree.Restore(s.State)
+	} else {
+		err = tree.ApplyDiff(s.State)
+	}
+
+	if err != nil {
+		return errors.Wrap(err, "failed to apply state snapshot")
+	}
+
+	if checksum := tree.Checksum(); checksum != s.Round.Merkle {
+		return errors.Errorf("state snapshot has merkle root %x, but its round expects %x", checksum, s.Round.Merkle)
+	}
+
+	return nil
+}
+
+// ExportState writes a full state snapshot of the latest finalized round into w.
+func (l *Ledger) ExportState(w io.Writer) error {
+	for i := 0; i < maxStateSnapshotAttempts; i++ {
+		latest := l.rounds.Latest()
+		tree := l.accounts.Snapshot()
+
+		// The latest round may be saved before, or after, its state is
+		// committed. Retry should they not match.
+
+		if tree.Checksum() != latest.Merkle {
+			continue
+		}
+
+		return writeStateSnapshot(w, StateSnapshot{Round: *latest, State: tree.Dump(), Full: true})
+	}
+
+	return errors.New("state changed while exporting it")
+}
+
+// ExportState writes a full state snapshot of the latest round finalized by a
+// stopped node into w, out of the store kv the node kept its state in.
+func ExportState(kv store.KV, w io.Writer) (Round, error) {
+	rounds, err := NewRounds(kv, sys.PruningLimit)
+	if err != nil {
+		return Round{}, errors.Wrap(err, "failed to load rounds")
+	}
+
+	latest := rounds.Latest()
+	tree := avl.New(kv)
+
+	if checksum := tree.Checksum(); checksum != latest.Merkle {
+		return Round{}, errors.Errorf("state has merkle root %x, but the latest round %d expects %x", checksum, latest.Index, latest.Merkle)
+	}
+
+	if err := writeStateSnapshot(w, StateSnapshot{Round: *latest, State: tree.Dump(), Full: true}); err != nil {
+		return Round{}, err
+	}
+
+	return *latest, nil
+}
+
+// ImportState replaces the state held in the store kv of a stopped node with
+// the state snapshot read from r, after verifying that it was taken at the
+// round with the ID trusted, and that it matches the merkle root of the round.
+// The node resumes from the round of the state snapshot once started. The store
+// may be empty, should the state snapshot be full.
+func ImportState(kv store.KV, r io.Reader, trusted RoundID) (Round, error) {
+	snapshot, err := UnmarshalStateSnapshot(r)
+	if err != nil {
+		return Round{}, err
+	}
+
+	if err := snapshot.verify(trusted); err != nil {
+		return Round{}, err
+	}
+
+	rounds, err := NewRounds(kv, sys.PruningLimit)
+
+	if err == nil {
+		if current := rounds.Latest(); snapshot.Round.Index <= current.Index {
+			return Round{}, errors.Errorf("state snapshot of round %d is not ahead of our latest round %d", snapshot.Round.Index, current.Index)
+		}
+	} else if !snapshot.Full {
+		return Round{}, errors.New("only full state snapshots may be imported into a store without any rounds")
+	}
+
+	accounts := NewAccounts(kv)
+	tree := accounts.Snapshot()
+
+	if err := snapshot.applyTo(tree); err != nil {
+		return Round{}, err
+	}
+
+	if err := accounts.Commit(tree); err != nil {
+		return Round{}, errors.Wrap(err, "failed to commit state snapshot")
+	}
+
+	if _, err := rounds.Save(&snapshot.Round); err != nil {
+		return Round{}, errors.Wrap(err, "failed to save the round of the state snapshot")
+	}
+
+	return snapshot.Round, nil
+}
+
+func writeStateSnapshot(w io.Writer, snapshot StateSnapshot) error {
+	if _, err := w.Write(snapshot.Marshal()); err != nil {
+		return errors.Wrap(err, "failed to write state snapshot")
+	}
+
+	return nil
+}
@@ -0,0 +1,194 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/perlin-network/noise/skademlia"
+	"github.com/perlin-network/wavelet/store"
+	"github.com/stretchr/testify/assert"
+)
+
+// finalizeTestRound has ledger finalize a round which credits id with balance,
+// without going through consensus.
+func finalizeTestRound(t *testing.T, ledger *Ledger, id AccountID, balance uint64) Round {
+	current := ledger.Rounds().Latest()
+
+	tree := ledger.accounts.Snapshot()
+	tree.SetViewID(current.Index + 1)
+	WriteAccountBalance(tree, id, balance)
+
+	round := NewRound(current.Index+1, tree.Checksum(), 0, current.End, current.End)
+
+	_, err := ledger.rounds.Save(&round)
+	assert.NoError(t, err)
+	assert.NoError(t, ledger.accounts.Commit(tree))
+
+	return round
+}
+
+func TestStateSnapshot(t *testing.T) {
+	keys, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	source := NewLedger(store.NewInmem(), skademlia.NewClient(":0", keys), nil)
+	defer source.Close()
+
+	var id AccountID
+	id[0] = 1
+
+	round := finalizeTestRound(t, source, id, 1000)
+
+	served, buf, err := source.StateSnapshot()
+	assert.NoError(t, err)
+	assert.Equal(t, round.ID, served.ID)
+
+	// The state snapshot is only built once per round.
+
+	_, again, err := source.StateSnapshot()
+	assert.NoError(t, err)
+	assert.True(t, &buf[0] == &again[0])
+
+	snapshot, err := UnmarshalStateSnapshot(bytes.NewReader(buf))
+	assert.NoError(t, err)
+	assert.Equal(t, round.ID, snapshot.Round.ID)
+
+	// State snapshots are only applied should they be of the round trusted.
+
+	untrusted := NewLedger(store.NewInmem(), skademlia.NewClient(":0", keys), nil, WithStateSnapshot(snapshot, ZeroRoundID))
+	defer untrusted.Close()
+
+	assert.EqualValues(t, 0, untrusted.Rounds().Latest().Index, "state snapshots must not be applied without a trusted round")
+
+	ledger := NewLedger(store.NewInmem(), skademlia.NewClient(":0", keys), nil, WithStateSnapshot(snapshot, round.ID))
+	defer ledger.Close()
+
+	assert.Equal(t, round.ID, ledger.Rounds().Latest().ID)
+
+	balance, exists := ReadAccountBalance(ledger.Snapshot(), id)
+	assert.True(t, exists)
+	assert.EqualValues(t, 1000, balance)
+}
+
+func TestStateSnapshotRejectsTamperedState(t *testing.T) {
+	keys, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	source := NewLedger(store.NewInmem(), skademlia.NewClient(":0", keys), nil)
+	defer source.Close()
+
+	var id AccountID
+	id[0] = 1
+
+	round := finalizeTestRound(t, source, id, 1000)
+
+	tampered := NewLedger(store.NewInmem(), skademlia.NewClient(":0", keys), nil)
+	defer tampered.Close()
+
+	finalizeTestRound(t, tampered, id, 2000)
+
+	_, buf, err := tampered.StateSnapshot()
+	assert.NoError(t, err)
+
+	snapshot, err := UnmarshalStateSnapshot(bytes.NewReader(buf))
+	assert.NoError(t, err)
+
+	// A state snapshot whose round is forged to match its state is rejected
+	// should the round not be the one trusted.
+
+	forged := NewLedger(store.NewInmem(), skademlia.NewClient(":0", keys), nil, WithStateSnapshot(snapshot, round.ID))
+	defer forged.Close()
+
+	assert.EqualValues(t, 0, forged.Rounds().Latest().Index, "forged rounds must not be trusted")
+
+	snapshot.Round = round
+
+	ledger := NewLedger(store.NewInmem(), skademlia.NewClient(":0", keys), nil, WithStateSnapshot(snapshot, round.ID))
+	defer ledger.Close()
+
+	assert.EqualValues(t, 0, ledger.Rounds().Latest().Index, "ledger must fall back to syncing from peers")
+
+	_, exists := ReadAccountBalance(ledger.Snapshot(), id)
+	assert.False(t, exists)
+
+	_, err = UnmarshalStateSnapshot(bytes.NewReader(buf[:3]))
+	assert.Error(t, err)
+}
+
+func TestExportImportState(t *testing.T) {
+	keys, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	kv := store.NewInmem()
+
+	source := NewLedger(kv, skademlia.NewClient(":0", keys), nil)
+	defer source.Close()
+
+	var id AccountID
+	id[0] = 1
+
+	round := finalizeTestRound(t, source, id, 1000)
+
+	var exported bytes.Buffer
+	assert.NoError(t, source.ExportState(&exported))
+
+	var offline bytes.Buffer
+
+	exportedRound, err := ExportState(kv, &offline)
+	assert.NoError(t, err)
+	assert.Equal(t, round.ID, exportedRound.ID)
+	assert.Equal(t, exported.Bytes(), offline.Bytes())
+
+	// Full state snapshots may be imported into an empty store.
+
+	imported := store.NewInmem()
+
+	_, err = ImportState(imported, bytes.NewReader(exported.Bytes()), RoundID{1})
+	assert.Error(t, err, "state snapshots of rounds other than the one trusted must be rejected")
+
+	importedRound, err := ImportState(imported, bytes.NewReader(exported.Bytes()), round.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, round.ID, importedRound.ID)
+
+	// State snapshots that are not ahead of the latest round are rejected.
+
+	_, err = ImportState(imported, bytes.NewReader(exported.Bytes()), round.ID)
+	assert.Error(t, err)
+
+	ledger := NewLedger(imported, skademlia.NewClient(":0", keys), nil)
+	defer ledger.Close()
+
+	assert.Equal(t, round.ID, ledger.Rounds().Latest().ID)
+
+	balance, exists := ReadAccountBalance(ledger.Snapshot(), id)
+	assert.True(t, exists)
+	assert.EqualValues(t, 1000, balance)
+
+	// State snapshots holding only the difference against genesis may not be
+	// imported into an empty store.
+
+	_, buf, err := source.StateSnapshot()
+	assert.NoError(t, err)
+
+	_, err = ImportState(store.NewInmem(), bytes.NewReader(buf), round.ID)
+	assert.Error(t, err)
+}
@@ -28,6 +28,13 @@ import (
 
 type kvPair struct {
 	key, value []byte
+	delete     bool
+}
+
+func init() {
+	Register("inmem", func(string) (KV, error) {
+		return NewInmem(), nil
+	})
 }
 
 var _ WriteBatch = (*inmemWriteBatch)(nil)
@@ -40,6 +47,10 @@ func (b *inmemWriteBatch) Put(key, value []byte) {
 	b.pairs = append(b.pairs, kvPair{key: key, value: value})
 }
 
+func (b *inmemWriteBatch) Delete(key []byte) {
+	b.pairs = append(b.pairs, kvPair{key: key, delete: true})
+}
+
 func (b *inmemWriteBatch) Clear() {
 	b.pairs = make([]kvPair, 0)
 }
@@ -121,9 +132,14 @@ func (s *inmemKV) CommitWriteBatch(batch WriteBatch) error {
 
 	if wb, ok := batch.(*inmemWriteBatch); ok {
 		for _, pair := range wb.pairs {
-			_ = s.db.Set(pair.key, pair.value)
+			if pair.delete {
+				_ = s.db.Remove(pair.key)
+			} else {
+				_ = s.db.Set(pair.key, pair.value)
+			}
 		}
 
+		wb.pairs = wb.pairs[:0]
 		writeBatchPool.Put(wb)
 		return nil
 	}
@@ -139,6 +155,25 @@ func (s *inmemKV) Delete(key []byte) error {
 	return nil
 }
 
+func (s *inmemKV) IteratePrefix(prefix []byte, callback func(key, value []byte) bool) error {
+	s.RLock()
+	defer s.RUnlock()
+
+	for e := s.db.Front(); e != nil; e = e.Next() {
+		key := e.Key().([]byte)
+
+		if !bytes.HasPrefix(key, prefix) {
+			continue
+		}
+
+		if !callback(key, e.Value.([]byte)) {
+			break
+		}
+	}
+
+	return nil
+}
+
 func NewInmem() *inmemKV {
 	var comparator skiplist.GreaterThanFunc = func(lhs, rhs interface{}) bool {
 		return bytes.Compare(lhs.([]byte), rhs.([]byte)) == 1
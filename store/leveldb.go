@@ -25,8 +25,20 @@ import (
 	"github.com/syndtr/goleveldb/leveldb/filter"
 	"github.com/syndtr/goleveldb/leveldb/opt"
 	"github.com/syndtr/goleveldb/leveldb/storage"
+	"github.com/syndtr/goleveldb/leveldb/util"
 )
 
+func init() {
+	Register("leveldb", func(dir string) (KV, error) {
+		db, err := NewLevelDB(dir)
+		if err != nil {
+			return nil, err
+		}
+
+		return db, nil
+	})
+}
+
 var _ WriteBatch = (*leveldbWriteBatch)(nil)
 
 type leveldbWriteBatch struct {
@@ -37,6 +49,10 @@ func (b *leveldbWriteBatch) Put(key, value []byte) {
 	b.batch.Put(key, value)
 }
 
+func (b *leveldbWriteBatch) Delete(key []byte) {
+	b.batch.Delete(key)
+}
+
 func (b *leveldbWriteBatch) Clear() {
 	b.batch.Reset()
 }
@@ -102,6 +118,24 @@ func (l *leveldbKV) Delete(key []byte) error {
 	return l.db.Delete(key, nil)
 }
 
+func (l *leveldbKV) IteratePrefix(prefix []byte, callback func(key, value []byte) bool) error {
+	it := l.db.NewIterator(util.BytesPrefix(prefix), nil)
+	defer it.Release()
+
+	for it.Next() {
+		if !callback(it.Key(), it.Value()) {
+			break
+		}
+	}
+
+	return it.Error()
+}
+
+// Compact compacts the entire underlying database.
+func (l *leveldbKV) Compact() error {
+	return l.db.CompactRange(util.Range{})
+}
+
 func NewLevelDB(dir string) (*leveldbKV, error) {
 	opts := &opt.Options{
 		Filter:       filter.NewBloomFilter(10),
@@ -35,10 +35,24 @@ type KV interface {
 	CommitWriteBatch(batch WriteBatch) error
 
 	Delete(key []byte) error
+
+	// IteratePrefix calls callback for every key beginning with prefix, in
+	// ascending key order, until callback returns false. The key and value
+	// passed to callback must not be retained after it returns.
+	IteratePrefix(prefix []byte, callback func(key, value []byte) bool) error
+}
+
+// Compactor is implemented by stores able to reclaim the space left behind by
+// deleted and overwritten keys.
+type Compactor interface {
+	Compact() error
 }
 
+// WriteBatch accumulates writes and deletions which are applied atomically
+// once the batch is committed through KV.CommitWriteBatch.
 type WriteBatch interface {
 	Put(key, value []byte)
+	Delete(key []byte)
 
 	Clear()
 	Count() int
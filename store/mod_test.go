@@ -0,0 +1,74 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package store
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteBatchDelete(t *testing.T) {
+	for _, name := range Backends() {
+		db, err := Open(name, "")
+		if !assert.NoError(t, err) {
+			continue
+		}
+
+		prefixed, err := NewPrefixed(db, "ns")
+		assert.NoError(t, err)
+
+		for _, kv := range []KV{db, prefixed} {
+			assert.NoError(t, kv.Put([]byte("a"), []byte("1")))
+			assert.NoError(t, kv.Put([]byte("b"), []byte("2")))
+
+			batch := kv.NewWriteBatch()
+			batch.Delete([]byte("a"))
+			batch.Put([]byte("c"), []byte("3"))
+			assert.Equal(t, 2, batch.Count())
+			assert.NoError(t, kv.CommitWriteBatch(batch))
+
+			_, err := kv.Get([]byte("a"))
+			assert.Error(t, err, name)
+
+			value, err := kv.Get([]byte("b"))
+			assert.NoError(t, err, name)
+			assert.Equal(t, []byte("2"), value)
+
+			value, err = kv.Get([]byte("c"))
+			assert.NoError(t, err, name)
+			assert.Equal(t, []byte("3"), value)
+
+			// A batch created after a commit must not replay earlier writes.
+
+			batch = kv.NewWriteBatch()
+			assert.Equal(t, 0, batch.Count(), name)
+			batch.Delete([]byte("c"))
+			assert.NoError(t, kv.CommitWriteBatch(batch))
+
+			assert.NoError(t, kv.Put([]byte("a"), []byte("4")))
+
+			_, err = kv.Get([]byte("c"))
+			assert.Error(t, err, name)
+		}
+
+		assert.NoError(t, db.Close())
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package store
+
+import (
+	"github.com/pkg/errors"
+)
+
+// MaxNamespaceLen is the maximum length of a namespace.
+const MaxNamespaceLen = 255
+
+// namespaceTag leads every namespaced key. No key written outside of a namespace
+// starts with it, as ledger keys count up from 0x01 and the keys of the accounts
+// tree start with '@' or '.', such that namespaced keys may never collide with
+// the keys of a ledger sharing the same store without a namespace.
+const namespaceTag = 0xff
+
+var _ WriteBatch = (*prefixedWriteBatch)(nil)
+
+type prefixedWriteBatch struct {
+	WriteBatch
+	prefix []byte
+}
+
+func (b *prefixedWriteBatch) Put(key, value []byte) {
+	b.WriteBatch.Put(prefixKey(b.prefix, key), value)
+}
+
+func (b *prefixedWriteBatch) Delete(key []byte) {
+	b.WriteBatch.Delete(prefixKey(b.prefix, key))
+}
+
+var _ KV = (*prefixedKV)(nil)
+
+type prefixedKV struct {
+	kv     KV
+	prefix []byte
+}
+
+// NewPrefixed namespaces all keys written to and read from kv, such that the
+// state of several ledgers or components may coexist within one physical store
+// without their keys colliding. Keys are prefixed by a reserved tag byte, the
+// length of namespace and namespace itself, so that no namespace is a prefix of
+// another nor of any key written outside of a namespace.
+//
+// Closing a namespaced store does not close the store underneath it; the owner
+// of the physical store is responsible for closing it.
+func NewPrefixed(kv KV, namespace string) (KV, error) {
+	if len(namespace) == 0 {
+		return nil, errors.New("store: namespace must not be empty")
+	}
+
+	if len(namespace) > MaxNamespaceLen {
+		return nil, errors.Errorf("store: namespace must be at most %d bytes long", MaxNamespaceLen)
+	}
+
+	prefix := append([]byte{namespaceTag, byte(len(namespace))}, namespace...)
+
+	return &prefixedKV{kv: kv, prefix: prefix}, nil
+}
+
+func prefixKey(prefix, key []byte) []byte {
+	buf := make([]byte, len(prefix)+len(key))
+
+	copy(buf, prefix)
+	copy(buf[len(prefix):], key)
+
+	return buf
+}
+
+func (s *prefixedKV) Close() error {
+	return nil
+}
+
+func (s *prefixedKV) Get(key []byte) ([]byte, error) {
+	return s.kv.Get(prefixKey(s.prefix, key))
+}
+
+func (s *prefixedKV) MultiGet(keys ...[]byte) ([][]byte, error) {
+	prefixed := make([][]byte, 0, len(keys))
+
+	for _, key := range keys {
+		prefixed = append(prefixed, prefixKey(s.prefix, key))
+	}
+
+	return s.kv.MultiGet(prefixed...)
+}
+
+func (s *prefixedKV) Put(key, value []byte) error {
+	return s.kv.Put(prefixKey(s.prefix, key), value)
+}
+
+func (s *prefixedKV) NewWriteBatch() WriteBatch {
+	return &prefixedWriteBatch{WriteBatch: s.kv.NewWriteBatch(), prefix: s.prefix}
+}
+
+func (s *prefixedKV) CommitWriteBatch(batch WriteBatch) error {
+	wb, ok := batch.(*prefixedWriteBatch)
+	if !ok {
+		return errors.New("prefixed: not fed in a write batch created by this store")
+	}
+
+	return s.kv.CommitWriteBatch(wb.WriteBatch)
+}
+
+func (s *prefixedKV) Delete(key []byte) error {
+	return s.kv.Delete(prefixKey(s.prefix, key))
+}
+
+func (s *prefixedKV) IteratePrefix(prefix []byte, callback func(key, value []byte) bool) error {
+	return s.kv.IteratePrefix(prefixKey(s.prefix, prefix), func(key, value []byte) bool {
+		return callback(key[len(s.prefix):], value)
+	})
+}
+
+// Compact compacts the store underneath, should it support compaction. Keys
+// outside of the namespace are compacted as well.
+func (s *prefixedKV) Compact() error {
+	if c, ok := s.kv.(Compactor); ok {
+		return c.Compact()
+	}
+
+	return errors.New("prefixed: underlying store does not support compaction")
+}
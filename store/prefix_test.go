@@ -0,0 +1,145 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package store
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrefixedIsolation(t *testing.T) {
+	db := NewInmem()
+	defer func() {
+		_ = db.Close()
+	}()
+
+	a, err := NewPrefixed(db, "a")
+	assert.NoError(t, err)
+
+	ab, err := NewPrefixed(db, "ab")
+	assert.NoError(t, err)
+
+	assert.NoError(t, a.Put([]byte("bkey"), []byte("from a")))
+	assert.NoError(t, ab.Put([]byte("key"), []byte("from ab")))
+
+	val, err := a.Get([]byte("bkey"))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("from a"), val)
+
+	val, err = ab.Get([]byte("key"))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("from ab"), val)
+
+	_, err = ab.Get([]byte("bkey"))
+	assert.Error(t, err)
+
+	_, err = db.Get([]byte("bkey"))
+	assert.Error(t, err, "keys must not leak outside of their namespace")
+
+	batch := a.NewWriteBatch()
+	batch.Put([]byte("x"), []byte("1"))
+	batch.Put([]byte("y"), []byte("2"))
+	assert.Equal(t, 2, batch.Count())
+	assert.NoError(t, a.CommitWriteBatch(batch))
+
+	vals, err := a.MultiGet([]byte("x"), []byte("y"))
+	assert.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte("1"), []byte("2")}, vals)
+
+	_, err = ab.Get([]byte("x"))
+	assert.Error(t, err)
+
+	assert.NoError(t, a.Delete([]byte("x")))
+
+	_, err = a.Get([]byte("x"))
+	assert.Error(t, err)
+
+	assert.Error(t, ab.CommitWriteBatch(db.NewWriteBatch()))
+
+	_, err = NewPrefixed(db, "")
+	assert.Error(t, err)
+}
+
+func TestPrefixedDoesNotCollideWithUnprefixed(t *testing.T) {
+	db := NewInmem()
+	defer func() {
+		_ = db.Close()
+	}()
+
+	// A namespace 28 bytes long must not collide with unprefixed keys starting
+	// with 0x1c, its length.
+
+	namespace := strings.Repeat("n", 0x1c)
+
+	ns, err := NewPrefixed(db, namespace)
+	assert.NoError(t, err)
+
+	assert.NoError(t, db.Put(append([]byte{0x1c}, namespace+"key"...), []byte("unprefixed")))
+	assert.NoError(t, ns.Put([]byte("key"), []byte("prefixed")))
+
+	val, err := db.Get(append([]byte{0x1c}, namespace+"key"...))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("unprefixed"), val)
+
+	val, err = ns.Get([]byte("key"))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("prefixed"), val)
+}
+
+func TestIteratePrefix(t *testing.T) {
+	leveldb, err := NewLevelDB("")
+	assert.NoError(t, err)
+	defer func() {
+		_ = leveldb.Close()
+	}()
+
+	inmem := NewInmem()
+	defer func() {
+		_ = inmem.Close()
+	}()
+
+	for _, db := range []KV{inmem, leveldb} {
+		a, err := NewPrefixed(db, "a")
+		assert.NoError(t, err)
+
+		assert.NoError(t, a.Put([]byte("k2"), []byte("2")))
+		assert.NoError(t, a.Put([]byte("k1"), []byte("1")))
+		assert.NoError(t, a.Put([]byte("x"), []byte("3")))
+		assert.NoError(t, db.Put([]byte("k0"), []byte("0")))
+
+		var keys []string
+
+		assert.NoError(t, a.IteratePrefix([]byte("k"), func(key, value []byte) bool {
+			keys = append(keys, string(key))
+			return true
+		}))
+		assert.Equal(t, []string{"k1", "k2"}, keys)
+
+		keys = keys[:0]
+
+		assert.NoError(t, a.IteratePrefix(nil, func(key, value []byte) bool {
+			keys = append(keys, string(key))
+			return len(keys) < 2
+		}))
+		assert.Equal(t, []string{"k1", "k2"}, keys)
+	}
+}
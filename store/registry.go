@@ -0,0 +1,85 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package store
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultBackend is the backend a node stores its state in should no other
+// backend be specified.
+const DefaultBackend = "leveldb"
+
+// Opener opens the store of a backend located at the directory path dir. An
+// empty dir opens a temporary store, should the backend support it.
+type Opener func(dir string) (KV, error)
+
+var backends = struct {
+	sync.RWMutex
+	openers map[string]Opener
+}{openers: make(map[string]Opener)}
+
+// Register makes a backend available under name to Open. It panics should a
+// backend already be registered under name, or should opener be nil.
+func Register(name string, opener Opener) {
+	if opener == nil {
+		panic("store: opener of backend " + name + " is nil")
+	}
+
+	backends.Lock()
+	defer backends.Unlock()
+
+	if _, exists := backends.openers[name]; exists {
+		panic("store: backend " + name + " is already registered")
+	}
+
+	backends.openers[name] = opener
+}
+
+// Open opens the store located at dir using the backend registered under name.
+func Open(name, dir string) (KV, error) {
+	backends.RLock()
+	opener, exists := backends.openers[name]
+	backends.RUnlock()
+
+	if !exists {
+		return nil, errors.Errorf("unknown store backend %q; available backends are %v", name, Backends())
+	}
+
+	return opener(dir)
+}
+
+// Backends returns the names of all registered backends in sorted order.
+func Backends() []string {
+	backends.RLock()
+	names := make([]string, 0, len(backends.openers))
+
+	for name := range backends.openers {
+		names = append(names, name)
+	}
+	backends.RUnlock()
+
+	sort.Strings(names)
+
+	return names
+}
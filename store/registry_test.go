@@ -0,0 +1,58 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package store
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry(t *testing.T) {
+	assert.Contains(t, Backends(), DefaultBackend)
+	assert.Contains(t, Backends(), "inmem")
+
+	for _, name := range []string{"leveldb", "inmem"} {
+		kv, err := Open(name, "")
+		if !assert.NoError(t, err) {
+			continue
+		}
+
+		assert.NoError(t, kv.Put([]byte("key"), []byte("value")))
+
+		value, err := kv.Get([]byte("key"))
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("value"), value)
+
+		assert.NoError(t, kv.Close())
+	}
+
+	kv, err := Open("rocksdb", "")
+	assert.Error(t, err)
+	assert.Nil(t, kv)
+
+	assert.Panics(t, func() {
+		Register("inmem", func(string) (KV, error) { return NewInmem(), nil })
+	})
+
+	assert.Panics(t, func() {
+		Register("nil", nil)
+	})
+}
@@ -0,0 +1,164 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package sys
+
+import (
+	"encoding/json"
+	"github.com/pkg/errors"
+	"io/ioutil"
+)
+
+// Config holds the consensus parameters which may differ between networks,
+// such that testnets may run with their own difficulty, fees and Snowball
+// parameters without recompiling. Every ledger holds its own Config, such that
+// ledgers bound to different networks may be hosted within the same process.
+type Config struct {
+	// Snowball consensus protocol parameters.
+	SnowballK     int     `json:"snowball_k"`
+	SnowballAlpha float64 `json:"snowball_alpha"`
+	SnowballBeta  int     `json:"snowball_beta"`
+
+	// Max graph depth difference to search for eligible transaction parents from.
+	MaxDepthDiff uint64 `json:"max_depth_diff"`
+
+	// Minimum difficulty to define a critical transaction, and the factor to
+	// scale a transactions confidence down by to compute the difficulty needed.
+	MinDifficulty         byte    `json:"min_difficulty"`
+	DifficultyScaleFactor float64 `json:"difficulty_scale_factor"`
+
+	// Fee amount paid per transaction.
+	TransactionFeeAmount uint64 `json:"transaction_fee_amount"`
+
+	// Minimum amount of stake to start being able to reap validator rewards.
+	MinimumStake uint64 `json:"minimum_stake"`
+
+	// Rounds at which upgrades to the rules of the protocol activate.
+	Upgrades []Upgrade `json:"upgrades"`
+}
+
+// DefaultConfig returns the consensus parameters wavelet is compiled with.
+func DefaultConfig() Config {
+	return Config{
+		SnowballK:             SnowballK,
+		SnowballAlpha:         SnowballAlpha,
+		SnowballBeta:          SnowballBeta,
+		MaxDepthDiff:          MaxDepthDiff,
+		MinDifficulty:         MinDifficulty,
+		DifficultyScaleFactor: DifficultyScaleFactor,
+		TransactionFeeAmount:  TransactionFeeAmount,
+		MinimumStake:          MinimumStake,
+		Upgrades:              DefaultUpgrades(),
+	}
+}
+
+// LoadConfig reads consensus parameters from the JSON file at path. Parameters
+// missing from the file are left to their defaults.
+func LoadConfig(path string) (Config, error) {
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return Config{}, errors.Wrapf(err, "failed to read consensus parameters from %q", path)
+	}
+
+	config := DefaultConfig()
+
+	if err := json.Unmarshal(buf, &config); err != nil {
+		return Config{}, errors.Wrapf(err, "failed to decode consensus parameters from %q", path)
+	}
+
+	if err := config.Validate(); err != nil {
+		return Config{}, errors.Wrapf(err, "invalid consensus parameters in %q", path)
+	}
+
+	return config, nil
+}
+
+// Validate returns an error should any of the parameters be unusable.
+func (c Config) Validate() error {
+	if c.SnowballK <= 0 {
+		return errors.Errorf("snowball k must be positive, but is %d", c.SnowballK)
+	}
+
+	if c.SnowballAlpha <= 0 || c.SnowballAlpha > 1 {
+		return errors.Errorf("snowball alpha must be within (0, 1], but is %f", c.SnowballAlpha)
+	}
+
+	if c.SnowballBeta <= 0 {
+		return errors.Errorf("snowball beta must be positive, but is %d", c.SnowballBeta)
+	}
+
+	if c.MaxDepthDiff == 0 {
+		return errors.New("max depth diff must be positive")
+	}
+
+	if c.DifficultyScaleFactor <= 0 {
+		return errors.Errorf("difficulty scale factor must be positive, but is %f", c.DifficultyScaleFactor)
+	}
+
+	if err := ValidateUpgrades(c.Upgrades); err != nil {
+		return errors.Wrap(err, "invalid upgrade schedule")
+	}
+
+	return nil
+}
+
+// ProtocolVersion returns the version of the protocol under whose rules the
+// round with the given index is collapsed.
+func (c Config) ProtocolVersion(round uint64) uint32 {
+	version := ProtocolGenesis
+
+	for _, upgrade := range c.Upgrades {
+		if upgrade.Round > round {
+			break
+		}
+
+		version = upgrade.Version
+	}
+
+	return version
+}
+
+// Activates returns the latest upgrade which activates at the round with the
+// given index. It returns false should no upgrade activate at the round.
+func (c Config) Activates(round uint64) (Upgrade, bool) {
+	var activated Upgrade
+	var exists bool
+
+	for _, upgrade := range c.Upgrades {
+		if upgrade.Round == round {
+			activated, exists = upgrade, true
+		}
+	}
+
+	return activated, exists
+}
+
+// ParamDefault returns the value of a governable parameter which applies until
+// a proposal to change it passes and activates. It returns false should the
+// parameter not exist.
+func (c Config) ParamDefault(param byte) (uint64, bool) {
+	switch param {
+	case ParamTransactionFeeAmount:
+		return c.TransactionFeeAmount, true
+	case ParamMinimumStake:
+		return c.MinimumStake, true
+	}
+
+	return 0, false
+}
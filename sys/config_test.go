@@ -0,0 +1,74 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package sys
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sys")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "config.json")
+	assert.NoError(t, ioutil.WriteFile(path, []byte(`{"snowball_k": 10, "minimum_stake": 1000}`), 0644))
+
+	config, err := LoadConfig(path)
+	assert.NoError(t, err)
+
+	expected := DefaultConfig()
+	expected.SnowballK = 10
+	expected.MinimumStake = 1000
+
+	assert.Equal(t, expected, config)
+
+	assert.NoError(t, ioutil.WriteFile(path, []byte(`{"snowball_alpha": 1.5}`), 0644))
+
+	_, err = LoadConfig(path)
+	assert.Error(t, err)
+
+	_, err = LoadConfig(filepath.Join(dir, "missing.json"))
+	assert.Error(t, err)
+}
+
+func TestConfigParamDefault(t *testing.T) {
+	config := DefaultConfig()
+	config.TransactionFeeAmount = 5
+	config.MinimumStake = 7
+
+	fee, exists := config.ParamDefault(ParamTransactionFeeAmount)
+	assert.True(t, exists)
+	assert.EqualValues(t, 5, fee)
+
+	stake, exists := config.ParamDefault(ParamMinimumStake)
+	assert.True(t, exists)
+	assert.EqualValues(t, 7, stake)
+
+	_, exists = config.ParamDefault(0xff)
+	assert.False(t, exists)
+
+	fee, _ = ParamDefault(ParamTransactionFeeAmount)
+	assert.Equal(t, TransactionFeeAmount, fee, "package defaults must not be affected by a config")
+}
@@ -31,6 +31,8 @@ const (
 	TagContract
 	TagStake
 	TagBatch
+	TagRecovery
+	TagGovernance
 )
 
 const (
@@ -39,6 +41,22 @@ const (
 	WithdrawReward
 )
 
+// Opcodes of recovery transactions.
+const (
+	SetGuardians byte = iota
+	ApproveRecovery
+	CancelRecovery
+	ExecuteRecovery
+)
+
+// Opcodes of governance transactions.
+const (
+	ProposeParameter byte = iota
+	VoteParameter
+	FreezeAccount
+	UnfreezeAccount
+)
+
 var (
 	// S/Kademlia overlay network parameters.
 	SKademliaC1 = 1
@@ -58,6 +76,15 @@ var (
 	// Size of individual chunks sent for a syncing peer.
 	SyncChunkSize = 16384
 
+	// Number of missing transactions, or how long any transaction may have been
+	// missing for, past which missing transactions are fetched from several peers
+	// at once.
+	MissingRepairCount = 1024
+	MissingRepairAge   = 5 * time.Second
+
+	// Number of peers to fetch missing transactions from at once.
+	MissingRepairFanout = 4
+
 	// Max graph depth difference to search for eligible transaction
 	// parents from for our node.
 	MaxDepthDiff uint64 = 10
@@ -74,10 +101,15 @@ var (
 	// Fee amount paid by a node per transaction.
 	TransactionFeeAmount uint64 = 2
 
-	// Minimum amount of stake to start being able to reap validator rewards.
+	// Minimum amount of stake to start being able to reap validator rewards,
+	// which is also the minimum amount of rewards which may be withdrawn.
 	MinimumStake uint64 = 100
 
-	MinimumRewardWithdraw = MinimumStake
+	// Maximum number of guardians which may be designated to recover an account.
+	MaxGuardians = 16
+
+	// Percentage of all stake which must vote for a parameter change for it to pass.
+	GovernanceQuorum uint64 = 67
 
 	RewardWithdrawalsRoundLimit = 50
 
@@ -85,6 +117,11 @@ var (
 
 	FaucetAddress = "0f569c84d434fb0ca682c733176f7c0c2d853fce04d95ae131d2f9b4124d93d8"
 
+	// Human-readable part of bech32-encoded account addresses. It differs
+	// between networks, such that addresses meant for one network are rejected
+	// by another.
+	AddressHRP = "twav"
+
 	GasTable = map[string]uint64{
 		"nop":                     1,
 		"unreachable":             1,
@@ -263,22 +300,32 @@ var (
 		"wavelet.hash.sha256":     2500, // TODO: Review
 		"wavelet.hash.sha512":     3000, // TODO: Review
 		"wavelet.verify.ed25519":  5000, // TODO: Review
+		"wavelet.state.read":      500,
+		"wavelet.state.write":     2000,
+		"wavelet.state.delete":    1000,
+		"wavelet.state.byte":      10,
+		"wavelet.round.entropy":   100,
+		"wavelet.contract.call":   1000,
+		"wavelet.event.emit":      500,
+		"wavelet.event.byte":      10,
 	}
 
 	TagLabels = map[string]Tag{
-		`nop`:      TagNop,
-		`transfer`: TagTransfer,
-		`contract`: TagContract,
-		`batch`:    TagBatch,
-		`stake`:    TagStake,
+		`nop`:        TagNop,
+		`transfer`:   TagTransfer,
+		`contract`:   TagContract,
+		`batch`:      TagBatch,
+		`stake`:      TagStake,
+		`recovery`:   TagRecovery,
+		`governance`: TagGovernance,
 	}
 )
 
 // String converts a given tag to a string.
 func (tag Tag) String() string {
-	if tag < 0 || tag > 4 { // Check out of bounds
+	if tag < 0 || tag > 6 { // Check out of bounds
 		return "" // Return invalid tag
 	}
 
-	return []string{"nop", "transfer", "contract", "stake", "batch"}[tag] // Return tag
+	return []string{"nop", "transfer", "contract", "stake", "batch", "recovery", "governance"}[tag] // Return tag
 }
@@ -0,0 +1,64 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package sys
+
+// Parameters of the protocol which may be changed on-chain by staked accounts
+// voting for a governance proposal.
+const (
+	ParamTransactionFeeAmount byte = iota
+	ParamMinimumStake
+)
+
+var paramLabels = []string{"transaction_fee_amount", "minimum_stake"}
+
+// paramBounds holds the least and the most every governable parameter may be
+// changed to, such that no proposal may have fees or stakes take on values
+// which would halt the network.
+var paramBounds = [][2]uint64{
+	ParamTransactionFeeAmount: {1, 1000000},
+	ParamMinimumStake:         {1, 1000000000},
+}
+
+// ParamLabel returns the name of a governable parameter, or an empty string
+// should the parameter not exist.
+func ParamLabel(param byte) string {
+	if int(param) >= len(paramLabels) {
+		return ""
+	}
+
+	return paramLabels[param]
+}
+
+// ParamBounds returns the least and the most a governable parameter may be
+// changed to. It returns false should the parameter not exist.
+func ParamBounds(param byte) (min, max uint64, exists bool) {
+	if int(param) >= len(paramBounds) {
+		return 0, 0, false
+	}
+
+	return paramBounds[param][0], paramBounds[param][1], true
+}
+
+// ParamDefault returns the value a governable parameter takes on under the
+// consensus parameters wavelet is compiled with, until a proposal to change it
+// passes and activates. It returns false should the parameter not exist.
+func ParamDefault(param byte) (uint64, bool) {
+	return DefaultConfig().ParamDefault(param)
+}
@@ -0,0 +1,143 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package sys
+
+import "github.com/pkg/errors"
+
+// Upgrade is a change to the rules of the protocol, which activates at a
+// predeclared round such that nodes running different versions of wavelet
+// fork at a known round rather than silently diverge.
+type Upgrade struct {
+	// Protocol version the upgrade bumps the ledger to.
+	Version uint32 `json:"version"`
+
+	Name string `json:"name"`
+
+	// Index of the first round collapsed under the rules of the upgrade. It
+	// must be positive, as the genesis round is never collapsed.
+	Round uint64 `json:"round"`
+}
+
+// Protocol versions, one per upgrade.
+const (
+	ProtocolGenesis uint32 = iota
+
+	// Introduces TagRecovery.
+	ProtocolRecovery
+
+	// Introduces TagGovernance.
+	ProtocolGovernance
+
+	// Permits accounts to sign the transactions they create under secp256k1.
+	ProtocolSecp256k1
+
+	// Permits freeze authorities to freeze and unfreeze accounts.
+	ProtocolFreeze
+
+	// Gives smart contracts a local key/value state.
+	ProtocolContractState
+
+	// Exposes the entropy and index of the round to smart contracts.
+	ProtocolContractEntropy
+
+	// Permits smart contracts to call into other smart contracts.
+	ProtocolContractCalls
+
+	// Permits smart contracts to emit events.
+	ProtocolContractEvents
+
+	// Has Snowball fall back on a deterministic preference when it makes no
+	// progress finalizing a round.
+	ProtocolSnowballFallback
+)
+
+// DefaultUpgrades returns the rounds at which upgrades activate on networks
+// which do not configure a schedule of their own, in ascending order of
+// version.
+func DefaultUpgrades() []Upgrade {
+	return []Upgrade{
+		{Version: ProtocolRecovery, Name: "recovery", Round: 250000},
+		{Version: ProtocolGovernance, Name: "governance", Round: 300000},
+		{Version: ProtocolSecp256k1, Name: "secp256k1", Round: 350000},
+		{Version: ProtocolFreeze, Name: "freeze", Round: 400000},
+		{Version: ProtocolContractState, Name: "contract_state", Round: 450000},
+		{Version: ProtocolContractEntropy, Name: "contract_entropy", Round: 500000},
+		{Version: ProtocolContractCalls, Name: "contract_calls", Round: 550000},
+		{Version: ProtocolContractEvents, Name: "contract_events", Round: 600000},
+		{Version: ProtocolSnowballFallback, Name: "snowball_fallback", Round: 650000},
+	}
+}
+
+// ValidateUpgrades returns an error should the versions of upgrades not be
+// ascending, should the rounds at which they activate decrease with their
+// version, or should any of them activate at the genesis round.
+func ValidateUpgrades(upgrades []Upgrade) error {
+	for i, upgrade := range upgrades {
+		if upgrade.Round == 0 {
+			return errors.Errorf("upgrade %q may not activate at the genesis round", upgrade.Name)
+		}
+
+		if i == 0 {
+			continue
+		}
+
+		if upgrade.Version <= upgrades[i-1].Version {
+			return errors.Errorf("upgrade %q must bump the protocol version past that of upgrade %q", upgrade.Name, upgrades[i-1].Name)
+		}
+
+		if upgrade.Round < upgrades[i-1].Round {
+			return errors.Errorf("upgrade %q may not activate before upgrade %q", upgrade.Name, upgrades[i-1].Name)
+		}
+	}
+
+	return nil
+}
+
+// TagVersions maps tags to the protocol version from which transactions with
+// the tag may be applied. Tags not listed have been supported since genesis.
+var TagVersions = map[Tag]uint32{
+	TagRecovery:   ProtocolRecovery,
+	TagGovernance: ProtocolGovernance,
+}
+
+// TagActive returns true if transactions with the given tag may be applied under
+// the specified protocol version.
+func TagActive(tag Tag, version uint32) bool {
+	return version >= TagVersions[tag]
+}
+
+// ImportVersions maps functions the host exposes to smart contracts to the
+// protocol version from which smart contracts may call them. Functions not
+// listed have been exposed since genesis.
+var ImportVersions = map[string]uint32{
+	"_read_state":    ProtocolContractState,
+	"_write_state":   ProtocolContractState,
+	"_delete_state":  ProtocolContractState,
+	"_round_entropy": ProtocolContractEntropy,
+	"_round_index":   ProtocolContractEntropy,
+	"_call_contract": ProtocolContractCalls,
+	"_emit_event":    ProtocolContractEvents,
+}
+
+// ImportActive returns true if smart contracts may call the function the host
+// exposes under the given name under the specified protocol version.
+func ImportActive(name string, version uint32) bool {
+	return version >= ImportVersions[name]
+}
@@ -0,0 +1,114 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"math/rand"
+	"time"
+)
+
+// TimeoutConfig describes how long the ledger waits on its peers before giving
+// up on them, and how long it backs off before retrying. Any duration left at zero
+// falls back to its default.
+type TimeoutConfig struct {
+	// Deadline for a single peer to respond to a consensus query.
+	Query time.Duration
+
+	// Deadline for a single peer to respond with its latest round when checking
+	// whether or not we are out of sync.
+	OutOfSync time.Duration
+
+	// Deadline for a single peer to respond with transactions we are missing.
+	Download time.Duration
+
+	// Delay before retrying after a failed attempt. It doubles with every
+	// consecutive failed attempt, up until MaxRetryBackoff.
+	RetryBackoff    time.Duration
+	MaxRetryBackoff time.Duration
+
+	// Fraction of the retry delay, in [0, 1], by which it is randomly spread out
+	// such that nodes which failed at the same time do not all retry in lockstep.
+	RetryJitter float64
+}
+
+func DefaultTimeoutConfig() TimeoutConfig {
+	return TimeoutConfig{
+		Query:           3 * time.Second,
+		OutOfSync:       3 * time.Second,
+		Download:        3 * time.Second,
+		RetryBackoff:    1 * time.Second,
+		MaxRetryBackoff: 30 * time.Second,
+		RetryJitter:     0.2,
+	}
+}
+
+func (c TimeoutConfig) withDefaults() TimeoutConfig {
+	defaults := DefaultTimeoutConfig()
+
+	if c.Query <= 0 {
+		c.Query = defaults.Query
+	}
+
+	if c.OutOfSync <= 0 {
+		c.OutOfSync = defaults.OutOfSync
+	}
+
+	if c.Download <= 0 {
+		c.Download = defaults.Download
+	}
+
+	if c.RetryBackoff <= 0 {
+		c.RetryBackoff = defaults.RetryBackoff
+	}
+
+	if c.MaxRetryBackoff < c.RetryBackoff {
+		c.MaxRetryBackoff = c.RetryBackoff
+	}
+
+	if c.RetryJitter < 0 {
+		c.RetryJitter = 0
+	}
+
+	if c.RetryJitter > 1 {
+		c.RetryJitter = 1
+	}
+
+	return c
+}
+
+// Backoff returns how long to wait before retrying after the given number of
+// consecutive failed attempts.
+func (c TimeoutConfig) Backoff(attempts int) time.Duration {
+	delay := c.RetryBackoff
+
+	for i := 1; i < attempts && delay < c.MaxRetryBackoff; i++ {
+		delay *= 2
+	}
+
+	if delay > c.MaxRetryBackoff {
+		delay = c.MaxRetryBackoff
+	}
+
+	if spread := int64(float64(delay) * c.RetryJitter); spread > 0 {
+		delay += time.Duration(rand.Int63n(2*spread+1) - spread)
+	}
+
+	return delay
+}
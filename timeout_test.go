@@ -0,0 +1,48 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimeoutBackoff(t *testing.T) {
+	t.Parallel()
+
+	config := TimeoutConfig{RetryBackoff: 1 * time.Second, MaxRetryBackoff: 5 * time.Second}.withDefaults()
+
+	assert.Equal(t, DefaultTimeoutConfig().Query, config.Query)
+	assert.Equal(t, 1*time.Second, config.Backoff(1))
+	assert.Equal(t, 2*time.Second, config.Backoff(2))
+	assert.Equal(t, 4*time.Second, config.Backoff(3))
+	assert.Equal(t, 5*time.Second, config.Backoff(4))
+	assert.Equal(t, 5*time.Second, config.Backoff(100))
+
+	config.RetryJitter = 0.5
+
+	for i := 0; i < 100; i++ {
+		delay := config.Backoff(1)
+
+		assert.True(t, delay >= 500*time.Millisecond && delay <= 1500*time.Millisecond)
+	}
+}
@@ -30,7 +30,9 @@ import (
 
 	"github.com/perlin-network/noise/edwards25519"
 	"github.com/perlin-network/noise/skademlia"
+	"github.com/perlin-network/wavelet/secp256k1"
 	"github.com/perlin-network/wavelet/sys"
+	"github.com/perlin-network/wavelet/wallet"
 	"github.com/pkg/errors"
 	"golang.org/x/crypto/blake2b"
 )
@@ -51,6 +53,8 @@ type Transaction struct {
 	SenderSignature  Signature
 	CreatorSignature Signature
 
+	CreatorScheme SignatureScheme // Scheme the creator signed under.
+
 	ID TransactionID // BLAKE2b(*).
 
 	Seed    [blake2b.Size256]byte // BLAKE2b(Sender || ParentIDs)
@@ -58,16 +62,62 @@ type Transaction struct {
 }
 
 func NewTransaction(creator *skademlia.Keypair, tag sys.Tag, payload []byte) Transaction {
-	tx := Transaction{Tag: tag, Payload: payload}
+	return NewTransactionWithNonce(creator, 0, tag, payload)
+}
+
+// NewTransactionWithNonce creates a transaction which the creator signs under
+// nonce. Transactions signed by the same creator under the same non-zero nonce
+// conflict with one another, such that at most one of them is expected to be
+// honest. A nonce of zero leaves the transaction unsequenced.
+func NewTransactionWithNonce(creator *skademlia.Keypair, nonce uint64, tag sys.Tag, payload []byte) Transaction {
+	tx := Transaction{Nonce: nonce, Tag: tag, Payload: payload}
+
+	tx.Creator = creator.PublicKey()
+	tx.CreatorSignature = edwards25519.Sign(creator.PrivateKey(), tx.creatorMessage())
+
+	return tx
+}
 
-	var nonce [8]byte // TODO(kenta): nonce
+// SignTransaction creates a transaction which creator signs under nonce. Unlike
+// NewTransactionWithNonce, the private key of the creator need not be held in
+// memory, such as should it be held by Vault.
+func SignTransaction(creator wallet.Signer, nonce uint64, tag sys.Tag, payload []byte) (Transaction, error) {
+	tx := Transaction{Nonce: nonce, Tag: tag, Payload: payload}
 
 	tx.Creator = creator.PublicKey()
-	tx.CreatorSignature = edwards25519.Sign(creator.PrivateKey(), append(nonce[:], append([]byte{byte(tx.Tag)}, tx.Payload...)...))
+
+	signature, err := creator.Sign(tx.creatorMessage())
+	if err != nil {
+		return tx, errors.Wrap(err, "failed to sign transaction as its creator")
+	}
+
+	tx.CreatorSignature = signature
+
+	return tx, nil
+}
+
+// NewSecp256k1Transaction creates a transaction on behalf of the account
+// controlled by a secp256k1 key. The transaction must be attached to a sender
+// controlled by an Ed25519 key.
+func NewSecp256k1Transaction(creator *secp256k1.PrivateKey, tag sys.Tag, payload []byte) Transaction {
+	tx := Transaction{Tag: tag, Payload: payload, CreatorScheme: SignatureSecp256k1}
+
+	tx.Creator = creator.PublicKey().ID()
+	tx.CreatorSignature = creator.Sign(tx.creatorMessage())
 
 	return tx
 }
 
+// creatorMessage returns the message the creator of a transaction signs, which
+// is comprised of the big-endian nonce of the transaction followed by its tag
+// and payload.
+func (t Transaction) creatorMessage() []byte {
+	var nonce [8]byte
+	binary.BigEndian.PutUint64(nonce[:], t.Nonce)
+
+	return append(nonce[:], append([]byte{byte(t.Tag)}, t.Payload...)...)
+}
+
 func NewBatchTransaction(creator *skademlia.Keypair, tags []byte, payloads [][]byte) Transaction {
 	if len(tags) != len(payloads) {
 		panic("UNEXPECTED: Number of tags must be equivalent to number of payloads.")
@@ -92,6 +142,44 @@ func NewBatchTransaction(creator *skademlia.Keypair, tags []byte, payloads [][]b
 }
 
 func AttachSenderToTransaction(sender *skademlia.Keypair, tx Transaction, parents ...*Transaction) Transaction {
+	tx = attachParentsToTransaction(tx, parents)
+	tx.Sender = sender.PublicKey()
+
+	buf := AcquireBuffer()
+	tx.Write(buf)
+	tx.SenderSignature = edwards25519.Sign(sender.PrivateKey(), buf.Bytes())
+	ReleaseBuffer(buf)
+
+	tx.rehash()
+
+	return tx
+}
+
+// AttachSignerToTransaction attaches parents to a transaction, which sender
+// then signs as the sender of the transaction. Unlike AttachSenderToTransaction,
+// the private key of the sender need not be held in memory.
+func AttachSignerToTransaction(sender wallet.Signer, tx Transaction, parents ...*Transaction) (Transaction, error) {
+	tx = attachParentsToTransaction(tx, parents)
+	tx.Sender = sender.PublicKey()
+
+	buf := AcquireBuffer()
+	tx.Write(buf)
+	signature, err := sender.Sign(buf.Bytes())
+	ReleaseBuffer(buf)
+
+	if err != nil {
+		return tx, errors.Wrap(err, "failed to sign transaction as its sender")
+	}
+
+	tx.SenderSignature = signature
+	tx.rehash()
+
+	return tx, nil
+}
+
+// attachParentsToTransaction sets the parents of a transaction, and places it
+// one level deeper in the graph than the deepest of its parents.
+func attachParentsToTransaction(tx Transaction, parents []*Transaction) Transaction {
 	if len(parents) > 0 {
 		tx.ParentIDs = make([]TransactionID, 0, len(parents))
 
@@ -110,36 +198,57 @@ func AttachSenderToTransaction(sender *skademlia.Keypair, tx Transaction, parent
 		})
 	}
 
-	tx.Sender = sender.PublicKey()
-	tx.SenderSignature = edwards25519.Sign(sender.PrivateKey(), tx.Marshal())
-
-	tx.rehash()
-
 	return tx
 }
 
 func (t *Transaction) rehash() *Transaction {
-	t.ID = blake2b.Sum256(t.Marshal())
+	buf := AcquireBuffer()
+	defer ReleaseBuffer(buf)
 
-	buf := make([]byte, 0, SizeAccountID+len(t.ParentIDs)*SizeTransactionID)
-	buf = append(buf, t.Sender[:]...)
+	t.Write(buf)
+	t.ID = blake2b.Sum256(buf.Bytes())
+
+	buf.Reset()
+
+	buf.Write(t.Sender[:])
 	for _, parentID := range t.ParentIDs {
-		buf = append(buf, parentID[:]...)
+		buf.Write(parentID[:])
 	}
 
-	t.Seed = blake2b.Sum256(buf)
+	t.Seed = blake2b.Sum256(buf.Bytes())
 	t.SeedLen = byte(prefixLen(t.Seed[:]))
 
 	return t
 }
 
+// size returns the length of the marshaled form of the transaction.
+func (t Transaction) size() int {
+	n := SizeAccountID + 1 + 8 + 1 + len(t.ParentIDs)*SizeTransactionID + 8 + 1 + 4 + len(t.Payload) + SizeSignature
+
+	if t.Creator != t.Sender {
+		n += SizeAccountID + SizeSignature
+	}
+
+	return n
+}
+
 func (t Transaction) Marshal() []byte {
-	w := bytes.NewBuffer(make([]byte, 0, 222+(32*len(t.ParentIDs))+len(t.Payload)))
+	w := bytes.NewBuffer(make([]byte, 0, t.size()))
+	t.Write(w)
+
+	return w.Bytes()
+}
+
+// Write writes the marshaled form of the transaction to w. Paths which only
+// hash or sign the marshaled form of a transaction should write it to a pooled
+// buffer acquired with AcquireBuffer, rather than allocate it with Marshal.
+func (t Transaction) Write(w *bytes.Buffer) {
+	w.Grow(t.size())
 
 	w.Write(t.Sender[:])
 
 	if t.Creator != t.Sender {
-		w.WriteByte(1)
+		w.WriteByte(1 + byte(t.CreatorScheme))
 		w.Write(t.Creator[:])
 	} else {
 		w.WriteByte(0)
@@ -169,8 +278,6 @@ func (t Transaction) Marshal() []byte {
 	if t.Creator != t.Sender {
 		w.Write(t.CreatorSignature[:])
 	}
-
-	return w.Bytes()
 }
 
 func UnmarshalTransaction(r io.Reader) (t Transaction, err error) {
@@ -186,12 +293,19 @@ func UnmarshalTransaction(r io.Reader) (t Transaction, err error) {
 		return
 	}
 
-	if buf[0] != 0 && buf[0] != 1 {
-		err = errors.Errorf("flag must be zero or one, but is %d instead", buf[0])
+	// The flag is one plus the signature scheme of the creator should the
+	// creator be recorded.
+
+	if buf[0] > 1+byte(SignatureSecp256k1) {
+		err = errors.Errorf("flag must be at most %d, but is %d instead", 1+byte(SignatureSecp256k1), buf[0])
 		return
 	}
 
-	creatorRecorded := buf[0] == 1
+	creatorRecorded := buf[0] != 0
+
+	if creatorRecorded {
+		t.CreatorScheme = SignatureScheme(buf[0] - 1)
+	}
 
 	if !creatorRecorded {
 		t.Creator = t.Sender
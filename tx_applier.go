@@ -29,12 +29,72 @@ import (
 	"github.com/pkg/errors"
 )
 
+// applyContext carries what applying a transaction as part of collapsing the
+// transactions of a round requires beyond the state being applied to. A nil
+// applyContext is valid, and applies a transaction outside of any round.
+type applyContext struct {
+	critical *Transaction // Critical transaction of the round being finalized.
+
+	events []ContractEvent // Events emitted by smart contracts invoked.
+
+	// Whether anything applied depended on the critical transaction, such that
+	// the state applied to may not be reused while collapsing towards another
+	// critical transaction.
+	dependsOnCritical bool
+
+	logs   *log.Scope  // Scope of the ledger the round is being finalized by.
+	config *sys.Config // Consensus parameters of the ledger.
+}
+
+func (c *applyContext) criticalTransaction() *Transaction {
+	if c == nil {
+		return nil
+	}
+
+	c.dependsOnCritical = true
+
+	return c.critical
+}
+
+func (c *applyContext) scope() *log.Scope {
+	if c == nil || c.logs == nil {
+		return log.Root()
+	}
+
+	return c.logs
+}
+
+// param returns the value a governable parameter takes on in the round with the
+// given index, falling back to the consensus parameters of the ledger should no
+// proposal to change it have passed.
+func (c *applyContext) param(snapshot *avl.Tree, param byte, round uint64) uint64 {
+	config := sys.DefaultConfig()
+
+	if c != nil && c.config != nil {
+		config = *c.config
+	}
+
+	fallback, _ := config.ParamDefault(param)
+
+	return readParam(snapshot, param, round, fallback)
+}
+
+func (c *applyContext) emit(events []ContractEvent) {
+	if c != nil {
+		c.events = append(c.events, events...)
+	}
+}
+
 type ContractExecutorState struct {
 	Sender   AccountID
 	GasLimit uint64
 }
 
 func ApplyTransferTransaction(snapshot *avl.Tree, round *Round, tx *Transaction, state *ContractExecutorState) (*avl.Tree, error) {
+	return applyTransferTransaction(snapshot, round, tx, state, nil)
+}
+
+func applyTransferTransaction(snapshot *avl.Tree, round *Round, tx *Transaction, state *ContractExecutorState, ctx *applyContext) (*avl.Tree, error) {
 	params, err := ParseTransferTransaction(tx.Payload)
 	if err != nil {
 		return nil, err
@@ -46,6 +106,10 @@ func ApplyTransferTransaction(snapshot *avl.Tree, round *Round, tx *Transaction,
 		return nil, errors.New("transfer: transactions to non-contract accounts should not specify gas limit or function names or params")
 	}
 
+	if err := checkNotFrozen(snapshot, tx.Creator); err != nil {
+		return nil, errors.Wrap(err, "transfer")
+	}
+
 	senderBalance, _ := ReadAccountBalance(snapshot, tx.Creator)
 
 	// FIXME(kenta): FOR TESTNET ONLY. FAUCET DOES NOT GET ANY PERLs DEDUCTED.
@@ -80,6 +144,10 @@ func ApplyTransferTransaction(snapshot *avl.Tree, round *Round, tx *Transaction,
 		return nil, errors.New("transfer: gas limit for invoking smart contract must be greater than zero")
 	}
 
+	if err := checkNotFrozen(snapshot, sender); err != nil {
+		return nil, errors.Wrap(err, "transfer")
+	}
+
 	senderBalance, _ = ReadAccountBalance(snapshot, sender)
 
 	if senderBalance < params.GasLimit {
@@ -92,7 +160,7 @@ func ApplyTransferTransaction(snapshot *avl.Tree, round *Round, tx *Transaction,
 	recipientBalance, _ := ReadAccountBalance(snapshot, params.Recipient)
 	WriteAccountBalance(snapshot, params.Recipient, recipientBalance+params.Amount)
 
-	executor := &ContractExecutor{}
+	executor := &ContractExecutor{critical: ctx.criticalTransaction(), logs: ctx.scope()}
 
 	if err := executor.Execute(snapshot, params.Recipient, round, tx, params.Amount, params.GasLimit, string(params.FuncName), params.FuncParams, code); err != nil {
 		return nil, errors.Wrap(err, "transfer: failed to invoke smart contract")
@@ -104,7 +172,7 @@ func ApplyTransferTransaction(snapshot *avl.Tree, round *Round, tx *Transaction,
 		recipientBalance, _ := ReadAccountBalance(snapshot, params.Recipient)
 		WriteAccountBalance(snapshot, params.Recipient, recipientBalance)
 
-		logger := log.Contracts("gas")
+		logger := ctx.scope().Contracts("gas")
 		logger.Info().
 			Hex("sender_id", tx.Creator[:]).
 			Hex("contract_id", params.Recipient[:]).
@@ -114,7 +182,7 @@ func ApplyTransferTransaction(snapshot *avl.Tree, round *Round, tx *Transaction,
 	} else {
 		WriteAccountBalance(snapshot, tx.Creator, senderBalance-params.Amount-executor.Gas)
 
-		logger := log.Contracts("gas")
+		logger := ctx.scope().Contracts("gas")
 		logger.Info().
 			Hex("sender_id", tx.Creator[:]).
 			Hex("contract_id", params.Recipient[:]).
@@ -122,6 +190,8 @@ func ApplyTransferTransaction(snapshot *avl.Tree, round *Round, tx *Transaction,
 			Uint64("gas_limit", params.GasLimit).
 			Msg("Deducted PERLs for invoking smart contract function.")
 
+		ctx.emit(executor.Events)
+
 		if state == nil {
 			state = &ContractExecutorState{Sender: tx.Sender}
 		}
@@ -134,19 +204,19 @@ func ApplyTransferTransaction(snapshot *avl.Tree, round *Round, tx *Transaction,
 			switch entry.Tag {
 			case sys.TagNop:
 			case sys.TagTransfer:
-				if _, err := ApplyTransferTransaction(snapshot, round, entry, state); err != nil {
+				if _, err := applyTransferTransaction(snapshot, round, entry, state, ctx); err != nil {
 					return nil, err
 				}
 			case sys.TagStake:
-				if _, err := ApplyStakeTransaction(snapshot, round, entry); err != nil {
+				if _, err := applyStakeTransaction(snapshot, round, entry, ctx); err != nil {
 					return nil, err
 				}
 			case sys.TagContract:
-				if _, err := ApplyContractTransaction(snapshot, round, entry, state); err != nil {
+				if _, err := applyContractTransaction(snapshot, round, entry, state, ctx); err != nil {
 					return nil, err
 				}
 			case sys.TagBatch:
-				if _, err := ApplyBatchTransaction(snapshot, round, entry); err != nil {
+				if _, err := applyBatchTransaction(snapshot, round, entry, ctx); err != nil {
 					return nil, err
 				}
 			}
@@ -157,11 +227,19 @@ func ApplyTransferTransaction(snapshot *avl.Tree, round *Round, tx *Transaction,
 }
 
 func ApplyStakeTransaction(snapshot *avl.Tree, round *Round, tx *Transaction) (*avl.Tree, error) {
+	return applyStakeTransaction(snapshot, round, tx, nil)
+}
+
+func applyStakeTransaction(snapshot *avl.Tree, round *Round, tx *Transaction, ctx *applyContext) (*avl.Tree, error) {
 	params, err := ParseStakeTransaction(tx.Payload)
 	if err != nil {
 		return nil, err
 	}
 
+	if err := checkNotFrozen(snapshot, tx.Creator); err != nil {
+		return nil, errors.Wrap(err, "stake")
+	}
+
 	balance, _ := ReadAccountBalance(snapshot, tx.Creator)
 	stake, _ := ReadAccountStake(snapshot, tx.Creator)
 	reward, _ := ReadAccountReward(snapshot, tx.Creator)
@@ -179,11 +257,15 @@ func ApplyStakeTransaction(snapshot *avl.Tree, round *Round, tx *Transaction) (*
 			return nil, errors.Errorf("stake: %x attempt to withdraw a stake of %d PERLs, but only has staked %d PERLs", tx.Creator, params.Amount, stake)
 		}
 
+		if until, locked := ReadAccountStakeLock(snapshot, tx.Creator); locked && round.Index < until {
+			return nil, errors.Errorf("stake: %x voted with its stake in a vote which closes at round %d, and may not withdraw its stake until then", tx.Creator, until)
+		}
+
 		WriteAccountBalance(snapshot, tx.Creator, balance+params.Amount)
 		WriteAccountStake(snapshot, tx.Creator, stake-params.Amount)
 	case sys.WithdrawReward:
-		if params.Amount < sys.MinimumRewardWithdraw {
-			return nil, errors.Errorf("stake: %x attempt to withdraw rewards amounting to %d PERLs, but system requires the minimum amount to withdraw to be %d PERLs", tx.Creator, params.Amount, sys.MinimumRewardWithdraw)
+		if minimum := ctx.param(snapshot, sys.ParamMinimumStake, round.Index); params.Amount < minimum {
+			return nil, errors.Errorf("stake: %x attempt to withdraw rewards amounting to %d PERLs, but system requires the minimum amount to withdraw to be %d PERLs", tx.Creator, params.Amount, minimum)
 		}
 
 		if reward < params.Amount {
@@ -202,6 +284,10 @@ func ApplyStakeTransaction(snapshot *avl.Tree, round *Round, tx *Transaction) (*
 }
 
 func ApplyContractTransaction(snapshot *avl.Tree, round *Round, tx *Transaction, state *ContractExecutorState) (*avl.Tree, error) {
+	return applyContractTransaction(snapshot, round, tx, state, nil)
+}
+
+func applyContractTransaction(snapshot *avl.Tree, round *Round, tx *Transaction, state *ContractExecutorState, ctx *applyContext) (*avl.Tree, error) {
 	params, err := ParseContractTransaction(tx.Payload)
 	if err != nil {
 		return nil, err
@@ -221,13 +307,17 @@ func ApplyContractTransaction(snapshot *avl.Tree, round *Round, tx *Transaction,
 		return nil, errors.New("contract: gas limit for invoking smart contract must be greater than zero")
 	}
 
+	if err := checkNotFrozen(snapshot, sender); err != nil {
+		return nil, errors.Wrap(err, "contract")
+	}
+
 	balance, _ := ReadAccountBalance(snapshot, sender)
 
 	if balance < params.GasLimit {
 		return nil, errors.Errorf("contract: %x tried to spawn a contract using a gas limit of %d PERLs but only has %d PERLs", sender, params.GasLimit, balance)
 	}
 
-	executor := &ContractExecutor{}
+	executor := &ContractExecutor{critical: ctx.criticalTransaction(), logs: ctx.scope()}
 
 	if err := executor.Execute(snapshot, tx.ID, round, tx, 0, params.GasLimit, `init`, params.Params, params.Code); err != nil {
 		return nil, errors.Wrap(err, "contract: failed to init smart contract")
@@ -236,6 +326,8 @@ func ApplyContractTransaction(snapshot *avl.Tree, round *Round, tx *Transaction,
 	WriteAccountBalance(snapshot, tx.Creator, balance-executor.Gas)
 
 	if !executor.GasLimitExceeded {
+		ctx.emit(executor.Events)
+
 		if state == nil {
 			state = &ContractExecutorState{Sender: tx.Sender}
 		}
@@ -248,19 +340,19 @@ func ApplyContractTransaction(snapshot *avl.Tree, round *Round, tx *Transaction,
 			switch entry.Tag {
 			case sys.TagNop:
 			case sys.TagTransfer:
-				if _, err := ApplyTransferTransaction(snapshot, round, entry, state); err != nil {
+				if _, err := applyTransferTransaction(snapshot, round, entry, state, ctx); err != nil {
 					return nil, err
 				}
 			case sys.TagStake:
-				if _, err := ApplyStakeTransaction(snapshot, round, entry); err != nil {
+				if _, err := applyStakeTransaction(snapshot, round, entry, ctx); err != nil {
 					return nil, err
 				}
 			case sys.TagContract:
-				if _, err := ApplyContractTransaction(snapshot, round, entry, state); err != nil {
+				if _, err := applyContractTransaction(snapshot, round, entry, state, ctx); err != nil {
 					return nil, err
 				}
 			case sys.TagBatch:
-				if _, err := ApplyBatchTransaction(snapshot, round, entry); err != nil {
+				if _, err := applyBatchTransaction(snapshot, round, entry, ctx); err != nil {
 					return nil, err
 				}
 			}
@@ -269,7 +361,7 @@ func ApplyContractTransaction(snapshot *avl.Tree, round *Round, tx *Transaction,
 		WriteAccountContractCode(snapshot, tx.ID, params.Code)
 	}
 
-	logger := log.Contracts("gas")
+	logger := ctx.scope().Contracts("gas")
 	logger.Info().
 		Hex("creator_id", tx.Creator[:]).
 		Hex("contract_id", tx.ID[:]).
@@ -281,6 +373,10 @@ func ApplyContractTransaction(snapshot *avl.Tree, round *Round, tx *Transaction,
 }
 
 func ApplyBatchTransaction(snapshot *avl.Tree, round *Round, tx *Transaction) (*avl.Tree, error) {
+	return applyBatchTransaction(snapshot, round, tx, nil)
+}
+
+func applyBatchTransaction(snapshot *avl.Tree, round *Round, tx *Transaction, ctx *applyContext) (*avl.Tree, error) {
 	params, err := ParseBatchTransaction(tx.Payload)
 	if err != nil {
 		return nil, err
@@ -296,23 +392,298 @@ func ApplyBatchTransaction(snapshot *avl.Tree, round *Round, tx *Transaction) (*
 			Payload: params.Payloads[i],
 		}
 
+		if version := ReadProtocolVersion(snapshot); !sys.TagActive(entry.Tag, version) {
+			return nil, errors.Errorf("transactions tagged %s may not be applied under protocol version %d", entry.Tag, version)
+		}
+
 		switch entry.Tag {
 		case sys.TagNop:
 		case sys.TagTransfer:
-			if _, err := ApplyTransferTransaction(snapshot, round, entry, nil); err != nil {
+			if _, err := applyTransferTransaction(snapshot, round, entry, nil, ctx); err != nil {
 				return nil, err
 			}
 		case sys.TagStake:
-			if _, err := ApplyStakeTransaction(snapshot, round, entry); err != nil {
+			if _, err := applyStakeTransaction(snapshot, round, entry, ctx); err != nil {
 				fmt.Println(err)
 				return nil, err
 			}
 		case sys.TagContract:
-			if _, err := ApplyContractTransaction(snapshot, round, entry, nil); err != nil {
+			if _, err := applyContractTransaction(snapshot, round, entry, nil, ctx); err != nil {
+				return nil, err
+			}
+		case sys.TagRecovery:
+			if _, err := applyRecoveryTransaction(snapshot, round, entry, ctx); err != nil {
 				return nil, err
 			}
+		case sys.TagGovernance:
+			if _, err := applyGovernanceTransaction(snapshot, round, entry, ctx); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return snapshot, nil
+}
+
+func ApplyRecoveryTransaction(snapshot *avl.Tree, round *Round, tx *Transaction) (*avl.Tree, error) {
+	return applyRecoveryTransaction(snapshot, round, tx, nil)
+}
+
+func applyRecoveryTransaction(snapshot *avl.Tree, round *Round, tx *Transaction, ctx *applyContext) (*avl.Tree, error) {
+	params, err := ParseRecoveryTransaction(tx.Payload)
+	if err != nil {
+		return nil, err
+	}
+
+	switch params.Opcode {
+	case sys.SetGuardians:
+		// Designating a new set of guardians voids any recovery that is pending.
+
+		DeleteAccountRecovery(snapshot, tx.Creator)
+		WriteAccountGuardians(snapshot, tx.Creator, Guardians{Threshold: params.Threshold, Delay: params.Delay, Accounts: params.Guardians})
+	case sys.ApproveRecovery:
+		guardians, exists := ReadAccountGuardians(snapshot, params.Account)
+		if !exists || !guardians.Contains(tx.Creator) {
+			return nil, errors.Errorf("recovery: %x is not a guardian of %x", tx.Creator, params.Account)
+		}
+
+		recovery, pending := ReadAccountRecovery(snapshot, params.Account)
+
+		if pending && recovery.NewKey != params.NewKey {
+			if recovery.Ready {
+				return nil, errors.Errorf("recovery: %x is already being recovered to %x", params.Account, recovery.NewKey)
+			}
+
+			pending = false
+		}
+
+		if !pending {
+			recovery = PendingRecovery{NewKey: params.NewKey}
+		}
+
+		for _, approval := range recovery.Approvals {
+			if approval == tx.Creator {
+				return nil, errors.Errorf("recovery: %x has already approved recovering %x", tx.Creator, params.Account)
+			}
+		}
+
+		recovery.Approvals = append(recovery.Approvals, tx.Creator)
+
+		if !recovery.Ready && len(recovery.Approvals) >= int(guardians.Threshold) {
+			recovery.Ready = true
+			recovery.ReadyRound = round.Index
+		}
+
+		WriteAccountRecovery(snapshot, params.Account, recovery)
+	case sys.CancelRecovery:
+		if _, pending := ReadAccountRecovery(snapshot, tx.Creator); !pending {
+			return nil, errors.Errorf("recovery: %x has no pending recovery to cancel", tx.Creator)
+		}
+
+		DeleteAccountRecovery(snapshot, tx.Creator)
+	case sys.ExecuteRecovery:
+		recovery, pending := ReadAccountRecovery(snapshot, params.Account)
+		if !pending || !recovery.Ready {
+			return nil, errors.Errorf("recovery: %x has not been approved to be recovered by its guardians", params.Account)
+		}
+
+		guardians, _ := ReadAccountGuardians(snapshot, params.Account)
+
+		if round.Index < recovery.ReadyRound+guardians.Delay {
+			return nil, errors.Errorf("recovery: %x may only be recovered from round %d onwards", params.Account, recovery.ReadyRound+guardians.Delay)
+		}
+
+		// Stake which was voted with may not be carried over to an account which
+		// may vote with it again until the vote closes.
+
+		if until, locked := ReadAccountStakeLock(snapshot, params.Account); locked && round.Index < until {
+			return nil, errors.Errorf("recovery: %x voted with its stake in a vote which closes at round %d, and may only be recovered from then onwards", params.Account, until)
+		}
+
+		balance, _ := ReadAccountBalance(snapshot, params.Account)
+		stake, _ := ReadAccountStake(snapshot, params.Account)
+		reward, _ := ReadAccountReward(snapshot, params.Account)
+
+		newBalance, _ := ReadAccountBalance(snapshot, recovery.NewKey)
+		newStake, _ := ReadAccountStake(snapshot, recovery.NewKey)
+		newReward, _ := ReadAccountReward(snapshot, recovery.NewKey)
+
+		WriteAccountBalance(snapshot, recovery.NewKey, newBalance+balance)
+		WriteAccountStake(snapshot, recovery.NewKey, newStake+stake)
+		WriteAccountReward(snapshot, recovery.NewKey, newReward+reward)
+
+		WriteAccountBalance(snapshot, params.Account, 0)
+		WriteAccountStake(snapshot, params.Account, 0)
+		WriteAccountReward(snapshot, params.Account, 0)
+
+		// Recovering an account does not lift its freeze.
+
+		if freeze, frozen := ReadAccountFrozen(snapshot, params.Account); frozen {
+			if _, alreadyFrozen := ReadAccountFrozen(snapshot, recovery.NewKey); !alreadyFrozen {
+				WriteAccountFrozen(snapshot, recovery.NewKey, freeze)
+			}
+		}
+
+		if _, exists := ReadAccountGuardians(snapshot, recovery.NewKey); !exists {
+			WriteAccountGuardians(snapshot, recovery.NewKey, guardians)
+		}
+
+		WriteAccountGuardians(snapshot, params.Account, Guardians{})
+		DeleteAccountRecovery(snapshot, params.Account)
+
+		WriteAccountRecoveredTo(snapshot, params.Account, recovery.NewKey)
+
+		logger := ctx.scope().Accounts("recovered")
+		logger.Info().
+			Hex("account_id", params.Account[:]).
+			Hex("new_key", recovery.NewKey[:]).
+			Uint64("balance", balance).
+			Uint64("stake", stake).
+			Uint64("reward", reward).
+			Msg("Account has been recovered by its guardians.")
+	}
+
+	return snapshot, nil
+}
+
+func ApplyGovernanceTransaction(snapshot *avl.Tree, round *Round, tx *Transaction) (*avl.Tree, error) {
+	return applyGovernanceTransaction(snapshot, round, tx, nil)
+}
+
+func applyGovernanceTransaction(snapshot *avl.Tree, round *Round, tx *Transaction, ctx *applyContext) (*avl.Tree, error) {
+	params, err := ParseGovernanceTransaction(tx.Payload)
+	if err != nil {
+		return nil, err
+	}
+
+	if params.Opcode == sys.FreezeAccount || params.Opcode == sys.UnfreezeAccount {
+		return applyFreezeTransaction(snapshot, round, tx, params, ctx)
+	}
+
+	stake, _ := ReadAccountStake(snapshot, tx.Creator)
+
+	if minimum := ctx.param(snapshot, sys.ParamMinimumStake, round.Index); stake == 0 || stake < minimum {
+		return nil, errors.Errorf("governance: %x must have a stake of at least %d PERLs to propose or vote for parameter changes, but only has staked %d PERLs", tx.Creator, minimum, stake)
+	}
+
+	var (
+		id       TransactionID
+		proposal Proposal
+	)
+
+	switch params.Opcode {
+	case sys.ProposeParameter:
+		if params.ActivationRound <= round.Index {
+			return nil, errors.Errorf("governance: parameter changes must activate after round %d, but was proposed to activate at round %d", round.Index, params.ActivationRound)
+		}
+
+		// The proposal is identified by the ID of the transaction which proposed it, which
+		// is shared by all entries of a batch.
+
+		id = tx.ID
+
+		if _, exists := ReadProposal(snapshot, id); exists {
+			return nil, errors.Errorf("governance: proposal %x already exists", id)
+		}
+
+		proposal = Proposal{
+			Proposer:        tx.Creator,
+			Param:           params.Param,
+			Value:           params.Value,
+			ActivationRound: params.ActivationRound,
+		}
+	case sys.VoteParameter:
+		id = params.ProposalID
+
+		var exists bool
+
+		if proposal, exists = ReadProposal(snapshot, id); !exists {
+			return nil, errors.Errorf("governance: proposal %x does not exist", id)
+		}
+
+		if proposal.Passed {
+			return nil, errors.Errorf("governance: proposal %x has already passed", id)
+		}
+
+		if round.Index >= proposal.ActivationRound {
+			return nil, errors.Errorf("governance: voting for proposal %x closed at round %d", id, proposal.ActivationRound)
+		}
+
+		if proposal.Voted(tx.Creator) {
+			return nil, errors.Errorf("governance: %x has already voted for proposal %x", tx.Creator, id)
 		}
 	}
 
+	// Proposers implicitly vote for their own proposal. The stake voted with may
+	// not be withdrawn until voting closes, such that it may not be voted with
+	// again by another account.
+
+	proposal.Voters = append(proposal.Voters, tx.Creator)
+	proposal.VotedStake += stake
+
+	LockAccountStake(snapshot, tx.Creator, proposal.ActivationRound)
+
+	if reachedQuorum(proposal.VotedStake, ReadTotalStake(snapshot)) {
+		proposal.Passed = true
+
+		WriteParam(snapshot, proposal.Param, proposal.Value, proposal.ActivationRound, round.Index)
+
+		logger := ctx.scope().Consensus("proposal_passed")
+		logger.Info().
+			Hex("proposal_id", id[:]).
+			Str("param", sys.ParamLabel(proposal.Param)).
+			Uint64("value", proposal.Value).
+			Uint64("activation_round", proposal.ActivationRound).
+			Msg("Parameter change proposal has passed.")
+	}
+
+	WriteProposal(snapshot, id, proposal)
+
+	return snapshot, nil
+}
+
+func applyFreezeTransaction(snapshot *avl.Tree, round *Round, tx *Transaction, params Governance, ctx *applyContext) (*avl.Tree, error) {
+	if version := ReadProtocolVersion(snapshot); version < sys.ProtocolFreeze {
+		return nil, errors.Errorf("governance: accounts may not be frozen or unfrozen under protocol version %d", version)
+	}
+
+	if !ReadAccountFreezeAuthority(snapshot, tx.Creator) {
+		return nil, errors.Errorf("governance: %x is not an authority permitted to freeze or unfreeze accounts", tx.Creator)
+	}
+
+	_, frozen := ReadAccountFrozen(snapshot, params.Account)
+
+	if params.Opcode == sys.FreezeAccount {
+		if frozen {
+			return nil, errors.Errorf("governance: %x is already frozen", params.Account)
+		}
+
+		WriteAccountFrozen(snapshot, params.Account, Freeze{Authority: tx.Creator, Round: round.Index})
+
+		logger := ctx.scope().Accounts("frozen")
+		logger.Info().
+			Hex("account_id", params.Account[:]).
+			Hex("authority", tx.Creator[:]).
+			Hex("tx_id", tx.ID[:]).
+			Uint64("round", round.Index).
+			Msg("Account has been frozen.")
+
+		return snapshot, nil
+	}
+
+	if !frozen {
+		return nil, errors.Errorf("governance: %x is not frozen", params.Account)
+	}
+
+	DeleteAccountFrozen(snapshot, params.Account)
+
+	logger := ctx.scope().Accounts("unfrozen")
+	logger.Info().
+		Hex("account_id", params.Account[:]).
+		Hex("authority", tx.Creator[:]).
+		Hex("tx_id", tx.ID[:]).
+		Uint64("round", round.Index).
+		Msg("Account has been unfrozen.")
+
 	return snapshot, nil
 }
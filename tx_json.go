@@ -109,18 +109,11 @@ func parseTransfer(data []byte) ([]byte, error) {
 		return nil, ErrNilField // Return nil field error
 	}
 
-	decodedRecipient, err := hex.DecodeString(string(json.GetStringBytes(PayloadParamNameRecipient))) // Decode recipient hex string
-	if err != nil {                                                                                   // Check for errors
+	recipient, err := ParseAccountID(string(json.GetStringBytes(PayloadParamNameRecipient))) // Decode recipient hex string or address
+	if err != nil {                                                                          // Check for errors
 		return nil, err // Return found error
 	}
 
-	if len(decodedRecipient) != SizeAccountID { // Check invalid length
-		return nil, ErrInvalidAccountIDSize // Return invalid recipient ID error
-	}
-
-	var recipient AccountID              // Initialize ID buffer
-	copy(recipient[:], decodedRecipient) // Copy address to buffer
-
 	_, err = payload.Write(recipient[:]) // Write recipient value
 	if err != nil {                      // Check for errors
 		return nil, err // Return found error
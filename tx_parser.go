@@ -108,6 +108,44 @@ func ParseTransferTransaction(payload []byte) (Transfer, error) {
 	return tx, nil
 }
 
+// Marshal encodes the transfer into the payload of a transfer transaction.
+// Fields that follow the amount are only encoded should either they, or any
+// field after them, be set.
+func (t Transfer) Marshal() []byte {
+	w := bytes.NewBuffer(nil)
+	b := make([]byte, 8)
+
+	w.Write(t.Recipient[:])
+
+	binary.LittleEndian.PutUint64(b, t.Amount)
+	w.Write(b)
+
+	if t.GasLimit == 0 && len(t.FuncName) == 0 && len(t.FuncParams) == 0 {
+		return w.Bytes()
+	}
+
+	binary.LittleEndian.PutUint64(b, t.GasLimit)
+	w.Write(b)
+
+	if len(t.FuncName) == 0 && len(t.FuncParams) == 0 {
+		return w.Bytes()
+	}
+
+	binary.LittleEndian.PutUint32(b[:4], uint32(len(t.FuncName)))
+	w.Write(b[:4])
+	w.Write(t.FuncName)
+
+	if len(t.FuncParams) == 0 {
+		return w.Bytes()
+	}
+
+	binary.LittleEndian.PutUint32(b[:4], uint32(len(t.FuncParams)))
+	w.Write(b[:4])
+	w.Write(t.FuncParams)
+
+	return w.Bytes()
+}
+
 type Stake struct {
 	Opcode byte
 	Amount uint64
@@ -133,10 +171,6 @@ func ParseStakeTransaction(payload []byte) (Stake, error) {
 		return tx, errors.New("stake: amount must be greater than zero")
 	}
 
-	if tx.Opcode == sys.WithdrawReward && tx.Amount < sys.MinimumRewardWithdraw {
-		return tx, errors.Errorf("stake: must withdraw a reward of a minimum of %d PERLs, but requested to withdraw %d PERLs", sys.MinimumRewardWithdraw, tx.Amount)
-	}
-
 	return tx, nil
 }
 
@@ -191,6 +225,23 @@ func ParseContractTransaction(payload []byte) (Contract, error) {
 	return tx, nil
 }
 
+// Marshal encodes the contract into the payload of a contract transaction.
+func (c Contract) Marshal() []byte {
+	w := bytes.NewBuffer(nil)
+	b := make([]byte, 8)
+
+	binary.LittleEndian.PutUint64(b, c.GasLimit)
+	w.Write(b)
+
+	binary.LittleEndian.PutUint32(b[:4], uint32(len(c.Params)))
+	w.Write(b[:4])
+	w.Write(c.Params)
+
+	w.Write(c.Code)
+
+	return w.Bytes()
+}
+
 type Batch struct {
 	Size     uint8
 	Tags     []uint8
@@ -246,3 +297,178 @@ func ParseBatchTransaction(payload []byte) (Batch, error) {
 
 	return tx, nil
 }
+
+type Recovery struct {
+	Opcode byte
+
+	// Only set when designating guardians.
+	Threshold uint8
+	Delay     uint64
+	Guardians []AccountID
+
+	// Only set when approving or executing the recovery of an account.
+	Account AccountID
+
+	// Only set when approving the recovery of an account.
+	NewKey AccountID
+}
+
+// ParseRecoveryTransaction parses and performs sanity checks on the payload of a recovery transaction.
+func ParseRecoveryTransaction(payload []byte) (Recovery, error) {
+	r := bytes.NewReader(payload)
+	b := make([]byte, 8)
+
+	tx := Recovery{}
+
+	if _, err := io.ReadFull(r, b[:1]); err != nil {
+		return tx, errors.Wrap(err, "recovery: failed to decode opcode")
+	}
+
+	tx.Opcode = b[0]
+
+	switch tx.Opcode {
+	case sys.SetGuardians:
+		if _, err := io.ReadFull(r, b[:1]); err != nil {
+			return tx, errors.Wrap(err, "recovery: failed to decode threshold of guardian approvals")
+		}
+
+		tx.Threshold = b[0]
+
+		if _, err := io.ReadFull(r, b); err != nil {
+			return tx, errors.Wrap(err, "recovery: failed to decode number of rounds to delay recovery by")
+		}
+
+		tx.Delay = binary.LittleEndian.Uint64(b)
+
+		if _, err := io.ReadFull(r, b[:1]); err != nil {
+			return tx, errors.Wrap(err, "recovery: failed to decode number of guardians")
+		}
+
+		if int(b[0]) > sys.MaxGuardians {
+			return tx, errors.Errorf("recovery: at most %d guardians may be designated", sys.MaxGuardians)
+		}
+
+		tx.Guardians = make([]AccountID, b[0])
+
+		set := make(map[AccountID]struct{}, len(tx.Guardians))
+
+		for i := range tx.Guardians {
+			if _, err := io.ReadFull(r, tx.Guardians[i][:]); err != nil {
+				return tx, errors.Wrap(err, "recovery: failed to decode guardian")
+			}
+
+			if _, duplicate := set[tx.Guardians[i]]; duplicate {
+				return tx, errors.New("recovery: guardians must not be duplicated")
+			}
+
+			set[tx.Guardians[i]] = struct{}{}
+		}
+
+		if int(tx.Threshold) > len(tx.Guardians) {
+			return tx, errors.Errorf("recovery: threshold of %d approvals exceeds the %d guardians designated", tx.Threshold, len(tx.Guardians))
+		}
+
+		if len(tx.Guardians) > 0 && tx.Threshold == 0 {
+			return tx, errors.New("recovery: threshold of guardian approvals must be greater than zero")
+		}
+	case sys.ApproveRecovery:
+		if _, err := io.ReadFull(r, tx.Account[:]); err != nil {
+			return tx, errors.Wrap(err, "recovery: failed to decode account to recover")
+		}
+
+		if _, err := io.ReadFull(r, tx.NewKey[:]); err != nil {
+			return tx, errors.Wrap(err, "recovery: failed to decode new key of account to recover")
+		}
+
+		if tx.Account == tx.NewKey {
+			return tx, errors.New("recovery: new key must differ from the account to recover")
+		}
+	case sys.CancelRecovery:
+	case sys.ExecuteRecovery:
+		if _, err := io.ReadFull(r, tx.Account[:]); err != nil {
+			return tx, errors.Wrap(err, "recovery: failed to decode account to recover")
+		}
+	default:
+		return tx, errors.New("recovery: opcode must be 0, 1, 2, or 3")
+	}
+
+	if r.Len() > 0 {
+		return tx, errors.New("recovery: payload has trailing bytes")
+	}
+
+	return tx, nil
+}
+
+type Governance struct {
+	Opcode byte
+
+	// Only set when proposing a parameter change.
+	Param           byte
+	Value           uint64
+	ActivationRound uint64
+
+	// Only set when voting for a proposal.
+	ProposalID TransactionID
+
+	// Only set when freezing or unfreezing an account.
+	Account AccountID
+}
+
+// ParseGovernanceTransaction parses and performs sanity checks on the payload of a governance transaction.
+func ParseGovernanceTransaction(payload []byte) (Governance, error) {
+	r := bytes.NewReader(payload)
+	b := make([]byte, 8)
+
+	tx := Governance{}
+
+	if _, err := io.ReadFull(r, b[:1]); err != nil {
+		return tx, errors.Wrap(err, "governance: failed to decode opcode")
+	}
+
+	tx.Opcode = b[0]
+
+	switch tx.Opcode {
+	case sys.ProposeParameter:
+		if _, err := io.ReadFull(r, b[:1]); err != nil {
+			return tx, errors.Wrap(err, "governance: failed to decode parameter to change")
+		}
+
+		tx.Param = b[0]
+
+		if _, exists := sys.ParamDefault(tx.Param); !exists {
+			return tx, errors.Errorf("governance: parameter %d does not exist", tx.Param)
+		}
+
+		if _, err := io.ReadFull(r, b); err != nil {
+			return tx, errors.Wrap(err, "governance: failed to decode proposed value of parameter")
+		}
+
+		tx.Value = binary.LittleEndian.Uint64(b)
+
+		if min, max, _ := sys.ParamBounds(tx.Param); tx.Value < min || tx.Value > max {
+			return tx, errors.Errorf("governance: %s must be changed to between %d and %d, but was proposed to be changed to %d", sys.ParamLabel(tx.Param), min, max, tx.Value)
+		}
+
+		if _, err := io.ReadFull(r, b); err != nil {
+			return tx, errors.Wrap(err, "governance: failed to decode round to activate parameter change at")
+		}
+
+		tx.ActivationRound = binary.LittleEndian.Uint64(b)
+	case sys.VoteParameter:
+		if _, err := io.ReadFull(r, tx.ProposalID[:]); err != nil {
+			return tx, errors.Wrap(err, "governance: failed to decode ID of proposal to vote for")
+		}
+	case sys.FreezeAccount, sys.UnfreezeAccount:
+		if _, err := io.ReadFull(r, tx.Account[:]); err != nil {
+			return tx, errors.Wrap(err, "governance: failed to decode account to freeze or unfreeze")
+		}
+	default:
+		return tx, errors.New("governance: opcode must be 0, 1, 2, or 3")
+	}
+
+	if r.Len() > 0 {
+		return tx, errors.New("governance: payload has trailing bytes")
+	}
+
+	return tx, nil
+}
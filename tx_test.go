@@ -23,10 +23,56 @@ import (
 	"bytes"
 	"github.com/perlin-network/noise/skademlia"
 	"github.com/perlin-network/wavelet/sys"
+	"github.com/perlin-network/wavelet/wallet"
 	"github.com/stretchr/testify/assert"
 	"testing"
 )
 
+func TestTransactionWrite(t *testing.T) {
+	keys, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	creator, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	parent := AttachSenderToTransaction(keys, NewTransaction(keys, sys.TagNop, nil))
+
+	for _, tx := range []Transaction{
+		parent,
+		AttachSenderToTransaction(keys, NewTransaction(creator, sys.TagTransfer, []byte("payload")), &parent),
+	} {
+		buf := AcquireBuffer()
+		tx.Write(buf)
+
+		assert.Equal(t, tx.Marshal(), buf.Bytes())
+		assert.Len(t, buf.Bytes(), tx.size())
+
+		ReleaseBuffer(buf)
+
+		decoded, err := UnmarshalTransaction(bytes.NewReader(tx.Marshal()))
+		assert.NoError(t, err)
+		assert.Equal(t, tx.ID, decoded.ID)
+	}
+}
+
+func TestSignTransaction(t *testing.T) {
+	keys, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	signer := wallet.Key(keys.PrivateKey())
+
+	parent := AttachSenderToTransaction(keys, NewTransaction(keys, sys.TagNop, nil))
+	expected := AttachSenderToTransaction(keys, NewTransactionWithNonce(keys, 1, sys.TagTransfer, []byte("payload")), &parent)
+
+	tx, err := SignTransaction(signer, 1, sys.TagTransfer, []byte("payload"))
+	assert.NoError(t, err)
+
+	tx, err = AttachSignerToTransaction(signer, tx, &parent)
+	assert.NoError(t, err)
+
+	assert.Equal(t, expected, tx)
+}
+
 func BenchmarkNewTX(b *testing.B) {
 	keys, err := skademlia.NewKeys(1, 1)
 	assert.NoError(b, err)
@@ -53,3 +99,17 @@ func BenchmarkMarshalUnmarshalTX(b *testing.B) {
 		assert.NoError(b, err)
 	}
 }
+
+func BenchmarkRehashTX(b *testing.B) {
+	keys, err := skademlia.NewKeys(1, 1)
+	assert.NoError(b, err)
+
+	tx := AttachSenderToTransaction(keys, NewTransaction(keys, sys.TagTransfer, make([]byte, 256)))
+
+	b.ResetTimer()
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		tx.rehash()
+	}
+}
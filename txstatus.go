@@ -0,0 +1,160 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"sync"
+)
+
+// TransactionStatus denotes how far along a transaction is in being finalized.
+type TransactionStatus byte
+
+const (
+	// TxUnknown denotes that the node has no record of the transaction.
+	TxUnknown TransactionStatus = iota
+
+	// TxReceived denotes that the transaction was received, but is waiting on
+	// some of its ancestors to arrive before it may be added to the graph.
+	TxReceived
+
+	// TxInGraph denotes that the transaction and all of its ancestors are in
+	// the graph.
+	TxInGraph
+
+	// TxGossiped denotes that the transaction was in the graph, and has been
+	// queued up to be gossiped to peers.
+	TxGossiped
+
+	// TxFinalized denotes that the transaction is below the root of the graph,
+	// though the node did not keep track of whether it was applied or rejected.
+	TxFinalized
+
+	// TxApplied denotes that the transaction was finalized and applied.
+	TxApplied
+
+	// TxRejected denotes that the transaction was finalized, but failed to be
+	// applied.
+	TxRejected
+
+	// TxPruned denotes that the transaction was dropped from the graph without
+	// ever being finalized.
+	TxPruned
+)
+
+var transactionStatusLabels = []string{"unknown", "received", "in_graph", "gossiped", "finalized", "applied", "rejected", "pruned"}
+
+func (s TransactionStatus) String() string {
+	if int(s) < len(transactionStatusLabels) {
+		return transactionStatusLabels[s]
+	}
+
+	return "unknown"
+}
+
+// final returns whether or not s is the last status a transaction may take on.
+func (s TransactionStatus) final() bool {
+	return s >= TxFinalized
+}
+
+// TransactionState is the status of a transaction, alongside the index of the
+// round it was finalized in and the reason it was rejected, if any.
+type TransactionState struct {
+	Status TransactionStatus
+	Round  uint64
+	Err    error
+}
+
+// txStatuses keeps track of the status of the most recently seen transactions.
+// Statuses only ever advance, and statuses which are final are never changed.
+type txStatuses struct {
+	sync.Mutex
+	states *LRU
+}
+
+func newTxStatuses(size int) *txStatuses {
+	return &txStatuses{states: NewLRU(size)}
+}
+
+func (t *txStatuses) advance(id TransactionID, state TransactionState) {
+	t.Lock()
+	defer t.Unlock()
+
+	if val, exists := t.states.load(id); exists {
+		current := val.(TransactionState)
+
+		if current.Status.final() || current.Status >= state.Status {
+			return
+		}
+	}
+
+	t.states.put(id, state)
+}
+
+func (t *txStatuses) load(id TransactionID) (TransactionState, bool) {
+	val, exists := t.states.load(id)
+	if !exists {
+		return TransactionState{}, false
+	}
+
+	return val.(TransactionState), true
+}
+
+// TransactionStatus returns the status of the transaction with ID id. The
+// statuses of only the most recently seen transactions are kept track of;
+// transactions whose status is no longer kept track of are reported either as
+// being in the graph or as having been finalized should they still be in the
+// graph, and as unknown otherwise. It is safe to call this method concurrently.
+func (l *Ledger) TransactionStatus(id TransactionID) TransactionState {
+	state, tracked := l.txStatuses.load(id)
+
+	if tracked && state.Status.final() {
+		return state
+	}
+
+	tx := l.graph.FindTransaction(id)
+
+	if tx == nil {
+		if tracked {
+			return TransactionState{Status: TxPruned}
+		}
+
+		return TransactionState{Status: TxUnknown}
+	}
+
+	if tx.Depth <= l.graph.RootDepth() {
+		state = TransactionState{Status: TxFinalized}
+
+		if round, err := l.rounds.GetByDepth(tx.Depth); err == nil {
+			state.Round = round.Index
+		}
+
+		return state
+	}
+
+	if l.graph.IsIncomplete(id) {
+		return TransactionState{Status: TxReceived}
+	}
+
+	if !tracked || state.Status < TxInGraph {
+		return TransactionState{Status: TxInGraph}
+	}
+
+	return state
+}
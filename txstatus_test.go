@@ -0,0 +1,85 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"testing"
+
+	"github.com/perlin-network/noise/skademlia"
+	"github.com/perlin-network/wavelet/store"
+	"github.com/perlin-network/wavelet/sys"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTxStatusesAdvance(t *testing.T) {
+	statuses := newTxStatuses(16)
+	id := TransactionID{1}
+
+	statuses.advance(id, TransactionState{Status: TxGossiped})
+	statuses.advance(id, TransactionState{Status: TxInGraph})
+
+	state, _ := statuses.load(id)
+	assert.Equal(t, TxGossiped, state.Status, "statuses never regress")
+
+	statuses.advance(id, TransactionState{Status: TxRejected, Round: 3, Err: errors.New("insufficient balance")})
+	statuses.advance(id, TransactionState{Status: TxPruned})
+
+	state, _ = statuses.load(id)
+	assert.Equal(t, TxRejected, state.Status, "final statuses never change")
+	assert.Equal(t, uint64(3), state.Round)
+	assert.EqualError(t, state.Err, "insufficient balance")
+
+	assert.Equal(t, "in_graph", TxInGraph.String())
+	assert.Equal(t, "unknown", TransactionStatus(255).String())
+}
+
+func TestLedgerTransactionStatus(t *testing.T) {
+	keys, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	root := AttachSenderToTransaction(keys, NewTransaction(keys, sys.TagNop, nil))
+	rounds, _ := NewRounds(store.NewInmem(), 4)
+
+	l := &Ledger{graph: NewGraph(WithRoot(root)), rounds: rounds, txStatuses: newTxStatuses(16)}
+
+	assert.Equal(t, TxUnknown, l.TransactionStatus(TransactionID{1}).Status)
+	assert.Equal(t, TxFinalized, l.TransactionStatus(root.ID).Status)
+
+	tx := AttachSenderToTransaction(keys, NewTransaction(keys, sys.TagNop, nil), l.graph.FindEligibleParents()...)
+	assert.NoError(t, l.graph.AddTransaction(tx))
+
+	assert.Equal(t, TxInGraph, l.TransactionStatus(tx.ID).Status, "untracked transactions in the graph are reported as such")
+
+	l.txStatuses.advance(tx.ID, TransactionState{Status: TxGossiped})
+	assert.Equal(t, TxGossiped, l.TransactionStatus(tx.ID).Status)
+
+	orphan := AttachSenderToTransaction(keys, NewTransaction(keys, sys.TagNop, nil), &Transaction{ID: TransactionID{2}, Depth: tx.Depth})
+	assert.Equal(t, ErrMissingParents, errors.Cause(l.graph.AddTransaction(orphan)))
+	assert.Equal(t, TxReceived, l.TransactionStatus(orphan.ID).Status)
+
+	dropped := TransactionID{3}
+	l.txStatuses.advance(dropped, TransactionState{Status: TxReceived})
+	assert.Equal(t, TxPruned, l.TransactionStatus(dropped).Status)
+
+	applied := TransactionID{4}
+	l.txStatuses.advance(applied, TransactionState{Status: TxApplied, Round: 7})
+	assert.Equal(t, TransactionState{Status: TxApplied, Round: 7}, l.TransactionStatus(applied))
+}
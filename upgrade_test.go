@@ -0,0 +1,89 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/perlin-network/wavelet/avl"
+	"github.com/perlin-network/wavelet/store"
+	"github.com/perlin-network/wavelet/sys"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProtocolVersion(t *testing.T) {
+	config := sys.DefaultConfig()
+	config.Upgrades = []sys.Upgrade{
+		{Version: 1, Name: "first", Round: 10},
+		{Version: 2, Name: "second", Round: 20},
+	}
+
+	assert.EqualValues(t, 0, config.ProtocolVersion(0))
+	assert.EqualValues(t, 0, config.ProtocolVersion(9))
+	assert.EqualValues(t, 1, config.ProtocolVersion(10))
+	assert.EqualValues(t, 1, config.ProtocolVersion(19))
+	assert.EqualValues(t, 2, config.ProtocolVersion(20))
+
+	_, activates := config.Activates(19)
+	assert.False(t, activates, "the protocol version must only be recorded at the round an upgrade activates")
+
+	upgrade, activates := config.Activates(20)
+	assert.True(t, activates)
+	assert.Equal(t, "second", upgrade.Name)
+
+	assert.NoError(t, sys.ValidateUpgrades(sys.DefaultUpgrades()))
+	assert.Error(t, sys.ValidateUpgrades([]sys.Upgrade{{Version: 1, Name: "genesis", Round: 0}}))
+	assert.Error(t, sys.ValidateUpgrades([]sys.Upgrade{{Version: 1, Name: "a", Round: 20}, {Version: 2, Name: "b", Round: 10}}))
+	assert.Error(t, sys.ValidateUpgrades([]sys.Upgrade{{Version: 2, Name: "a", Round: 10}, {Version: 1, Name: "b", Round: 20}}))
+
+	for _, upgrade := range sys.DefaultUpgrades() {
+		assert.NotZero(t, upgrade.Round, "upgrade %q must activate at a real round", upgrade.Name)
+	}
+
+	assert.True(t, sys.TagActive(sys.TagTransfer, sys.ProtocolGenesis))
+	assert.False(t, sys.TagActive(sys.TagRecovery, sys.ProtocolGenesis))
+	assert.True(t, sys.TagActive(sys.TagRecovery, sys.ProtocolRecovery))
+}
+
+func TestBatchRejectsInactiveTags(t *testing.T) {
+	tree := avl.New(store.NewInmem())
+
+	creator := AccountID{1}
+	WriteAccountBalance(tree, creator, 100)
+
+	payload := setGuardiansPayload(1, 0, AccountID{2})
+
+	batch := []byte{1, byte(sys.TagRecovery)}
+	batch = append(batch, make([]byte, 4)...)
+	binary.BigEndian.PutUint32(batch[2:], uint32(len(payload)))
+	batch = append(batch, payload...)
+
+	tx := &Transaction{Creator: creator, Sender: creator, Tag: sys.TagBatch, Payload: batch}
+
+	_, err := ApplyBatchTransaction(tree, &Round{Index: 1}, tx)
+	assert.Error(t, err)
+
+	WriteProtocolVersion(tree, sys.ProtocolRecovery)
+	assert.EqualValues(t, sys.ProtocolRecovery, ReadProtocolVersion(tree))
+
+	_, err = ApplyBatchTransaction(tree, &Round{Index: 1}, tx)
+	assert.NoError(t, err)
+}
@@ -21,30 +21,78 @@ package wavelet
 
 import (
 	"github.com/perlin-network/noise/skademlia"
-	"github.com/perlin-network/wavelet/sys"
 	"sync"
 )
 
 type vote struct {
 	voter     *skademlia.ID
 	preferred *Round
+
+	// viewID is the view that the voter was queried for. Votes cast for a view
+	// older than the latest one seen by the collector are discarded as stale.
+	viewID uint64
+}
+
+// VotingStake returns the stake which weighs an accounts vote in Snowball. Every
+// voter is considered to have at least the minimum stake.
+func VotingStake(stake, minimumStake uint64) uint64 {
+	if stake < minimumStake {
+		return minimumStake
+	}
+
+	return stake
 }
 
-func CollectVotes(accounts *Accounts, snowball *Snowball, voteChan <-chan vote, wg *sync.WaitGroup) {
-	votes := make([]vote, 0, sys.SnowballK)
-	voters := make(map[AccountID]struct{}, sys.SnowballK)
+// CollectVotes tallies votes in batches of k and ticks the given Snowball instance
+// with the majority of each batch holding at least alpha of its weight, weighed
+// using weighting and the minimum stake minimumStake returns, which is read anew
+// for every batch such that governed changes to it apply. Replayed votes
+// from a voter already counted in the current batch, stale votes cast for an older
+// view, and votes from voters not eligible to vote are rejected so that they may
+// not skew the tally.
+func CollectVotes(accounts *Accounts, weighting Weighting, minimumStake func() uint64, snowball *Snowball, k int, alpha float64, voteChan <-chan vote, wg *sync.WaitGroup, metrics *Metrics) {
+	votes := make([]vote, 0, k)
+	voters := make(map[AccountID]struct{}, k)
+
+	var view, stake uint64
+
+	reject := func() {
+		if metrics != nil {
+			metrics.rejectedVotes.Mark(1)
+		}
+	}
 
 	for vote := range voteChan {
+		if vote.viewID < view {
+			reject()
+			continue
+		}
+
+		if vote.viewID > view { // Votes collected for an older view may no longer be tallied.
+			view = vote.viewID
+
+			voters = make(map[AccountID]struct{}, k)
+			votes = votes[:0]
+		}
+
 		if _, recorded := voters[vote.voter.PublicKey()]; recorded {
+			reject()
 			continue // To make sure the sampling process is fair, only allow one vote per peer.
 		}
 
+		if len(votes) == 0 {
+			stake = minimumStake()
+		}
+
+		if _, eligible := weighting.WeighAccount(accounts, vote.voter.PublicKey(), stake); !eligible {
+			reject()
+			continue
+		}
+
 		voters[vote.voter.PublicKey()] = struct{}{}
 		votes = append(votes, vote)
 
 		if len(votes) == cap(votes) {
-			snapshot := accounts.Snapshot()
-
 			stakes := make(map[AccountID]float64, len(votes))
 			maxStake := float64(0)
 
@@ -53,13 +101,9 @@ func CollectVotes(accounts *Accounts, snowball *Snowball, voteChan <-chan vote,
 					vote.preferred = ZeroRoundPtr
 				}
 
-				stake, _ := ReadAccountStake(snapshot, vote.voter.PublicKey())
-
-				if stake < sys.MinimumStake {
-					stake = sys.MinimumStake
-				}
+				weight, _ := weighting.WeighAccount(accounts, vote.voter.PublicKey(), stake)
 
-				stakes[vote.voter.PublicKey()] = float64(stake)
+				stakes[vote.voter.PublicKey()] = float64(weight)
 
 				if maxStake < stakes[vote.voter.PublicKey()] {
 					maxStake = stakes[vote.voter.PublicKey()]
@@ -86,7 +130,7 @@ func CollectVotes(accounts *Accounts, snowball *Snowball, voteChan <-chan vote,
 					vote.preferred = ZeroRoundPtr
 				}
 
-				if counts[vote.preferred.ID]/totalCount >= sys.SnowballAlpha {
+				if counts[vote.preferred.ID]/totalCount >= alpha {
 					majority = vote.preferred
 					break
 				}
@@ -94,7 +138,7 @@ func CollectVotes(accounts *Accounts, snowball *Snowball, voteChan <-chan vote,
 
 			snowball.Tick(majority)
 
-			voters = make(map[AccountID]struct{}, sys.SnowballK)
+			voters = make(map[AccountID]struct{}, k)
 			votes = votes[:0]
 		}
 	}
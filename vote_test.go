@@ -0,0 +1,99 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/perlin-network/noise/skademlia"
+	"github.com/perlin-network/wavelet/store"
+	"github.com/perlin-network/wavelet/sys"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollectVotesRejectsReplays(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	metrics := NewMetrics(ctx)
+	snowball := NewSnowball(WithBeta(10))
+
+	keys, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	start := AttachSenderToTransaction(keys, NewTransaction(keys, sys.TagTransfer, nil))
+	end := AttachSenderToTransaction(keys, NewTransaction(keys, sys.TagStake, nil))
+
+	round := NewRound(2, ZeroMerkleNodeID, 1, start, end)
+
+	voters := make([]*skademlia.ID, sys.SnowballK+1)
+
+	for i := range voters {
+		keys, err := skademlia.NewKeys(1, 1)
+		assert.NoError(t, err)
+
+		voters[i] = keys.ID("127.0.0.1")
+	}
+
+	voteChan := make(chan vote)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+
+	// The minimum stake is read anew for every batch of votes.
+
+	var reads int
+
+	minimumStake := func() uint64 {
+		reads++
+		return sys.MinimumStake
+	}
+
+	go CollectVotes(NewAccounts(store.NewInmem()), WeightByStake, minimumStake, snowball, sys.SnowballK, sys.SnowballAlpha, voteChan, &wg, metrics)
+
+	// A newer view discards any votes tallied for an older view.
+
+	voteChan <- vote{voter: voters[sys.SnowballK], preferred: &round, viewID: 1}
+
+	for i := 0; i < sys.SnowballK-1; i++ {
+		voteChan <- vote{voter: voters[i], preferred: &round, viewID: 2}
+	}
+
+	// Neither replayed votes nor stale votes should complete the batch.
+
+	voteChan <- vote{voter: voters[0], preferred: &round, viewID: 2}
+	voteChan <- vote{voter: voters[sys.SnowballK], preferred: &round, viewID: 1}
+
+	// The last fresh vote does complete the batch.
+
+	voteChan <- vote{voter: voters[sys.SnowballK-1], preferred: &round, viewID: 2}
+
+	close(voteChan)
+	wg.Wait()
+
+	assert.Equal(t, 2, reads)
+	assert.EqualValues(t, 2, metrics.rejectedVotes.Count())
+	assert.Len(t, snowball.counts, 1)
+	assert.Equal(t, 1, snowball.counts[round.ID])
+}
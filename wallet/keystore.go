@@ -0,0 +1,137 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wallet
+
+import (
+	"bytes"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+
+	"github.com/perlin-network/noise/edwards25519"
+	"github.com/perlin-network/noise/skademlia"
+	"github.com/perlin-network/wavelet/keystore"
+	"github.com/pkg/errors"
+)
+
+// PassphraseFunc returns the passphrase a keystore is encrypted under. The
+// passphrase returned is zeroed once it has been used.
+type PassphraseFunc func() ([]byte, error)
+
+// PassphraseFile reads the passphrase a keystore is encrypted under from the
+// file at path, ignoring any trailing newline. It suits services which may not
+// prompt for a passphrase, such as those run by systemd.
+func PassphraseFile(path string) PassphraseFunc {
+	return func() ([]byte, error) {
+		buf, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read passphrase file %q", path)
+		}
+
+		passphrase := bytes.TrimRight(buf, "\r\n")
+		if len(passphrase) == 0 {
+			Zero(buf)
+			return nil, errors.Errorf("passphrase file %q is empty", path)
+		}
+
+		return passphrase, nil
+	}
+}
+
+type keystoreSource struct {
+	path       string
+	account    string
+	passphrase PassphraseFunc
+}
+
+// Keystore loads a private key by decrypting it out of the keystore file at
+// path, using the passphrase returned by passphrase. The private key is only
+// ever decrypted in memory. The account to load is given by its hex-encoded
+// public key, and may be left empty should the keystore hold a single account.
+func Keystore(path, account string, passphrase PassphraseFunc) Source {
+	return keystoreSource{path: path, account: account, passphrase: passphrase}
+}
+
+func (s keystoreSource) PrivateKey() (edwards25519.PrivateKey, error) {
+	f, err := os.Open(s.path)
+	if err != nil {
+		return edwards25519.PrivateKey{}, errors.Wrapf(err, "failed to open keystore %q", s.path)
+	}
+
+	ks, err := keystore.Read(f)
+	_ = f.Close()
+
+	if err != nil {
+		return edwards25519.PrivateKey{}, err
+	}
+
+	var account keystore.Account
+
+	if len(s.account) == 0 {
+		if len(ks.Accounts) != 1 {
+			return edwards25519.PrivateKey{}, errors.Errorf("keystore %q holds %d accounts: the account to load must be specified", s.path, len(ks.Accounts))
+		}
+
+		account = ks.Accounts[0]
+	} else {
+		var publicKey edwards25519.PublicKey
+
+		buf, err := hex.DecodeString(s.account)
+		if err != nil || len(buf) != edwards25519.SizePublicKey {
+			return edwards25519.PrivateKey{}, errors.Errorf("account %q must be a hex-encoded public key", s.account)
+		}
+
+		copy(publicKey[:], buf)
+
+		var ok bool
+
+		if account, ok = ks.Find(publicKey); !ok {
+			return edwards25519.PrivateKey{}, errors.Errorf("keystore %q holds no account %s", s.path, s.account)
+		}
+	}
+
+	passphrase, err := s.passphrase()
+	if err != nil {
+		return edwards25519.PrivateKey{}, err
+	}
+
+	defer Zero(passphrase)
+
+	return account.Decrypt(passphrase)
+}
+
+func (s keystoreSource) String() string {
+	return "keystore " + s.path
+}
+
+// Zero overwrites buf with zeroes, such that key material does not linger in
+// memory once it is no longer needed.
+func Zero(buf []byte) {
+	for i := range buf {
+		buf[i] = 0
+	}
+}
+
+// Destroy zeroes the key pair held by keys in place, such that its private key
+// does not linger in memory once the node shuts down. keys may not be used
+// afterwards.
+func Destroy(keys *skademlia.Keypair) {
+	*keys = skademlia.Keypair{}
+}
@@ -0,0 +1,109 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wallet
+
+import (
+	"bytes"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/perlin-network/noise/edwards25519"
+	"github.com/perlin-network/noise/skademlia"
+	"github.com/perlin-network/wavelet/keystore"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeystore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wallet")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	privateKey, err := Decode([]byte(testPrivateKey))
+	assert.NoError(t, err)
+
+	passphrasePath := filepath.Join(dir, "passphrase.txt")
+	assert.NoError(t, ioutil.WriteFile(passphrasePath, []byte("passphrase\n"), 0600))
+
+	ks := keystore.New()
+	assert.NoError(t, ks.Add(privateKey, []byte("passphrase"), keystore.KDF{N: 1 << 10, R: 8, P: 1}))
+
+	var buf bytes.Buffer
+	assert.NoError(t, ks.Write(&buf))
+
+	path := filepath.Join(dir, "keystore.json")
+	assert.NoError(t, ioutil.WriteFile(path, buf.Bytes(), 0600))
+
+	// The account may be omitted should the keystore hold a single account.
+	loaded, err := Keystore(path, "", PassphraseFile(passphrasePath)).PrivateKey()
+	assert.NoError(t, err)
+	assert.Equal(t, privateKey, loaded)
+
+	account := hex.EncodeToString(privateKey[edwards25519.SizePrivateKey-edwards25519.SizePublicKey:])
+
+	loaded, err = Keystore(path, account, PassphraseFile(passphrasePath)).PrivateKey()
+	assert.NoError(t, err)
+	assert.Equal(t, privateKey, loaded)
+
+	_, err = Keystore(path, hex.EncodeToString(make([]byte, edwards25519.SizePublicKey)), PassphraseFile(passphrasePath)).PrivateKey()
+	assert.Error(t, err)
+
+	_, err = Keystore(path, "invalid", PassphraseFile(passphrasePath)).PrivateKey()
+	assert.Error(t, err)
+
+	// The passphrase handed out must be zeroed once used.
+	var passphrase []byte
+
+	_, err = Keystore(path, "", func() ([]byte, error) {
+		passphrase = []byte("wrong")
+		return passphrase, nil
+	}).PrivateKey()
+	assert.Equal(t, keystore.ErrWrongPassphrase, err)
+	assert.Equal(t, make([]byte, len("wrong")), passphrase)
+
+	assert.NoError(t, ioutil.WriteFile(passphrasePath, []byte("\n"), 0600))
+
+	_, err = PassphraseFile(passphrasePath)()
+	assert.Error(t, err)
+
+	_, err = Keystore(filepath.Join(dir, "missing.json"), "", PassphraseFile(passphrasePath)).PrivateKey()
+	assert.Error(t, err)
+}
+
+func TestDestroy(t *testing.T) {
+	keys, err := skademlia.NewKeys(1, 1)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	Destroy(keys)
+
+	assert.Equal(t, edwards25519.PrivateKey{}, keys.PrivateKey())
+	assert.Equal(t, edwards25519.PublicKey{}, keys.PublicKey())
+
+	buf := []byte("secret")
+	Zero(buf)
+
+	assert.Equal(t, make([]byte, len("secret")), buf)
+}
@@ -0,0 +1,221 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wallet
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/perlin-network/noise/edwards25519"
+	"github.com/pkg/errors"
+	"github.com/valyala/fastjson"
+)
+
+// Signer signs messages on behalf of a wallet, without necessarily holding the
+// private key of the wallet.
+type Signer interface {
+	// PublicKey returns the public key of the wallet.
+	PublicKey() edwards25519.PublicKey
+
+	// Sign signs msg with the private key of the wallet.
+	Sign(msg []byte) (edwards25519.Signature, error)
+}
+
+type keySigner struct {
+	privateKey edwards25519.PrivateKey
+	publicKey  edwards25519.PublicKey
+}
+
+// Key returns a signer which signs with privateKey in memory, such as a private
+// key loaded from a Source.
+func Key(privateKey edwards25519.PrivateKey) Signer {
+	return keySigner{privateKey: privateKey, publicKey: privateKey.Public()}
+}
+
+// Load loads the private key of a wallet from source into a signer.
+func Load(source Source) (Signer, error) {
+	privateKey, err := source.PrivateKey()
+	if err != nil {
+		return nil, err
+	}
+
+	defer Zero(privateKey[:])
+
+	return Key(privateKey), nil
+}
+
+func (s keySigner) PublicKey() edwards25519.PublicKey {
+	return s.publicKey
+}
+
+func (s keySigner) Sign(msg []byte) (edwards25519.Signature, error) {
+	return edwards25519.Sign(s.privateKey, msg), nil
+}
+
+type vaultSigner struct {
+	key     string
+	sign    string
+	token   string
+	version int
+
+	publicKey edwards25519.PublicKey
+
+	client *http.Client
+}
+
+// Vault returns a signer which signs through an Ed25519 key held by the transit
+// secrets engine of HashiCorp Vault, such that the private key never leaves
+// Vault. The key is given as its URL, such as:
+//
+//	https://vault.example.com:8200/v1/transit/keys/wavelet
+//
+// The public key of the latest version of the key is fetched upon the signer
+// being created, and all messages are signed under that version. Requests to
+// Vault are authenticated with token.
+func Vault(key, token string) (Signer, error) {
+	u, err := url.Parse(key)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid vault key url")
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, errors.Errorf("vault key url must be http or https, but got %q", u.Scheme)
+	}
+
+	i := strings.LastIndex(u.Path, "/keys/")
+	if i < 0 || len(u.Path) == i+len("/keys/") {
+		return nil, errors.New("vault key url must name a transit key, such as https://vault:8200/v1/transit/keys/wavelet")
+	}
+
+	if len(token) == 0 {
+		return nil, errors.New("a vault token must be provided")
+	}
+
+	s := &vaultSigner{key: u.String(), token: token, client: &http.Client{Timeout: 10 * time.Second}}
+
+	u.Path = u.Path[:i] + "/sign/" + u.Path[i+len("/keys/"):]
+	s.sign = u.String()
+
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// load fetches the public key of the latest version of the transit key.
+func (s *vaultSigner) load() error {
+	v, err := s.request(http.MethodGet, s.key, nil)
+	if err != nil {
+		return errors.Wrapf(err, "failed to fetch vault key %s", s.key)
+	}
+
+	if typ := string(v.GetStringBytes("data", "type")); typ != "ed25519" {
+		return errors.Errorf("vault key %s must be of type ed25519, but is of type %q", s.key, typ)
+	}
+
+	s.version = v.GetInt("data", "latest_version")
+
+	raw := v.GetStringBytes("data", "keys", strconv.Itoa(s.version), "public_key")
+
+	publicKey, err := base64.StdEncoding.DecodeString(string(raw))
+	if err != nil || len(publicKey) != edwards25519.SizePublicKey {
+		return errors.Errorf("vault key %s holds no valid public key for version %d", s.key, s.version)
+	}
+
+	copy(s.publicKey[:], publicKey)
+
+	return nil
+}
+
+func (s *vaultSigner) PublicKey() edwards25519.PublicKey {
+	return s.publicKey
+}
+
+func (s *vaultSigner) Sign(msg []byte) (edwards25519.Signature, error) {
+	var signature edwards25519.Signature
+
+	var arena fastjson.Arena
+
+	body := arena.NewObject()
+	body.Set("input", arena.NewString(base64.StdEncoding.EncodeToString(msg)))
+	body.Set("key_version", arena.NewNumberInt(s.version))
+
+	v, err := s.request(http.MethodPost, s.sign, body.MarshalTo(nil))
+	if err != nil {
+		return signature, errors.Wrapf(err, "failed to sign with vault key %s", s.key)
+	}
+
+	// Signatures are prefixed with the name and version of the key they were
+	// signed under, such as vault:v1:<base64>.
+	raw := string(v.GetStringBytes("data", "signature"))
+	raw = raw[strings.LastIndex(raw, ":")+1:]
+
+	buf, err := base64.StdEncoding.DecodeString(raw)
+	if err != nil || len(buf) != edwards25519.SizeSignature {
+		return signature, errors.Errorf("vault key %s responded with an invalid signature", s.key)
+	}
+
+	copy(signature[:], buf)
+
+	if !edwards25519.Verify(s.publicKey, msg, signature) {
+		return signature, errors.Errorf("vault key %s responded with a signature that does not verify against its public key", s.key)
+	}
+
+	return signature, nil
+}
+
+func (s *vaultSigner) request(method, endpoint string, body []byte) (*fastjson.Value, error) {
+	req, err := http.NewRequest(method, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create vault request")
+	}
+
+	req.Header.Set("X-Vault-Token", s.token)
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer res.Body.Close()
+
+	buf, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read vault response")
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("vault responded with status %d: %s", res.StatusCode, strings.TrimSpace(string(buf)))
+	}
+
+	v, err := fastjson.ParseBytes(buf)
+	if err != nil {
+		return nil, errors.Wrap(err, "vault responded with invalid json")
+	}
+
+	return v, nil
+}
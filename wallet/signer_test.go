@@ -0,0 +1,132 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wallet
+
+import (
+	"encoding/base64"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/perlin-network/noise/edwards25519"
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fastjson"
+)
+
+func TestLoad(t *testing.T) {
+	assert.NoError(t, os.Setenv(EnvPrivateKey, testPrivateKey))
+
+	signer, err := Load(Env(EnvPrivateKey))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	privateKey, err := Decode([]byte(testPrivateKey))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, privateKey.Public(), signer.PublicKey())
+
+	signature, err := signer.Sign([]byte("message"))
+	assert.NoError(t, err)
+	assert.True(t, edwards25519.Verify(signer.PublicKey(), []byte("message"), signature))
+
+	_, err = Load(Env(EnvPrivateKey))
+	assert.Error(t, err)
+}
+
+func TestVault(t *testing.T) {
+	privateKey, err := Decode([]byte(testPrivateKey))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	publicKey := privateKey.Public()
+
+	var requests int
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "token" {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"errors":["permission denied"]}`))
+			return
+		}
+
+		requests++
+
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/transit/keys/wavelet":
+			_, _ = w.Write([]byte(`{"data":{"type":"ed25519","latest_version":2,"keys":{"2":{"public_key":"` + base64.StdEncoding.EncodeToString(publicKey[:]) + `"}}}}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/transit/keys/aes":
+			_, _ = w.Write([]byte(`{"data":{"type":"aes256-gcm96","latest_version":1,"keys":{"1":1}}}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/transit/sign/wavelet":
+			body, _ := ioutil.ReadAll(r.Body)
+
+			v, err := fastjson.ParseBytes(body)
+			if err != nil || v.GetInt("key_version") != 2 {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+
+			msg, err := base64.StdEncoding.DecodeString(string(v.GetStringBytes("input")))
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+
+			signature := edwards25519.Sign(privateKey, msg)
+			_, _ = w.Write([]byte(`{"data":{"signature":"vault:v2:` + base64.StdEncoding.EncodeToString(signature[:]) + `"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	signer, err := Vault(server.URL+"/v1/transit/keys/wavelet", "token")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, publicKey, signer.PublicKey())
+	assert.Equal(t, 1, requests, "the public key must be fetched upon the signer being created")
+
+	signature, err := signer.Sign([]byte("message"))
+	assert.NoError(t, err)
+	assert.True(t, edwards25519.Verify(publicKey, []byte("message"), signature))
+	assert.Equal(t, 2, requests, "messages must be signed by vault")
+
+	_, err = Vault(server.URL+"/v1/transit/keys/aes", "token")
+	assert.Error(t, err, "the key must be an ed25519 key")
+
+	_, err = Vault(server.URL+"/v1/transit/keys/missing", "token")
+	assert.Error(t, err)
+
+	_, err = Vault(server.URL+"/v1/transit/keys/wavelet", "wrong")
+	assert.Error(t, err)
+
+	_, err = Vault(server.URL+"/v1/secret/data/wavelet#private_key", "token")
+	assert.Error(t, err, "the url must name a transit key")
+
+	_, err = Vault(server.URL+"/v1/transit/keys/wavelet", "")
+	assert.Error(t, err, "a token must be specified")
+}
@@ -0,0 +1,137 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+// Package wallet loads the private keys of wallets from sources which keep them
+// out of shell histories and process listings: files, passphrase-encrypted
+// keystores, and environment variables. Wallets whose keys are held by
+// HashiCorp Vault are instead signed for through Vault, such that their keys
+// are never exported.
+package wallet
+
+import (
+	"bytes"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+
+	"github.com/perlin-network/noise/edwards25519"
+	"github.com/pkg/errors"
+)
+
+// EnvPrivateKey is the environment variable a hex-encoded private key may be
+// specified through.
+const EnvPrivateKey = "WAVELET_PRIVATE_KEY"
+
+// EnvVaultToken is the environment variable the token used to authenticate to
+// HashiCorp Vault is read from.
+const EnvVaultToken = "VAULT_TOKEN"
+
+// Source is where the private key of a wallet is loaded from. Wallets whose
+// keys may not leave where they are held are instead represented by a Signer.
+type Source interface {
+	// PrivateKey loads the private key of the wallet.
+	PrivateKey() (edwards25519.PrivateKey, error)
+
+	// String describes where the private key is loaded from, without revealing
+	// the private key.
+	String() string
+}
+
+// Decode decodes a hex-encoded private key, ignoring any surrounding whitespace.
+func Decode(buf []byte) (edwards25519.PrivateKey, error) {
+	var privateKey edwards25519.PrivateKey
+
+	buf = bytes.TrimSpace(buf)
+
+	if len(buf) != hex.EncodedLen(edwards25519.SizePrivateKey) {
+		return privateKey, errors.Errorf("private key must be %d hex characters long", hex.EncodedLen(edwards25519.SizePrivateKey))
+	}
+
+	if _, err := hex.Decode(privateKey[:], buf); err != nil {
+		return privateKey, errors.Wrap(err, "private key must be hex-encoded")
+	}
+
+	return privateKey, nil
+}
+
+// LooksLikePrivateKey returns true if s is shaped like a hex-encoded private
+// key, such that callers may reject private keys specified where the path to a
+// wallet file was expected.
+func LooksLikePrivateKey(s string) bool {
+	_, err := Decode([]byte(s))
+	return err == nil
+}
+
+type fileSource string
+
+// File loads a hex-encoded private key from the file at path. The error returned
+// should the file not exist satisfies os.IsNotExist once unwrapped with
+// errors.Cause.
+func File(path string) Source {
+	return fileSource(path)
+}
+
+func (s fileSource) PrivateKey() (edwards25519.PrivateKey, error) {
+	buf, err := ioutil.ReadFile(string(s))
+	if err != nil {
+		return edwards25519.PrivateKey{}, errors.Wrapf(err, "failed to read wallet %q", string(s))
+	}
+
+	defer Zero(buf)
+
+	privateKey, err := Decode(buf)
+	if err != nil {
+		return privateKey, errors.Wrapf(err, "wallet %q does not hold a valid private key", string(s))
+	}
+
+	return privateKey, nil
+}
+
+func (s fileSource) String() string {
+	return "file " + string(s)
+}
+
+type envSource string
+
+// Env loads a hex-encoded private key from the environment variable name. The
+// variable is unset once read, such that it is not inherited by any processes
+// spawned afterwards.
+func Env(name string) Source {
+	return envSource(name)
+}
+
+func (s envSource) PrivateKey() (edwards25519.PrivateKey, error) {
+	buf := os.Getenv(string(s))
+	if len(buf) == 0 {
+		return edwards25519.PrivateKey{}, errors.Errorf("environment variable %s is not set", string(s))
+	}
+
+	_ = os.Unsetenv(string(s))
+
+	privateKey, err := Decode([]byte(buf))
+	if err != nil {
+		return privateKey, errors.Wrapf(err, "environment variable %s does not hold a valid private key", string(s))
+	}
+
+	return privateKey, nil
+}
+
+func (s envSource) String() string {
+	return "environment variable " + string(s)
+}
@@ -0,0 +1,70 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wallet
+
+import (
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+const testPrivateKey = "87a6813c3b4cf534b6ae82db9b1409fa7dbd5c13dba5858970b56084c4a930eb400056ee68a7cc2695222df05ea76875bc27ec6e61e8e62317c336157019c405"
+
+func TestFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wallet")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "wallet.txt")
+	assert.NoError(t, ioutil.WriteFile(path, []byte(testPrivateKey+"\n"), 0600))
+
+	privateKey, err := File(path).PrivateKey()
+	assert.NoError(t, err)
+	assert.Equal(t, testPrivateKey, hex.EncodeToString(privateKey[:]))
+
+	_, err = File(filepath.Join(dir, "missing.txt")).PrivateKey()
+	assert.True(t, os.IsNotExist(errors.Cause(err)))
+
+	assert.NoError(t, ioutil.WriteFile(path, []byte("invalid"), 0600))
+
+	_, err = File(path).PrivateKey()
+	assert.Error(t, err)
+}
+
+func TestEnv(t *testing.T) {
+	assert.NoError(t, os.Setenv(EnvPrivateKey, testPrivateKey))
+
+	privateKey, err := Env(EnvPrivateKey).PrivateKey()
+	assert.NoError(t, err)
+	assert.Equal(t, testPrivateKey, hex.EncodeToString(privateKey[:]))
+
+	_, set := os.LookupEnv(EnvPrivateKey)
+	assert.False(t, set, "the private key must be unset once read")
+
+	_, err = Env(EnvPrivateKey).PrivateKey()
+	assert.Error(t, err)
+}
@@ -0,0 +1,133 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// WatchdogConfig describes when a ledger considers consensus to have stalled,
+// and how it attempts to recover from a stall.
+type WatchdogConfig struct {
+	// Recover should no round be finalized, and no sync be triggered, for this
+	// long. The watchdog is disabled if zero.
+	Timeout time.Duration
+
+	// Addresses of peers to dial anew before bootstrapping to peers during a
+	// recovery. Optional.
+	Peers []string
+}
+
+// WithWatchdog has the ledger attempt to recover whenever consensus stalls as
+// described by config.
+func WithWatchdog(config WatchdogConfig) LedgerOption {
+	return func(ledger *Ledger) {
+		ledger.watchdog = config
+	}
+}
+
+// progressed marks that the ledger has either just finalized a round, or just
+// noticed it is out of sync.
+func (l *Ledger) progressed() {
+	atomic.StoreInt64(&l.lastProgress, time.Now().UnixNano())
+}
+
+// Watchdog checks whether consensus has stalled four times every watchdog
+// timeout, and attempts to recover once every watchdog timeout for as long as
+// it stays stalled. It is intended to call Watchdog() in a new goroutine.
+func (l *Ledger) Watchdog() {
+	if l.watchdog.Timeout <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(l.watchdog.Timeout / 4)
+	defer ticker.Stop()
+
+	var lastRecovery time.Time
+
+	for {
+		var now time.Time
+
+		select {
+		case <-l.ctx.Done():
+			return
+		case now = <-ticker.C:
+		}
+
+		if !l.stalled(now) {
+			continue
+		}
+
+		if now.Sub(lastRecovery) < l.watchdog.Timeout {
+			continue
+		}
+
+		lastRecovery = now
+
+		l.recoverFromStall(now)
+	}
+}
+
+// stalled returns whether neither has a round been finalized, nor has a sync
+// been triggered for longer than the watchdog timeout as of now, firing or
+// resolving a critical alert accordingly. A ledger that is halted, or that is
+// amidst syncing, is never considered to have stalled.
+func (l *Ledger) stalled(now time.Time) bool {
+	if l.Halted() || l.Syncing() {
+		return false
+	}
+
+	elapsed := now.Sub(time.Unix(0, atomic.LoadInt64(&l.lastProgress)))
+	stalled := elapsed >= l.watchdog.Timeout
+
+	l.alerts.ConsensusStalled(stalled, elapsed, l.watchdog.Timeout)
+
+	return stalled
+}
+
+// recoverFromStall resets both Snowball instances, has the ledger immediately
+// check whether it is out of sync, and bootstraps to peers anew.
+func (l *Ledger) recoverFromStall(now time.Time) {
+	logger := l.logs.Consensus("recover")
+	logger.Warn().
+		Dur("stalled_for", now.Sub(time.Unix(0, atomic.LoadInt64(&l.lastProgress)))).
+		Msg("Consensus has stalled. Resetting Snowball, checking if we are out of sync, and bootstrapping to peers anew.")
+
+	l.finalizer.Reset()
+	l.syncer.Reset()
+
+	select {
+	case l.syncNow <- struct{}{}:
+	default:
+	}
+
+	for _, addr := range l.watchdog.Peers {
+		if _, err := l.client.Dial(addr); err != nil {
+			logger.Warn().Err(err).Str("address", addr).Msg("Failed to dial peer while recovering from a stall.")
+		}
+	}
+
+	peers := l.client.Bootstrap()
+
+	logger.Info().
+		Int("num_peers", len(peers)).
+		Msg("Bootstrapped to peers anew while recovering from a stall.")
+}
@@ -0,0 +1,65 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"testing"
+	"time"
+
+	"github.com/perlin-network/noise/skademlia"
+	"github.com/perlin-network/wavelet/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatchdogRecoversFromStall(t *testing.T) {
+	keys, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	ledger := NewLedger(store.NewInmem(), skademlia.NewClient(":0", keys), nil, WithWatchdog(WatchdogConfig{Timeout: 100 * time.Millisecond}))
+
+	ledger.finalizer.Prefer(ledger.Rounds().Latest())
+	assert.NotNil(t, ledger.finalizer.Preferred())
+
+	time.Sleep(300 * time.Millisecond)
+
+	assert.Contains(t, ledger.alerts.Firing(), AlertConsensusStalled)
+	assert.Nil(t, ledger.finalizer.Preferred(), "snowball must be reset upon recovering from a stall")
+
+	// The alert resolves once consensus makes progress again.
+
+	ledger.progressed()
+
+	assert.False(t, ledger.stalled(time.Now()))
+	assert.NotContains(t, ledger.alerts.Firing(), AlertConsensusStalled)
+}
+
+func TestWatchdogDisabled(t *testing.T) {
+	keys, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	ledger := NewLedger(store.NewInmem(), skademlia.NewClient(":0", keys), nil)
+
+	ledger.finalizer.Prefer(ledger.Rounds().Latest())
+
+	time.Sleep(100 * time.Millisecond)
+
+	assert.NotContains(t, ledger.alerts.Firing(), AlertConsensusStalled)
+	assert.NotNil(t, ledger.finalizer.Preferred())
+}
@@ -21,13 +21,17 @@ package wctl
 
 import (
 	"encoding/base64"
+	"encoding/binary"
 	"encoding/hex"
 	"fmt"
 	"github.com/fasthttp/websocket"
 	"github.com/perlin-network/noise/edwards25519"
+	"github.com/perlin-network/wavelet/secp256k1"
+	"github.com/perlin-network/wavelet/wallet"
 	"github.com/valyala/fasthttp"
 	"net/http"
 	"net/url"
+	"strings"
 	"time"
 )
 
@@ -36,6 +40,19 @@ type Config struct {
 	APIPort    uint16
 	PrivateKey edwards25519.PrivateKey
 	UseHTTPS   bool
+
+	// Signer, should it be set, signs transactions in place of PrivateKey, such
+	// that the private key need not be held in memory.
+	Signer wallet.Signer
+
+	// Secp256k1Key, should it be set, is the key of a secp256k1 account which
+	// transactions are created on behalf of instead of the account of PrivateKey.
+	Secp256k1Key *secp256k1.PrivateKey
+
+	// AdminToken, should it be set, authorizes requests to admin endpoints, and
+	// is used to obtain tokens for websocket connections should the node require
+	// websockets to be authenticated.
+	AdminToken string
 }
 
 type Client struct {
@@ -52,7 +69,11 @@ func NewClient(config Config) (*Client, error) {
 		Timeout: 5 * time.Second,
 	}
 
-	return &Client{Config: config, PrivateKey: config.PrivateKey, PublicKey: config.PrivateKey.Public(), stdClient: stdClient}, nil
+	if config.Signer == nil {
+		config.Signer = wallet.Key(config.PrivateKey)
+	}
+
+	return &Client{Config: config, PrivateKey: config.PrivateKey, PublicKey: config.Signer.PublicKey(), stdClient: stdClient}, nil
 }
 
 // Request will make a request to a given path, with a given body and return result in out.
@@ -84,6 +105,10 @@ func (c *Client) Request(path string, method string, body MarshalableJSON) ([]by
 	req.Header.SetMethod(method)
 	req.Header.SetContentType("application/json")
 
+	if len(c.Config.AdminToken) > 0 && strings.HasPrefix(path, "/admin/") {
+		req.Header.Set("Authorization", "Bearer "+c.Config.AdminToken)
+	}
+
 	if body != nil {
 		raw, err := body.MarshalJSON()
 		if err != nil {
@@ -101,14 +126,28 @@ func (c *Client) Request(path string, method string, body MarshalableJSON) ([]by
 	}
 
 	if res.StatusCode() != http.StatusOK {
+		if e, ok := parseAPIError(res.StatusCode(), string(res.Header.Peek("X-Request-ID")), res.Body()); ok {
+			return nil, e
+		}
+
 		return nil, fmt.Errorf("unexpected status code for query sent to %q: %d. request body: %q, response body: %q", addr, res.StatusCode(), req.Body(), res.Body())
 	}
 
 	return res.Body(), nil
 }
 
-// EstablishWS will create a websocket connection.
+// EstablishWS will create a websocket connection. Should an admin token be
+// configured, a token authorizing the connection is obtained beforehand.
 func (c *Client) EstablishWS(path string, query url.Values) (*websocket.Conn, error) {
+	if len(c.Config.AdminToken) > 0 && len(query.Get("token")) == 0 {
+		token, err := c.WebsocketToken(strings.TrimPrefix(path, "/poll/"))
+		if err != nil {
+			return nil, err
+		}
+
+		query.Set("token", token)
+	}
+
 	prot := "ws"
 	if c.Config.UseHTTPS {
 		prot = "wss"
@@ -124,6 +163,19 @@ func (c *Client) EstablishWS(path string, query url.Values) (*websocket.Conn, er
 	return conn, err
 }
 
+// WebsocketToken obtains a short-lived, single-use token which authorizes a
+// websocket connection to any of the given sinks, or to every sink should none
+// be given.
+func (c *Client) WebsocketToken(sinks ...string) (string, error) {
+	var res WebsocketTokenResponse
+
+	if err := c.RequestJSON(RouteWSToken, ReqPost, &WebsocketTokenRequest{Sinks: sinks}, &res); err != nil {
+		return "", err
+	}
+
+	return res.Token, nil
+}
+
 func (c *Client) PollLoggerSink(stop <-chan struct{}, sinkRoute string) (<-chan []byte, error) {
 	if stop == nil {
 		stop = make(chan struct{})
@@ -193,6 +245,46 @@ func (c *Client) PollAccounts(stop <-chan struct{}, accountID *string) (<-chan [
 	return evChan, nil
 }
 
+// PollStake streams stake placements and withdrawals, reward withdrawal
+// requests and payouts, and validator rewards. Should accountID not be nil,
+// only events concerning the specified account are streamed.
+func (c *Client) PollStake(stop <-chan struct{}, accountID *string) (<-chan []byte, error) {
+	v := url.Values{}
+	if accountID != nil {
+		v.Set("id", *accountID)
+	}
+
+	if stop == nil {
+		stop = make(chan struct{})
+	}
+
+	ws, err := c.EstablishWS(RouteWSStake, v)
+	if err != nil {
+		return nil, err
+	}
+
+	evChan := make(chan []byte)
+
+	go func() {
+		defer close(evChan)
+
+		for {
+			_, message, err := ws.ReadMessage()
+			if err != nil {
+				return
+			}
+
+			select {
+			case <-stop:
+				return
+			case evChan <- message:
+			}
+		}
+	}()
+
+	return evChan, nil
+}
+
 func (c *Client) PollContracts(stop <-chan struct{}, contractID *string) (<-chan []byte, error) {
 	v := url.Values{}
 	if contractID != nil {
@@ -321,6 +413,15 @@ func (c *Client) GetContractPages(contractID string, index *uint64) (string, err
 	return base64.StdEncoding.EncodeToString(res), err
 }
 
+// ExportGraph exports the graph of transactions held by the node in the
+// specified format, which is either "dot" or "json". Should depths be non-zero,
+// only transactions within the last depths depths of the graph are exported.
+func (c *Client) ExportGraph(format string, depths uint64) ([]byte, error) {
+	path := fmt.Sprintf("%s?format=%s&depths=%d", RouteExportGraph, url.QueryEscape(format), depths)
+
+	return c.Request(path, ReqGet, nil)
+}
+
 func (c *Client) ListTransactions(senderID *string, creatorID *string, offset *uint64, limit *uint64) ([]Transaction, error) {
 	path := fmt.Sprintf("%s?", RouteTxList)
 	if senderID != nil {
@@ -343,6 +444,28 @@ func (c *Client) ListTransactions(senderID *string, creatorID *string, offset *u
 
 }
 
+// ListTransactionsAfter lists a page of transactions that come after the position
+// marked by cursor. An empty cursor lists from the most recent transaction. The
+// page returned holds the cursor to the next page, which is empty should there be
+// no transactions left to list.
+func (c *Client) ListTransactionsAfter(senderID *string, creatorID *string, cursor string, limit *uint64) (TransactionPage, error) {
+	path := fmt.Sprintf("%s?cursor=%s&", RouteTxList, url.QueryEscape(cursor))
+	if senderID != nil {
+		path = fmt.Sprintf("%ssender=%s&", path, *senderID)
+	}
+	if creatorID != nil {
+		path = fmt.Sprintf("%screator=%s&", path, *creatorID)
+	}
+	if limit != nil {
+		path = fmt.Sprintf("%slimit=%d&", path, *limit)
+	}
+
+	var res TransactionPage
+
+	err := c.RequestJSON(path, ReqGet, nil, &res)
+	return res, err
+}
+
 func (c *Client) GetTransaction(txID string) (Transaction, error) {
 	path := fmt.Sprintf("%s/%s", RouteTxList, txID)
 
@@ -351,21 +474,81 @@ func (c *Client) GetTransaction(txID string) (Transaction, error) {
 	return res, err
 }
 
+// GetTransactionStatus returns how far along the transaction with ID txID is
+// in being finalized.
+func (c *Client) GetTransactionStatus(txID string) (TransactionStatus, error) {
+	path := fmt.Sprintf("%s/%s/status", RouteTxList, txID)
+
+	var res TransactionStatus
+	err := c.RequestJSON(path, ReqGet, nil, &res)
+	return res, err
+}
+
 func (c *Client) SendTransaction(tag byte, payload []byte) (SendTransactionResponse, error) {
 	var res SendTransactionResponse
 
-	var nonce [8]byte // TODO(kenta): nonce
+	req, err := c.SignTransaction(tag, payload)
+	if err != nil {
+		return res, err
+	}
+
+	err = c.RequestJSON(RouteTxSend, ReqPost, &req, &res)
+
+	return res, err
+}
+
+// SignTransaction signs a transaction with the given tag and payload using the
+// clients private key, so that it may be sent later, possibly in a batch. The
+// transaction is unsequenced.
+func (c *Client) SignTransaction(tag byte, payload []byte) (SendTransactionRequest, error) {
+	return c.SignTransactionWithNonce(0, tag, payload)
+}
+
+// SignTransactionWithNonce signs a transaction with the given tag and payload
+// under nonce using the clients private key. Transactions signed under the same
+// non-zero nonce conflict with one another.
+func (c *Client) SignTransactionWithNonce(nonce uint64, tag byte, payload []byte) (SendTransactionRequest, error) {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], nonce)
+
+	msg := append(buf[:], append([]byte{tag}, payload...)...)
+
+	if c.Secp256k1Key != nil {
+		id := c.Secp256k1Key.PublicKey().ID()
+		signature := c.Secp256k1Key.Sign(msg)
+
+		return SendTransactionRequest{
+			Sender:    hex.EncodeToString(id[:]),
+			Tag:       tag,
+			Payload:   hex.EncodeToString(payload),
+			Signature: hex.EncodeToString(signature[:]),
+			Scheme:    "secp256k1",
+			Nonce:     nonce,
+		}, nil
+	}
 
-	signature := edwards25519.Sign(c.PrivateKey, append(nonce[:], append([]byte{tag}, payload...)...))
+	signature, err := c.Signer.Sign(msg)
+	if err != nil {
+		return SendTransactionRequest{}, fmt.Errorf("failed to sign transaction: %v", err)
+	}
 
-	req := SendTransactionRequest{
+	return SendTransactionRequest{
 		Sender:    hex.EncodeToString(c.PublicKey[:]),
 		Tag:       tag,
 		Payload:   hex.EncodeToString(payload),
 		Signature: hex.EncodeToString(signature[:]),
-	}
+		Nonce:     nonce,
+	}, nil
+}
+
+// SendBatch sends several signed transactions in a single request. Each
+// transaction is accepted or rejected independently of the others.
+func (c *Client) SendBatch(txs []SendTransactionRequest) (SendBatchResponse, error) {
+	var res SendBatchResponse
+
+	req := SendBatchRequest{Transactions: txs}
 
-	err := c.RequestJSON(RouteTxSend, ReqPost, &req, &res)
+	err := c.RequestJSON(RouteTxBatch, ReqPost, &req, &res)
 
 	return res, err
 }
@@ -20,7 +20,10 @@
 package wctl
 
 import (
+	"fmt"
 	"github.com/valyala/fastjson"
+	"strconv"
+	"time"
 )
 
 const (
@@ -29,14 +32,20 @@ const (
 	RouteContract = "/contract"
 	RouteTxList   = "/tx"
 	RouteTxSend   = "/tx/send"
+	RouteTxBatch  = "/tx/batch"
+	RouteWSToken  = "/admin/ws/token"
+
+	RouteExportGraph = "/export/graph"
 
 	RouteWSBroadcaster  = "/poll/broadcaster"
 	RouteWSConsensus    = "/poll/consensus"
+	RouteWSRounds       = "/poll/rounds"
 	RouteWSStake        = "/poll/stake"
 	RouteWSAccounts     = "/poll/accounts"
 	RouteWSContracts    = "/poll/contract"
 	RouteWSTransactions = "/poll/tx"
 	RouteWSMetrics      = "/poll/metrics"
+	RouteWSQueues       = "/poll/queues"
 
 	ReqPost = "POST"
 	ReqGet  = "GET"
@@ -44,12 +53,14 @@ const (
 
 var (
 	_ UnmarshalableJSON = (*SendTransactionResponse)(nil)
+	_ UnmarshalableJSON = (*SendBatchResponse)(nil)
 	_ UnmarshalableJSON = (*LedgerStatusResponse)(nil)
 	_ UnmarshalableJSON = (*Transaction)(nil)
 	_ UnmarshalableJSON = (*TransactionList)(nil)
 	_ UnmarshalableJSON = (*Account)(nil)
 
 	_ MarshalableJSON = (*SendTransactionRequest)(nil)
+	_ MarshalableJSON = (*SendBatchRequest)(nil)
 )
 
 type UnmarshalableJSON interface {
@@ -65,6 +76,8 @@ type SendTransactionRequest struct {
 	Tag       byte   `json:"tag"`
 	Payload   string `json:"payload"`
 	Signature string `json:"signature"`
+	Scheme    string `json:"scheme,omitempty"`
+	Nonce     uint64 `json:"nonce,omitempty"`
 }
 
 func (s *SendTransactionRequest) MarshalJSON() ([]byte, error) {
@@ -76,6 +89,14 @@ func (s *SendTransactionRequest) MarshalJSON() ([]byte, error) {
 	o.Set("payload", arena.NewString(s.Payload))
 	o.Set("signature", arena.NewString(s.Signature))
 
+	if len(s.Scheme) > 0 {
+		o.Set("scheme", arena.NewString(s.Scheme))
+	}
+
+	if s.Nonce > 0 {
+		o.Set("nonce", arena.NewNumberString(strconv.FormatUint(s.Nonce, 10)))
+	}
+
 	return o.MarshalTo(nil), nil
 }
 
@@ -105,14 +126,200 @@ func (s *SendTransactionResponse) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
+type WebsocketTokenRequest struct {
+	Sinks []string `json:"sinks"`
+}
+
+func (s *WebsocketTokenRequest) MarshalJSON() ([]byte, error) {
+	var arena fastjson.Arena
+
+	o := arena.NewObject()
+	sinks := arena.NewArray()
+
+	for i, sink := range s.Sinks {
+		sinks.SetArrayItem(i, arena.NewString(sink))
+	}
+
+	o.Set("sinks", sinks)
+
+	return o.MarshalTo(nil), nil
+}
+
+type WebsocketTokenResponse struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func (s *WebsocketTokenResponse) UnmarshalJSON(b []byte) error {
+	var parser fastjson.Parser
+
+	v, err := parser.ParseBytes(b)
+	if err != nil {
+		return err
+	}
+
+	s.Token = string(v.GetStringBytes("token"))
+
+	if raw := v.GetStringBytes("expires_at"); len(raw) > 0 {
+		if s.ExpiresAt, err = time.Parse(time.RFC3339, string(raw)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type SendBatchRequest struct {
+	Transactions []SendTransactionRequest `json:"transactions"`
+}
+
+func (s *SendBatchRequest) MarshalJSON() ([]byte, error) {
+	var arena fastjson.Arena
+	o := arena.NewObject()
+
+	txs := arena.NewArray()
+
+	for i, tx := range s.Transactions {
+		item := arena.NewObject()
+
+		item.Set("sender", arena.NewString(tx.Sender))
+		item.Set("tag", arena.NewNumberInt(int(tx.Tag)))
+		item.Set("payload", arena.NewString(tx.Payload))
+		item.Set("signature", arena.NewString(tx.Signature))
+
+		txs.SetArrayItem(i, item)
+	}
+
+	o.Set("transactions", txs)
+
+	return o.MarshalTo(nil), nil
+}
+
+// APIError is an error reported by the API of a node. Code identifies the
+// kind of failure, and Retryable denotes whether the request may succeed
+// should it be retried as-is later on. RequestID is the ID the node assigned
+// to the failed request, which the node tags its logs about the request with.
+type APIError struct {
+	StatusCode int               `json:"-"`
+	RequestID  string            `json:"-"`
+	Code       string            `json:"code"`
+	Message    string            `json:"message"`
+	Details    map[string]string `json:"details,omitempty"`
+	Retryable  bool              `json:"retryable"`
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("%s: %s", e.Code, e.Message)
+
+	if e.StatusCode != 0 {
+		msg = fmt.Sprintf("%s (status %d): %s", e.Code, e.StatusCode, e.Message)
+	}
+
+	if len(e.RequestID) > 0 {
+		msg += fmt.Sprintf(" [request ID %s]", e.RequestID)
+	}
+
+	return msg
+}
+
+func (e *APIError) unmarshal(v *fastjson.Value) {
+	e.Code = string(v.GetStringBytes("code"))
+	e.Message = string(v.GetStringBytes("message"))
+	e.Retryable = v.GetBool("retryable")
+
+	if details := v.GetObject("details"); details != nil {
+		e.Details = make(map[string]string)
+
+		details.Visit(func(key []byte, v *fastjson.Value) {
+			e.Details[string(key)] = string(v.GetStringBytes())
+		})
+	}
+}
+
+// parseAPIError parses the error envelope rendered by the API of a node from
+// body. It returns false should body not be an error envelope.
+func parseAPIError(statusCode int, requestID string, body []byte) (*APIError, bool) {
+	var parser fastjson.Parser
+
+	v, err := parser.ParseBytes(body)
+	if err != nil {
+		return nil, false
+	}
+
+	o := v.Get("error")
+	if o == nil || o.Type() != fastjson.TypeObject {
+		return nil, false
+	}
+
+	e := &APIError{StatusCode: statusCode, RequestID: requestID}
+	e.unmarshal(o)
+
+	return e, true
+}
+
+type SendBatchResult struct {
+	SendTransactionResponse
+
+	Accepted bool      `json:"accepted"`
+	Error    *APIError `json:"error"`
+}
+
+type SendBatchResponse struct {
+	Accepted int               `json:"accepted"`
+	Rejected int               `json:"rejected"`
+	Results  []SendBatchResult `json:"results"`
+}
+
+func (s *SendBatchResponse) UnmarshalJSON(b []byte) error {
+	var parser fastjson.Parser
+
+	v, err := parser.ParseBytes(b)
+	if err != nil {
+		return err
+	}
+
+	s.Accepted = v.GetInt("accepted")
+	s.Rejected = v.GetInt("rejected")
+
+	for _, item := range v.GetArray("results") {
+		var result SendBatchResult
+
+		result.Accepted = item.GetBool("accepted")
+		if e := item.Get("error"); e != nil {
+			result.Error = new(APIError)
+			result.Error.unmarshal(e)
+		}
+
+		if result.Accepted {
+			if err := result.SendTransactionResponse.UnmarshalJSON(item.MarshalTo(nil)); err != nil {
+				return err
+			}
+		}
+
+		s.Results = append(s.Results, result)
+	}
+
+	return nil
+}
+
 type LedgerStatusResponse struct {
 	PublicKey     string   `json:"public_key"`
 	HostAddress   string   `json:"address"`
 	PeerAddresses []string `json:"peers"`
 
 	RootID     string `json:"root_id"`
-	RoundID    uint64 `json:"round_id"`
+	RoundID    uint64 `json:"view_id"`
 	Difficulty uint64 `json:"difficulty"`
+
+	Height       uint64 `json:"height"`
+	NumTx        uint64 `json:"num_tx"`
+	NumMissingTx uint64 `json:"num_missing_tx"`
+	NumTxInStore uint64 `json:"num_tx_in_store"`
+
+	// How long the longest missing transaction has been missing for.
+	MissingOldestAge time.Duration `json:"missing_oldest_age"`
+
+	Syncing bool `json:"syncing"`
 }
 
 func (l *LedgerStatusResponse) UnmarshalJSON(b []byte) error {
@@ -132,9 +339,18 @@ func (l *LedgerStatusResponse) UnmarshalJSON(b []byte) error {
 	}
 
 	l.RootID = string(v.GetStringBytes("root_id"))
-	l.RoundID = v.GetUint64("round_id")
+	l.RoundID = v.GetUint64("view_id")
 	l.Difficulty = v.GetUint64("difficulty")
 
+	l.Height = v.GetUint64("height")
+	l.NumTx = v.GetUint64("num_tx")
+	l.NumMissingTx = v.GetUint64("num_missing_tx")
+	l.NumTxInStore = v.GetUint64("num_tx_in_store")
+
+	l.MissingOldestAge = time.Duration(v.GetInt64("missing", "oldest_age_ms")) * time.Millisecond
+
+	l.Syncing = v.GetBool("sync", "syncing")
+
 	return nil
 }
 
@@ -155,8 +371,10 @@ type Transaction struct {
 
 	SenderSignature  string `json:"sender_signature"`
 	CreatorSignature string `json:"creator_signature"`
+	CreatorScheme    string `json:"creator_scheme"`
 
-	Depth uint64 `json:"depth"`
+	Depth  uint64 `json:"depth"`
+	Status string `json:"status"`
 }
 
 func (t *Transaction) UnmarshalJSON(b []byte) error {
@@ -188,7 +406,35 @@ func (t *Transaction) ParseJSON(v *fastjson.Value) {
 	t.AccountsMerkleRoot = string(v.GetStringBytes("accounts_root"))
 	t.SenderSignature = string(v.GetStringBytes("sender_signature"))
 	t.CreatorSignature = string(v.GetStringBytes("creator_signature"))
+	t.CreatorScheme = string(v.GetStringBytes("creator_scheme"))
 	t.Depth = v.GetUint64("depth")
+	t.Status = string(v.GetStringBytes("status"))
+}
+
+// TransactionStatus describes how far along a transaction is in being
+// finalized. Round is only set for finalized transactions, and Error only for
+// rejected transactions.
+type TransactionStatus struct {
+	ID     string `json:"id"`
+	Status string `json:"status"`
+	Round  uint64 `json:"round,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+func (t *TransactionStatus) UnmarshalJSON(b []byte) error {
+	var parser fastjson.Parser
+
+	v, err := parser.ParseBytes(b)
+	if err != nil {
+		return err
+	}
+
+	t.ID = string(v.GetStringBytes("id"))
+	t.Status = string(v.GetStringBytes("status"))
+	t.Round = v.GetUint64("round")
+	t.Error = string(v.GetStringBytes("error"))
+
+	return nil
 }
 
 type TransactionList []Transaction
@@ -221,10 +467,42 @@ func (t *TransactionList) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
+type TransactionPage struct {
+	Transactions TransactionList `json:"transactions"`
+	NextCursor   string          `json:"next_cursor,omitempty"`
+}
+
+func (t *TransactionPage) UnmarshalJSON(b []byte) error {
+	var parser fastjson.Parser
+
+	v, err := parser.ParseBytes(b)
+	if err != nil {
+		return err
+	}
+
+	t.Transactions = nil
+
+	for _, item := range v.GetArray("transactions") {
+		var tx Transaction
+		tx.ParseJSON(item)
+
+		t.Transactions = append(t.Transactions, tx)
+	}
+
+	t.NextCursor = string(v.GetStringBytes("next_cursor"))
+
+	return nil
+}
+
 type Account struct {
 	PublicKey string `json:"public_key"`
+	Address   string `json:"account_address"`
 	Balance   uint64 `json:"balance"`
 	Stake     uint64 `json:"stake"`
+	Reward    uint64 `json:"reward"`
+	Nonce     uint64 `json:"nonce"`
+
+	SignatureScheme string `json:"signature_scheme"`
 
 	IsContract bool   `json:"is_contract"`
 	NumPages   uint64 `json:"num_mem_pages,omitempty"`
@@ -239,8 +517,12 @@ func (a *Account) UnmarshalJSON(b []byte) error {
 	}
 
 	a.PublicKey = string(v.GetStringBytes("public_key"))
+	a.Address = string(v.GetStringBytes("account_address"))
 	a.Balance = v.GetUint64("balance")
 	a.Stake = v.GetUint64("stake")
+	a.Reward = v.GetUint64("reward")
+	a.Nonce = v.GetUint64("nonce")
+	a.SignatureScheme = string(v.GetStringBytes("signature_scheme"))
 	a.IsContract = v.GetBool("is_contract")
 	a.NumPages = v.GetUint64("num_mem_pages")
 
@@ -0,0 +1,132 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"github.com/perlin-network/wavelet/avl"
+	"github.com/pkg/errors"
+)
+
+// Weighting decides which accounts may vote in consensus, and how much their
+// votes count for.
+type Weighting string
+
+const (
+	// Every account may vote, with votes weighed by the voters stake.
+	WeightByStake Weighting = "stake"
+
+	// Only accounts on the on-chain allowlist of validators may vote, with all
+	// of their votes weighed equally. Suited for private networks that do not
+	// want token-weighted security.
+	WeightByAuthority Weighting = "authority"
+)
+
+func ParseWeighting(weighting string) (Weighting, error) {
+	switch w := Weighting(weighting); w {
+	case WeightByStake, WeightByAuthority:
+		return w, nil
+	}
+
+	return "", errors.Errorf("unknown vote weighting %q", weighting)
+}
+
+// Weigh returns the weight of the vote of voter given the state in snapshot, and
+// the minimum stake every voter is considered to have. It returns false should
+// voter not be eligible to vote.
+func (w Weighting) Weigh(snapshot *avl.Tree, voter AccountID, minimumStake uint64) (uint64, bool) {
+	if w == WeightByAuthority {
+		if !ReadAccountValidator(snapshot, voter) {
+			return 0, false
+		}
+
+		return 1, true
+	}
+
+	stake, _ := ReadAccountStake(snapshot, voter)
+
+	return VotingStake(stake, minimumStake), true
+}
+
+// WeighAccount returns the weight of the vote of voter given the latest state
+// committed to accounts. The stakes of voters are read through the accounts
+// cache, such that tallying votes does not hit the accounts tree every time.
+func (w Weighting) WeighAccount(accounts *Accounts, voter AccountID, minimumStake uint64) (uint64, bool) {
+	if w == WeightByAuthority {
+		return w.Weigh(accounts.Snapshot(), voter, minimumStake)
+	}
+
+	stake, _ := accounts.ReadStake(voter)
+
+	return VotingStake(stake, minimumStake), true
+}
+
+// TotalWeight returns the sum of the weights of all accounts eligible to vote
+// given the state in snapshot, and the minimum stake every voter is considered
+// to have.
+func (w Weighting) TotalWeight(snapshot *avl.Tree, minimumStake uint64) uint64 {
+	var total uint64
+
+	if w == WeightByAuthority {
+		IterateAccountValidators(snapshot, func(AccountID) {
+			total++
+		})
+
+		return total
+	}
+
+	IterateAccountStakes(snapshot, func(id AccountID, stake uint64) {
+		if stake > 0 {
+			total += VotingStake(stake, minimumStake)
+		}
+	})
+
+	return total
+}
+
+func ReadAccountValidator(tree *avl.Tree, id AccountID) bool {
+	buf, exists := readUnderAccounts(tree, id, keyAccountValidator[:])
+	return exists && len(buf) == 1 && buf[0] == 1
+}
+
+func WriteAccountValidator(tree *avl.Tree, id AccountID, validator bool) {
+	if !validator {
+		deleteUnderAccounts(tree, id, keyAccountValidator[:])
+		return
+	}
+
+	writeUnderAccounts(tree, id, keyAccountValidator[:], []byte{1})
+}
+
+// IterateAccountValidators calls fn with the ID of every account on the allowlist
+// of validators.
+func IterateAccountValidators(tree *avl.Tree, fn func(id AccountID)) {
+	prefix := append(keyAccounts[:], keyAccountValidator[:]...)
+
+	tree.IteratePrefix(prefix, func(key, value []byte) {
+		if len(key) != len(prefix)+SizeAccountID || len(value) != 1 || value[0] != 1 {
+			return
+		}
+
+		var id AccountID
+		copy(id[:], key[len(prefix):])
+
+		fn(id)
+	})
+}
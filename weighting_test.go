@@ -0,0 +1,127 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/perlin-network/noise/skademlia"
+	"github.com/perlin-network/wavelet/store"
+	"github.com/perlin-network/wavelet/sys"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseWeighting(t *testing.T) {
+	weighting, err := ParseWeighting("authority")
+	assert.NoError(t, err)
+	assert.Equal(t, WeightByAuthority, weighting)
+
+	_, err = ParseWeighting("reputation")
+	assert.Error(t, err)
+}
+
+func TestWeighByAuthority(t *testing.T) {
+	accounts := NewAccounts(store.NewInmem())
+
+	a, b, c := AccountID{1}, AccountID{2}, AccountID{3}
+
+	WriteAccountValidator(accounts.tree, a, true)
+	WriteAccountValidator(accounts.tree, b, true)
+	WriteAccountStake(accounts.tree, c, sys.MinimumStake*10)
+	assert.NoError(t, accounts.Commit(nil))
+
+	snapshot := accounts.Snapshot()
+
+	weight, eligible := WeightByAuthority.Weigh(snapshot, a, sys.MinimumStake)
+	assert.True(t, eligible)
+	assert.EqualValues(t, 1, weight)
+
+	_, eligible = WeightByAuthority.Weigh(snapshot, c, sys.MinimumStake)
+	assert.False(t, eligible, "stake does not make an account eligible to vote")
+
+	assert.EqualValues(t, 2, WeightByAuthority.TotalWeight(snapshot, sys.MinimumStake))
+
+	weight, eligible = WeightByStake.Weigh(snapshot, c, sys.MinimumStake)
+	assert.True(t, eligible)
+	assert.EqualValues(t, sys.MinimumStake*10, weight)
+
+	WriteAccountValidator(accounts.tree, b, false)
+	assert.NoError(t, accounts.Commit(nil))
+
+	assert.EqualValues(t, 1, WeightByAuthority.TotalWeight(accounts.Snapshot(), sys.MinimumStake))
+}
+
+func TestCollectVotesByAuthority(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	metrics := NewMetrics(ctx)
+	snowball := NewSnowball(WithBeta(10))
+
+	keys, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	start := AttachSenderToTransaction(keys, NewTransaction(keys, sys.TagTransfer, nil))
+	end := AttachSenderToTransaction(keys, NewTransaction(keys, sys.TagStake, nil))
+
+	round := NewRound(1, ZeroMerkleNodeID, 1, start, end)
+
+	accounts := NewAccounts(store.NewInmem())
+
+	voters := make([]*skademlia.ID, sys.SnowballK+1)
+
+	for i := range voters {
+		keys, err := skademlia.NewKeys(1, 1)
+		assert.NoError(t, err)
+
+		voters[i] = keys.ID("127.0.0.1")
+
+		// The last voter is not on the allowlist of validators.
+
+		if i < sys.SnowballK {
+			WriteAccountValidator(accounts.tree, voters[i].PublicKey(), true)
+		}
+	}
+
+	assert.NoError(t, accounts.Commit(nil))
+
+	voteChan := make(chan vote)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+
+	go CollectVotes(accounts, WeightByAuthority, func() uint64 { return sys.MinimumStake }, snowball, sys.SnowballK, sys.SnowballAlpha, voteChan, &wg, metrics)
+
+	voteChan <- vote{voter: voters[sys.SnowballK], preferred: &round, viewID: 1}
+
+	for i := 0; i < sys.SnowballK; i++ {
+		voteChan <- vote{voter: voters[i], preferred: &round, viewID: 1}
+	}
+
+	close(voteChan)
+	wg.Wait()
+
+	assert.EqualValues(t, 1, metrics.rejectedVotes.Count())
+	assert.Equal(t, 1, snowball.counts[round.ID])
+}